@@ -0,0 +1,212 @@
+package aitelemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultFileSinkMaxSizeInBytes = 10 << 20
+	defaultFileSinkMaxBackups     = 3
+
+	recordTypeLog    = "log"
+	recordTypeEvent  = "event"
+	recordTypeMetric = "metric"
+)
+
+// fileRecord is a line of the file written by the file sink.
+type fileRecord struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`
+	App        string            `json:"app"`
+	Version    string            `json:"version"`
+	OS         string            `json:"os"`
+	Name       string            `json:"name,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Context    string            `json:"context,omitempty"`
+	Value      *float64          `json:"value,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// fileTelemetry writes the telemetry as JSON lines to a local file, which it rotates by size.
+type fileTelemetry struct {
+	appName    string
+	appVersion string
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	closed     bool
+	mutex      sync.Mutex
+}
+
+// NewFileTelemetry creates a telemetry handle which writes to the file of the sink config.
+func NewFileTelemetry(aiConfig AIConfig, sink SinkConfig) (TelemetryHandle, error) {
+	if sink.Path == "" {
+		return nil, errors.New("file sink path is empty")
+	}
+
+	ft := &fileTelemetry{
+		appName:    aiConfig.AppName,
+		appVersion: aiConfig.AppVersion,
+		path:       sink.Path,
+		maxSize:    sink.MaxSizeInBytes,
+		maxBackups: sink.MaxBackups,
+	}
+
+	if ft.maxSize == 0 {
+		ft.maxSize = defaultFileSinkMaxSizeInBytes
+	}
+
+	if ft.maxBackups == 0 {
+		ft.maxBackups = defaultFileSinkMaxBackups
+	}
+
+	if err := ft.open(); err != nil {
+		return nil, err
+	}
+
+	return ft, nil
+}
+
+func (ft *fileTelemetry) open() error {
+	f, err := os.OpenFile(ft.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gomnd // rw-r--r--
+	if err != nil {
+		return errors.Wrap(err, "failed to open telemetry file")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to stat telemetry file")
+	}
+
+	ft.file = f
+	ft.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups, dropping the oldest, and moves the current file to the first backup. If the current
+// file cannot be moved, it is reopened and kept until the next rotation succeeds.
+func (ft *fileTelemetry) rotate() error {
+	ft.file.Close()
+	ft.file = nil
+
+	for i := ft.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", ft.path, i), fmt.Sprintf("%s.%d", ft.path, i+1))
+	}
+
+	if err := os.Rename(ft.path, ft.path+".1"); err != nil {
+		if openErr := ft.open(); openErr != nil {
+			return openErr
+		}
+		return errors.Wrap(err, "failed to rotate telemetry file")
+	}
+
+	return ft.open()
+}
+
+func (ft *fileTelemetry) write(record fileRecord) {
+	record.Time = time.Now().UTC()
+	record.App = ft.appName
+	record.Version = ft.appVersion
+	record.OS = runtime.GOOS
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("[Telemetry] Failed to marshal telemetry record: %v", err)
+		return
+	}
+	b = append(b, '\n')
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	if ft.closed {
+		return
+	}
+
+	// The file is reopened after a failure to open it on rotation.
+	if ft.file == nil {
+		if err := ft.open(); err != nil {
+			log.Printf("[Telemetry] %v", err)
+			return
+		}
+	}
+
+	if ft.size > 0 && ft.size+int64(len(b)) > ft.maxSize {
+		if err := ft.rotate(); err != nil {
+			log.Printf("[Telemetry] %v", err)
+			if ft.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := ft.file.Write(b)
+	ft.size += int64(n)
+	if err != nil {
+		log.Printf("[Telemetry] Failed to write telemetry record: %v", err)
+	}
+}
+
+// TrackLog writes the report to the file.
+func (ft *fileTelemetry) TrackLog(report Report) {
+	ft.write(fileRecord{
+		Type:       recordTypeLog,
+		Message:    report.Message,
+		Context:    report.Context,
+		Properties: report.CustomDimensions,
+	})
+}
+
+// TrackEvent writes the event to the file.
+func (ft *fileTelemetry) TrackEvent(event Event) {
+	ft.write(fileRecord{
+		Type:       recordTypeEvent,
+		Name:       event.EventName,
+		Context:    event.ResourceID,
+		Properties: event.Properties,
+	})
+}
+
+// TrackMetric writes the metric to the file.
+func (ft *fileTelemetry) TrackMetric(metric Metric) {
+	value := metric.Value
+	ft.write(fileRecord{
+		Type:       recordTypeMetric,
+		Name:       metric.Name,
+		Value:      &value,
+		Properties: metric.CustomDimensions,
+	})
+}
+
+// Close closes the file.
+func (ft *fileTelemetry) Close(int) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	ft.closed = true
+	if ft.file != nil {
+		ft.file.Close()
+		ft.file = nil
+	}
+}
+
+// Flush commits the file to disk.
+func (ft *fileTelemetry) Flush() {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	if ft.file != nil {
+		_ = ft.file.Sync()
+	}
+}
@@ -0,0 +1,112 @@
+package aitelemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	otlpInstrumentationName = "github.com/Azure/azure-container-networking/aitelemetry"
+	otlpLogSpanName         = "log"
+	otlpContextKey          = "context"
+	otlpResourceIDKey       = "resource.id"
+	otlpMetricValueKey      = "metric.value"
+)
+
+// otlpTelemetry exports the telemetry to an OTLP collector. The OpenTelemetry SDK used here has no stable log
+// or metric export, so each log, event and metric is exported as a span of its own, carrying the
+// dimensions as attributes.
+type otlpTelemetry struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewOTLPTelemetry creates a telemetry handle which exports to the OTLP collector of the sink config.
+func NewOTLPTelemetry(aiConfig AIConfig, sink SinkConfig) (TelemetryHandle, error) {
+	if sink.Endpoint == "" {
+		return nil, errors.New("otlp sink endpoint is empty")
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(sink.Endpoint)}
+	if sink.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create OTLP exporter")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(aiConfig.AppName),
+			semconv.ServiceVersionKey.String(aiConfig.AppVersion),
+		)),
+	)
+
+	return &otlpTelemetry{
+		provider: provider,
+		tracer:   provider.Tracer(otlpInstrumentationName),
+	}, nil
+}
+
+func attributes(properties map[string]string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(properties))
+	for key, value := range properties {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+	return attrs
+}
+
+func (ot *otlpTelemetry) export(name string, attrs []attribute.KeyValue) trace.Span {
+	_, span := ot.tracer.Start(context.Background(), name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// TrackLog exports the report as a span with the message as an event.
+func (ot *otlpTelemetry) TrackLog(report Report) {
+	span := ot.export(otlpLogSpanName, append(attributes(report.CustomDimensions), attribute.String(otlpContextKey, report.Context)))
+	span.AddEvent(report.Message)
+	span.End()
+}
+
+// TrackEvent exports the event as a span named after it.
+func (ot *otlpTelemetry) TrackEvent(event Event) {
+	ot.export(event.EventName, append(attributes(event.Properties), attribute.String(otlpResourceIDKey, event.ResourceID))).End()
+}
+
+// TrackMetric exports the metric as a span named after it, with the value as an attribute.
+func (ot *otlpTelemetry) TrackMetric(metric Metric) {
+	ot.export(metric.Name, append(attributes(metric.CustomDimensions), attribute.Float64(otlpMetricValueKey, metric.Value))).End()
+}
+
+// Close exports the pending telemetry, waiting for up to timeout seconds, and stops the export.
+func (ot *otlpTelemetry) Close(timeout int) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	if err := ot.provider.Shutdown(ctx); err != nil {
+		log.Printf("[Telemetry] Failed to shut down OTLP export: %v", err)
+	}
+}
+
+// Flush exports the pending telemetry.
+func (ot *otlpTelemetry) Flush() {
+	if err := ot.provider.ForceFlush(context.Background()); err != nil {
+		log.Printf("[Telemetry] Failed to flush OTLP export: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package aitelemetry
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/pkg/errors"
+)
+
+// Telemetry sink types
+const (
+	SinkAppInsights = "appinsights"
+	SinkFile        = "file"
+	SinkOTLP        = "otlp"
+)
+
+// SinkConfig selects a telemetry sink and configures it. Only the fields of its type are used.
+type SinkConfig struct {
+	// Type is one of appinsights, file or otlp.
+	Type string `json:"type"`
+	// Path of the JSONL file written by the file sink.
+	Path string `json:"path,omitempty"`
+	// MaxSizeInBytes of the file beyond which the file sink rotates it.
+	MaxSizeInBytes int64 `json:"maxSizeInBytes,omitempty"`
+	// MaxBackups is the number of rotated files kept by the file sink.
+	MaxBackups int `json:"maxBackups,omitempty"`
+	// Endpoint is the host:port of the OTLP/HTTP collector of the otlp sink.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Insecure disables TLS to the collector of the otlp sink.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// NewTelemetry creates a telemetry handle which fans out to the configured sinks. Without sinks, it sends
+// to appinsights as NewAITelemetry does. The sinks which fail to be created are skipped, and an error is
+// returned only if none could be.
+func NewTelemetry(azEnvUrl, id string, aiConfig AIConfig, sinks []SinkConfig) (TelemetryHandle, error) {
+	if len(sinks) == 0 {
+		return NewAITelemetry(azEnvUrl, id, aiConfig)
+	}
+
+	var (
+		handles fanOut
+		lastErr error
+	)
+
+	for _, sink := range sinks {
+		th, err := newSink(azEnvUrl, id, aiConfig, sink)
+		if err != nil {
+			log.Printf("[Telemetry] Failed to create %s sink: %v", sink.Type, err)
+			lastErr = err
+			continue
+		}
+
+		handles = append(handles, th)
+	}
+
+	if len(handles) == 0 {
+		return nil, errors.Wrap(lastErr, "failed to create any telemetry sink")
+	}
+
+	if len(handles) == 1 {
+		return handles[0], nil
+	}
+
+	return handles, nil
+}
+
+func newSink(azEnvUrl, id string, aiConfig AIConfig, sink SinkConfig) (TelemetryHandle, error) {
+	switch sink.Type {
+	case SinkAppInsights:
+		return NewAITelemetry(azEnvUrl, id, aiConfig)
+	case SinkFile:
+		return NewFileTelemetry(aiConfig, sink)
+	case SinkOTLP:
+		return NewOTLPTelemetry(aiConfig, sink)
+	default:
+		return nil, fmt.Errorf("unknown telemetry sink type %q", sink.Type) //nolint:goerr113 // config error
+	}
+}
+
+// fanOut sends the telemetry to each of its handles.
+type fanOut []TelemetryHandle
+
+func (f fanOut) TrackLog(report Report) {
+	for _, th := range f {
+		th.TrackLog(report)
+	}
+}
+
+func (f fanOut) TrackMetric(metric Metric) {
+	for _, th := range f {
+		th.TrackMetric(metric)
+	}
+}
+
+func (f fanOut) TrackEvent(event Event) {
+	for _, th := range f {
+		th.TrackEvent(event)
+	}
+}
+
+func (f fanOut) Close(timeout int) {
+	for _, th := range f {
+		th.Close(timeout)
+	}
+}
+
+func (f fanOut) Flush() {
+	for _, th := range f {
+		th.Flush()
+	}
+}
@@ -0,0 +1,188 @@
+package aitelemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func readRecords(t *testing.T, path string) []fileRecord {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var records []fileRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to decode %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestFileTelemetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	th, err := NewFileTelemetry(AIConfig{AppName: "azure-cns", AppVersion: "v1"}, SinkConfig{Type: SinkFile, Path: path})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	th.TrackLog(Report{Message: "started", Context: "node1", CustomDimensions: map[string]string{"k": "v"}})
+	th.TrackEvent(Event{EventName: "NCCreated", ResourceID: "nc1"})
+	th.TrackMetric(Metric{Name: "HeartBeat", Value: 1})
+	th.Close(0)
+
+	records := readRecords(t, path)
+	if len(records) != 3 {
+		t.Fatalf("Unexpected records %+v", records)
+	}
+
+	if r := records[0]; r.Type != recordTypeLog || r.Message != "started" || r.Context != "node1" || r.Properties["k"] != "v" || r.App != "azure-cns" {
+		t.Errorf("Unexpected log record %+v", r)
+	}
+
+	if r := records[1]; r.Type != recordTypeEvent || r.Name != "NCCreated" || r.Context != "nc1" {
+		t.Errorf("Unexpected event record %+v", r)
+	}
+
+	if r := records[2]; r.Type != recordTypeMetric || r.Name != "HeartBeat" || r.Value == nil || *r.Value != 1 {
+		t.Errorf("Unexpected metric record %+v", r)
+	}
+}
+
+func TestFileTelemetryRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	th, err := NewFileTelemetry(AIConfig{}, SinkConfig{Type: SinkFile, Path: path, MaxSizeInBytes: 256, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		th.TrackMetric(Metric{Name: "HeartBeat", Value: float64(i)})
+	}
+	th.Close(0)
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Missing file %s: %v", name, err)
+		}
+
+		if info.Size() > 256 {
+			t.Errorf("File %s of %d bytes exceeds the max size", name, info.Size())
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Unexpected backup beyond max backups: %v", err)
+	}
+
+	records := readRecords(t, path)
+	if last := records[len(records)-1]; *last.Value != 19 {
+		t.Errorf("Unexpected last record %+v", last)
+	}
+}
+
+func TestFileTelemetryRotationFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	th, err := NewFileTelemetry(AIConfig{}, SinkConfig{Type: SinkFile, Path: path, MaxSizeInBytes: 256, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("Failed to create file sink: %v", err)
+	}
+
+	// a non-empty directory in place of the backup fails the rotation
+	if err := os.MkdirAll(filepath.Join(path+".1", "busy"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		th.TrackMetric(Metric{Name: "HeartBeat", Value: float64(i)})
+	}
+
+	// the records are kept in the current file while it cannot be rotated
+	if records := readRecords(t, path); len(records) != 10 {
+		t.Fatalf("Expected the records to be written to the current file, actual %+v", records)
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+
+	th.TrackMetric(Metric{Name: "HeartBeat", Value: 10})
+	th.Close(0)
+
+	if records := readRecords(t, path+".1"); len(records) != 10 {
+		t.Errorf("Expected the current file to be rotated, actual %+v", records)
+	}
+
+	records := readRecords(t, path)
+	if len(records) != 1 || *records[0].Value != 10 {
+		t.Errorf("Unexpected records after the rotation %+v", records)
+	}
+}
+
+func TestNewTelemetryFanOut(t *testing.T) {
+	dir := t.TempDir()
+	sinks := []SinkConfig{
+		{Type: SinkFile, Path: filepath.Join(dir, "a.jsonl")},
+		{Type: SinkFile, Path: filepath.Join(dir, "b.jsonl")},
+		{Type: "unknown"},
+	}
+
+	th, err := NewTelemetry("", "", AIConfig{}, sinks)
+	if err != nil {
+		t.Fatalf("Failed to create telemetry: %v", err)
+	}
+
+	th.TrackMetric(Metric{Name: "HeartBeat", Value: 1})
+	th.Close(0)
+
+	for _, name := range []string{"a.jsonl", "b.jsonl"} {
+		if records := readRecords(t, filepath.Join(dir, name)); len(records) != 1 {
+			t.Errorf("Unexpected records in %s: %+v", name, records)
+		}
+	}
+}
+
+func TestNewTelemetryNoSink(t *testing.T) {
+	if _, err := NewTelemetry("", "", AIConfig{}, []SinkConfig{{Type: SinkFile}, {Type: SinkOTLP}}); err == nil {
+		t.Errorf("Expected error when no sink can be created")
+	}
+}
+
+func TestOTLPTelemetry(t *testing.T) {
+	var exports int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			atomic.AddInt32(&exports, 1)
+		}
+	}))
+	defer collector.Close()
+
+	endpoint := strings.TrimPrefix(collector.URL, "http://")
+	th, err := NewOTLPTelemetry(AIConfig{AppName: "azure-cns"}, SinkConfig{Type: SinkOTLP, Endpoint: endpoint, Insecure: true})
+	if err != nil {
+		t.Fatalf("Failed to create otlp sink: %v", err)
+	}
+
+	th.TrackLog(Report{Message: "started"})
+	th.TrackEvent(Event{EventName: "NCCreated"})
+	th.TrackMetric(Metric{Name: "HeartBeat", Value: 1})
+	th.Close(1)
+
+	if atomic.LoadInt32(&exports) == 0 {
+		t.Errorf("Telemetry was not exported to the collector")
+	}
+}
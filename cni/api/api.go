@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net"
 	"os"
+	"time"
 
 	"github.com/Azure/azure-container-networking/log"
 )
@@ -16,6 +17,19 @@ type PodNetworkInterfaceInfo struct {
 	IPAddresses   []net.IPNet
 }
 
+// EndpointMirrorRequest is read from stdin by the commands adding and deleting the mirror of an endpoint. The
+// endpoint is identified by its ID, or by its pod. The copies of its traffic are sent to a local interface, or to
+// a GRE remote, until the mirror expires.
+type EndpointMirrorRequest struct {
+	NetworkID    string
+	EndpointID   string        `json:",omitempty"`
+	PodName      string        `json:",omitempty"`
+	PodNamespace string        `json:",omitempty"`
+	TargetIfName string        `json:",omitempty"`
+	RemoteIP     net.IP        `json:",omitempty"`
+	Duration     time.Duration `json:",omitempty"`
+}
+
 type AzureCNIState struct {
 	ContainerInterfaces map[string]PodNetworkInterfaceInfo
 }
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"github.com/Azure/azure-container-networking/cni"
 	"github.com/Azure/azure-container-networking/cni/api"
 	"github.com/Azure/azure-container-networking/log"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
 	semver "github.com/hashicorp/go-version"
 	utilexec "k8s.io/utils/exec"
 )
@@ -19,6 +21,8 @@ const (
 
 type Client interface {
 	GetEndpointState() (*api.AzureCNIState, error)
+	AddEndpointMirror(req *api.EndpointMirrorRequest) error
+	DeleteEndpointMirror(req *api.EndpointMirrorRequest) error
 }
 
 var _ (Client) = (*client)(nil)
@@ -53,6 +57,49 @@ func (c *client) GetEndpointState() (*api.AzureCNIState, error) {
 	return state, nil
 }
 
+// AddEndpointMirror mirrors the traffic of an endpoint until the mirror expires.
+func (c *client) AddEndpointMirror(req *api.EndpointMirrorRequest) error {
+	return c.execEndpointMirror(cni.CmdAddEndpointMirror, req)
+}
+
+// DeleteEndpointMirror deletes the mirror of an endpoint.
+func (c *client) DeleteEndpointMirror(req *api.EndpointMirrorRequest) error {
+	return c.execEndpointMirror(cni.CmdDeleteEndpointMirror, req)
+}
+
+func (c *client) execEndpointMirror(cniCmd string, req *api.EndpointMirrorRequest) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode endpoint mirror request: %w", err)
+	}
+
+	cmd := c.exec.Command(azureVnetExecutable)
+
+	envs := os.Environ()
+	cmdenv := fmt.Sprintf("%s=%s", cni.Cmd, cniCmd)
+	log.Printf("Setting cmd to %s", cmdenv)
+	envs = append(envs, cmdenv)
+	cmd.SetEnv(envs)
+	cmd.SetStdin(bytes.NewReader(b))
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to call Azure CNI bin with err: [%w], output: [%s]", err, string(output))
+	}
+
+	// Azure CNI reports a failure to mirror as a CNI error on stdout.
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	cniErr := &cniTypes.Error{}
+	if err := json.Unmarshal(output, cniErr); err != nil {
+		return fmt.Errorf("failed to decode response from Azure CNI when mirroring endpoint: [%w], response from CNI: [%s]", err, string(output))
+	}
+
+	return cniErr
+}
+
 func (c *client) GetVersion() (*semver.Version, error) {
 	cmd := c.exec.Command(azureVnetExecutable, "-v")
 
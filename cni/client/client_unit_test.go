@@ -4,6 +4,7 @@ package client
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cni/api"
 	testutils "github.com/Azure/azure-container-networking/test/utils"
@@ -32,6 +33,20 @@ func TestGetState(t *testing.T) {
 	require.Equal(t, res, state)
 }
 
+func TestEndpointMirror(t *testing.T) {
+	calls := []testutils.TestCmd{
+		{Cmd: []string{"/opt/cni/bin/azure-vnet"}},
+		{Cmd: []string{"/opt/cni/bin/azure-vnet"}, Stdout: `{"code":100,"msg":"endpoint mirror not found"}`},
+	}
+
+	fakeexec := testutils.GetFakeExecWithScripts(calls)
+
+	c := New(fakeexec)
+	req := &api.EndpointMirrorRequest{NetworkID: "azure", EndpointID: "3f813b02-eth0", TargetIfName: "ifb0", Duration: time.Minute}
+	require.NoError(t, c.AddEndpointMirror(req))
+	require.EqualError(t, c.DeleteEndpointMirror(req), "endpoint mirror not found")
+}
+
 func TestGetVersion(t *testing.T) {
 	calls := []testutils.TestCmd{
 		{Cmd: []string{"/opt/cni/bin/azure-vnet", "-v"}, Stdout: `Azure CNI Version v1.4.0-2-g984c5a5e-dirty`},
@@ -24,6 +24,10 @@ const (
 	// nonstandard CNI spec command, used to dump CNI state to stdout
 	CmdGetEndpointsState = "GET_ENDPOINT_STATE"
 
+	// nonstandard CNI spec commands, used to mirror the traffic of an endpoint for troubleshooting
+	CmdAddEndpointMirror    = "ADD_ENDPOINT_MIRROR"
+	CmdDeleteEndpointMirror = "DEL_ENDPOINT_MIRROR"
+
 	// CNI errors.
 	ErrRuntime = 100
 
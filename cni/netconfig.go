@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-container-networking/network/policy"
+	"github.com/Azure/azure-container-networking/tracing"
 
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 )
@@ -30,8 +31,9 @@ type PortMapping struct {
 }
 
 type RuntimeConfig struct {
-	PortMappings []PortMapping    `json:"portMappings,omitempty"`
-	DNS          RuntimeDNSConfig `json:"dns,omitempty"`
+	PortMappings []PortMapping     `json:"portMappings,omitempty"`
+	DNS          RuntimeDNSConfig  `json:"dns,omitempty"`
+	Sysctls      map[string]string `json:"sysctls,omitempty"`
 }
 
 // https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/dockershim/network/cni/cni.go#L104
@@ -63,8 +65,10 @@ type NetworkConfig struct {
 	EnableExactMatchForPodName    bool     `json:"enableExactMatchForPodName,omitempty"`
 	DisableHairpinOnHostInterface bool     `json:"disableHairpinOnHostInterface,omitempty"`
 	DisableIPTableLock            bool     `json:"disableIPTableLock,omitempty"`
+	IPTablesBackend               string   `json:"iptablesBackend,omitempty"`
 	CNSUrl                        string   `json:"cnsurl,omitempty"`
 	ExecutionMode                 string   `json:"executionMode,omitempty"`
+	MTU                           int      `json:"mtu,omitempty"`
 	Ipam                          struct {
 		Type          string `json:"type"`
 		Environment   string `json:"environment,omitempty"`
@@ -73,9 +77,41 @@ type NetworkConfig struct {
 		Address       string `json:"ipAddress,omitempty"`
 		QueryInterval string `json:"queryInterval,omitempty"`
 	} `json:"ipam,omitempty"`
-	DNS            cniTypes.DNS  `json:"dns,omitempty"`
-	RuntimeConfig  RuntimeConfig `json:"runtimeConfig,omitempty"`
-	AdditionalArgs []KVPair      `json:"AdditionalArgs,omitempty"`
+	DNS            cniTypes.DNS    `json:"dns,omitempty"`
+	RuntimeConfig  RuntimeConfig   `json:"runtimeConfig,omitempty"`
+	AdditionalArgs []KVPair        `json:"AdditionalArgs,omitempty"`
+	Tracing        *tracing.Config `json:"tracing,omitempty"`
+	Vxlan          *VxlanConfig    `json:"vxlan,omitempty"`
+
+	AdditionalInterfaces []InterfaceConfig `json:"additionalInterfaces,omitempty"`
+	Sysctls              map[string]string `json:"sysctls,omitempty"`
+	EgressSNATNamespaces []string          `json:"egressSnatNamespaces,omitempty"`
+	LogFormat            string            `json:"logFormat,omitempty"`
+	ComponentLogLevels   map[string]string `json:"componentLogLevels,omitempty"`
+}
+
+// VxlanConfig enables the VXLAN overlay of the tunnel mode. The peers are the underlay IPs of the other nodes,
+// the peers file is a JSON list of them maintained on the node, and CNS lists them from the nodes of the cluster.
+type VxlanConfig struct {
+	VNI          int      `json:"vni"`
+	Port         int      `json:"port,omitempty"`
+	Peers        []string `json:"peers,omitempty"`
+	PeersFile    string   `json:"peersFile,omitempty"`
+	PeersFromCNS bool     `json:"peersFromCns,omitempty"`
+}
+
+// InterfaceConfig describes an additional interface of the pods, backed by a network of its own. The network
+// is named after the one of the primary interface unless a name is set, and finds its master interface from
+// its subnet unless a master is set.
+type InterfaceConfig struct {
+	IfName string `json:"ifName"`
+	Name   string `json:"name,omitempty"`
+	Master string `json:"master,omitempty"`
+	Bridge string `json:"bridge,omitempty"`
+	Ipam   struct {
+		AddrSpace string `json:"addressSpace,omitempty"`
+		Subnet    string `json:"subnet,omitempty"`
+	} `json:"ipam,omitempty"`
 }
 
 type K8SPodEnvArgs struct {
@@ -128,6 +164,25 @@ func GetPoliciesFromNwCfg(kvp []KVPair) []policy.Policy {
 	return policies
 }
 
+// GetSysctlsFromNwCfg returns the sysctls of the pods from network config, the ones passed at runtime take
+// precedence over the configured ones.
+func GetSysctlsFromNwCfg(nwCfg *NetworkConfig) map[string]string {
+	if len(nwCfg.Sysctls) == 0 && len(nwCfg.RuntimeConfig.Sysctls) == 0 {
+		return nil
+	}
+
+	sysctls := make(map[string]string, len(nwCfg.Sysctls)+len(nwCfg.RuntimeConfig.Sysctls))
+	for key, value := range nwCfg.Sysctls {
+		sysctls[key] = value
+	}
+
+	for key, value := range nwCfg.RuntimeConfig.Sysctls {
+		sysctls[key] = value
+	}
+
+	return sysctls
+}
+
 // Serialize marshals a network configuration to bytes.
 func (nwcfg *NetworkConfig) Serialize() []byte {
 	bytes, _ := json.Marshal(nwcfg)
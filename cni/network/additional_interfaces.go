@@ -0,0 +1,306 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cns"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
+)
+
+var (
+	errAdditionalInterfaceIpam   = errors.New("additional interfaces are not supported with the azure-cns ipam")
+	errAdditionalInterfaceSubnet = errors.New("subnet of the additional interface not set")
+)
+
+// additionalInterface is an additional interface of a pod, backed by a network of its own. In multitenancy its
+// IP comes from the NC of the interface, otherwise it is allocated from the subnet of the interface.
+type additionalInterface struct {
+	nwCfg            *cni.NetworkConfig
+	ifName           string
+	cnsNetworkConfig *cns.GetNetworkContainerResponse
+	subnetPrefix     net.IPNet
+	result           *cniTypesCurr.Result
+}
+
+// additionalInterfaceNetworkConfig returns the network configuration of an additional interface. The interface
+// only gets its IPs and the routes of its subnet, SNAT, port mappings, sysctls and IPv6 stay on the primary interface.
+func additionalInterfaceNetworkConfig(nwCfg *cni.NetworkConfig, ifCfg *cni.InterfaceConfig) *cni.NetworkConfig {
+	ifNwCfg := *nwCfg
+	ifNwCfg.AdditionalInterfaces = nil
+	ifNwCfg.AdditionalArgs = nil
+	ifNwCfg.RuntimeConfig.PortMappings = nil
+	ifNwCfg.Sysctls = nil
+	ifNwCfg.EnableSnatOnHost = false
+	ifNwCfg.IPV6Mode = ""
+	ifNwCfg.Vxlan = nil
+
+	ifNwCfg.Name = fmt.Sprintf("%s-%s", nwCfg.Name, ifCfg.IfName)
+	if ifCfg.Name != "" {
+		ifNwCfg.Name = ifCfg.Name
+	}
+
+	ifNwCfg.Master = ifCfg.Master
+	ifNwCfg.Bridge = ifCfg.Bridge
+	ifNwCfg.Ipam.AddrSpace = ifCfg.Ipam.AddrSpace
+	ifNwCfg.Ipam.Subnet = ifCfg.Ipam.Subnet
+	ifNwCfg.Ipam.Address = ""
+
+	return &ifNwCfg
+}
+
+// interfaceConfig returns the configuration of an additional interface from the network configuration.
+func interfaceConfig(nwCfg *cni.NetworkConfig, ifName string) *cni.InterfaceConfig {
+	for i := range nwCfg.AdditionalInterfaces {
+		if nwCfg.AdditionalInterfaces[i].IfName == ifName {
+			return &nwCfg.AdditionalInterfaces[i]
+		}
+	}
+
+	return &cni.InterfaceConfig{IfName: ifName}
+}
+
+func additionalInterfaceArgs(args *cniSkel.CmdArgs, ifName string) *cniSkel.CmdArgs {
+	ifArgs := *args
+	ifArgs.IfName = ifName
+	return &ifArgs
+}
+
+func (plugin *NetPlugin) additionalInterfaceIpamInvoker(nwCfg *cni.NetworkConfig, nwInfo *network.NetworkInfo) IPAMInvoker {
+	return instrumentedIPAMInvoker{NewAzureIpamInvoker(plugin, nwInfo), nwCfg.Ipam.Type, plugin.tb}
+}
+
+// getAdditionalInterfaces returns the additional interfaces of the pod, from the CNS response in multitenancy
+// and from the network configuration otherwise.
+func (plugin *NetPlugin) getAdditionalInterfaces(
+	nwCfg *cni.NetworkConfig,
+	cnsNetworkConfig *cns.GetNetworkContainerResponse) ([]additionalInterface, error) {
+	var ifaces []additionalInterface
+
+	if nwCfg.MultiTenancy {
+		if cnsNetworkConfig == nil {
+			return nil, nil
+		}
+
+		for i := range cnsNetworkConfig.AdditionalInterfaces {
+			podInterface := &cnsNetworkConfig.AdditionalInterfaces[i]
+			result, ifCnsNetworkConfig, subnetPrefix, err := plugin.multitenancyClient.GetPodInterfaceNetworkConfiguration(podInterface)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the configuration of interface %s: %w", podInterface.InterfaceName, err)
+			}
+
+			ifaces = append(ifaces, additionalInterface{
+				nwCfg:            additionalInterfaceNetworkConfig(nwCfg, interfaceConfig(nwCfg, podInterface.InterfaceName)),
+				ifName:           podInterface.InterfaceName,
+				cnsNetworkConfig: ifCnsNetworkConfig,
+				subnetPrefix:     subnetPrefix,
+				result:           result,
+			})
+		}
+
+		return ifaces, nil
+	}
+
+	for i := range nwCfg.AdditionalInterfaces {
+		ifCfg := &nwCfg.AdditionalInterfaces[i]
+		if nwCfg.Ipam.Type == network.AzureCNS {
+			return nil, errAdditionalInterfaceIpam
+		}
+
+		if ifCfg.Ipam.Subnet == "" {
+			return nil, fmt.Errorf("%w: %s", errAdditionalInterfaceSubnet, ifCfg.IfName)
+		}
+
+		ifaces = append(ifaces, additionalInterface{
+			nwCfg:  additionalInterfaceNetworkConfig(nwCfg, ifCfg),
+			ifName: ifCfg.IfName,
+		})
+	}
+
+	return ifaces, nil
+}
+
+// addAdditionalInterfaces creates the endpoints of the additional interfaces of the pod, along with their
+// networks if they don't exist yet. The endpoints are deleted again if one of them fails.
+func (plugin *NetPlugin) addAdditionalInterfaces(
+	ctx context.Context,
+	args *cniSkel.CmdArgs,
+	nwCfg *cni.NetworkConfig,
+	cnsNetworkConfig *cns.GetNetworkContainerResponse,
+	k8sPodName string,
+	k8sNamespace string) ([]network.AdditionalEndpointInfo, []*cniTypesCurr.Result, error) {
+	var (
+		endpoints []network.AdditionalEndpointInfo
+		results   []*cniTypesCurr.Result
+	)
+
+	ifaces, err := plugin.getAdditionalInterfaces(nwCfg, cnsNetworkConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if er := plugin.deleteAdditionalInterfaces(ctx, args, nwCfg, endpoints); er != nil {
+				log.Errorf("[cni-net] Failed to clean up additional interfaces: %v", er)
+			}
+		}
+	}()
+
+	for i := range ifaces {
+		var (
+			endpoint network.AdditionalEndpointInfo
+			result   *cniTypesCurr.Result
+		)
+
+		endpoint, result, err = plugin.addAdditionalInterface(ctx, args, &ifaces[i], k8sPodName, k8sNamespace)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		endpoints = append(endpoints, endpoint)
+		results = append(results, result)
+	}
+
+	return endpoints, results, nil
+}
+
+func (plugin *NetPlugin) addAdditionalInterface(
+	ctx context.Context,
+	args *cniSkel.CmdArgs,
+	iface *additionalInterface,
+	k8sPodName string,
+	k8sNamespace string) (network.AdditionalEndpointInfo, *cniTypesCurr.Result, error) {
+	ifArgs := additionalInterfaceArgs(args, iface.ifName)
+	networkID := iface.nwCfg.Name
+	result := iface.result
+
+	nwInfo, nwInfoErr := plugin.nm.GetNetworkInfo(networkID)
+
+	var err error
+	if !iface.nwCfg.MultiTenancy {
+		options := make(map[string]interface{})
+		if nwInfoErr == nil {
+			options = nwInfo.Options
+		}
+
+		invoker := plugin.additionalInterfaceIpamInvoker(iface.nwCfg, &nwInfo)
+		if result, _, err = invoker.Add(ctx, iface.nwCfg, ifArgs, &iface.subnetPrefix, options); err != nil {
+			return network.AdditionalEndpointInfo{}, nil, err
+		}
+
+		defer func() {
+			if err != nil && len(result.IPs) > 0 {
+				if er := invoker.Delete(ctx, &result.IPs[0].Address, iface.nwCfg, ifArgs, options); er != nil {
+					log.Errorf("Failed to cleanup ip allocation of interface %s on failure: %v", iface.ifName, er)
+				}
+			}
+		}()
+	}
+
+	if len(result.Interfaces) == 0 {
+		result.Interfaces = append(result.Interfaces, &cniTypesCurr.Interface{Name: iface.ifName})
+	}
+
+	if nwInfoErr != nil {
+		log.Printf("[cni-net] Creating network %v for interface %v.", networkID, iface.ifName)
+		if nwInfo, err = plugin.createNetworkInternal(
+			networkID, nil, ifArgs, iface.nwCfg, iface.cnsNetworkConfig, iface.subnetPrefix, result, nil); err != nil {
+			log.Errorf("Create network failed:%v", err)
+			return network.AdditionalEndpointInfo{}, nil, err
+		}
+	}
+
+	epInfo, err := plugin.createEndpointInternal(&createEndpointInternalOpt{
+		ctx:                   ctx,
+		nwCfg:                 iface.nwCfg,
+		cnsNetworkConfig:      iface.cnsNetworkConfig,
+		result:                result,
+		args:                  ifArgs,
+		nwInfo:                &nwInfo,
+		endpointID:            GetEndpointID(ifArgs),
+		k8sPodName:            k8sPodName,
+		k8sNamespace:          k8sNamespace,
+		isAdditionalInterface: true,
+	})
+	if err != nil {
+		log.Errorf("Endpoint creation of interface %s failed:%v", iface.ifName, err)
+		return network.AdditionalEndpointInfo{}, nil, err
+	}
+
+	return network.AdditionalEndpointInfo{NetworkID: networkID, EndpointID: epInfo.Id, IfName: iface.ifName}, result, nil
+}
+
+// deleteAdditionalInterfaces deletes the endpoints of the additional interfaces of a pod and releases their IPs.
+// Endpoints which don't exist anymore are skipped, so that a failed delete can be retried.
+func (plugin *NetPlugin) deleteAdditionalInterfaces(
+	ctx context.Context,
+	args *cniSkel.CmdArgs,
+	nwCfg *cni.NetworkConfig,
+	endpoints []network.AdditionalEndpointInfo) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	cnsclient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to initialize cns client with URL %s: %w", nwCfg.CNSUrl, err)
+	}
+
+	for _, endpoint := range endpoints {
+		nwInfo, err := plugin.nm.GetNetworkInfo(endpoint.NetworkID)
+		if err != nil {
+			log.Printf("[cni-net] Network %v of interface %v not found: %v", endpoint.NetworkID, endpoint.IfName, err)
+			continue
+		}
+
+		epInfo, err := plugin.nm.GetEndpointInfo(endpoint.NetworkID, endpoint.EndpointID)
+		if err != nil {
+			log.Printf("[cni-net] Endpoint %v of interface %v not found: %v", endpoint.EndpointID, endpoint.IfName, err)
+			continue
+		}
+
+		log.Printf("[cni-net] Deleting endpoint %v of interface %v.", endpoint.EndpointID, endpoint.IfName)
+		if err = plugin.nm.DeleteEndpoint(cnsclient, endpoint.NetworkID, endpoint.EndpointID); err != nil {
+			return fmt.Errorf("failed to delete endpoint %s: %w", endpoint.EndpointID, err)
+		}
+
+		if nwCfg.MultiTenancy {
+			continue
+		}
+
+		ifNwCfg := additionalInterfaceNetworkConfig(nwCfg, interfaceConfig(nwCfg, endpoint.IfName))
+		ifArgs := additionalInterfaceArgs(args, endpoint.IfName)
+		invoker := plugin.additionalInterfaceIpamInvoker(ifNwCfg, &nwInfo)
+		for i := range epInfo.IPAddresses {
+			if err = invoker.Delete(ctx, &epInfo.IPAddresses[i], ifNwCfg, ifArgs, nwInfo.Options); err != nil {
+				return fmt.Errorf("failed to release address %v: %w", epInfo.IPAddresses[i], err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAdditionalInterfacesToResult appends the interfaces, IPs and routes of the additional interfaces to the
+// result of the primary interface.
+func addAdditionalInterfacesToResult(result *cniTypesCurr.Result, additionalResults []*cniTypesCurr.Result) {
+	for _, additionalResult := range additionalResults {
+		index := len(result.Interfaces)
+		result.Interfaces = append(result.Interfaces, additionalResult.Interfaces[0])
+
+		for _, ipconfig := range additionalResult.IPs {
+			ipconfig.Interface = cniTypesCurr.Int(index)
+			result.IPs = append(result.IPs, ipconfig)
+		}
+
+		result.Routes = append(result.Routes, additionalResult.Routes...)
+	}
+}
@@ -0,0 +1,191 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cns"
+	acnnetwork "github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/telemetry"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdditionalInterfaceNetworkConfig(t *testing.T) {
+	primaryNwCfg := cni.NetworkConfig{
+		Name:             "azure",
+		Mode:             "bridge",
+		Master:           "eth0",
+		Bridge:           "azure0",
+		EnableSnatOnHost: true,
+		IPV6Mode:         "ipv6nat",
+		AdditionalInterfaces: []cni.InterfaceConfig{
+			{IfName: "eth1", Master: "eth1"},
+			{IfName: "eth2", Name: "storage"},
+		},
+	}
+	primaryNwCfg.Ipam.Type = "azure-vnet-ipam"
+	primaryNwCfg.Ipam.Subnet = "10.240.0.0/16"
+	primaryNwCfg.Ipam.Address = "10.240.0.4"
+	primaryNwCfg.AdditionalInterfaces[0].Ipam.Subnet = "10.241.0.0/16"
+
+	tests := []struct {
+		name       string
+		ifName     string
+		wantName   string
+		wantMaster string
+		wantSubnet string
+	}{
+		{
+			name:       "Network named after the primary one",
+			ifName:     "eth1",
+			wantName:   "azure-eth1",
+			wantMaster: "eth1",
+			wantSubnet: "10.241.0.0/16",
+		},
+		{
+			name:     "Network with a configured name",
+			ifName:   "eth2",
+			wantName: "storage",
+		},
+		{
+			name:     "Interface not in the network config",
+			ifName:   "eth3",
+			wantName: "azure-eth3",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ifNwCfg := additionalInterfaceNetworkConfig(&primaryNwCfg, interfaceConfig(&primaryNwCfg, tt.ifName))
+			require.Equal(t, tt.wantName, ifNwCfg.Name)
+			require.Equal(t, tt.wantMaster, ifNwCfg.Master)
+			require.Equal(t, tt.wantSubnet, ifNwCfg.Ipam.Subnet)
+			require.Equal(t, "azure-vnet-ipam", ifNwCfg.Ipam.Type)
+			require.Empty(t, ifNwCfg.Ipam.Address)
+			require.Empty(t, ifNwCfg.Bridge)
+			require.Empty(t, ifNwCfg.IPV6Mode)
+			require.Empty(t, ifNwCfg.AdditionalInterfaces)
+			require.False(t, ifNwCfg.EnableSnatOnHost)
+		})
+	}
+
+	// The primary network config is left as is.
+	require.Equal(t, "azure", primaryNwCfg.Name)
+	require.Equal(t, "10.240.0.4", primaryNwCfg.Ipam.Address)
+}
+
+func TestGetAdditionalInterfacesErrors(t *testing.T) {
+	plugin := GetTestResources()
+
+	localNwCfg := cni.NetworkConfig{
+		Name:                 "azure",
+		AdditionalInterfaces: []cni.InterfaceConfig{{IfName: "eth1"}},
+	}
+	localNwCfg.Ipam.Type = "azure-vnet-ipam"
+	_, err := plugin.getAdditionalInterfaces(&localNwCfg, nil)
+	require.ErrorIs(t, err, errAdditionalInterfaceSubnet)
+
+	localNwCfg.Ipam.Type = acnnetwork.AzureCNS
+	_, err = plugin.getAdditionalInterfaces(&localNwCfg, nil)
+	require.ErrorIs(t, err, errAdditionalInterfaceIpam)
+}
+
+func TestAddAdditionalInterfacesToResult(t *testing.T) {
+	_, primaryIP, _ := net.ParseCIDR("10.240.0.4/16")
+	_, additionalIP, _ := net.ParseCIDR("10.241.0.4/16")
+	_, additionalRoute, _ := net.ParseCIDR("10.0.0.0/8")
+
+	result := &cniTypesCurr.Result{
+		Interfaces: []*cniTypesCurr.Interface{{Name: eth0IfName}},
+		IPs:        []*cniTypesCurr.IPConfig{{Version: "4", Address: *primaryIP}},
+	}
+	additionalResult := &cniTypesCurr.Result{
+		Interfaces: []*cniTypesCurr.Interface{{Name: "eth1"}},
+		IPs:        []*cniTypesCurr.IPConfig{{Version: "4", Address: *additionalIP}},
+		Routes:     []*cniTypes.Route{{Dst: *additionalRoute}},
+	}
+
+	addAdditionalInterfacesToResult(result, []*cniTypesCurr.Result{additionalResult})
+	require.Len(t, result.Interfaces, 2)
+	require.Equal(t, "eth1", result.Interfaces[1].Name)
+	require.Len(t, result.IPs, 2)
+	require.Nil(t, result.IPs[0].Interface)
+	require.Equal(t, 1, *result.IPs[1].Interface)
+	require.Len(t, result.Routes, 1)
+}
+
+func TestPluginMultitenancyAdditionalInterfaces(t *testing.T) {
+	plugin, _ := cni.NewPlugin("test", "0.3.0")
+
+	localNwCfg := cni.NetworkConfig{
+		CNIVersion:                 "0.3.0",
+		Name:                       "mulnet",
+		MultiTenancy:               true,
+		EnableExactMatchForPodName: true,
+		Master:                     "eth0",
+		// The master of the additional interface is otherwise found from its subnet on the host.
+		AdditionalInterfaces: []cni.InterfaceConfig{{IfName: "eth2", Master: "eth0"}},
+	}
+
+	multitenancyClient := NewMockMultitenancy(false)
+	multitenancyClient.additionalInterfaces = []cns.PodInterfaceConfig{
+		{
+			InterfaceName:      "eth2",
+			NetworkContainerID: "nc-storage",
+			IPConfiguration: cns.IPConfiguration{
+				IPSubnet:         cns.IPSubnet{IPAddress: "192.168.1.4", PrefixLength: ipPrefixLen},
+				GatewayIPAddress: "192.168.1.1",
+			},
+			PrimaryInterfaceIdentifier: "10.241.0.4/24",
+			MultiTenancyInfo:           cns.MultiTenancyInfo{EncapType: cns.Vlan, ID: 2},
+		},
+	}
+
+	netPlugin := &NetPlugin{
+		Plugin:             plugin,
+		nm:                 acnnetwork.NewMockNetworkmanager(),
+		tb:                 &telemetry.TelemetryBuffer{},
+		report:             &telemetry.CNIReport{},
+		multitenancyClient: multitenancyClient,
+	}
+
+	args := &cniSkel.CmdArgs{
+		StdinData:   localNwCfg.Serialize(),
+		ContainerID: "test-container",
+		Netns:       "test-container",
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+		IfName:      eth0IfName,
+	}
+
+	require.NoError(t, netPlugin.Add(args))
+
+	endpoints, _ := netPlugin.nm.GetAllEndpoints(localNwCfg.Name)
+	require.Len(t, endpoints, 2)
+
+	_, err := netPlugin.nm.GetNetworkInfo("mulnet-eth2")
+	require.NoError(t, err)
+
+	epInfo, err := netPlugin.nm.GetEndpointInfo(localNwCfg.Name, GetEndpointID(args))
+	require.NoError(t, err)
+	require.Len(t, epInfo.AdditionalEndpoints, 1)
+	require.Equal(t, "mulnet-eth2", epInfo.AdditionalEndpoints[0].NetworkID)
+	require.Equal(t, "eth2", epInfo.AdditionalEndpoints[0].IfName)
+
+	additionalEpInfo, err := netPlugin.nm.GetEndpointInfo("mulnet-eth2", epInfo.AdditionalEndpoints[0].EndpointID)
+	require.NoError(t, err)
+	require.Equal(t, "eth2", additionalEpInfo.IfName)
+	require.Equal(t, "192.168.1.4", additionalEpInfo.IPAddresses[0].IP.String())
+	// The default route stays on the primary interface.
+	require.Empty(t, additionalEpInfo.Routes)
+
+	require.NoError(t, netPlugin.Delete(args))
+
+	endpoints, _ = netPlugin.nm.GetAllEndpoints(localNwCfg.Name)
+	require.Empty(t, endpoints)
+}
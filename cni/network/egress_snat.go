@@ -0,0 +1,101 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network/policy"
+)
+
+var errInvalidEgressSNATIP = errors.New("invalid egress SNAT IP")
+
+// egressSNATClient gets and releases the egress SNAT IPs of namespaces from CNS.
+type egressSNATClient interface {
+	RequestEgressSNATIP(ctx context.Context, namespace string) (string, error)
+	ReleaseEgressSNATIP(ctx context.Context, namespace string) error
+}
+
+// isEgressSNATNamespace returns whether the pods of the namespace egress with an IP of their own.
+func isEgressSNATNamespace(nwCfg *cni.NetworkConfig, podNamespace string) bool {
+	for _, ns := range nwCfg.EgressSNATNamespaces {
+		if ns == podNamespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getEgressSNATPolicies returns the EgressSNAT policy of the pods of the egress SNAT namespaces, nil for the other
+// pods. Their egress traffic is SNATed to the egress SNAT IP of their namespace, a secondary IP obtained from CNS.
+func (plugin *NetPlugin) getEgressSNATPolicies(ctx context.Context, nwCfg *cni.NetworkConfig, podNamespace string) ([]policy.Policy, error) {
+	if !egressSNATSupported || !isEgressSNATNamespace(nwCfg, podNamespace) {
+		return nil, nil
+	}
+
+	cnsClient, err := plugin.getEgressSNATClient(nwCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	egressSNATPolicy, err := getEgressSNATPolicy(ctx, cnsClient, podNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return []policy.Policy{egressSNATPolicy}, nil
+}
+
+// getEgressSNATClient returns the egress SNAT client of the plugin, a CNS client unless one is set.
+func (plugin *NetPlugin) getEgressSNATClient(nwCfg *cni.NetworkConfig) (egressSNATClient, error) {
+	if plugin.egressSNATClient == nil {
+		cnsClient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cns client: %w", err)
+		}
+		plugin.egressSNATClient = cnsClient
+	}
+
+	return plugin.egressSNATClient, nil
+}
+
+// releaseEgressSNATIP releases the egress SNAT IP of the namespace to CNS once no endpoint of the node is SNATed to
+// it anymore. sourceIP is the egress SNAT IP of the deleted endpoint, nil if it was not SNATed.
+func (plugin *NetPlugin) releaseEgressSNATIP(ctx context.Context, nwCfg *cni.NetworkConfig, sourceIP net.IP, podNamespace string) error {
+	if sourceIP == nil || plugin.nm.IsEgressSNATIPInUse(sourceIP) {
+		return nil
+	}
+
+	cnsClient, err := plugin.getEgressSNATClient(nwCfg)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[cni-net] Releasing egress SNAT IP %v of namespace %s.", sourceIP, podNamespace)
+	if err = cnsClient.ReleaseEgressSNATIP(ctx, podNamespace); err != nil {
+		return fmt.Errorf("failed to release the egress SNAT IP of namespace %s: %w", podNamespace, err)
+	}
+
+	return nil
+}
+
+// getEgressSNATPolicy returns the EgressSNAT policy to the egress SNAT IP of the namespace.
+func getEgressSNATPolicy(ctx context.Context, cnsClient egressSNATClient, podNamespace string) (policy.Policy, error) {
+	ipAddress, err := cnsClient.RequestEgressSNATIP(ctx, podNamespace)
+	if err != nil {
+		return policy.Policy{}, fmt.Errorf("failed to get the egress SNAT IP of namespace %s: %w", podNamespace, err)
+	}
+
+	sourceIP := net.ParseIP(ipAddress)
+	if sourceIP == nil || sourceIP.To4() == nil {
+		return policy.Policy{}, fmt.Errorf("%w %q for namespace %s", errInvalidEgressSNATIP, ipAddress, podNamespace)
+	}
+
+	log.Printf("[cni-net] Egress traffic of namespace %s is SNATed to %v.", podNamespace, sourceIP)
+	return policy.NewEgressSNATPolicy(sourceIP)
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/network/policy"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockEgressSNAT = errors.New("mock egress SNAT error")
+
+type mockEgressSNATClient struct {
+	ipAddress          string
+	err                error
+	releasedNamespaces []string
+}
+
+func (c *mockEgressSNATClient) RequestEgressSNATIP(_ context.Context, _ string) (string, error) {
+	return c.ipAddress, c.err
+}
+
+func (c *mockEgressSNATClient) ReleaseEgressSNATIP(_ context.Context, namespace string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.releasedNamespaces = append(c.releasedNamespaces, namespace)
+	return nil
+}
+
+func TestGetEgressSNATPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *mockEgressSNATClient
+		wantSourceIP string
+		wantErr      error
+	}{
+		{
+			name:         "Egress SNAT IP from CNS",
+			client:       &mockEgressSNATClient{ipAddress: "10.240.5.7"},
+			wantSourceIP: "10.240.5.7",
+		},
+		{
+			name:    "CNS failure",
+			client:  &mockEgressSNATClient{err: errMockEgressSNAT},
+			wantErr: errMockEgressSNAT,
+		},
+		{
+			name:    "IPv6 egress SNAT IP",
+			client:  &mockEgressSNATClient{ipAddress: "fd00::5"},
+			wantErr: errInvalidEgressSNATIP,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			egressSNATPolicy, err := getEgressSNATPolicy(context.Background(), tt.client, "egress-ns")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, policy.EgressSNATPolicy, egressSNATPolicy.Type)
+
+			sourceIP, err := policy.GetEgressSNATSourceIP([]policy.Policy{egressSNATPolicy})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSourceIP, sourceIP.String())
+		})
+	}
+}
+
+func TestGetEgressSNATPoliciesOutsideEgressSNATNamespaces(t *testing.T) {
+	plugin := GetTestResources()
+	nwCfg := &cni.NetworkConfig{EgressSNATNamespaces: []string{"egress-ns"}}
+
+	require.True(t, isEgressSNATNamespace(nwCfg, "egress-ns"))
+	require.False(t, isEgressSNATNamespace(nwCfg, "default"))
+
+	// CNS is not called for the pods of the other namespaces.
+	policies, err := plugin.getEgressSNATPolicies(context.Background(), nwCfg, "default")
+	require.NoError(t, err)
+	require.Empty(t, policies)
+}
+
+func TestReleaseEgressSNATIP(t *testing.T) {
+	plugin := GetTestResources()
+	client := &mockEgressSNATClient{}
+	plugin.egressSNATClient = client
+	nwCfg := &cni.NetworkConfig{EgressSNATNamespaces: []string{"egress-ns"}}
+	sourceIP := net.ParseIP("10.240.5.7")
+
+	// The IP is kept while an endpoint of the node is SNATed to it.
+	require.NoError(t, plugin.nm.CreateEndpoint(context.Background(), nil, "net", &network.EndpointInfo{Id: "ep1", EgressSNATIP: sourceIP}))
+	require.NoError(t, plugin.releaseEgressSNATIP(context.Background(), nwCfg, sourceIP, "egress-ns"))
+	require.Empty(t, client.releasedNamespaces)
+
+	require.NoError(t, plugin.nm.DeleteEndpoint(nil, "net", "ep1"))
+	require.NoError(t, plugin.releaseEgressSNATIP(context.Background(), nwCfg, sourceIP, "egress-ns"))
+	require.Equal(t, []string{"egress-ns"}, client.releasedNamespaces)
+
+	// Endpoints that are not SNATed release nothing.
+	require.NoError(t, plugin.releaseEgressSNATIP(context.Background(), nwCfg, nil, "default"))
+	require.Equal(t, []string{"egress-ns"}, client.releasedNamespaces)
+
+	client.err = errMockEgressSNAT
+	require.ErrorIs(t, plugin.releaseEgressSNATIP(context.Background(), nwCfg, sourceIP, "egress-ns"), errMockEgressSNAT)
+}
@@ -1,9 +1,13 @@
 package network
 
 import (
+	"context"
 	"net"
 
+	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
 )
@@ -14,8 +18,48 @@ import (
 type IPAMInvoker interface {
 
 	// Add returns two results, one IPv4, the other IPv6.
-	Add(nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, subnetPrefix *net.IPNet, options map[string]interface{}) (*cniTypesCurr.Result, *cniTypesCurr.Result, error)
+	Add(ctx context.Context, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, subnetPrefix *net.IPNet, options map[string]interface{}) (*cniTypesCurr.Result, *cniTypesCurr.Result, error)
 
 	// Delete calls to the invoker source, and returns error. Returning an error here will fail the CNI Delete call.
-	Delete(address *net.IPNet, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, options map[string]interface{}) error
+	Delete(ctx context.Context, address *net.IPNet, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, options map[string]interface{}) error
+}
+
+// instrumentedIPAMInvoker records a span around each call to the IPAM invoker, and reports its errors to
+// the telemetry service.
+type instrumentedIPAMInvoker struct {
+	IPAMInvoker
+	ipamType string
+	tb       *telemetry.TelemetryBuffer
+}
+
+func (i instrumentedIPAMInvoker) Add(ctx context.Context, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, subnetPrefix *net.IPNet, options map[string]interface{}) (*cniTypesCurr.Result, *cniTypesCurr.Result, error) {
+	ctx, span := tracing.Start(ctx, "cni.ipam.Add")
+	result, resultV6, err := i.IPAMInvoker.Add(ctx, nwCfg, args, subnetPrefix, options)
+	tracing.End(span, err)
+	i.reportError(CNI_ADD, err)
+	return result, resultV6, err
+}
+
+func (i instrumentedIPAMInvoker) Delete(ctx context.Context, address *net.IPNet, nwCfg *cni.NetworkConfig, args *cniSkel.CmdArgs, options map[string]interface{}) error {
+	ctx, span := tracing.Start(ctx, "cni.ipam.Delete")
+	err := i.IPAMInvoker.Delete(ctx, address, nwCfg, args, options)
+	tracing.End(span, err)
+	i.reportError(CNI_DEL, err)
+	return err
+}
+
+func (i instrumentedIPAMInvoker) reportError(operation string, err error) {
+	if err == nil {
+		return
+	}
+	telemetry.SendCNIMetric(&telemetry.AIMetric{
+		Metric: aitelemetry.Metric{
+			Name:  telemetry.CNIIPAMErrorMetricStr,
+			Value: 1,
+			CustomDimensions: map[string]string{
+				telemetry.OperationTypeStr: operation,
+				telemetry.IPAMTypeStr:      i.ipamType,
+			},
+		},
+	}, i.tb)
 }
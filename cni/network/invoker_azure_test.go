@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -223,7 +224,7 @@ func TestAzureIPAMInvoker_Add(t *testing.T) {
 				plugin: tt.fields.plugin,
 				nwInfo: tt.fields.nwInfo,
 			}
-			got, got1, err := invoker.Add(tt.args.nwCfg, tt.args.in1, tt.args.subnetPrefix, tt.args.options)
+			got, got1, err := invoker.Add(context.TODO(), tt.args.nwCfg, tt.args.in1, tt.args.subnetPrefix, tt.args.options)
 			if tt.wantErr {
 				require.NotNil(err) // use NotNil since *cniTypes.Error is not of type Error
 			} else {
@@ -357,7 +358,7 @@ func TestAzureIPAMInvoker_Delete(t *testing.T) {
 				plugin: tt.fields.plugin,
 				nwInfo: tt.fields.nwInfo,
 			}
-			err := invoker.Delete(tt.args.address, tt.args.nwCfg, tt.args.in2, tt.args.options)
+			err := invoker.Delete(context.TODO(), tt.args.address, tt.args.nwCfg, tt.args.in2, tt.args.options)
 			if tt.wantErr {
 				require.NotNil(err)
 				return
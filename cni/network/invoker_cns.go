@@ -53,6 +53,7 @@ func NewCNSInvoker(podName, namespace string, cnsClient cnsclient) *CNSIPAMInvok
 
 // Add uses the requestipconfig API in cns, and returns ipv4 and a nil ipv6 as CNS doesn't support IPv6 yet
 func (invoker *CNSIPAMInvoker) Add( //nolint don't consider unnamedResult
+	ctx context.Context,
 	_ *cni.NetworkConfig,
 	args *cniSkel.CmdArgs,
 	hostSubnetPrefix *net.IPNet,
@@ -80,7 +81,7 @@ func (invoker *CNSIPAMInvoker) Add( //nolint don't consider unnamedResult
 	}
 
 	log.Printf("Requesting IP for pod %+v using ipconfig %+v", podInfo, ipconfig)
-	response, err := invoker.cnsClient.RequestIPAddress(context.TODO(), ipconfig)
+	response, err := invoker.cnsClient.RequestIPAddress(ctx, ipconfig)
 	if err != nil {
 		log.Printf("Failed to get IP address from CNS with error %v, response: %v", err, response)
 		return nil, nil, err
@@ -196,7 +197,7 @@ func setHostOptions(hostSubnetPrefix, ncSubnetPrefix *net.IPNet, options map[str
 }
 
 // Delete calls into the releaseipconfiguration API in CNS
-func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, _ *cni.NetworkConfig, args *cniSkel.CmdArgs, _ map[string]interface{}) error {
+func (invoker *CNSIPAMInvoker) Delete(ctx context.Context, address *net.IPNet, _ *cni.NetworkConfig, args *cniSkel.CmdArgs, _ map[string]interface{}) error {
 	// Parse Pod arguments.
 	podInfo := cns.KubernetesPodInfo{
 		PodName:      invoker.podName,
@@ -224,7 +225,7 @@ func (invoker *CNSIPAMInvoker) Delete(address *net.IPNet, _ *cni.NetworkConfig,
 		log.Printf("CNS invoker called with empty IP address")
 	}
 
-	if err := invoker.cnsClient.ReleaseIPAddress(context.TODO(), req); err != nil {
+	if err := invoker.cnsClient.ReleaseIPAddress(ctx, req); err != nil {
 		return fmt.Errorf("failed to release IP %v with err %w", address, err)
 	}
 
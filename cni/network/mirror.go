@@ -0,0 +1,62 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network"
+)
+
+var errInvalidEndpointMirrorRequest = errors.New("invalid endpoint mirror request")
+
+// AddEndpointMirror mirrors the traffic of the endpoint of the request until the mirror expires.
+func (plugin *NetPlugin) AddEndpointMirror(req *api.EndpointMirrorRequest) error {
+	endpointID, err := plugin.getEndpointMirrorEndpointID(req)
+	if err != nil {
+		return err
+	}
+
+	mirrorInfo := &network.MirrorInfo{
+		TargetIfName: req.TargetIfName,
+		RemoteIP:     req.RemoteIP,
+		Duration:     req.Duration,
+	}
+
+	log.Printf("[cni-net] Adding mirror %+v of endpoint %s.", mirrorInfo, endpointID)
+	return plugin.nm.AddEndpointMirror(req.NetworkID, endpointID, mirrorInfo)
+}
+
+// DeleteEndpointMirror deletes the mirror of the endpoint of the request.
+func (plugin *NetPlugin) DeleteEndpointMirror(req *api.EndpointMirrorRequest) error {
+	endpointID, err := plugin.getEndpointMirrorEndpointID(req)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[cni-net] Deleting mirror of endpoint %s.", endpointID)
+	return plugin.nm.DeleteEndpointMirror(req.NetworkID, endpointID)
+}
+
+// getEndpointMirrorEndpointID returns the ID of the endpoint of the request, looked up from its pod if not set.
+func (plugin *NetPlugin) getEndpointMirrorEndpointID(req *api.EndpointMirrorRequest) (string, error) {
+	if req.NetworkID == "" {
+		return "", fmt.Errorf("%w: network ID is required", errInvalidEndpointMirrorRequest)
+	}
+
+	if req.EndpointID != "" {
+		return req.EndpointID, nil
+	}
+
+	if req.PodName == "" || req.PodNamespace == "" {
+		return "", fmt.Errorf("%w: endpoint ID or pod name and namespace are required", errInvalidEndpointMirrorRequest)
+	}
+
+	epInfo, err := plugin.nm.GetEndpointInfoBasedOnPODDetails(req.NetworkID, req.PodName, req.PodNamespace, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the endpoint of pod %s/%s: %w", req.PodNamespace, req.PodName, err)
+	}
+
+	return epInfo.Id, nil
+}
@@ -0,0 +1,52 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointMirrorRequests(t *testing.T) {
+	plugin := GetTestResources()
+
+	tests := []struct {
+		name    string
+		req     *api.EndpointMirrorRequest
+		wantErr bool
+	}{
+		{
+			name: "Mirror by endpoint ID",
+			req:  &api.EndpointMirrorRequest{NetworkID: "azure", EndpointID: "test-eth0", TargetIfName: "ifb0", Duration: time.Minute},
+		},
+		{
+			name: "Mirror by pod",
+			req:  &api.EndpointMirrorRequest{NetworkID: "azure", PodName: "test-pod", PodNamespace: "test-ns", TargetIfName: "ifb0", Duration: time.Minute},
+		},
+		{
+			name:    "Missing network ID",
+			req:     &api.EndpointMirrorRequest{EndpointID: "test-eth0", TargetIfName: "ifb0", Duration: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "Missing endpoint",
+			req:     &api.EndpointMirrorRequest{NetworkID: "azure", PodName: "test-pod", TargetIfName: "ifb0", Duration: time.Minute},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := plugin.AddEndpointMirror(tt.req)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errInvalidEndpointMirrorRequest)
+				require.ErrorIs(t, plugin.DeleteEndpointMirror(tt.req), errInvalidEndpointMirrorRequest)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, plugin.DeleteEndpointMirror(tt.req))
+		})
+	}
+}
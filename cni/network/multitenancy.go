@@ -46,6 +46,9 @@ type MultitenancyClient interface {
 		podNamespace string,
 		ifName string) (*cniTypesCurr.Result, *cns.GetNetworkContainerResponse, net.IPNet, error)
 
+	GetPodInterfaceNetworkConfiguration(
+		podInterface *cns.PodInterfaceConfig) (*cniTypesCurr.Result, *cns.GetNetworkContainerResponse, net.IPNet, error)
+
 	Init(cnsclient cnsclient, netioshim netioshim)
 }
 
@@ -229,6 +232,32 @@ func (m *Multitenancy) getContainerNetworkConfigurationInternal(
 	return convertToCniResult(networkConfig, ifName), networkConfig, *subnetPrefix, nil
 }
 
+// GetPodInterfaceNetworkConfiguration returns the configuration of an additional interface of the pod, as
+// received from CNS along with the one of its primary interface.
+func (m *Multitenancy) GetPodInterfaceNetworkConfiguration(
+	podInterface *cns.PodInterfaceConfig) (*cniTypesCurr.Result, *cns.GetNetworkContainerResponse, net.IPNet, error) {
+	networkConfig := &cns.GetNetworkContainerResponse{
+		NetworkContainerID:         podInterface.NetworkContainerID,
+		IPConfiguration:            podInterface.IPConfiguration,
+		Routes:                     podInterface.Routes,
+		CnetAddressSpace:           podInterface.CnetAddressSpace,
+		MultiTenancyInfo:           podInterface.MultiTenancyInfo,
+		PrimaryInterfaceIdentifier: podInterface.PrimaryInterfaceIdentifier,
+		LocalIPConfiguration:       podInterface.LocalIPConfiguration,
+		AllowHostToNCCommunication: podInterface.AllowHostToNCCommunication,
+		AllowNCToHostCommunication: podInterface.AllowNCToHostCommunication,
+	}
+
+	subnetPrefix := m.netioshim.GetInterfaceSubnetWithSpecificIP(networkConfig.PrimaryInterfaceIdentifier)
+	if subnetPrefix == nil {
+		errBuf := fmt.Sprintf("Interface not found for this ip %v", networkConfig.PrimaryInterfaceIdentifier)
+		log.Printf(errBuf)
+		return nil, nil, net.IPNet{}, fmt.Errorf(errBuf)
+	}
+
+	return convertToCniResult(networkConfig, podInterface.InterfaceName), networkConfig, *subnetPrefix, nil
+}
+
 func convertToCniResult(networkConfig *cns.GetNetworkContainerResponse, ifName string) *cniTypesCurr.Result {
 	result := &cniTypesCurr.Result{}
 	resultIpconfig := &cniTypesCurr.IPConfig{}
@@ -286,7 +315,7 @@ func getInfraVnetIP(
 		log.Printf("call ipam to allocate ip from subnet %v", nwCfg.Ipam.Subnet)
 		subnetPrefix := &net.IPNet{}
 		options := make(map[string]interface{})
-		azIpamResult, _, err := plugin.ipamInvoker.Add(nwCfg, nil, subnetPrefix, options)
+		azIpamResult, _, err := plugin.ipamInvoker.Add(context.TODO(), nwCfg, nil, subnetPrefix, options)
 		if err != nil {
 			err = plugin.Errorf("Failed to allocate address: %v", err)
 			return nil, err
@@ -311,7 +340,7 @@ func cleanupInfraVnetIP(
 		nwCfg.Ipam.Subnet = ipNet.String()
 		nwCfg.Ipam.Address = infraIPNet.IP.String()
 		if err := plugin.DelegateDel(nwCfg.Ipam.Type, nwCfg); err != nil {
-			log.Errorf("failed to cleanup infravnet ip with err %w", err)
+			log.Errorf("failed to cleanup infravnet ip with err %v", err)
 		}
 	}
 }
@@ -12,7 +12,8 @@ import (
 )
 
 type MockMultitenancy struct {
-	fail bool
+	fail                 bool
+	additionalInterfaces []cns.PodInterfaceConfig
 }
 
 const (
@@ -73,9 +74,28 @@ func (m *MockMultitenancy) GetContainerNetworkConfiguration(
 			EncapType: cns.Vlan,
 			ID:        1,
 		},
+		AdditionalInterfaces: m.additionalInterfaces,
 	}
 	_, ipnet, _ := net.ParseCIDR(cnsResponse.PrimaryInterfaceIdentifier)
 	result := convertToCniResult(cnsResponse, "eth1")
 
 	return result, cnsResponse, *ipnet, nil
 }
+
+func (m *MockMultitenancy) GetPodInterfaceNetworkConfiguration(
+	podInterface *cns.PodInterfaceConfig) (*current.Result, *cns.GetNetworkContainerResponse, net.IPNet, error) {
+	if m.fail {
+		return nil, nil, net.IPNet{}, errMockMulAdd
+	}
+
+	cnsResponse := &cns.GetNetworkContainerResponse{
+		NetworkContainerID:         podInterface.NetworkContainerID,
+		IPConfiguration:            podInterface.IPConfiguration,
+		PrimaryInterfaceIdentifier: podInterface.PrimaryInterfaceIdentifier,
+		MultiTenancyInfo:           podInterface.MultiTenancyInfo,
+	}
+	_, ipnet, _ := net.ParseCIDR(cnsResponse.PrimaryInterfaceIdentifier)
+	result := convertToCniResult(cnsResponse, podInterface.InterfaceName)
+
+	return result, cnsResponse, *ipnet, nil
+}
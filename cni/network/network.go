@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"os"
 	"strconv"
 	"time"
 
@@ -28,18 +29,22 @@ import (
 	nnscontracts "github.com/Azure/azure-container-networking/proto/nodenetworkservice/3.302.0.744"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	cniTypes "github.com/containernetworking/cni/pkg/types"
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	dockerNetworkOption = "com.docker.network.generic"
 	opModeTransparent   = "transparent"
+	opModeTunnel        = "tunnel"
 	// Supported IP version. Currently support only IPv4
 	ipVersion             = "4"
 	ipamV6                = "azure-vnet-ipamv6"
 	defaultRequestTimeout = 15 * time.Second
+	tracingFlushTimeout   = 5 * time.Second
 )
 
 // CNI Operation Types
@@ -74,6 +79,9 @@ type NetPlugin struct {
 	nnsClient          NnsClient
 	hnsEndpointClient  network.AzureHNSEndpointClient
 	multitenancyClient MultitenancyClient
+	podSysctlsClient   podSysctlsClient
+	egressSNATClient   egressSNATClient
+	vxlanPeersClient   vxlanPeersClient
 }
 
 // client for node network service
@@ -152,6 +160,11 @@ func (plugin *NetPlugin) Start(config *common.PluginConfig) error {
 		return err
 	}
 
+	// Expired mirrors are removed by the network monitor, this catches those it has not removed yet.
+	if err = plugin.nm.DeleteExpiredEndpointMirrors(); err != nil {
+		log.Printf("[cni-net] Failed to delete expired endpoint mirrors, err:%v.", err)
+	}
+
 	log.Printf("[cni-net] Plugin started.")
 
 	return nil
@@ -274,6 +287,44 @@ func SetCustomDimensions(cniMetric *telemetry.AIMetric, nwCfg *cni.NetworkConfig
 	}
 }
 
+// configureLogging applies the log level, the log format and the component log levels of the network config.
+func configureLogging(nwCfg *cni.NetworkConfig) {
+	if nwCfg.LogLevel != "" {
+		level, err := log.ParseLevel(nwCfg.LogLevel)
+		if err != nil {
+			log.Errorf("[cni-net] Ignoring log level: %v", err)
+		} else {
+			log.SetLevel(level)
+		}
+	}
+
+	if nwCfg.LogFormat != "" {
+		format, err := log.ParseFormat(nwCfg.LogFormat)
+		if err != nil {
+			log.Errorf("[cni-net] Ignoring log format: %v", err)
+		} else {
+			log.SetFormat(format)
+		}
+	}
+
+	for component, name := range nwCfg.ComponentLogLevels {
+		level, err := log.ParseLevel(name)
+		if err != nil {
+			log.Errorf("[cni-net] Ignoring log level of %s: %v", component, err)
+			continue
+		}
+		log.SetComponentLevel(component, level)
+	}
+}
+
+// configureIPTables applies the iptables settings of the network config.
+func configureIPTables(nwCfg *cni.NetworkConfig) {
+	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+	if err := iptables.SelectBackend(nwCfg.IPTablesBackend); err != nil {
+		log.Printf("[cni-net] Failed to select iptables backend %s, using %s: %v", nwCfg.IPTablesBackend, iptables.BackendIptables, err)
+	}
+}
+
 func (plugin *NetPlugin) setCNIReportDetails(nwCfg *cni.NetworkConfig, opType, msg string) {
 	plugin.report.OperationType = opType
 	plugin.report.SubContext = fmt.Sprintf("%+v", nwCfg)
@@ -298,6 +349,23 @@ func addNatIPV6SubnetInfo(nwCfg *cni.NetworkConfig,
 	}
 }
 
+// startTracing sets up the export of the spans of a command from the network configuration, and returns
+// a func which flushes them before the plugin exits.
+func (plugin *NetPlugin) startTracing(nwCfg *cni.NetworkConfig) func() {
+	shutdown, err := tracing.Init(context.Background(), plugin.Name, plugin.Version, nwCfg.Tracing)
+	if err != nil {
+		log.Errorf("[cni-net] Failed to initialize tracing: %v", err)
+		return func() {}
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Errorf("[cni-net] Failed to flush traces: %v", err)
+		}
+	}
+}
+
 //
 // CNI implementation
 // https://github.com/containernetworking/cni/blob/master/SPEC.md
@@ -314,6 +382,10 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		enableInfraVnet  bool
 		enableSnatForDns bool
 		cniMetric        telemetry.AIMetric
+
+		additionalEndpoints []network.AdditionalEndpointInfo
+		additionalResults   []*cniTypesCurr.Result
+		interfaceMTUs       map[string]int
 	)
 
 	startTime := time.Now()
@@ -328,9 +400,14 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	configureLogging(nwCfg)
 	log.Printf("[cni-net] Read network configuration %+v.", nwCfg)
 
-	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+	defer plugin.startTracing(nwCfg)()
+	ctx, span := tracing.Start(context.Background(), "cni.Add", attribute.String("container.id", args.ContainerID))
+	defer func() { tracing.End(span, err) }()
+
+	configureIPTables(nwCfg)
 	plugin.setCNIReportDetails(nwCfg, CNI_ADD, "")
 
 	defer func() {
@@ -357,6 +434,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			result.IPs = append(result.IPs, resultV6.IPs...)
 		}
 
+		addAdditionalInterfacesToResult(result, additionalResults)
 		addSnatInterface(nwCfg, result)
 		// Convert result to the requested CNI version.
 		res, vererr := result.GetAsVersion(nwCfg.CNIVersion)
@@ -367,7 +445,9 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 
 		if err == nil && res != nil {
 			// Output the result to stdout.
-			res.Print()
+			if er := printResult(os.Stdout, res, interfaceMTUs); er != nil {
+				log.Printf("Failed to print result with error %v", er)
+			}
 		}
 
 		log.Printf("[cni-net] ADD command completed with result:%+v err:%v.", result, err)
@@ -395,6 +475,26 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		return plugin.Errorf(errMsg)
 	}
 
+	// Rejected sysctls fail the ADD before any resource is allocated.
+	sysctls, err := plugin.getSysctls(ctx, nwCfg, k8sPodName, k8sNamespace)
+	if err != nil {
+		err = plugin.Errorf("Failed to get sysctls: %v", err)
+		return err
+	}
+	if err = network.ValidateSysctls(sysctls); err != nil {
+		err = plugin.Errorf("Failed to validate sysctls: %v", err)
+		return err
+	}
+
+	// correlate the logs of this command across CNI and CNS.
+	ctx = log.WithCorrelation(ctx, log.Correlation{
+		RequestID:    log.NewRequestID(),
+		PodNamespace: k8sNamespace,
+		PodName:      k8sPodName,
+		ContainerID:  k8sContainerID,
+	})
+	log.Component("cni-net").WithContext(ctx).Info("Processing ADD command", "netns", args.Netns, "ifName", k8sIfName)
+
 	log.Printf("Execution mode :%s", nwCfg.ExecutionMode)
 	if nwCfg.ExecutionMode == string(util.Baremetal) {
 		var res *nnscontracts.ConfigureContainerNetworkingResponse
@@ -490,23 +590,23 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 			if er != nil {
 				return fmt.Errorf("initializing cns client failed with err %w", er)
 			}
-			plugin.ipamInvoker = NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient)
+			plugin.ipamInvoker = instrumentedIPAMInvoker{NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient), nwCfg.Ipam.Type, plugin.tb}
 
 		default:
-			plugin.ipamInvoker = NewAzureIpamInvoker(plugin, &nwInfo)
+			plugin.ipamInvoker = instrumentedIPAMInvoker{NewAzureIpamInvoker(plugin, &nwInfo), nwCfg.Ipam.Type, plugin.tb}
 		}
 	}
 
 	// Allocate from azure ipam
 	if !nwCfg.MultiTenancy {
-		result, resultV6, err = plugin.ipamInvoker.Add(nwCfg, args, &subnetPrefix, options)
+		result, resultV6, err = plugin.ipamInvoker.Add(ctx, nwCfg, args, &subnetPrefix, options)
 		if err != nil {
 			return err
 		}
 
 		defer func() {
 			if err != nil {
-				plugin.cleanupAllocationOnError(result, resultV6, nwCfg, args, options)
+				plugin.cleanupAllocationOnError(ctx, result, resultV6, nwCfg, args, options)
 			}
 		}()
 	}
@@ -516,7 +616,7 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		// Network does not exist.
 		log.Printf("[cni-net] Creating network %v.", networkID)
 		if nwInfo, err = plugin.createNetworkInternal(networkID, policies, args, nwCfg, cnsNetworkConfig, subnetPrefix, result, resultV6); err != nil {
-			log.Errorf("Create network failed:%w", err)
+			log.Errorf("Create network failed:%v", err)
 			return err
 		}
 
@@ -525,7 +625,38 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 
 	natInfo := getNATInfo(nwCfg.ExecutionMode, options[network.SNATIPKey], nwCfg.MultiTenancy, enableSnatForDns)
 
+	// The endpoints of the additional interfaces are created first, the endpoint of the primary interface tracks them.
+	additionalEndpoints, additionalResults, err = plugin.addAdditionalInterfaces(ctx, args, nwCfg, cnsNetworkConfig, k8sPodName, k8sNamespace)
+	if err != nil {
+		log.Errorf("Additional interfaces creation failed:%v", err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if er := plugin.deleteAdditionalInterfaces(ctx, args, nwCfg, additionalEndpoints); er != nil {
+				log.Errorf("Failed to clean up additional interfaces on failure: %v", er)
+			}
+		}
+	}()
+
+	// The pods of the egress SNAT namespaces egress with the IP of their namespace, or not at all.
+	egressSNATPolicies, err := plugin.getEgressSNATPolicies(ctx, nwCfg, k8sNamespace)
+	if err != nil {
+		err = plugin.Errorf("Failed to get egress SNAT policy: %v", err)
+		return err
+	}
+	defer func() {
+		if err != nil && len(egressSNATPolicies) > 0 {
+			sourceIP, _ := policy.GetEgressSNATSourceIP(egressSNATPolicies)
+			if er := plugin.releaseEgressSNATIP(ctx, nwCfg, sourceIP, k8sNamespace); er != nil {
+				log.Errorf("Failed to release egress SNAT IP on failure: %v", er)
+			}
+		}
+	}()
+
 	createEndpointInternalOpt := createEndpointInternalOpt{
+		ctx:              ctx,
 		nwCfg:            nwCfg,
 		cnsNetworkConfig: cnsNetworkConfig,
 		result:           result,
@@ -533,40 +664,48 @@ func (plugin *NetPlugin) Add(args *cniSkel.CmdArgs) error {
 		azIpamResult:     azIpamResult,
 		args:             args,
 		nwInfo:           &nwInfo,
-		policies:         policies,
+		policies:         append(policies, egressSNATPolicies...),
 		endpointID:       endpointId,
 		k8sPodName:       k8sPodName,
 		k8sNamespace:     k8sNamespace,
 		enableInfraVnet:  enableInfraVnet,
 		enableSnatForDNS: enableSnatForDns,
 		natInfo:          natInfo,
+
+		additionalEndpoints: additionalEndpoints,
+		sysctls:             sysctls,
 	}
 	epInfo, err := plugin.createEndpointInternal(&createEndpointInternalOpt)
 	if err != nil {
-		log.Errorf("Endpoint creation failed:%w", err)
+		log.Errorf("Endpoint creation failed:%v", err)
 		return err
 	}
 
-	msg := fmt.Sprintf("CNI ADD succeeded : CNI Version %+v, IP:%+v, VlanID: %v, Interfaces:%+v, podname %v, namespace %v",
-		result.CNIVersion, result.IPs, epInfo.Data[network.VlanIDKey], result.Interfaces, k8sPodName, k8sNamespace)
+	// The MTU of the networks is resolved when they are created if it is not configured, it is reported on the
+	// interfaces of the result.
+	interfaceMTUs = plugin.getInterfaceMTUs(args.IfName, networkID, additionalEndpoints)
+
+	msg := fmt.Sprintf("CNI ADD succeeded : CNI Version %+v, IP:%+v, VlanID: %v, Interfaces:%+v, MTUs: %v, podname %v, namespace %v",
+		result.CNIVersion, result.IPs, epInfo.Data[network.VlanIDKey], result.Interfaces, interfaceMTUs, k8sPodName, k8sNamespace)
 	plugin.setCNIReportDetails(nwCfg, CNI_ADD, msg)
 
 	return nil
 }
 
 func (plugin *NetPlugin) cleanupAllocationOnError(
+	ctx context.Context,
 	result, resultV6 *cniTypesCurr.Result,
 	nwCfg *cni.NetworkConfig,
 	args *cniSkel.CmdArgs,
 	options map[string]interface{}) {
 
 	if result != nil && len(result.IPs) > 0 {
-		if er := plugin.ipamInvoker.Delete(&result.IPs[0].Address, nwCfg, args, options); er != nil {
+		if er := plugin.ipamInvoker.Delete(ctx, &result.IPs[0].Address, nwCfg, args, options); er != nil {
 			log.Errorf("Failed to cleanup ip allocation on failure: %v", er)
 		}
 	}
 	if resultV6 != nil && len(resultV6.IPs) > 0 {
-		if er := plugin.ipamInvoker.Delete(&resultV6.IPs[0].Address, nwCfg, args, options); er != nil {
+		if er := plugin.ipamInvoker.Delete(ctx, &resultV6.IPs[0].Address, nwCfg, args, options); er != nil {
 			log.Errorf("Failed to cleanup ipv6 allocation on failure: %v", er)
 		}
 	}
@@ -636,10 +775,20 @@ func (plugin *NetPlugin) createNetworkInternal(
 		DisableHairpinOnHostInterface: nwCfg.DisableHairpinOnHostInterface,
 		IPV6Mode:                      nwCfg.IPV6Mode,
 		ServiceCidrs:                  nwCfg.ServiceCidrs,
+		MTU:                           nwCfg.MTU,
 	}
 
 	nwInfo.IPAMType = nwCfg.Ipam.Type
 
+	if nwCfg.Mode == opModeTunnel && nwCfg.Vxlan != nil {
+		nwInfo.Vxlan = &network.VxlanInfo{
+			VNI:       nwCfg.Vxlan.VNI,
+			Port:      nwCfg.Vxlan.Port,
+			Peers:     nwCfg.Vxlan.Peers,
+			PeersFile: nwCfg.Vxlan.PeersFile,
+		}
+	}
+
 	if len(result.IPs) > 0 {
 		var podnetwork *net.IPNet
 		_, podnetwork, err = net.ParseCIDR(result.IPs[0].Address.String())
@@ -668,6 +817,7 @@ func (plugin *NetPlugin) createNetworkInternal(
 }
 
 type createEndpointInternalOpt struct {
+	ctx              context.Context
 	nwCfg            *cni.NetworkConfig
 	cnsNetworkConfig *cns.GetNetworkContainerResponse
 	result           *cniTypesCurr.Result
@@ -682,6 +832,10 @@ type createEndpointInternalOpt struct {
 	enableInfraVnet  bool
 	enableSnatForDNS bool
 	natInfo          []policy.NATInfo
+
+	additionalEndpoints   []network.AdditionalEndpointInfo
+	isAdditionalInterface bool
+	sysctls               map[string]string
 }
 
 func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt) (network.EndpointInfo, error) {
@@ -706,6 +860,11 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 	}
 
 	vethName := fmt.Sprintf("%s.%s", opt.k8sNamespace, opt.k8sPodName)
+	if opt.isAdditionalInterface {
+		// The additional interfaces of a pod need veth pairs of their own.
+		vethName = fmt.Sprintf("%s.%s.%s", opt.k8sNamespace, opt.k8sPodName, opt.args.IfName)
+	}
+
 	if opt.nwCfg.Mode != opModeTransparent {
 		// this mechanism of using only namespace and name is not unique for different incarnations of POD/container.
 		// IT will result in unpredictable behavior if API server decides to
@@ -713,6 +872,12 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 		vethName = fmt.Sprintf("%s%s%s", opt.nwInfo.Id, opt.args.ContainerID, opt.args.IfName)
 	}
 
+	vxlanPeers, err := plugin.getVxlanPeers(opt.ctx, opt.nwCfg)
+	if err != nil {
+		err = plugin.Errorf("Failed to get vxlan peers: %v", err)
+		return epInfo, err
+	}
+
 	epInfo = network.EndpointInfo{
 		Id:                 opt.endpointID,
 		ContainerID:        opt.args.ContainerID,
@@ -733,6 +898,10 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 		VnetCidrs:          opt.nwCfg.VnetCidrs,
 		ServiceCidrs:       opt.nwCfg.ServiceCidrs,
 		NATInfo:            opt.natInfo,
+
+		AdditionalEndpoints: opt.additionalEndpoints,
+		Sysctls:             opt.sysctls,
+		VxlanPeers:          vxlanPeers,
 	}
 
 	epPolicies := getPoliciesFromRuntimeCfg(opt.nwCfg)
@@ -758,7 +927,8 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 		epInfo.InfraVnetIP = opt.azIpamResult.IPs[0].Address
 	}
 
-	if opt.nwCfg.MultiTenancy {
+	// The additional interfaces only get the routes of their NC, the default route stays on the primary interface.
+	if opt.nwCfg.MultiTenancy && !opt.isAdditionalInterface {
 		plugin.multitenancyClient.SetupRoutingForMultitenancy(opt.nwCfg, opt.cnsNetworkConfig, opt.azIpamResult, &epInfo, opt.result)
 	}
 
@@ -772,7 +942,7 @@ func (plugin *NetPlugin) createEndpointInternal(opt *createEndpointInternalOpt)
 
 	// Create the endpoint.
 	log.Printf("[cni-net] Creating endpoint %v.", epInfo.Id)
-	err = plugin.nm.CreateEndpoint(cnsclient, opt.nwInfo.Id, &epInfo)
+	err = plugin.nm.CreateEndpoint(opt.ctx, cnsclient, opt.nwInfo.Id, &epInfo)
 	if err != nil {
 		err = plugin.Errorf("Failed to create endpoint: %v", err)
 	}
@@ -824,9 +994,10 @@ func (plugin *NetPlugin) Get(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	configureLogging(nwCfg)
 	log.Printf("[cni-net] Read network configuration %+v.", nwCfg)
 
-	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+	configureIPTables(nwCfg)
 
 	// Parse Pod arguments.
 	if k8sPodName, k8sNamespace, err = plugin.getPodInfo(args.Args); err != nil {
@@ -906,15 +1077,29 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	configureLogging(nwCfg)
 	log.Printf("[cni-net] Read network configuration %+v.", nwCfg)
 
+	defer plugin.startTracing(nwCfg)()
+	ctx, span := tracing.Start(context.Background(), "cni.Delete", attribute.String("container.id", args.ContainerID))
+	defer func() { tracing.End(span, err) }()
+
 	// Parse Pod arguments.
 	if k8sPodName, k8sNamespace, err = plugin.getPodInfo(args.Args); err != nil {
 		log.Printf("[cni-net] Failed to get POD info due to error: %v", err)
 	}
 
+	// correlate the logs of this command across CNI and CNS.
+	ctx = log.WithCorrelation(ctx, log.Correlation{
+		RequestID:    log.NewRequestID(),
+		PodNamespace: k8sNamespace,
+		PodName:      k8sPodName,
+		ContainerID:  args.ContainerID,
+	})
+	log.Component("cni-net").WithContext(ctx).Info("Processing DEL command", "netns", args.Netns, "ifName", args.IfName)
+
 	plugin.setCNIReportDetails(nwCfg, CNI_DEL, "")
-	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+	configureIPTables(nwCfg)
 
 	sendMetricFunc := func() {
 		operationTimeMs := time.Since(startTime).Milliseconds()
@@ -944,13 +1129,13 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 			cnsURL := "http://localhost:" + strconv.Itoa(cnsPort)
 			cnsClient, er := cnscli.New(cnsURL, defaultRequestTimeout)
 			if err != nil {
-				log.Printf("[cni-net] failed to create cns client", networkId, err)
+				log.Printf("[cni-net] failed to create cns client for network %s: %v", networkId, er)
 				return fmt.Errorf("ailed to create cns client with err %w", er)
 			}
-			plugin.ipamInvoker = NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient)
+			plugin.ipamInvoker = instrumentedIPAMInvoker{NewCNSInvoker(k8sPodName, k8sNamespace, cnsClient), nwCfg.Ipam.Type, plugin.tb}
 
 		default:
-			plugin.ipamInvoker = NewAzureIpamInvoker(plugin, &nwInfo)
+			plugin.ipamInvoker = instrumentedIPAMInvoker{NewAzureIpamInvoker(plugin, &nwInfo), nwCfg.Ipam.Type, plugin.tb}
 		}
 	}
 	// Initialize values from network config.
@@ -974,7 +1159,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 		if !nwCfg.MultiTenancy {
 			// attempt to release address associated with this Endpoint id
 			// This is to ensure clean up is done even in failure cases
-			err = plugin.ipamInvoker.Delete(nil, nwCfg, args, nwInfo.Options)
+			err = plugin.ipamInvoker.Delete(ctx, nil, nwCfg, args, nwInfo.Options)
 			if err != nil {
 				log.Printf("Network not found, attempted to release address with error:  %v", err)
 			}
@@ -993,7 +1178,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 			// attempt to release address associated with this Endpoint id
 			// This is to ensure clean up is done even in failure cases
 			log.Printf("release ip ep not found")
-			if err = plugin.ipamInvoker.Delete(nil, nwCfg, args, nwInfo.Options); err != nil {
+			if err = plugin.ipamInvoker.Delete(ctx, nil, nwCfg, args, nwInfo.Options); err != nil {
 				log.Printf("Endpoint not found, attempted to release address with error: %v", err)
 			}
 		}
@@ -1012,18 +1197,31 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 
 	// schedule send metric before attempting delete
 	defer sendMetricFunc()
+
+	// The additional interfaces of the pod are deleted along with its primary interface.
+	if err = plugin.deleteAdditionalInterfaces(ctx, args, nwCfg, epInfo.AdditionalEndpoints); err != nil {
+		err = plugin.Errorf("Failed to delete additional interfaces: %v", err)
+		return err
+	}
+
 	// Delete the endpoint.
 	if err = plugin.nm.DeleteEndpoint(cnsclient, networkId, endpointId); err != nil {
 		err = plugin.Errorf("Failed to delete endpoint: %v", err)
 		return err
 	}
 
+	// The egress SNAT IP of the namespace is released with the last endpoint of the node SNATed to it. A failure
+	// does not fail the delete, which would not be retried for the deleted endpoint.
+	if er := plugin.releaseEgressSNATIP(ctx, nwCfg, epInfo.EgressSNATIP, k8sNamespace); er != nil {
+		log.Errorf("[cni-net] %v", er)
+	}
+
 	if !nwCfg.MultiTenancy {
 		log.Printf("epinfo:%+v", epInfo)
 		// Call into IPAM plugin to release the endpoint's addresses.
 		for _, address := range epInfo.IPAddresses {
 			log.Printf("release ip:%s", address.IP.String())
-			err = plugin.ipamInvoker.Delete(&address, nwCfg, args, nwInfo.Options)
+			err = plugin.ipamInvoker.Delete(ctx, &address, nwCfg, args, nwInfo.Options)
 			if err != nil {
 				err = plugin.Errorf("Failed to release address %v with error: %v", address, err)
 				return err
@@ -1032,7 +1230,7 @@ func (plugin *NetPlugin) Delete(args *cniSkel.CmdArgs) error {
 	} else if epInfo.EnableInfraVnet {
 		nwCfg.Ipam.Subnet = nwInfo.Subnets[0].Prefix.String()
 		nwCfg.Ipam.Address = epInfo.InfraVnetIP.IP.String()
-		err = plugin.ipamInvoker.Delete(nil, nwCfg, args, nwInfo.Options)
+		err = plugin.ipamInvoker.Delete(ctx, nil, nwCfg, args, nwInfo.Options)
 		if err != nil {
 			log.Printf("Failed to release address: %v", err)
 			err = plugin.Errorf("Failed to release address %v with error: %v", nwCfg.Ipam.Address, err)
@@ -1070,9 +1268,10 @@ func (plugin *NetPlugin) Update(args *cniSkel.CmdArgs) error {
 		return err
 	}
 
+	configureLogging(nwCfg)
 	log.Printf("[cni-net] Read network configuration %+v.", nwCfg)
 
-	iptables.DisableIPTableLock = nwCfg.DisableIPTableLock
+	configureIPTables(nwCfg)
 	plugin.setCNIReportDetails(nwCfg, CNI_UPDATE, "")
 
 	defer func() {
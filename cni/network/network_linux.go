@@ -22,6 +22,9 @@ const (
 
 const snatConfigFileName = "/tmp/snatConfig"
 
+// egressSNATSupported is set as the EgressSNAT policy is implemented on Linux.
+const egressSNATSupported = true
+
 // handleConsecutiveAdd is a dummy function for Linux platform.
 func (plugin *NetPlugin) handleConsecutiveAdd(args *cniSkel.CmdArgs, endpointID string, networkID string,
 	nwInfo *network.NetworkInfo, nwCfg *cni.NetworkConfig) (*cniTypesCurr.Result, error) {
@@ -3,10 +3,12 @@
 package network
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/network"
+	cniSkel "github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -160,3 +162,70 @@ func TestAddSnatForDns(t *testing.T) {
 		})
 	}
 }
+
+// Test that configured, runtime or pod sysctls outside the allow-list fail the add before an endpoint is created
+func TestPluginAddSysctls(t *testing.T) {
+	tests := []struct {
+		name           string
+		sysctls        map[string]string
+		runtimeSysctls map[string]string
+		podSysctls     map[string]string
+		wantErr        bool
+		wantErrMsg     string
+	}{
+		{
+			name:    "Allowed sysctls",
+			sysctls: map[string]string{"net.core.somaxconn": "4096", "net.ipv4.tcp_keepalive_time": "60"},
+			wantErr: false,
+		},
+		{
+			name:       "Sysctl not allowed",
+			sysctls:    map[string]string{"net.ipv4.ip_forward": "1"},
+			wantErr:    true,
+			wantErrMsg: "net.ipv4.ip_forward",
+		},
+		{
+			name:           "Runtime sysctl not allowed",
+			sysctls:        map[string]string{"net.core.somaxconn": "4096"},
+			runtimeSysctls: map[string]string{"net.ipv4.ip_forward": "1"},
+			wantErr:        true,
+			wantErrMsg:     "net.ipv4.ip_forward",
+		},
+		{
+			name:       "Pod sysctl not allowed",
+			sysctls:    map[string]string{"net.core.somaxconn": "4096"},
+			podSysctls: map[string]string{"net.ipv4.conf.eth0.rp_filter": "0"},
+			wantErr:    true,
+			wantErrMsg: "net.ipv4.conf.eth0.rp_filter",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := GetTestResources()
+			plugin.podSysctlsClient = &mockPodSysctlsClient{sysctls: tt.podSysctls}
+			localNwCfg := nwCfg
+			localNwCfg.Sysctls = tt.sysctls
+			localNwCfg.RuntimeConfig.Sysctls = tt.runtimeSysctls
+			args := &cniSkel.CmdArgs{
+				StdinData:   localNwCfg.Serialize(),
+				ContainerID: "test-container",
+				Netns:       "test-container",
+				Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+				IfName:      eth0IfName,
+			}
+
+			err := plugin.Add(args)
+			endpoints, _ := plugin.nm.GetAllEndpoints(localNwCfg.Name)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErrMsg)
+				require.Empty(t, endpoints)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, endpoints, 1)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
@@ -73,6 +74,7 @@ func GetTestResources() *NetPlugin {
 	mockNetworkManager := acnnetwork.NewMockNetworkmanager()
 	plugin.nm = mockNetworkManager
 	plugin.ipamInvoker = NewMockIpamInvoker(false, false, false)
+	plugin.podSysctlsClient = &mockPodSysctlsClient{}
 	return plugin
 }
 
@@ -452,22 +454,24 @@ func TestAddDualStack(t *testing.T) {
 		{
 			name: "Dualstack happy path",
 			plugin: &NetPlugin{
-				Plugin:      cniPlugin,
-				nm:          acnnetwork.NewMockNetworkmanager(),
-				ipamInvoker: NewMockIpamInvoker(true, false, false),
-				report:      &telemetry.CNIReport{},
-				tb:          &telemetry.TelemetryBuffer{},
+				Plugin:           cniPlugin,
+				nm:               acnnetwork.NewMockNetworkmanager(),
+				ipamInvoker:      NewMockIpamInvoker(true, false, false),
+				report:           &telemetry.CNIReport{},
+				tb:               &telemetry.TelemetryBuffer{},
+				podSysctlsClient: &mockPodSysctlsClient{},
 			},
 			wantErr: false,
 		},
 		{
 			name: "Dualstack ipv6 fail",
 			plugin: &NetPlugin{
-				Plugin:      cniPlugin,
-				nm:          acnnetwork.NewMockNetworkmanager(),
-				ipamInvoker: NewMockIpamInvoker(true, false, true),
-				report:      &telemetry.CNIReport{},
-				tb:          &telemetry.TelemetryBuffer{},
+				Plugin:           cniPlugin,
+				nm:               acnnetwork.NewMockNetworkmanager(),
+				ipamInvoker:      NewMockIpamInvoker(true, false, true),
+				report:           &telemetry.CNIReport{},
+				tb:               &telemetry.TelemetryBuffer{},
+				podSysctlsClient: &mockPodSysctlsClient{},
 			},
 			wantErr: true,
 		},
@@ -509,11 +513,12 @@ func TestPluginGet(t *testing.T) {
 			name:    "CNI Get happy path",
 			methods: []string{CNI_ADD, "GET"},
 			plugin: &NetPlugin{
-				Plugin:      plugin,
-				nm:          acnnetwork.NewMockNetworkmanager(),
-				ipamInvoker: NewMockIpamInvoker(false, false, false),
-				report:      &telemetry.CNIReport{},
-				tb:          &telemetry.TelemetryBuffer{},
+				Plugin:           plugin,
+				nm:               acnnetwork.NewMockNetworkmanager(),
+				ipamInvoker:      NewMockIpamInvoker(false, false, false),
+				report:           &telemetry.CNIReport{},
+				tb:               &telemetry.TelemetryBuffer{},
+				podSysctlsClient: &mockPodSysctlsClient{},
 			},
 			wantErr: false,
 		},
@@ -521,11 +526,12 @@ func TestPluginGet(t *testing.T) {
 			name:    "CNI Get fail with network not found",
 			methods: []string{"GET"},
 			plugin: &NetPlugin{
-				Plugin:      plugin,
-				nm:          acnnetwork.NewMockNetworkmanager(),
-				ipamInvoker: NewMockIpamInvoker(false, false, false),
-				report:      &telemetry.CNIReport{},
-				tb:          &telemetry.TelemetryBuffer{},
+				Plugin:           plugin,
+				nm:               acnnetwork.NewMockNetworkmanager(),
+				ipamInvoker:      NewMockIpamInvoker(false, false, false),
+				report:           &telemetry.CNIReport{},
+				tb:               &telemetry.TelemetryBuffer{},
+				podSysctlsClient: &mockPodSysctlsClient{},
 			},
 			wantErr:    true,
 			wantErrMsg: "Network not found",
@@ -534,11 +540,12 @@ func TestPluginGet(t *testing.T) {
 			name:    "CNI Get fail with endpoint not found",
 			methods: []string{CNI_ADD, CNI_DEL, "GET"},
 			plugin: &NetPlugin{
-				Plugin:      plugin,
-				nm:          acnnetwork.NewMockNetworkmanager(),
-				ipamInvoker: NewMockIpamInvoker(false, false, false),
-				report:      &telemetry.CNIReport{},
-				tb:          &telemetry.TelemetryBuffer{},
+				Plugin:           plugin,
+				nm:               acnnetwork.NewMockNetworkmanager(),
+				ipamInvoker:      NewMockIpamInvoker(false, false, false),
+				report:           &telemetry.CNIReport{},
+				tb:               &telemetry.TelemetryBuffer{},
+				podSysctlsClient: &mockPodSysctlsClient{},
 			},
 			wantErr:    true,
 			wantErrMsg: "Endpoint not found",
@@ -1005,10 +1012,10 @@ func TestGetAllEndpointState(t *testing.T) {
 	ep1 := getTestEndpoint("podname1", "podnamespace1", "10.0.0.1/24", "podinterfaceid1", "testcontainerid1")
 	ep2 := getTestEndpoint("podname2", "podnamespace2", "10.0.0.2/24", "podinterfaceid2", "testcontainerid2")
 
-	err := plugin.nm.CreateEndpoint(nil, networkid, ep1)
+	err := plugin.nm.CreateEndpoint(context.TODO(), nil, networkid, ep1)
 	require.NoError(t, err)
 
-	err = plugin.nm.CreateEndpoint(nil, networkid, ep2)
+	err = plugin.nm.CreateEndpoint(context.TODO(), nil, networkid, ep2)
 	require.NoError(t, err)
 
 	state, err := plugin.GetAllEndpointState(networkid)
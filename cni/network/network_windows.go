@@ -26,6 +26,9 @@ import (
 	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
 )
 
+// egressSNATSupported is not set as the EgressSNAT policy is implemented on Linux only.
+const egressSNATSupported = false
+
 var (
 	snatConfigFileName = filepath.FromSlash(os.Getenv("TEMP")) + "\\snatConfig"
 	// windows build for version 1903
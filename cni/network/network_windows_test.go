@@ -88,6 +88,7 @@ func TestPluginSecondAddSamePodWindows(t *testing.T) {
 				hnsEndpointClient: network.NewMockHNSEndpoint(true, false),
 				report:            &telemetry.CNIReport{},
 				tb:                &telemetry.TelemetryBuffer{},
+				podSysctlsClient:  &mockPodSysctlsClient{},
 			},
 			wantErr: false,
 		},
@@ -108,6 +109,7 @@ func TestPluginSecondAddSamePodWindows(t *testing.T) {
 				hnsEndpointClient: network.NewMockHNSEndpoint(false, false),
 				report:            &telemetry.CNIReport{},
 				tb:                &telemetry.TelemetryBuffer{},
+				podSysctlsClient:  &mockPodSysctlsClient{},
 			},
 			wantErr: false,
 		},
@@ -241,3 +243,23 @@ func TestSetPoliciesFromNwCfg(t *testing.T) {
 		})
 	}
 }
+
+// Test that sysctls fail the add, as they are not supported on Windows
+func TestPluginAddSysctlsNotSupported(t *testing.T) {
+	plugin := GetTestResources()
+	localNwCfg := nwCfg
+	localNwCfg.Sysctls = map[string]string{"net.core.somaxconn": "4096"}
+	args := &skel.CmdArgs{
+		StdinData:   localNwCfg.Serialize(),
+		ContainerID: "test-container",
+		Netns:       "test-container",
+		Args:        fmt.Sprintf("K8S_POD_NAME=%v;K8S_POD_NAMESPACE=%v", "test-pod", "test-pod-ns"),
+		IfName:      eth0IfName,
+	}
+
+	err := plugin.Add(args)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sysctls are not supported")
+	endpoints, _ := plugin.nm.GetAllEndpoints(localNwCfg.Name)
+	require.Empty(t, endpoints)
+}
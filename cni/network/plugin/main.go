@@ -5,6 +5,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-container-networking/cni"
+	"github.com/Azure/azure-container-networking/cni/api"
 	"github.com/Azure/azure-container-networking/cni/network"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
@@ -20,6 +22,7 @@ import (
 	"github.com/Azure/azure-container-networking/platform"
 	"github.com/Azure/azure-container-networking/telemetry"
 	"github.com/containernetworking/cni/pkg/skel"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
 )
 
 const (
@@ -54,6 +57,10 @@ func printVersion() {
 func reportPluginError(reportManager *telemetry.ReportManager, tb *telemetry.TelemetryBuffer, err error) {
 	log.Printf("Report plugin error")
 	reflect.ValueOf(reportManager.Report).Elem().FieldByName("ErrorMessage").SetString(err.Error())
+	var cniErr *cniTypes.Error
+	if errors.As(err, &cniErr) {
+		reflect.ValueOf(reportManager.Report).Elem().FieldByName("ErrorCode").SetUint(uint64(cniErr.Code))
+	}
 
 	if err := reportManager.SendReport(tb); err != nil {
 		log.Errorf("SendReport failed due to %v", err)
@@ -124,6 +131,25 @@ func handleIfCniUpdate(update func(*skel.CmdArgs) error) (bool, error) {
 	return isupdate, nil
 }
 
+// handleEndpointMirror reads the endpoint mirror request from stdin, and adds or deletes the mirror.
+func handleEndpointMirror(netPlugin *network.NetPlugin, cniCmd string) error {
+	stdinData, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("error reading from stdin: %w", err)
+	}
+
+	var req api.EndpointMirrorRequest
+	if err = json.Unmarshal(stdinData, &req); err != nil {
+		return fmt.Errorf("error reading endpoint mirror request: %w", err)
+	}
+
+	if cniCmd == cni.CmdAddEndpointMirror {
+		return netPlugin.AddEndpointMirror(&req)
+	}
+
+	return netPlugin.DeleteEndpointMirror(&req)
+}
+
 // Main is the entry point for CNI network plugin.
 func main() {
 	startTime := time.Now()
@@ -196,10 +222,12 @@ func main() {
 		if err = netPlugin.Plugin.InitializeKeyValueStore(&config); err != nil {
 			log.Errorf("Failed to initialize key-value store of network plugin, err:%v.\n", err)
 			tb := telemetry.NewTelemetryBuffer()
-			if tberr := tb.Connect(); tberr == nil {
-				reportPluginError(reportManager, tb, err)
-				tb.Close()
+			tb.SetSpool(telemetry.NewSpool(telemetry.DefaultSpoolPath(), telemetry.DefaultSpoolMaxSizeInBytes, telemetry.DefaultSpoolMaxAge))
+			if tberr := tb.Connect(); tberr != nil {
+				log.Printf("Failed to connect to telemetry service, spooling report: %v", tberr)
 			}
+			reportPluginError(reportManager, tb, err)
+			tb.Close()
 
 			if isSafe, _ := netPlugin.Plugin.IsSafeToRemoveLock(name); isSafe {
 				log.Printf("[CNI] Removing lock file as process holding lock exited")
@@ -224,6 +252,7 @@ func main() {
 		// Start telemetry process if not already started. This should be done inside lock, otherwise multiple process
 		// end up creating/killing telemetry process results in undesired state.
 		tb = telemetry.NewTelemetryBuffer()
+		tb.SetSpool(telemetry.NewSpool(telemetry.DefaultSpoolPath(), telemetry.DefaultSpoolMaxSizeInBytes, telemetry.DefaultSpoolMaxAge))
 		tb.ConnectToTelemetryService(telemetryNumRetries, telemetryWaitTimeInMilliseconds)
 		defer tb.Close()
 
@@ -254,6 +283,21 @@ func main() {
 
 			return
 		}
+
+		// used to mirror the traffic of an endpoint
+		if cniCmd == cni.CmdAddEndpointMirror || cniCmd == cni.CmdDeleteEndpointMirror {
+			log.Printf("Handling %s", cniCmd)
+			if err = handleEndpointMirror(netPlugin, cniCmd); err != nil {
+				log.Errorf("Failed to handle %s, err:%v.\n", cniCmd, err)
+				cniErr := &cniTypes.Error{Code: cni.ErrRuntime, Msg: err.Error()}
+				if printErr := cniErr.Print(); printErr != nil {
+					log.Errorf("Failed to print error to stdout with err %v\n", printErr)
+				}
+			}
+
+			netPlugin.Stop()
+			return
+		}
 	}
 
 	handled, err := handleIfCniUpdate(netPlugin.Update)
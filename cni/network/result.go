@@ -0,0 +1,71 @@
+package network
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network"
+	cniTypes "github.com/containernetworking/cni/pkg/types"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
+)
+
+// interfaceWithMTU is an interface of the CNI result along with its MTU, which the interface of the CNI types
+// does not report.
+type interfaceWithMTU struct {
+	cniTypesCurr.Interface
+	Mtu int `json:"mtu,omitempty"`
+}
+
+// resultWithMTU is a CNI result whose interfaces report their MTU.
+type resultWithMTU struct {
+	CNIVersion string                   `json:"cniVersion,omitempty"`
+	Interfaces []*interfaceWithMTU      `json:"interfaces,omitempty"`
+	IPs        []*cniTypesCurr.IPConfig `json:"ips,omitempty"`
+	Routes     []*cniTypes.Route        `json:"routes,omitempty"`
+	DNS        cniTypes.DNS             `json:"dns,omitempty"`
+}
+
+// getInterfaceMTUs returns the MTUs of the interfaces of the pod by name, the MTUs of their networks.
+func (plugin *NetPlugin) getInterfaceMTUs(ifName, networkID string, additionalEndpoints []network.AdditionalEndpointInfo) map[string]int {
+	mtus := make(map[string]int)
+	if nwInfo, err := plugin.nm.GetNetworkInfo(networkID); err == nil && nwInfo.MTU != 0 {
+		mtus[ifName] = nwInfo.MTU
+	}
+
+	for _, endpoint := range additionalEndpoints {
+		if nwInfo, err := plugin.nm.GetNetworkInfo(endpoint.NetworkID); err == nil && nwInfo.MTU != 0 {
+			mtus[endpoint.IfName] = nwInfo.MTU
+		}
+	}
+
+	return mtus
+}
+
+// printResult writes the result in the requested CNI version. The interfaces of the versions reporting them carry
+// their MTU.
+func printResult(w io.Writer, res cniTypes.Result, mtus map[string]int) error {
+	result, ok := res.(*cniTypesCurr.Result)
+	if !ok || len(mtus) == 0 {
+		return res.PrintTo(w)
+	}
+
+	resWithMTU := resultWithMTU{
+		CNIVersion: result.CNIVersion,
+		IPs:        result.IPs,
+		Routes:     result.Routes,
+		DNS:        result.DNS,
+	}
+	for _, iface := range result.Interfaces {
+		resWithMTU.Interfaces = append(resWithMTU.Interfaces, &interfaceWithMTU{Interface: *iface, Mtu: mtus[iface.Name]})
+	}
+
+	data, err := json.MarshalIndent(resWithMTU, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[cni-net] Reporting interface MTUs %v.", mtus)
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/network"
+	cniTypesCurr "github.com/containernetworking/cni/pkg/types/current"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintResultWithMTU(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.240.0.4/16")
+	result := &cniTypesCurr.Result{
+		CNIVersion: "0.3.0",
+		Interfaces: []*cniTypesCurr.Interface{{Name: "eth0"}, {Name: "eth1"}},
+		IPs:        []*cniTypesCurr.IPConfig{{Version: "4", Address: *ipNet, Interface: cniTypesCurr.Int(0)}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printResult(&buf, result, map[string]int{"eth0": 1450}))
+
+	var printed struct {
+		CNIVersion string `json:"cniVersion"`
+		Interfaces []struct {
+			Name string `json:"name"`
+			Mtu  int    `json:"mtu"`
+		} `json:"interfaces"`
+		IPs []json.RawMessage `json:"ips"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+	require.Equal(t, "0.3.0", printed.CNIVersion)
+	require.Len(t, printed.Interfaces, 2)
+	require.Equal(t, "eth0", printed.Interfaces[0].Name)
+	require.Equal(t, 1450, printed.Interfaces[0].Mtu)
+	require.Zero(t, printed.Interfaces[1].Mtu)
+	require.Len(t, printed.IPs, 1)
+
+	// The result is printed as is without MTUs.
+	buf.Reset()
+	require.NoError(t, printResult(&buf, result, nil))
+	require.NotContains(t, buf.String(), "mtu")
+}
+
+func TestGetInterfaceMTUs(t *testing.T) {
+	plugin := GetTestResources()
+	require.NoError(t, plugin.nm.CreateNetwork(&network.NetworkInfo{Id: "net", MTU: 1450}))
+	require.NoError(t, plugin.nm.CreateNetwork(&network.NetworkInfo{Id: "net1"}))
+	require.NoError(t, plugin.nm.CreateNetwork(&network.NetworkInfo{Id: "net2", MTU: 9000}))
+
+	mtus := plugin.getInterfaceMTUs("eth0", "net", []network.AdditionalEndpointInfo{
+		{NetworkID: "net1", IfName: "eth1"},
+		{NetworkID: "net2", IfName: "eth2"},
+	})
+	require.Equal(t, map[string]int{"eth0": 1450, "eth2": 9000}, mtus)
+}
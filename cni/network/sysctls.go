@@ -0,0 +1,66 @@
+package network
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network"
+)
+
+// podSysctlsClient gets the sysctls pods request in their annotation from CNS.
+type podSysctlsClient interface {
+	GetPodSysctls(ctx context.Context, namespace, name string) (map[string]string, error)
+}
+
+// getSysctls returns the sysctls to apply in the network namespace of the pod, the configured ones overridden by
+// those passed at runtime. When CNS is the IPAM, the sysctls the pod requests in its annotation, which CNS reads from
+// the API server, override both.
+func (plugin *NetPlugin) getSysctls(ctx context.Context, nwCfg *cni.NetworkConfig, podName, podNamespace string) (map[string]string, error) {
+	if nwCfg.Ipam.Type != network.AzureCNS {
+		return cni.GetSysctlsFromNwCfg(nwCfg), nil
+	}
+
+	if plugin.podSysctlsClient == nil {
+		cnsClient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cns client: %w", err)
+		}
+		plugin.podSysctlsClient = cnsClient
+	}
+
+	return getSysctls(ctx, plugin.podSysctlsClient, nwCfg, podName, podNamespace)
+}
+
+func getSysctls(ctx context.Context, cnsClient podSysctlsClient, nwCfg *cni.NetworkConfig, podName, podNamespace string) (map[string]string, error) {
+	podSysctls, err := cnsClient.GetPodSysctls(ctx, podNamespace, podName)
+	if err != nil {
+		// CNS only reads pods from the API server in CRD mode, and older versions do not serve the sysctls of pods.
+		if !cnscli.IsUnsupported(err) {
+			return nil, fmt.Errorf("failed to get the sysctls of pod %s/%s: %w", podNamespace, podName, err)
+		}
+		log.Printf("[cni-net] CNS does not support the sysctls of pods, err:%v.", err)
+	}
+
+	return mergeSysctls(cni.GetSysctlsFromNwCfg(nwCfg), podSysctls), nil
+}
+
+// mergeSysctls returns the sysctls of the network config overridden by those of the pod, nil if there are none.
+func mergeSysctls(configured, pod map[string]string) map[string]string {
+	if len(configured) == 0 && len(pod) == 0 {
+		return nil
+	}
+
+	sysctls := make(map[string]string, len(configured)+len(pod))
+	for key, value := range configured {
+		sysctls[key] = value
+	}
+
+	for key, value := range pod {
+		sysctls[key] = value
+	}
+
+	return sysctls
+}
@@ -0,0 +1,109 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockPodSysctls = errors.New("mock pod sysctls error")
+
+type mockPodSysctlsClient struct {
+	sysctls map[string]string
+	err     error
+}
+
+func (c *mockPodSysctlsClient) GetPodSysctls(_ context.Context, _, _ string) (map[string]string, error) {
+	return c.sysctls, c.err
+}
+
+func TestGetSysctls(t *testing.T) {
+	tests := []struct {
+		name        string
+		configured  map[string]string
+		runtime     map[string]string
+		client      *mockPodSysctlsClient
+		wantSysctls map[string]string
+		wantErr     error
+	}{
+		{
+			name:        "No sysctls",
+			client:      &mockPodSysctlsClient{},
+			wantSysctls: nil,
+		},
+		{
+			name:        "Configured sysctls",
+			configured:  map[string]string{"net.core.somaxconn": "1024"},
+			client:      &mockPodSysctlsClient{},
+			wantSysctls: map[string]string{"net.core.somaxconn": "1024"},
+		},
+		{
+			name:       "Pod sysctls override the configured ones",
+			configured: map[string]string{"net.core.somaxconn": "1024", "net.ipv4.tcp_syncookies": "1"},
+			client:     &mockPodSysctlsClient{sysctls: map[string]string{"net.core.somaxconn": "4096"}},
+			wantSysctls: map[string]string{
+				"net.core.somaxconn":      "4096",
+				"net.ipv4.tcp_syncookies": "1",
+			},
+		},
+		{
+			name:        "Runtime sysctls override the configured ones",
+			configured:  map[string]string{"net.core.somaxconn": "1024"},
+			runtime:     map[string]string{"net.core.somaxconn": "2048"},
+			client:      &mockPodSysctlsClient{},
+			wantSysctls: map[string]string{"net.core.somaxconn": "2048"},
+		},
+		{
+			name:       "Pod sysctls override the runtime ones",
+			configured: map[string]string{"net.core.somaxconn": "1024"},
+			runtime:    map[string]string{"net.core.somaxconn": "2048", "net.ipv4.tcp_syncookies": "1"},
+			client:     &mockPodSysctlsClient{sysctls: map[string]string{"net.core.somaxconn": "4096"}},
+			wantSysctls: map[string]string{
+				"net.core.somaxconn":      "4096",
+				"net.ipv4.tcp_syncookies": "1",
+			},
+		},
+		{
+			name:       "CNS does not support pod sysctls",
+			configured: map[string]string{"net.core.somaxconn": "1024"},
+			client: &mockPodSysctlsClient{err: &cnscli.CNSClientError{
+				Code: types.UnsupportedEnvironment,
+				Err:  errMockPodSysctls,
+			}},
+			wantSysctls: map[string]string{"net.core.somaxconn": "1024"},
+		},
+		{
+			name: "CNS does not serve pod sysctls",
+			client: &mockPodSysctlsClient{err: &cnscli.CNSClientError{
+				Code: types.UnsupportedVerb,
+				Err:  errMockPodSysctls,
+			}},
+			wantSysctls: nil,
+		},
+		{
+			name:    "CNS failure",
+			client:  &mockPodSysctlsClient{err: errMockPodSysctls},
+			wantErr: errMockPodSysctls,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nwCfg := &cni.NetworkConfig{Sysctls: tt.configured}
+			nwCfg.RuntimeConfig.Sysctls = tt.runtime
+			sysctls, err := getSysctls(context.Background(), tt.client, nwCfg, "test-pod", "test-pod-ns")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSysctls, sysctls)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package network
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/cni"
+	cnscli "github.com/Azure/azure-container-networking/cns/client"
+)
+
+// vxlanPeersClient gets the peers of the VXLAN overlay from CNS.
+type vxlanPeersClient interface {
+	GetVxlanPeers(ctx context.Context) ([]string, error)
+}
+
+// getVxlanPeers returns the peers of the VXLAN overlay which CNS lists from the nodes of the cluster, nil unless the
+// tunnel mode network takes its peers from CNS. The configured peers are added by the network.
+func (plugin *NetPlugin) getVxlanPeers(ctx context.Context, nwCfg *cni.NetworkConfig) ([]string, error) {
+	if nwCfg.Mode != opModeTunnel || nwCfg.Vxlan == nil || !nwCfg.Vxlan.PeersFromCNS {
+		return nil, nil
+	}
+
+	if plugin.vxlanPeersClient == nil {
+		cnsClient, err := cnscli.New(nwCfg.CNSUrl, defaultRequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cns client: %w", err)
+		}
+		plugin.vxlanPeersClient = cnsClient
+	}
+
+	peers, err := plugin.vxlanPeersClient.GetVxlanPeers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the vxlan peers from cns: %w", err)
+	}
+
+	return peers, nil
+}
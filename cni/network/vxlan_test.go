@@ -0,0 +1,70 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cni"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockVxlanPeers = errors.New("mock vxlan peers error")
+
+type mockVxlanPeersClient struct {
+	peers []string
+	err   error
+}
+
+func (c *mockVxlanPeersClient) GetVxlanPeers(_ context.Context) ([]string, error) {
+	return c.peers, c.err
+}
+
+func TestGetVxlanPeers(t *testing.T) {
+	tests := []struct {
+		name      string
+		nwCfg     *cni.NetworkConfig
+		client    *mockVxlanPeersClient
+		wantPeers []string
+		wantErr   error
+	}{
+		{
+			name:      "Peers from CNS",
+			nwCfg:     &cni.NetworkConfig{Mode: opModeTunnel, Vxlan: &cni.VxlanConfig{VNI: 4096, PeersFromCNS: true}},
+			client:    &mockVxlanPeersClient{peers: []string{"10.240.0.4", "10.240.0.5"}},
+			wantPeers: []string{"10.240.0.4", "10.240.0.5"},
+		},
+		{
+			name:    "CNS failure",
+			nwCfg:   &cni.NetworkConfig{Mode: opModeTunnel, Vxlan: &cni.VxlanConfig{VNI: 4096, PeersFromCNS: true}},
+			client:  &mockVxlanPeersClient{err: errMockVxlanPeers},
+			wantErr: errMockVxlanPeers,
+		},
+		{
+			name:   "Configured peers only",
+			nwCfg:  &cni.NetworkConfig{Mode: opModeTunnel, Vxlan: &cni.VxlanConfig{VNI: 4096, Peers: []string{"10.240.0.4"}}},
+			client: &mockVxlanPeersClient{err: errMockVxlanPeers},
+		},
+		{
+			name:   "Bridge mode",
+			nwCfg:  &cni.NetworkConfig{Mode: "bridge"},
+			client: &mockVxlanPeersClient{err: errMockVxlanPeers},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := GetTestResources()
+			plugin.vxlanPeersClient = tt.client
+
+			peers, err := plugin.getVxlanPeers(context.Background(), tt.nwCfg)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPeers, peers)
+		})
+	}
+}
@@ -21,6 +21,7 @@ const (
 	defaultBatchIntervalInSecs        = 15
 	defaultGetEnvRetryCount           = 2
 	defaultGetEnvRetryWaitTimeInSecs  = 3
+	defaultSpoolMaxAgeInSecs          = 3600
 	pluginName                        = "AzureCNI"
 	azureVnetTelemetry                = "azure-vnet-telemetry"
 	configExtension                   = ".config"
@@ -107,6 +108,10 @@ func setTelemetryDefaults(config *telemetry.TelemetryConfig) {
 	if config.GetEnvRetryWaitTimeInSecs == 0 {
 		config.GetEnvRetryWaitTimeInSecs = defaultGetEnvRetryWaitTimeInSecs
 	}
+
+	if config.SpoolMaxAgeInSecs == 0 {
+		config.SpoolMaxAgeInSecs = defaultSpoolMaxAgeInSecs
+	}
 }
 
 func main() {
@@ -186,9 +191,23 @@ func main() {
 		GetEnvRetryWaitTimeInSecs:    config.GetEnvRetryWaitTimeInSecs,
 	}
 
-	err = telemetry.CreateAITelemetryHandle(aiConfig, config.DisableAll, config.DisableTrace, config.DisableMetric)
+	err = telemetry.CreateAITelemetryHandle(aiConfig, config.Sinks, config.DisableAll, config.DisableTrace, config.DisableMetric)
 	log.Printf("[Telemetry] AI Handle creation status:%v", err)
 	log.Logf("[Telemetry] Report to host for an interval of %d seconds", config.ReportToHostIntervalInSeconds)
+
+	if config.MetricsBindAddress != "" {
+		go func() {
+			if err := telemetry.ServeMetrics(config.MetricsBindAddress); err != nil {
+				log.Errorf("[Telemetry] Metrics server failed: %v", err)
+			}
+		}()
+	}
+
+	// Recover the reports which CNI spooled while the service was down.
+	spool := telemetry.NewSpool(telemetry.DefaultSpoolPath(), telemetry.DefaultSpoolMaxSizeInBytes,
+		time.Duration(config.SpoolMaxAgeInSecs)*time.Second)
+	go tb.DrainSpool(spool)
+
 	tb.PushData()
 	telemetry.CloseAITelemetryHandle()
 
@@ -4,6 +4,7 @@
 package network
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"time"
@@ -244,9 +245,9 @@ func (plugin *netPlugin) createEndpoint(w http.ResponseWriter, r *http.Request)
 
 	cnscli, err := cnsclient.New("", defaultCNSTimeout)
 	if err != nil {
-		log.Errorf("failed to init CNS client", err)
+		log.Errorf("failed to init CNS client: %v", err)
 	}
-	err = plugin.nm.CreateEndpoint(cnscli, req.NetworkID, &epInfo)
+	err = plugin.nm.CreateEndpoint(context.TODO(), cnscli, req.NetworkID, &epInfo)
 	if err != nil {
 		plugin.SendErrorResponse(w, err)
 		return
@@ -273,7 +274,7 @@ func (plugin *netPlugin) deleteEndpoint(w http.ResponseWriter, r *http.Request)
 
 	cnscli, err := cnsclient.New("", defaultCNSTimeout)
 	if err != nil {
-		log.Errorf("failed to init CNS client", err)
+		log.Errorf("failed to init CNS client: %v", err)
 	}
 	// Process request.
 	err = plugin.nm.DeleteEndpoint(cnscli, req.NetworkID, req.EndpointID)
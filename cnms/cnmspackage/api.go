@@ -1,9 +1,41 @@
 package cnms
 
-import "github.com/Azure/azure-container-networking/telemetry"
+import (
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/telemetry"
+)
 
 type NetworkMonitor struct {
-	AddRulesToBeValidated    map[string]int
-	DeleteRulesToBeValidated map[string]int
-	CNIReport                *telemetry.CNIReport
+	AddRulesToBeValidated         map[string]int
+	DeleteRulesToBeValidated      map[string]int
+	AddIptablesRulesToBeValidated map[string]int
+	CNIReport                     *telemetry.CNIReport
+	// Diff records the rules changed by the current monitor pass.
+	Diff Diff
+	// IptablesRunner runs iptables commands, defaults to iptables.RunCmd.
+	IptablesRunner func(version, params string) error
+}
+
+// Diff is the set of rules a monitor pass programmed to bring the dataplane back in line with state.
+type Diff struct {
+	EbtablesAdded   []string
+	EbtablesRemoved []string
+	IptablesAdded   []string
+}
+
+// Empty returns true if the pass did not change any rules.
+func (d Diff) Empty() bool {
+	return len(d.EbtablesAdded) == 0 && len(d.EbtablesRemoved) == 0 && len(d.IptablesAdded) == 0
+}
+
+// ResetDiff clears the diff before a new monitor pass.
+func (networkMonitor *NetworkMonitor) ResetDiff() {
+	networkMonitor.Diff = Diff{}
+}
+
+func (networkMonitor *NetworkMonitor) runIptables(version, params string) error {
+	if networkMonitor.IptablesRunner != nil {
+		return networkMonitor.IptablesRunner(version, params)
+	}
+	return iptables.RunCmd(version, params)
 }
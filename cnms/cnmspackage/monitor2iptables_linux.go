@@ -0,0 +1,64 @@
+package cnms
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/log"
+)
+
+// CreateRequiredIptablesRules re-programs chains and rules that exist in state but are missing from iptables.
+// Like the ebtables rules, an entry has to be missing for more than one iteration before it is re-added, so
+// that rules being programmed by an in-flight CNI invocation are not touched.
+// Rules are only ever added: iptables chains are shared with kube-proxy and NPM, so unknown rules are left alone.
+func (networkMonitor *NetworkMonitor) CreateRequiredIptablesRules(stateRules []iptables.IPTableEntry) {
+	if networkMonitor.AddIptablesRulesToBeValidated == nil {
+		networkMonitor.AddIptablesRulesToBeValidated = make(map[string]int)
+	}
+
+	current := make(map[string]bool, len(stateRules))
+	for _, entry := range stateRules {
+		key := iptablesRuleKey(entry)
+		current[key] = true
+
+		checkCmd, ok := iptables.GetCheckCmd(entry)
+		if !ok {
+			continue
+		}
+
+		if err := networkMonitor.runIptables(checkCmd.Version, checkCmd.Params); err == nil {
+			delete(networkMonitor.AddIptablesRulesToBeValidated, key)
+			continue
+		}
+
+		itr, ok := networkMonitor.AddIptablesRulesToBeValidated[key]
+		if !ok || itr == 0 {
+			log.Printf("[ADD] Found missing iptables rule %v itr %d. Giving one more iteration.", key, itr)
+			networkMonitor.AddIptablesRulesToBeValidated[key] = itr + 1
+			continue
+		}
+
+		buf := fmt.Sprintf("[monitor] Adding iptables rule as it existed in state but not in iptables for %d iterations: %v", itr, key)
+		if err := networkMonitor.runIptables(entry.Version, entry.Params); err != nil {
+			buf = fmt.Sprintf("[monitor] Error while adding iptables rule %v: %v", key, err)
+		} else {
+			networkMonitor.Diff.IptablesAdded = append(networkMonitor.Diff.IptablesAdded, key)
+		}
+
+		log.Printf(buf)
+		networkMonitor.CNIReport.ErrorMessage = buf
+		networkMonitor.CNIReport.OperationType = "IPTableAdd"
+		delete(networkMonitor.AddIptablesRulesToBeValidated, key)
+	}
+
+	// forget rules that are no longer in state
+	for key := range networkMonitor.AddIptablesRulesToBeValidated {
+		if !current[key] {
+			delete(networkMonitor.AddIptablesRulesToBeValidated, key)
+		}
+	}
+}
+
+func iptablesRuleKey(entry iptables.IPTableEntry) string {
+	return fmt.Sprintf("ipv%s %s", entry.Version, entry.Params)
+}
@@ -18,6 +18,8 @@ func (networkMonitor *NetworkMonitor) deleteRulesNotExistInMap(chainRules map[st
 				buf := fmt.Sprintf("[monitor] Deleting Ebtable rule as it didn't exist in state for %d iterations chain %v rule %v", itr, chain, rule)
 				if err := ebtables.SetEbRule(table, action, chain, rule); err != nil {
 					buf = fmt.Sprintf("[monitor] Error while deleting ebtable rule %v", err)
+				} else {
+					networkMonitor.Diff.EbtablesRemoved = append(networkMonitor.Diff.EbtablesRemoved, rule)
 				}
 
 				log.Printf(buf)
@@ -46,6 +48,8 @@ func (networkMonitor *NetworkMonitor) addRulesNotExistInMap(
 				buf := fmt.Sprintf("[monitor] Adding Ebtable rule as it existed in state rules but not in current chain rules for %d iterations chain %v rule %v", itr, chain, rule)
 				if err := ebtables.SetEbRule(table, action, chain, rule); err != nil {
 					buf = fmt.Sprintf("[monitor] Error while adding ebtable rule %v", err)
+				} else {
+					networkMonitor.Diff.EbtablesAdded = append(networkMonitor.Diff.EbtablesAdded, rule)
 				}
 
 				log.Printf(buf)
@@ -0,0 +1,50 @@
+package reconciler
+
+import (
+	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	tableLabel    = "table"
+	ebtablesTable = "ebtables"
+	iptablesTable = "iptables"
+)
+
+var (
+	rulesAdded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_monitor_rules_added_total",
+			Help: "Rules re-added because they were in CNI state but missing from the dataplane.",
+		},
+		[]string{tableLabel},
+	)
+	rulesRemoved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_monitor_rules_removed_total",
+			Help: "Rules removed because they were in the dataplane but not in CNI state.",
+		},
+		[]string{tableLabel},
+	)
+	reconcileFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "network_monitor_reconcile_failures_total",
+			Help: "Reconcile passes which failed.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		rulesAdded,
+		rulesRemoved,
+		reconcileFailures,
+	)
+}
+
+func recordDiff(diff cnms.Diff) {
+	rulesAdded.WithLabelValues(ebtablesTable).Add(float64(len(diff.EbtablesAdded)))
+	rulesRemoved.WithLabelValues(ebtablesTable).Add(float64(len(diff.EbtablesRemoved)))
+	rulesAdded.WithLabelValues(iptablesTable).Add(float64(len(diff.IptablesAdded)))
+}
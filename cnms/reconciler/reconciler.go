@@ -0,0 +1,189 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
+	acn "github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultInterval is the time between reconcile passes when none is configured.
+	DefaultInterval = 10 * time.Second
+	// DefaultStateFilePath is the CNI state file the expected rules are computed from.
+	DefaultStateFilePath = platform.CNIRuntimePath + "azure-vnet.json"
+)
+
+// Config configures the Reconciler.
+type Config struct {
+	// Interval between reconcile passes.
+	Interval time.Duration
+	// StateFilePath is the path of the CNI state file.
+	StateFilePath string
+	// Version of the hosting component, recorded in the network manager.
+	Version string
+}
+
+// ManagerFactory creates a network manager initialized from the CNI state file.
+type ManagerFactory func(config Config) (network.NetworkManager, error)
+
+// Reconciler periodically compares the ebtables and iptables rules the CNI programmed against
+// the CNI state file and repairs any drift. It also deletes the endpoint mirrors past their
+// expiry, which the CNI only removes when it next runs. It is the loop the standalone
+// azure-cnimonitor service runs, packaged so other components such as CNS can host it.
+type Reconciler struct {
+	config     Config
+	monitor    *cnms.NetworkMonitor
+	newManager ManagerFactory
+	// deleteExpiredMirrors deletes the expired endpoint mirrors from the CNI state file.
+	deleteExpiredMirrors func(config Config) error
+	// OnDiff is called after every pass that changed rules or reported a discrepancy.
+	OnDiff func(cnms.Diff, *telemetry.CNIReport)
+}
+
+// New creates a Reconciler that reports discrepancies into report.
+func New(config Config, report *telemetry.CNIReport) *Reconciler {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.StateFilePath == "" {
+		config.StateFilePath = DefaultStateFilePath
+	}
+	if report == nil {
+		report = &telemetry.CNIReport{}
+	}
+
+	return &Reconciler{
+		config: config,
+		monitor: &cnms.NetworkMonitor{
+			AddRulesToBeValidated:         make(map[string]int),
+			DeleteRulesToBeValidated:      make(map[string]int),
+			AddIptablesRulesToBeValidated: make(map[string]int),
+			CNIReport:                     report,
+		},
+		newManager:           newStateFileManager,
+		deleteExpiredMirrors: deleteExpiredStateFileMirrors,
+	}
+}
+
+// newStateFileManager loads a fresh network manager from the CNI state file.
+func newStateFileManager(config Config) (network.NetworkManager, error) {
+	kvs, err := store.NewJsonFileStore(config.StateFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create store")
+	}
+
+	return newStoreManager(config, kvs)
+}
+
+// deleteExpiredStateFileMirrors deletes the expired endpoint mirrors holding the lock of the CNI state file,
+// so that the state written back does not overwrite a concurrent CNI invocation.
+func deleteExpiredStateFileMirrors(config Config) error {
+	kvs, err := store.NewJsonFileStore(config.StateFilePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to create store")
+	}
+
+	if err = kvs.Lock(true); err != nil {
+		return errors.Wrap(err, "failed to lock store")
+	}
+	defer func() {
+		if err := kvs.Unlock(false); err != nil {
+			log.Errorf("[monitor] Failed to unlock store: %v", err)
+		}
+	}()
+
+	// The state is read again under the lock, the CNI may have changed it since the pass loaded it.
+	nm, err := newStoreManager(config, kvs)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(nm.DeleteExpiredEndpointMirrors(), "failed to delete expired endpoint mirrors")
+}
+
+// newStoreManager creates a network manager initialized from the store.
+func newStoreManager(config Config, kvs store.KeyValueStore) (network.NetworkManager, error) {
+	nm, err := network.NewNetworkManager(netlink.NewNetlink(), platform.NewExecClient(), &netio.NetIO{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create network manager")
+	}
+
+	pluginConfig := acn.PluginConfig{
+		Version: config.Version,
+		Store:   kvs,
+	}
+	if err := nm.Initialize(&pluginConfig, false); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize network manager")
+	}
+
+	return nm, nil
+}
+
+// ReconcileOnce runs a single pass and returns the rules it changed.
+func (r *Reconciler) ReconcileOnce() (cnms.Diff, error) {
+	r.monitor.ResetDiff()
+	r.monitor.CNIReport.ErrorMessage = ""
+
+	nm, err := r.newManager(r.config)
+	if err != nil {
+		reconcileFailures.Inc()
+		return cnms.Diff{}, err
+	}
+
+	err = nm.SetupNetworkUsingState(r.monitor)
+	diff := r.monitor.Diff
+	recordDiff(diff)
+	if err != nil {
+		reconcileFailures.Inc()
+		return diff, errors.Wrap(err, "failed to reconcile rules with state")
+	}
+
+	if (!diff.Empty() || r.monitor.CNIReport.ErrorMessage != "") && r.OnDiff != nil {
+		r.OnDiff(diff, r.monitor.CNIReport)
+	}
+
+	// Mirrors expire within an interval rather than on the next CNI invocation.
+	if nm.HasExpiredEndpointMirrors() {
+		if err = r.deleteExpiredMirrors(r.config); err != nil {
+			reconcileFailures.Inc()
+			return diff, err
+		}
+	}
+
+	return diff, nil
+}
+
+// Run reconciles every interval until the context is cancelled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		diff, err := r.ReconcileOnce()
+		if err != nil {
+			log.Errorf("[monitor] Reconcile failed: %v", err)
+		} else if !diff.Empty() {
+			log.Printf("[monitor] Reconciled rules, ebtables added %v removed %v, iptables added %v",
+				diff.EbtablesAdded, diff.EbtablesRemoved, diff.IptablesAdded)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
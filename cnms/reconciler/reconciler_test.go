@@ -0,0 +1,197 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
+	"github.com/Azure/azure-container-networking/network"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestReconcile = errors.New("test reconcile error")
+
+// fakeManager reports a fixed diff, and discrepancy if any, from its monitor pass.
+type fakeManager struct {
+	network.NetworkManager
+	diff           cnms.Diff
+	report         string
+	err            error
+	expiredMirrors bool
+}
+
+func (nm *fakeManager) SetupNetworkUsingState(networkMonitor *cnms.NetworkMonitor) error {
+	networkMonitor.Diff = nm.diff
+	if nm.report != "" {
+		networkMonitor.CNIReport.ErrorMessage = nm.report
+	}
+	return nm.err
+}
+
+func (nm *fakeManager) HasExpiredEndpointMirrors() bool {
+	return nm.expiredMirrors
+}
+
+func newTestReconciler(nm network.NetworkManager, factoryErr error) *Reconciler {
+	rc := New(Config{}, nil)
+	rc.newManager = func(Config) (network.NetworkManager, error) {
+		if factoryErr != nil {
+			return nil, factoryErr
+		}
+		return nm, nil
+	}
+
+	return rc
+}
+
+func TestReconcileOnce(t *testing.T) {
+	diff := cnms.Diff{
+		EbtablesAdded:   []string{"-p ARP -i eth0 --arp-op Reply -j dnat --to-dst ff:ff:ff:ff:ff:ff --dnat-target ACCEPT"},
+		EbtablesRemoved: []string{"-p IPv4 -i eth0 --ip-dst 10.240.0.7 -j dnat --to-dst cc:ad:1d:4e:e5:f1 --dnat-target ACCEPT"},
+		IptablesAdded:   []string{"ipv4 -t nat -N SWIFT", "ipv4 -t nat -I POSTROUTING 1 -j SWIFT"},
+	}
+
+	tests := []struct {
+		name         string
+		nm           *fakeManager
+		factoryErr   error
+		wantDiff     cnms.Diff
+		wantErr      error
+		wantOnDiff   bool
+		wantFailures float64
+	}{
+		{
+			name:       "drift is repaired and reported",
+			nm:         &fakeManager{diff: diff, report: "repaired"},
+			wantDiff:   diff,
+			wantOnDiff: true,
+		},
+		{
+			name: "no drift",
+			nm:   &fakeManager{},
+		},
+		{
+			name:       "discrepancy without changes is reported",
+			nm:         &fakeManager{report: "failed to add rule"},
+			wantOnDiff: true,
+		},
+		{
+			name:         "monitor pass failure",
+			nm:           &fakeManager{diff: diff, err: errTestReconcile},
+			wantDiff:     diff,
+			wantErr:      errTestReconcile,
+			wantFailures: 1,
+		},
+		{
+			name:         "state file failure",
+			factoryErr:   errTestReconcile,
+			wantErr:      errTestReconcile,
+			wantFailures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newTestReconciler(tt.nm, tt.factoryErr)
+			onDiff := false
+			rc.OnDiff = func(got cnms.Diff, report *telemetry.CNIReport) {
+				onDiff = true
+				require.Equal(t, tt.wantDiff, got)
+				require.Equal(t, tt.nm.report, report.ErrorMessage)
+			}
+
+			ebtablesAdded := testutil.ToFloat64(rulesAdded.WithLabelValues(ebtablesTable))
+			ebtablesRemoved := testutil.ToFloat64(rulesRemoved.WithLabelValues(ebtablesTable))
+			iptablesAdded := testutil.ToFloat64(rulesAdded.WithLabelValues(iptablesTable))
+			failures := testutil.ToFloat64(reconcileFailures)
+
+			got, err := rc.ReconcileOnce()
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantDiff, got)
+			require.Equal(t, tt.wantOnDiff, onDiff)
+
+			require.Equal(t, float64(len(tt.wantDiff.EbtablesAdded)), testutil.ToFloat64(rulesAdded.WithLabelValues(ebtablesTable))-ebtablesAdded)
+			require.Equal(t, float64(len(tt.wantDiff.EbtablesRemoved)), testutil.ToFloat64(rulesRemoved.WithLabelValues(ebtablesTable))-ebtablesRemoved)
+			require.Equal(t, float64(len(tt.wantDiff.IptablesAdded)), testutil.ToFloat64(rulesAdded.WithLabelValues(iptablesTable))-iptablesAdded)
+			require.Equal(t, tt.wantFailures, testutil.ToFloat64(reconcileFailures)-failures)
+		})
+	}
+}
+
+func TestReconcileOnceResetsReport(t *testing.T) {
+	nm := &fakeManager{report: "failed to add rule"}
+	rc := newTestReconciler(nm, nil)
+
+	_, err := rc.ReconcileOnce()
+	require.NoError(t, err)
+	require.Equal(t, "failed to add rule", rc.monitor.CNIReport.ErrorMessage)
+
+	// a discrepancy is only reported by the pass which found it
+	nm.report = ""
+	reported := false
+	rc.OnDiff = func(cnms.Diff, *telemetry.CNIReport) { reported = true }
+	_, err = rc.ReconcileOnce()
+	require.NoError(t, err)
+	require.False(t, reported)
+}
+
+func TestReconcileOnceDeletesExpiredMirrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		nm          *fakeManager
+		deleteErr   error
+		wantDeleted bool
+		wantErr     error
+	}{
+		{
+			name:        "expired mirrors are deleted",
+			nm:          &fakeManager{expiredMirrors: true},
+			wantDeleted: true,
+		},
+		{
+			name: "no expired mirrors",
+			nm:   &fakeManager{},
+		},
+		{
+			name:        "delete failure",
+			nm:          &fakeManager{expiredMirrors: true},
+			deleteErr:   errTestReconcile,
+			wantDeleted: true,
+			wantErr:     errTestReconcile,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rc := newTestReconciler(tt.nm, nil)
+			deleted := false
+			rc.deleteExpiredMirrors = func(Config) error {
+				deleted = true
+				return tt.deleteErr
+			}
+
+			_, err := rc.ReconcileOnce()
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantDeleted, deleted)
+		})
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	passes := 0
+	rc := New(Config{}, nil)
+	rc.newManager = func(Config) (network.NetworkManager, error) {
+		passes++
+		return &fakeManager{}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rc.Run(ctx)
+	require.Equal(t, 1, passes)
+}
@@ -4,18 +4,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
+	"github.com/Azure/azure-container-networking/cnms/reconciler"
 	acn "github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
-	"github.com/Azure/azure-container-networking/netio"
-	"github.com/Azure/azure-container-networking/netlink"
-	"github.com/Azure/azure-container-networking/network"
 	"github.com/Azure/azure-container-networking/platform"
-	"github.com/Azure/azure-container-networking/store"
 	"github.com/Azure/azure-container-networking/telemetry"
 )
 
@@ -99,14 +99,6 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Initialize CNMS.
-	var config acn.PluginConfig
-	config.Version = version
-
-	// Create a channel to receive unhandled errors from CNMS.
-	config.ErrChan = make(chan error, 1)
-
-	var err error
 	// Create logging provider.
 	log.SetName(name)
 	log.SetLevel(logLevel)
@@ -131,54 +123,33 @@ func main() {
 
 	reportManager.Report.(*telemetry.CNIReport).GetOSDetails()
 
-	netMonitor := &cnms.NetworkMonitor{
-		AddRulesToBeValidated:    make(map[string]int),
-		DeleteRulesToBeValidated: make(map[string]int),
-		CNIReport:                reportManager.Report.(*telemetry.CNIReport),
-	}
-
 	tb := telemetry.NewTelemetryBuffer()
 	tb.ConnectToTelemetryService(telemetryNumRetries, telemetryWaitTimeInMilliseconds)
 	defer tb.Close()
 
-	for {
-		config.Store, err = store.NewJsonFileStore(platform.CNIRuntimePath + pluginName + ".json")
-		if err != nil {
-			fmt.Printf("[monitor] Failed to create store: %v\n", err)
-			return
-		}
+	rc := reconciler.New(reconciler.Config{
+		Interval:      time.Duration(timeout) * time.Second,
+		StateFilePath: platform.CNIRuntimePath + pluginName + ".json",
+		Version:       version,
+	}, reportManager.Report.(*telemetry.CNIReport))
 
-		nl := netlink.NewNetlink()
-		nm, err := network.NewNetworkManager(nl, platform.NewExecClient(), &netio.NetIO{})
-		if err != nil {
-			log.Printf("[monitor] Failed while creating network manager")
+	rc.OnDiff = func(_ cnms.Diff, report *telemetry.CNIReport) {
+		if report.ErrorMessage == "" {
 			return
 		}
 
-		if err := nm.Initialize(&config, false); err != nil {
-			log.Printf("[monitor] Failed while initializing network manager %+v", err)
+		log.Printf("[monitor] Reporting discrepancy in rules")
+		report.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+		if err := reportManager.SendReport(tb); err != nil {
+			log.Errorf("[monitor] SendReport failed due to %v", err)
+		} else {
+			log.Printf("[monitor] Reported successfully")
 		}
+	}
 
-		log.Printf("[monitor] network manager:%+v", nm)
-
-		if err := nm.SetupNetworkUsingState(netMonitor); err != nil {
-			log.Printf("[monitor] Failed while calling SetupNetworkUsingState with error %v", err)
-		}
-
-		if netMonitor.CNIReport.ErrorMessage != "" {
-			log.Printf("[monitor] Reporting discrepancy in rules")
-			netMonitor.CNIReport.Timestamp = time.Now().Format("2006-01-02 15:04:05")
-			if err := reportManager.SendReport(tb); err != nil {
-				log.Errorf("[monitor] SendReport failed due to %v", err)
-			} else {
-				log.Printf("[monitor] Reported successfully")
-			}
-
-			netMonitor.CNIReport.ErrorMessage = ""
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		log.Printf("[monitor] Going to sleep for %v seconds", timeout)
-		time.Sleep(time.Duration(timeout) * time.Second)
-		nm = nil
-	}
+	log.Printf("[monitor] Reconciling rules every %v seconds", timeout)
+	rc.Run(ctx)
 }
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"testing"
 
 	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
 	"github.com/Azure/azure-container-networking/ebtables"
+	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/telemetry"
 )
 
@@ -113,3 +115,50 @@ func TestDeleteInvalidRule(t *testing.T) {
 		t.Fatalf("Expected DeleteRulesToBeValidated length to be 0 but got %v", len(netMonitor.DeleteRulesToBeValidated))
 	}
 }
+
+func TestAddMissingIptablesRule(t *testing.T) {
+	programmed := map[string]bool{
+		"-t nat -L SWIFT":                        true,
+		"-t nat -C POSTROUTING -j SWIFT":         true,
+		"-t filter -C FORWARD -o azv1 -j ACCEPT": false,
+	}
+	var added []string
+	netMonitor := &cnms.NetworkMonitor{
+		CNIReport: &telemetry.CNIReport{},
+		IptablesRunner: func(version, params string) error {
+			if _, ok := programmed[params]; ok {
+				if !programmed[params] {
+					return errors.New("rule does not exist")
+				}
+				return nil
+			}
+			added = append(added, params)
+			return nil
+		},
+	}
+
+	stateRules := []iptables.IPTableEntry{
+		{Version: iptables.V4, Params: "-t nat -N SWIFT"},
+		{Version: iptables.V4, Params: "-t nat -I POSTROUTING 1 -j SWIFT"},
+		{Version: iptables.V4, Params: "-t filter -A FORWARD -o azv1 -j ACCEPT"},
+	}
+
+	netMonitor.CreateRequiredIptablesRules(stateRules)
+	if len(added) != 0 {
+		t.Fatalf("Expected no rules to be added on the first iteration but got %v", added)
+	}
+	if len(netMonitor.AddIptablesRulesToBeValidated) != 1 {
+		t.Fatalf("Expected AddIptablesRulesToBeValidated length to be 1 but got %v", len(netMonitor.AddIptablesRulesToBeValidated))
+	}
+
+	netMonitor.CreateRequiredIptablesRules(stateRules)
+	if len(added) != 1 || added[0] != stateRules[2].Params {
+		t.Fatalf("Expected %v to be added but got %v", stateRules[2].Params, added)
+	}
+	if len(netMonitor.Diff.IptablesAdded) != 1 {
+		t.Fatalf("Expected IptablesAdded length to be 1 but got %v", len(netMonitor.Diff.IptablesAdded))
+	}
+	if len(netMonitor.AddIptablesRulesToBeValidated) != 0 {
+		t.Fatalf("Expected AddIptablesRulesToBeValidated length to be 0 but got %v", len(netMonitor.AddIptablesRulesToBeValidated))
+	}
+}
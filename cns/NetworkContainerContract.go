@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Container Network Service DNC Contract
@@ -23,6 +24,12 @@ const (
 	DetachContainerFromNetwork               = "/network/detachcontainerfromnetwork"
 	RequestIPConfig                          = "/network/requestipconfig"
 	ReleaseIPConfig                          = "/network/releaseipconfig"
+	ReserveIPConfig                          = "/network/reserveipconfig"
+	UnreserveIPConfig                        = "/network/unreserveipconfig"
+	RequestEgressSNATIP                      = "/network/requestegresssnatip"
+	ReleaseEgressSNATIP                      = "/network/releaseegresssnatip"
+	GetPodSysctls                            = "/network/getpodsysctls"
+	GetVxlanPeers                            = "/network/getvxlanpeers"
 	PathDebugIPAddresses                     = "/debug/ipaddresses"
 	PathDebugPodContext                      = "/debug/podcontext"
 	PathDebugRestData                        = "/debug/restdata"
@@ -99,6 +106,8 @@ type CreateNetworkContainerRequest struct {
 	AllowHostToNCCommunication bool
 	AllowNCToHostCommunication bool
 	EndpointPolicies           []NetworkContainerRequestPolicies
+	// PodInterfaceName is the pod interface the NC backs when it is not the primary interface of the pod.
+	PodInterfaceName string `json:",omitempty"`
 }
 
 // NetworkContainerRequestPolicies - specifies policies associated with create network request
@@ -336,6 +345,22 @@ type GetNetworkContainerResponse struct {
 	Response                   Response
 	AllowHostToNCCommunication bool
 	AllowNCToHostCommunication bool
+	AdditionalInterfaces       []PodInterfaceConfig `json:",omitempty"`
+}
+
+// PodInterfaceConfig is the configuration of an additional interface of a pod, backed by another NC than the
+// one of its primary interface.
+type PodInterfaceConfig struct {
+	InterfaceName              string
+	NetworkContainerID         string
+	IPConfiguration            IPConfiguration
+	Routes                     []Route
+	CnetAddressSpace           []IPSubnet
+	MultiTenancyInfo           MultiTenancyInfo
+	PrimaryInterfaceIdentifier string
+	LocalIPConfiguration       IPConfiguration
+	AllowHostToNCCommunication bool
+	AllowNCToHostCommunication bool
 }
 
 // DeleteNetworkContainerRequest specifies the details about the request to delete a specifc network container.
@@ -354,14 +379,81 @@ type HostIPInfo struct {
 
 type IPConfigRequest struct {
 	DesiredIPAddress    string
+	DesiredSubnet       string // Optional CIDR of the subnet to allocate from, when the node has NCs in several subnets.
 	PodInterfaceID      string
 	InfraContainerID    string
 	OrchestratorContext json.RawMessage
 }
 
+// EgressSNATIPRequest requests the egress SNAT IP of a namespace, the secondary IP the egress traffic of its pods
+// is SNATed to.
+type EgressSNATIPRequest struct {
+	PodNamespace string
+}
+
+// EgressSNATIPResponse is the response to EgressSNATIPRequest.
+type EgressSNATIPResponse struct {
+	IPAddress string
+	Response  Response
+}
+
+// PodSysctlsAnnotation is the annotation in which a pod requests the sysctls applied in its network namespace, as a
+// JSON object of the sysctl values by key.
+const PodSysctlsAnnotation = "cni.azure.com/sysctls"
+
+// PodSysctlsRequest requests the sysctls a pod requests in its PodSysctlsAnnotation.
+type PodSysctlsRequest struct {
+	PodNamespace string
+	PodName      string
+}
+
+// PodSysctlsResponse is the response to PodSysctlsRequest.
+type PodSysctlsResponse struct {
+	Sysctls  map[string]string
+	Response Response
+}
+
+// VxlanPeersResponse returns the peers of the VXLAN overlay of the tunnel mode, the underlay IPs of the nodes.
+type VxlanPeersResponse struct {
+	Peers    []string
+	Response Response
+}
+
+// IPReservation holds a secondary IP for a pod, so that the pod gets the same IP back when it is recreated.
+type IPReservation struct {
+	PodIdentity string        // namespace/name of the pod
+	IPConfigID  string        // ID of the reserved secondary IP
+	IPAddress   string        // reserved secondary IP
+	TTL         time.Duration // how long the IP is held once the pod is gone
+	ExpiresAt   time.Time     // zero while the IP is allocated to the pod
+}
+
+// ReserveIPConfigRequest reserves an IP for a pod, identified either by PodNamespace and PodName or by OrchestratorContext.
+type ReserveIPConfigRequest struct {
+	PodNamespace        string
+	PodName             string
+	OrchestratorContext json.RawMessage
+	IPAddress           string // IP to reserve, the IP allocated to the pod if empty
+	TTLInSeconds        int    // how long the IP is held once the pod is gone, a day if zero
+}
+
+// ReserveIPConfigResponse is the response to ReserveIPConfigRequest.
+type ReserveIPConfigResponse struct {
+	Reservation IPReservation
+	Response    Response
+}
+
+// UnreserveIPConfigRequest removes the IP reservation of a pod, identified either by PodNamespace and PodName
+// or by OrchestratorContext.
+type UnreserveIPConfigRequest struct {
+	PodNamespace        string
+	PodName             string
+	OrchestratorContext json.RawMessage
+}
+
 func (i IPConfigRequest) String() string {
-	return fmt.Sprintf("[IPConfigRequest: DesiredIPAddress %s, PodInterfaceID %s, InfraContainerID %s, OrchestratorContext %s]",
-		i.DesiredIPAddress, i.PodInterfaceID, i.InfraContainerID, string(i.OrchestratorContext))
+	return fmt.Sprintf("[IPConfigRequest: DesiredIPAddress %s, DesiredSubnet %s, PodInterfaceID %s, InfraContainerID %s, OrchestratorContext %s]",
+		i.DesiredIPAddress, i.DesiredSubnet, i.PodInterfaceID, i.InfraContainerID, string(i.OrchestratorContext))
 }
 
 // IPConfigResponse is used in CNS IPAM mode as a response to CNI ADD
@@ -385,6 +477,8 @@ type GetIPAddressStateResponse struct {
 // GetIPAddressStatusResponse is used in CNS IPAM mode as a response to get IP address, state and Pod info
 type GetIPAddressStatusResponse struct {
 	IPConfigurationStatus []IPConfigurationStatus
+	IPSelectionStrategy   string // strategy with which CNS picks the IP to allocate among the available ones
+	IPReservations        []IPReservation
 	Response              Response
 }
 
@@ -46,6 +46,7 @@ type HTTPService interface {
 	GetPendingReleaseIPConfigs() []IPConfigurationStatus
 	GetPodIPConfigState() map[string]IPConfigurationStatus
 	MarkIPAsPendingRelease(numberToMark int) (map[string]IPConfigurationStatus, error)
+	MarkNCIPsAsPendingRelease(ncID string, numberToMark int) (map[string]IPConfigurationStatus, error)
 }
 
 // This is used for KubernetesCRD orchestrator Type where NC has multiple ips.
@@ -224,6 +225,7 @@ type IpamPoolMonitorStateSnapshot struct {
 	MinimumFreeIps           int
 	MaximumFreeIps           int
 	UpdatingIpsNotInUseCount int
+	IPCountByNC              map[string]map[IPConfigState]int // NC ID is key.
 	CachedNNC                v1alpha.NodeNetworkConfig
 }
 
@@ -11,6 +11,8 @@ import (
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -28,6 +30,12 @@ var clientPaths = []string{
 	cns.DeleteHostNCApipaEndpointPath,
 	cns.RequestIPConfig,
 	cns.ReleaseIPConfig,
+	cns.ReserveIPConfig,
+	cns.UnreserveIPConfig,
+	cns.RequestEgressSNATIP,
+	cns.ReleaseEgressSNATIP,
+	cns.GetPodSysctls,
+	cns.GetVxlanPeers,
 	cns.PathDebugIPAddresses,
 	cns.PathDebugPodContext,
 	cns.PathDebugRestData,
@@ -82,6 +90,14 @@ func buildRoutes(baseURL string, paths []string) (map[string]url.URL, error) {
 	return routes, nil
 }
 
+// send sends the request with the correlation ID and the trace context of its context in the headers,
+// so that CNS logs and traces the request as part of the operation of the caller.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	log.CorrelationFromContext(req.Context()).SetHeaders(req.Header)
+	tracing.Inject(req.Context(), req.Header)
+	return c.client.Do(req) //nolint:wrapcheck // wrapped by the callers
+}
+
 // GetNetworkConfiguration Request to get network config.
 func (c *Client) GetNetworkConfiguration(ctx context.Context, orchestratorContext []byte) (*cns.GetNetworkContainerResponse, error) {
 	payload := cns.GetNetworkContainerRequest{
@@ -102,7 +118,7 @@ func (c *Client) GetNetworkConfiguration(ctx context.Context, orchestratorContex
 		return nil, errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
@@ -151,7 +167,7 @@ func (c *Client) CreateHostNCApipaEndpoint(ctx context.Context, networkContainer
 		return "", errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return "", errors.Wrap(err, "http request failed")
 	}
@@ -192,7 +208,7 @@ func (c *Client) DeleteHostNCApipaEndpoint(ctx context.Context, networkContainer
 		return errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return errors.Wrap(err, "http request failed")
 	}
@@ -240,7 +256,7 @@ func (c *Client) RequestIPAddress(ctx context.Context, ipconfig cns.IPConfigRequ
 		return nil, errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
@@ -278,7 +294,7 @@ func (c *Client) ReleaseIPAddress(ctx context.Context, ipconfig cns.IPConfigRequ
 		return errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return errors.Wrap(err, "http request failed")
 	}
@@ -302,6 +318,233 @@ func (c *Client) ReleaseIPAddress(ctx context.Context, ipconfig cns.IPConfigRequ
 	return nil
 }
 
+// ReserveIPConfig calls reserveIPConfig on CNS to reserve an IP for the pod, the IP allocated to it if the
+// request has no IP address.
+func (c *Client) ReserveIPConfig(ctx context.Context, reserveReq cns.ReserveIPConfigRequest) (*cns.IPReservation, error) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(reserveReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode ReserveIPConfigRequest")
+	}
+
+	u := c.routes[cns.ReserveIPConfig]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.ReserveIPConfigResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ReserveIPConfigResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, errors.New(resp.Response.Message)
+	}
+
+	return &resp.Reservation, nil
+}
+
+// UnreserveIPConfig calls unreserveIPConfig on CNS to remove the IP reservation of the pod.
+func (c *Client) UnreserveIPConfig(ctx context.Context, unreserveReq cns.UnreserveIPConfigRequest) error {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(unreserveReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode UnreserveIPConfigRequest")
+	}
+
+	u := c.routes[cns.UnreserveIPConfig]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.send(req)
+	if err != nil {
+		return errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.Response
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode Response")
+	}
+
+	if resp.ReturnCode != 0 {
+		return errors.New(resp.Message)
+	}
+
+	return nil
+}
+
+// RequestEgressSNATIP calls requestEgressSNATIP on CNS to get the egress SNAT IP of the namespace, which CNS
+// allocates on the first request.
+func (c *Client) RequestEgressSNATIP(ctx context.Context, namespace string) (string, error) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(cns.EgressSNATIPRequest{PodNamespace: namespace})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode EgressSNATIPRequest")
+	}
+
+	u := c.routes[cns.RequestEgressSNATIP]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.send(req)
+	if err != nil {
+		return "", errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.EgressSNATIPResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode EgressSNATIPResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return "", errors.New(resp.Response.Message)
+	}
+
+	return resp.IPAddress, nil
+}
+
+// ReleaseEgressSNATIP calls releaseEgressSNATIP on CNS to release the egress SNAT IP of the namespace.
+func (c *Client) ReleaseEgressSNATIP(ctx context.Context, namespace string) error {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(cns.EgressSNATIPRequest{PodNamespace: namespace})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode EgressSNATIPRequest")
+	}
+
+	u := c.routes[cns.ReleaseEgressSNATIP]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.send(req)
+	if err != nil {
+		return errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.Response
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode Response")
+	}
+
+	if resp.ReturnCode != 0 {
+		return errors.New(resp.Message)
+	}
+
+	return nil
+}
+
+// GetPodSysctls calls getPodSysctls on CNS to get the sysctls the pod requests in its cns.PodSysctlsAnnotation.
+func (c *Client) GetPodSysctls(ctx context.Context, namespace, name string) (map[string]string, error) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(cns.PodSysctlsRequest{PodNamespace: namespace, PodName: name})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode PodSysctlsRequest")
+	}
+
+	u := c.routes[cns.GetPodSysctls]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	req.Header.Set(headerContentType, contentTypeJSON)
+	res, err := c.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	// CNS versions without the API do not route it.
+	if res.StatusCode == http.StatusNotFound {
+		return nil, &CNSClientError{
+			Code: types.UnsupportedVerb,
+			Err:  errors.Errorf("http response %d", res.StatusCode),
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.PodSysctlsResponse
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode PodSysctlsResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, &CNSClientError{
+			Code: resp.Response.ReturnCode,
+			Err:  errors.New(resp.Response.Message),
+		}
+	}
+
+	return resp.Sysctls, nil
+}
+
+// GetVxlanPeers calls getVxlanPeers on CNS to get the peers of the VXLAN overlay, the underlay IPs of the nodes.
+func (c *Client) GetVxlanPeers(ctx context.Context) ([]string, error) {
+	u := c.routes[cns.GetVxlanPeers]
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http response %d", res.StatusCode)
+	}
+
+	var resp cns.VxlanPeersResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode VxlanPeersResponse")
+	}
+
+	if resp.Response.ReturnCode != 0 {
+		return nil, errors.New(resp.Response.Message)
+	}
+
+	return resp.Peers, nil
+}
+
 // GetIPAddressesMatchingStates takes a variadic number of string parameters, to get all IP Addresses matching a number of states
 // usage GetIPAddressesWithStates(cns.Available, cns.Allocated)
 func (c *Client) GetIPAddressesMatchingStates(ctx context.Context, stateFilter ...cns.IPConfigState) ([]cns.IPConfigurationStatus, error) {
@@ -324,7 +567,7 @@ func (c *Client) GetIPAddressesMatchingStates(ctx context.Context, stateFilter .
 		return nil, errors.Wrap(err, "failed to build request")
 	}
 	req.Header.Set(headerContentType, contentTypeJSON)
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
@@ -354,7 +597,7 @@ func (c *Client) GetPodOrchestratorContext(ctx context.Context) (map[string]stri
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to build request")
 	}
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
@@ -383,7 +626,7 @@ func (c *Client) GetHTTPServiceData(ctx context.Context) (*restserver.GetHTTPSer
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to build request")
 	}
-	res, err := c.client.Do(req)
+	res, err := c.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "http request failed")
 	}
@@ -1422,3 +1422,37 @@ func TestGetHTTPServiceData(t *testing.T) {
 		})
 	}
 }
+
+type headerdo struct {
+	mockdo
+	header http.Header
+}
+
+func (h *headerdo) Do(req *http.Request) (*http.Response, error) {
+	h.header = req.Header
+	return h.mockdo.Do(req)
+}
+
+func TestRequestIPAddressPropagatesCorrelation(t *testing.T) {
+	emptyRoutes, _ := buildRoutes(defaultBaseURL, clientPaths)
+	correlation := log.Correlation{
+		RequestID:    uuid.New().String(),
+		PodNamespace: "default",
+		PodName:      "pod1",
+		ContainerID:  "infra",
+	}
+	hd := &headerdo{
+		mockdo: mockdo{
+			objToReturn:            &cns.IPConfigResponse{},
+			httpStatusCodeToReturn: http.StatusOK,
+		},
+	}
+	client := &Client{
+		client: hd,
+		routes: emptyRoutes,
+	}
+
+	_, err := client.RequestIPAddress(log.WithCorrelation(context.Background(), correlation), cns.IPConfigRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, correlation, log.CorrelationFromHeaders(hd.header))
+}
@@ -23,3 +23,11 @@ func IsNotFound(err error) bool {
 	e := &CNSClientError{}
 	return errors.As(err, &e) && (e.Code == types.UnknownContainerID)
 }
+
+// IsUnsupported tests if the provided error is of type CNSClientError and then
+// further tests if CNS does not support the API, either in its environment or,
+// when it is older than the client, at all.
+func IsUnsupported(err error) bool {
+	e := &CNSClientError{}
+	return errors.As(err, &e) && (e.Code == types.UnsupportedEnvironment || e.Code == types.UnsupportedVerb)
+}
@@ -44,3 +44,49 @@ func TestIsNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "is unsupported environment",
+			err: &CNSClientError{
+				Code: types.UnsupportedEnvironment,
+				Err:  errors.New("CNS does not read pods from the API server"),
+			},
+			want: true,
+		},
+		{
+			name: "is unsupported api",
+			err: errors.Wrap(&CNSClientError{
+				Code: types.UnsupportedVerb,
+				Err:  errors.New("http response 404"),
+			}, "failed to get pod sysctls"),
+			want: true,
+		},
+		{
+			name: "is not cnsclienterr",
+			err:  errors.New("error"),
+			want: false,
+		},
+		{
+			name: "is other cnsclienterr",
+			err: &CNSClientError{
+				Code: types.UnexpectedError,
+				Err:  errors.New("unexpected err"),
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnsupported(tt.err); got != tt.want {
+				t.Errorf("IsUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,6 +40,8 @@ type ServiceConfig struct {
 	Store       store.KeyValueStore
 	ChannelMode string
 	TlsSettings tls.TlsSettings
+	// IPSelectionStrategy is the ipselection.Strategy with which the pod IPs are allocated.
+	IPSelectionStrategy string
 }
 
 // NewService creates a new Service object.
@@ -8,9 +8,12 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/ipselection"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -22,9 +25,12 @@ const (
 
 type CNSConfig struct {
 	ChannelMode                 string
+	IPSelectionStrategy         ipselection.Strategy
 	InitializeFromCNI           bool
+	LogSettings                 LogSettings
 	ManagedSettings             ManagedSettings
 	MetricsBindAddress          string
+	NetworkMonitorSettings      NetworkMonitorSettings
 	SyncHostNCTimeoutMs         time.Duration
 	SyncHostNCVersionIntervalMs time.Duration
 	TLSCertificatePath          string
@@ -32,10 +38,18 @@ type CNSConfig struct {
 	TLSPort                     string
 	TLSSubjectName              string
 	TelemetrySettings           TelemetrySettings
+	TracingSettings             tracing.Config
 	UseHTTPS                    bool
 	WireserverIP                string
 }
 
+type LogSettings struct {
+	// Format of the log entries: text, logfmt or json.
+	Format string
+	// Log level by component, e.g. {"ipam-pool-monitor": "debug"}.
+	ComponentLevels map[string]string
+}
+
 type TelemetrySettings struct {
 	// Flag to disable the telemetry.
 	DisableAll bool
@@ -59,6 +73,19 @@ type TelemetrySettings struct {
 	DebugMode bool
 	// Interval for sending snapshot events.
 	SnapshotIntervalInMins int
+	// Sinks the telemetry is sent to, appinsights only if empty
+	Sinks []aitelemetry.SinkConfig
+}
+
+// NetworkMonitorSettings configures the ebtables/iptables drift repair loop
+// CNS can host for bridge mode CNI in place of the azure-cnimonitor service.
+type NetworkMonitorSettings struct {
+	// Flag to enable the network monitor.
+	Enable bool
+	// Interval between reconcile passes, defaults to 10 seconds.
+	IntervalInSecs int
+	// Path of the CNI state file, defaults to /var/run/azure-vnet.json.
+	StateFilePath string
 }
 
 type ManagedSettings struct {
@@ -150,6 +177,9 @@ func SetCNSConfigDefaults(config *CNSConfig) {
 	if config.MetricsBindAddress == "" {
 		config.MetricsBindAddress = ":9090"
 	}
+	if config.IPSelectionStrategy == "" {
+		config.IPSelectionStrategy = ipselection.Random
+	}
 	if config.SyncHostNCVersionIntervalMs == 0 {
 		config.SyncHostNCVersionIntervalMs = 1000 * time.Millisecond //nolint:gomnd // default times
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-container-networking/cns/ipselection"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -183,7 +184,8 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 			name: "unset defaults",
 			in:   CNSConfig{},
 			want: CNSConfig{
-				ChannelMode: "Direct",
+				ChannelMode:         "Direct",
+				IPSelectionStrategy: ipselection.Random,
 				ManagedSettings: ManagedSettings{
 					NodeSyncIntervalInSeconds: 30,
 				},
@@ -202,7 +204,8 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 		{
 			name: "don't overwrite set values",
 			in: CNSConfig{
-				ChannelMode: "Other",
+				ChannelMode:         "Other",
+				IPSelectionStrategy: ipselection.LowestFirst,
 				ManagedSettings: ManagedSettings{
 					NodeSyncIntervalInSeconds: 1,
 				},
@@ -218,7 +221,8 @@ func TestSetCNSConfigDefaults(t *testing.T) {
 				},
 			},
 			want: CNSConfig{
-				ChannelMode: "Other",
+				ChannelMode:         "Other",
+				IPSelectionStrategy: ipselection.LowestFirst,
 				ManagedSettings: ManagedSettings{
 					NodeSyncIntervalInSeconds: 1,
 				},
@@ -7,14 +7,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/common"
+	"github.com/Azure/azure-container-networking/cns/ipselection"
 	"github.com/Azure/azure-container-networking/cns/types"
 )
 
+var errNotEnoughAvailableIPs = errors.New("not enough Available IPs to mark as PendingRelease")
+
 type StringStack struct {
 	sync.Mutex
 	items []string
@@ -31,6 +35,19 @@ func (stack *StringStack) Push(v string) {
 	stack.items = append(stack.items, v)
 }
 
+// Remove drops the item v from the stack, if it is there.
+func (stack *StringStack) Remove(v string) {
+	stack.Lock()
+	defer stack.Unlock()
+
+	for i, item := range stack.items {
+		if item == v {
+			stack.items = append(stack.items[:i], stack.items[i+1:]...)
+			return
+		}
+	}
+}
+
 func (stack *StringStack) Pop() (string, error) {
 	stack.Lock()
 	defer stack.Unlock()
@@ -111,43 +128,38 @@ func (ipm *IPStateManager) ReleaseIPConfig(ipconfigID string) (cns.IPConfigurati
 	return ipm.AvailableIPConfigState[ipconfigID], nil
 }
 
+// MarkIPAsPendingRelease marks the Available IPs as PendingRelease in the order of ipselection.SortForRelease, like
+// CNS does, so that the IPs marked do not depend on the iteration order of the state maps. The state is left
+// untouched if there are not enough Available IPs.
 func (ipm *IPStateManager) MarkIPAsPendingRelease(numberOfIPsToMark int) (map[string]cns.IPConfigurationStatus, error) {
+	return ipm.MarkNCIPsAsPendingRelease("", numberOfIPsToMark)
+}
+
+// MarkNCIPsAsPendingRelease is MarkIPAsPendingRelease restricted to the Available IPs of the NC, of any NC if ncID
+// is empty.
+func (ipm *IPStateManager) MarkNCIPsAsPendingRelease(ncID string, numberOfIPsToMark int) (map[string]cns.IPConfigurationStatus, error) {
 	ipm.Lock()
 	defer ipm.Unlock()
 
-	var err error
-
-	pendingReleaseIPs := make(map[string]cns.IPConfigurationStatus)
-
-	defer func() {
-		// if there was an error, and not all ip's have been freed, restore state
-		if err != nil && len(pendingReleaseIPs) != numberOfIPsToMark {
-			for uuid, ipState := range pendingReleaseIPs {
-				ipState.State = cns.Available
-				ipm.AvailableIPIDStack.Push(pendingReleaseIPs[uuid].ID)
-				ipm.AvailableIPConfigState[pendingReleaseIPs[uuid].ID] = ipState
-				delete(ipm.PendingReleaseIPConfigState, pendingReleaseIPs[uuid].ID)
-			}
+	candidates := make([]ipselection.ReleaseCandidate, 0, len(ipm.AvailableIPConfigState))
+	for _, ipConfig := range ipm.AvailableIPConfigState {
+		if ncID == "" || ipConfig.NCID == ncID {
+			candidates = append(candidates, ipselection.ReleaseCandidate{IPConfigurationStatus: ipConfig})
 		}
-	}()
-
-	for i := 0; i < numberOfIPsToMark; i++ {
-		id, err := ipm.AvailableIPIDStack.Pop()
-		if err != nil {
-			return ipm.PendingReleaseIPConfigState, err
-		}
-
-		// add all pending release to a slice
-		ipConfig := ipm.AvailableIPConfigState[id]
-		ipConfig.State = cns.PendingRelease
-		pendingReleaseIPs[id] = ipConfig
-
-		delete(ipm.AvailableIPConfigState, id)
 	}
+	if len(candidates) < numberOfIPsToMark {
+		return ipm.PendingReleaseIPConfigState, errNotEnoughAvailableIPs
+	}
+	ipselection.SortForRelease(candidates)
 
-	// if no errors at this point, add the pending ips to the Pending state
-	for _, pendingReleaseIP := range pendingReleaseIPs {
-		ipm.PendingReleaseIPConfigState[pendingReleaseIP.ID] = pendingReleaseIP
+	pendingReleaseIPs := make(map[string]cns.IPConfigurationStatus, numberOfIPsToMark)
+	for _, candidate := range candidates[:numberOfIPsToMark] {
+		ipConfig := candidate.IPConfigurationStatus
+		ipConfig.State = cns.PendingRelease
+		pendingReleaseIPs[ipConfig.ID] = ipConfig
+		ipm.PendingReleaseIPConfigState[ipConfig.ID] = ipConfig
+		ipm.AvailableIPIDStack.Remove(ipConfig.ID)
+		delete(ipm.AvailableIPConfigState, ipConfig.ID)
 	}
 
 	return pendingReleaseIPs, nil
@@ -179,17 +191,20 @@ func (fake *HTTPServiceFake) SetNumberOfAllocatedIPs(desiredAllocatedIPCount int
 		}
 		return nil
 	}
-	// deallocate IPs
+	// deallocate IPs, in ID order so that the IPs released do not depend on the iteration order of the map
 	delta *= -1
-	i := 0
+	ids := make([]string, 0, len(fake.IPStateManager.AllocatedIPConfigState))
 	for id := range fake.IPStateManager.AllocatedIPConfigState {
-		if i >= delta {
-			break
-		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if delta > len(ids) {
+		delta = len(ids)
+	}
+	for _, id := range ids[:delta] {
 		if _, err := fake.IPStateManager.ReleaseIPConfig(id); err != nil {
 			return err
 		}
-		i++
 	}
 	return nil
 }
@@ -257,6 +272,10 @@ func (fake *HTTPServiceFake) MarkIPAsPendingRelease(numberToMark int) (map[strin
 	return fake.IPStateManager.MarkIPAsPendingRelease(numberToMark)
 }
 
+func (fake *HTTPServiceFake) MarkNCIPsAsPendingRelease(ncID string, numberToMark int) (map[string]cns.IPConfigurationStatus, error) {
+	return fake.IPStateManager.MarkNCIPsAsPendingRelease(ncID, numberToMark)
+}
+
 func (fake *HTTPServiceFake) GetOption(string) interface{} {
 	return nil
 }
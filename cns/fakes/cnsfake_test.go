@@ -0,0 +1,126 @@
+package fakes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIPConfigs(state cns.IPConfigState, n int) []cns.IPConfigurationStatus {
+	ipconfigs := make([]cns.IPConfigurationStatus, 0, n)
+	for i := 1; i <= n; i++ {
+		ipconfigs = append(ipconfigs, cns.IPConfigurationStatus{
+			ID:        fmt.Sprintf("id-%d", i),
+			IPAddress: fmt.Sprintf("10.0.0.%d", i),
+			State:     state,
+		})
+	}
+	return ipconfigs
+}
+
+func TestStringStack(t *testing.T) {
+	stack := NewStack()
+	stack.Push("a")
+	stack.Push("b")
+	stack.Push("c")
+	stack.Remove("b")
+	stack.Remove("d")
+
+	for _, want := range []string{"c", "a"} {
+		got, err := stack.Pop()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := stack.Pop()
+	assert.Error(t, err)
+}
+
+func TestIPStateManagerReserveAndReleaseIPConfig(t *testing.T) {
+	ipm := NewIPStateManager()
+	ipm.AddIPConfigs(testIPConfigs(cns.Available, 2))
+
+	reserved, err := ipm.ReserveIPConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "id-2", reserved.ID)
+	assert.Contains(t, ipm.AllocatedIPConfigState, "id-2")
+	assert.NotContains(t, ipm.AvailableIPConfigState, "id-2")
+
+	_, err = ipm.ReleaseIPConfig("id-2")
+	require.NoError(t, err)
+	assert.Contains(t, ipm.AvailableIPConfigState, "id-2")
+	assert.NotContains(t, ipm.AllocatedIPConfigState, "id-2")
+}
+
+func TestIPStateManagerMarkIPAsPendingRelease(t *testing.T) {
+	// the highest addresses are released first, whatever the iteration order of the maps
+	for i := 0; i < 10; i++ {
+		ipm := NewIPStateManager()
+		ipm.AddIPConfigs(testIPConfigs(cns.Available, 5))
+
+		released, err := ipm.MarkIPAsPendingRelease(2)
+		require.NoError(t, err)
+		assert.Len(t, released, 2)
+		for _, id := range []string{"id-5", "id-4"} {
+			assert.Equal(t, cns.PendingRelease, released[id].State)
+			assert.Contains(t, ipm.PendingReleaseIPConfigState, id)
+			assert.NotContains(t, ipm.AvailableIPConfigState, id)
+		}
+
+		// the released IPs are no longer reserved
+		reserved, err := ipm.ReserveIPConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "id-3", reserved.ID)
+	}
+}
+
+func TestIPStateManagerMarkIPAsPendingReleaseNotEnoughIPs(t *testing.T) {
+	ipm := NewIPStateManager()
+	ipm.AddIPConfigs(testIPConfigs(cns.Available, 2))
+
+	_, err := ipm.MarkIPAsPendingRelease(3)
+	require.ErrorIs(t, err, errNotEnoughAvailableIPs)
+	assert.Len(t, ipm.AvailableIPConfigState, 2)
+	assert.Empty(t, ipm.PendingReleaseIPConfigState)
+}
+
+func TestHTTPServiceFakeSetNumberOfAllocatedIPs(t *testing.T) {
+	fake := NewHTTPServiceFake()
+	fake.IPStateManager.AddIPConfigs(testIPConfigs(cns.Available, 4))
+
+	require.NoError(t, fake.SetNumberOfAllocatedIPs(3))
+	assert.Len(t, fake.GetAllocatedIPConfigs(), 3)
+	assert.Len(t, fake.GetAvailableIPConfigs(), 1)
+
+	// the IPs are released in ID order
+	require.NoError(t, fake.SetNumberOfAllocatedIPs(1))
+	allocated := fake.GetAllocatedIPConfigs()
+	require.Len(t, allocated, 1)
+	assert.Equal(t, "id-4", allocated[0].ID)
+
+	require.NoError(t, fake.SetNumberOfAllocatedIPs(0))
+	assert.Empty(t, fake.GetAllocatedIPConfigs())
+	assert.Len(t, fake.GetPodIPConfigState(), 4)
+}
+
+func TestIPStateManagerMarkNCIPsAsPendingRelease(t *testing.T) {
+	ipm := NewIPStateManager()
+	ipconfigs := testIPConfigs(cns.Available, 4)
+	for i := range ipconfigs {
+		ipconfigs[i].NCID = fmt.Sprintf("nc-%d", i%2)
+	}
+	ipm.AddIPConfigs(ipconfigs)
+
+	released, err := ipm.MarkNCIPsAsPendingRelease("nc-0", 2)
+	require.NoError(t, err)
+	assert.Len(t, released, 2)
+	for _, ipConfig := range released {
+		assert.Equal(t, "nc-0", ipConfig.NCID)
+	}
+
+	_, err = ipm.MarkNCIPsAsPendingRelease("nc-0", 1)
+	require.ErrorIs(t, err, errNotEnoughAvailableIPs)
+	assert.Len(t, ipm.AvailableIPConfigState, 2)
+}
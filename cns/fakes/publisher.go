@@ -0,0 +1,34 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package fakes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PublisherFake records the events and conditions published by CNS in memory.
+type PublisherFake struct {
+	Events     []string
+	Conditions []metav1.Condition
+}
+
+func (f *PublisherFake) Eventf(eventType, reason, messageFmt string, args ...interface{}) {
+	f.Events = append(f.Events, eventType+" "+reason+" "+fmt.Sprintf(messageFmt, args...))
+}
+
+func (f *PublisherFake) SetCondition(_ context.Context, condition metav1.Condition) { //nolint:gocritic // ignore hugeParam
+	meta.SetStatusCondition(&f.Conditions, condition)
+}
+
+func (*PublisherFake) Observe(context.Context, *v1alpha.NodeNetworkConfig) {}
+
+// Condition returns the condition of the type, or nil if it was never set.
+func (f *PublisherFake) Condition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(f.Conditions, conditionType)
+}
@@ -36,6 +36,13 @@ var (
 			Help: "IP pool size.",
 		},
 	)
+	ipamNCIPCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ipam_nc_ips",
+			Help: "IP count per network container and IP state.",
+		},
+		[]string{"nc", "state"},
+	)
 	ipamMaxIPCount = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "ipam_max_ips",
@@ -76,6 +83,7 @@ func init() {
 		ipamFreeIPCount,
 		ipamIPPool,
 		ipamMaxIPCount,
+		ipamNCIPCount,
 		ipamPendingProgramIPCount,
 		ipamPendingReleaseIPCount,
 	)
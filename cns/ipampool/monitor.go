@@ -3,6 +3,7 @@ package ipampool
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/Azure/azure-container-networking/cns/metric"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -18,22 +21,64 @@ const (
 	DefaultRefreshDelay = 1 * time.Second
 	// DefaultMaxIPs default maximum allocatable IPs
 	DefaultMaxIPs = 250
+	// DefaultStuckThreshold is how long IPs may wait to be programmed or released before the pool is reported as not ready.
+	DefaultStuckThreshold = 5 * time.Minute
+)
+
+// Event reasons for pool scaling. The reasons for the pool health are the ones of the IPPoolReady condition.
+const (
+	reasonScaleUp   = "ScaleUp"
+	reasonScaleDown = "ScaleDown"
 )
 
 type nodeNetworkConfigSpecUpdater interface {
 	UpdateSpec(context.Context, *v1alpha.NodeNetworkConfigSpec) (*v1alpha.NodeNetworkConfig, error)
 }
 
+type statusPublisher interface {
+	Eventf(eventType, reason, messageFmt string, args ...interface{})
+	SetCondition(context.Context, metav1.Condition)
+}
+
 // poolState is the Monitor's view of the IP pool.
 type poolState struct {
 	minFreeCount  int
 	maxFreeCount  int
 	notInUseCount int
+	ipCountByNC   map[string]map[cns.IPConfigState]int
+	exhausted     bool
+	// how long IPs have been waiting on NMAgent to program them, and on DNC to release them
+	pendingProgram stuckTimer
+	pendingRelease stuckTimer
+}
+
+// stuckTimer tracks how long a state of the pool has been observed without interruption.
+type stuckTimer struct {
+	since time.Time
+	stuck bool
+}
+
+// observe records whether the state is active at now and returns true the first time it has been active for
+// longer than the threshold.
+func (s *stuckTimer) observe(active bool, now time.Time, threshold time.Duration) bool {
+	if !active {
+		*s = stuckTimer{}
+		return false
+	}
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if !s.stuck && now.Sub(s.since) >= threshold {
+		s.stuck = true
+		return true
+	}
+	return false
 }
 
 type Options struct {
-	RefreshDelay time.Duration
-	MaxIPs       int
+	RefreshDelay   time.Duration
+	MaxIPs         int
+	StuckThreshold time.Duration
 }
 
 type Monitor struct {
@@ -42,23 +87,28 @@ type Monitor struct {
 	scaler      v1alpha.Scaler
 	state       poolState
 	nnccli      nodeNetworkConfigSpecUpdater
+	publisher   statusPublisher
 	httpService cns.HTTPService
 	initialized chan interface{}
 	nncSource   chan v1alpha.NodeNetworkConfig
 	once        sync.Once
 }
 
-func NewMonitor(httpService cns.HTTPService, nnccli nodeNetworkConfigSpecUpdater, opts *Options) *Monitor {
+func NewMonitor(httpService cns.HTTPService, nnccli nodeNetworkConfigSpecUpdater, publisher statusPublisher, opts *Options) *Monitor {
 	if opts.RefreshDelay < 1 {
 		opts.RefreshDelay = DefaultRefreshDelay
 	}
 	if opts.MaxIPs < 1 {
 		opts.MaxIPs = DefaultMaxIPs
 	}
+	if opts.StuckThreshold < 1 {
+		opts.StuckThreshold = DefaultStuckThreshold
+	}
 	return &Monitor{
 		opts:        opts,
 		httpService: httpService,
 		nnccli:      nnccli,
+		publisher:   publisher,
 		initialized: make(chan interface{}),
 		nncSource:   make(chan v1alpha.NodeNetworkConfig),
 	}
@@ -98,7 +148,8 @@ func (pm *Monitor) Start(ctx context.Context) error {
 }
 
 func (pm *Monitor) reconcile(ctx context.Context) error {
-	cnsPodIPConfigCount := len(pm.httpService.GetPodIPConfigState())
+	podIPConfigState := pm.httpService.GetPodIPConfigState()
+	cnsPodIPConfigCount := len(podIPConfigState)
 	pendingProgramCount := len(pm.httpService.GetPendingProgramIPConfigs()) // TODO: add pending program count to real cns
 	allocatedPodIPCount := len(pm.httpService.GetAllocatedIPConfigs())
 	pendingReleaseIPCount := len(pm.httpService.GetPendingReleaseIPConfigs())
@@ -123,6 +174,18 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 	ipamRequestedIPConfigCount.Set(float64(requestedIPConfigCount))
 	ipamUnallocatedIPCount.Set(float64(unallocatedIPConfigCount))
 
+	pm.state.ipCountByNC = countIPsByNC(podIPConfigState)
+	ipamNCIPCount.Reset()
+	for ncID, ipCountByState := range pm.state.ipCountByNC {
+		for state, count := range ipCountByState {
+			ipamNCIPCount.WithLabelValues(ncID, string(state)).Set(float64(count))
+		}
+	}
+
+	pm.publishPoolHealth(ctx, availableIPConfigCount, pendingProgramCount, time.Now())
+
+	// The NNC requests a single IP count for the node and DNC chooses the NCs the IPs come from, so the pool scales
+	// on the node-wide free count. The IPs released on scale down are taken per NC, see releaseCountsByNC.
 	switch {
 	// pod count is increasing
 	case freeIPConfigCount < int64(pm.state.minFreeCount):
@@ -154,6 +217,58 @@ func (pm *Monitor) reconcile(ctx context.Context) error {
 	return nil
 }
 
+// publishPoolHealth sets the IPPoolReady condition, and records an event when the pool becomes exhausted or
+// IPs get stuck waiting to be programmed or released.
+func (pm *Monitor) publishPoolHealth(ctx context.Context, availableIPCount, pendingProgramCount int, now time.Time) {
+	threshold := pm.opts.StuckThreshold
+
+	exhausted := availableIPCount == 0 && pm.spec.RequestedIPCount >= pm.scaler.MaxIPCount
+	if exhausted && !pm.state.exhausted {
+		pm.publisher.Eventf(corev1.EventTypeWarning, v1alpha.ReasonPoolExhausted, "No IPs are available and the pool is at the max of %d IPs", pm.scaler.MaxIPCount)
+	}
+	pm.state.exhausted = exhausted
+
+	if pm.state.pendingProgram.observe(pendingProgramCount > 0, now, threshold) {
+		pm.publisher.Eventf(corev1.EventTypeWarning, v1alpha.ReasonNCVersionMismatch,
+			"%d IPs have been waiting for more than %s for NMAgent to program the NC version they were assigned in", pendingProgramCount, threshold)
+	}
+	if pm.state.pendingRelease.observe(len(pm.spec.IPsNotInUse) > 0, now, threshold) {
+		pm.publisher.Eventf(corev1.EventTypeWarning, v1alpha.ReasonPendingReleaseStuck,
+			"%d IPs have been pending release for more than %s", len(pm.spec.IPsNotInUse), threshold)
+	}
+
+	condition := metav1.Condition{
+		Type:    v1alpha.IPPoolReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha.ReasonPoolReady,
+		Message: "The IP pool can serve pod IP requests",
+	}
+	switch {
+	case pm.state.exhausted:
+		condition.Status, condition.Reason = metav1.ConditionFalse, v1alpha.ReasonPoolExhausted
+		condition.Message = fmt.Sprintf("No IPs are available and the pool is at the max of %d IPs", pm.scaler.MaxIPCount)
+	case pm.state.pendingProgram.stuck:
+		condition.Status, condition.Reason = metav1.ConditionFalse, v1alpha.ReasonNCVersionMismatch
+		condition.Message = fmt.Sprintf("IPs have been waiting for more than %s for NMAgent to program their NC version", threshold)
+	case pm.state.pendingRelease.stuck:
+		condition.Status, condition.Reason = metav1.ConditionFalse, v1alpha.ReasonPendingReleaseStuck
+		condition.Message = fmt.Sprintf("IPs have been pending release for more than %s", threshold)
+	}
+	pm.publisher.SetCondition(ctx, condition)
+}
+
+// countIPsByNC groups the IPs in the pool by NC and then by state.
+func countIPsByNC(podIPConfigState map[string]cns.IPConfigurationStatus) map[string]map[cns.IPConfigState]int {
+	ipCountByNC := map[string]map[cns.IPConfigState]int{}
+	for _, ipConfig := range podIPConfigState {
+		if _, ok := ipCountByNC[ipConfig.NCID]; !ok {
+			ipCountByNC[ipConfig.NCID] = map[cns.IPConfigState]int{}
+		}
+		ipCountByNC[ipConfig.NCID][ipConfig.State]++
+	}
+	return ipCountByNC
+}
+
 func (pm *Monitor) increasePoolSize(ctx context.Context) error {
 	tempNNCSpec := pm.createNNCSpecForCRD()
 
@@ -183,6 +298,7 @@ func (pm *Monitor) increasePoolSize(ctx context.Context) error {
 	}
 
 	logger.Printf("[ipam-pool-monitor] Increasing pool size: UpdateCRDSpec succeeded for spec %+v", tempNNCSpec)
+	pm.publisher.Eventf(corev1.EventTypeNormal, reasonScaleUp, "Requested %d IPs, up from %d", tempNNCSpec.RequestedIPCount, previouslyRequestedIPCount)
 	// start an alloc timer
 	metric.StartPoolIncreaseTimer(int(batchSize))
 	// save the updated state to cachedSpec
@@ -222,7 +338,7 @@ func (pm *Monitor) decreasePoolSize(ctx context.Context, existingPendingReleaseI
 		pm.state.notInUseCount < existingPendingReleaseIPCount {
 		logger.Printf("[ipam-pool-monitor] Marking IPs as PendingRelease, ipsToBeReleasedCount %d", int(decreaseIPCountBy))
 		var err error
-		pendingIPAddresses, err = pm.httpService.MarkIPAsPendingRelease(int(decreaseIPCountBy))
+		pendingIPAddresses, err = pm.markIPsAsPendingRelease(int(decreaseIPCountBy))
 		if err != nil {
 			return err
 		}
@@ -250,6 +366,7 @@ func (pm *Monitor) decreasePoolSize(ctx context.Context, existingPendingReleaseI
 	}
 
 	logger.Printf("[ipam-pool-monitor] Decreasing pool size: UpdateCRDSpec succeeded for spec %+v", tempNNCSpec)
+	pm.publisher.Eventf(corev1.EventTypeNormal, reasonScaleDown, "Requested %d IPs, down from %d", tempNNCSpec.RequestedIPCount, previouslyRequestedIPCount)
 	// start a dealloc timer
 	metric.StartPoolDecreaseTimer(int(batchSize))
 
@@ -263,6 +380,59 @@ func (pm *Monitor) decreasePoolSize(ctx context.Context, existingPendingReleaseI
 	return nil
 }
 
+// markIPsAsPendingRelease marks the IPs to release as PendingRelease, taking them from each NC as releaseCountsByNC
+// decides.
+func (pm *Monitor) markIPsAsPendingRelease(count int) (map[string]cns.IPConfigurationStatus, error) {
+	releaseCounts := releaseCountsByNC(pm.state.ipCountByNC, count)
+	ncIDs := make([]string, 0, len(releaseCounts))
+	for ncID := range releaseCounts {
+		ncIDs = append(ncIDs, ncID)
+	}
+	sort.Strings(ncIDs)
+
+	pendingIPAddresses := map[string]cns.IPConfigurationStatus{}
+	for _, ncID := range ncIDs {
+		logger.Printf("[ipam-pool-monitor] Marking %d IPs of NC %s as PendingRelease", releaseCounts[ncID], ncID)
+		ips, err := pm.httpService.MarkNCIPsAsPendingRelease(ncID, releaseCounts[ncID])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to mark IPs of NC %s as PendingRelease", ncID)
+		}
+		for id, ip := range ips {
+			pendingIPAddresses[id] = ip
+		}
+	}
+	return pendingIPAddresses, nil
+}
+
+// releaseCountsByNC splits the count of IPs to release between the NCs, one IP at a time from the NC with the most
+// free IPs left, so that the NCs holding more free IPs than the others are scaled down first and no NC is drained
+// while another has free IPs to spare. The free IPs of an NC are the ones it can release, Available or
+// PendingProgramming. Fewer IPs are released if the NCs do not have enough free IPs.
+func releaseCountsByNC(ipCountByNC map[string]map[cns.IPConfigState]int, count int) map[string]int {
+	freeByNC := map[string]int{}
+	for ncID, ipCountByState := range ipCountByNC {
+		if free := ipCountByState[cns.Available] + ipCountByState[cns.PendingProgramming]; free > 0 {
+			freeByNC[ncID] = free
+		}
+	}
+
+	releaseCounts := map[string]int{}
+	for i := 0; i < count; i++ {
+		selected, selectedFree := "", 0
+		for ncID, free := range freeByNC {
+			if free > selectedFree || (free == selectedFree && free > 0 && ncID < selected) {
+				selected, selectedFree = ncID, free
+			}
+		}
+		if selectedFree == 0 {
+			break
+		}
+		freeByNC[selected]--
+		releaseCounts[selected]++
+	}
+	return releaseCounts
+}
+
 // cleanPendingRelease removes IPs from the cache and CRD if the request controller has reconciled
 // CNS state and the pending IP release map is empty.
 func (pm *Monitor) cleanPendingRelease(ctx context.Context) error {
@@ -304,6 +474,7 @@ func (pm *Monitor) GetStateSnapshot() cns.IpamPoolMonitorStateSnapshot {
 		MinimumFreeIps:           state.minFreeCount,
 		MaximumFreeIps:           state.maxFreeCount,
 		UpdatingIpsNotInUseCount: state.notInUseCount,
+		IPCountByNC:              state.ipCountByNC,
 		CachedNNC: v1alpha.NodeNetworkConfig{
 			Spec: spec,
 			Status: v1alpha.NodeNetworkConfigStatus{
@@ -2,6 +2,7 @@ package ipampool
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type fakeNodeNetworkConfigUpdater struct {
@@ -54,7 +56,7 @@ func initFakes(initState state) (*fakes.HTTPServiceFake, *fakes.RequestControlle
 	fakecns := fakes.NewHTTPServiceFake()
 	fakerc := fakes.NewRequestControllerFake(fakecns, scalarUnits, subnetaddresspace, initState.ipConfigCount)
 
-	poolmonitor := NewMonitor(fakecns, &fakeNodeNetworkConfigUpdater{fakerc.NNC}, &Options{RefreshDelay: 100 * time.Second})
+	poolmonitor := NewMonitor(fakecns, &fakeNodeNetworkConfigUpdater{fakerc.NNC}, &fakes.PublisherFake{}, &Options{RefreshDelay: 100 * time.Second})
 
 	fakecns.PoolMonitor = &directUpdatePoolMonitor{m: poolmonitor}
 	_ = fakecns.SetNumberOfAllocatedIPs(initState.allocatedIPCount)
@@ -443,3 +445,144 @@ func TestCalculateIPs(t *testing.T) {
 		})
 	}
 }
+
+func TestCountIPsByNC(t *testing.T) {
+	podIPConfigState := map[string]cns.IPConfigurationStatus{
+		"1": {NCID: "nc1", State: cns.Allocated},
+		"2": {NCID: "nc1", State: cns.Available},
+		"3": {NCID: "nc1", State: cns.Available},
+		"4": {NCID: "nc2", State: cns.PendingRelease},
+	}
+	want := map[string]map[cns.IPConfigState]int{
+		"nc1": {cns.Allocated: 1, cns.Available: 2},
+		"nc2": {cns.PendingRelease: 1},
+	}
+	assert.Equal(t, want, countIPsByNC(podIPConfigState))
+}
+
+func TestReleaseCountsByNC(t *testing.T) {
+	tests := []struct {
+		name        string
+		ipCountByNC map[string]map[cns.IPConfigState]int
+		count       int
+		want        map[string]int
+	}{
+		{
+			name: "single NC",
+			ipCountByNC: map[string]map[cns.IPConfigState]int{
+				"nc1": {cns.Allocated: 2, cns.Available: 8, cns.PendingProgramming: 2},
+			},
+			count: 10,
+			want:  map[string]int{"nc1": 10},
+		},
+		{
+			name: "NC with the most free IPs first",
+			ipCountByNC: map[string]map[cns.IPConfigState]int{
+				"nc1": {cns.Allocated: 2, cns.Available: 2},
+				"nc2": {cns.Available: 14},
+			},
+			count: 8,
+			want:  map[string]int{"nc2": 8},
+		},
+		{
+			name: "NCs left with even free IPs",
+			ipCountByNC: map[string]map[cns.IPConfigState]int{
+				"nc1": {cns.Available: 4},
+				"nc2": {cns.Available: 8},
+			},
+			count: 8,
+			want:  map[string]int{"nc1": 2, "nc2": 6},
+		},
+		{
+			name: "not enough free IPs",
+			ipCountByNC: map[string]map[cns.IPConfigState]int{
+				"nc1": {cns.Allocated: 4, cns.Available: 1},
+				"nc2": {cns.PendingRelease: 4, cns.Available: 2},
+			},
+			count: 10,
+			want:  map[string]int{"nc1": 1, "nc2": 2},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, releaseCountsByNC(tt.ipCountByNC, tt.count))
+		})
+	}
+}
+
+func TestPoolDecreaseByNC(t *testing.T) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+
+	// nc1 has the highest addresses, which a node-wide release would take first
+	fakecns := fakes.NewHTTPServiceFake()
+	ipConfigs := []cns.IPConfigurationStatus{
+		{ID: "nc1-1", NCID: "nc1", IPAddress: "10.0.1.1", State: cns.Allocated},
+		{ID: "nc1-2", NCID: "nc1", IPAddress: "10.0.1.2", State: cns.Allocated},
+		{ID: "nc1-3", NCID: "nc1", IPAddress: "10.0.1.3", State: cns.Available},
+		{ID: "nc1-4", NCID: "nc1", IPAddress: "10.0.1.4", State: cns.Available},
+	}
+	for i := 1; i <= 14; i++ {
+		ipConfigs = append(ipConfigs, cns.IPConfigurationStatus{
+			ID: fmt.Sprintf("nc2-%d", i), NCID: "nc2", IPAddress: fmt.Sprintf("10.0.0.%d", i), State: cns.Available,
+		})
+	}
+	fakecns.IPStateManager.AddIPConfigs(ipConfigs)
+
+	nnc := &v1alpha.NodeNetworkConfig{}
+	poolmonitor := NewMonitor(fakecns, &fakeNodeNetworkConfigUpdater{nnc}, &fakes.PublisherFake{}, &Options{RefreshDelay: 100 * time.Second})
+	poolmonitor.scaler = v1alpha.Scaler{BatchSize: 10, RequestThresholdPercent: 50, ReleaseThresholdPercent: 150, MaxIPCount: 30}
+	poolmonitor.spec.RequestedIPCount = 18
+	poolmonitor.state.minFreeCount, poolmonitor.state.maxFreeCount = CalculateMinFreeIPs(poolmonitor.scaler), CalculateMaxFreeIPs(poolmonitor.scaler)
+
+	// 16 free IPs are above the release threshold of 15, the pool goes down to 10 IPs
+	assert.NoError(t, poolmonitor.reconcile(context.Background()))
+	assert.Equal(t, int64(10), nnc.Spec.RequestedIPCount)
+	assert.Len(t, nnc.Spec.IPsNotInUse, 8)
+
+	// the IPs are released from nc2, nc1 keeps its free IPs
+	for _, ipConfig := range fakecns.GetPendingReleaseIPConfigs() {
+		assert.Equal(t, "nc2", ipConfig.NCID)
+	}
+	assert.Equal(t, map[cns.IPConfigState]int{cns.Allocated: 2, cns.Available: 2}, countIPsByNC(fakecns.GetPodIPConfigState())["nc1"])
+}
+
+func TestPublishPoolHealth(t *testing.T) {
+	initState := state{
+		batchSize:               10,
+		allocatedIPCount:        10,
+		ipConfigCount:           10,
+		requestThresholdPercent: 50,
+		releaseThresholdPercent: 150,
+		maxIPCount:              10,
+	}
+	_, fakerc, poolmonitor := initFakes(initState)
+	publisher := &fakes.PublisherFake{}
+	poolmonitor.publisher = publisher
+	assert.NoError(t, fakerc.Reconcile(true))
+	ctx := context.Background()
+	now := time.Now()
+
+	// every IP is allocated and the pool is at the max
+	poolmonitor.publishPoolHealth(ctx, 0, 0, now)
+	assert.Equal(t, []string{"Warning PoolExhausted No IPs are available and the pool is at the max of 10 IPs"}, publisher.Events)
+	assert.Equal(t, v1alpha.ReasonPoolExhausted, publisher.Condition(v1alpha.IPPoolReady).Reason)
+
+	// IPs pending programming are only reported once they have waited past the threshold
+	poolmonitor.publishPoolHealth(ctx, 1, 1, now)
+	assert.Equal(t, v1alpha.ReasonPoolReady, publisher.Condition(v1alpha.IPPoolReady).Reason)
+	poolmonitor.publishPoolHealth(ctx, 1, 1, now.Add(DefaultStuckThreshold))
+	assert.Equal(t, v1alpha.ReasonNCVersionMismatch, publisher.Condition(v1alpha.IPPoolReady).Reason)
+	poolmonitor.publishPoolHealth(ctx, 1, 1, now.Add(2*DefaultStuckThreshold))
+	assert.Len(t, publisher.Events, 2)
+
+	poolmonitor.spec.IPsNotInUse = []string{"a"}
+	poolmonitor.publishPoolHealth(ctx, 1, 0, now)
+	poolmonitor.publishPoolHealth(ctx, 1, 0, now.Add(DefaultStuckThreshold))
+	assert.Equal(t, v1alpha.ReasonPendingReleaseStuck, publisher.Condition(v1alpha.IPPoolReady).Reason)
+	assert.Equal(t, "Warning PendingReleaseStuck 1 IPs have been pending release for more than 5m0s", publisher.Events[2])
+
+	poolmonitor.spec.IPsNotInUse = nil
+	poolmonitor.publishPoolHealth(ctx, 1, 0, now)
+	assert.Equal(t, metav1.ConditionTrue, publisher.Condition(v1alpha.IPPoolReady).Status)
+}
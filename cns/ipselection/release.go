@@ -0,0 +1,46 @@
+package ipselection
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+)
+
+// ReleaseCandidate is an IP which may be released when the pool scales down.
+type ReleaseCandidate struct {
+	cns.IPConfigurationStatus
+	// NCVersion is the version of the NC in which the IP was added.
+	NCVersion int
+	// ReleasedAt is when the IP was last released by a pod, zero if it was never allocated.
+	ReleasedAt time.Time
+}
+
+// SortForRelease orders the candidates in the order in which they should be released, which is:
+//  1. PendingProgramming IPs before Available ones, as they are not programmed on the host yet.
+//  2. The highest NC version first, so that the newest batch is released before the IPs in use for longer.
+//  3. The IPs which were never allocated before the ones which were.
+//  4. The IPs which were released the longest ago first, so that the IPs released just now, which may
+//     still have conntrack entries or be quarantined by the peers, are kept.
+//  5. The highest address first, which keeps the pool packed at the start of the subnet.
+func SortForRelease(candidates []ReleaseCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return lessForRelease(candidates[i], candidates[j])
+	})
+}
+
+func lessForRelease(a, b ReleaseCandidate) bool {
+	if a.State != b.State {
+		return a.State == cns.PendingProgramming
+	}
+	if a.NCVersion != b.NCVersion {
+		return a.NCVersion > b.NCVersion
+	}
+	if a.ReleasedAt.IsZero() != b.ReleasedAt.IsZero() {
+		return a.ReleasedAt.IsZero()
+	}
+	if !a.ReleasedAt.Equal(b.ReleasedAt) {
+		return a.ReleasedAt.Before(b.ReleasedAt)
+	}
+	return lessAddress(b.IPConfigurationStatus, a.IPConfigurationStatus)
+}
@@ -0,0 +1,74 @@
+package ipselection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortForRelease(t *testing.T) {
+	now := time.Now()
+	candidate := func(ip string, state cns.IPConfigState, ncVersion int, releasedAt time.Time) ReleaseCandidate {
+		return ReleaseCandidate{
+			IPConfigurationStatus: cns.IPConfigurationStatus{ID: "id-" + ip, IPAddress: ip, State: state},
+			NCVersion:             ncVersion,
+			ReleasedAt:            releasedAt,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []ReleaseCandidate
+		want       []string
+	}{
+		{
+			name: "pending programming first",
+			candidates: []ReleaseCandidate{
+				candidate("10.0.0.1", cns.Available, 2, time.Time{}),
+				candidate("10.0.0.2", cns.PendingProgramming, 1, time.Time{}),
+			},
+			want: []string{"10.0.0.2", "10.0.0.1"},
+		},
+		{
+			name: "highest nc version first",
+			candidates: []ReleaseCandidate{
+				candidate("10.0.0.1", cns.Available, 1, time.Time{}),
+				candidate("10.0.0.2", cns.Available, 3, now),
+				candidate("10.0.0.3", cns.Available, 2, time.Time{}),
+			},
+			want: []string{"10.0.0.2", "10.0.0.3", "10.0.0.1"},
+		},
+		{
+			name: "never allocated first, then idle the longest",
+			candidates: []ReleaseCandidate{
+				candidate("10.0.0.1", cns.Available, 1, now),
+				candidate("10.0.0.2", cns.Available, 1, now.Add(-time.Hour)),
+				candidate("10.0.0.3", cns.Available, 1, time.Time{}),
+			},
+			want: []string{"10.0.0.3", "10.0.0.2", "10.0.0.1"},
+		},
+		{
+			name: "highest address last resort",
+			candidates: []ReleaseCandidate{
+				candidate("10.0.0.2", cns.Available, 1, time.Time{}),
+				candidate("10.0.0.10", cns.Available, 1, time.Time{}),
+				candidate("10.0.0.1", cns.Available, 1, time.Time{}),
+			},
+			want: []string{"10.0.0.10", "10.0.0.2", "10.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			SortForRelease(tt.candidates)
+			got := make([]string, 0, len(tt.candidates))
+			for _, c := range tt.candidates {
+				got = append(got, c.IPAddress)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
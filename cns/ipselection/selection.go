@@ -0,0 +1,134 @@
+// Package ipselection implements the strategies with which CNS picks the IP to allocate to a pod
+// among the available ones.
+package ipselection
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/pkg/errors"
+)
+
+// Strategy names an IP selection strategy.
+type Strategy string
+
+const (
+	// Random picks any of the available IPs.
+	Random Strategy = "Random"
+	// LowestFirst picks the available IP with the lowest address, which keeps the allocations packed at
+	// the start of the subnet.
+	LowestFirst Strategy = "LowestFirst"
+	// LeastRecentlyReleased picks the available IP which was released the longest ago, so that an IP is
+	// reused as late as possible. The IPs which were never released come first, lowest address first.
+	LeastRecentlyReleased Strategy = "LeastRecentlyReleased"
+)
+
+// ErrUnknownStrategy is returned for a strategy name which is not one of the above.
+var ErrUnknownStrategy = errors.New("unknown IP selection strategy")
+
+// Selector picks the IP to allocate among the available ones. It is not safe for concurrent use, callers
+// are expected to hold the lock of the IP state.
+type Selector interface {
+	// Strategy returns the name of the strategy of the selector.
+	Strategy() Strategy
+	// Select returns the index of the candidate to allocate. The candidates must not be empty.
+	Select(candidates []cns.IPConfigurationStatus) int
+}
+
+// ReleaseTimes records when each IP was last released by a pod. It is not safe for concurrent use, callers
+// are expected to hold the lock of the IP state.
+type ReleaseTimes struct {
+	releasedAt map[string]time.Time
+	now        func() time.Time
+}
+
+// NewReleaseTimes returns empty release times which are read from the clock now.
+func NewReleaseTimes(now func() time.Time) *ReleaseTimes {
+	return &ReleaseTimes{releasedAt: map[string]time.Time{}, now: now}
+}
+
+// Released records that the IP with the ID was released.
+func (r *ReleaseTimes) Released(id string) {
+	r.releasedAt[id] = r.now()
+}
+
+// ReleasedAt returns when the IP with the ID was last released, zero if it never was.
+func (r *ReleaseTimes) ReleasedAt(id string) time.Time {
+	return r.releasedAt[id]
+}
+
+// Forget drops the release time of the IP with the ID, once the IP is removed from the pool.
+func (r *ReleaseTimes) Forget(id string) {
+	delete(r.releasedAt, id)
+}
+
+// New returns the selector of the strategy, Random if it is empty. The LeastRecentlyReleased selector picks
+// the IPs in the order of the release times.
+func New(strategy Strategy, releases *ReleaseTimes) (Selector, error) {
+	switch strategy {
+	case "", Random:
+		return &random{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil //nolint:gosec // not security sensitive
+	case LowestFirst:
+		return lowestFirst{}, nil
+	case LeastRecentlyReleased:
+		return &leastRecentlyReleased{releases: releases}, nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownStrategy, "%q", strategy)
+	}
+}
+
+type random struct {
+	rand *rand.Rand
+}
+
+func (*random) Strategy() Strategy { return Random }
+
+func (r *random) Select(candidates []cns.IPConfigurationStatus) int {
+	return r.rand.Intn(len(candidates))
+}
+
+type lowestFirst struct{}
+
+func (lowestFirst) Strategy() Strategy { return LowestFirst }
+
+func (lowestFirst) Select(candidates []cns.IPConfigurationStatus) int {
+	lowest := 0
+	for i := 1; i < len(candidates); i++ {
+		if lessAddress(candidates[i], candidates[lowest]) {
+			lowest = i
+		}
+	}
+	return lowest
+}
+
+type leastRecentlyReleased struct {
+	releases *ReleaseTimes
+}
+
+func (*leastRecentlyReleased) Strategy() Strategy { return LeastRecentlyReleased }
+
+func (l *leastRecentlyReleased) Select(candidates []cns.IPConfigurationStatus) int {
+	oldest := 0
+	for i := 1; i < len(candidates); i++ {
+		releasedAt, oldestReleasedAt := l.releases.ReleasedAt(candidates[i].ID), l.releases.ReleasedAt(candidates[oldest].ID)
+		if releasedAt.Before(oldestReleasedAt) || releasedAt.Equal(oldestReleasedAt) && lessAddress(candidates[i], candidates[oldest]) {
+			oldest = i
+		}
+	}
+	return oldest
+}
+
+// lessAddress orders the IPs by address, with the unparsable ones last.
+func lessAddress(a, b cns.IPConfigurationStatus) bool {
+	ipA, ipB := net.ParseIP(a.IPAddress), net.ParseIP(b.IPAddress)
+	switch {
+	case ipA == nil:
+		return false
+	case ipB == nil:
+		return true
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+}
@@ -0,0 +1,96 @@
+package ipselection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func candidates(ips ...string) []cns.IPConfigurationStatus {
+	out := make([]cns.IPConfigurationStatus, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, cns.IPConfigurationStatus{ID: "id-" + ip, IPAddress: ip})
+	}
+	return out
+}
+
+// drain selects from the candidates until they are exhausted, and returns the IPs in selection order.
+func drain(s Selector, c []cns.IPConfigurationStatus) []string {
+	var order []string
+	for len(c) > 0 {
+		i := s.Select(c)
+		order = append(order, c[i].IPAddress)
+		c = append(c[:i], c[i+1:]...)
+	}
+	return order
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range []Strategy{"", Random, LowestFirst, LeastRecentlyReleased} {
+		s, err := New(strategy, NewReleaseTimes(time.Now))
+		require.NoError(t, err)
+		if strategy == "" {
+			strategy = Random
+		}
+		assert.Equal(t, strategy, s.Strategy())
+	}
+
+	_, err := New("MostRecentlyUsed", NewReleaseTimes(time.Now))
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestLowestFirst(t *testing.T) {
+	s, err := New(LowestFirst, NewReleaseTimes(time.Now))
+	require.NoError(t, err)
+
+	order := drain(s, candidates("10.0.0.10", "10.0.0.9", "10.0.1.1", "not-an-ip", "10.0.0.2"))
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.9", "10.0.0.10", "10.0.1.1", "not-an-ip"}, order)
+}
+
+func TestLeastRecentlyReleased(t *testing.T) {
+	now := time.Unix(0, 0)
+	releases := NewReleaseTimes(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+	s, err := New(LeastRecentlyReleased, releases)
+	require.NoError(t, err)
+
+	releases.Released("id-10.0.0.1")
+	releases.Released("id-10.0.0.3")
+	releases.Released("id-10.0.0.2")
+
+	// never released first, lowest address first, then in release order
+	order := drain(s, candidates("10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.5", "10.0.0.4"))
+	assert.Equal(t, []string{"10.0.0.4", "10.0.0.5", "10.0.0.1", "10.0.0.3", "10.0.0.2"}, order)
+
+	// a release moves the IP to the back
+	releases.Released("id-10.0.0.1")
+	order = drain(s, candidates("10.0.0.1", "10.0.0.2", "10.0.0.3"))
+	assert.Equal(t, []string{"10.0.0.3", "10.0.0.2", "10.0.0.1"}, order)
+}
+
+func TestReleaseTimes(t *testing.T) {
+	now := time.Unix(0, 0)
+	releases := NewReleaseTimes(func() time.Time { return now })
+
+	assert.True(t, releases.ReleasedAt("id-10.0.0.1").IsZero())
+
+	releases.Released("id-10.0.0.1")
+	assert.Equal(t, now, releases.ReleasedAt("id-10.0.0.1"))
+
+	releases.Forget("id-10.0.0.1")
+	assert.True(t, releases.ReleasedAt("id-10.0.0.1").IsZero())
+}
+
+func TestRandom(t *testing.T) {
+	s, err := New(Random, NewReleaseTimes(time.Now))
+	require.NoError(t, err)
+
+	ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}
+	order := drain(s, candidates(ips...))
+	assert.ElementsMatch(t, ips, order)
+}
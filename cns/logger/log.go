@@ -34,13 +34,34 @@ func InitLogger(fileName string, logLevel, logTarget int, logDir string) {
 	Log = &CNSLogger{
 		logger: log.NewLogger(fileName, logLevel, logTarget, logDir),
 	}
+	Log.logger.SetHook(func(_ int, line string) {
+		if Log.th == nil || Log.DisableTraceLogging {
+			return
+		}
+		sendTraceInternal(line)
+	})
 }
 
-// Intialize CNS AI telmetry instance
-func InitAI(aiConfig aitelemetry.AIConfig, disableTraceLogging, disableMetricLogging bool, disableEventLogging bool) {
+// SetFormat sets the format of the CNS log entries.
+func SetFormat(format int) {
+	Log.logger.SetFormat(format)
+}
+
+// SetComponentLevel overrides the log level of the CNS component.
+func SetComponentLevel(component string, level int) {
+	Log.logger.SetComponentLevel(component, level)
+}
+
+// Component returns a structured logger for the CNS component, whose entries are also sent to AI telemetry.
+func Component(name string) *log.Entry {
+	return Log.logger.Component(name)
+}
+
+// Intialize CNS AI telmetry instance, sending to the sinks if any
+func InitAI(aiConfig aitelemetry.AIConfig, sinks []aitelemetry.SinkConfig, disableTraceLogging, disableMetricLogging bool, disableEventLogging bool) {
 	var err error
 
-	Log.th, err = aitelemetry.NewAITelemetry("", aiMetadata, aiConfig)
+	Log.th, err = aitelemetry.NewTelemetry("", aiMetadata, aiConfig, sinks)
 	if err != nil {
 		Log.logger.Errorf("Error initializing AI Telemetry:%v", err)
 		return
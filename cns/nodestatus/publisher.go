@@ -0,0 +1,145 @@
+// Package nodestatus surfaces the health of CNS in the Kubernetes API, so that it is visible
+// with kubectl describe on the Node and its NodeNetworkConfig.
+package nodestatus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+)
+
+const component = "azure-cns"
+
+type cnsStatusPatcher interface {
+	PatchCNSStatus(context.Context, *v1alpha.CNSStatus) (*v1alpha.NodeNetworkConfig, error)
+}
+
+// Publisher records Events on the Node and its NodeNetworkConfig, and maintains the conditions
+// in the CNS section of the NodeNetworkConfig status.
+type Publisher struct {
+	recorder record.EventRecorder
+	cli      cnsStatusPatcher
+	node     *corev1.ObjectReference
+	sync.Mutex
+	nnc        corev1.ObjectReference
+	conditions []metav1.Condition
+	observed   bool // the conditions have been seeded from the NodeNetworkConfig
+	dirty      bool // the last patch of the conditions failed
+}
+
+// NewEventRecorder returns an EventRecorder which sends the events to the API server as coming from CNS on the node.
+func NewEventRecorder(kubeConfig *rest.Config, nodeName string) (record.EventRecorder, error) {
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create event clientset")
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(clientgoscheme.Scheme, corev1.EventSource{Component: component, Host: nodeName}), nil
+}
+
+// NewPublisher creates a Publisher for the Node and the NodeNetworkConfig identified by nncKey.
+func NewPublisher(recorder record.EventRecorder, cli cnsStatusPatcher, nodeName string, nncKey types.NamespacedName) *Publisher {
+	return &Publisher{
+		recorder: recorder,
+		cli:      cli,
+		// the kubelet uses the node name as the UID of the Node in its events, which kubectl describe also looks for.
+		node: &corev1.ObjectReference{
+			Kind: "Node",
+			Name: nodeName,
+			UID:  types.UID(nodeName),
+		},
+		nnc: corev1.ObjectReference{
+			APIVersion: v1alpha.GroupVersion.String(),
+			Kind:       "NodeNetworkConfig",
+			Namespace:  nncKey.Namespace,
+			Name:       nncKey.Name,
+		},
+	}
+}
+
+// Eventf records an event on both the Node and its NodeNetworkConfig.
+func (p *Publisher) Eventf(eventType, reason, messageFmt string, args ...interface{}) {
+	p.Lock()
+	nnc := p.nnc
+	p.Unlock()
+	logger.Printf("[cns-nodestatus] %s event %s: "+messageFmt, append([]interface{}{eventType, reason}, args...)...)
+	p.recorder.Eventf(p.node, eventType, reason, messageFmt, args...)
+	p.recorder.Eventf(&nnc, eventType, reason, messageFmt, args...)
+}
+
+// Observe ingests the latest NodeNetworkConfig. The first one seeds the conditions so that their transition
+// times survive a restart of CNS, and later ones republish the conditions if they were lost from the status.
+func (p *Publisher) Observe(ctx context.Context, nnc *v1alpha.NodeNetworkConfig) {
+	p.Lock()
+	defer p.Unlock()
+	p.nnc.UID = nnc.UID
+
+	if !p.observed {
+		p.observed = true
+		for _, condition := range nnc.Status.CNS.Conditions {
+			if meta.FindStatusCondition(p.conditions, condition.Type) == nil {
+				p.conditions = append(p.conditions, condition)
+			}
+		}
+	}
+
+	if p.dirty || !conditionsEqual(p.conditions, nnc.Status.CNS.Conditions) {
+		p.patch(ctx)
+	}
+}
+
+// SetCondition sets the condition in the CNS status of the NodeNetworkConfig. The status is only patched
+// when the condition changes.
+func (p *Publisher) SetCondition(ctx context.Context, condition metav1.Condition) {
+	p.Lock()
+	defer p.Unlock()
+
+	if existing := meta.FindStatusCondition(p.conditions, condition.Type); !p.dirty && existing != nil && conditionEqual(*existing, condition) {
+		return
+	}
+	meta.SetStatusCondition(&p.conditions, condition)
+	p.patch(ctx)
+}
+
+func (p *Publisher) patch(ctx context.Context) {
+	status := &v1alpha.CNSStatus{
+		Conditions: append([]metav1.Condition{}, p.conditions...),
+	}
+	if _, err := p.cli.PatchCNSStatus(ctx, status); err != nil {
+		logger.Errorf("[cns-nodestatus] Failed to publish conditions %+v: %v", status.Conditions, err)
+		p.dirty = true
+		return
+	}
+	p.dirty = false
+}
+
+// conditionEqual compares the conditions ignoring the transition time, which loses precision in the API server.
+func conditionEqual(a, b metav1.Condition) bool { //nolint:gocritic // ignore hugeParam
+	return a.Type == b.Type && a.Status == b.Status && a.Reason == b.Reason && a.Message == b.Message && a.ObservedGeneration == b.ObservedGeneration
+}
+
+func conditionsEqual(a, b []metav1.Condition) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		other := meta.FindStatusCondition(b, a[i].Type)
+		if other == nil || !conditionEqual(a[i], *other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package nodestatus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+type mockPatcher struct {
+	patches []*v1alpha.CNSStatus
+	err     error
+}
+
+func (m *mockPatcher) PatchCNSStatus(_ context.Context, status *v1alpha.CNSStatus) (*v1alpha.NodeNetworkConfig, error) {
+	m.patches = append(m.patches, status)
+	return nil, m.err
+}
+
+var poolExhausted = metav1.Condition{
+	Type:    v1alpha.IPPoolReady,
+	Status:  metav1.ConditionFalse,
+	Reason:  v1alpha.ReasonPoolExhausted,
+	Message: "no free IPs",
+}
+
+func newTestPublisher() (*Publisher, *record.FakeRecorder, *mockPatcher) {
+	logger.InitLogger("testlogs", 0, 0, "./")
+	recorder := record.NewFakeRecorder(10)
+	patcher := &mockPatcher{}
+	return NewPublisher(recorder, patcher, "node1", types.NamespacedName{Namespace: "kube-system", Name: "node1"}), recorder, patcher
+}
+
+func TestEventf(t *testing.T) {
+	p, recorder, _ := newTestPublisher()
+	p.Eventf(corev1.EventTypeWarning, v1alpha.ReasonPoolExhausted, "%d IPs allocated", 30)
+	// one for the Node and one for the NodeNetworkConfig
+	assert.Equal(t, "Warning PoolExhausted 30 IPs allocated", <-recorder.Events)
+	assert.Equal(t, "Warning PoolExhausted 30 IPs allocated", <-recorder.Events)
+}
+
+func TestSetConditionOnlyPatchesChanges(t *testing.T) {
+	p, _, patcher := newTestPublisher()
+	ctx := context.Background()
+
+	p.SetCondition(ctx, poolExhausted)
+	require.Len(t, patcher.patches, 1)
+	assert.Equal(t, v1alpha.ReasonPoolExhausted, patcher.patches[0].Conditions[0].Reason)
+	assert.False(t, patcher.patches[0].Conditions[0].LastTransitionTime.IsZero())
+
+	p.SetCondition(ctx, poolExhausted)
+	assert.Len(t, patcher.patches, 1)
+
+	ready := metav1.Condition{Type: v1alpha.IPPoolReady, Status: metav1.ConditionTrue, Reason: v1alpha.ReasonPoolReady}
+	p.SetCondition(ctx, ready)
+	require.Len(t, patcher.patches, 2)
+	assert.Len(t, patcher.patches[1].Conditions, 1)
+	assert.Equal(t, metav1.ConditionTrue, patcher.patches[1].Conditions[0].Status)
+}
+
+func TestSetConditionRetriesFailedPatch(t *testing.T) {
+	p, _, patcher := newTestPublisher()
+	ctx := context.Background()
+
+	patcher.err = errors.New("conflict")
+	p.SetCondition(ctx, poolExhausted)
+	patcher.err = nil
+	p.SetCondition(ctx, poolExhausted)
+	assert.Len(t, patcher.patches, 2)
+	p.SetCondition(ctx, poolExhausted)
+	assert.Len(t, patcher.patches, 2)
+}
+
+func TestObserve(t *testing.T) {
+	p, _, patcher := newTestPublisher()
+	ctx := context.Background()
+
+	// the conditions published before a restart are kept
+	nnc := &v1alpha.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{UID: "uid"},
+		Status: v1alpha.NodeNetworkConfigStatus{
+			CNS: v1alpha.CNSStatus{Conditions: []metav1.Condition{poolExhausted}},
+		},
+	}
+	p.Observe(ctx, nnc)
+	assert.Empty(t, patcher.patches)
+	assert.Equal(t, types.UID("uid"), p.nnc.UID)
+	p.SetCondition(ctx, poolExhausted)
+	assert.Empty(t, patcher.patches)
+
+	// conditions lost from the status are republished
+	p.Observe(ctx, &v1alpha.NodeNetworkConfig{})
+	require.Len(t, patcher.patches, 1)
+	assert.Equal(t, []metav1.Condition{poolExhausted}, patcher.patches[0].Conditions)
+}
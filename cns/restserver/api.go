@@ -21,6 +21,8 @@ import (
 	"github.com/Azure/azure-container-networking/cns/wireserver"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/platform"
+	"github.com/Azure/azure-container-networking/tracing"
+	"github.com/pkg/errors"
 )
 
 // This file contains implementation of all HTTP APIs which are exposed to external clients.
@@ -772,6 +774,8 @@ func (service *HTTPRestService) setOrchestratorType(w http.ResponseWriter, r *ht
 
 func (service *HTTPRestService) createOrUpdateNetworkContainer(w http.ResponseWriter, r *http.Request) {
 	logger.Printf("[Azure CNS] createOrUpdateNetworkContainer")
+	_, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "cns.createOrUpdateNetworkContainer")
+	defer span.End()
 
 	var req cns.CreateNetworkContainerRequest
 	err := service.Listener.Decode(w, r, &req)
@@ -825,6 +829,10 @@ func (service *HTTPRestService) createOrUpdateNetworkContainer(w http.ResponseWr
 		Message:    returnMessage,
 	}
 
+	if returnCode != types.Success {
+		tracing.RecordError(span, errors.New(returnMessage))
+	}
+
 	reserveResp := &cns.CreateNetworkContainerResponse{Response: resp}
 	err = service.Listener.Encode(w, &reserveResp)
 
@@ -926,19 +934,7 @@ func (service *HTTPRestService) deleteNetworkContainer(w http.ResponseWriter, r
 		service.Lock()
 		defer service.Unlock()
 
-		if service.state.ContainerStatus != nil {
-			delete(service.state.ContainerStatus, req.NetworkContainerid)
-		}
-
-		if service.state.ContainerIDByOrchestratorContext != nil {
-			for orchestratorContext, networkContainerID := range service.state.ContainerIDByOrchestratorContext {
-				if networkContainerID == req.NetworkContainerid {
-					delete(service.state.ContainerIDByOrchestratorContext, orchestratorContext)
-					break
-				}
-			}
-		}
-
+		service.deleteNetworkContainerUntransacted(req.NetworkContainerid)
 		service.saveState()
 	default:
 		returnMessage = "[Azure CNS] Error. DeleteNetworkContainer did not receive a POST."
@@ -0,0 +1,121 @@
+package restserver
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+)
+
+// egressSNATPodName names the pod the egress SNAT IP of a namespace is allocated to. Pod names are lowercase, so it
+// never collides with a pod of the namespace.
+const egressSNATPodName = "EgressSNAT"
+
+var errInvalidEgressSNATNamespace = errors.New("pod namespace is required")
+
+// egressSNATPodInfo returns the pod info the egress SNAT IP of the namespace is allocated to.
+func egressSNATPodInfo(namespace string) cns.PodInfo {
+	return cns.NewPodInfo("", egressSNATPodName+"-"+namespace, egressSNATPodName, namespace)
+}
+
+// RequestEgressSNATIP returns the egress SNAT IP of the namespace, allocating a secondary IP to it on the first
+// request. The IP is reserved for the namespace, so that it stays the same across CNS restarts until it is released.
+func (service *HTTPRestService) RequestEgressSNATIP(namespace string) (string, error) {
+	if namespace == "" {
+		return "", errInvalidEgressSNATNamespace
+	}
+
+	service.egressSNATLock.Lock()
+	defer service.egressSNATLock.Unlock()
+
+	podInfo := egressSNATPodInfo(namespace)
+	podIPInfo, exists, err := service.GetExistingIPConfig(podInfo)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return podIPInfo.PodIPConfig.IPAddress, nil
+	}
+
+	// The IP reserved for the namespace is allocated back to it.
+	if podIPInfo, err = service.AllocateAnyAvailableIPConfig(podInfo, ""); err != nil {
+		return "", errors.Wrapf(err, "failed to allocate the egress SNAT IP of namespace %s", namespace)
+	}
+
+	if _, err = service.ReserveIPConfig(podIdentity(podInfo), podIPInfo.PodIPConfig.IPAddress, defaultIPReservationTTL); err != nil {
+		if er := service.releaseIPConfig(podInfo); er != nil {
+			logger.Errorf("[RequestEgressSNATIP] Failed to release IP %s of namespace %s: %v", podIPInfo.PodIPConfig.IPAddress, namespace, er)
+		}
+		return "", err
+	}
+
+	logger.Printf("[RequestEgressSNATIP] Allocated egress SNAT IP %s to namespace %s", podIPInfo.PodIPConfig.IPAddress, namespace)
+	return podIPInfo.PodIPConfig.IPAddress, nil
+}
+
+// ReleaseEgressSNATIP releases the egress SNAT IP of the namespace and its reservation.
+func (service *HTTPRestService) ReleaseEgressSNATIP(namespace string) error {
+	if namespace == "" {
+		return errInvalidEgressSNATNamespace
+	}
+
+	service.egressSNATLock.Lock()
+	defer service.egressSNATLock.Unlock()
+
+	podInfo := egressSNATPodInfo(namespace)
+	if err := service.UnreserveIPConfig(podIdentity(podInfo)); err != nil && !errors.Is(err, errIPReservationNotFound) {
+		return err
+	}
+
+	logger.Printf("[ReleaseEgressSNATIP] Releasing egress SNAT IP of namespace %s", namespace)
+	return service.releaseIPConfig(podInfo)
+}
+
+func (service *HTTPRestService) requestEgressSNATIPHandler(w http.ResponseWriter, r *http.Request) {
+	var req cns.EgressSNATIPRequest
+	err := service.Listener.Decode(w, r, &req)
+	logger.Request(service.Name+"requestEgressSNATIPHandler", req, err)
+	if err == nil {
+		var resp cns.EgressSNATIPResponse
+		resp.IPAddress, err = service.RequestEgressSNATIP(req.PodNamespace)
+		if err == nil {
+			err = service.Listener.Encode(w, &resp)
+			logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+			return
+		}
+		logger.Errorf("requestEgressSNATIPHandler failed: %v", err)
+	}
+
+	resp := cns.EgressSNATIPResponse{
+		Response: cns.Response{ReturnCode: egressSNATReturnCode(err), Message: err.Error()},
+	}
+	err = service.Listener.Encode(w, &resp)
+	logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+}
+
+func (service *HTTPRestService) releaseEgressSNATIPHandler(w http.ResponseWriter, r *http.Request) {
+	var req cns.EgressSNATIPRequest
+	err := service.Listener.Decode(w, r, &req)
+	logger.Request(service.Name+"releaseEgressSNATIPHandler", req, err)
+	if err == nil {
+		err = service.ReleaseEgressSNATIP(req.PodNamespace)
+	}
+
+	var resp cns.Response
+	if err != nil {
+		resp = cns.Response{ReturnCode: egressSNATReturnCode(err), Message: err.Error()}
+		logger.Errorf("releaseEgressSNATIPHandler failed: %v", err)
+	}
+
+	err = service.Listener.Encode(w, &resp)
+	logger.ResponseEx(service.Name, req, resp, resp.ReturnCode, err)
+}
+
+func egressSNATReturnCode(err error) types.ResponseCode {
+	if errors.Is(err, errInvalidEgressSNATNamespace) {
+		return types.InvalidRequest
+	}
+	return types.UnexpectedError
+}
@@ -0,0 +1,73 @@
+package restserver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequestEgressSNATIPIsStableForTheNamespace(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1, testPod2GUID: testIP2})
+
+	egressIP, err := svc.RequestEgressSNATIP("egress-ns")
+	if err != nil {
+		t.Fatalf("Unexpected failure requesting egress SNAT IP: %+v", err)
+	}
+
+	// the pods of the namespace share its egress SNAT IP
+	ip, err := svc.RequestEgressSNATIP("egress-ns")
+	if err != nil || ip != egressIP {
+		t.Fatalf("Expected egress SNAT IP %s for the namespace, actual %s, err %+v", egressIP, ip, err)
+	}
+
+	// the egress SNAT IP is not handed out to pods
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil {
+		t.Fatalf("Unexpected failure allocating IP: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress == egressIP {
+		t.Fatalf("Expected egress SNAT IP %s not to be allocated to a pod", egressIP)
+	}
+	if _, err = svc.RequestEgressSNATIP("other-ns"); err == nil {
+		t.Fatalf("Expected egress SNAT IP request to fail with no IP available")
+	}
+
+	// the reservation gives the namespace its IP back once the IP is no longer allocated to it, as after a restart
+	if err = svc.releaseIPConfig(egressSNATPodInfo("egress-ns")); err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+	ip, err = svc.RequestEgressSNATIP("egress-ns")
+	if err != nil || ip != egressIP {
+		t.Fatalf("Expected reserved egress SNAT IP %s back for the namespace, actual %s, err %+v", egressIP, ip, err)
+	}
+}
+
+func TestReleaseEgressSNATIP(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1})
+
+	if _, err := svc.RequestEgressSNATIP(""); !errors.Is(err, errInvalidEgressSNATNamespace) {
+		t.Fatalf("Expected invalid namespace error, actual %+v", err)
+	}
+
+	egressIP, err := svc.RequestEgressSNATIP("egress-ns")
+	if err != nil {
+		t.Fatalf("Unexpected failure requesting egress SNAT IP: %+v", err)
+	}
+
+	if err = svc.ReleaseEgressSNATIP("egress-ns"); err != nil {
+		t.Fatalf("Unexpected failure releasing egress SNAT IP: %+v", err)
+	}
+	if reservations := svc.GetIPReservations(); len(reservations) != 0 {
+		t.Fatalf("Expected no reservation once the egress SNAT IP is released, actual %+v", reservations)
+	}
+
+	// the released IP goes back to the pods
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil || podIPInfo.PodIPConfig.IPAddress != egressIP {
+		t.Fatalf("Expected released egress SNAT IP %s to be allocated to the pod, actual %+v, err %+v", egressIP, podIPInfo, err)
+	}
+
+	// releasing again is a no-op
+	if err = svc.ReleaseEgressSNATIP("egress-ns"); err != nil {
+		t.Fatalf("Unexpected failure releasing egress SNAT IP twice: %+v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
 	"strconv"
 
 	"github.com/Azure/azure-container-networking/cns"
@@ -214,21 +215,40 @@ func (service *HTTPRestService) SyncHostNCVersion(ctx context.Context, channelMo
 
 // This API will be called by CNS RequestController on CRD update.
 func (service *HTTPRestService) ReconcileNCState(
-	ncRequest *cns.CreateNetworkContainerRequest, podInfoByIP map[string]cns.PodInfo, nnc *v1alpha.NodeNetworkConfig) types.ResponseCode {
+	ncRequests []cns.CreateNetworkContainerRequest, podInfoByIP map[string]cns.PodInfo, nnc *v1alpha.NodeNetworkConfig) types.ResponseCode {
 	logger.Printf("Reconciling NC state with podInfo %+v", podInfoByIP)
-	// check if ncRequest is null, then return as there is no CRD state yet
-	if ncRequest == nil {
+	// check if there are no ncRequests, then return as there is no CRD state yet
+	if len(ncRequests) == 0 {
 		logger.Printf("CNS starting with no NC state, podInfoMap count %d", len(podInfoByIP))
 		return types.Success
 	}
 
-	// If the NC was created successfully, then reconcile the allocated pod state
-	returnCode := service.CreateOrUpdateNetworkContainerInternal(ncRequest)
-	if returnCode != types.Success {
-		return returnCode
+	// If the NCs were created successfully, then reconcile the allocated pod state
+	for i := range ncRequests {
+		returnCode := service.CreateOrUpdateNetworkContainerInternal(&ncRequests[i])
+		if returnCode != types.Success {
+			return returnCode
+		}
 	}
 	service.IPAMPoolMonitor.Update(nnc)
 
+	for i := range ncRequests {
+		if returnCode := service.reconcileAllocatedIPs(&ncRequests[i], podInfoByIP); returnCode != types.Success {
+			return returnCode
+		}
+	}
+
+	err := service.MarkExistingIPsAsPending(nnc.Spec.IPsNotInUse)
+	if err != nil {
+		logger.Errorf("[Azure CNS] Error. Failed to mark IP's as pending %v", nnc.Spec.IPsNotInUse)
+		return types.UnexpectedError
+	}
+
+	return 0
+}
+
+// reconcileAllocatedIPs allocates the secondary IPs of the NC which exist in the PodInfo list to their pods.
+func (service *HTTPRestService) reconcileAllocatedIPs(ncRequest *cns.CreateNetworkContainerRequest, podInfoByIP map[string]cns.PodInfo) types.ResponseCode {
 	// now parse the secondaryIP list, if it exists in PodInfo list, then allocate that ip
 	for _, secIpConfig := range ncRequest.SecondaryIPConfigs {
 		if podInfo, exists := podInfoByIP[secIpConfig.IPAddress]; exists {
@@ -255,14 +275,7 @@ func (service *HTTPRestService) ReconcileNCState(
 			logger.Printf("SecondaryIP %+v is not allocated. ncId: %s", secIpConfig, ncRequest.NetworkContainerid)
 		}
 	}
-
-	err := service.MarkExistingIPsAsPending(nnc.Spec.IPsNotInUse)
-	if err != nil {
-		logger.Errorf("[Azure CNS] Error. Failed to mark IP's as pending %v", nnc.Spec.IPsNotInUse)
-		return types.UnexpectedError
-	}
-
-	return 0
+	return types.Success
 }
 
 // GetNetworkContainerInternal gets network container details.
@@ -286,21 +299,92 @@ func (service *HTTPRestService) DeleteNetworkContainerInternal(
 
 	service.Lock()
 	defer service.Unlock()
+	service.deleteNetworkContainerUntransacted(req.NetworkContainerid)
+	service.saveState()
+	return types.Success
+}
+
+// deleteNetworkContainerUntransacted removes the NC from the service state.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) deleteNetworkContainerUntransacted(ncID string) {
 	if service.state.ContainerStatus != nil {
-		delete(service.state.ContainerStatus, req.NetworkContainerid)
+		delete(service.state.ContainerStatus, ncID)
 	}
 
 	if service.state.ContainerIDByOrchestratorContext != nil {
 		for orchestratorContext, networkContainerID := range service.state.ContainerIDByOrchestratorContext {
-			if networkContainerID == req.NetworkContainerid {
+			if networkContainerID == ncID {
 				delete(service.state.ContainerIDByOrchestratorContext, orchestratorContext)
 				break
 			}
 		}
 	}
 
-	service.saveState()
-	return types.Success
+	for orchestratorContext, networkContainerIDs := range service.state.PodInterfaceContainerIDs {
+		for ifName, networkContainerID := range networkContainerIDs {
+			if networkContainerID == ncID {
+				delete(networkContainerIDs, ifName)
+			}
+		}
+		if len(networkContainerIDs) == 0 {
+			delete(service.state.PodInterfaceContainerIDs, orchestratorContext)
+		}
+	}
+}
+
+// RemoveStaleNetworkContainers drains the NCs created by the RequestController which are not in activeNCIDs.
+// The unallocated IPs of a stale NC are removed from the pool right away so they are no longer handed out,
+// and the NC is deleted once none of its IPs are allocated. It returns the IDs of the NCs which are still draining.
+func (service *HTTPRestService) RemoveStaleNetworkContainers(activeNCIDs []string) []string {
+	active := make(map[string]struct{}, len(activeNCIDs))
+	for _, ncID := range activeNCIDs {
+		active[ncID] = struct{}{}
+	}
+
+	service.Lock()
+	defer service.Unlock()
+
+	allocatedIPCountByNC := map[string]int{}
+	for _, ipConfig := range service.PodIPConfigState {
+		if ipConfig.State == cns.Allocated {
+			allocatedIPCountByNC[ipConfig.NCID]++
+		}
+	}
+
+	var draining []string
+	drainingNCs := map[string]struct{}{}
+	stateChanged := false
+	for ncID, ncInfo := range service.state.ContainerStatus {
+		// only NCs created from the NodeNetworkConfig are managed here
+		if _, ok := active[ncID]; ok || ncInfo.CreateNetworkContainerRequest.NetworkContainerType != cns.Docker {
+			continue
+		}
+
+		for ipID, ipConfig := range service.PodIPConfigState {
+			if ipConfig.NCID == ncID && ipConfig.State != cns.Allocated {
+				service.removeToBeDeletedIPStateUntransacted(ipID, true)
+				stateChanged = true
+			}
+		}
+
+		if allocatedIPCountByNC[ncID] > 0 {
+			logger.Printf("[Azure CNS] NC %s was removed from the NNC, waiting for %d allocated IPs to be released", ncID, allocatedIPCountByNC[ncID])
+			drainingNCs[ncID] = struct{}{}
+			draining = append(draining, ncID)
+			continue
+		}
+
+		logger.Printf("[Azure CNS] NC %s was removed from the NNC and has no allocated IPs, deleting it", ncID)
+		service.deleteNetworkContainerUntransacted(ncID)
+		stateChanged = true
+	}
+	service.drainingNCs = drainingNCs
+
+	if stateChanged {
+		service.saveState()
+	}
+	sort.Strings(draining)
+	return draining
 }
 
 // This API will be called by CNS RequestController on CRD update.
@@ -241,7 +241,7 @@ func TestReconcileNCWithExistingState(t *testing.T) {
 	}
 
 	expectedNcCount := len(svc.state.ContainerStatus)
-	returnCode := svc.ReconcileNCState(req, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
+	returnCode := svc.ReconcileNCState([]cns.CreateNetworkContainerRequest{*req}, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
 		Status: v1alpha.NodeNetworkConfigStatus{
 			Scaler: v1alpha.Scaler{
 				BatchSize:               batchSize,
@@ -285,7 +285,7 @@ func TestReconcileNCWithExistingStateFromInterfaceID(t *testing.T) {
 	}
 
 	expectedNcCount := len(svc.state.ContainerStatus)
-	returnCode := svc.ReconcileNCState(req, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
+	returnCode := svc.ReconcileNCState([]cns.CreateNetworkContainerRequest{*req}, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
 		Status: v1alpha.NodeNetworkConfigStatus{
 			Scaler: v1alpha.Scaler{
 				BatchSize:               batchSize,
@@ -328,7 +328,7 @@ func TestReconcileNCWithSystemPods(t *testing.T) {
 	expectedAllocatedPods["192.168.0.1"] = cns.NewPodInfo("", "", "systempod", "kube-system")
 
 	expectedNcCount := len(svc.state.ContainerStatus)
-	returnCode := svc.ReconcileNCState(req, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
+	returnCode := svc.ReconcileNCState([]cns.CreateNetworkContainerRequest{*req}, expectedAllocatedPods, &v1alpha.NodeNetworkConfig{
 		Status: v1alpha.NodeNetworkConfigStatus{
 			Scaler: v1alpha.Scaler{
 				BatchSize:               batchSize,
@@ -622,3 +622,52 @@ func restartService() {
 		os.Exit(1)
 	}
 }
+
+func TestPodInterfaceConfigs(t *testing.T) {
+	svc := getTestService()
+	svc.state.ContainerStatus = map[string]containerstatus{
+		"nc-storage": {
+			ID: "nc-storage",
+			CreateNetworkContainerRequest: cns.CreateNetworkContainerRequest{
+				NetworkContainerid: "nc-storage",
+				PodInterfaceName:   "eth2",
+				IPConfiguration: cns.IPConfiguration{
+					IPSubnet:         cns.IPSubnet{IPAddress: "11.1.0.5", PrefixLength: subnetPrfixLength},
+					GatewayIPAddress: "11.1.0.1",
+				},
+			},
+		},
+		"nc-management": {
+			ID: "nc-management",
+			CreateNetworkContainerRequest: cns.CreateNetworkContainerRequest{
+				NetworkContainerid: "nc-management",
+				PodInterfaceName:   "eth1",
+			},
+		},
+	}
+	svc.state.PodInterfaceContainerIDs = map[string]map[string]string{
+		"testpodtestpodnamespace": {"eth2": "nc-storage", "eth1": "nc-management", "eth3": "nc-missing"},
+	}
+
+	configs := svc.podInterfaceConfigsUntransacted(svc.state.PodInterfaceContainerIDs["testpodtestpodnamespace"])
+	if len(configs) != 2 {
+		t.Fatalf("Expected the configs of the 2 existing NCs, actual %+v", configs)
+	}
+	if configs[0].InterfaceName != "eth1" || configs[0].NetworkContainerID != "nc-management" {
+		t.Fatalf("Expected eth1 backed by nc-management first, actual %+v", configs[0])
+	}
+	if configs[1].InterfaceName != "eth2" || configs[1].IPConfiguration.IPSubnet.IPAddress != "11.1.0.5" {
+		t.Fatalf("Expected eth2 with the ip of nc-storage second, actual %+v", configs[1])
+	}
+
+	svc.deleteNetworkContainerUntransacted("nc-management")
+	svc.deleteNetworkContainerUntransacted("nc-missing")
+	if ids := svc.state.PodInterfaceContainerIDs["testpodtestpodnamespace"]; len(ids) != 1 || ids["eth2"] != "nc-storage" {
+		t.Fatalf("Expected only eth2 to remain, actual %+v", ids)
+	}
+
+	svc.deleteNetworkContainerUntransacted("nc-storage")
+	if _, ok := svc.state.PodInterfaceContainerIDs["testpodtestpodnamespace"]; ok {
+		t.Fatalf("Expected the pod to be removed, actual %+v", svc.state.PodInterfaceContainerIDs)
+	}
+}
@@ -5,18 +5,32 @@ package restserver
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/filter"
+	"github.com/Azure/azure-container-networking/cns/ipselection"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 )
 
+const ipamComponent = "cns-ipam"
+
+// requestLogger returns a structured logger with the correlation ID sent by the caller of the request.
+func requestLogger(r *http.Request) *log.Entry {
+	return logger.Component(ipamComponent).With(log.CorrelationFromHeaders(r.Header).KeysAndValues()...)
+}
+
 // used to request an IPConfig from the CNS state
 func (service *HTTPRestService) requestIPConfigHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "cns.requestIPConfigHandler")
+	defer span.End()
+
 	var ipconfigRequest cns.IPConfigRequest
 	err := service.Listener.Decode(w, r, &ipconfigRequest)
 	operationName := "requestIPConfigHandler"
@@ -42,8 +56,15 @@ func (service *HTTPRestService) requestIPConfigHandler(w http.ResponseWriter, r
 	// record a pod requesting an IP
 	service.podsPendingIPAllocation.Push(podInfo.Key())
 
-	podIPInfo, err := requestIPConfigHelper(service, ipconfigRequest)
+	var podIPInfo cns.PodIpInfo
+	err = tracing.Trace(ctx, "cns.AllocateIPConfig", func() error {
+		var allocErr error
+		podIPInfo, allocErr = requestIPConfigHelper(service, ipconfigRequest)
+		return allocErr
+	})
 	if err != nil {
+		tracing.RecordError(span, err)
+		requestLogger(r).Error(err, "Failed to allocate IP", "podInterfaceID", ipconfigRequest.PodInterfaceID)
 		reserveResp := &cns.IPConfigResponse{
 			Response: cns.Response{
 				ReturnCode: types.FailedToAllocateIPConfig,
@@ -69,6 +90,7 @@ func (service *HTTPRestService) requestIPConfigHandler(w http.ResponseWriter, r
 		},
 		PodIpInfo: podIPInfo,
 	}
+	requestLogger(r).Info("Allocated IP", "ip", podIPInfo.PodIPConfig.IPAddress, "podInterfaceID", ipconfigRequest.PodInterfaceID)
 
 	err = service.Listener.Encode(w, &reserveResp)
 	logger.ResponseEx(service.Name+operationName, ipconfigRequest, reserveResp, reserveResp.Response.ReturnCode, err)
@@ -95,6 +117,8 @@ func (service *HTTPRestService) releaseIPConfigHandler(w http.ResponseWriter, r
 		returnCode = types.UnexpectedError
 		message = err.Error()
 		logger.Errorf("releaseIPConfigHandler releaseIPConfig failed because %v, release IP config info %s", message, req)
+	} else {
+		requestLogger(r).Info("Released IP", "podInterfaceID", req.PodInterfaceID)
 	}
 	resp := cns.Response{
 		ReturnCode: returnCode,
@@ -106,45 +130,76 @@ func (service *HTTPRestService) releaseIPConfigHandler(w http.ResponseWriter, r
 
 // MarkIPAsPendingRelease will set the IPs which are in PendingProgramming or Available to PendingRelease state
 // It will try to update [totalIpsToRelease]  number of ips.
+// The IPs are picked in the order of ipselection.SortForRelease, skipping the IPs reserved for pods.
 func (service *HTTPRestService) MarkIPAsPendingRelease(totalIpsToRelease int) (map[string]cns.IPConfigurationStatus, error) {
+	return service.markIPsAsPendingRelease("", totalIpsToRelease)
+}
+
+// MarkNCIPsAsPendingRelease is MarkIPAsPendingRelease restricted to the IPs of the NC.
+func (service *HTTPRestService) MarkNCIPsAsPendingRelease(ncID string, totalIpsToRelease int) (map[string]cns.IPConfigurationStatus, error) {
+	return service.markIPsAsPendingRelease(ncID, totalIpsToRelease)
+}
+
+// markIPsAsPendingRelease marks the IPs of the NC as PendingRelease, of any NC if ncID is empty.
+func (service *HTTPRestService) markIPsAsPendingRelease(ncID string, totalIpsToRelease int) (map[string]cns.IPConfigurationStatus, error) {
 	pendingReleasedIps := make(map[string]cns.IPConfigurationStatus)
 	service.Lock()
 	defer service.Unlock()
 
-	for uuid, existingIpConfig := range service.PodIPConfigState {
-		if existingIpConfig.State == cns.PendingProgramming {
-			updatedIpConfig, err := service.updateIPConfigState(uuid, cns.PendingRelease, existingIpConfig.PodInfo)
-			if err != nil {
-				return nil, err
-			}
+	// IPs reserved for pods are kept in the pool
+	reserved := service.reservedIPConfigIDsUntransacted()
 
-			pendingReleasedIps[uuid] = updatedIpConfig
-			if len(pendingReleasedIps) == totalIpsToRelease {
-				return pendingReleasedIps, nil
-			}
+	candidates := []ipselection.ReleaseCandidate{}
+	for uuid, existingIpConfig := range service.PodIPConfigState {
+		if ncID != "" && existingIpConfig.NCID != ncID {
+			continue
+		}
+		if _, isReserved := reserved[uuid]; isReserved {
+			continue
 		}
+		if existingIpConfig.State != cns.PendingProgramming && existingIpConfig.State != cns.Available {
+			continue
+		}
+		candidates = append(candidates, ipselection.ReleaseCandidate{
+			IPConfigurationStatus: existingIpConfig,
+			NCVersion:             service.ipNCVersionUntransacted(existingIpConfig),
+			ReleasedAt:            service.ipReleases.ReleasedAt(uuid),
+		})
 	}
 
-	// if not all expected IPs are set to PendingRelease, then check the Available IPs
-	for uuid, existingIpConfig := range service.PodIPConfigState {
-		if existingIpConfig.State == cns.Available {
-			updatedIpConfig, err := service.updateIPConfigState(uuid, cns.PendingRelease, existingIpConfig.PodInfo)
-			if err != nil {
-				return nil, err
-			}
+	ipselection.SortForRelease(candidates)
 
-			pendingReleasedIps[uuid] = updatedIpConfig
+	for _, candidate := range candidates {
+		if len(pendingReleasedIps) == totalIpsToRelease {
+			return pendingReleasedIps, nil
+		}
 
-			if len(pendingReleasedIps) == totalIpsToRelease {
-				return pendingReleasedIps, nil
-			}
+		updatedIpConfig, err := service.updateIPConfigState(candidate.ID, cns.PendingRelease, candidate.PodInfo)
+		if err != nil {
+			return nil, err
 		}
+
+		pendingReleasedIps[candidate.ID] = updatedIpConfig
 	}
 
-	logger.Printf("[MarkIPAsPendingRelease] Set total ips to PendingRelease %d, expected %d", len(pendingReleasedIps), totalIpsToRelease)
+	if len(pendingReleasedIps) != totalIpsToRelease {
+		logger.Printf("[MarkIPAsPendingRelease] Set total ips to PendingRelease %d, expected %d", len(pendingReleasedIps), totalIpsToRelease)
+	}
 	return pendingReleasedIps, nil
 }
 
+// ipNCVersionUntransacted returns the version of the NC in which the IP was added, -1 if the NC or the IP is not
+// found.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) ipNCVersionUntransacted(ipConfig cns.IPConfigurationStatus) int {
+	if ncInfo, exists := service.state.ContainerStatus[ipConfig.NCID]; exists {
+		if secondaryIPConfig, exists := ncInfo.CreateNetworkContainerRequest.SecondaryIPConfigs[ipConfig.ID]; exists {
+			return secondaryIPConfig.NCVersion
+		}
+	}
+	return -1
+}
+
 func (service *HTTPRestService) updateIPConfigState(ipID string, updatedState cns.IPConfigState, podInfo cns.PodInfo) (cns.IPConfigurationStatus, error) {
 	if ipConfig, found := service.PodIPConfigState[ipID]; found {
 		logger.Printf("[updateIPConfigState] Changing IpId [%s] state to [%s], podInfo [%+v]. Current config [%+v]", ipID, updatedState, podInfo, ipConfig)
@@ -220,6 +275,7 @@ func (service *HTTPRestService) handleDebugRestData(w http.ResponseWriter, r *ht
 			PodIPIDByPodInterfaceKey: service.PodIPIDByPodInterfaceKey,
 			PodIPConfigState:         service.PodIPConfigState,
 			IPAMPoolMonitor:          service.IPAMPoolMonitor.GetStateSnapshot(),
+			IPReservations:           service.ipReservationsUntransacted(),
 		},
 	}
 	err := service.Listener.Encode(w, &resp)
@@ -240,9 +296,13 @@ func (service *HTTPRestService) handleDebugIPAddresses(w http.ResponseWriter, r
 		return
 	}
 	// Get all IPConfigs matching a state and return in the response
+	service.RLock()
 	resp := cns.GetIPAddressStatusResponse{
 		IPConfigurationStatus: filter.MatchAnyIPConfigState(service.PodIPConfigState, filter.PredicatesForStates(req.IPConfigStateFilter...)...),
+		IPSelectionStrategy:   string(service.ipSelector.Strategy()),
+		IPReservations:        service.ipReservationsUntransacted(),
 	}
+	service.RUnlock()
 	err := service.Listener.Encode(w, &resp)
 	logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
 }
@@ -288,6 +348,7 @@ func (service *HTTPRestService) setIPConfigAsAllocated(ipconfig cns.IPConfigurat
 	}
 
 	service.PodIPIDByPodInterfaceKey[podInfo.Key()] = ipconfig.ID
+	service.updateReservationExpiryUntransacted(ipconfig.ID, true)
 	return nil
 }
 
@@ -299,6 +360,8 @@ func (service *HTTPRestService) setIPConfigAsAvailable(ipconfig cns.IPConfigurat
 	}
 
 	delete(service.PodIPIDByPodInterfaceKey, podInfo.Key())
+	service.ipReleases.Released(ipconfig.ID)
+	service.updateReservationExpiryUntransacted(ipconfig.ID, false)
 	logger.Printf("[setIPConfigAsAvailable] Deleted outdated pod info %s from PodIPIDByOrchestratorContext since IP %s with ID %s will be released and set as Available",
 		podInfo.Key(), ipconfig.IPAddress, ipconfig.ID)
 	return ipconfig, nil
@@ -382,9 +445,16 @@ func (service *HTTPRestService) AllocateDesiredIPConfig(podInfo cns.PodInfo, des
 	service.Lock()
 	defer service.Unlock()
 
+	reserved := service.reservedIPConfigIDsUntransacted()
+
 	found := false
 	for _, ipConfig := range service.PodIPConfigState {
 		if ipConfig.IPAddress == desiredIpAddress {
+			if holder, isReserved := reserved[ipConfig.ID]; isReserved && holder != podIdentity(podInfo) {
+				//nolint:goerr113
+				return podIpInfo, fmt.Errorf("[AllocateDesiredIPConfig] Desired IP %s is reserved for pod %s, requested for pod %+v", desiredIpAddress, holder, podInfo)
+			}
+
 			if ipConfig.State == cns.Allocated {
 				// This IP has already been allocated, if it is allocated to same pod, then return the same
 				// IPconfiguration
@@ -414,28 +484,91 @@ func (service *HTTPRestService) AllocateDesiredIPConfig(podInfo cns.PodInfo, des
 	return podIpInfo, fmt.Errorf("Requested IP not found in pool")
 }
 
-func (service *HTTPRestService) AllocateAnyAvailableIPConfig(podInfo cns.PodInfo) (cns.PodIpInfo, error) {
+// AllocateAnyAvailableIPConfig allocates a free IP to the pod, picked by the IP selection strategy. If subnet is set,
+// only the NCs in that subnet are considered. The IPs of NCs which are draining are never handed out.
+func (service *HTTPRestService) AllocateAnyAvailableIPConfig(podInfo cns.PodInfo, subnet string) (cns.PodIpInfo, error) {
 	service.Lock()
 	defer service.Unlock()
 
-	for _, ipState := range service.PodIPConfigState {
-		if ipState.State == cns.Available {
-			if err := service.setIPConfigAsAllocated(ipState, podInfo); err != nil {
-				return cns.PodIpInfo{}, err
-			}
+	var eligibleNCs map[string]struct{}
+	if subnet != "" {
+		var err error
+		if eligibleNCs, err = service.getNCsInSubnetUntransacted(subnet); err != nil {
+			return cns.PodIpInfo{}, err
+		}
+	}
 
-			podIPInfo := cns.PodIpInfo{}
-			if err := service.populateIPConfigInfoUntransacted(ipState, &podIPInfo); err != nil {
-				return cns.PodIpInfo{}, err
+	// The IP reserved for the pod is allocated back to it, the IPs reserved for other pods are never handed out.
+	reserved := service.reservedIPConfigIDsUntransacted()
+	identity := podIdentity(podInfo)
+
+	var (
+		candidates []cns.IPConfigurationStatus
+		ipState    cns.IPConfigurationStatus
+	)
+	for _, ipConfig := range service.PodIPConfigState {
+		if ipConfig.State != cns.Available {
+			continue
+		}
+		if _, draining := service.drainingNCs[ipConfig.NCID]; draining {
+			continue
+		}
+		if _, eligible := eligibleNCs[ipConfig.NCID]; eligibleNCs != nil && !eligible {
+			continue
+		}
+		if holder, isReserved := reserved[ipConfig.ID]; isReserved {
+			if holder == identity {
+				candidates = []cns.IPConfigurationStatus{ipConfig}
+				break
 			}
+			continue
+		}
+		candidates = append(candidates, ipConfig)
+	}
 
-			return podIPInfo, nil
+	if len(candidates) > 0 {
+		ipState = candidates[service.ipSelector.Select(candidates)]
+		if err := service.setIPConfigAsAllocated(ipState, podInfo); err != nil {
+			return cns.PodIpInfo{}, err
 		}
+
+		podIPInfo := cns.PodIpInfo{}
+		if err := service.populateIPConfigInfoUntransacted(ipState, &podIPInfo); err != nil {
+			return cns.PodIpInfo{}, err
+		}
+
+		return podIPInfo, nil
+	}
+	if subnet != "" {
+		//nolint:goerr113
+		return cns.PodIpInfo{}, fmt.Errorf("no more free IPs available in subnet %s, waiting on Azure CNS to allocated more", subnet)
 	}
 	//nolint:goerr113
 	return cns.PodIpInfo{}, fmt.Errorf("no more free IPs available, waiting on Azure CNS to allocated more")
 }
 
+// getNCsInSubnetUntransacted returns the IDs of the NCs whose primary IP is in the subnet CIDR.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) getNCsInSubnetUntransacted(subnet string) (map[string]struct{}, error) {
+	_, desiredNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid desired subnet %s", subnet)
+	}
+
+	ncIDs := map[string]struct{}{}
+	for ncID, ncInfo := range service.state.ContainerStatus {
+		ipSubnet := ncInfo.CreateNetworkContainerRequest.IPConfiguration.IPSubnet
+		_, ncNet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", ipSubnet.IPAddress, ipSubnet.PrefixLength))
+		if err != nil {
+			continue
+		}
+		if ncNet.String() == desiredNet.String() {
+			ncIDs[ncID] = struct{}{}
+		}
+	}
+	return ncIDs, nil
+}
+
 // If IPConfig is already allocated for pod, it returns that else it returns one of the available ipconfigs.
 func requestIPConfigHelper(service *HTTPRestService, req cns.IPConfigRequest) (cns.PodIpInfo, error) {
 	// check if ipconfig already allocated for this pod and return if exists or error
@@ -454,6 +587,6 @@ func requestIPConfigHelper(service *HTTPRestService, req cns.IPConfigRequest) (c
 		return service.AllocateDesiredIPConfig(podInfo, req.DesiredIPAddress)
 	}
 
-	// return any free IPConfig
-	return service.AllocateAnyAvailableIPConfig(podInfo)
+	// return any free IPConfig, from the desired subnet if one was requested
+	return service.AllocateAnyAvailableIPConfig(podInfo, req.DesiredSubnet)
 }
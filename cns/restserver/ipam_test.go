@@ -7,10 +7,13 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/fakes"
+	"github.com/Azure/azure-container-networking/cns/ipselection"
+	"github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
 )
 
@@ -731,3 +734,228 @@ func TestIPAMMarkExistingIPConfigAsPending(t *testing.T) {
 		t.Fatalf("Expected to see ID %v in pending release ipconfigs, actual %+v", testPod1GUID, allocatedIPConfigs)
 	}
 }
+
+// createSecondNC adds an NC in the 10.1.0.0/24 subnet with a single available IP to the state.
+func createSecondNC(t *testing.T, ncID, ipID, ipAddress string) {
+	req := generateNetworkContainerRequest(map[string]cns.SecondaryIPConfig{ipID: newSecondaryIPConfig(ipAddress, -1)}, ncID, "-1")
+	req.IPConfiguration.IPSubnet.IPAddress = "10.1.0.5"
+	if returnCode := svc.CreateOrUpdateNetworkContainerInternal(req); returnCode != types.Success {
+		t.Fatalf("Failed to create NC %s, returnCode %d", ncID, returnCode)
+	}
+}
+
+func TestIPAMRequestIPConfigFromDesiredSubnet(t *testing.T) {
+	svc := getTestService()
+
+	testState := NewPodState(testIP1, 24, testPod1GUID, testNCID, cns.Available, 0)
+	UpdatePodIpConfigState(t, svc, map[string]cns.IPConfigurationStatus{testState.ID: testState})
+	createSecondNC(t, "secondNC", testPod2GUID, "10.1.0.6")
+
+	req := cns.IPConfigRequest{
+		DesiredSubnet:    "10.1.0.0/24",
+		PodInterfaceID:   testPod1Info.InterfaceID(),
+		InfraContainerID: testPod1Info.InfraContainerID(),
+	}
+	req.OrchestratorContext, _ = testPod1Info.OrchestratorContext()
+
+	podIPInfo, err := requestIPConfigHelper(svc, req)
+	if err != nil {
+		t.Fatalf("Expected IP retrieval from the desired subnet to succeed: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress != "10.1.0.6" {
+		t.Fatalf("Expected IP from the desired subnet, actual %+v", podIPInfo.PodIPConfig)
+	}
+
+	// no NC in the subnet
+	req.DesiredSubnet = "10.2.0.0/24"
+	req.PodInterfaceID, req.InfraContainerID = testPod2Info.InterfaceID(), testPod2Info.InfraContainerID()
+	req.OrchestratorContext, _ = testPod2Info.OrchestratorContext()
+	if _, err := requestIPConfigHelper(svc, req); err == nil {
+		t.Fatalf("Expected to fail when no NC is in the desired subnet")
+	}
+
+	req.DesiredSubnet = "10.1.0.0"
+	if _, err := requestIPConfigHelper(svc, req); err == nil {
+		t.Fatalf("Expected to fail when the desired subnet is not a CIDR")
+	}
+}
+
+func TestRemoveStaleNetworkContainers(t *testing.T) {
+	svc := getTestService()
+
+	testState := NewPodState(testIP1, 24, testPod1GUID, testNCID, cns.Available, 0)
+	UpdatePodIpConfigState(t, svc, map[string]cns.IPConfigurationStatus{testState.ID: testState})
+	createSecondNC(t, "staleNC", testPod2GUID, "10.1.0.6")
+	createSecondNC(t, "drainedNC", testPod3GUID, "10.1.0.7")
+
+	if _, err := svc.AllocateDesiredIPConfig(testPod2Info, "10.1.0.6"); err != nil {
+		t.Fatalf("Expected to allocate IP on the stale NC: %+v", err)
+	}
+
+	// the NC with an allocated IP drains, the other one is deleted
+	draining := svc.RemoveStaleNetworkContainers([]string{testNCID})
+	if !reflect.DeepEqual(draining, []string{"staleNC"}) {
+		t.Fatalf("Expected staleNC to be draining, actual %v", draining)
+	}
+	if _, exists := svc.state.ContainerStatus["drainedNC"]; exists {
+		t.Fatalf("Expected drainedNC to be deleted")
+	}
+	if _, exists := svc.PodIPConfigState[testPod3GUID]; exists {
+		t.Fatalf("Expected the IP of drainedNC to be removed")
+	}
+
+	// IPs of a draining NC are not handed out once released
+	if err := svc.releaseIPConfig(testPod2Info); err != nil {
+		t.Fatalf("Expected to release IP on the stale NC: %+v", err)
+	}
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil {
+		t.Fatalf("Expected to allocate IP on the active NC: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress != testIP1 {
+		t.Fatalf("Expected IP of the active NC, actual %+v", podIPInfo.PodIPConfig)
+	}
+
+	draining = svc.RemoveStaleNetworkContainers([]string{testNCID})
+	if len(draining) != 0 {
+		t.Fatalf("Expected no NCs to be draining, actual %v", draining)
+	}
+	if _, exists := svc.state.ContainerStatus["staleNC"]; exists {
+		t.Fatalf("Expected staleNC to be deleted")
+	}
+	if _, exists := svc.state.ContainerStatus[testNCID]; !exists {
+		t.Fatalf("Expected the active NC to be kept")
+	}
+}
+
+func TestIPAMAllocateAnyAvailableIPConfigLowestFirst(t *testing.T) {
+	svc := getTestService()
+	selector, err := ipselection.New(ipselection.LowestFirst, svc.ipReleases)
+	if err != nil {
+		t.Fatalf("Failed to create selector: %+v", err)
+	}
+	svc.ipSelector = selector
+
+	ipconfigs := map[string]cns.IPConfigurationStatus{}
+	for id, ip := range map[string]string{testPod4GUID: testIP4, testPod2GUID: testIP2, testPod3GUID: testIP3, testPod1GUID: testIP1} {
+		ipconfigs[id] = NewPodState(ip, 24, id, testNCID, cns.Available, 0)
+	}
+	if err := UpdatePodIpConfigState(t, svc, ipconfigs); err != nil {
+		t.Fatalf("Expected to not fail adding IP's to state: %+v", err)
+	}
+
+	for i, podInfo := range []cns.PodInfo{testPod1Info, testPod2Info, testPod3Info} {
+		podIPInfo, err := svc.AllocateAnyAvailableIPConfig(podInfo, "")
+		if err != nil {
+			t.Fatalf("Unexpected failure allocating IP: %+v", err)
+		}
+		if want := []string{testIP1, testIP2, testIP3}[i]; podIPInfo.PodIPConfig.IPAddress != want {
+			t.Fatalf("Expected lowest IP %s to be allocated, actual %+v", want, podIPInfo.PodIPConfig)
+		}
+	}
+
+	// the released IP is the lowest available again
+	if err := svc.releaseIPConfig(testPod1Info); err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil {
+		t.Fatalf("Unexpected failure allocating IP: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress != testIP1 {
+		t.Fatalf("Expected released IP %s to be allocated, actual %+v", testIP1, podIPInfo.PodIPConfig)
+	}
+}
+
+func TestIPAMAllocateAnyAvailableIPConfigLeastRecentlyReleased(t *testing.T) {
+	svc := getTestService()
+	selector, err := ipselection.New(ipselection.LeastRecentlyReleased, svc.ipReleases)
+	if err != nil {
+		t.Fatalf("Failed to create selector: %+v", err)
+	}
+	svc.ipSelector = selector
+
+	state1 := NewPodState(testIP1, 24, testPod1GUID, testNCID, cns.Available, 0)
+	state2 := NewPodState(testIP2, 24, testPod2GUID, testNCID, cns.Available, 0)
+	if err := UpdatePodIpConfigState(t, svc, map[string]cns.IPConfigurationStatus{state1.ID: state1, state2.ID: state2}); err != nil {
+		t.Fatalf("Expected to not fail adding IP's to state: %+v", err)
+	}
+
+	if podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, ""); err != nil || podIPInfo.PodIPConfig.IPAddress != testIP1 {
+		t.Fatalf("Expected %s to be allocated, actual %+v, err %+v", testIP1, podIPInfo.PodIPConfig, err)
+	}
+	if err := svc.releaseIPConfig(testPod1Info); err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+
+	// the IP which was never released is picked before the one just released
+	if podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod3Info, ""); err != nil || podIPInfo.PodIPConfig.IPAddress != testIP2 {
+		t.Fatalf("Expected %s to be allocated, actual %+v, err %+v", testIP2, podIPInfo.PodIPConfig, err)
+	}
+}
+
+func TestIPAMMarkIPAsPendingReleaseOrder(t *testing.T) {
+	svc := getTestService()
+	now := time.Unix(0, 0)
+	svc.ipReleases = ipselection.NewReleaseTimes(func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	})
+	ipconfigs := map[string]cns.IPConfigurationStatus{}
+	for id, ip := range map[string]string{testPod1GUID: testIP1, testPod2GUID: testIP2, testPod3GUID: testIP3, testPod4GUID: testIP4} {
+		ipconfigs[id] = NewPodState(ip, 24, id, testNCID, cns.Available, 0)
+	}
+	if err := UpdatePodIpConfigState(t, svc, ipconfigs); err != nil {
+		t.Fatalf("Expected to not fail adding IP's to state: %+v", err)
+	}
+
+	// IP 1 and 2 were used, 2 most recently
+	for _, desired := range []struct {
+		podInfo cns.PodInfo
+		ip      string
+	}{{testPod1Info, testIP1}, {testPod2Info, testIP2}} {
+		if _, err := svc.AllocateDesiredIPConfig(desired.podInfo, desired.ip); err != nil {
+			t.Fatalf("Unexpected failure allocating IP: %+v", err)
+		}
+		if err := svc.releaseIPConfig(desired.podInfo); err != nil {
+			t.Fatalf("Unexpected failure releasing IP: %+v", err)
+		}
+	}
+
+	// IP 3 belongs to the newest batch
+	ncStatus := svc.state.ContainerStatus[testNCID]
+	secondaryIPConfig := ncStatus.CreateNetworkContainerRequest.SecondaryIPConfigs[testPod3GUID]
+	secondaryIPConfig.NCVersion = 1
+	ncStatus.CreateNetworkContainerRequest.SecondaryIPConfigs[testPod3GUID] = secondaryIPConfig
+	svc.state.ContainerStatus[testNCID] = ncStatus
+
+	for _, want := range []string{testPod3GUID, testPod4GUID, testPod1GUID, testPod2GUID} {
+		ips, err := svc.MarkIPAsPendingRelease(1)
+		if err != nil {
+			t.Fatalf("Unexpected failure releasing IP: %+v", err)
+		}
+		if _, exists := ips[want]; !exists || len(ips) != 1 {
+			t.Fatalf("Expected %s to be marked as pending release, actual %+v", want, ips)
+		}
+	}
+}
+
+func TestIPAMMarkNCIPsAsPendingRelease(t *testing.T) {
+	svc := getTestService()
+
+	// the IP of the second NC has the highest address, it would be released first from any NC
+	testState := NewPodState(testIP1, 24, testPod1GUID, testNCID, cns.Available, 0)
+	UpdatePodIpConfigState(t, svc, map[string]cns.IPConfigurationStatus{testState.ID: testState})
+	createSecondNC(t, "secondNC", testPod2GUID, "10.1.0.6")
+
+	ips, err := svc.MarkNCIPsAsPendingRelease(testNCID, 2)
+	if err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+	if _, exists := ips[testPod1GUID]; !exists || len(ips) != 1 {
+		t.Fatalf("Expected only the IP of %s to be marked as pending release, actual %+v", testNCID, ips)
+	}
+	if state := svc.PodIPConfigState[testPod2GUID].State; state != cns.Available {
+		t.Fatalf("Expected the IP of secondNC to stay available, actual %s", state)
+	}
+}
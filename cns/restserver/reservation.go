@@ -0,0 +1,332 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultIPReservationTTL = 24 * time.Hour
+	// IPReservationSweepInterval is the interval at which the inactive reservations are dropped from the state.
+	IPReservationSweepInterval = time.Minute
+)
+
+var (
+	errInvalidPodIdentity      = errors.New("pod namespace and name or orchestrator context are required")
+	errReservedIPNotFound      = errors.New("IP to reserve not found")
+	errReservedIPUnavailable   = errors.New("IP to reserve is unavailable")
+	errIPReservationNotFound   = errors.New("IP reservation not found")
+	errIPReservedForAnotherPod = errors.New("IP is reserved for another pod")
+	errNoIPAllocatedToReserve  = errors.New("no IP allocated to the pod to reserve")
+)
+
+// reservationIdentity returns the namespace/name identity of the pod, from the orchestrator context if the
+// namespace and name are not set.
+func reservationIdentity(namespace, name string, orchestratorContext json.RawMessage) (string, error) {
+	if namespace == "" && name == "" && len(orchestratorContext) > 0 {
+		var podInfo cns.KubernetesPodInfo
+		if err := json.Unmarshal(orchestratorContext, &podInfo); err != nil {
+			return "", errors.Wrap(err, "failed to unmarshal orchestrator context")
+		}
+		namespace, name = podInfo.PodNamespace, podInfo.PodName
+	}
+
+	if namespace == "" || name == "" {
+		return "", errInvalidPodIdentity
+	}
+
+	return namespace + "/" + name, nil
+}
+
+// podIdentity returns the identity of the pod that its IP reservation is keyed by.
+func podIdentity(podInfo cns.PodInfo) string {
+	if podInfo == nil {
+		return ""
+	}
+	return podInfo.Namespace() + "/" + podInfo.Name()
+}
+
+// ReserveIPConfig reserves the IP for the pod, or the IP allocated to the pod if ipAddress is empty, replacing any
+// previous reservation of the pod. The IP is held for ttl once the pod is gone.
+func (service *HTTPRestService) ReserveIPConfig(identity, ipAddress string, ttl time.Duration) (cns.IPReservation, error) {
+	service.Lock()
+	defer service.Unlock()
+
+	reserved := service.reservedIPConfigIDsUntransacted()
+
+	var (
+		ipConfig cns.IPConfigurationStatus
+		found    bool
+	)
+	for _, ipState := range service.PodIPConfigState {
+		if ipAddress == "" && ipState.State == cns.Allocated && podIdentity(ipState.PodInfo) == identity ||
+			ipAddress != "" && ipState.IPAddress == ipAddress {
+			ipConfig, found = ipState, true
+			break
+		}
+	}
+
+	switch {
+	case !found && ipAddress == "":
+		return cns.IPReservation{}, errors.Wrapf(errNoIPAllocatedToReserve, "pod %s", identity)
+	case !found:
+		return cns.IPReservation{}, errors.Wrapf(errReservedIPNotFound, "IP %s", ipAddress)
+	case ipConfig.State == cns.PendingRelease:
+		return cns.IPReservation{}, errors.Wrapf(errReservedIPUnavailable, "IP %s is pending release", ipConfig.IPAddress)
+	case ipConfig.State == cns.Allocated && podIdentity(ipConfig.PodInfo) != identity:
+		return cns.IPReservation{}, errors.Wrapf(errReservedIPUnavailable, "IP %s is allocated to another pod", ipConfig.IPAddress)
+	}
+
+	if holder, ok := reserved[ipConfig.ID]; ok && holder != identity {
+		return cns.IPReservation{}, errors.Wrapf(errIPReservedForAnotherPod, "IP %s is reserved for %s", ipConfig.IPAddress, holder)
+	}
+
+	reservation := cns.IPReservation{
+		PodIdentity: identity,
+		IPConfigID:  ipConfig.ID,
+		IPAddress:   ipConfig.IPAddress,
+		TTL:         ttl,
+	}
+	if ipConfig.State != cns.Allocated {
+		reservation.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	if service.state.IPReservations == nil {
+		service.state.IPReservations = map[string]cns.IPReservation{}
+	}
+	service.state.IPReservations[identity] = reservation
+	logger.Printf("[ReserveIPConfig] Reserved IP %s for pod %s", reservation.IPAddress, identity)
+
+	// The state is saved anyway, so the inactive reservations are dropped along.
+	service.dropInactiveIPReservationsUntransacted()
+
+	return reservation, service.saveState()
+}
+
+// UnreserveIPConfig removes the IP reservation of the pod.
+func (service *HTTPRestService) UnreserveIPConfig(identity string) error {
+	service.Lock()
+	defer service.Unlock()
+
+	reservation, ok := service.state.IPReservations[identity]
+	if !ok {
+		return errors.Wrapf(errIPReservationNotFound, "pod %s", identity)
+	}
+
+	delete(service.state.IPReservations, identity)
+	logger.Printf("[UnreserveIPConfig] Removed reservation of IP %s for pod %s", reservation.IPAddress, identity)
+
+	return service.saveState()
+}
+
+// GetIPReservations returns the active IP reservations.
+func (service *HTTPRestService) GetIPReservations() []cns.IPReservation {
+	service.RLock()
+	defer service.RUnlock()
+
+	return service.ipReservationsUntransacted()
+}
+
+// DeleteInactiveIPReservations drops the expired reservations and those of IPs which no longer exist from the state.
+func (service *HTTPRestService) DeleteInactiveIPReservations() error {
+	service.Lock()
+	defer service.Unlock()
+
+	if !service.dropInactiveIPReservationsUntransacted() {
+		return nil
+	}
+
+	return service.saveState()
+}
+
+// DeleteInactiveIPReservationsPeriodically drops the inactive reservations every interval until ctx is cancelled.
+func (service *HTTPRestService) DeleteInactiveIPReservationsPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := service.DeleteInactiveIPReservations(); err != nil {
+				logger.Errorf("[IPReservation] Failed to save the state after dropping inactive reservations: %v", err)
+			}
+		}
+	}
+}
+
+// ipReservationsUntransacted returns the active IP reservations.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) ipReservationsUntransacted() []cns.IPReservation {
+	now := time.Now()
+	reservations := make([]cns.IPReservation, 0, len(service.state.IPReservations))
+	for _, reservation := range service.state.IPReservations {
+		if service.isIPReservationActiveUntransacted(reservation, now) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations
+}
+
+// reservedIPConfigIDsUntransacted returns the pod identity holding each reserved IP by IP ID, for the active
+// reservations only. The inactive reservations are left in the state until they are dropped.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) reservedIPConfigIDsUntransacted() map[string]string {
+	now := time.Now()
+	reserved := make(map[string]string, len(service.state.IPReservations))
+	for identity, reservation := range service.state.IPReservations {
+		if service.isIPReservationActiveUntransacted(reservation, now) {
+			reserved[reservation.IPConfigID] = identity
+		}
+	}
+	return reserved
+}
+
+// isIPReservationActiveUntransacted returns whether the reservation has not expired and its IP still exists.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) isIPReservationActiveUntransacted(reservation cns.IPReservation, now time.Time) bool {
+	// The IP state is empty until CNS is reconciled with the NNC, so the reservations are only checked against it
+	// once it is populated.
+	if _, exists := service.PodIPConfigState[reservation.IPConfigID]; !exists && len(service.PodIPConfigState) > 0 {
+		return false
+	}
+
+	return reservation.ExpiresAt.IsZero() || !now.After(reservation.ExpiresAt)
+}
+
+// dropInactiveIPReservationsUntransacted deletes the inactive reservations from the state, without saving it, and
+// returns whether any was deleted.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) dropInactiveIPReservationsUntransacted() bool {
+	now := time.Now()
+	dropped := false
+	for identity, reservation := range service.state.IPReservations {
+		if service.isIPReservationActiveUntransacted(reservation, now) {
+			continue
+		}
+
+		if !reservation.ExpiresAt.IsZero() && now.After(reservation.ExpiresAt) {
+			logger.Printf("[IPReservation] Reservation of IP %s for pod %s expired at %v", reservation.IPAddress, identity, reservation.ExpiresAt)
+		} else {
+			logger.Printf("[IPReservation] Dropping reservation of IP %s for pod %s, the IP no longer exists", reservation.IPAddress, identity)
+		}
+		delete(service.state.IPReservations, identity)
+		dropped = true
+	}
+	return dropped
+}
+
+// updateReservationExpiryUntransacted starts the TTL of the reservation of the IP when it is released, and stops it
+// when the IP is allocated back to its pod.
+// Note: this func is an untransacted API as the caller will take a Service lock
+func (service *HTTPRestService) updateReservationExpiryUntransacted(ipConfigID string, allocated bool) {
+	// The inactive reservations are dropped first so that one of them is never revived by the allocation of its IP.
+	changed := service.dropInactiveIPReservationsUntransacted()
+	for identity, reservation := range service.state.IPReservations {
+		if reservation.IPConfigID != ipConfigID {
+			continue
+		}
+
+		if allocated {
+			reservation.ExpiresAt = time.Time{}
+		} else {
+			reservation.ExpiresAt = time.Now().Add(reservation.TTL)
+		}
+		service.state.IPReservations[identity] = reservation
+		changed = true
+		break
+	}
+
+	if !changed {
+		return
+	}
+	if err := service.saveState(); err != nil {
+		logger.Errorf("[IPReservation] Failed to save reservations after updating IP %s: %v", ipConfigID, err)
+	}
+}
+
+func (service *HTTPRestService) reserveIPConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req cns.ReserveIPConfigRequest
+	err := service.Listener.Decode(w, r, &req)
+	logger.Request(service.Name+"reserveIPConfigHandler", req, err)
+	if err != nil {
+		resp := cns.ReserveIPConfigResponse{
+			Response: cns.Response{
+				ReturnCode: types.UnexpectedError,
+				Message:    err.Error(),
+			},
+		}
+		err = service.Listener.Encode(w, &resp)
+		logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+		return
+	}
+
+	var resp cns.ReserveIPConfigResponse
+	identity, err := reservationIdentity(req.PodNamespace, req.PodName, req.OrchestratorContext)
+	if err == nil {
+		ttl := time.Duration(req.TTLInSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = defaultIPReservationTTL
+		}
+		resp.Reservation, err = service.ReserveIPConfig(identity, req.IPAddress, ttl)
+	}
+
+	if err != nil {
+		resp.Response = cns.Response{ReturnCode: reservationReturnCode(err), Message: err.Error()}
+		logger.Errorf("reserveIPConfigHandler failed: %v", err)
+	}
+
+	err = service.Listener.Encode(w, &resp)
+	logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+}
+
+func (service *HTTPRestService) unreserveIPConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req cns.UnreserveIPConfigRequest
+	err := service.Listener.Decode(w, r, &req)
+	logger.Request(service.Name+"unreserveIPConfigHandler", req, err)
+	if err != nil {
+		resp := cns.Response{
+			ReturnCode: types.UnexpectedError,
+			Message:    err.Error(),
+		}
+		err = service.Listener.Encode(w, &resp)
+		logger.ResponseEx(service.Name, req, resp, resp.ReturnCode, err)
+		return
+	}
+
+	var resp cns.Response
+	identity, err := reservationIdentity(req.PodNamespace, req.PodName, req.OrchestratorContext)
+	if err == nil {
+		err = service.UnreserveIPConfig(identity)
+	}
+
+	if err != nil {
+		resp = cns.Response{ReturnCode: reservationReturnCode(err), Message: err.Error()}
+		logger.Errorf("unreserveIPConfigHandler failed: %v", err)
+	}
+
+	err = service.Listener.Encode(w, &resp)
+	logger.ResponseEx(service.Name, req, resp, resp.ReturnCode, err)
+}
+
+func reservationReturnCode(err error) types.ResponseCode {
+	switch {
+	case errors.Is(err, errInvalidPodIdentity):
+		return types.InvalidRequest
+	case errors.Is(err, errReservedIPNotFound), errors.Is(err, errNoIPAllocatedToReserve):
+		return types.NotFound
+	case errors.Is(err, errReservedIPUnavailable), errors.Is(err, errIPReservedForAnotherPod):
+		return types.AddressUnavailable
+	case errors.Is(err, errIPReservationNotFound):
+		return types.ReservationNotFound
+	default:
+		return types.UnexpectedError
+	}
+}
@@ -0,0 +1,215 @@
+package restserver
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/store"
+)
+
+func getTestServiceWithIPs(t *testing.T, ips map[string]string) *HTTPRestService {
+	svc := getTestService()
+	ipconfigs := map[string]cns.IPConfigurationStatus{}
+	for id, ip := range ips {
+		ipconfigs[id] = NewPodState(ip, 24, id, testNCID, cns.Available, 0)
+	}
+	if err := UpdatePodIpConfigState(t, svc, ipconfigs); err != nil {
+		t.Fatalf("Expected to not fail adding IP's to state: %+v", err)
+	}
+	return svc
+}
+
+func TestReservationIdentity(t *testing.T) {
+	orchestratorContext, err := json.Marshal(testPod1Info)
+	if err != nil {
+		t.Fatalf("Failed to marshal pod info: %+v", err)
+	}
+
+	identity, err := reservationIdentity("", "", orchestratorContext)
+	if err != nil || identity != podIdentity(testPod1Info) {
+		t.Fatalf("Expected identity %s from orchestrator context, actual %s, err %+v", podIdentity(testPod1Info), identity, err)
+	}
+
+	identity, err = reservationIdentity("ns", "name", nil)
+	if err != nil || identity != "ns/name" {
+		t.Fatalf("Expected identity ns/name, actual %s, err %+v", identity, err)
+	}
+
+	if _, err = reservationIdentity("ns", "", nil); !errors.Is(err, errInvalidPodIdentity) {
+		t.Fatalf("Expected invalid pod identity error, actual %+v", err)
+	}
+}
+
+func TestReserveIPConfigIsStickyToThePod(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1, testPod2GUID: testIP2})
+
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil {
+		t.Fatalf("Unexpected failure allocating IP: %+v", err)
+	}
+	allocatedIP := podIPInfo.PodIPConfig.IPAddress
+
+	reservation, err := svc.ReserveIPConfig(podIdentity(testPod1Info), "", time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	if reservation.IPAddress != allocatedIP || !reservation.ExpiresAt.IsZero() {
+		t.Fatalf("Expected allocated IP %s to be reserved without expiry, actual %+v", allocatedIP, reservation)
+	}
+
+	if err = svc.releaseIPConfig(testPod1Info); err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+	if reservations := svc.GetIPReservations(); len(reservations) != 1 || reservations[0].ExpiresAt.IsZero() {
+		t.Fatalf("Expected the reservation TTL to start once the IP is released, actual %+v", reservations)
+	}
+
+	// the reserved IP is not handed out to other pods
+	podIPInfo, err = svc.AllocateAnyAvailableIPConfig(testPod2Info, "")
+	if err != nil {
+		t.Fatalf("Unexpected failure allocating IP: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress == allocatedIP {
+		t.Fatalf("Expected reserved IP %s not to be allocated to another pod", allocatedIP)
+	}
+	if _, err = svc.AllocateAnyAvailableIPConfig(testPod3Info, ""); err == nil {
+		t.Fatalf("Expected allocation to fail with only the reserved IP available")
+	}
+	if _, err = svc.AllocateDesiredIPConfig(testPod3Info, allocatedIP); err == nil {
+		t.Fatalf("Expected desired IP %s reserved for another pod to be rejected", allocatedIP)
+	}
+
+	// the pod gets its IP back
+	podIPInfo, err = svc.AllocateAnyAvailableIPConfig(testPod1Info, "")
+	if err != nil {
+		t.Fatalf("Unexpected failure allocating IP: %+v", err)
+	}
+	if podIPInfo.PodIPConfig.IPAddress != allocatedIP {
+		t.Fatalf("Expected reserved IP %s to be allocated back to the pod, actual %+v", allocatedIP, podIPInfo.PodIPConfig)
+	}
+	if reservations := svc.GetIPReservations(); len(reservations) != 1 || !reservations[0].ExpiresAt.IsZero() {
+		t.Fatalf("Expected the reservation TTL to stop once the IP is allocated back, actual %+v", reservations)
+	}
+}
+
+func TestReserveIPConfigExpires(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1})
+
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod1Info), testIP1, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	if _, err := svc.AllocateAnyAvailableIPConfig(testPod2Info, ""); err == nil {
+		t.Fatalf("Expected allocation to fail with only the reserved IP available")
+	}
+
+	reservation := svc.state.IPReservations[podIdentity(testPod1Info)]
+	reservation.ExpiresAt = time.Now().Add(-time.Second)
+	svc.state.IPReservations[podIdentity(testPod1Info)] = reservation
+
+	podIPInfo, err := svc.AllocateAnyAvailableIPConfig(testPod2Info, "")
+	if err != nil || podIPInfo.PodIPConfig.IPAddress != testIP1 {
+		t.Fatalf("Expected expired reservation IP %s to be allocated, actual %+v, err %+v", testIP1, podIPInfo.PodIPConfig, err)
+	}
+	if reservations := svc.GetIPReservations(); len(reservations) != 0 {
+		t.Fatalf("Expected the expired reservation to be filtered out, actual %+v", reservations)
+	}
+}
+
+func TestGetIPReservationsDoesNotDropExpiredReservations(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1})
+
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod1Info), testIP1, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	reservation := svc.state.IPReservations[podIdentity(testPod1Info)]
+	reservation.ExpiresAt = time.Now().Add(-time.Second)
+	svc.state.IPReservations[podIdentity(testPod1Info)] = reservation
+
+	if reservations := svc.GetIPReservations(); len(reservations) != 0 {
+		t.Fatalf("Expected the expired reservation to be filtered out, actual %+v", reservations)
+	}
+	if _, exists := svc.state.IPReservations[podIdentity(testPod1Info)]; !exists {
+		t.Fatalf("Expected reading the reservations to leave the state untouched")
+	}
+}
+
+func TestDeleteInactiveIPReservations(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1, testPod2GUID: testIP2})
+	kvs, err := store.NewJsonFileStore(filepath.Join(t.TempDir(), "azure-cns.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the store: %+v", err)
+	}
+	svc.store = kvs
+
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod1Info), testIP1, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod2Info), testIP2, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	reservation := svc.state.IPReservations[podIdentity(testPod1Info)]
+	reservation.ExpiresAt = time.Now().Add(-time.Second)
+	svc.state.IPReservations[podIdentity(testPod1Info)] = reservation
+
+	if err := svc.DeleteInactiveIPReservations(); err != nil {
+		t.Fatalf("Unexpected failure deleting inactive reservations: %+v", err)
+	}
+	if _, exists := svc.state.IPReservations[podIdentity(testPod1Info)]; exists {
+		t.Fatalf("Expected the expired reservation to be deleted")
+	}
+	if _, exists := svc.state.IPReservations[podIdentity(testPod2Info)]; !exists {
+		t.Fatalf("Expected the active reservation to be kept")
+	}
+
+	var saved httpRestServiceState
+	if err := svc.store.Read(storeKey, &saved); err != nil {
+		t.Fatalf("Unexpected failure reading the saved state: %+v", err)
+	}
+	if len(saved.IPReservations) != 1 {
+		t.Fatalf("Expected the deletion to be saved, actual %+v", saved.IPReservations)
+	}
+}
+
+func TestReserveIPConfigConflicts(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1})
+
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod2Info), "", time.Hour); !errors.Is(err, errNoIPAllocatedToReserve) {
+		t.Fatalf("Expected no allocated IP error, actual %+v", err)
+	}
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod2Info), testIP4, time.Hour); !errors.Is(err, errReservedIPNotFound) {
+		t.Fatalf("Expected IP not found error, actual %+v", err)
+	}
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod1Info), testIP1, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod2Info), testIP1, time.Hour); !errors.Is(err, errIPReservedForAnotherPod) {
+		t.Fatalf("Expected IP reserved for another pod error, actual %+v", err)
+	}
+
+	if err := svc.UnreserveIPConfig(podIdentity(testPod1Info)); err != nil {
+		t.Fatalf("Unexpected failure removing reservation: %+v", err)
+	}
+	if err := svc.UnreserveIPConfig(podIdentity(testPod1Info)); !errors.Is(err, errIPReservationNotFound) {
+		t.Fatalf("Expected reservation not found error, actual %+v", err)
+	}
+}
+
+func TestMarkIPAsPendingReleaseSkipsReservedIPs(t *testing.T) {
+	svc := getTestServiceWithIPs(t, map[string]string{testPod1GUID: testIP1, testPod2GUID: testIP2})
+
+	if _, err := svc.ReserveIPConfig(podIdentity(testPod1Info), testIP1, time.Hour); err != nil {
+		t.Fatalf("Unexpected failure reserving IP: %+v", err)
+	}
+
+	ips, err := svc.MarkIPAsPendingRelease(2)
+	if err != nil {
+		t.Fatalf("Unexpected failure releasing IP: %+v", err)
+	}
+	if _, exists := ips[testPod2GUID]; !exists || len(ips) != 1 {
+		t.Fatalf("Expected only the unreserved IP to be marked as pending release, actual %+v", ips)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/common"
 	"github.com/Azure/azure-container-networking/cns/dockerclient"
 	"github.com/Azure/azure-container-networking/cns/ipamclient"
+	"github.com/Azure/azure-container-networking/cns/ipselection"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/networkcontainers"
 	"github.com/Azure/azure-container-networking/cns/nmagent"
@@ -19,6 +20,9 @@ import (
 	acn "github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/store"
 	"github.com/pkg/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 // This file contains the initialization of RestServer.
@@ -52,11 +56,18 @@ type HTTPRestService struct {
 	networkContainer         *networkcontainers.NetworkContainers
 	PodIPIDByPodInterfaceKey map[string]string                    // PodInterfaceId is key and value is Pod IP (SecondaryIP) uuid.
 	PodIPConfigState         map[string]cns.IPConfigurationStatus // Secondary IP ID(uuid) is key
+	drainingNCs              map[string]struct{}                  // IDs of the NCs removed from the NNC which still have allocated IPs.
+	ipSelector               ipselection.Selector
+	ipReleases               *ipselection.ReleaseTimes // When each secondary IP was last released by a pod.
 	IPAMPoolMonitor          cns.IPAMPoolMonitor
+	PodsGetter               corev1client.PodsGetter  // Reads the pods from the API server, nil if CNS does not.
+	NodeLister               corev1listers.NodeLister // Lists the nodes from a shared informer cache, nil if CNS does not.
+	NodesSynced              cache.InformerSynced     // Whether the node cache has synced, nil if it needs no sync.
 	routingTable             *routes.RoutingTable
 	store                    store.KeyValueStore
 	state                    *httpRestServiceState
 	podsPendingIPAllocation  *bounded.TimedSet
+	egressSNATLock           sync.Mutex // serializes the allocations of the egress SNAT IPs of namespaces.
 	sync.RWMutex
 	dncPartitionKey string
 }
@@ -71,6 +82,7 @@ type HTTPRestServiceData struct {
 	PodIPIDByPodInterfaceKey map[string]string                    // PodInterfaceId is key and value is Pod IP uuid.
 	PodIPConfigState         map[string]cns.IPConfigurationStatus // secondaryipid(uuid) is key
 	IPAMPoolMonitor          cns.IpamPoolMonitorStateSnapshot
+	IPReservations           []cns.IPReservation
 }
 
 type Response struct {
@@ -94,8 +106,10 @@ type httpRestServiceState struct {
 	OrchestratorType                 string
 	NodeID                           string
 	Initialized                      bool
-	ContainerIDByOrchestratorContext map[string]string          // OrchestratorContext is key and value is NetworkContainerID.
-	ContainerStatus                  map[string]containerstatus // NetworkContainerID is key.
+	ContainerIDByOrchestratorContext map[string]string            // OrchestratorContext is key and value is NetworkContainerID.
+	PodInterfaceContainerIDs         map[string]map[string]string // OrchestratorContext and pod interface name are keys, value is NetworkContainerID.
+	ContainerStatus                  map[string]containerstatus   // NetworkContainerID is key.
+	IPReservations                   map[string]cns.IPReservation // Pod namespace/name is key.
 	Networks                         map[string]*networkInfo
 	TimeStamp                        time.Time
 	joinedNetworks                   map[string]struct{}
@@ -145,6 +159,12 @@ func NewHTTPRestService(config *common.ServiceConfig, wscli interfaceGetter, nma
 	podIPIDByPodInterfaceKey := make(map[string]string)
 	podIPConfigState := make(map[string]cns.IPConfigurationStatus)
 
+	ipReleases := ipselection.NewReleaseTimes(time.Now)
+	ipSelector, err := ipselection.New(ipselection.Strategy(config.IPSelectionStrategy), ipReleases)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create IP selector")
+	}
+
 	return &HTTPRestService{
 		Service:                  service,
 		store:                    service.Service.Store,
@@ -155,6 +175,8 @@ func NewHTTPRestService(config *common.ServiceConfig, wscli interfaceGetter, nma
 		networkContainer:         nc,
 		PodIPIDByPodInterfaceKey: podIPIDByPodInterfaceKey,
 		PodIPConfigState:         podIPConfigState,
+		ipSelector:               ipSelector,
+		ipReleases:               ipReleases,
 		routingTable:             routingTable,
 		state:                    serviceState,
 		podsPendingIPAllocation:  bounded.NewTimedSet(250), // nolint:gomnd // maxpods
@@ -203,6 +225,12 @@ func (service *HTTPRestService) Init(config *common.ServiceConfig) error {
 	listener.AddHandler(cns.UnpublishNetworkContainer, service.unpublishNetworkContainer)
 	listener.AddHandler(cns.RequestIPConfig, newHandlerFuncWithHistogram(service.requestIPConfigHandler, httpRequestLatency))
 	listener.AddHandler(cns.ReleaseIPConfig, newHandlerFuncWithHistogram(service.releaseIPConfigHandler, httpRequestLatency))
+	listener.AddHandler(cns.ReserveIPConfig, service.reserveIPConfigHandler)
+	listener.AddHandler(cns.UnreserveIPConfig, service.unreserveIPConfigHandler)
+	listener.AddHandler(cns.RequestEgressSNATIP, service.requestEgressSNATIPHandler)
+	listener.AddHandler(cns.ReleaseEgressSNATIP, service.releaseEgressSNATIPHandler)
+	listener.AddHandler(cns.GetPodSysctls, service.getPodSysctlsHandler)
+	listener.AddHandler(cns.GetVxlanPeers, service.getVxlanPeersHandler)
 	listener.AddHandler(cns.NmAgentSupportedApisPath, service.nmAgentSupportedApisHandler)
 	listener.AddHandler(cns.PathDebugIPAddresses, service.handleDebugIPAddresses)
 	listener.AddHandler(cns.PathDebugPodContext, service.handleDebugPodContext)
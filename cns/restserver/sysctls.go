@@ -0,0 +1,80 @@
+package restserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	errInvalidPodSysctlsRequest    = errors.New("pod namespace and name are required")
+	errPodSysctlsNotSupported      = errors.New("CNS does not read pods from the API server")
+	errInvalidPodSysctlsAnnotation = errors.New("invalid " + cns.PodSysctlsAnnotation + " annotation")
+)
+
+// GetPodSysctls returns the sysctls the pod requests in its cns.PodSysctlsAnnotation, read from the API server, nil
+// if it has no such annotation. The sysctls are validated by CNI.
+func (service *HTTPRestService) GetPodSysctls(ctx context.Context, namespace, name string) (map[string]string, error) {
+	if namespace == "" || name == "" {
+		return nil, errInvalidPodSysctlsRequest
+	}
+	if service.PodsGetter == nil {
+		return nil, errPodSysctlsNotSupported
+	}
+
+	pod, err := service.PodsGetter.Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pod %s/%s", namespace, name)
+	}
+
+	annotation, ok := pod.Annotations[cns.PodSysctlsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	var sysctls map[string]string
+	if err = json.Unmarshal([]byte(annotation), &sysctls); err != nil {
+		return nil, errors.Wrapf(errInvalidPodSysctlsAnnotation, "pod %s/%s: %v", namespace, name, err)
+	}
+
+	return sysctls, nil
+}
+
+func (service *HTTPRestService) getPodSysctlsHandler(w http.ResponseWriter, r *http.Request) {
+	var req cns.PodSysctlsRequest
+	err := service.Listener.Decode(w, r, &req)
+	logger.Request(service.Name+"getPodSysctlsHandler", req, err)
+	if err == nil {
+		var resp cns.PodSysctlsResponse
+		resp.Sysctls, err = service.GetPodSysctls(r.Context(), req.PodNamespace, req.PodName)
+		if err == nil {
+			err = service.Listener.Encode(w, &resp)
+			logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+			return
+		}
+		logger.Errorf("getPodSysctlsHandler failed: %v", err)
+	}
+
+	resp := cns.PodSysctlsResponse{
+		Response: cns.Response{ReturnCode: podSysctlsReturnCode(err), Message: err.Error()},
+	}
+	err = service.Listener.Encode(w, &resp)
+	logger.ResponseEx(service.Name, req, resp, resp.Response.ReturnCode, err)
+}
+
+func podSysctlsReturnCode(err error) types.ResponseCode {
+	switch {
+	case errors.Is(err, errInvalidPodSysctlsRequest), errors.Is(err, errInvalidPodSysctlsAnnotation):
+		return types.InvalidRequest
+	case errors.Is(err, errPodSysctlsNotSupported):
+		return types.UnsupportedEnvironment
+	default:
+		return types.UnexpectedError
+	}
+}
@@ -0,0 +1,58 @@
+package restserver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/cns"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPod(name string, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "pod-ns", Name: name, Annotations: annotations},
+	}
+}
+
+func TestGetPodSysctls(t *testing.T) {
+	svc := getTestService()
+	svc.PodsGetter = fake.NewSimpleClientset(
+		testPod("with-sysctls", map[string]string{cns.PodSysctlsAnnotation: `{"net.core.somaxconn":"4096"}`}),
+		testPod("without-sysctls", nil),
+		testPod("invalid-sysctls", map[string]string{cns.PodSysctlsAnnotation: "net.core.somaxconn=4096"}),
+	).CoreV1()
+
+	sysctls, err := svc.GetPodSysctls(context.Background(), "pod-ns", "with-sysctls")
+	if err != nil || !reflect.DeepEqual(sysctls, map[string]string{"net.core.somaxconn": "4096"}) {
+		t.Fatalf("Expected the sysctls of the annotation, actual %+v, err %+v", sysctls, err)
+	}
+
+	if sysctls, err = svc.GetPodSysctls(context.Background(), "pod-ns", "without-sysctls"); err != nil || sysctls != nil {
+		t.Fatalf("Expected no sysctls without the annotation, actual %+v, err %+v", sysctls, err)
+	}
+
+	if _, err = svc.GetPodSysctls(context.Background(), "pod-ns", "invalid-sysctls"); !errors.Is(err, errInvalidPodSysctlsAnnotation) {
+		t.Fatalf("Expected an invalid annotation error, actual %+v", err)
+	}
+
+	if _, err = svc.GetPodSysctls(context.Background(), "pod-ns", "missing"); err == nil {
+		t.Fatalf("Expected a missing pod to fail")
+	}
+
+	if _, err = svc.GetPodSysctls(context.Background(), "", "with-sysctls"); !errors.Is(err, errInvalidPodSysctlsRequest) {
+		t.Fatalf("Expected an invalid request error, actual %+v", err)
+	}
+}
+
+func TestGetPodSysctlsWithoutPodsGetter(t *testing.T) {
+	svc := getTestService()
+	svc.PodsGetter = nil
+
+	if _, err := svc.GetPodSysctls(context.Background(), "pod-ns", "with-sysctls"); !errors.Is(err, errPodSysctlsNotSupported) {
+		t.Fatalf("Expected a not supported error, actual %+v", err)
+	}
+}
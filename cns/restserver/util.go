@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
@@ -173,6 +174,21 @@ func (service *HTTPRestService) saveNetworkContainerGoalState(
 
 			logger.Printf("Pod info %v", podInfo)
 
+			// An NC of an additional interface of the pod does not replace the one of its primary interface.
+			if req.PodInterfaceName != "" {
+				if service.state.PodInterfaceContainerIDs == nil {
+					service.state.PodInterfaceContainerIDs = make(map[string]map[string]string)
+				}
+
+				podKey := podInfo.Name() + podInfo.Namespace()
+				if service.state.PodInterfaceContainerIDs[podKey] == nil {
+					service.state.PodInterfaceContainerIDs[podKey] = make(map[string]string)
+				}
+
+				service.state.PodInterfaceContainerIDs[podKey][req.PodInterfaceName] = req.NetworkContainerid
+				break
+			}
+
 			if service.state.ContainerIDByOrchestratorContext == nil {
 				service.state.ContainerIDByOrchestratorContext = make(map[string]string)
 			}
@@ -333,6 +349,7 @@ func (service *HTTPRestService) removeToBeDeletedIPStateUntransacted(
 		ipID,
 		service.PodIPConfigState[ipID])
 	delete(service.PodIPConfigState, ipID)
+	service.ipReleases.Forget(ipID)
 	return 0, ""
 }
 
@@ -344,6 +361,7 @@ func (service *HTTPRestService) getNetworkContainerResponse(
 		getNetworkContainerResponse cns.GetNetworkContainerResponse
 		exists                      bool
 		waitingForUpdate            bool
+		podInterfaceContainerIDs    map[string]string
 	)
 
 	service.Lock()
@@ -414,6 +432,7 @@ func (service *HTTPRestService) getNetworkContainerResponse(
 		}
 
 		logger.Printf("containerid %v", containerID)
+		podInterfaceContainerIDs = service.state.PodInterfaceContainerIDs[podInfo.Name()+podInfo.Namespace()]
 
 	default:
 		getNetworkContainerResponse.Response.ReturnCode = types.UnsupportedOrchestratorType
@@ -442,9 +461,46 @@ func (service *HTTPRestService) getNetworkContainerResponse(
 		AllowNCToHostCommunication: savedReq.AllowNCToHostCommunication,
 	}
 
+	getNetworkContainerResponse.AdditionalInterfaces = service.podInterfaceConfigsUntransacted(podInterfaceContainerIDs)
+
 	return getNetworkContainerResponse
 }
 
+// podInterfaceConfigsUntransacted returns the configurations of the additional interfaces of a pod, ordered by
+// interface name, from the NCs backing them.
+func (service *HTTPRestService) podInterfaceConfigsUntransacted(networkContainerIDs map[string]string) []cns.PodInterfaceConfig {
+	ifNames := make([]string, 0, len(networkContainerIDs))
+	for ifName := range networkContainerIDs {
+		ifNames = append(ifNames, ifName)
+	}
+	sort.Strings(ifNames)
+
+	var configs []cns.PodInterfaceConfig
+	for _, ifName := range ifNames {
+		containerDetails, ok := service.state.ContainerStatus[networkContainerIDs[ifName]]
+		if !ok {
+			logger.Errorf("[Azure-CNS] NC %s of pod interface %s doesn't exist", networkContainerIDs[ifName], ifName)
+			continue
+		}
+
+		savedReq := containerDetails.CreateNetworkContainerRequest
+		configs = append(configs, cns.PodInterfaceConfig{
+			InterfaceName:              ifName,
+			NetworkContainerID:         savedReq.NetworkContainerid,
+			IPConfiguration:            savedReq.IPConfiguration,
+			Routes:                     savedReq.Routes,
+			CnetAddressSpace:           savedReq.CnetAddressSpace,
+			MultiTenancyInfo:           savedReq.MultiTenancyInfo,
+			PrimaryInterfaceIdentifier: savedReq.PrimaryInterfaceIdentifier,
+			LocalIPConfiguration:       savedReq.LocalIPConfiguration,
+			AllowHostToNCCommunication: savedReq.AllowHostToNCCommunication,
+			AllowNCToHostCommunication: savedReq.AllowNCToHostCommunication,
+		})
+	}
+
+	return configs
+}
+
 // restoreNetworkState restores Network state that existed before reboot.
 func (service *HTTPRestService) restoreNetworkState() error {
 	logger.Printf("[Azure CNS] Enter Restoring Network State")
@@ -0,0 +1,66 @@
+package restserver
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/logger"
+	"github.com/Azure/azure-container-networking/cns/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+	errVxlanPeersNotSupported = errors.New("CNS does not list nodes from the API server")
+	errVxlanPeersNotSynced    = errors.New("the nodes have not been listed from the API server yet")
+)
+
+// GetVxlanPeers returns the peers of the VXLAN overlay of the tunnel mode, the first internal IP of each node of the
+// cluster in node name order, read from the shared informer cache of the nodes. The node of CNS is among them, CNI
+// skips its own IP.
+func (service *HTTPRestService) GetVxlanPeers() ([]string, error) {
+	if service.NodeLister == nil {
+		return nil, errVxlanPeersNotSupported
+	}
+	if service.NodesSynced != nil && !service.NodesSynced() {
+		return nil, errVxlanPeersNotSynced
+	}
+
+	nodes, err := service.NodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	peers := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == corev1.NodeInternalIP {
+				peers = append(peers, addr.Address)
+				break
+			}
+		}
+	}
+
+	return peers, nil
+}
+
+func (service *HTTPRestService) getVxlanPeersHandler(w http.ResponseWriter, r *http.Request) {
+	var resp cns.VxlanPeersResponse
+	peers, err := service.GetVxlanPeers()
+	if err != nil {
+		logger.Errorf("getVxlanPeersHandler failed: %v", err)
+		returnCode := types.UnexpectedError
+		if errors.Is(err, errVxlanPeersNotSupported) {
+			returnCode = types.UnsupportedEnvironment
+		}
+		resp.Response = cns.Response{ReturnCode: returnCode, Message: err.Error()}
+	} else {
+		resp.Peers = peers
+	}
+
+	err = service.Listener.Encode(w, &resp)
+	logger.Response(service.Name, resp, resp.Response.ReturnCode, err)
+}
@@ -0,0 +1,66 @@
+package restserver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func testNode(name string, addresses ...corev1.NodeAddress) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Addresses: addresses},
+	}
+}
+
+func testNodeLister(t *testing.T, nodes ...*corev1.Node) corev1listers.NodeLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range nodes {
+		if err := indexer.Add(node); err != nil {
+			t.Fatalf("Failed to add node %s to the cache, err %+v", node.Name, err)
+		}
+	}
+
+	return corev1listers.NewNodeLister(indexer)
+}
+
+func TestGetVxlanPeers(t *testing.T) {
+	svc := getTestService()
+	svc.NodeLister = testNodeLister(t,
+		testNode("node2", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.240.0.6"}),
+		testNode("node1",
+			corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node1"},
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.240.0.4"},
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.240.0.5"}),
+		testNode("node3", corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node3"}),
+	)
+
+	peers, err := svc.GetVxlanPeers()
+	if err != nil || !reflect.DeepEqual(peers, []string{"10.240.0.4", "10.240.0.6"}) {
+		t.Fatalf("Expected the first internal IP of each node, actual %+v, err %+v", peers, err)
+	}
+}
+
+func TestGetVxlanPeersNotSynced(t *testing.T) {
+	svc := getTestService()
+	svc.NodeLister = testNodeLister(t)
+	svc.NodesSynced = func() bool { return false }
+
+	if _, err := svc.GetVxlanPeers(); !errors.Is(err, errVxlanPeersNotSynced) {
+		t.Fatalf("Expected a not synced error, actual %+v", err)
+	}
+}
+
+func TestGetVxlanPeersWithoutNodeLister(t *testing.T) {
+	svc := getTestService()
+	svc.NodeLister = nil
+
+	if _, err := svc.GetVxlanPeers(); !errors.Is(err, errVxlanPeersNotSupported) {
+		t.Fatalf("Expected a not supported error, actual %+v", err)
+	}
+}
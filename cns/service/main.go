@@ -20,6 +20,8 @@ import (
 	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/cnm/ipam"
 	"github.com/Azure/azure-container-networking/cnm/network"
+	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
+	"github.com/Azure/azure-container-networking/cnms/reconciler"
 	"github.com/Azure/azure-container-networking/cns"
 	cnscli "github.com/Azure/azure-container-networking/cns/cmd/cli"
 	"github.com/Azure/azure-container-networking/cns/cnireconciler"
@@ -32,6 +34,7 @@ import (
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller"
 	"github.com/Azure/azure-container-networking/cns/multitenantcontroller/multitenantoperator"
 	"github.com/Azure/azure-container-networking/cns/nmagent"
+	"github.com/Azure/azure-container-networking/cns/nodestatus"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	kubecontroller "github.com/Azure/azure-container-networking/cns/singletenantcontroller"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
@@ -44,8 +47,12 @@ import (
 	"github.com/Azure/azure-container-networking/platform"
 	localtls "github.com/Azure/azure-container-networking/server/tls"
 	"github.com/Azure/azure-container-networking/store"
+	"github.com/Azure/azure-container-networking/telemetry"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -60,6 +67,8 @@ const (
 
 	// 720 * acn.FiveSeconds sec sleeps = 1Hr
 	maxRetryNodeRegister = 720
+
+	tracingFlushTimeout = 5 * time.Second
 )
 
 var (
@@ -428,8 +437,11 @@ func main() {
 	}
 
 	configuration.SetCNSConfigDefaults(cnsconfig)
+	configureLogging(cnsconfig.LogSettings)
 	logger.Printf("[Azure CNS] Read config :%+v", cnsconfig)
 
+	config.IPSelectionStrategy = string(cnsconfig.IPSelectionStrategy)
+
 	if cnsconfig.WireserverIP != "" {
 		nmagent.WireserverIP = cnsconfig.WireserverIP
 	}
@@ -460,7 +472,13 @@ func main() {
 			DebugMode:                    ts.DebugMode,
 		}
 
-		logger.InitAI(aiConfig, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+		logger.InitAI(aiConfig, ts.Sinks, ts.DisableTrace, ts.DisableMetric, ts.DisableEvent)
+	}
+
+	shutdownTracing, err := tracing.Init(rootCtx, name, version, &cnsconfig.TracingSettings)
+	if err != nil {
+		logger.Errorf("[Azure CNS] Failed to initialize tracing: %v", err)
+		shutdownTracing = func(context.Context) error { return nil }
 	}
 
 	// Log platform information.
@@ -589,6 +607,10 @@ func main() {
 		}
 	}
 
+	if cnsconfig.NetworkMonitorSettings.Enable {
+		startNetworkMonitor(rootCtx, cnsconfig.NetworkMonitorSettings)
+	}
+
 	if !disableTelemetry {
 		go logger.SendHeartBeat(rootCtx, cnsconfig.TelemetrySettings.HeartBeatIntervalInMins)
 		go httpRestService.SendNCSnapShotPeriodically(rootCtx, cnsconfig.TelemetrySettings.SnapshotIntervalInMins)
@@ -706,10 +728,53 @@ func main() {
 		}
 	}
 
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Errorf("[Azure CNS] Failed to flush traces: %v", err)
+	}
+	cancelTracing()
+
 	logger.Printf("CNS exited")
 	logger.Close()
 }
 
+// configureLogging applies the log format and the component log levels from the CNS config.
+func configureLogging(settings configuration.LogSettings) {
+	if settings.Format != "" {
+		format, err := log.ParseFormat(settings.Format)
+		if err != nil {
+			logger.Errorf("[Azure CNS] Ignoring log settings: %v", err)
+		} else {
+			logger.SetFormat(format)
+		}
+	}
+	for component, name := range settings.ComponentLevels {
+		level, err := log.ParseLevel(name)
+		if err != nil {
+			logger.Errorf("[Azure CNS] Ignoring log level of %s: %v", component, err)
+			continue
+		}
+		logger.SetComponentLevel(component, level)
+	}
+}
+
+// startNetworkMonitor runs the CNI rule reconciler in the background until ctx is cancelled, the same loop as the
+// azure-cnimonitor service. Like there, the ebtables rules are only reconciled on nodes with bridge mode networks.
+func startNetworkMonitor(ctx context.Context, settings configuration.NetworkMonitorSettings) {
+	rc := reconciler.New(reconciler.Config{
+		Interval:      time.Duration(settings.IntervalInSecs) * time.Second,
+		StateFilePath: settings.StateFilePath,
+		Version:       version,
+	}, &telemetry.CNIReport{Context: "AzureCNSNetworkMonitor", Version: version})
+	rc.OnDiff = func(diff cnms.Diff, report *telemetry.CNIReport) {
+		logger.Printf("[Azure CNS] Network monitor reconciled rules, ebtables added %v removed %v, iptables added %v, %s",
+			diff.EbtablesAdded, diff.EbtablesRemoved, diff.IptablesAdded, report.ErrorMessage)
+	}
+
+	logger.Printf("[Azure CNS] Starting network monitor")
+	go rc.Run(ctx)
+}
+
 func InitializeMultiTenantController(ctx context.Context, httpRestService cns.HTTPService, cnsconfig configuration.CNSConfig) error {
 	var multiTenantController multitenantcontroller.RequestController
 	kubeConfig, err := ctrl.GetConfig()
@@ -787,7 +852,7 @@ type nodeNetworkConfigGetter interface {
 }
 
 type ncStateReconciler interface {
-	ReconcileNCState(ncRequest *cns.CreateNetworkContainerRequest, podInfoByIP map[string]cns.PodInfo, nnc *v1alpha.NodeNetworkConfig) cnstypes.ResponseCode
+	ReconcileNCState(ncRequests []cns.CreateNetworkContainerRequest, podInfoByIP map[string]cns.PodInfo, nnc *v1alpha.NodeNetworkConfig) cnstypes.ResponseCode
 }
 
 // TODO(rbtr) where should this live??
@@ -817,8 +882,8 @@ func initCNS(ctx context.Context, cli nodeNetworkConfigGetter, ncReconciler ncSt
 		return errors.Wrap(err, "failed to reconcile NC state")
 	}
 
-	// Convert to CreateNetworkContainerRequest
-	ncRequest, err := kubecontroller.CRDStatusToNCRequest(&nnc.Status)
+	// Convert to CreateNetworkContainerRequests
+	ncRequests, err := kubecontroller.CRDStatusToNCRequests(&nnc.Status)
 	if err != nil {
 		return errors.Wrap(err, "failed to convert NNC status to network container request")
 	}
@@ -836,7 +901,7 @@ func initCNS(ctx context.Context, cli nodeNetworkConfigGetter, ncReconciler ncSt
 
 	// errors.Wrap provides additional context, and return nil if the err input arg is nil
 	// Call cnsclient init cns passing those two things.
-	err = restserver.ResponseCodeToError(ncReconciler.ReconcileNCState(&ncRequest, podInfoByIP, nnc))
+	err = restserver.ResponseCodeToError(ncReconciler.ReconcileNCState(ncRequests, podInfoByIP, nnc))
 	return errors.Wrap(err, "err in CNS reconciliation")
 }
 
@@ -872,11 +937,32 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 		return errors.Wrap(err, "failed to get NodeName")
 	}
 	// TODO(rbtr): nodename and namespace should be in the cns config
-	scopedcli := kubecontroller.NewScopedClient(nnccli, types.NamespacedName{Namespace: "kube-system", Name: nodeName})
+	nncKey := types.NamespacedName{Namespace: "kube-system", Name: nodeName}
+	scopedcli := kubecontroller.NewScopedClient(nnccli, nncKey)
+
+	// publish CNS health as events and NNC status conditions
+	eventRecorder, err := nodestatus.NewEventRecorder(kubeConfig, nodeName)
+	if err != nil {
+		return errors.Wrap(err, "failed to create event recorder")
+	}
+	publisher := nodestatus.NewPublisher(eventRecorder, scopedcli, nodeName, nncKey)
 
 	// initialize the ipam pool monitor
-	poolMonitor := ipampool.NewMonitor(httpRestServiceImplementation, scopedcli, &ipampool.Options{RefreshDelay: poolIPAMRefreshRateInMilliseconds})
+	poolMonitor := ipampool.NewMonitor(httpRestServiceImplementation, scopedcli, publisher, &ipampool.Options{RefreshDelay: poolIPAMRefreshRateInMilliseconds})
 	httpRestServiceImplementation.IPAMPoolMonitor = poolMonitor
+
+	// CNI gets the sysctls the pods request in their annotations, and the VXLAN peers of the tunnel mode, through CNS.
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pods clientset")
+	}
+	httpRestServiceImplementation.PodsGetter = clientset.CoreV1()
+	// The peers are served from a watched cache of the nodes, rather than listing them on every pod ADD.
+	informerFactory := informers.NewSharedInformerFactory(clientset, 0)
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	httpRestServiceImplementation.NodeLister = nodeInformer.Lister()
+	httpRestServiceImplementation.NodesSynced = nodeInformer.Informer().HasSynced
+	informerFactory.Start(ctx.Done())
 	logger.Printf("Starting IPAM Pool Monitor")
 	go func() {
 		if e := poolMonitor.Start(ctx); e != nil {
@@ -897,7 +983,7 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 	if err != nil {
 		return errors.Wrap(err, "failed to create manager")
 	}
-	reconciler := kubecontroller.NewReconciler(nnccli, httpRestServiceImplementation, httpRestServiceImplementation.IPAMPoolMonitor)
+	reconciler := kubecontroller.NewReconciler(nnccli, httpRestServiceImplementation, httpRestServiceImplementation.IPAMPoolMonitor, publisher)
 	if err := reconciler.SetupWithManager(manager, nodeName); err != nil {
 		return err
 	}
@@ -935,5 +1021,8 @@ func InitializeCRDState(ctx context.Context, httpRestService cns.HTTPService, cn
 		}
 	}()
 
+	logger.Printf("Starting the IP reservation sweep")
+	go httpRestServiceImplementation.DeleteInactiveIPReservationsPeriodically(ctx, restserver.IPReservationSweepInterval)
+
 	return nil
 }
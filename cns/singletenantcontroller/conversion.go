@@ -14,24 +14,24 @@ var (
 	ErrInvalidPrimaryIP = errors.New("invalid primary IP")
 	// ErrInvalidSecondaryIP indicates that a secondary IP on the NC is invalid.
 	ErrInvalidSecondaryIP = errors.New("invalid secondary IP")
-	// ErrUnsupportedNCQuantity indicates that the node has an unsupported nummber of Network Containers attached.
-	ErrUnsupportedNCQuantity = errors.New("unsupported number of network containers")
 )
 
-// CRDStatusToNCRequest translates a crd status to createnetworkcontainer request
-func CRDStatusToNCRequest(status *v1alpha.NodeNetworkConfigStatus) (cns.CreateNetworkContainerRequest, error) {
-	// if NNC has no NC, return an empty request
-	if len(status.NetworkContainers) == 0 {
-		return cns.CreateNetworkContainerRequest{}, nil
-	}
-
-	// only support a single NC per node, error on more
-	if len(status.NetworkContainers) > 1 {
-		return cns.CreateNetworkContainerRequest{}, errors.Wrapf(ErrUnsupportedNCQuantity, "count: %d", len(status.NetworkContainers))
+// CRDStatusToNCRequests translates a crd status to a createnetworkcontainer request for each NC in the status,
+// in the order they are listed.
+func CRDStatusToNCRequests(status *v1alpha.NodeNetworkConfigStatus) ([]cns.CreateNetworkContainerRequest, error) {
+	ncRequests := make([]cns.CreateNetworkContainerRequest, 0, len(status.NetworkContainers))
+	for i := range status.NetworkContainers {
+		ncRequest, err := ncToNCRequest(&status.NetworkContainers[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert NC %s", status.NetworkContainers[i].ID)
+		}
+		ncRequests = append(ncRequests, ncRequest)
 	}
+	return ncRequests, nil
+}
 
-	nc := status.NetworkContainers[0]
-
+// ncToNCRequest translates a single NC in the crd status to a createnetworkcontainer request.
+func ncToNCRequest(nc *v1alpha.NetworkContainer) (cns.CreateNetworkContainerRequest, error) {
 	ip := net.ParseIP(nc.PrimaryIP)
 	if ip == nil {
 		return cns.CreateNetworkContainerRequest{}, errors.Wrapf(ErrInvalidPrimaryIP, "IP: %s", nc.PrimaryIP)
@@ -23,10 +23,18 @@ const (
 	version            = 1
 )
 
-var invalidStatusMultiNC = v1alpha.NodeNetworkConfigStatus{
+const (
+	uuid2               = "66b7de2e-c2dd-11ea-b3de-0242ac130004"
+	ncID2               = "2b9d9d3c-cd02-11ea-87d0-0242ac130003"
+	primaryIP2          = "10.1.0.1"
+	subnetAddressSpace2 = "10.1.0.0/24"
+	subnetName2         = "subnet2"
+	testSecIP2          = "10.1.0.2"
+)
+
+var invalidStatus = v1alpha.NodeNetworkConfigStatus{
 	NetworkContainers: []v1alpha.NetworkContainer{
 		{},
-		{},
 	},
 }
 
@@ -71,22 +79,64 @@ var validRequest = cns.CreateNetworkContainerRequest{
 	},
 }
 
-func TestConvertNNCStatusToNCRequest(t *testing.T) {
+var validNC2 = v1alpha.NetworkContainer{
+	PrimaryIP: primaryIP2,
+	ID:        ncID2,
+	IPAssignments: []v1alpha.IPAssignment{
+		{
+			Name: uuid2,
+			IP:   testSecIP2,
+		},
+	},
+	SubnetName:         subnetName2,
+	DefaultGateway:     defaultGateway,
+	SubnetAddressSpace: subnetAddressSpace2,
+	Version:            version,
+}
+
+var validStatusMultiNC = v1alpha.NodeNetworkConfigStatus{
+	NetworkContainers: []v1alpha.NetworkContainer{
+		validStatus.NetworkContainers[0],
+		validNC2,
+	},
+	Scaler: validStatus.Scaler,
+}
+
+var validRequest2 = cns.CreateNetworkContainerRequest{
+	Version: strconv.FormatInt(version, 10),
+	IPConfiguration: cns.IPConfiguration{
+		GatewayIPAddress: defaultGateway,
+		IPSubnet: cns.IPSubnet{
+			PrefixLength: uint8(subnetPrefixLen),
+			IPAddress:    primaryIP2,
+		},
+	},
+	NetworkContainerid:   ncID2,
+	NetworkContainerType: cns.Docker,
+	SecondaryIPConfigs: map[string]cns.SecondaryIPConfig{
+		uuid2: {
+			IPAddress: testSecIP2,
+			NCVersion: version,
+		},
+	},
+}
+
+func TestConvertNNCStatusToNCRequests(t *testing.T) {
 	tests := []struct {
 		name    string
 		status  v1alpha.NodeNetworkConfigStatus
-		ncreq   cns.CreateNetworkContainerRequest
+		ncreqs  []cns.CreateNetworkContainerRequest
 		wantErr bool
 	}{
 		{
 			name:    "no nc",
 			status:  v1alpha.NodeNetworkConfigStatus{},
 			wantErr: false,
-			ncreq:   cns.CreateNetworkContainerRequest{},
+			ncreqs:  []cns.CreateNetworkContainerRequest{},
 		},
 		{
-			name:    ">1 nc",
-			status:  invalidStatusMultiNC,
+			name:    "empty nc",
+			status:  invalidStatus,
 			wantErr: true,
 		},
 		{
@@ -188,19 +238,25 @@ func TestConvertNNCStatusToNCRequest(t *testing.T) {
 			name:    "valid",
 			status:  validStatus,
 			wantErr: false,
-			ncreq:   validRequest,
+			ncreqs:  []cns.CreateNetworkContainerRequest{validRequest},
+		},
+		{
+			name:    "valid multiple ncs",
+			status:  validStatusMultiNC,
+			wantErr: false,
+			ncreqs:  []cns.CreateNetworkContainerRequest{validRequest, validRequest2},
 		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CRDStatusToNCRequest(&tt.status)
+			got, err := CRDStatusToNCRequests(&tt.status)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ConvertNNCStatusToNCRequest() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("CRDStatusToNCRequests() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.ncreq) {
-				t.Errorf("ConvertNNCStatusToNCRequest()\nhave: %+v\n want: %+v", got, tt.ncreq)
+			if !reflect.DeepEqual(got, tt.ncreqs) {
+				t.Errorf("CRDStatusToNCRequests()\nhave: %+v\n want: %+v", got, tt.ncreqs)
 			}
 		})
 	}
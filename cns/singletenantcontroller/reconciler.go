@@ -2,14 +2,20 @@ package kubecontroller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/Azure/azure-container-networking/cns"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	"github.com/Azure/azure-container-networking/cns/restserver"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	"github.com/Azure/azure-container-networking/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -18,8 +24,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// staleNCRequeueDelay is how long to wait before checking again whether the NCs removed from the NNC have drained.
+const staleNCRequeueDelay = 30 * time.Second
+
 type cnsClient interface {
 	CreateOrUpdateNetworkContainerInternal(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode
+	RemoveStaleNetworkContainers(activeNCIDs []string) []string
 }
 
 type ipamPoolMonitorClient interface {
@@ -30,18 +40,26 @@ type nncGetter interface {
 	Get(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error)
 }
 
+type statusPublisher interface {
+	Eventf(eventType, reason, messageFmt string, args ...interface{})
+	SetCondition(context.Context, metav1.Condition)
+	Observe(context.Context, *v1alpha.NodeNetworkConfig)
+}
+
 // Reconciler watches for CRD status changes
 type Reconciler struct {
 	cnscli             cnsClient
 	ipampoolmonitorcli ipamPoolMonitorClient
 	nnccli             nncGetter
+	publisher          statusPublisher
 }
 
-func NewReconciler(nnccli nncGetter, cnscli cnsClient, ipampipampoolmonitorcli ipamPoolMonitorClient) *Reconciler {
+func NewReconciler(nnccli nncGetter, cnscli cnsClient, ipampipampoolmonitorcli ipamPoolMonitorClient, publisher statusPublisher) *Reconciler {
 	return &Reconciler{
 		cnscli:             cnscli,
 		ipampoolmonitorcli: ipampipampoolmonitorcli,
 		nnccli:             nnccli,
+		publisher:          publisher,
 	}
 }
 
@@ -58,6 +76,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 
 	logger.Printf("[cns-rc] CRD Spec: %v", nnc.Spec)
+	r.publisher.Observe(ctx, nnc)
 
 	// If there are no network containers, don't hand it off to CNS
 	if len(nnc.Status.NetworkContainers) == 0 {
@@ -65,29 +84,65 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{}, nil
 	}
 
-	// Create NC request and hand it off to CNS
-	ncRequest, err := CRDStatusToNCRequest(&nnc.Status)
+	// Create NC requests and hand them off to CNS
+	ncRequests, err := CRDStatusToNCRequests(&nnc.Status)
 	if err != nil {
 		logger.Errorf("[cns-rc] Error translating crd status to nc request %v", err)
+		r.setNCsNotReady(ctx, "Invalid NC in the NodeNetworkConfig status: %v", err)
 		// requeue
 		return reconcile.Result{}, errors.Wrap(err, "failed to convert NNC status to network container request")
 	}
 
-	responseCode := r.cnscli.CreateOrUpdateNetworkContainerInternal(&ncRequest)
-	err = restserver.ResponseCodeToError(responseCode)
-	if err != nil {
-		logger.Errorf("[cns-rc] Error creating or updating NC in reconcile: %v", err)
-		// requeue
-		return reconcile.Result{}, errors.Wrap(err, "failed to create or update network container")
+	activeNCIDs := make([]string, 0, len(ncRequests))
+	for i := range ncRequests {
+		_, span := tracing.Start(ctx, "cns.CreateOrUpdateNetworkContainer", attribute.String("nc.id", ncRequests[i].NetworkContainerid))
+		responseCode := r.cnscli.CreateOrUpdateNetworkContainerInternal(&ncRequests[i])
+		err = restserver.ResponseCodeToError(responseCode)
+		tracing.End(span, err)
+		if err != nil {
+			logger.Errorf("[cns-rc] Error creating or updating NC %s in reconcile: %v", ncRequests[i].NetworkContainerid, err)
+			r.setNCsNotReady(ctx, "Failed to create or update NC %s: %v", ncRequests[i].NetworkContainerid, err)
+			// requeue
+			return reconcile.Result{}, errors.Wrapf(err, "failed to create or update network container %s", ncRequests[i].NetworkContainerid)
+		}
+		activeNCIDs = append(activeNCIDs, ncRequests[i].NetworkContainerid)
 	}
+	r.publisher.SetCondition(ctx, metav1.Condition{
+		Type:    v1alpha.NetworkContainersReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  v1alpha.ReasonNCsCreated,
+		Message: fmt.Sprintf("Created %d NCs", len(ncRequests)),
+	})
 
 	r.ipampoolmonitorcli.Update(nnc)
 	// record assigned IPs metric
-	assignedIPs.Set(float64(len(nnc.Status.NetworkContainers[0].IPAssignments)))
+	var assignedIPCount int
+	for i := range nnc.Status.NetworkContainers {
+		assignedIPCount += len(nnc.Status.NetworkContainers[i].IPAssignments)
+	}
+	assignedIPs.Set(float64(assignedIPCount))
+
+	// NCs which are no longer in the NNC are deleted from CNS once their IPs are released
+	if draining := r.cnscli.RemoveStaleNetworkContainers(activeNCIDs); len(draining) > 0 {
+		logger.Printf("[cns-rc] Waiting for stale NCs %v to release their IPs", draining)
+		return reconcile.Result{RequeueAfter: staleNCRequeueDelay}, nil
+	}
 
 	return reconcile.Result{}, nil
 }
 
+// setNCsNotReady records a warning event for the NC failure and sets the NetworkContainersReady condition to false.
+func (r *Reconciler) setNCsNotReady(ctx context.Context, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	r.publisher.Eventf(corev1.EventTypeWarning, v1alpha.ReasonNCCreateFailed, "%s", message)
+	r.publisher.SetCondition(ctx, metav1.Condition{
+		Type:    v1alpha.NetworkContainersReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  v1alpha.ReasonNCCreateFailed,
+		Message: message,
+	})
+}
+
 // SetupWithManager Sets up the reconciler with a new manager, filtering using NodeNetworkConfigFilter on nodeName.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, nodeName string) error {
 	err := ctrl.NewControllerManagedBy(mgr).
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-container-networking/cns"
+	"github.com/Azure/azure-container-networking/cns/fakes"
 	"github.com/Azure/azure-container-networking/cns/logger"
 	cnstypes "github.com/Azure/azure-container-networking/cns/types"
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
@@ -12,28 +13,36 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type cnsClientState struct {
-	req *cns.CreateNetworkContainerRequest
-	nnc *v1alpha.NodeNetworkConfig
+	reqs        []cns.CreateNetworkContainerRequest
+	nnc         *v1alpha.NodeNetworkConfig
+	activeNCIDs []string
 }
 
 type mockCNSClient struct {
 	state            cnsClientState
 	createOrUpdateNC func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode
 	update           func(*v1alpha.NodeNetworkConfig)
+	removeStaleNCs   func([]string) []string
 }
 
 //nolint:gocritic // ignore hugeParam pls
 func (m *mockCNSClient) CreateOrUpdateNetworkContainerInternal(req *cns.CreateNetworkContainerRequest) cnstypes.ResponseCode {
-	m.state.req = req
+	m.state.reqs = append(m.state.reqs, *req)
 	return m.createOrUpdateNC(req)
 }
 
+func (m *mockCNSClient) RemoveStaleNetworkContainers(activeNCIDs []string) []string {
+	m.state.activeNCIDs = activeNCIDs
+	return m.removeStaleNCs(activeNCIDs)
+}
+
 func (m *mockCNSClient) Update(nnc *v1alpha.NodeNetworkConfig) {
 	m.state.nnc = nnc
 	m.update(nnc)
@@ -56,6 +65,7 @@ func TestReconcile(t *testing.T) {
 		cnsClient          mockCNSClient
 		want               reconcile.Result
 		wantCNSClientState cnsClientState
+		wantNCsReady       metav1.ConditionStatus
 		wantErr            bool
 	}{
 		{
@@ -90,11 +100,12 @@ func TestReconcile(t *testing.T) {
 			ncGetter: mockNCGetter{
 				get: func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
 					return &v1alpha.NodeNetworkConfig{
-						Status: invalidStatusMultiNC,
+						Status: invalidStatus,
 					}, nil
 				},
 			},
-			wantErr: true,
+			wantNCsReady: metav1.ConditionFalse,
+			wantErr:      true,
 		},
 		{
 			name: "err in CreateOrUpdateNC",
@@ -110,9 +121,10 @@ func TestReconcile(t *testing.T) {
 					return cnstypes.UnexpectedError
 				},
 			},
-			wantErr: true,
+			wantNCsReady: metav1.ConditionFalse,
+			wantErr:      true,
 			wantCNSClientState: cnsClientState{
-				req: &validRequest,
+				reqs: []cns.CreateNetworkContainerRequest{validRequest},
 			},
 		},
 		{
@@ -131,24 +143,55 @@ func TestReconcile(t *testing.T) {
 				createOrUpdateNC: func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode {
 					return cnstypes.Success
 				},
-				update: func(*v1alpha.NodeNetworkConfig) {},
+				update:         func(*v1alpha.NodeNetworkConfig) {},
+				removeStaleNCs: func([]string) []string { return nil },
 			},
-			wantErr: false,
+			wantNCsReady: metav1.ConditionTrue,
+			wantErr:      false,
 			wantCNSClientState: cnsClientState{
-				req: &validRequest,
+				reqs: []cns.CreateNetworkContainerRequest{validRequest},
 				nnc: &v1alpha.NodeNetworkConfig{
 					Status: validStatus,
 					Spec: v1alpha.NodeNetworkConfigSpec{
 						RequestedIPCount: 1,
 					},
 				},
+				activeNCIDs: []string{ncID},
+			},
+		},
+		{
+			name: "multiple NCs with stale NC draining",
+			ncGetter: mockNCGetter{
+				get: func(context.Context, types.NamespacedName) (*v1alpha.NodeNetworkConfig, error) {
+					return &v1alpha.NodeNetworkConfig{
+						Status: validStatusMultiNC,
+					}, nil
+				},
+			},
+			cnsClient: mockCNSClient{
+				createOrUpdateNC: func(*cns.CreateNetworkContainerRequest) cnstypes.ResponseCode {
+					return cnstypes.Success
+				},
+				update:         func(*v1alpha.NodeNetworkConfig) {},
+				removeStaleNCs: func([]string) []string { return []string{"staleNC"} },
+			},
+			want:         reconcile.Result{RequeueAfter: staleNCRequeueDelay},
+			wantNCsReady: metav1.ConditionTrue,
+			wantErr:      false,
+			wantCNSClientState: cnsClientState{
+				reqs: []cns.CreateNetworkContainerRequest{validRequest, validRequest2},
+				nnc: &v1alpha.NodeNetworkConfig{
+					Status: validStatusMultiNC,
+				},
+				activeNCIDs: []string{ncID, ncID2},
 			},
 		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			r := NewReconciler(&tt.ncGetter, &tt.cnsClient, &tt.cnsClient)
+			publisher := &fakes.PublisherFake{}
+			r := NewReconciler(&tt.ncGetter, &tt.cnsClient, &tt.cnsClient, publisher)
 			got, err := r.Reconcile(context.Background(), tt.in)
 			if tt.wantErr {
 				require.Error(t, err)
@@ -157,6 +200,16 @@ func TestReconcile(t *testing.T) {
 			}
 			assert.Equal(t, tt.want, got)
 			assert.Equal(t, tt.wantCNSClientState, tt.cnsClient.state)
+			if tt.wantNCsReady == "" {
+				assert.Nil(t, publisher.Condition(v1alpha.NetworkContainersReady))
+				return
+			}
+			require.NotNil(t, publisher.Condition(v1alpha.NetworkContainersReady))
+			assert.Equal(t, tt.wantNCsReady, publisher.Condition(v1alpha.NetworkContainersReady).Status)
+			if tt.wantNCsReady == metav1.ConditionFalse {
+				require.Len(t, publisher.Events, 1)
+				assert.Contains(t, publisher.Events[0], "Warning NCCreateFailed")
+			}
 		})
 	}
 }
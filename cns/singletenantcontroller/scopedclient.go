@@ -34,3 +34,9 @@ func (sc *ScopedClient) UpdateSpec(ctx context.Context, spec *v1alpha.NodeNetwor
 	nnc, err := sc.Client.UpdateSpec(ctx, sc.NamespacedName, spec)
 	return nnc, errors.Wrapf(err, "failed to update nnc %v", sc.NamespacedName)
 }
+
+// PatchCNSStatus patches the CNS section of the status of the associated NodeNetworkConfig.
+func (sc *ScopedClient) PatchCNSStatus(ctx context.Context, status *v1alpha.CNSStatus) (*v1alpha.NodeNetworkConfig, error) {
+	nnc, err := sc.Client.PatchCNSStatus(ctx, sc.NamespacedName, status)
+	return nnc, errors.Wrapf(err, "failed to patch cns status of nnc %v", sc.NamespacedName)
+}
@@ -51,8 +51,31 @@ type NodeNetworkConfigStatus struct {
 	Scaler            Scaler             `json:"scaler,omitempty"`
 	Status            Status             `json:"status,omitempty"`
 	NetworkContainers []NetworkContainer `json:"networkContainers,omitempty"`
+	CNS               CNSStatus          `json:"cns,omitempty"`
 }
 
+// CNSStatus is the section of the status which is owned and written by CNS on the node.
+type CNSStatus struct {
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Condition types and reasons published by CNS in the CNSStatus.
+const (
+	// NetworkContainersReady indicates whether CNS has created all the NCs in the status.
+	NetworkContainersReady = "NetworkContainersReady"
+	// IPPoolReady indicates whether the IP pool can serve pod IP requests.
+	IPPoolReady = "IPPoolReady"
+
+	ReasonNCsCreated          = "NCsCreated"
+	ReasonNCCreateFailed      = "NCCreateFailed"
+	ReasonPoolReady           = "PoolReady"
+	ReasonPoolExhausted       = "PoolExhausted"
+	ReasonNCVersionMismatch   = "NCVersionMismatch"
+	ReasonPendingReleaseStuck = "PendingReleaseStuck"
+)
+
 // Scaler groups IP request params together
 type Scaler struct {
 	BatchSize               int64 `json:"batchSize,omitempty"`
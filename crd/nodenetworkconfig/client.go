@@ -123,6 +123,28 @@ func (c *Client) PatchSpec(ctx context.Context, key types.NamespacedName, spec *
 	return obj, nil
 }
 
+// PatchCNSStatus performs a merge patch of the CNS section of the status of the NodeNetworkConfig specified by the NamespacedName.
+// The rest of the status is written by the controller which owns the NodeNetworkConfig and is left as is.
+func (c *Client) PatchCNSStatus(ctx context.Context, key types.NamespacedName, status *v1alpha.CNSStatus) (*v1alpha.NodeNetworkConfig, error) {
+	obj := &v1alpha.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+	}
+
+	patch, err := cnsStatusToJSON(status)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.nnccli.Status().Patch(ctx, obj, ctrlcli.RawPatch(types.MergePatchType, patch)); err != nil {
+		return nil, errors.Wrap(err, "failed to patch nnc cns status")
+	}
+
+	return obj, nil
+}
+
 // UpdateSpec does a fetch, deepcopy, and update of the NodeNetworkConfig with the passed spec.
 // Deprecated: UpdateSpec is deprecated and usage should migrate to PatchSpec.
 func (c *Client) UpdateSpec(ctx context.Context, key types.NamespacedName, spec *v1alpha.NodeNetworkConfigSpec) (*v1alpha.NodeNetworkConfig, error) {
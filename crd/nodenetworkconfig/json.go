@@ -17,3 +17,16 @@ func specToJSON(spec *v1alpha.NodeNetworkConfigSpec) ([]byte, error) {
 	}
 	return b, nil
 }
+
+func cnsStatusToJSON(status *v1alpha.CNSStatus) ([]byte, error) {
+	m := map[string]map[string]*v1alpha.CNSStatus{
+		"status": {
+			"cns": status,
+		},
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal nnc cns status")
+	}
+	return b, nil
+}
@@ -3,8 +3,10 @@ package nodenetworkconfig
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-container-networking/crd/nodenetworkconfig/api/v1alpha"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestSpecToJSON(t *testing.T) {
@@ -38,3 +40,25 @@ func TestSpecToJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestCNSStatusToJSON(t *testing.T) {
+	status := &v1alpha.CNSStatus{
+		Conditions: []metav1.Condition{
+			{
+				Type:               v1alpha.IPPoolReady,
+				Status:             metav1.ConditionFalse,
+				Reason:             v1alpha.ReasonPoolExhausted,
+				Message:            "no free IPs",
+				LastTransitionTime: metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	want := `{"status":{"cns":{"conditions":[{"type":"IPPoolReady","status":"False","lastTransitionTime":"2021-01-01T00:00:00Z","reason":"PoolExhausted","message":"no free IPs"}]}}}`
+	got, err := cnsStatusToJSON(status)
+	if err != nil {
+		t.Fatalf("cnsStatusToJSON() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("cnsStatusToJSON() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package iptables
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/log"
+)
+
+// Backend names accepted by SelectBackend.
+const (
+	BackendAuto     = "auto"
+	BackendIptables = "iptables"
+	BackendNftables = "nftables"
+)
+
+// iptables command actions, as used in IPTableEntry params.
+const (
+	actionNewChain  = "-N"
+	actionListChain = "-L"
+	actionAppend    = "-A"
+	actionInsert    = "-I"
+	actionCheck     = "-C"
+	actionDelete    = "-D"
+)
+
+var errInvalidCommand = errors.New("invalid iptables command")
+
+// Backend programs iptables chains and rules.
+// Rules are described in iptables syntax whichever backend programs them.
+type Backend interface {
+	// RunCmd runs iptables-style params, as stored in an IPTableEntry.
+	RunCmd(version, params string) error
+	ChainExists(version, tableName, chainName string) bool
+	CreateChain(version, tableName, chainName string) error
+	RuleExists(version, tableName, chainName, match, target string) bool
+	InsertRule(version, tableName, chainName, match, target string) error
+	AppendRule(version, tableName, chainName, match, target string) error
+	DeleteRule(version, tableName, chainName, match, target string) error
+}
+
+var backend Backend = execBackend{}
+
+// SetBackend replaces the backend used by the package functions.
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// SelectBackend sets the backend by name. An empty name or BackendAuto detects the backend,
+// see detectBackend.
+func SelectBackend(name string) error {
+	switch name {
+	case "", BackendAuto:
+		SetBackend(detectBackend())
+	case BackendIptables:
+		SetBackend(execBackend{})
+	case BackendNftables:
+		b, err := newNftablesBackend()
+		if err != nil {
+			return err
+		}
+		SetBackend(b)
+	default:
+		return fmt.Errorf("unknown iptables backend %q", name)
+	}
+
+	log.Printf("[iptables] Using %T", backend)
+	return nil
+}
+
+// execBackend runs the iptables and ip6tables binaries.
+type execBackend struct{}
+
+func (execBackend) RunCmd(version, params string) error {
+	return runCmd(version, params)
+}
+
+func (execBackend) ChainExists(version, tableName, chainName string) bool {
+	params := fmt.Sprintf("-t %s -L %s", tableName, chainName)
+	return runCmd(version, params) == nil
+}
+
+func (execBackend) CreateChain(version, tableName, chainName string) error {
+	cmd := GetCreateChainCmd(version, tableName, chainName)
+	return runCmd(version, cmd.Params)
+}
+
+func (execBackend) RuleExists(version, tableName, chainName, match, target string) bool {
+	params := fmt.Sprintf("-t %s -C %s %s -j %s", tableName, chainName, match, target)
+	return runCmd(version, params) == nil
+}
+
+func (execBackend) InsertRule(version, tableName, chainName, match, target string) error {
+	cmd := GetInsertIptableRuleCmd(version, tableName, chainName, match, target)
+	return runCmd(version, cmd.Params)
+}
+
+func (execBackend) AppendRule(version, tableName, chainName, match, target string) error {
+	cmd := GetAppendIptableRuleCmd(version, tableName, chainName, match, target)
+	return runCmd(version, cmd.Params)
+}
+
+func (execBackend) DeleteRule(version, tableName, chainName, match, target string) error {
+	params := fmt.Sprintf("-t %s -D %s %s -j %s", tableName, chainName, match, target)
+	return runCmd(version, params)
+}
+
+// Command is an iptables command parsed from iptables-style params.
+type Command struct {
+	Action   string
+	Table    string
+	Chain    string
+	Position int
+	Match    string
+	Target   string
+}
+
+// ParseCommand parses the params of an IPTableEntry. Only the actions the CNI uses are supported.
+func ParseCommand(params string) (Command, error) {
+	cmd := Command{Table: Filter}
+	fields := strings.Fields(params)
+	var match []string
+
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "-t":
+			if i+1 >= len(fields) {
+				return Command{}, fmt.Errorf("%w: missing table in %q", errInvalidCommand, params)
+			}
+			cmd.Table = fields[i+1]
+			i++
+		case actionNewChain, actionListChain, actionAppend, actionInsert, actionCheck, actionDelete:
+			if cmd.Action != "" || i+1 >= len(fields) {
+				return Command{}, fmt.Errorf("%w: %q", errInvalidCommand, params)
+			}
+			cmd.Action = fields[i]
+			cmd.Chain = fields[i+1]
+			i++
+			if cmd.Action == actionInsert && i+1 < len(fields) {
+				if pos, err := strconv.Atoi(fields[i+1]); err == nil {
+					cmd.Position = pos
+					i++
+				}
+			}
+		case "-j":
+			cmd.Target = strings.Join(fields[i+1:], " ")
+			i = len(fields)
+		default:
+			match = append(match, fields[i])
+		}
+	}
+
+	if cmd.Action == "" {
+		return Command{}, fmt.Errorf("%w: no action in %q", errInvalidCommand, params)
+	}
+	cmd.Match = strings.Join(match, " ")
+	return cmd, nil
+}
+
+// runCommand runs parsed params against a backend that does not take raw params.
+func runCommand(b Backend, version, params string) error {
+	cmd, err := ParseCommand(params)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Action {
+	case actionNewChain:
+		return b.CreateChain(version, cmd.Table, cmd.Chain)
+	case actionListChain:
+		if !b.ChainExists(version, cmd.Table, cmd.Chain) {
+			return fmt.Errorf("chain %s does not exist in table %s", cmd.Chain, cmd.Table)
+		}
+		return nil
+	case actionCheck:
+		if !b.RuleExists(version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target) {
+			return fmt.Errorf("rule %q does not exist in chain %s", params, cmd.Chain)
+		}
+		return nil
+	case actionAppend:
+		return b.AppendRule(version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target)
+	case actionInsert:
+		if cmd.Position > 1 {
+			return fmt.Errorf("%w: insert position %d is not supported", errInvalidCommand, cmd.Position)
+		}
+		return b.InsertRule(version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target)
+	case actionDelete:
+		return b.DeleteRule(version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target)
+	default:
+		return fmt.Errorf("%w: %q", errInvalidCommand, params)
+	}
+}
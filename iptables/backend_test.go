@@ -0,0 +1,103 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		params string
+		want   Command
+	}{
+		{
+			params: "-t nat -N SWIFT",
+			want:   Command{Action: "-N", Table: Nat, Chain: Swift},
+		},
+		{
+			params: "-t nat -I SWIFT 1  -m addrtype ! --dst-type local -p udp --dport 53 -j SNAT --to 10.0.0.4",
+			want: Command{
+				Action:   "-I",
+				Table:    Nat,
+				Chain:    Swift,
+				Position: 1,
+				Match:    "-m addrtype ! --dst-type local -p udp --dport 53",
+				Target:   "SNAT --to 10.0.0.4",
+			},
+		},
+		{
+			params: "-A FORWARD -j ACCEPT",
+			want:   Command{Action: "-A", Table: Filter, Chain: Forward, Target: Accept},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCommand(tt.params)
+		require.NoError(t, err, tt.params)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := ParseCommand("-t nat")
+	require.Error(t, err)
+}
+
+func TestNftablesBackend(t *testing.T) {
+	conn := nftables.NewMockConn(false)
+	b := NewNftablesBackend(conn)
+	SetBackend(b)
+	defer SetBackend(execBackend{})
+
+	// the SWIFT rules as programmed through IPTableEntry params
+	require.NoError(t, RunCmd(V4, GetCreateChainCmd(V4, Nat, Swift).Params))
+	require.NoError(t, RunCmd(V4, GetAppendIptableRuleCmd(V4, Nat, Postrouting, "", Swift).Params))
+	match := " -s 10.240.0.0/16 -d 168.63.129.16 -p udp --dport 53"
+	require.NoError(t, RunCmd(V4, GetInsertIptableRuleCmd(V4, Nat, Swift, match, "SNAT --to 10.240.0.4").Params))
+
+	assert.True(t, ChainExists(V4, Nat, Swift))
+	assert.True(t, ChainExists(V4, Nat, Postrouting))
+	assert.False(t, ChainExists(V4, Nat, "MISSING"))
+	assert.True(t, RuleExists(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+	assert.False(t, RuleExists(V6, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+
+	check, ok := GetCheckCmd(GetInsertIptableRuleCmd(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+	require.True(t, ok)
+	require.NoError(t, RunCmd(check.Version, check.Params))
+
+	chains, err := conn.ListChains(nftables.IPv4, NftablesTable)
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, "nat-SWIFT", chains[0].Name)
+	assert.False(t, chains[0].IsBaseChain())
+	assert.Equal(t, nftables.Chain{
+		Table:    NftablesTable,
+		Name:     "nat-POSTROUTING",
+		Type:     nftables.ChainTypeNat,
+		Hook:     nftables.HookPostrouting,
+		Priority: nftables.PrioritySrcNat,
+	}, chains[1])
+
+	// inserting an existing rule is a no-op
+	require.NoError(t, InsertIptableRule(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+	require.NoError(t, InsertIptableRule(V4, Nat, Swift, "-d 168.63.129.16", Return))
+	rules, err := conn.ListRules(nftables.IPv4, NftablesTable, "nat-SWIFT")
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "-d 168.63.129.16 -j RETURN", rules[0].Comment)
+
+	require.NoError(t, DeleteIptableRule(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+	assert.False(t, RuleExists(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+	require.Error(t, DeleteIptableRule(V4, Nat, Swift, match, "SNAT --to 10.240.0.4"))
+
+	// rules in user chains need the chain, and translation failures are surfaced
+	require.Error(t, AppendIptableRule(V4, Filter, CNIInputChain, "", Accept))
+	require.Error(t, AppendIptableRule(V4, Filter, Input, "-m iprange --dst-range 10.0.0.1-10.0.0.2", Accept))
+}
+
+func TestRuleComment(t *testing.T) {
+	assert.Equal(t, "-s 10.0.0.1 -j ACCEPT", ruleComment("  -s   10.0.0.1 ", "ACCEPT"))
+	long := ruleComment("-s 2001:db8:aaaa:bbbb:cccc:dddd:eeee:1/128 -d 2001:db8:aaaa:bbbb:cccc:dddd:eeee:2/128 -p tcp --dport 80", "SNAT --to 2001:db8:aaaa:bbbb:cccc:dddd:eeee:3")
+	assert.LessOrEqual(t, len(long), maxRuleCommentLen)
+}
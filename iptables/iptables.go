@@ -4,6 +4,8 @@ package iptables
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/platform"
@@ -86,6 +88,11 @@ type IPTableEntry struct {
 
 // Run iptables command
 func RunCmd(version, params string) error {
+	return backend.RunCmd(version, params)
+}
+
+// runCmd runs the iptables or ip6tables binary.
+func runCmd(version, params string) error {
 	var cmd string
 
 	p := platform.NewExecClient()
@@ -109,12 +116,7 @@ func RunCmd(version, params string) error {
 
 // check if iptable chain alreay exists
 func ChainExists(version, tableName, chainName string) bool {
-	params := fmt.Sprintf("-t %s -L %s", tableName, chainName)
-	if err := RunCmd(version, params); err != nil {
-		return false
-	}
-
-	return true
+	return backend.ChainExists(version, tableName, chainName)
 }
 
 func GetCreateChainCmd(version, tableName, chainName string) IPTableEntry {
@@ -129,8 +131,7 @@ func CreateChain(version, tableName, chainName string) error {
 	var err error
 
 	if !ChainExists(version, tableName, chainName) {
-		cmd := GetCreateChainCmd(version, tableName, chainName)
-		err = RunCmd(version, cmd.Params)
+		err = backend.CreateChain(version, tableName, chainName)
 	} else {
 		log.Printf("%s Chain exists in table %s", chainName, tableName)
 	}
@@ -140,11 +141,7 @@ func CreateChain(version, tableName, chainName string) error {
 
 // check if iptable rule alreay exists
 func RuleExists(version, tableName, chainName, match, target string) bool {
-	params := fmt.Sprintf("-t %s -C %s %s -j %s", tableName, chainName, match, target)
-	if err := RunCmd(version, params); err != nil {
-		return false
-	}
-	return true
+	return backend.RuleExists(version, tableName, chainName, match, target)
 }
 
 func GetInsertIptableRuleCmd(version, tableName, chainName, match, target string) IPTableEntry {
@@ -161,8 +158,7 @@ func InsertIptableRule(version, tableName, chainName, match, target string) erro
 		return nil
 	}
 
-	cmd := GetInsertIptableRuleCmd(version, tableName, chainName, match, target)
-	return RunCmd(version, cmd.Params)
+	return backend.InsertRule(version, tableName, chainName, match, target)
 }
 
 func GetAppendIptableRuleCmd(version, tableName, chainName, match, target string) IPTableEntry {
@@ -179,12 +175,58 @@ func AppendIptableRule(version, tableName, chainName, match, target string) erro
 		return nil
 	}
 
-	cmd := GetAppendIptableRuleCmd(version, tableName, chainName, match, target)
-	return RunCmd(version, cmd.Params)
+	return backend.AppendRule(version, tableName, chainName, match, target)
 }
 
 // Delete matched iptable rule
 func DeleteIptableRule(version, tableName, chainName, match, target string) error {
-	params := fmt.Sprintf("-t %s -D %s %s -j %s", tableName, chainName, match, target)
-	return RunCmd(version, params)
+	return backend.DeleteRule(version, tableName, chainName, match, target)
+}
+
+// EnsureEntry creates the chain or rule of the entry if it does not exist, the same way CreateChain,
+// InsertIptableRule and AppendIptableRule do. It lets callers program the entries they describe to the
+// network monitor.
+func EnsureEntry(entry IPTableEntry) error {
+	cmd, err := ParseCommand(entry.Params)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Action {
+	case actionNewChain:
+		return CreateChain(entry.Version, cmd.Table, cmd.Chain)
+	case actionInsert:
+		return InsertIptableRule(entry.Version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target)
+	case actionAppend:
+		return AppendIptableRule(entry.Version, cmd.Table, cmd.Chain, cmd.Match, cmd.Target)
+	default:
+		return fmt.Errorf("%w: %q does not create a chain or rule", errInvalidCommand, entry.Params)
+	}
+}
+
+// GetCheckCmd returns the command that checks whether the chain or rule created by entry exists.
+// Entries which do not create a chain or rule are not checkable and return false.
+func GetCheckCmd(entry IPTableEntry) (IPTableEntry, bool) {
+	fields := strings.Fields(entry.Params)
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "-N":
+			fields[i] = "-L"
+		case "-A":
+			fields[i] = "-C"
+		case "-I":
+			fields[i] = "-C"
+			// drop the optional rule position following the chain name
+			if i+2 < len(fields) {
+				if _, err := strconv.Atoi(fields[i+2]); err == nil {
+					fields = append(fields[:i+2], fields[i+3:]...)
+				}
+			}
+		default:
+			continue
+		}
+		return IPTableEntry{Version: entry.Version, Params: strings.Join(fields, " ")}, true
+	}
+
+	return IPTableEntry{}, false
 }
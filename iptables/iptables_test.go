@@ -0,0 +1,84 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCheckCmd(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  IPTableEntry
+		want   IPTableEntry
+		wantOk bool
+	}{
+		{
+			name:   "new chain is listed",
+			entry:  GetCreateChainCmd(V4, Nat, Swift),
+			want:   IPTableEntry{Version: V4, Params: "-t nat -L SWIFT"},
+			wantOk: true,
+		},
+		{
+			name:   "appended rule is checked",
+			entry:  GetAppendIptableRuleCmd(V4, Filter, Forward, "-o azv1", Accept),
+			want:   IPTableEntry{Version: V4, Params: "-t filter -C FORWARD -o azv1 -j ACCEPT"},
+			wantOk: true,
+		},
+		{
+			name:   "inserted rule is checked without its position",
+			entry:  GetInsertIptableRuleCmd(V6, Mangle, Postrouting, "", "MARK --set-mark 0x0"),
+			want:   IPTableEntry{Version: V6, Params: "-t mangle -C POSTROUTING -j MARK --set-mark 0x0"},
+			wantOk: true,
+		},
+		{
+			name:   "inserted rule keeps a numeric match",
+			entry:  IPTableEntry{Version: V4, Params: "-t nat -I SWIFT -p udp --dport 53 -j RETURN"},
+			want:   IPTableEntry{Version: V4, Params: "-t nat -C SWIFT -p udp --dport 53 -j RETURN"},
+			wantOk: true,
+		},
+		{
+			name:  "deleted rule is not checkable",
+			entry: IPTableEntry{Version: V4, Params: "-t nat -D SWIFT -j RETURN"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetCheckCmd(tt.entry)
+			require.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnsureEntry(t *testing.T) {
+	conn := nftables.NewMockConn(false)
+	SetBackend(NewNftablesBackend(conn))
+	defer SetBackend(execBackend{})
+
+	entries := []IPTableEntry{
+		GetCreateChainCmd(V4, Filter, CNIInputChain),
+		GetInsertIptableRuleCmd(V4, Filter, Input, "", CNIInputChain),
+		GetAppendIptableRuleCmd(V4, Filter, CNIInputChain, " -i azSnatbr -d 10.0.0.0/8", Drop),
+	}
+
+	// programming the entries twice does not duplicate them
+	for i := 0; i < 2; i++ {
+		for _, entry := range entries {
+			require.NoError(t, EnsureEntry(entry))
+		}
+	}
+
+	assert.True(t, ChainExists(V4, Filter, CNIInputChain))
+	assert.True(t, RuleExists(V4, Filter, Input, "", CNIInputChain))
+	assert.True(t, RuleExists(V4, Filter, CNIInputChain, "-i azSnatbr -d 10.0.0.0/8", Drop))
+	rules, err := conn.ListRules(nftables.IPv4, NftablesTable, "filter-"+CNIInputChain)
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+
+	require.ErrorIs(t, EnsureEntry(IPTableEntry{Version: V4, Params: "-t nat -D SWIFT -j RETURN"}), errInvalidCommand)
+}
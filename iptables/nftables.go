@@ -0,0 +1,218 @@
+package iptables
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/nftables"
+)
+
+// NftablesTable is the table, in both the ip and ip6 families, the nftables backend programs rules into.
+const NftablesTable = "azure-cni"
+
+// maxRuleCommentLen is the longest rule comment nft accepts, excluding the terminating null.
+const maxRuleCommentLen = 127
+
+// baseChain describes the nftables base chain that stands in for a built-in iptables chain.
+type baseChain struct {
+	chainType string
+	hook      nftables.Hook
+	priority  int32
+}
+
+var baseChains = map[string]map[string]baseChain{
+	Filter: {
+		Input:   {nftables.ChainTypeFilter, nftables.HookInput, nftables.PriorityFilter},
+		Forward: {nftables.ChainTypeFilter, nftables.HookForward, nftables.PriorityFilter},
+		Output:  {nftables.ChainTypeFilter, nftables.HookOutput, nftables.PriorityFilter},
+	},
+	Nat: {
+		Prerouting:  {nftables.ChainTypeNat, nftables.HookPrerouting, nftables.PriorityDstNat},
+		Input:       {nftables.ChainTypeNat, nftables.HookInput, nftables.PrioritySrcNat},
+		Output:      {nftables.ChainTypeNat, nftables.HookOutput, nftables.PriorityDstNat},
+		Postrouting: {nftables.ChainTypeNat, nftables.HookPostrouting, nftables.PrioritySrcNat},
+	},
+	Mangle: {
+		Prerouting:  {nftables.ChainTypeFilter, nftables.HookPrerouting, nftables.PriorityMangle},
+		Input:       {nftables.ChainTypeFilter, nftables.HookInput, nftables.PriorityMangle},
+		Forward:     {nftables.ChainTypeFilter, nftables.HookForward, nftables.PriorityMangle},
+		Output:      {nftables.ChainTypeRoute, nftables.HookOutput, nftables.PriorityMangle},
+		Postrouting: {nftables.ChainTypeFilter, nftables.HookPostrouting, nftables.PriorityMangle},
+	},
+}
+
+// nftablesBackend programs rules into the azure-cni nftables tables over netlink.
+// Each iptables table and chain maps to the chain "<table>-<chain>", built-in chains become base chains
+// created on first use. Rules are translated by nftables.Translate and identified by a comment holding
+// their iptables match and target, which is how they are found again for checks and deletes.
+//
+// Note that an ACCEPT in the azure-cni table only ends evaluation of that table, chains of other tables
+// attached to the same hook, such as those of kube-proxy and NPM, still see the packet.
+type nftablesBackend struct {
+	conn nftables.Conn
+}
+
+// NewNftablesBackend creates a backend that programs rules through conn.
+func NewNftablesBackend(conn nftables.Conn) Backend {
+	return &nftablesBackend{conn: conn}
+}
+
+// newNftablesBackend creates a netlink backend after checking that nf_tables is available.
+func newNftablesBackend() (Backend, error) {
+	conn := nftables.NewConn()
+	if _, err := conn.ListChains(nftables.IPv4, NftablesTable); err != nil {
+		return nil, fmt.Errorf("nftables is not available: %w", err)
+	}
+	return NewNftablesBackend(conn), nil
+}
+
+// detectBackend keeps using the iptables binaries when they are installed, so that rules land next to
+// those of kube-proxy and NPM, and falls back to nftables on hosts which only ship nft.
+func detectBackend() Backend {
+	if _, err := exec.LookPath(iptables); err == nil {
+		return execBackend{}
+	}
+
+	b, err := newNftablesBackend()
+	if err != nil {
+		log.Printf("[iptables] %s not found and %v, using %s", iptables, err, iptables)
+		return execBackend{}
+	}
+	return b
+}
+
+func nftablesFamily(version string) nftables.Family {
+	if version == V6 {
+		return nftables.IPv6
+	}
+	return nftables.IPv4
+}
+
+func nftablesChainName(tableName, chainName string) string {
+	return tableName + "-" + chainName
+}
+
+func isBuiltinChain(tableName, chainName string) bool {
+	_, ok := baseChains[tableName][chainName]
+	return ok
+}
+
+// ruleComment identifies a rule by its normalized match and target.
+func ruleComment(match, target string) string {
+	comment := strings.Join(strings.Fields(match+" -j "+target), " ")
+	if len(comment) > maxRuleCommentLen {
+		sum := sha256.Sum256([]byte(comment))
+		comment = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return comment
+}
+
+func (b *nftablesBackend) RunCmd(version, params string) error {
+	return runCommand(b, version, params)
+}
+
+func (b *nftablesBackend) ChainExists(version, tableName, chainName string) bool {
+	// built-in chains always exist in iptables, their base chains are created on first use
+	if isBuiltinChain(tableName, chainName) {
+		return true
+	}
+
+	chains, err := b.conn.ListChains(nftablesFamily(version), NftablesTable)
+	if err != nil {
+		return false
+	}
+	name := nftablesChainName(tableName, chainName)
+	for _, chain := range chains {
+		if chain.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *nftablesBackend) CreateChain(version, tableName, chainName string) error {
+	family := nftablesFamily(version)
+	if err := b.conn.AddTable(family, NftablesTable); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", NftablesTable, err)
+	}
+
+	chain := nftables.Chain{Table: NftablesTable, Name: nftablesChainName(tableName, chainName)}
+	if base, ok := baseChains[tableName][chainName]; ok {
+		chain.Type = base.chainType
+		chain.Hook = base.hook
+		chain.Priority = base.priority
+	}
+	if err := b.conn.AddChain(family, chain); err != nil {
+		return fmt.Errorf("failed to create chain %s: %w", chain.Name, err)
+	}
+	return nil
+}
+
+// findRule returns the rule with the comment of match and target.
+func (b *nftablesBackend) findRule(version, tableName, chainName, match, target string) (nftables.Rule, bool) {
+	rules, err := b.conn.ListRules(nftablesFamily(version), NftablesTable, nftablesChainName(tableName, chainName))
+	if err != nil {
+		return nftables.Rule{}, false
+	}
+	comment := ruleComment(match, target)
+	for _, rule := range rules {
+		if rule.Comment == comment {
+			return rule, true
+		}
+	}
+	return nftables.Rule{}, false
+}
+
+func (b *nftablesBackend) RuleExists(version, tableName, chainName, match, target string) bool {
+	_, found := b.findRule(version, tableName, chainName, match, target)
+	return found
+}
+
+func (b *nftablesBackend) newRule(version, tableName, chainName, match, target string) (nftables.Rule, error) {
+	if isBuiltinChain(tableName, chainName) {
+		if err := b.CreateChain(version, tableName, chainName); err != nil {
+			return nftables.Rule{}, err
+		}
+	}
+
+	jumpChain := func(chain string) string { return nftablesChainName(tableName, chain) }
+	exprs, err := nftables.Translate(nftablesFamily(version), match, target, jumpChain)
+	if err != nil {
+		return nftables.Rule{}, err
+	}
+
+	return nftables.Rule{
+		Table:   NftablesTable,
+		Chain:   nftablesChainName(tableName, chainName),
+		Exprs:   exprs,
+		Comment: ruleComment(match, target),
+	}, nil
+}
+
+func (b *nftablesBackend) InsertRule(version, tableName, chainName, match, target string) error {
+	rule, err := b.newRule(version, tableName, chainName, match, target)
+	if err != nil {
+		return err
+	}
+	return b.conn.InsertRule(nftablesFamily(version), rule)
+}
+
+func (b *nftablesBackend) AppendRule(version, tableName, chainName, match, target string) error {
+	rule, err := b.newRule(version, tableName, chainName, match, target)
+	if err != nil {
+		return err
+	}
+	return b.conn.AppendRule(nftablesFamily(version), rule)
+}
+
+func (b *nftablesBackend) DeleteRule(version, tableName, chainName, match, target string) error {
+	rule, found := b.findRule(version, tableName, chainName, match, target)
+	if !found {
+		return fmt.Errorf("rule %q does not exist in chain %s", ruleComment(match, target), chainName)
+	}
+	return b.conn.DeleteRule(nftablesFamily(version), rule)
+}
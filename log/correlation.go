@@ -0,0 +1,87 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package log
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// HTTP headers carrying the correlation ID between ACN components.
+const (
+	HeaderRequestID    = "X-Acn-Request-Id"
+	HeaderPodNamespace = "X-Acn-Pod-Namespace"
+	HeaderPodName      = "X-Acn-Pod-Name"
+	HeaderContainerID  = "X-Acn-Container-Id"
+)
+
+// Correlation identifies the pod operation that a log entry belongs to, across components.
+type Correlation struct {
+	RequestID    string
+	PodNamespace string
+	PodName      string
+	ContainerID  string
+}
+
+type correlationKey struct{}
+
+// NewRequestID returns a new random request ID.
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
+// WithCorrelation returns a copy of the context carrying the correlation ID.
+func WithCorrelation(ctx context.Context, c Correlation) context.Context {
+	return context.WithValue(ctx, correlationKey{}, c)
+}
+
+// CorrelationFromContext returns the correlation ID carried by the context, if any.
+func CorrelationFromContext(ctx context.Context) Correlation {
+	c, _ := ctx.Value(correlationKey{}).(Correlation)
+	return c
+}
+
+// CorrelationFromHeaders returns the correlation ID carried by the HTTP headers, if any.
+func CorrelationFromHeaders(h http.Header) Correlation {
+	return Correlation{
+		RequestID:    h.Get(HeaderRequestID),
+		PodNamespace: h.Get(HeaderPodNamespace),
+		PodName:      h.Get(HeaderPodName),
+		ContainerID:  h.Get(HeaderContainerID),
+	}
+}
+
+// SetHeaders sets the non-empty parts of the correlation ID in the HTTP headers.
+func (c Correlation) SetHeaders(h http.Header) {
+	for header, value := range map[string]string{
+		HeaderRequestID:    c.RequestID,
+		HeaderPodNamespace: c.PodNamespace,
+		HeaderPodName:      c.PodName,
+		HeaderContainerID:  c.ContainerID,
+	} {
+		if value != "" {
+			h.Set(header, value)
+		}
+	}
+}
+
+// KeysAndValues returns the non-empty parts of the correlation ID as log fields.
+func (c Correlation) KeysAndValues() []interface{} {
+	var kv []interface{}
+	if c.RequestID != "" {
+		kv = append(kv, "requestID", c.RequestID)
+	}
+	if c.PodNamespace != "" {
+		kv = append(kv, "podNamespace", c.PodNamespace)
+	}
+	if c.PodName != "" {
+		kv = append(kv, "podName", c.PodName)
+	}
+	if c.ContainerID != "" {
+		kv = append(kv, "containerID", c.ContainerID)
+	}
+	return kv
+}
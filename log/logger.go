@@ -54,6 +54,10 @@ type Logger struct {
 	callCount    int
 	directory    string
 	mutex        *sync.Mutex
+
+	format          int
+	componentLevels map[string]int
+	hook            EntryHook
 }
 
 var pid = os.Getpid()
@@ -206,30 +210,20 @@ func (logger *Logger) logf(format string, args ...interface{}) {
 
 // Logf wraps logf.
 func (logger *Logger) Logf(format string, args ...interface{}) {
-	logger.mutex.Lock()
-	logger.logf(format, args...)
-	logger.mutex.Unlock()
+	logger.printf(LevelInfo, false, format, args...)
 }
 
 // Printf logs a formatted string at info level.
 func (logger *Logger) Printf(format string, args ...interface{}) {
-	if logger.level < LevelInfo {
-		return
-	}
-
-	logger.Logf(format, args...)
+	logger.printf(LevelInfo, true, format, args...)
 }
 
-// Debugf logs a formatted string at info level.
+// Debugf logs a formatted string at debug level.
 func (logger *Logger) Debugf(format string, args ...interface{}) {
-	if logger.level < LevelDebug {
-		return
-	}
-
-	logger.Logf(format, args...)
+	logger.printf(LevelDebug, true, format, args...)
 }
 
-// Errorf logs a formatted string at info level and sends the string to TelemetryBuffer.
+// Errorf logs a formatted string at error level.
 func (logger *Logger) Errorf(format string, args ...interface{}) {
-	logger.Logf(format, args...)
+	logger.printf(LevelError, true, format, args...)
 }
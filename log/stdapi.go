@@ -61,3 +61,16 @@ func Debugf(format string, args ...interface{}) {
 func Errorf(format string, args ...interface{}) {
 	stdLog.Errorf(format, args...)
 }
+
+func SetFormat(format int) {
+	stdLog.SetFormat(format)
+}
+
+func SetComponentLevel(component string, level int) {
+	stdLog.SetComponentLevel(component, level)
+}
+
+// Component returns a structured Entry for the named component on the standard logger.
+func Component(name string) *Entry {
+	return stdLog.Component(name)
+}
@@ -0,0 +1,323 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log format
+const (
+	// FormatText is the legacy printf style format.
+	FormatText = iota
+	// FormatLogfmt writes entries as key=value pairs.
+	FormatLogfmt
+	// FormatJSON writes entries as JSON objects, one per line.
+	FormatJSON
+)
+
+var levelNames = map[int]string{
+	LevelAlert:   "alert",
+	LevelError:   "error",
+	LevelWarning: "warning",
+	LevelInfo:    "info",
+	LevelDebug:   "debug",
+}
+
+var formatNames = map[string]int{
+	"text":   FormatText,
+	"logfmt": FormatLogfmt,
+	"json":   FormatJSON,
+}
+
+// ParseFormat returns the log format with the name text, logfmt or json.
+func ParseFormat(name string) (int, error) {
+	format, ok := formatNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid log format %q", name)
+	}
+	return format, nil
+}
+
+// ParseLevel returns the log level with the name alert, error, warning, info or debug.
+func ParseLevel(name string) (int, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid log level %q", name)
+}
+
+// EntryHook is called with the text rendering of every structured entry written by a Logger.
+type EntryHook func(level int, line string)
+
+// Entry writes leveled, structured log entries for a component through a Logger.
+// The fields of an Entry are added to every entry it writes.
+type Entry struct {
+	logger    *Logger
+	component string
+	fields    []interface{}
+}
+
+// SetFormat sets the format of the log entries.
+func (logger *Logger) SetFormat(format int) {
+	logger.mutex.Lock()
+	logger.format = format
+	logger.mutex.Unlock()
+}
+
+// SetComponentLevel overrides the log level for the component. Legacy printf call sites are
+// attributed to the component named in their bracketed prefix, e.g. "[cni-net]".
+func (logger *Logger) SetComponentLevel(component string, level int) {
+	logger.mutex.Lock()
+	if logger.componentLevels == nil {
+		logger.componentLevels = make(map[string]int)
+	}
+	logger.componentLevels[component] = level
+	logger.mutex.Unlock()
+}
+
+// SetHook sets the hook called with every structured entry.
+func (logger *Logger) SetHook(hook EntryHook) {
+	logger.mutex.Lock()
+	logger.hook = hook
+	logger.mutex.Unlock()
+}
+
+// Component returns an Entry for the named component.
+func (logger *Logger) Component(name string) *Entry {
+	return &Entry{logger: logger, component: name}
+}
+
+// With returns a copy of the Entry with the key/value pairs added to its fields.
+func (e *Entry) With(keysAndValues ...interface{}) *Entry {
+	fields := make([]interface{}, 0, len(e.fields)+len(keysAndValues))
+	fields = append(fields, e.fields...)
+	fields = append(fields, keysAndValues...)
+	return &Entry{logger: e.logger, component: e.component, fields: fields}
+}
+
+// WithContext returns a copy of the Entry with the correlation fields of the context added.
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	kv := CorrelationFromContext(ctx).KeysAndValues()
+	if len(kv) == 0 {
+		return e
+	}
+	return e.With(kv...)
+}
+
+// Debug logs the message and key/value pairs at debug level.
+func (e *Entry) Debug(msg string, keysAndValues ...interface{}) {
+	e.log(LevelDebug, msg, keysAndValues)
+}
+
+// Info logs the message and key/value pairs at info level.
+func (e *Entry) Info(msg string, keysAndValues ...interface{}) {
+	e.log(LevelInfo, msg, keysAndValues)
+}
+
+// Warn logs the message and key/value pairs at warning level.
+func (e *Entry) Warn(msg string, keysAndValues ...interface{}) {
+	e.log(LevelWarning, msg, keysAndValues)
+}
+
+// Error logs the message, the error and key/value pairs at error level.
+func (e *Entry) Error(err error, msg string, keysAndValues ...interface{}) {
+	if err != nil {
+		keysAndValues = append([]interface{}{"error", err.Error()}, keysAndValues...)
+	}
+	e.log(LevelError, msg, keysAndValues)
+}
+
+// Printf logs a formatted string at info level.
+func (e *Entry) Printf(format string, args ...interface{}) {
+	e.log(LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+// Debugf logs a formatted string at debug level.
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	e.log(LevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+// Errorf logs a formatted string at error level.
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	e.log(LevelError, fmt.Sprintf(format, args...), nil)
+}
+
+func (e *Entry) log(level int, msg string, keysAndValues []interface{}) {
+	fields := e.fields
+	if len(keysAndValues) > 0 {
+		fields = append(append(make([]interface{}, 0, len(fields)+len(keysAndValues)), fields...), keysAndValues...)
+	}
+
+	logger := e.logger
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if !logger.enabled(level, e.component) {
+		return
+	}
+	line := logger.writeEntry(level, e.component, msg, fields)
+	if logger.hook != nil {
+		logger.hook(level, line)
+	}
+}
+
+// enabled returns whether entries of the level are written for the component.
+// Errors are always written unless the level of the component is explicitly lowered.
+func (logger *Logger) enabled(level int, component string) bool {
+	if componentLevel, ok := logger.componentLevels[component]; ok {
+		return level <= componentLevel
+	}
+	return level == LevelError || level <= logger.level
+}
+
+// structured returns whether legacy printf call sites need to be parsed into entries.
+func (logger *Logger) structured() bool {
+	return logger.format != FormatText || len(logger.componentLevels) > 0
+}
+
+// printf is the adapter from the legacy printf call sites to the structured entries.
+// Unleveled messages are only filtered by the level of their component.
+func (logger *Logger) printf(level int, leveled bool, format string, args ...interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	if !logger.structured() {
+		if !leveled || logger.enabled(level, "") {
+			logger.logf(format, args...)
+		}
+		return
+	}
+
+	component, msg := splitComponent(fmt.Sprintf(format, args...))
+	if _, ok := logger.componentLevels[component]; (leveled || ok) && !logger.enabled(level, component) {
+		return
+	}
+	logger.writeEntry(level, component, msg, nil)
+}
+
+// writeEntry writes the entry in the format of the logger and returns its text rendering.
+// The caller must hold the logger mutex.
+func (logger *Logger) writeEntry(level int, component, msg string, fields []interface{}) string {
+	text := formatText(component, msg, fields)
+	if logger.format == FormatText {
+		logger.logf("%s", text)
+		return text
+	}
+
+	if logger.callCount%rotationCheckFrq == 0 {
+		logger.rotate()
+	}
+	logger.callCount++
+
+	var buf bytes.Buffer
+	if logger.format == FormatJSON {
+		encodeJSON(&buf, level, component, msg, fields)
+	} else {
+		encodeLogfmt(&buf, level, component, msg, fields)
+	}
+	buf.WriteByte('\n')
+	if w := logger.l.Writer(); w != nil {
+		_, _ = w.Write(buf.Bytes())
+	}
+	return text
+}
+
+// splitComponent splits the bracketed component prefix of a legacy log message, e.g. "[cni-net] msg".
+func splitComponent(msg string) (string, string) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg
+	}
+	end := strings.IndexByte(msg, ']')
+	if end < 2 || strings.ContainsAny(msg[1:end], " \t") {
+		return "", msg
+	}
+	return msg[1:end], strings.TrimLeft(msg[end+1:], " ")
+}
+
+// fieldPairs calls f for each key/value pair. A dangling key is paired with a placeholder value.
+func fieldPairs(fields []interface{}, f func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		var value interface{} = "(MISSING)"
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		f(key, value)
+	}
+}
+
+func formatText(component, msg string, fields []interface{}) string {
+	var sb strings.Builder
+	if component != "" {
+		sb.WriteString("[" + component + "] ")
+	}
+	sb.WriteString(msg)
+	fieldPairs(fields, func(key string, value interface{}) {
+		sb.WriteString(" " + key + "=" + logfmtValue(value))
+	})
+	return sb.String()
+}
+
+func encodeLogfmt(buf *bytes.Buffer, level int, component, msg string, fields []interface{}) {
+	buf.WriteString("time=" + time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(" level=" + levelNames[level])
+	buf.WriteString(" pid=" + strconv.Itoa(pid))
+	if component != "" {
+		buf.WriteString(" component=" + logfmtValue(component))
+	}
+	buf.WriteString(" msg=" + logfmtValue(msg))
+	fieldPairs(fields, func(key string, value interface{}) {
+		buf.WriteString(" " + key + "=" + logfmtValue(value))
+	})
+}
+
+func logfmtValue(value interface{}) string {
+	s := fmt.Sprintf("%+v", value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func encodeJSON(buf *bytes.Buffer, level int, component, msg string, fields []interface{}) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, levelNames[level])
+	buf.WriteString(`,"pid":` + strconv.Itoa(pid))
+	if component != "" {
+		buf.WriteString(`,"component":`)
+		writeJSON(buf, component)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, msg)
+	fieldPairs(fields, func(key string, value interface{}) {
+		buf.WriteByte(',')
+		writeJSON(buf, key)
+		buf.WriteByte(':')
+		writeJSON(buf, value)
+	})
+	buf.WriteByte('}')
+}
+
+// writeJSON writes the JSON encoding of the value, falling back to its string form
+// for values that cannot be encoded.
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	buf.Write(b)
+}
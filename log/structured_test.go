@@ -0,0 +1,109 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newBufferLogger(level, format int) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := NewLogger(logName, level, TargetStderr, "")
+	l.l.SetOutput(&buf)
+	l.SetFormat(format)
+	return l, &buf
+}
+
+func TestEntryJSON(t *testing.T) {
+	l, buf := newBufferLogger(LevelInfo, FormatJSON)
+
+	l.Component("cni-net").With("ifName", "eth0").Error(errors.New("boom"), "failed to add", "attempt", 2)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode entry %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]interface{}{
+		"level":     "error",
+		"component": "cni-net",
+		"msg":       "failed to add",
+		"ifName":    "eth0",
+		"error":     "boom",
+		"attempt":   float64(2),
+	} {
+		if entry[key] != want {
+			t.Errorf("Unexpected %s %v, expected %v", key, entry[key], want)
+		}
+	}
+}
+
+func TestEntryLogfmt(t *testing.T) {
+	l, buf := newBufferLogger(LevelInfo, FormatLogfmt)
+
+	l.Component("cns").Info("allocated ip", "ip", "10.0.0.4", "pod", "default/pod 1")
+
+	line := buf.String()
+	for _, want := range []string{" level=info ", " component=cns ", ` msg="allocated ip" `, " ip=10.0.0.4 ", ` pod="default/pod 1"`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Entry %q does not contain %q", line, want)
+		}
+	}
+}
+
+func TestPrintfAdapter(t *testing.T) {
+	l, buf := newBufferLogger(LevelInfo, FormatLogfmt)
+
+	l.Printf("[cni-net] Processing ADD command for %s.", "pod1")
+	if line := buf.String(); !strings.Contains(line, ` component=cni-net msg="Processing ADD command for pod1."`) {
+		t.Errorf("Unexpected entry %q", line)
+	}
+
+	buf.Reset()
+	l.Debugf("[cni-net] debug")
+	if buf.Len() != 0 {
+		t.Errorf("Unexpected debug entry %q", buf.String())
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	l, buf := newBufferLogger(LevelInfo, FormatText)
+	l.SetComponentLevel("cni-net", LevelDebug)
+	l.SetComponentLevel("cni-ipam", LevelError)
+
+	l.Debugf("[cni-net] verbose")
+	l.Printf("[cni-ipam] quiet")
+	l.Component("cni-ipam").Info("quiet")
+	l.Errorf("[cni-ipam] loud")
+	l.Debugf("[other] hidden")
+
+	out := buf.String()
+	if !strings.Contains(out, "[cni-net] verbose") || !strings.Contains(out, "[cni-ipam] loud") {
+		t.Errorf("Missing entries in %q", out)
+	}
+	if strings.Contains(out, "quiet") || strings.Contains(out, "hidden") {
+		t.Errorf("Unexpected entries in %q", out)
+	}
+}
+
+func TestCorrelationPropagation(t *testing.T) {
+	c := Correlation{RequestID: NewRequestID(), PodNamespace: "default", PodName: "pod1", ContainerID: "abc"}
+
+	h := http.Header{}
+	CorrelationFromContext(WithCorrelation(context.Background(), c)).SetHeaders(h)
+	if got := CorrelationFromHeaders(h); got != c {
+		t.Fatalf("Unexpected correlation %+v, expected %+v", got, c)
+	}
+
+	l, buf := newBufferLogger(LevelInfo, FormatText)
+	l.Component("cns").WithContext(WithCorrelation(context.Background(), c)).Info("released ip")
+	if !strings.Contains(buf.String(), "[cns] released ip requestID="+c.RequestID+" podNamespace=default podName=pod1 containerID=abc") {
+		t.Errorf("Unexpected entry %q", buf.String())
+	}
+}
@@ -17,10 +17,13 @@ import (
 
 // Link types.
 const (
-	LINK_TYPE_BRIDGE = "bridge"
-	LINK_TYPE_VETH   = "veth"
-	LINK_TYPE_IPVLAN = "ipvlan"
-	LINK_TYPE_DUMMY  = "dummy"
+	LINK_TYPE_BRIDGE  = "bridge"
+	LINK_TYPE_VETH    = "veth"
+	LINK_TYPE_IPVLAN  = "ipvlan"
+	LINK_TYPE_MACVLAN = "macvlan"
+	LINK_TYPE_DUMMY   = "dummy"
+	LINK_TYPE_VXLAN   = "vxlan"
+	LINK_TYPE_IFB     = "ifb"
 )
 
 // IPVLAN link attributes.
@@ -33,6 +36,17 @@ const (
 	IPVLAN_MODE_MAX
 )
 
+// MACVLAN link attributes.
+type MacvlanMode uint32
+
+const (
+	MACVLAN_MODE_PRIVATE MacvlanMode = 1 << iota
+	MACVLAN_MODE_VEPA
+	MACVLAN_MODE_BRIDGE
+	MACVLAN_MODE_PASSTHRU
+	MACVLAN_MODE_SOURCE
+)
+
 const (
 	ADD = iota
 	REMOVE
@@ -74,11 +88,34 @@ type IPVlanLink struct {
 	Mode IPVlanMode
 }
 
+// MacvlanLink represents a MACVLAN network interface.
+type MacvlanLink struct {
+	LinkInfo
+	Mode MacvlanMode
+}
+
 // DummyLink represents a dummy network interface.
 type DummyLink struct {
 	LinkInfo
 }
 
+// VXLANLink represents a VXLAN tunnel network interface. The remote IP is the unicast or multicast destination of
+// the traffic with no FDB entry.
+type VXLANLink struct {
+	LinkInfo
+	VNI           uint32
+	UnderlayIndex int
+	LocalIP       net.IP
+	RemoteIP      net.IP
+	Port          uint16
+	Learning      bool
+}
+
+// IFBLink represents an intermediate functional block network interface.
+type IFBLink struct {
+	LinkInfo
+}
+
 // AddLink adds a new network interface of a specified type.
 func (Netlink) AddLink(link Link) error {
 	info := link.Info()
@@ -92,6 +129,13 @@ func (Netlink) AddLink(link Link) error {
 		return err
 	}
 
+	return s.sendAndWaitForAck(newAddLinkRequest(link))
+}
+
+// newAddLinkRequest returns the request adding the network interface.
+func newAddLinkRequest(link Link) *message {
+	info := link.Info()
+
 	req := newRequest(unix.RTM_NEWLINK, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
 
 	// Set interface information.
@@ -123,33 +167,41 @@ func (Netlink) AddLink(link Link) error {
 		req.addPayload(newAttributeUint32(unix.IFLA_LINK, uint32(info.ParentIndex)))
 	}
 
-	// Set link info.
-	attrLinkInfo := newAttribute(unix.IFLA_LINKINFO, nil)
-	attrLinkInfo.addNested(newAttributeString(IFLA_INFO_KIND, info.Type))
+	// Set link info with the link type-specific attributes.
+	req.addPayload(newAttributeLinkInfo(link))
+
+	return req
+}
 
-	// Set link type-specific attributes.
-	if veth, ok := link.(*VEthLink); ok {
-		// Set VEth attributes.
-		attrData := newAttribute(IFLA_INFO_DATA, nil)
+// GetLink returns the network interface with the given name, typed after its link type. The link types this
+// package does not create are returned as a *LinkInfo.
+func (Netlink) GetLink(name string) (Link, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
 
-		attrPeer := newAttribute(VETH_INFO_PEER, nil)
-		attrPeer.addNested(newIfInfoMsg())
-		attrPeer.addNested(newAttributeStringZ(unix.IFLA_IFNAME, veth.PeerName))
-		attrData.addNested(attrPeer)
+	s, err := getSocket()
+	if err != nil {
+		return nil, err
+	}
 
-		attrLinkInfo.addNested(attrData)
+	req := newRequest(unix.RTM_GETLINK, 0)
 
-	} else if ipvlan, ok := link.(*IPVlanLink); ok {
-		// Set IPVlan attributes.
-		attrData := newAttribute(IFLA_INFO_DATA, nil)
-		attrData.addNested(newAttributeUint16(IFLA_IPVLAN_MODE, uint16(ipvlan.Mode)))
+	ifInfo := newIfInfoMsg()
+	ifInfo.Index = int32(iface.Index)
+	req.addPayload(ifInfo)
 
-		attrLinkInfo.addNested(attrData)
+	msgs, err := s.sendAndWaitForResponse(req)
+	if err != nil {
+		return nil, err
 	}
 
-	req.addPayload(attrLinkInfo)
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("Unexpected number of link messages %d for %s", len(msgs), name)
+	}
 
-	return s.sendAndWaitForAck(req)
+	return deserializeLink(msgs[0])
 }
 
 func (Netlink) SetLinkMTU(name string, mtu int) error {
@@ -463,3 +515,43 @@ func (Netlink) AddOrRemoveStaticArp(mode int, name string, ipaddr net.IP, mac ne
 
 	return s.sendAndWaitForAck(req)
 }
+
+// AddOrRemoveFdbEntry adds/removes a bridge forwarding database entry of the interface based on mode. On a VXLAN
+// interface, the entry sends the frames to mac through the tunnel to the remote dst, an all-zero mac floods the
+// broadcast and unknown unicast frames to dst.
+func (Netlink) AddOrRemoveFdbEntry(mode int, name string, mac net.HardwareAddr, dst net.IP) error {
+	s, err := getSocket()
+	if err != nil {
+		return err
+	}
+
+	var req *message
+	if mode == ADD {
+		req = newRequest(unix.RTM_NEWNEIGH, unix.NLM_F_CREATE|unix.NLM_F_APPEND|unix.NLM_F_ACK)
+	} else {
+		req = newRequest(unix.RTM_DELNEIGH, unix.NLM_F_ACK)
+	}
+
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return err
+	}
+
+	msg := neighMsg{
+		Family: unix.AF_BRIDGE,
+		Index:  uint32(iface.Index),
+		State:  NUD_PERMANENT | NUD_NOARP,
+		Flags:  NTF_SELF,
+	}
+	req.addPayload(&msg)
+
+	req.addPayload(newRtAttr(NDA_LLADDR, []byte(mac)))
+
+	dstData := dst.To4()
+	if dstData == nil {
+		dstData = dst.To16()
+	}
+	req.addPayload(newRtAttr(NDA_DST, dstData))
+
+	return s.sendAndWaitForAck(req)
+}
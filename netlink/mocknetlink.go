@@ -36,6 +36,10 @@ func (f *MockNetlink) AddLink(l Link) error {
 	return f.error()
 }
 
+func (f *MockNetlink) GetLink(string) (Link, error) {
+	return nil, f.error()
+}
+
 func (f *MockNetlink) SetLinkMTU(name string, mtu int) error {
 	return f.error()
 }
@@ -76,6 +80,10 @@ func (f *MockNetlink) AddOrRemoveStaticArp(int, string, net.IP, net.HardwareAddr
 	return f.error()
 }
 
+func (f *MockNetlink) AddOrRemoveFdbEntry(int, string, net.HardwareAddr, net.IP) error {
+	return f.error()
+}
+
 func (f *MockNetlink) AddIPAddress(string, net.IP, *net.IPNet) error {
 	return f.error()
 }
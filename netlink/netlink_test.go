@@ -8,6 +8,7 @@ package netlink
 
 import (
 	"net"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -299,3 +300,199 @@ func TestAddRemoveStaticArp(t *testing.T) {
 		t.Errorf("DeleteLink failed: %+v", err)
 	}
 }
+
+// TestAddDeleteVxlan tests adding and deleting a VXLAN interface with a flood entry to a remote.
+func TestAddDeleteVxlan(t *testing.T) {
+	nl := NewNetlink()
+
+	err := nl.AddLink(&BridgeLink{
+		LinkInfo: LinkInfo{
+			Type: LINK_TYPE_BRIDGE,
+			Name: ifName2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("AddLink failed: %+v", err)
+	}
+
+	underlay, err := net.InterfaceByName(ifName2)
+	if err != nil {
+		t.Fatalf("InterfaceByName failed: %+v", err)
+	}
+
+	link := VXLANLink{
+		LinkInfo: LinkInfo{
+			Type: LINK_TYPE_VXLAN,
+			Name: ifName,
+		},
+		VNI:           4096,
+		UnderlayIndex: underlay.Index,
+		LocalIP:       net.ParseIP("192.168.0.1"),
+		Port:          4789,
+	}
+
+	err = nl.AddLink(&link)
+	if err != nil {
+		t.Errorf("AddLink failed: %+v", err)
+	}
+
+	zeroMac := net.HardwareAddr{0, 0, 0, 0, 0, 0}
+	remote := net.ParseIP("192.168.0.2")
+
+	err = nl.AddOrRemoveFdbEntry(ADD, ifName, zeroMac, remote)
+	if err != nil {
+		t.Errorf("AddOrRemoveFdbEntry add failed: %+v", err)
+	}
+
+	err = nl.AddOrRemoveFdbEntry(REMOVE, ifName, zeroMac, remote)
+	if err != nil {
+		t.Errorf("AddOrRemoveFdbEntry remove failed: %+v", err)
+	}
+
+	err = nl.DeleteLink(ifName)
+	if err != nil {
+		t.Errorf("DeleteLink failed: %+v", err)
+	}
+
+	err = nl.DeleteLink(ifName2)
+	if err != nil {
+		t.Errorf("DeleteLink failed: %+v", err)
+	}
+}
+
+// TestLinkRoundTrip tests that the links read back from their encoded add link requests are equal to the links.
+func TestLinkRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		link Link
+	}{
+		{
+			name: "Bridge",
+			link: &BridgeLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_BRIDGE, Name: ifName, Flags: net.FlagUp, MTU: 1500},
+			},
+		},
+		{
+			name: "VEth with peer",
+			link: &VEthLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_VETH, Name: ifName, TxQLen: 1000},
+				PeerName: ifName2,
+			},
+		},
+		{
+			name: "IPVlan L2",
+			link: &IPVlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_IPVLAN, Name: ifName, ParentIndex: 2},
+				Mode:     IPVLAN_MODE_L2,
+			},
+		},
+		{
+			name: "IPVlan L3",
+			link: &IPVlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_IPVLAN, Name: ifName, ParentIndex: 2},
+				Mode:     IPVLAN_MODE_L3,
+			},
+		},
+		{
+			name: "IPVlan L3S",
+			link: &IPVlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_IPVLAN, Name: ifName, ParentIndex: 2, MTU: 9000},
+				Mode:     IPVLAN_MODE_L3S,
+			},
+		},
+		{
+			name: "Macvlan bridge",
+			link: &MacvlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_MACVLAN, Name: ifName, ParentIndex: 3, Flags: net.FlagUp},
+				Mode:     MACVLAN_MODE_BRIDGE,
+			},
+		},
+		{
+			name: "Macvlan private",
+			link: &MacvlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_MACVLAN, Name: ifName, ParentIndex: 3},
+				Mode:     MACVLAN_MODE_PRIVATE,
+			},
+		},
+		{
+			name: "Macvlan passthru",
+			link: &MacvlanLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_MACVLAN, Name: ifName, ParentIndex: 3},
+				Mode:     MACVLAN_MODE_PASSTHRU,
+			},
+		},
+		{
+			name: "VXLAN over IPv4 with learning",
+			link: &VXLANLink{
+				LinkInfo:      LinkInfo{Type: LINK_TYPE_VXLAN, Name: ifName, MTU: 1450},
+				VNI:           4096,
+				UnderlayIndex: 2,
+				LocalIP:       net.ParseIP("192.168.0.1").To4(),
+				RemoteIP:      net.ParseIP("239.1.1.1").To4(),
+				Port:          4789,
+				Learning:      true,
+			},
+		},
+		{
+			name: "VXLAN over IPv6",
+			link: &VXLANLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_VXLAN, Name: ifName},
+				VNI:      1,
+				LocalIP:  net.ParseIP("fd00::1"),
+				RemoteIP: net.ParseIP("fd00::2"),
+				Port:     8472,
+			},
+		},
+		{
+			name: "Dummy",
+			link: &DummyLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_DUMMY, Name: dummyName},
+			},
+		},
+		{
+			name: "IFB",
+			link: &IFBLink{
+				LinkInfo: LinkInfo{Type: LINK_TYPE_IFB, Name: ifName, Flags: net.FlagUp},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nlMsgs, err := syscall.ParseNetlinkMessage(newAddLinkRequest(tt.link).serialize())
+			require.NoError(t, err)
+			require.Len(t, nlMsgs, 1)
+
+			link, err := deserializeLink(parseMessage(&nlMsgs[0]))
+			require.NoError(t, err)
+			require.Equal(t, tt.link, link)
+		})
+	}
+}
+
+// TestDeserializeLinkInvalid tests that malformed link messages are rejected.
+func TestDeserializeLinkInvalid(t *testing.T) {
+	_, err := deserializeLink(&message{data: make([]byte, 4)})
+	require.Error(t, err)
+
+	_, err = deserializeLinkInfo(LinkInfo{}, []byte{0xff, 0, 1, 0})
+	require.Error(t, err)
+}
+
+// TestGetLink tests reading back a dummy interface.
+func TestGetLink(t *testing.T) {
+	nl := NewNetlink()
+
+	_, err := addDummyInterface(dummyName)
+	require.NoError(t, err)
+	defer func() { _ = nl.DeleteLink(dummyName) }()
+
+	link, err := nl.GetLink(dummyName)
+	require.NoError(t, err)
+
+	dummy, ok := link.(*DummyLink)
+	require.True(t, ok, "unexpected link type %T", link)
+	require.Equal(t, dummyName, dummy.Name)
+	require.Equal(t, LINK_TYPE_DUMMY, dummy.Type)
+}
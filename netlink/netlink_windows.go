@@ -26,6 +26,10 @@ func (Netlink) AddLink(link Link) error {
 	return nil
 }
 
+func (Netlink) GetLink(name string) (Link, error) {
+	return nil, nil
+}
+
 func (Netlink) SetLinkMTU(name string, mtu int) error {
 	return nil
 }
@@ -66,6 +70,10 @@ func (Netlink) AddOrRemoveStaticArp(mode int, name string, ipaddr net.IP, mac ne
 	return nil
 }
 
+func (Netlink) AddOrRemoveFdbEntry(mode int, name string, mac net.HardwareAddr, dst net.IP) error {
+	return nil
+}
+
 func (Netlink) AddIPAddress(ifName string, ipAddress net.IP, ipNet *net.IPNet) error {
 	return nil
 }
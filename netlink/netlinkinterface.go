@@ -10,6 +10,7 @@ import (
 type NetlinkInterface interface {
 	AddLink(link Link) error
 	DeleteLink(name string) error
+	GetLink(name string) (Link, error)
 	SetLinkName(name string, newName string) error
 	SetLinkState(name string, up bool) error
 	SetLinkMTU(name string, mtu int) error
@@ -19,6 +20,7 @@ type NetlinkInterface interface {
 	SetLinkPromisc(ifName string, on bool) error
 	SetLinkHairpin(bridgeName string, on bool) error
 	AddOrRemoveStaticArp(mode int, name string, ipaddr net.IP, mac net.HardwareAddr, isProxy bool) error
+	AddOrRemoveFdbEntry(mode int, name string, mac net.HardwareAddr, dst net.IP) error
 	AddIPAddress(ifName string, ipAddress net.IP, ipNet *net.IPNet) error
 	DeleteIPAddress(ifName string, ipAddress net.IP, ipNet *net.IPNet) error
 	GetIPRoute(filter *Route) ([]*Route, error)
@@ -7,7 +7,10 @@ package netlink
 
 import (
 	"encoding/binary"
+	"fmt"
 	"net"
+	"strings"
+	"syscall"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
@@ -50,13 +53,15 @@ const (
 
 // Netlink protocol constants that are not already defined in unix package.
 const (
-	IFLA_INFO_KIND   = 1
-	IFLA_INFO_DATA   = 2
-	IFLA_NET_NS_FD   = 28
-	IFLA_IPVLAN_MODE = 1
-	IFLA_BRPORT_MODE = 4
-	VETH_INFO_PEER   = 1
-	DEFAULT_CHANGE   = 0xFFFFFFFF
+	IFLA_INFO_KIND    = 1
+	IFLA_INFO_DATA    = 2
+	IFLA_NET_NS_FD    = 28
+	IFLA_IPVLAN_MODE  = 1
+	IFLA_MACVLAN_MODE = 1
+	IFLA_BRPORT_MODE  = 4
+	VETH_INFO_PEER    = 1
+	DEFAULT_CHANGE    = 0xFFFFFFFF
+	NLA_TYPE_MASK     = ^uint16(unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER)
 )
 
 // Serializable types are used to construct netlink messages.
@@ -165,6 +170,41 @@ func (msg *message) serialize() []byte {
 	return b
 }
 
+// Converts a received netlink message to a message object, with its body as data and its attributes as payload.
+func parseMessage(nlMsg *syscall.NetlinkMessage) *message {
+	msg := message{
+		NlMsghdr: unix.NlMsghdr{
+			Len:   nlMsg.Header.Len,
+			Type:  nlMsg.Header.Type,
+			Flags: nlMsg.Header.Flags,
+			Seq:   nlMsg.Header.Seq,
+			Pid:   nlMsg.Header.Pid,
+		},
+		data: nlMsg.Data,
+	}
+
+	// Parse body.
+	msg.payload = append(msg.payload, nil)
+
+	// Parse attributes.
+	// Ignore failures as not all messages have attributes.
+	nlAttrs, _ := syscall.ParseNetlinkRouteAttr(nlMsg)
+
+	// Convert to attribute objects.
+	for _, nlAttr := range nlAttrs {
+		attr := attribute{
+			NlAttr: unix.NlAttr{
+				Len:  nlAttr.Attr.Len,
+				Type: nlAttr.Attr.Type,
+			},
+			value: nlAttr.Value,
+		}
+		msg.payload = append(msg.payload, &attr)
+	}
+
+	return &msg
+}
+
 // Get attributes.
 func (msg *message) getAttributes(body serializable) []*attribute {
 	var attrs []*attribute
@@ -220,6 +260,13 @@ func newAttributeUint16(attrType int, value uint16) *attribute {
 	return newAttribute(attrType, buf)
 }
 
+// Creates a new attribute with a uint16 value in network byte order.
+func newAttributeUint16BE(attrType int, value uint16) *attribute {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, value)
+	return newAttribute(attrType, buf)
+}
+
 // Creates a new attribute with a net.IP value.
 func newAttributeIpAddress(attrType int, value net.IP) *attribute {
 	addr := value.To4()
@@ -230,6 +277,55 @@ func newAttributeIpAddress(attrType int, value net.IP) *attribute {
 	}
 }
 
+// Parses the nested attributes in the value of an attribute.
+func parseAttributes(b []byte) ([]*attribute, error) {
+	var attrs []*attribute
+
+	for len(b) >= unix.SizeofNlAttr {
+		length := int(encoder.Uint16(b[0:2]))
+		if length < unix.SizeofNlAttr || length > len(b) {
+			return nil, fmt.Errorf("Invalid attribute length %d", length)
+		}
+
+		attrs = append(attrs, &attribute{
+			NlAttr: unix.NlAttr{
+				Len:  uint16(length),
+				Type: encoder.Uint16(b[2:4]) & NLA_TYPE_MASK,
+			},
+			value: b[unix.SizeofNlAttr:length],
+		})
+
+		aligned := (length + unix.NLA_ALIGNTO - 1) & ^(unix.NLA_ALIGNTO - 1)
+		if aligned > len(b) {
+			break
+		}
+		b = b[aligned:]
+	}
+
+	return attrs, nil
+}
+
+// Returns the value of an attribute as a string, without its null terminator.
+func (attr *attribute) stringValue() string {
+	return strings.TrimRight(string(attr.value), "\000")
+}
+
+// Returns the value of an attribute as a uint32, zero if the value is too short.
+func (attr *attribute) uint32Value() uint32 {
+	if len(attr.value) < 4 {
+		return 0
+	}
+	return encoder.Uint32(attr.value[0:4])
+}
+
+// Returns the value of an attribute as a uint16, zero if the value is too short.
+func (attr *attribute) uint16Value() uint16 {
+	if len(attr.value) < 2 {
+		return 0
+	}
+	return encoder.Uint16(attr.value[0:2])
+}
+
 // Adds a nested attribute to an attribute.
 func (attr *attribute) addNested(nested serializable) {
 	attr.children = append(attr.children, nested)
@@ -310,6 +406,217 @@ func (ifInfo *ifInfoMsg) length() int {
 	return unix.SizeofIfInfomsg
 }
 
+// Deserializes an interface info message.
+func deserializeIfInfoMsg(b []byte) (*ifInfoMsg, error) {
+	if len(b) < unix.SizeofIfInfomsg {
+		return nil, fmt.Errorf("Invalid interface info message length %d", len(b))
+	}
+
+	return &ifInfoMsg{
+		IfInfomsg: unix.IfInfomsg{
+			Family: b[0],
+			Type:   encoder.Uint16(b[2:4]),
+			Index:  int32(encoder.Uint32(b[4:8])),
+			Flags:  encoder.Uint32(b[8:12]),
+			Change: encoder.Uint32(b[12:16]),
+		},
+	}, nil
+}
+
+// Creates a new link info attribute, with the kind of the link and its type-specific attributes.
+func newAttributeLinkInfo(link Link) *attribute {
+	attrLinkInfo := newAttribute(unix.IFLA_LINKINFO, nil)
+	attrLinkInfo.addNested(newAttributeString(IFLA_INFO_KIND, link.Info().Type))
+
+	attrData := newAttribute(IFLA_INFO_DATA, nil)
+
+	switch l := link.(type) {
+	case *VEthLink:
+		attrPeer := newAttribute(VETH_INFO_PEER, nil)
+		attrPeer.addNested(newIfInfoMsg())
+		attrPeer.addNested(newAttributeStringZ(unix.IFLA_IFNAME, l.PeerName))
+		attrData.addNested(attrPeer)
+
+	case *IPVlanLink:
+		attrData.addNested(newAttributeUint16(IFLA_IPVLAN_MODE, uint16(l.Mode)))
+
+	case *MacvlanLink:
+		if l.Mode != 0 {
+			attrData.addNested(newAttributeUint32(IFLA_MACVLAN_MODE, uint32(l.Mode)))
+		}
+
+	case *VXLANLink:
+		attrData.addNested(newAttributeUint32(unix.IFLA_VXLAN_ID, l.VNI))
+
+		if l.UnderlayIndex != 0 {
+			attrData.addNested(newAttributeUint32(unix.IFLA_VXLAN_LINK, uint32(l.UnderlayIndex)))
+		}
+
+		if ip := l.LocalIP.To4(); ip != nil {
+			attrData.addNested(newAttribute(unix.IFLA_VXLAN_LOCAL, ip))
+		} else if ip := l.LocalIP.To16(); ip != nil {
+			attrData.addNested(newAttribute(unix.IFLA_VXLAN_LOCAL6, ip))
+		}
+
+		if ip := l.RemoteIP.To4(); ip != nil {
+			attrData.addNested(newAttribute(unix.IFLA_VXLAN_GROUP, ip))
+		} else if ip := l.RemoteIP.To16(); ip != nil {
+			attrData.addNested(newAttribute(unix.IFLA_VXLAN_GROUP6, ip))
+		}
+
+		learning := []byte{0}
+		if l.Learning {
+			learning[0] = 1
+		}
+		attrData.addNested(newAttribute(unix.IFLA_VXLAN_LEARNING, learning))
+
+		if l.Port != 0 {
+			attrData.addNested(newAttributeUint16BE(unix.IFLA_VXLAN_PORT, l.Port))
+		}
+	}
+
+	if len(attrData.children) > 0 {
+		attrLinkInfo.addNested(attrData)
+	}
+
+	return attrLinkInfo
+}
+
+// Deserializes a link message into a link typed after its kind.
+func deserializeLink(msg *message) (Link, error) {
+	ifInfo, err := deserializeIfInfoMsg(msg.data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info LinkInfo
+	if ifInfo.Flags&unix.IFF_UP != 0 {
+		info.Flags |= net.FlagUp
+	}
+
+	var attrLinkInfo *attribute
+	for _, attr := range msg.getAttributes(ifInfo) {
+		switch attr.Type {
+		case unix.IFLA_IFNAME:
+			info.Name = attr.stringValue()
+		case unix.IFLA_MTU:
+			info.MTU = uint(attr.uint32Value())
+		case unix.IFLA_TXQLEN:
+			info.TxQLen = uint(attr.uint32Value())
+		case unix.IFLA_LINK:
+			info.ParentIndex = int(attr.uint32Value())
+		case unix.IFLA_LINKINFO:
+			attrLinkInfo = attr
+		}
+	}
+
+	if attrLinkInfo == nil {
+		return &info, nil
+	}
+
+	return deserializeLinkInfo(info, attrLinkInfo.value)
+}
+
+// Deserializes the value of a link info attribute into a link of its kind.
+func deserializeLinkInfo(info LinkInfo, b []byte) (Link, error) {
+	attrs, err := parseAttributes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*attribute
+	for _, attr := range attrs {
+		switch attr.Type {
+		case IFLA_INFO_KIND:
+			info.Type = attr.stringValue()
+		case IFLA_INFO_DATA:
+			if data, err = parseAttributes(attr.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch info.Type {
+	case LINK_TYPE_BRIDGE:
+		return &BridgeLink{LinkInfo: info}, nil
+
+	case LINK_TYPE_VETH:
+		veth := &VEthLink{LinkInfo: info}
+		for _, attr := range data {
+			// The peer is only set in requests, as an interface info message followed by the peer attributes.
+			if attr.Type != VETH_INFO_PEER || len(attr.value) < unix.SizeofIfInfomsg {
+				continue
+			}
+			peerAttrs, err := parseAttributes(attr.value[unix.SizeofIfInfomsg:])
+			if err != nil {
+				return nil, err
+			}
+			for _, peerAttr := range peerAttrs {
+				if peerAttr.Type == unix.IFLA_IFNAME {
+					veth.PeerName = peerAttr.stringValue()
+				}
+			}
+		}
+		return veth, nil
+
+	case LINK_TYPE_IPVLAN:
+		ipvlan := &IPVlanLink{LinkInfo: info}
+		for _, attr := range data {
+			if attr.Type == IFLA_IPVLAN_MODE {
+				ipvlan.Mode = IPVlanMode(attr.uint16Value())
+			}
+		}
+		return ipvlan, nil
+
+	case LINK_TYPE_MACVLAN:
+		macvlan := &MacvlanLink{LinkInfo: info}
+		for _, attr := range data {
+			if attr.Type == IFLA_MACVLAN_MODE {
+				macvlan.Mode = MacvlanMode(attr.uint32Value())
+			}
+		}
+		return macvlan, nil
+
+	case LINK_TYPE_VXLAN:
+		return deserializeVXLANLink(info, data), nil
+
+	case LINK_TYPE_DUMMY:
+		return &DummyLink{LinkInfo: info}, nil
+
+	case LINK_TYPE_IFB:
+		return &IFBLink{LinkInfo: info}, nil
+
+	default:
+		return &info, nil
+	}
+}
+
+// Deserializes the VXLAN attributes of a link.
+func deserializeVXLANLink(info LinkInfo, data []*attribute) *VXLANLink {
+	vxlan := &VXLANLink{LinkInfo: info}
+
+	for _, attr := range data {
+		switch attr.Type {
+		case unix.IFLA_VXLAN_ID:
+			vxlan.VNI = attr.uint32Value()
+		case unix.IFLA_VXLAN_LINK:
+			vxlan.UnderlayIndex = int(attr.uint32Value())
+		case unix.IFLA_VXLAN_LOCAL, unix.IFLA_VXLAN_LOCAL6:
+			vxlan.LocalIP = net.IP(attr.value)
+		case unix.IFLA_VXLAN_GROUP, unix.IFLA_VXLAN_GROUP6:
+			vxlan.RemoteIP = net.IP(attr.value)
+		case unix.IFLA_VXLAN_LEARNING:
+			vxlan.Learning = len(attr.value) > 0 && attr.value[0] != 0
+		case unix.IFLA_VXLAN_PORT:
+			if len(attr.value) >= 2 {
+				vxlan.Port = binary.BigEndian.Uint16(attr.value[0:2])
+			}
+		}
+	}
+
+	return vxlan
+}
+
 //
 // IP address service module
 //
@@ -144,16 +144,7 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 		// Process received messages.
 		for _, nlMsg := range nlMsgs {
 			// Convert to message object.
-			msg := message{
-				NlMsghdr: unix.NlMsghdr{
-					Len:   nlMsg.Header.Len,
-					Type:  nlMsg.Header.Type,
-					Flags: nlMsg.Header.Flags,
-					Seq:   nlMsg.Header.Seq,
-					Pid:   nlMsg.Header.Pid,
-				},
-				data: nlMsg.Data,
-			}
+			msg := parseMessage(&nlMsg)
 
 			// Ignore if the message is not in response to the sent message.
 			if msg.Seq != sent.Seq || msg.Pid != sent.Pid {
@@ -178,25 +169,6 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 			// Log response message.
 			log.Debugf("[netlink] Received %+v\n", msg)
 
-			// Parse body.
-			msg.payload = append(msg.payload, nil)
-
-			// Parse attributes.
-			// Ignore failures as not all messages have attributes.
-			nlAttrs, _ := syscall.ParseNetlinkRouteAttr(&nlMsg)
-
-			// Convert to attribute objects.
-			for _, nlAttr := range nlAttrs {
-				attr := attribute{
-					NlAttr: unix.NlAttr{
-						Len:  nlAttr.Attr.Len,
-						Type: nlAttr.Attr.Type,
-					},
-					value: nlAttr.Value,
-				}
-				msg.payload = append(msg.payload, &attr)
-			}
-
 			multi = ((msg.Flags & unix.NLM_F_MULTI) != 0)
 			done = (msg.Type == unix.NLMSG_DONE)
 
@@ -205,7 +177,7 @@ func (s *socket) receiveResponse(sent *message) ([]*message, error) {
 				break
 			}
 
-			messages = append(messages, &msg)
+			messages = append(messages, msg)
 		}
 
 		// Exit if response is a single message,
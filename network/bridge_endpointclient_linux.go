@@ -27,6 +27,7 @@ type LinuxBridgeEndpointClient struct {
 	containerMac      net.HardwareAddr
 	hostIPAddresses   []*net.IPNet
 	mode              string
+	mtu               int
 	netlink           netlink.NetlinkInterface
 	plClient          platform.ExecClient
 	netioshim         netio.NetIOInterface
@@ -38,6 +39,7 @@ func NewLinuxBridgeEndpointClient(
 	hostVethName string,
 	containerVethName string,
 	mode string,
+	mtu int,
 	nl netlink.NetlinkInterface,
 	plc platform.ExecClient,
 ) *LinuxBridgeEndpointClient {
@@ -50,6 +52,7 @@ func NewLinuxBridgeEndpointClient(
 		hostPrimaryMac:    extIf.MacAddress,
 		hostIPAddresses:   []*net.IPNet{},
 		mode:              mode,
+		mtu:               mtu,
 		netlink:           nl,
 		plClient:          plc,
 		netioshim:         &netio.NetIO{},
@@ -65,6 +68,10 @@ func (client *LinuxBridgeEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 		return err
 	}
 
+	if err := client.nuc.SetEndpointMTU(client.hostVethName, client.containerVethName, client.mtu); err != nil {
+		return err
+	}
+
 	containerIf, err := net.InterfaceByName(client.containerVethName)
 	if err != nil {
 		return err
@@ -0,0 +1,147 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/log"
+)
+
+// The egress traffic of an endpoint with an EgressSNAT policy is marked in the mangle table from the IP of the pod,
+// then SNATed in the nat table from its mark. The endpoints SNATed to the same IP share its mark and SNAT rule. Marks
+// use the upper 16 bits of the fwmark, clear of the bits of kube-proxy.
+const (
+	egressSNATMarkMask  = 0xffff0000
+	egressSNATMarkShift = 16
+)
+
+var errorEgressSNAT = errors.New("EgressSNAT Error")
+
+func newErrorEgressSNAT(errStr string) error {
+	return fmt.Errorf("%w : %s", errorEgressSNAT, errStr)
+}
+
+// egressSNATMark returns the mark of the traffic SNATed to sourceIP. The mark of the endpoints of the node already
+// SNATed to sourceIP is reused, else the lowest mark unused on the node is allocated.
+func (nm *networkManager) egressSNATMark(sourceIP net.IP) (uint32, error) {
+	if sourceIP.To4() == nil {
+		return 0, newErrorEgressSNAT(fmt.Sprintf("source IP %v is not an IPv4 address", sourceIP))
+	}
+
+	usedMarks := make(map[uint32]struct{})
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				if ep.EgressSNATMark == 0 {
+					continue
+				}
+				if ep.EgressSNATIP.Equal(sourceIP) {
+					return ep.EgressSNATMark, nil
+				}
+				usedMarks[ep.EgressSNATMark] = struct{}{}
+			}
+		}
+	}
+
+	for i := uint32(1); i <= egressSNATMarkMask>>egressSNATMarkShift; i++ {
+		mark := i << egressSNATMarkShift
+		if _, ok := usedMarks[mark]; !ok {
+			return mark, nil
+		}
+	}
+
+	return 0, newErrorEgressSNAT(fmt.Sprintf("no mark left for source IP %v", sourceIP))
+}
+
+// egressSNATMarkRule returns the match and target of the rule marking the egress traffic of the endpoint. The
+// traffic within the subnet of the pod keeps its IP.
+func egressSNATMarkRule(ep *endpoint, mark uint32) (string, string, error) {
+	for _, ipAddr := range ep.IPAddresses {
+		if ipAddr.IP.To4() == nil {
+			continue
+		}
+
+		subnet := net.IPNet{IP: ipAddr.IP.Mask(ipAddr.Mask), Mask: ipAddr.Mask}
+		match := fmt.Sprintf("-s %s ! -d %s", ipAddr.IP, subnet.String())
+		target := fmt.Sprintf("MARK --set-xmark 0x%x/0x%x", mark, egressSNATMarkMask)
+		return match, target, nil
+	}
+
+	return "", "", newErrorEgressSNAT(fmt.Sprintf("endpoint %s has no IPv4 address", ep.Id))
+}
+
+// egressSNATRule returns the match and target of the rule SNATing the marked traffic to sourceIP.
+func egressSNATRule(sourceIP net.IP, mark uint32) (string, string) {
+	match := fmt.Sprintf("-m mark --mark 0x%x/0x%x", mark, egressSNATMarkMask)
+	target := fmt.Sprintf("%s --to-source %s", iptables.Snat, sourceIP)
+	return match, target
+}
+
+// addEgressSNATRules adds the rules SNATing the egress traffic of the endpoint to its egress SNAT IP. The SNAT rule
+// is inserted first, ahead of the masquerade rules, so that marked traffic is never left unSNATed.
+func addEgressSNATRules(ep *endpoint) error {
+	mark := ep.EgressSNATMark
+	markMatch, markTarget, err := egressSNATMarkRule(ep, mark)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[net] Adding egress SNAT rules of endpoint %s to %v with mark 0x%x.", ep.Id, ep.EgressSNATIP, mark)
+	snatMatch, snatTarget := egressSNATRule(ep.EgressSNATIP, mark)
+	if err = iptables.InsertIptableRule(iptables.V4, iptables.Nat, iptables.Postrouting, snatMatch, snatTarget); err != nil {
+		return newErrorEgressSNAT(err.Error())
+	}
+
+	if err = iptables.InsertIptableRule(iptables.V4, iptables.Mangle, iptables.Prerouting, markMatch, markTarget); err != nil {
+		return newErrorEgressSNAT(err.Error())
+	}
+
+	return nil
+}
+
+// deleteEgressSNATRules deletes the rules of the endpoint. The SNAT rule is kept while other endpoints of the
+// node are SNATed to the same IP. The rules are deleted best effort, the last failure is returned.
+func (nw *network) deleteEgressSNATRules(ep *endpoint) error {
+	mark := ep.EgressSNATMark
+	log.Printf("[net] Deleting egress SNAT rules of endpoint %s to %v.", ep.Id, ep.EgressSNATIP)
+	var lastErr error
+	if markMatch, markTarget, err := egressSNATMarkRule(ep, mark); err == nil {
+		if err = iptables.DeleteIptableRule(iptables.V4, iptables.Mangle, iptables.Prerouting, markMatch, markTarget); err != nil {
+			log.Printf("[net] Failed to delete egress SNAT mark rule of endpoint %s, err:%v.", ep.Id, err)
+			lastErr = newErrorEgressSNAT(err.Error())
+		}
+	}
+
+	if nw.nm.isEgressSNATIPInUse(ep) {
+		log.Printf("[net] Keeping egress SNAT rule to %v shared with other endpoints.", ep.EgressSNATIP)
+		return lastErr
+	}
+
+	snatMatch, snatTarget := egressSNATRule(ep.EgressSNATIP, mark)
+	if err := iptables.DeleteIptableRule(iptables.V4, iptables.Nat, iptables.Postrouting, snatMatch, snatTarget); err != nil {
+		log.Printf("[net] Failed to delete egress SNAT rule to %v, err:%v.", ep.EgressSNATIP, err)
+		lastErr = newErrorEgressSNAT(err.Error())
+	}
+
+	return lastErr
+}
+
+// isEgressSNATIPInUse returns whether other endpoints of the node are SNATed to the egress SNAT IP of ep.
+func (nm *networkManager) isEgressSNATIPInUse(ep *endpoint) bool {
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, other := range nw.Endpoints {
+				if other != ep && other.EgressSNATIP.Equal(ep.EgressSNATIP) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
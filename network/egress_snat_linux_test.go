@@ -0,0 +1,191 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/nftables"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressSNATMark(t *testing.T) {
+	newNetworkManager := func(sourceIPs ...string) *networkManager {
+		nw := &network{Endpoints: make(map[string]*endpoint)}
+		for i, sourceIP := range sourceIPs {
+			if sourceIP == "" {
+				continue
+			}
+			id := fmt.Sprintf("ep%d", i)
+			nw.Endpoints[id] = &endpoint{Id: id, EgressSNATIP: net.ParseIP(sourceIP), EgressSNATMark: uint32(i+1) << 16}
+		}
+		return &networkManager{
+			ExternalInterfaces: map[string]*externalInterface{"eth0": {Networks: map[string]*network{"nw": nw}}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		nm       *networkManager
+		sourceIP net.IP
+		wantMark uint32
+		wantErr  bool
+	}{
+		{
+			name:     "First mark",
+			nm:       newNetworkManager(),
+			sourceIP: net.ParseIP("10.240.5.7"),
+			wantMark: 0x10000,
+		},
+		{
+			name:     "Mark shared with the endpoints SNATed to the same IP",
+			nm:       newNetworkManager("10.240.0.7", "10.240.5.7"),
+			sourceIP: net.ParseIP("10.240.5.7"),
+			wantMark: 0x20000,
+		},
+		{
+			name:     "Lowest unused mark",
+			nm:       newNetworkManager("10.240.0.7", "", "10.240.1.7"),
+			sourceIP: net.ParseIP("10.240.5.7"),
+			wantMark: 0x20000,
+		},
+		{
+			name:     "IPs sharing their lower bits get different marks",
+			nm:       newNetworkManager("10.241.5.7"),
+			sourceIP: net.ParseIP("10.240.5.7"),
+			wantMark: 0x20000,
+		},
+		{
+			name:     "IPv6 source IP",
+			nm:       newNetworkManager(),
+			sourceIP: net.ParseIP("fd00::5"),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mark, err := tt.nm.egressSNATMark(tt.sourceIP)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorEgressSNAT)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMark, mark)
+		})
+	}
+}
+
+func TestEgressSNATRules(t *testing.T) {
+	iptables.SetBackend(iptables.NewNftablesBackend(nftables.NewMockConn(false)))
+	defer func() { _ = iptables.SelectBackend(iptables.BackendIptables) }()
+
+	sourceIP := net.ParseIP("10.240.5.7")
+	newEndpoint := func(id, ip string) *endpoint {
+		ipAddr, subnet, _ := net.ParseCIDR(ip)
+		return &endpoint{
+			Id:             id,
+			IPAddresses:    []net.IPNet{{IP: ipAddr, Mask: subnet.Mask}},
+			EgressSNATIP:   sourceIP,
+			EgressSNATMark: 0x10000,
+		}
+	}
+	// The endpoints SNATed to the same IP are in different networks of the node.
+	ep1 := newEndpoint("ep1", "10.241.0.4/16")
+	ep2 := newEndpoint("ep2", "10.241.0.5/16")
+	nm := &networkManager{ExternalInterfaces: map[string]*externalInterface{"eth0": {Networks: map[string]*network{}}}}
+	nw1 := &network{Id: "nw1", Endpoints: map[string]*endpoint{ep1.Id: ep1}, nm: nm}
+	nw2 := &network{Id: "nw2", Endpoints: map[string]*endpoint{ep2.Id: ep2}, nm: nm}
+	nm.ExternalInterfaces["eth0"].Networks[nw1.Id] = nw1
+	nm.ExternalInterfaces["eth0"].Networks[nw2.Id] = nw2
+
+	snatMatch, snatTarget := egressSNATRule(sourceIP, 0x10000)
+	require.Equal(t, "-m mark --mark 0x10000/0xffff0000", snatMatch)
+	require.Equal(t, "SNAT --to-source 10.240.5.7", snatTarget)
+	snatRuleExists := func() bool {
+		return iptables.RuleExists(iptables.V4, iptables.Nat, iptables.Postrouting, snatMatch, snatTarget)
+	}
+	markRuleExists := func(ep *endpoint) bool {
+		markMatch, markTarget, err := egressSNATMarkRule(ep, 0x10000)
+		require.NoError(t, err)
+		return iptables.RuleExists(iptables.V4, iptables.Mangle, iptables.Prerouting, markMatch, markTarget)
+	}
+
+	markMatch, markTarget, err := egressSNATMarkRule(ep1, 0x10000)
+	require.NoError(t, err)
+	require.Equal(t, "-s 10.241.0.4 ! -d 10.241.0.0/16", markMatch)
+	require.Equal(t, "MARK --set-xmark 0x10000/0xffff0000", markTarget)
+
+	require.NoError(t, addEgressSNATRules(ep1))
+	require.NoError(t, addEgressSNATRules(ep2))
+	require.True(t, snatRuleExists())
+	require.True(t, markRuleExists(ep1))
+	require.True(t, markRuleExists(ep2))
+
+	// The SNAT rule is shared until the last endpoint SNATed to the IP is deleted.
+	require.NoError(t, nw1.deleteEgressSNATRules(ep1))
+	delete(nw1.Endpoints, ep1.Id)
+	require.False(t, markRuleExists(ep1))
+	require.True(t, snatRuleExists())
+
+	require.NoError(t, nw2.deleteEgressSNATRules(ep2))
+	require.False(t, markRuleExists(ep2))
+	require.False(t, snatRuleExists())
+
+	// An endpoint without IPv4 address is not SNATed.
+	ep3 := newEndpoint("ep3", "fd00::4/64")
+	require.ErrorIs(t, addEgressSNATRules(ep3), errorEgressSNAT)
+}
+
+func TestSetupEndpointRollsBackEgressSNATRules(t *testing.T) {
+	iptables.SetBackend(iptables.NewNftablesBackend(nftables.NewMockConn(false)))
+	defer func() { _ = iptables.SelectBackend(iptables.BackendIptables) }()
+
+	nl := netlink.NewMockNetlink(false, "")
+	client := &recordingEndpointClient{
+		IPVlanEndpointClient: &IPVlanEndpointClient{
+			hostPrimaryIfName:   "eth0",
+			hostIPVlanIfName:    "azipvl2",
+			containerIPVlanName: "azvcontainer",
+			mode:                netlink.IPVLAN_MODE_L2,
+			netlink:             nl,
+			plClient:            platform.NewMockExecClient(false),
+			nuc:                 networkutils.NewNetworkUtils(nl, platform.NewMockExecClient(false)),
+			netioshim:           netio.NewMockNetIO(false, 0),
+		},
+		failStep: "ConfigureContainerInterfacesAndRoutes",
+	}
+	ipAddr, subnet, _ := net.ParseCIDR("10.241.0.4/16")
+	epInfo := &EndpointInfo{
+		Id:          "test-con-eth0",
+		IfName:      "eth0",
+		IPAddresses: []net.IPNet{{IP: ipAddr, Mask: subnet.Mask}},
+	}
+	ep := &endpoint{
+		Id:             epInfo.Id,
+		IfName:         "azvcontainer",
+		IPAddresses:    epInfo.IPAddresses,
+		EgressSNATIP:   net.ParseIP("10.240.5.7"),
+		EgressSNATMark: 0x10000,
+	}
+
+	err := (&network{nm: &networkManager{}}).setupEndpoint(context.Background(), client, epInfo, ep, netio.NewMockNetIO(false, 0),
+		networkutils.NewNetworkUtils(nl, platform.NewMockExecClient(false)))
+	require.Error(t, err)
+
+	snatMatch, snatTarget := egressSNATRule(ep.EgressSNATIP, ep.EgressSNATMark)
+	require.False(t, iptables.RuleExists(iptables.V4, iptables.Nat, iptables.Postrouting, snatMatch, snatTarget))
+	markMatch, markTarget, err := egressSNATMarkRule(ep, ep.EgressSNATMark)
+	require.NoError(t, err)
+	require.False(t, iptables.RuleExists(iptables.V4, iptables.Mangle, iptables.Prerouting, markMatch, markTarget))
+}
@@ -43,10 +43,15 @@ type endpoint struct {
 	NetworkContainerID       string
 	NetworkNameSpace         string `json:",omitempty"`
 	ContainerID              string
-	PODName                  string `json:",omitempty"`
-	PODNameSpace             string `json:",omitempty"`
-	InfraVnetAddressSpace    string `json:",omitempty"`
-	NetNs                    string `json:",omitempty"`
+	PODName                  string                   `json:",omitempty"`
+	PODNameSpace             string                   `json:",omitempty"`
+	InfraVnetAddressSpace    string                   `json:",omitempty"`
+	NetNs                    string                   `json:",omitempty"`
+	AdditionalEndpoints      []AdditionalEndpointInfo `json:",omitempty"`
+	Sysctls                  map[string]string        `json:",omitempty"`
+	EgressSNATIP             net.IP                   `json:",omitempty"`
+	EgressSNATMark           uint32                   `json:",omitempty"`
+	Mirror                   *EndpointMirror          `json:",omitempty"`
 }
 
 // EndpointInfo contains read-only information about an endpoint.
@@ -81,6 +86,19 @@ type EndpointInfo struct {
 	VnetCidrs                string
 	ServiceCidrs             string
 	NATInfo                  []policy.NATInfo
+	AdditionalEndpoints      []AdditionalEndpointInfo
+	Sysctls                  map[string]string
+	EgressSNATIP             net.IP
+	Mirror                   *EndpointMirror
+	VxlanPeers               []string
+}
+
+// AdditionalEndpointInfo identifies an endpoint of another interface of the same pod, which is created and
+// deleted along with the endpoint of its primary interface.
+type AdditionalEndpointInfo struct {
+	NetworkID  string
+	EndpointID string
+	IfName     string
 }
 
 // RouteInfo contains information about an IP route.
@@ -100,7 +118,7 @@ type apipaClient interface {
 }
 
 // NewEndpoint creates a new endpoint in the network.
-func (nw *network) newEndpoint(cli apipaClient, nl netlink.NetlinkInterface, plc platform.ExecClient, epInfo *EndpointInfo) (*endpoint, error) {
+func (nw *network) newEndpoint(ctx context.Context, cli apipaClient, nl netlink.NetlinkInterface, plc platform.ExecClient, epInfo *EndpointInfo) (*endpoint, error) {
 	var ep *endpoint
 	var err error
 
@@ -112,11 +130,12 @@ func (nw *network) newEndpoint(cli apipaClient, nl netlink.NetlinkInterface, plc
 	}()
 
 	// Call the platform implementation.
-	ep, err = nw.newEndpointImpl(cli, nl, plc, epInfo)
+	ep, err = nw.newEndpointImpl(ctx, cli, nl, plc, epInfo)
 	if err != nil {
 		return nil, err
 	}
 
+	ep.AdditionalEndpoints = epInfo.AdditionalEndpoints
 	nw.Endpoints[epInfo.Id] = ep
 	log.Printf("[net] Created endpoint %+v.", ep)
 
@@ -227,6 +246,10 @@ func (ep *endpoint) getInfo() *EndpointInfo {
 		PODName:                  ep.PODName,
 		PODNameSpace:             ep.PODNameSpace,
 		NetworkContainerID:       ep.NetworkContainerID,
+		AdditionalEndpoints:      ep.AdditionalEndpoints,
+		Sysctls:                  ep.Sysctls,
+		EgressSNATIP:             ep.EgressSNATIP,
+		Mirror:                   ep.Mirror,
 	}
 
 	info.Routes = append(info.Routes, ep.Routes...)
@@ -4,6 +4,7 @@
 package network
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/Azure/azure-container-networking/netio"
 	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/policy"
 	"github.com/Azure/azure-container-networking/ovsctl"
 	"github.com/Azure/azure-container-networking/platform"
 )
@@ -48,9 +50,7 @@ func ConstructEndpointID(containerID string, _ string, ifName string) (string, s
 }
 
 // newEndpointImpl creates a new endpoint in the network.
-func (nw *network) newEndpointImpl(_ apipaClient, nl netlink.NetlinkInterface, plc platform.ExecClient, epInfo *EndpointInfo) (*endpoint, error) {
-	var containerIf *net.Interface
-	var ns *Namespace
+func (nw *network) newEndpointImpl(ctx context.Context, _ apipaClient, nl netlink.NetlinkInterface, plc platform.ExecClient, epInfo *EndpointInfo) (*endpoint, error) {
 	var ep *endpoint
 	var err error
 	var hostIfName string
@@ -104,81 +104,150 @@ func (nw *network) newEndpointImpl(_ apipaClient, nl netlink.NetlinkInterface, p
 			nl,
 			ovsctl.NewOvsctl(),
 			plc)
+	} else if isIPVlanMode(nw.Mode) {
+		log.Printf("IPVlan client")
+		epClient = NewIPVlanEndpointClient(nw, contIfName, nl, plc)
+	} else if nw.Vxlan != nil {
+		log.Printf("Vxlan client")
+		nw.syncVxlanPeers(nl, plc, epInfo.VxlanPeers)
+		epClient = NewVxlanEndpointClient(nw, hostIfName, contIfName, nl, plc)
 	} else if nw.Mode != opModeTransparent {
 		log.Printf("Bridge client")
-		epClient = NewLinuxBridgeEndpointClient(nw.extIf, hostIfName, contIfName, nw.Mode, nl, plc)
+		epClient = NewLinuxBridgeEndpointClient(nw.extIf, hostIfName, contIfName, nw.Mode, nw.MTU, nl, plc)
 	} else {
 		log.Printf("Transparent client")
-		epClient = NewTransparentEndpointClient(nw.extIf, hostIfName, contIfName, nw.Mode, nl, plc)
+		epClient = NewTransparentEndpointClient(nw.extIf, hostIfName, contIfName, nw.Mode, nw.MTU, nl, plc)
 	}
 
-	// Cleanup on failure.
-	defer func() {
-		if err != nil {
-			log.Printf("CNI error. Delete Endpoint %v and rules that are created.", contIfName)
-			endpt := &endpoint{
-				Id:                       epInfo.Id,
-				IfName:                   contIfName,
-				HostIfName:               hostIfName,
-				LocalIP:                  localIP,
-				IPAddresses:              epInfo.IPAddresses,
-				Gateways:                 []net.IP{nw.extIf.IPv4Gateway},
-				DNS:                      epInfo.DNS,
-				VlanID:                   vlanid,
-				EnableSnatOnHost:         epInfo.EnableSnatOnHost,
-				EnableMultitenancy:       epInfo.EnableMultiTenancy,
-				AllowInboundFromHostToNC: epInfo.AllowInboundFromHostToNC,
-				AllowInboundFromNCToHost: epInfo.AllowInboundFromNCToHost,
-			}
+	// Create the endpoint object.
+	ep = &endpoint{
+		Id:                       epInfo.Id,
+		IfName:                   contIfName, // container veth pair name. In cnm, we won't rename this and docker expects veth name.
+		HostIfName:               hostIfName,
+		InfraVnetIP:              epInfo.InfraVnetIP,
+		LocalIP:                  localIP,
+		IPAddresses:              epInfo.IPAddresses,
+		Gateways:                 []net.IP{nw.extIf.IPv4Gateway},
+		DNS:                      epInfo.DNS,
+		VlanID:                   vlanid,
+		EnableSnatOnHost:         epInfo.EnableSnatOnHost,
+		EnableInfraVnet:          epInfo.EnableInfraVnet,
+		EnableMultitenancy:       epInfo.EnableMultiTenancy,
+		AllowInboundFromHostToNC: epInfo.AllowInboundFromHostToNC,
+		AllowInboundFromNCToHost: epInfo.AllowInboundFromNCToHost,
+		NetworkNameSpace:         epInfo.NetNsPath,
+		ContainerID:              epInfo.ContainerID,
+		PODName:                  epInfo.PODName,
+		PODNameSpace:             epInfo.PODNameSpace,
+	}
 
-			if containerIf != nil {
-				endpt.MacAddress = containerIf.HardwareAddr
-				epClient.DeleteEndpointRules(endpt)
-			}
+	if ep.EgressSNATIP, err = policy.GetEgressSNATSourceIP(epInfo.Policies); err != nil {
+		return nil, err
+	}
+	if ep.EgressSNATIP != nil {
+		if ep.EgressSNATMark, err = nw.nm.egressSNATMark(ep.EgressSNATIP); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = nw.setupEndpoint(ctx, epClient, epInfo, ep, &netio.NetIO{}, networkutils.NewNetworkUtils(nl, plc)); err != nil {
+		return nil, err
+	}
 
-			epClient.DeleteEndpoints(endpt)
+	ep.Routes = append(ep.Routes, epInfo.Routes...)
+	ep.Sysctls = epInfo.Sysctls
+	return ep, nil
+}
+
+// setupEndpoint creates the interfaces of the endpoint with their rules, and configures them in the container
+// namespace. Each step registers its inverse, the steps are rolled back in reverse order if one of them fails.
+func (nw *network) setupEndpoint(
+	ctx context.Context,
+	epClient EndpointClient,
+	epInfo *EndpointInfo,
+	ep *endpoint,
+	netioshim netio.NetIOInterface,
+	nuc networkutils.NetworkUtils,
+) (err error) {
+	tx := newTransaction(ctx, "Endpoint "+epInfo.Id+" setup")
+
+	// Rolled back once back in the host network namespace.
+	defer func() {
+		if err != nil {
+			log.Printf("CNI error. Delete Endpoint %v and rules that are created.", ep.IfName)
+			tx.rollback()
+			return
 		}
+
+		tx.commit()
 	}()
 
-	if err = epClient.AddEndpoints(epInfo); err != nil {
-		return nil, err
+	if err = tx.do("AddEndpoints",
+		func() error { return epClient.AddEndpoints(epInfo) },
+		func() error { return epClient.DeleteEndpoints(ep) }); err != nil {
+		return err
 	}
 
-	containerIf, err = net.InterfaceByName(contIfName)
-	if err != nil {
-		return nil, err
+	if err = tx.do("GetContainerInterface", func() error {
+		containerIf, er := netioshim.GetNetworkInterfaceByName(ep.IfName)
+		if er != nil {
+			return er
+		}
+
+		ep.MacAddress = containerIf.HardwareAddr
+		return nil
+	}, nil); err != nil {
+		return err
 	}
 
 	// Setup rules for IP addresses on the container interface.
-	if err = epClient.AddEndpointRules(epInfo); err != nil {
-		return nil, err
+	if err = tx.do("AddEndpointRules",
+		func() error { return epClient.AddEndpointRules(epInfo) },
+		func() error {
+			epClient.DeleteEndpointRules(ep)
+			return nil
+		}); err != nil {
+		return err
+	}
+
+	// The egress traffic of the pod is SNATed on the host.
+	if ep.EgressSNATIP != nil {
+		if err = tx.do("AddEgressSNATRules",
+			func() error { return addEgressSNATRules(ep) },
+			func() error { return nw.deleteEgressSNATRules(ep) }); err != nil {
+			return err
+		}
 	}
 
 	// If a network namespace for the container interface is specified...
 	if epInfo.NetNsPath != "" {
+		var ns *Namespace
+
 		// Open the network namespace.
 		log.Printf("[net] Opening netns %v.", epInfo.NetNsPath)
-		ns, err = OpenNamespace(epInfo.NetNsPath)
-		if err != nil {
-			return nil, err
+		if ns, err = OpenNamespace(epInfo.NetNsPath); err != nil {
+			return err
 		}
 		defer ns.Close()
 
-		if err := epClient.MoveEndpointsToContainerNS(epInfo, ns.GetFd()); err != nil {
-			return nil, err
+		// Once moved, the container interface goes away with its host peer or with the container namespace.
+		if err = tx.do("MoveEndpointsToContainerNS", func() error {
+			return epClient.MoveEndpointsToContainerNS(epInfo, ns.GetFd())
+		}, nil); err != nil {
+			return err
 		}
 
 		// Enter the container network namespace.
 		log.Printf("[net] Entering netns %v.", epInfo.NetNsPath)
 		if err = ns.Enter(); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Return to host network namespace.
 		defer func() {
 			log.Printf("[net] Exiting netns %v.", epInfo.NetNsPath)
-			if err := ns.Exit(); err != nil {
-				log.Printf("[net] Failed to exit netns, err:%v.", err)
+			if er := ns.Exit(); er != nil {
+				log.Printf("[net] Failed to exit netns, err:%v.", er)
 			}
 		}()
 	}
@@ -186,48 +255,48 @@ func (nw *network) newEndpointImpl(_ apipaClient, nl netlink.NetlinkInterface, p
 	if epInfo.IPV6Mode != "" {
 		// Enable ipv6 setting in container
 		log.Printf("Enable ipv6 setting in container.")
-		nuc := networkutils.NewNetworkUtils(nl, plc)
-		if err = nuc.UpdateIPV6Setting(0); err != nil {
-			return nil, fmt.Errorf("Enable ipv6 in container failed:%w", err)
+		if err = tx.do("EnableIPV6", func() error { return nuc.UpdateIPV6Setting(0) }, nil); err != nil {
+			return fmt.Errorf("Enable ipv6 in container failed:%w", err)
 		}
 	}
 
 	// If a name for the container interface is specified...
 	if epInfo.IfName != "" {
-		if err = epClient.SetupContainerInterfaces(epInfo); err != nil {
-			return nil, err
+		if err = tx.do("SetupContainerInterfaces", func() error { return epClient.SetupContainerInterfaces(epInfo) }, nil); err != nil {
+			return err
 		}
 	}
 
-	if err = epClient.ConfigureContainerInterfacesAndRoutes(epInfo); err != nil {
-		return nil, err
+	if err = tx.do("ConfigureContainerInterfacesAndRoutes", func() error {
+		return epClient.ConfigureContainerInterfacesAndRoutes(epInfo)
+	}, nil); err != nil {
+		return err
 	}
 
-	// Create the endpoint object.
-	ep = &endpoint{
-		Id:                       epInfo.Id,
-		IfName:                   contIfName, // container veth pair name. In cnm, we won't rename this and docker expects veth name.
-		HostIfName:               hostIfName,
-		MacAddress:               containerIf.HardwareAddr,
-		InfraVnetIP:              epInfo.InfraVnetIP,
-		LocalIP:                  localIP,
-		IPAddresses:              epInfo.IPAddresses,
-		Gateways:                 []net.IP{nw.extIf.IPv4Gateway},
-		DNS:                      epInfo.DNS,
-		VlanID:                   vlanid,
-		EnableSnatOnHost:         epInfo.EnableSnatOnHost,
-		EnableInfraVnet:          epInfo.EnableInfraVnet,
-		EnableMultitenancy:       epInfo.EnableMultiTenancy,
-		AllowInboundFromHostToNC: epInfo.AllowInboundFromHostToNC,
-		AllowInboundFromNCToHost: epInfo.AllowInboundFromNCToHost,
-		NetworkNameSpace:         epInfo.NetNsPath,
-		ContainerID:              epInfo.ContainerID,
-		PODName:                  epInfo.PODName,
-		PODNameSpace:             epInfo.PODNameSpace,
+	// The sysctls are namespaced, they go away with the container namespace.
+	if len(epInfo.Sysctls) > 0 {
+		if err = tx.do("SetSysctls", func() error { return setSysctls(nuc, epInfo.Sysctls) }, nil); err != nil {
+			return err
+		}
 	}
 
-	ep.Routes = append(ep.Routes, epInfo.Routes...)
-	return ep, nil
+	return nil
+}
+
+// setSysctls sets the sysctls of the pod in its network namespace, in the order of their keys.
+func setSysctls(nuc networkutils.NetworkUtils, sysctls map[string]string) error {
+	if err := ValidateSysctls(sysctls); err != nil {
+		return err
+	}
+
+	for _, key := range sortedSysctlKeys(sysctls) {
+		log.Printf("[net] Setting sysctl %s to %s.", key, sysctls[key])
+		if err := nuc.SetSysctl(key, sysctls[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // deleteEndpointImpl deletes an existing endpoint from the network.
@@ -240,15 +309,34 @@ func (nw *network) deleteEndpointImpl(_ apipaClient, nl netlink.NetlinkInterface
 	if ep.VlanID != 0 {
 		epInfo := ep.getInfo()
 		epClient = NewOVSEndpointClient(nw, epInfo, ep.HostIfName, "", ep.VlanID, ep.LocalIP, nl, ovsctl.NewOvsctl(), plc)
+	} else if isIPVlanMode(nw.Mode) {
+		ipvlanClient := NewIPVlanEndpointClient(nw, ep.IfName, nl, plc)
+		// The ipvlan interface of an endpoint with a network namespace was moved into it.
+		ipvlanClient.movedToContainerNS = ep.NetworkNameSpace != ""
+		epClient = ipvlanClient
+	} else if nw.Vxlan != nil {
+		epClient = NewVxlanEndpointClient(nw, ep.HostIfName, "", nl, plc)
 	} else if nw.Mode != opModeTransparent {
-		epClient = NewLinuxBridgeEndpointClient(nw.extIf, ep.HostIfName, "", nw.Mode, nl, plc)
+		epClient = NewLinuxBridgeEndpointClient(nw.extIf, ep.HostIfName, "", nw.Mode, nw.MTU, nl, plc)
 	} else {
-		epClient = NewTransparentEndpointClient(nw.extIf, ep.HostIfName, "", nw.Mode, nl, plc)
+		epClient = NewTransparentEndpointClient(nw.extIf, ep.HostIfName, "", nw.Mode, nw.MTU, nl, plc)
+	}
+
+	if ep.Mirror != nil {
+		if err := nw.deleteEndpointMirror(nl, plc, ep); err != nil {
+			log.Printf("[net] Failed to delete mirror of endpoint %s, err:%v.", ep.Id, err)
+		}
 	}
 
 	epClient.DeleteEndpointRules(ep)
 	epClient.DeleteEndpoints(ep)
 
+	if ep.EgressSNATIP != nil {
+		if err := nw.deleteEgressSNATRules(ep); err != nil {
+			log.Printf("[net] Failed to delete egress SNAT rules of endpoint %s, err:%v.", ep.Id, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,208 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEndpointClient records the calls to an ipvlan endpoint client, whose netlink calls fail from the
+// fail step on.
+type recordingEndpointClient struct {
+	*IPVlanEndpointClient
+	failStep string
+	calls    []string
+}
+
+func (client *recordingEndpointClient) record(call string) {
+	client.calls = append(client.calls, call)
+	if call == client.failStep {
+		client.netlink = netlink.NewMockNetlink(true, call)
+		client.nuc = networkutils.NewNetworkUtils(client.netlink, client.plClient)
+	}
+}
+
+func (client *recordingEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
+	client.record("AddEndpoints")
+	return client.IPVlanEndpointClient.AddEndpoints(epInfo)
+}
+
+func (client *recordingEndpointClient) AddEndpointRules(epInfo *EndpointInfo) error {
+	client.record("AddEndpointRules")
+	return client.IPVlanEndpointClient.AddEndpointRules(epInfo)
+}
+
+func (client *recordingEndpointClient) DeleteEndpointRules(ep *endpoint) {
+	client.record("DeleteEndpointRules")
+	client.IPVlanEndpointClient.DeleteEndpointRules(ep)
+}
+
+func (client *recordingEndpointClient) MoveEndpointsToContainerNS(epInfo *EndpointInfo, nsID uintptr) error {
+	client.record("MoveEndpointsToContainerNS")
+	return client.IPVlanEndpointClient.MoveEndpointsToContainerNS(epInfo, nsID)
+}
+
+func (client *recordingEndpointClient) SetupContainerInterfaces(epInfo *EndpointInfo) error {
+	client.record("SetupContainerInterfaces")
+	return client.IPVlanEndpointClient.SetupContainerInterfaces(epInfo)
+}
+
+func (client *recordingEndpointClient) ConfigureContainerInterfacesAndRoutes(epInfo *EndpointInfo) error {
+	client.record("ConfigureContainerInterfacesAndRoutes")
+	return client.IPVlanEndpointClient.ConfigureContainerInterfacesAndRoutes(epInfo)
+}
+
+func (client *recordingEndpointClient) DeleteEndpoints(ep *endpoint) error {
+	client.record("DeleteEndpoints")
+	return client.IPVlanEndpointClient.DeleteEndpoints(ep)
+}
+
+func TestSetupEndpointRollback(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.240.0.4/16")
+	ipNet.IP = net.ParseIP("10.240.0.4")
+
+	tests := []struct {
+		name      string
+		failStep  string
+		ipv6Mode  string
+		sysctls   map[string]string
+		netioshim netio.NetIOInterface
+		plc       platform.ExecClient
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			name:      "Setup endpoint",
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "ConfigureContainerInterfacesAndRoutes"},
+		},
+		{
+			name:      "Add endpoints fail",
+			failStep:  "AddEndpoints",
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{"AddEndpoints", "DeleteEndpoints"},
+			wantErr:   true,
+		},
+		{
+			name:      "Get container interface fail",
+			netioshim: netio.NewMockNetIO(true, 1),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{"AddEndpoints", "DeleteEndpoints"},
+			wantErr:   true,
+		},
+		{
+			name:      "Add endpoint rules fail",
+			failStep:  "AddEndpointRules",
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{"AddEndpoints", "AddEndpointRules", "DeleteEndpointRules", "DeleteEndpoints"},
+			wantErr:   true,
+		},
+		{
+			name:      "Enable ipv6 fail",
+			ipv6Mode:  IPV6Nat,
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(true),
+			wantCalls: []string{"AddEndpoints", "AddEndpointRules", "DeleteEndpointRules", "DeleteEndpoints"},
+			wantErr:   true,
+		},
+		{
+			name:      "Setup container interfaces fail",
+			failStep:  "SetupContainerInterfaces",
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{
+				"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "DeleteEndpointRules", "DeleteEndpoints",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "Configure container interfaces fail",
+			failStep:  "ConfigureContainerInterfacesAndRoutes",
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{
+				"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "ConfigureContainerInterfacesAndRoutes",
+				"DeleteEndpointRules", "DeleteEndpoints",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "Setup endpoint with sysctls",
+			sysctls:   map[string]string{"net.core.somaxconn": "4096"},
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "ConfigureContainerInterfacesAndRoutes"},
+		},
+		{
+			name:      "Set sysctls fail",
+			sysctls:   map[string]string{"net.core.somaxconn": "4096"},
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(true),
+			wantCalls: []string{
+				"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "ConfigureContainerInterfacesAndRoutes",
+				"DeleteEndpointRules", "DeleteEndpoints",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "Sysctl not allowed",
+			sysctls:   map[string]string{"net.ipv4.ip_forward": "1"},
+			netioshim: netio.NewMockNetIO(false, 0),
+			plc:       platform.NewMockExecClient(false),
+			wantCalls: []string{
+				"AddEndpoints", "AddEndpointRules", "SetupContainerInterfaces", "ConfigureContainerInterfacesAndRoutes",
+				"DeleteEndpointRules", "DeleteEndpoints",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nl := netlink.NewMockNetlink(false, "")
+			client := &recordingEndpointClient{
+				IPVlanEndpointClient: &IPVlanEndpointClient{
+					hostPrimaryIfName:   "eth0",
+					hostIPVlanIfName:    "azipvl2",
+					containerIPVlanName: "azvcontainer",
+					mode:                netlink.IPVLAN_MODE_L2,
+					netlink:             nl,
+					plClient:            platform.NewMockExecClient(false),
+					nuc:                 networkutils.NewNetworkUtils(nl, platform.NewMockExecClient(false)),
+					netioshim:           netio.NewMockNetIO(false, 0),
+				},
+				failStep: tt.failStep,
+			}
+			epInfo := &EndpointInfo{
+				Id:          "test-con-eth0",
+				IfName:      "eth0",
+				IPAddresses: []net.IPNet{*ipNet},
+				IPV6Mode:    tt.ipv6Mode,
+				Sysctls:     tt.sysctls,
+			}
+			ep := &endpoint{Id: epInfo.Id, IfName: "azvcontainer", IPAddresses: epInfo.IPAddresses}
+
+			err := (&network{}).setupEndpoint(context.Background(), client, epInfo, ep, tt.netioshim, networkutils.NewNetworkUtils(nl, tt.plc))
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, ep.MacAddress)
+			}
+			require.Equal(t, tt.wantCalls, client.calls)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+)
+
+var errorIPVlanEndpointClient = errors.New("IPVlanEndpointClient Error")
+
+func newErrorIPVlanEndpointClient(errStr string) error {
+	return fmt.Errorf("%w : %s", errorIPVlanEndpointClient, errStr)
+}
+
+// IPVlanEndpointClient gives the pods an ipvlan child of the master interface, in L2 or L3 mode, instead of a
+// veth pair. The host reaches the pods through the host ipvlan interface of the network.
+type IPVlanEndpointClient struct {
+	hostPrimaryIfName   string
+	hostIPVlanIfName    string
+	containerIPVlanName string
+	mode                netlink.IPVlanMode
+	mtu                 int
+	netlink             netlink.NetlinkInterface
+	plClient            platform.ExecClient
+	netioshim           netio.NetIOInterface
+	nuc                 networkutils.NetworkUtils
+	// Once moved, the ipvlan interface is no longer in the host namespace, where its name may be reused.
+	movedToContainerNS bool
+}
+
+func NewIPVlanEndpointClient(
+	nw *network,
+	containerIPVlanName string,
+	nl netlink.NetlinkInterface,
+	plc platform.ExecClient,
+) *IPVlanEndpointClient {
+	return &IPVlanEndpointClient{
+		hostPrimaryIfName:   nw.extIf.Name,
+		hostIPVlanIfName:    nw.IPVlanHostIfName,
+		containerIPVlanName: containerIPVlanName,
+		mode:                ipvlanMode(nw.Mode),
+		mtu:                 nw.MTU,
+		netlink:             nl,
+		plClient:            plc,
+		netioshim:           &netio.NetIO{},
+		nuc:                 networkutils.NewNetworkUtils(nl, plc),
+	}
+}
+
+// hostRoutes returns the routes of the host to the IPs of the endpoint.
+func hostRoutes(ipAddresses []net.IPNet) []RouteInfo {
+	routes := make([]RouteInfo, 0, len(ipAddresses))
+	for _, ipAddr := range ipAddresses {
+		ipNet := net.IPNet{IP: ipAddr.IP, Mask: net.CIDRMask(ipv4FullMask, ipv4Bits)}
+		if ipAddr.IP.To4() == nil {
+			ipNet.Mask = net.CIDRMask(ipv6FullMask, ipv6Bits)
+		}
+		routes = append(routes, RouteInfo{Dst: ipNet})
+	}
+	return routes
+}
+
+func (client *IPVlanEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
+	hostIf, err := client.netioshim.GetNetworkInterfaceByName(client.hostPrimaryIfName)
+	if err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	// The ipvlan interface gets the MTU of the master interface unless a lower one is set.
+	log.Printf("[net] Creating ipvlan %v on %v mtu %d.", client.containerIPVlanName, client.hostPrimaryIfName, client.mtu)
+	if err := client.netlink.AddLink(&netlink.IPVlanLink{
+		LinkInfo: netlink.LinkInfo{
+			Type:        netlink.LINK_TYPE_IPVLAN,
+			Name:        client.containerIPVlanName,
+			MTU:         uint(client.mtu),
+			ParentIndex: hostIf.Index,
+		},
+		Mode: client.mode,
+	}); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+func (client *IPVlanEndpointClient) AddEndpointRules(epInfo *EndpointInfo) error {
+	// ip route add <podip> dev <host ipvlan>
+	// The master interface cannot reach its ipvlan children, the host reaches the pod through its own child.
+	if err := addRoutes(client.netlink, client.netioshim, client.hostIPVlanIfName, hostRoutes(epInfo.IPAddresses)); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+func (client *IPVlanEndpointClient) DeleteEndpointRules(ep *endpoint) {
+	if err := deleteRoutes(client.netlink, client.netioshim, client.hostIPVlanIfName, hostRoutes(ep.IPAddresses)); err != nil {
+		log.Printf("[net] Failed to delete host routes to the ips %v: %v", ep.IPAddresses, err)
+	}
+}
+
+func (client *IPVlanEndpointClient) MoveEndpointsToContainerNS(epInfo *EndpointInfo, nsID uintptr) error {
+	log.Printf("[net] Setting link %v netns %v.", client.containerIPVlanName, epInfo.NetNsPath)
+	if err := client.netlink.SetLinkNetNs(client.containerIPVlanName, nsID); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	client.movedToContainerNS = true
+	return nil
+}
+
+func (client *IPVlanEndpointClient) SetupContainerInterfaces(epInfo *EndpointInfo) error {
+	if err := client.nuc.SetupContainerInterface(client.containerIPVlanName, epInfo.IfName); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	client.containerIPVlanName = epInfo.IfName
+
+	return nil
+}
+
+func (client *IPVlanEndpointClient) ConfigureContainerInterfacesAndRoutes(epInfo *EndpointInfo) error {
+	if err := client.nuc.AssignIPToInterface(client.containerIPVlanName, epInfo.IPAddresses); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	if err := addRoutes(client.netlink, client.netioshim, client.containerIPVlanName, epInfo.Routes); err != nil {
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+// DeleteEndpoints deletes the ipvlan interface if it is still in the host namespace, which is only the case
+// when the endpoint creation failed before moving it. Otherwise it is deleted with the container namespace.
+func (client *IPVlanEndpointClient) DeleteEndpoints(ep *endpoint) error {
+	if client.movedToContainerNS {
+		log.Printf("[net] Leaving ipvlan %v to be deleted with netns %v.", client.containerIPVlanName, ep.NetworkNameSpace)
+		return nil
+	}
+
+	log.Printf("[net] Deleting ipvlan %v.", client.containerIPVlanName)
+	if err := client.netlink.DeleteLink(client.containerIPVlanName); err != nil {
+		log.Printf("[net] Failed to delete ipvlan %v: %v.", client.containerIPVlanName, err)
+		return newErrorIPVlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPVlanMode(t *testing.T) {
+	require.Equal(t, netlink.IPVLAN_MODE_L2, ipvlanMode(opModeIPVlanL2))
+	require.Equal(t, netlink.IPVLAN_MODE_L3, ipvlanMode(opModeIPVlanL3))
+	require.True(t, isIPVlanMode(opModeIPVlanL2))
+	require.True(t, isIPVlanMode(opModeIPVlanL3))
+	require.False(t, isIPVlanMode(opModeBridge))
+}
+
+func TestIPVlanHostRoutes(t *testing.T) {
+	_, v4, _ := net.ParseCIDR("10.240.0.4/16")
+	v4.IP = net.ParseIP("10.240.0.4")
+	_, v6, _ := net.ParseCIDR("fd00::4/64")
+	v6.IP = net.ParseIP("fd00::4")
+
+	routes := hostRoutes([]net.IPNet{*v4, *v6})
+	require.Len(t, routes, 2)
+	require.Equal(t, "10.240.0.4/32", routes[0].Dst.String())
+	require.Equal(t, "fd00::4/128", routes[1].Dst.String())
+}
+
+func TestIPVlanCreateHostInterface(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *IPVlanNetworkClient
+		wantErr bool
+	}{
+		{
+			name: "Create host interface",
+			client: &IPVlanNetworkClient{
+				hostInterfaceName:   "eth0",
+				ipvlanInterfaceName: "azipvl2",
+				mode:                netlink.IPVLAN_MODE_L2,
+				netlink:             netlink.NewMockNetlink(false, ""),
+				// The host ipvlan interface lookup fails, the one of the master succeeds.
+				netioshim: netio.NewMockNetIO(true, 1),
+			},
+		},
+		{
+			name: "Create host interface netlink fail",
+			client: &IPVlanNetworkClient{
+				hostInterfaceName:   "eth0",
+				ipvlanInterfaceName: "azipvl2",
+				mode:                netlink.IPVLAN_MODE_L3,
+				netlink:             netlink.NewMockNetlink(true, "netlink fail"),
+				netioshim:           netio.NewMockNetIO(true, 1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Create host interface already exists",
+			client: &IPVlanNetworkClient{
+				hostInterfaceName:   "eth0",
+				ipvlanInterfaceName: "azipvl2",
+				mode:                netlink.IPVLAN_MODE_L2,
+				netlink:             netlink.NewMockNetlink(true, "netlink fail"),
+				netioshim:           netio.NewMockNetIO(false, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.CreateHostInterface()
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorIPVlanNetworkClient)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIPVlanAddEndpoints(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+
+	tests := []struct {
+		name    string
+		client  *IPVlanEndpointClient
+		wantErr bool
+	}{
+		{
+			name: "Add endpoints",
+			client: &IPVlanEndpointClient{
+				hostPrimaryIfName:   "eth0",
+				hostIPVlanIfName:    "azipvl2",
+				containerIPVlanName: "azvcontainer",
+				mode:                netlink.IPVLAN_MODE_L2,
+				netlink:             netlink.NewMockNetlink(false, ""),
+				plClient:            platform.NewMockExecClient(false),
+				nuc:                 networkutils.NewNetworkUtils(nl, plc),
+				netioshim:           netio.NewMockNetIO(false, 0),
+			},
+		},
+		{
+			name: "Add endpoints netlink fail",
+			client: &IPVlanEndpointClient{
+				hostPrimaryIfName:   "eth0",
+				hostIPVlanIfName:    "azipvl2",
+				containerIPVlanName: "azvcontainer",
+				mode:                netlink.IPVLAN_MODE_L3,
+				netlink:             netlink.NewMockNetlink(true, "netlink fail"),
+				plClient:            platform.NewMockExecClient(false),
+				nuc:                 networkutils.NewNetworkUtils(nl, plc),
+				netioshim:           netio.NewMockNetIO(false, 0),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Add endpoints get master interface fail",
+			client: &IPVlanEndpointClient{
+				hostPrimaryIfName:   "eth0",
+				hostIPVlanIfName:    "azipvl2",
+				containerIPVlanName: "azvcontainer",
+				mode:                netlink.IPVLAN_MODE_L2,
+				netlink:             netlink.NewMockNetlink(false, ""),
+				plClient:            platform.NewMockExecClient(false),
+				nuc:                 networkutils.NewNetworkUtils(nl, plc),
+				netioshim:           netio.NewMockNetIO(true, 1),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.AddEndpoints(&EndpointInfo{})
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorIPVlanEndpointClient)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIPVlanAddEndpointRules(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+
+	_, ipNet, _ := net.ParseCIDR("10.240.0.4/16")
+	ipNet.IP = net.ParseIP("10.240.0.4")
+	epInfo := &EndpointInfo{IPAddresses: []net.IPNet{*ipNet}}
+
+	client := &IPVlanEndpointClient{
+		hostPrimaryIfName:   "eth0",
+		hostIPVlanIfName:    "azipvl2",
+		containerIPVlanName: "azvcontainer",
+		mode:                netlink.IPVLAN_MODE_L2,
+		netlink:             netlink.NewMockNetlink(false, ""),
+		plClient:            plc,
+		nuc:                 networkutils.NewNetworkUtils(nl, plc),
+		netioshim:           netio.NewMockNetIO(false, 0),
+	}
+	require.NoError(t, client.AddEndpointRules(epInfo))
+
+	client.netlink = netlink.NewMockNetlink(true, "netlink fail")
+	require.ErrorIs(t, client.AddEndpointRules(epInfo), errorIPVlanEndpointClient)
+}
+
+func TestIPVlanDeleteEndpoints(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+	ep := &endpoint{Id: "test-con-eth0", IfName: "azvcontainer", NetworkNameSpace: "/var/run/netns/test"}
+
+	client := &IPVlanEndpointClient{
+		hostPrimaryIfName:   "eth0",
+		hostIPVlanIfName:    "azipvl2",
+		containerIPVlanName: "azvcontainer",
+		mode:                netlink.IPVLAN_MODE_L2,
+		netlink:             netlink.NewMockNetlink(true, "netlink fail"),
+		plClient:            plc,
+		nuc:                 networkutils.NewNetworkUtils(nl, plc),
+		netioshim:           netio.NewMockNetIO(false, 0),
+	}
+
+	// The ipvlan interface is deleted from the host namespace until it is moved.
+	require.ErrorIs(t, client.DeleteEndpoints(ep), errorIPVlanEndpointClient)
+
+	client.netlink = nl
+	require.NoError(t, client.MoveEndpointsToContainerNS(&EndpointInfo{NetNsPath: ep.NetworkNameSpace}, 0))
+
+	// Once moved, it is left to the container namespace.
+	client.netlink = netlink.NewMockNetlink(true, "netlink fail")
+	require.NoError(t, client.DeleteEndpoints(ep))
+}
@@ -0,0 +1,119 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+)
+
+// Prefix for the host ipvlan interface names.
+const ipvlanHostPrefix = commonInterfacePrefix + "ipvl"
+
+var errorIPVlanNetworkClient = errors.New("IPVlanNetworkClient Error")
+
+func newErrorIPVlanNetworkClient(errStr string) error {
+	return fmt.Errorf("%w : %s", errorIPVlanNetworkClient, errStr)
+}
+
+// ipvlanMode returns the ipvlan mode of the network mode.
+func ipvlanMode(mode string) netlink.IPVlanMode {
+	if mode == opModeIPVlanL3 {
+		return netlink.IPVLAN_MODE_L3
+	}
+	return netlink.IPVLAN_MODE_L2
+}
+
+func isIPVlanMode(mode string) bool {
+	return mode == opModeIPVlanL2 || mode == opModeIPVlanL3
+}
+
+// IPVlanNetworkClient sets up the host side of an ipvlan network. The ipvlan children of the master interface
+// cannot reach the master itself, so the host reaches the pods through an ipvlan child of its own, which has
+// the host IPs of the master and the routes to the pod IPs.
+type IPVlanNetworkClient struct {
+	hostInterfaceName   string
+	ipvlanInterfaceName string
+	mode                netlink.IPVlanMode
+	netlink             netlink.NetlinkInterface
+	netioshim           netio.NetIOInterface
+}
+
+func NewIPVlanNetworkClient(hostInterfaceName string, ipvlanInterfaceName string, mode string, nl netlink.NetlinkInterface) *IPVlanNetworkClient {
+	return &IPVlanNetworkClient{
+		hostInterfaceName:   hostInterfaceName,
+		ipvlanInterfaceName: ipvlanInterfaceName,
+		mode:                ipvlanMode(mode),
+		netlink:             nl,
+		netioshim:           &netio.NetIO{},
+	}
+}
+
+func ipvlanHostInterfaceName(hostIf *net.Interface) string {
+	return fmt.Sprintf("%s%d", ipvlanHostPrefix, hostIf.Index)
+}
+
+// CreateHostInterface creates the host ipvlan interface on the master interface, with its host IPs.
+func (client *IPVlanNetworkClient) CreateHostInterface() error {
+	if _, err := client.netioshim.GetNetworkInterfaceByName(client.ipvlanInterfaceName); err == nil {
+		log.Printf("[net] Found existing host ipvlan interface %v.", client.ipvlanInterfaceName)
+		return nil
+	}
+
+	hostIf, err := client.netioshim.GetNetworkInterfaceByName(client.hostInterfaceName)
+	if err != nil {
+		return newErrorIPVlanNetworkClient(err.Error())
+	}
+
+	log.Printf("[net] Creating host ipvlan interface %v on %v.", client.ipvlanInterfaceName, client.hostInterfaceName)
+	if err = client.netlink.AddLink(&netlink.IPVlanLink{
+		LinkInfo: netlink.LinkInfo{
+			Type:        netlink.LINK_TYPE_IPVLAN,
+			Name:        client.ipvlanInterfaceName,
+			ParentIndex: hostIf.Index,
+		},
+		Mode: client.mode,
+	}); err != nil {
+		return newErrorIPVlanNetworkClient(err.Error())
+	}
+
+	addrs, err := client.netioshim.GetNetworkInterfaceAddrs(hostIf)
+	if err != nil {
+		return newErrorIPVlanNetworkClient(err.Error())
+	}
+
+	// The host IPs are added as host routes only, the subnet routes stay on the master interface.
+	for _, addr := range addrs {
+		ipAddr, _, err := net.ParseCIDR(addr.String())
+		if err != nil || !ipAddr.IsGlobalUnicast() {
+			continue
+		}
+
+		ipNet := &net.IPNet{IP: ipAddr, Mask: net.CIDRMask(ipv4FullMask, ipv4Bits)}
+		if ipAddr.To4() == nil {
+			ipNet.Mask = net.CIDRMask(ipv6FullMask, ipv6Bits)
+		}
+
+		log.Printf("[net] Adding IP address %v to %v.", ipNet, client.ipvlanInterfaceName)
+		if err = client.netlink.AddIPAddress(client.ipvlanInterfaceName, ipAddr, ipNet); err != nil {
+			return newErrorIPVlanNetworkClient(err.Error())
+		}
+	}
+
+	log.Printf("[net] Setting link %v state up.", client.ipvlanInterfaceName)
+	if err = client.netlink.SetLinkState(client.ipvlanInterfaceName, true); err != nil {
+		return newErrorIPVlanNetworkClient(err.Error())
+	}
+
+	return nil
+}
+
+// DeleteHostInterface deletes the host ipvlan interface.
+func (client *IPVlanNetworkClient) DeleteHostInterface() {
+	if err := client.netlink.DeleteLink(client.ipvlanInterfaceName); err != nil {
+		log.Printf("[net] Failed to delete host ipvlan interface %v, err:%v.", client.ipvlanInterfaceName, err)
+	}
+}
@@ -4,6 +4,7 @@
 package network
 
 import (
+	"context"
 	"net"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/Azure/azure-container-networking/netlink"
 	"github.com/Azure/azure-container-networking/platform"
 	"github.com/Azure/azure-container-networking/store"
+	"github.com/Azure/azure-container-networking/tracing"
 )
 
 const (
@@ -75,7 +77,7 @@ type NetworkManager interface {
 	DeleteNetwork(networkID string) error
 	GetNetworkInfo(networkID string) (NetworkInfo, error)
 
-	CreateEndpoint(client apipaClient, networkID string, epInfo *EndpointInfo) error
+	CreateEndpoint(ctx context.Context, client apipaClient, networkID string, epInfo *EndpointInfo) error
 	DeleteEndpoint(cli apipaClient, networkID string, endpointID string) error
 	GetEndpointInfo(networkID string, endpointID string) (*EndpointInfo, error)
 	GetAllEndpoints(networkID string) (map[string]*EndpointInfo, error)
@@ -84,7 +86,13 @@ type NetworkManager interface {
 	DetachEndpoint(networkID string, endpointID string) error
 	UpdateEndpoint(networkID string, existingEpInfo *EndpointInfo, targetEpInfo *EndpointInfo) error
 	GetNumberOfEndpoints(ifName string, networkID string) int
+	IsEgressSNATIPInUse(sourceIP net.IP) bool
 	SetupNetworkUsingState(networkMonitor *cnms.NetworkMonitor) error
+
+	AddEndpointMirror(networkID string, endpointID string, mirrorInfo *MirrorInfo) error
+	DeleteEndpointMirror(networkID string, endpointID string) error
+	HasExpiredEndpointMirrors() bool
+	DeleteExpiredEndpointMirrors() error
 }
 
 // Creates a new network manager.
@@ -178,6 +186,7 @@ func (nm *networkManager) restore(isRehydrationRequired bool) error {
 	for _, extIf := range nm.ExternalInterfaces {
 		for _, nw := range extIf.Networks {
 			nw.extIf = extIf
+			nw.nm = nm
 		}
 	}
 
@@ -309,6 +318,7 @@ func (nm *networkManager) GetNetworkInfo(networkId string) (NetworkInfo, error)
 		Mode:             nw.Mode,
 		EnableSnatOnHost: nw.EnableSnatOnHost,
 		DNS:              nw.DNS,
+		MTU:              nw.MTU,
 		Options:          make(map[string]interface{}),
 	}
 
@@ -322,7 +332,10 @@ func (nm *networkManager) GetNetworkInfo(networkId string) (NetworkInfo, error)
 }
 
 // CreateEndpoint creates a new container endpoint.
-func (nm *networkManager) CreateEndpoint(cli apipaClient, networkID string, epInfo *EndpointInfo) error {
+func (nm *networkManager) CreateEndpoint(ctx context.Context, cli apipaClient, networkID string, epInfo *EndpointInfo) (err error) {
+	ctx, span := tracing.Start(ctx, "network.CreateEndpoint")
+	defer func() { tracing.End(span, err) }()
+
 	nm.Lock()
 	defer nm.Unlock()
 
@@ -338,13 +351,17 @@ func (nm *networkManager) CreateEndpoint(cli apipaClient, networkID string, epIn
 		}
 	}
 
-	_, err = nw.newEndpoint(cli, nm.netlink, nm.plClient, epInfo)
+	_, err = nw.newEndpoint(ctx, cli, nm.netlink, nm.plClient, epInfo)
 	if err != nil {
 		return err
 	}
 
 	err = nm.save()
 	if err != nil {
+		// The endpoint is not in the saved state, delete it so that the next attempt starts from scratch.
+		if er := nw.deleteEndpoint(cli, nm.netlink, nm.plClient, epInfo.Id); er != nil {
+			log.Printf("[net] Failed to delete endpoint %v after failing to save the state, err:%v.", epInfo.Id, er)
+		}
 		return err
 	}
 
@@ -537,6 +554,24 @@ func (nm *networkManager) GetNumberOfEndpoints(ifName string, networkId string)
 	return 0
 }
 
+// IsEgressSNATIPInUse returns whether endpoints of the node are SNATed to sourceIP.
+func (nm *networkManager) IsEgressSNATIPInUse(sourceIP net.IP) bool {
+	nm.Lock()
+	defer nm.Unlock()
+
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				if ep.EgressSNATIP != nil && ep.EgressSNATIP.Equal(sourceIP) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func (nm *networkManager) SetupNetworkUsingState(networkMonitor *cnms.NetworkMonitor) error {
 	return nm.monitorNetworkState(networkMonitor)
 }
@@ -1,6 +1,9 @@
 package network
 
 import (
+	"context"
+	"net"
+
 	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
 	"github.com/Azure/azure-container-networking/common"
 )
@@ -52,7 +55,7 @@ func (nm *MockNetworkManager) GetNetworkInfo(networkID string) (NetworkInfo, err
 }
 
 // CreateEndpoint mock
-func (nm *MockNetworkManager) CreateEndpoint(_ apipaClient, networkID string, epInfo *EndpointInfo) error {
+func (nm *MockNetworkManager) CreateEndpoint(_ context.Context, _ apipaClient, networkID string, epInfo *EndpointInfo) error {
 	nm.TestEndpointInfoMap[epInfo.Id] = epInfo
 	return nil
 }
@@ -63,6 +66,16 @@ func (nm *MockNetworkManager) DeleteEndpoint(_ apipaClient, networkID string, en
 	return nil
 }
 
+// IsEgressSNATIPInUse mock
+func (nm *MockNetworkManager) IsEgressSNATIPInUse(sourceIP net.IP) bool {
+	for _, epInfo := range nm.TestEndpointInfoMap {
+		if epInfo.EgressSNATIP != nil && epInfo.EgressSNATIP.Equal(sourceIP) {
+			return true
+		}
+	}
+	return false
+}
+
 func (nm *MockNetworkManager) GetAllEndpoints(networkID string) (map[string]*EndpointInfo, error) {
 	return nm.TestEndpointInfoMap, nil
 }
@@ -104,3 +117,19 @@ func (nm *MockNetworkManager) GetNumberOfEndpoints(ifName string, networkID stri
 func (nm *MockNetworkManager) SetupNetworkUsingState(networkMonitor *cnms.NetworkMonitor) error {
 	return nil
 }
+
+func (nm *MockNetworkManager) AddEndpointMirror(networkID string, endpointID string, mirrorInfo *MirrorInfo) error {
+	return nil
+}
+
+func (nm *MockNetworkManager) DeleteEndpointMirror(networkID string, endpointID string) error {
+	return nil
+}
+
+func (nm *MockNetworkManager) HasExpiredEndpointMirrors() bool {
+	return false
+}
+
+func (nm *MockNetworkManager) DeleteExpiredEndpointMirrors() error {
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+)
+
+var (
+	errMirrorNotSupported = errors.New("endpoint mirrors are not supported")
+	errInvalidMirrorInfo  = errors.New("invalid endpoint mirror")
+	errMirrorNotFound     = errors.New("endpoint mirror not found")
+)
+
+// MirrorInfo contains the options of a mirror of the traffic of an endpoint, for troubleshooting. The copies are
+// sent to a local interface, created as an IFB interface if it does not exist, or to a GRE remote.
+type MirrorInfo struct {
+	TargetIfName string
+	RemoteIP     net.IP
+	Duration     time.Duration
+}
+
+// EndpointMirror represents an active mirror of the traffic of the host interface of an endpoint.
+type EndpointMirror struct {
+	TargetIfName    string
+	RemoteIP        net.IP `json:",omitempty"`
+	CreatedTargetIf bool
+	ExpiresAt       time.Time
+}
+
+// isExpired returns whether the mirror has expired at the given time.
+func (mirror *EndpointMirror) isExpired(now time.Time) bool {
+	return !now.Before(mirror.ExpiresAt)
+}
+
+// validate checks that the mirror has a single target and a duration.
+func (mirrorInfo *MirrorInfo) validate() error {
+	if (mirrorInfo.TargetIfName == "") == (mirrorInfo.RemoteIP == nil) {
+		return fmt.Errorf("%w: exactly one of target interface and remote IP is required", errInvalidMirrorInfo)
+	}
+
+	if mirrorInfo.Duration <= 0 {
+		return fmt.Errorf("%w: duration %v is not positive", errInvalidMirrorInfo, mirrorInfo.Duration)
+	}
+
+	return nil
+}
+
+// AddEndpointMirror mirrors the traffic of the endpoint until the mirror expires. An existing mirror of the
+// endpoint is replaced.
+func (nm *networkManager) AddEndpointMirror(networkID, endpointID string, mirrorInfo *MirrorInfo) error {
+	nm.Lock()
+	defer nm.Unlock()
+
+	if err := mirrorInfo.validate(); err != nil {
+		return err
+	}
+
+	nw, err := nm.getNetwork(networkID)
+	if err != nil {
+		return err
+	}
+
+	ep, err := nw.getEndpoint(endpointID)
+	if err != nil {
+		return err
+	}
+
+	if ep.Mirror != nil {
+		if err = nw.deleteEndpointMirror(nm.netlink, nm.plClient, ep); err != nil {
+			return err
+		}
+	}
+
+	if err = nw.addEndpointMirror(nm.netlink, nm.plClient, ep, mirrorInfo); err != nil {
+		return err
+	}
+
+	return nm.save()
+}
+
+// DeleteEndpointMirror deletes the mirror of the endpoint.
+func (nm *networkManager) DeleteEndpointMirror(networkID, endpointID string) error {
+	nm.Lock()
+	defer nm.Unlock()
+
+	nw, err := nm.getNetwork(networkID)
+	if err != nil {
+		return err
+	}
+
+	ep, err := nw.getEndpoint(endpointID)
+	if err != nil {
+		return err
+	}
+
+	if ep.Mirror == nil {
+		return fmt.Errorf("%w for endpoint %s", errMirrorNotFound, endpointID)
+	}
+
+	if err = nw.deleteEndpointMirror(nm.netlink, nm.plClient, ep); err != nil {
+		return err
+	}
+
+	return nm.save()
+}
+
+// HasExpiredEndpointMirrors returns whether any network has a mirror past its expiry.
+func (nm *networkManager) HasExpiredEndpointMirrors() bool {
+	nm.Lock()
+	defer nm.Unlock()
+
+	now := time.Now()
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				if ep.Mirror != nil && ep.Mirror.isExpired(now) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// DeleteExpiredEndpointMirrors deletes the mirrors past their expiry in all networks. The mirrors are deleted best
+// effort, the last failure is returned.
+func (nm *networkManager) DeleteExpiredEndpointMirrors() error {
+	nm.Lock()
+	defer nm.Unlock()
+
+	var lastErr error
+	deleted := false
+	now := time.Now()
+
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				if ep.Mirror == nil || !ep.Mirror.isExpired(now) {
+					continue
+				}
+
+				log.Printf("[net] Mirror of endpoint %s expired at %v.", ep.Id, ep.Mirror.ExpiresAt)
+				if err := nw.deleteEndpointMirror(nm.netlink, nm.plClient, ep); err != nil {
+					log.Printf("[net] Failed to delete expired mirror of endpoint %s, err:%v.", ep.Id, err)
+					lastErr = err
+					continue
+				}
+				deleted = true
+			}
+		}
+	}
+
+	if deleted {
+		if err := nm.save(); err != nil {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+// isMirrorTargetInUse returns whether other endpoints of the network are mirrored to the target interface.
+func (nw *network) isMirrorTargetInUse(ep *endpoint, targetIfName string) bool {
+	for id, other := range nw.Endpoints {
+		if id != ep.Id && other.Mirror != nil && other.Mirror.TargetIfName == targetIfName {
+			return true
+		}
+	}
+
+	return false
+}
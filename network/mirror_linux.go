@@ -0,0 +1,244 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/ovsctl"
+	"github.com/Azure/azure-container-networking/platform"
+)
+
+// Prefix for the GRE interfaces of the mirrors to remote targets.
+const mirrorGREInterfacePrefix = commonInterfacePrefix + "m"
+
+var errorEndpointMirror = errors.New("EndpointMirror Error")
+
+func newErrorEndpointMirror(errStr string) error {
+	return fmt.Errorf("%w : %s", errorEndpointMirror, errStr)
+}
+
+// addEndpointMirror mirrors the traffic of the host interface of the endpoint to the target of the mirror, with
+// an OVS mirror for the endpoints of the OVS client and a tc mirred action otherwise.
+func (nw *network) addEndpointMirror(nl netlink.NetlinkInterface, plc platform.ExecClient, ep *endpoint, mirrorInfo *MirrorInfo) error {
+	return nw.addEndpointMirrorWithOvsctl(nl, plc, ovsctl.NewOvsctl(), ep, mirrorInfo)
+}
+
+func (nw *network) addEndpointMirrorWithOvsctl(
+	nl netlink.NetlinkInterface,
+	plc platform.ExecClient,
+	ovs ovsctl.OvsInterface,
+	ep *endpoint,
+	mirrorInfo *MirrorInfo,
+) (err error) {
+	if ep.HostIfName == "" || isIPVlanMode(nw.Mode) {
+		return fmt.Errorf("%w: endpoint %s has no host interface", errMirrorNotSupported, ep.Id)
+	}
+
+	mirror := &EndpointMirror{
+		TargetIfName: mirrorInfo.TargetIfName,
+		RemoteIP:     mirrorInfo.RemoteIP,
+		ExpiresAt:    time.Now().Add(mirrorInfo.Duration),
+	}
+	if mirror.RemoteIP != nil {
+		mirror.TargetIfName = mirrorGREInterfacePrefix + generateVethName(ep.Id)
+	}
+
+	log.Printf("[net] Mirroring endpoint %s host interface %s to %s until %v.",
+		ep.Id, ep.HostIfName, mirror.TargetIfName, mirror.ExpiresAt)
+
+	tx := newTransaction(context.Background(), "Endpoint "+ep.Id+" mirror")
+	defer func() {
+		if err != nil {
+			tx.rollback()
+			return
+		}
+
+		tx.commit()
+	}()
+
+	if err = tx.do("AddMirrorTarget",
+		func() error { return nw.addMirrorTarget(nl, plc, ep, mirror) },
+		func() error { return nw.deleteMirrorTarget(nl, ep, mirror) }); err != nil {
+		return err
+	}
+
+	if ep.VlanID != 0 {
+		bridgeName := nw.extIf.BridgeName
+		if err = tx.do("AddOVSMirror", func() error {
+			if err := ovs.AddPortOnOVSBridge(mirror.TargetIfName, bridgeName, 0); err != nil {
+				return err
+			}
+			return ovs.AddMirror(bridgeName, ovsMirrorName(ep), ep.HostIfName, mirror.TargetIfName)
+		}, func() error {
+			return nw.deleteOVSMirror(ovs, ep, mirror)
+		}); err != nil {
+			return err
+		}
+	} else {
+		if err = tx.do("AddTCMirror",
+			func() error { return addTCMirror(plc, ep.HostIfName, mirror.TargetIfName) },
+			func() error { return deleteTCMirror(plc, ep.HostIfName) }); err != nil {
+			return err
+		}
+	}
+
+	ep.Mirror = mirror
+
+	return nil
+}
+
+// deleteEndpointMirror deletes the mirror of the endpoint, along with its target interface once no other mirror
+// uses it. The mirror is kept in the endpoint state if it cannot be deleted, to be retried.
+func (nw *network) deleteEndpointMirror(nl netlink.NetlinkInterface, plc platform.ExecClient, ep *endpoint) error {
+	return nw.deleteEndpointMirrorWithOvsctl(nl, plc, ovsctl.NewOvsctl(), ep)
+}
+
+func (nw *network) deleteEndpointMirrorWithOvsctl(
+	nl netlink.NetlinkInterface,
+	plc platform.ExecClient,
+	ovs ovsctl.OvsInterface,
+	ep *endpoint,
+) error {
+	mirror := ep.Mirror
+
+	log.Printf("[net] Deleting mirror of endpoint %s to %s.", ep.Id, mirror.TargetIfName)
+
+	if ep.VlanID != 0 {
+		if err := nw.deleteOVSMirror(ovs, ep, mirror); err != nil {
+			return err
+		}
+	} else if _, err := net.InterfaceByName(ep.HostIfName); err == nil {
+		// The tc mirror goes away with the host interface.
+		if err = deleteTCMirror(plc, ep.HostIfName); err != nil {
+			return err
+		}
+	}
+
+	if err := nw.deleteMirrorTarget(nl, ep, mirror); err != nil {
+		return err
+	}
+
+	ep.Mirror = nil
+
+	return nil
+}
+
+// addMirrorTarget creates the target interface of the mirror if it does not exist: a GRE interface to the remote
+// IP, or an IFB interface for a local target.
+func (nw *network) addMirrorTarget(nl netlink.NetlinkInterface, plc platform.ExecClient, ep *endpoint, mirror *EndpointMirror) error {
+	if _, err := net.InterfaceByName(mirror.TargetIfName); err == nil {
+		// The target is deleted with the last mirror to it if another mirror created it.
+		mirror.CreatedTargetIf = nw.isMirrorTargetCreated(ep, mirror.TargetIfName)
+		return nil
+	}
+
+	if mirror.RemoteIP != nil {
+		linkType := "gretap"
+		if mirror.RemoteIP.To4() == nil {
+			linkType = "ip6gretap"
+		}
+
+		cmd := fmt.Sprintf("ip link add %s type %s remote %s", mirror.TargetIfName, linkType, mirror.RemoteIP)
+		if _, err := plc.ExecuteCommand(cmd); err != nil {
+			return newErrorEndpointMirror(err.Error())
+		}
+	} else {
+		link := &netlink.IFBLink{
+			LinkInfo: netlink.LinkInfo{
+				Type: netlink.LINK_TYPE_IFB,
+				Name: mirror.TargetIfName,
+			},
+		}
+		if err := nl.AddLink(link); err != nil {
+			return newErrorEndpointMirror(err.Error())
+		}
+	}
+
+	mirror.CreatedTargetIf = true
+
+	if err := nl.SetLinkState(mirror.TargetIfName, true); err != nil {
+		return newErrorEndpointMirror(err.Error())
+	}
+
+	return nil
+}
+
+// deleteMirrorTarget deletes the target interface of the mirror if it was created for a mirror and no other mirror
+// of the network uses it.
+func (nw *network) deleteMirrorTarget(nl netlink.NetlinkInterface, ep *endpoint, mirror *EndpointMirror) error {
+	if !mirror.CreatedTargetIf || nw.isMirrorTargetInUse(ep, mirror.TargetIfName) {
+		return nil
+	}
+
+	if err := nl.DeleteLink(mirror.TargetIfName); err != nil {
+		return newErrorEndpointMirror(err.Error())
+	}
+
+	return nil
+}
+
+// deleteOVSMirror deletes the OVS mirror of the endpoint, and the port of its target once no other mirror uses it.
+func (nw *network) deleteOVSMirror(ovs ovsctl.OvsInterface, ep *endpoint, mirror *EndpointMirror) error {
+	bridgeName := nw.extIf.BridgeName
+
+	if err := ovs.DeleteMirror(bridgeName, ovsMirrorName(ep)); err != nil {
+		log.Printf("[net] Failed to delete OVS mirror of endpoint %s, err:%v.", ep.Id, err)
+	}
+
+	if nw.isMirrorTargetInUse(ep, mirror.TargetIfName) {
+		return nil
+	}
+
+	return ovs.DeletePortFromOVS(bridgeName, mirror.TargetIfName)
+}
+
+// ovsMirrorName returns the name of the OVS mirror of the endpoint.
+func ovsMirrorName(ep *endpoint) string {
+	return "mirror-" + ep.HostIfName
+}
+
+// addTCMirror mirrors the ingress and egress traffic of the interface to the target interface.
+func addTCMirror(plc platform.ExecClient, ifName, targetIfName string) error {
+	cmds := []string{
+		fmt.Sprintf("tc qdisc add dev %s clsact", ifName),
+		fmt.Sprintf("tc filter add dev %s ingress matchall action mirred egress mirror dev %s", ifName, targetIfName),
+		fmt.Sprintf("tc filter add dev %s egress matchall action mirred egress mirror dev %s", ifName, targetIfName),
+	}
+
+	for _, cmd := range cmds {
+		if _, err := plc.ExecuteCommand(cmd); err != nil {
+			return newErrorEndpointMirror(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// deleteTCMirror deletes the tc mirror of the interface, along with the clsact qdisc holding its filters.
+func deleteTCMirror(plc platform.ExecClient, ifName string) error {
+	if _, err := plc.ExecuteCommand(fmt.Sprintf("tc qdisc del dev %s clsact", ifName)); err != nil {
+		return newErrorEndpointMirror(err.Error())
+	}
+
+	return nil
+}
+
+// isMirrorTargetCreated returns whether another endpoint of the network is mirrored to the target interface, created
+// for its mirror.
+func (nw *network) isMirrorTargetCreated(ep *endpoint, targetIfName string) bool {
+	for id, other := range nw.Endpoints {
+		if id != ep.Id && other.Mirror != nil && other.Mirror.TargetIfName == targetIfName && other.Mirror.CreatedTargetIf {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/ovsctl"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExecClient records the commands it executes, failing the commands starting with failPrefix.
+type recordingExecClient struct {
+	failPrefix string
+	commands   []string
+}
+
+func (c *recordingExecClient) ExecuteCommand(command string) (string, error) {
+	c.commands = append(c.commands, command)
+	if c.failPrefix != "" && strings.HasPrefix(command, c.failPrefix) {
+		return "", platform.ErrMockExec
+	}
+
+	return "", nil
+}
+
+func newMirrorTestNetwork(eps ...*endpoint) *network {
+	nw := &network{
+		Id:        "azure",
+		Mode:      opModeBridge,
+		Endpoints: map[string]*endpoint{},
+		extIf:     &externalInterface{Name: "eth0", BridgeName: "azure0"},
+	}
+	for _, ep := range eps {
+		nw.Endpoints[ep.Id] = ep
+	}
+
+	return nw
+}
+
+func TestAddEndpointMirror(t *testing.T) {
+	greIfName := mirrorGREInterfacePrefix + generateVethName("ep1")
+
+	tests := []struct {
+		name         string
+		mode         string
+		vlanID       int
+		mirrorInfo   *MirrorInfo
+		failPrefix   string
+		wantCommands []string
+		wantTarget   string
+		wantErr      error
+	}{
+		{
+			name:       "tc mirror to a GRE remote",
+			mirrorInfo: &MirrorInfo{RemoteIP: net.ParseIP("10.0.0.5"), Duration: time.Minute},
+			wantCommands: []string{
+				"ip link add " + greIfName + " type gretap remote 10.0.0.5",
+				"tc qdisc add dev azv1 clsact",
+				"tc filter add dev azv1 ingress matchall action mirred egress mirror dev " + greIfName,
+				"tc filter add dev azv1 egress matchall action mirred egress mirror dev " + greIfName,
+			},
+			wantTarget: greIfName,
+		},
+		{
+			name:       "tc mirror to an IPv6 GRE remote",
+			mirrorInfo: &MirrorInfo{RemoteIP: net.ParseIP("fd00::5"), Duration: time.Minute},
+			wantCommands: []string{
+				"ip link add " + greIfName + " type ip6gretap remote fd00::5",
+				"tc qdisc add dev azv1 clsact",
+				"tc filter add dev azv1 ingress matchall action mirred egress mirror dev " + greIfName,
+				"tc filter add dev azv1 egress matchall action mirred egress mirror dev " + greIfName,
+			},
+			wantTarget: greIfName,
+		},
+		{
+			name:         "OVS mirror to a local IFB interface",
+			vlanID:       1,
+			mirrorInfo:   &MirrorInfo{TargetIfName: "azmirror0", Duration: time.Minute},
+			wantCommands: nil,
+			wantTarget:   "azmirror0",
+		},
+		{
+			name:       "tc failure is rolled back",
+			mirrorInfo: &MirrorInfo{TargetIfName: "azmirror0", Duration: time.Minute},
+			failPrefix: "tc filter add dev azv1 egress",
+			wantCommands: []string{
+				"tc qdisc add dev azv1 clsact",
+				"tc filter add dev azv1 ingress matchall action mirred egress mirror dev azmirror0",
+				"tc filter add dev azv1 egress matchall action mirred egress mirror dev azmirror0",
+				"tc qdisc del dev azv1 clsact",
+			},
+			wantErr: errorEndpointMirror,
+		},
+		{
+			name:       "No host interface in IPVlan mode",
+			mode:       opModeIPVlanL3,
+			mirrorInfo: &MirrorInfo{TargetIfName: "azmirror0", Duration: time.Minute},
+			wantErr:    errMirrorNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &endpoint{Id: "ep1", HostIfName: "azv1", VlanID: tt.vlanID}
+			nw := newMirrorTestNetwork(ep)
+			if tt.mode != "" {
+				nw.Mode = tt.mode
+			}
+			plc := &recordingExecClient{failPrefix: tt.failPrefix}
+
+			err := nw.addEndpointMirrorWithOvsctl(netlink.NewMockNetlink(false, ""), plc,
+				ovsctl.NewMockOvsctl(false, "", ""), ep, tt.mirrorInfo)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, ep.Mirror)
+				require.Equal(t, tt.wantCommands, plc.commands)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCommands, plc.commands)
+
+			require.NotNil(t, ep.Mirror)
+			require.Equal(t, tt.wantTarget, ep.Mirror.TargetIfName)
+			require.True(t, ep.Mirror.CreatedTargetIf)
+			require.WithinDuration(t, time.Now().Add(tt.mirrorInfo.Duration), ep.Mirror.ExpiresAt, time.Second)
+		})
+	}
+}
+
+func TestDeleteExpiredEndpointMirrors(t *testing.T) {
+	newMirroredEndpoint := func(id string, expiresAt time.Time) *endpoint {
+		return &endpoint{
+			Id:         id,
+			HostIfName: "azv" + id,
+			Mirror:     &EndpointMirror{TargetIfName: "azmirror0", CreatedTargetIf: true, ExpiresAt: expiresAt},
+		}
+	}
+	expired := newMirroredEndpoint("ep1", time.Now().Add(-time.Minute))
+	active := newMirroredEndpoint("ep2", time.Now().Add(time.Hour))
+	nw := newMirrorTestNetwork(expired, active)
+
+	nm := &networkManager{
+		ExternalInterfaces: map[string]*externalInterface{"eth0": {Networks: map[string]*network{nw.Id: nw}}},
+		netlink:            netlink.NewMockNetlink(false, ""),
+		plClient:           platform.NewMockExecClient(false),
+	}
+
+	// The target interface stays for the active mirror sharing it.
+	require.True(t, nw.isMirrorTargetInUse(expired, "azmirror0"))
+	require.True(t, nm.HasExpiredEndpointMirrors())
+	require.NoError(t, nm.DeleteExpiredEndpointMirrors())
+	require.Nil(t, expired.Mirror)
+	require.NotNil(t, active.Mirror)
+	require.False(t, nm.HasExpiredEndpointMirrors())
+
+	require.NoError(t, nm.DeleteEndpointMirror(nw.Id, active.Id))
+	require.Nil(t, active.Mirror)
+	require.ErrorIs(t, nm.DeleteEndpointMirror(nw.Id, active.Id), errMirrorNotFound)
+}
+
+func TestAddEndpointMirrorInvalid(t *testing.T) {
+	nm := &networkManager{ExternalInterfaces: map[string]*externalInterface{}}
+
+	invalid := []*MirrorInfo{
+		{Duration: time.Minute},
+		{TargetIfName: "azmirror0", RemoteIP: net.ParseIP("10.0.0.5"), Duration: time.Minute},
+		{TargetIfName: "azmirror0"},
+	}
+	for _, mirrorInfo := range invalid {
+		require.ErrorIs(t, nm.AddEndpointMirror("azure", "ep1", mirrorInfo), errInvalidMirrorInfo)
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/platform"
+)
+
+// addEndpointMirror is not supported on Windows, where the endpoints have no host interface.
+func (nw *network) addEndpointMirror(_ netlink.NetlinkInterface, _ platform.ExecClient, _ *endpoint, _ *MirrorInfo) error {
+	return errMirrorNotSupported
+}
+
+func (nw *network) deleteEndpointMirror(_ netlink.NetlinkInterface, _ platform.ExecClient, ep *endpoint) error {
+	ep.Mirror = nil
+	return nil
+}
@@ -5,33 +5,123 @@ import (
 
 	cnms "github.com/Azure/azure-container-networking/cnms/cnmspackage"
 	"github.com/Azure/azure-container-networking/ebtables"
+	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/ovssnat"
 )
 
 const (
 	ipv6Mask = "/ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"
 )
 
-// monitorNetworkState compares current ebtable nat rules with state rules and matches state.
+// monitorNetworkState compares current ebtable nat rules and iptables rules with state rules and matches state.
+// The ebtables rules are only reconciled on nodes with bridge mode networks, which are the only ones programming
+// them, so that the ebtables of other nodes are left alone.
 func (nm *networkManager) monitorNetworkState(networkMonitor *cnms.NetworkMonitor) error {
-	currentEbtableRulesMap, err := cnms.GetEbTableRulesInMap()
-	if err != nil {
-		log.Printf("GetEbTableRulesInMap failed with error %v", err)
-		return err
+	if nm.hasBridgeModeNetwork() {
+		currentEbtableRulesMap, err := cnms.GetEbTableRulesInMap()
+		if err != nil {
+			log.Printf("GetEbTableRulesInMap failed with error %v", err)
+			return err
+		}
+
+		currentStateRulesMap := nm.AddStateRulesToMap()
+		networkMonitor.CreateRequiredL2Rules(currentEbtableRulesMap, currentStateRulesMap)
+		networkMonitor.RemoveInvalidL2Rules(currentEbtableRulesMap, currentStateRulesMap)
 	}
 
-	currentStateRulesMap := nm.AddStateRulesToMap()
-	networkMonitor.CreateRequiredL2Rules(currentEbtableRulesMap, currentStateRulesMap)
-	networkMonitor.RemoveInvalidL2Rules(currentEbtableRulesMap, currentStateRulesMap)
+	networkMonitor.CreateRequiredIptablesRules(nm.AddIptablesStateRules())
 
 	return nil
 }
 
+// hasBridgeModeNetwork returns true if a network of the node is in bridge mode.
+func (nm *networkManager) hasBridgeModeNetwork() bool {
+	for _, extIf := range nm.ExternalInterfaces {
+		if isBridgeModeInterface(extIf) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBridgeModeInterface returns true if a network of the external interface connects it to a bridge, as bridge
+// mode and tunnel mode without VXLAN do.
+func isBridgeModeInterface(extIf *externalInterface) bool {
+	for _, nw := range extIf.Networks {
+		if nw.Mode == opModeBridge || (nw.Mode == opModeTunnel && nw.Vxlan == nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddIptablesStateRules returns the iptables entries that should exist based off network manager settings.
+// The entries are built by the same functions the rules are programmed with: the additional rules networks
+// were created with such as the SWIFT SNAT rules of transparent mode, the IPv6 SNAT rules of IPv6 NAT networks,
+// and the snat bridge and host/NC rules of the OVS SNAT endpoints. Entries are ordered so chains are created
+// before use.
+func (nm *networkManager) AddIptablesStateRules() []iptables.IPTableEntry {
+	var entries []iptables.IPTableEntry
+	seen := make(map[iptables.IPTableEntry]bool)
+	add := func(rules ...iptables.IPTableEntry) {
+		for _, rule := range rules {
+			if !seen[rule] {
+				seen[rule] = true
+				entries = append(entries, rule)
+			}
+		}
+	}
+
+	for _, extIf := range nm.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			add(nw.IPTablesRules...)
+
+			if nw.IPV6Mode == IPV6Nat {
+				// see connectExternalInterface
+				if rules, err := ipv6SnatRules(extIf, nw.Subnets); err == nil {
+					add(rules...)
+				}
+				add(ipv6UnmarkRule())
+			}
+
+			if nw.SnatBridgeIP == "" {
+				continue
+			}
+
+			for _, ep := range nw.Endpoints {
+				if ep.EnableSnatOnHost || ep.AllowInboundFromHostToNC || ep.AllowInboundFromNCToHost {
+					// see CreateSnatEndpoint and AddSnatEndpointRules
+					add(ovssnat.SnatBridgeRules(nw.SnatBridgeIP, ep.DNS.Servers)...)
+					add(networkutils.IPForwardingRule())
+				}
+
+				if ep.AllowInboundFromHostToNC {
+					add(ovssnat.InboundFromHostToNCRules(nw.SnatBridgeIP, ep.LocalIP)...)
+				}
+
+				if ep.AllowInboundFromNCToHost {
+					add(ovssnat.InboundFromNCToHostRules(nw.SnatBridgeIP, ep.LocalIP)...)
+				}
+			}
+		}
+	}
+
+	return entries
+}
+
 // AddStateRulesToMap adds rules to state based off network manager settings.
 func (nm *networkManager) AddStateRulesToMap() map[string]string {
 	rulesMap := make(map[string]string)
 
 	for _, extIf := range nm.ExternalInterfaces {
+		if !isBridgeModeInterface(extIf) {
+			continue
+		}
+
 		arpDnatKey := fmt.Sprintf("-p ARP -i %s --arp-op Reply -j dnat --to-dst ff:ff:ff:ff:ff:ff --dnat-target ACCEPT", extIf.Name)
 		rulesMap[arpDnatKey] = ebtables.PreRouting
 
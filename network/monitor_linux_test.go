@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/iptables"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/network/ovssnat"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddIptablesStateRules(t *testing.T) {
+	_, v6Prefix, _ := net.ParseCIDR("fd00::/64")
+	hostV6 := &net.IPNet{IP: net.ParseIP("fd00:1::4"), Mask: net.CIDRMask(128, 128)}
+	swiftRule := iptables.GetCreateChainCmd(iptables.V4, iptables.Nat, iptables.Swift)
+
+	nm := &networkManager{
+		ExternalInterfaces: map[string]*externalInterface{
+			"eth0": {
+				Name:        "eth0",
+				IPAddresses: []*net.IPNet{hostV6},
+				Networks: map[string]*network{
+					"azure": {
+						Id:            "azure",
+						Mode:          opModeBridge,
+						IPV6Mode:      IPV6Nat,
+						Subnets:       []SubnetInfo{{Family: platform.AfINET6, Prefix: *v6Prefix}},
+						SnatBridgeIP:  "169.254.0.1/16",
+						IPTablesRules: []iptables.IPTableEntry{swiftRule},
+						Endpoints: map[string]*endpoint{
+							"ep1": {
+								Id:                       "ep1",
+								LocalIP:                  "169.254.0.4/16",
+								DNS:                      DNSInfo{Servers: []string{"168.63.129.16"}},
+								EnableSnatOnHost:         true,
+								AllowInboundFromHostToNC: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rules := nm.AddIptablesStateRules()
+
+	want := []iptables.IPTableEntry{swiftRule}
+	want = append(want, networkutils.SnatRule("-s fd00::/64", hostV6.IP), ipv6UnmarkRule())
+	want = append(want, ovssnat.SnatBridgeRules("169.254.0.1/16", []string{"168.63.129.16"})...)
+	want = append(want, networkutils.IPForwardingRule())
+	want = append(want, ovssnat.InboundFromHostToNCRules("169.254.0.1/16", "169.254.0.4/16")...)
+
+	require.Equal(t, want, rules)
+
+	require.Contains(t, rules, iptables.GetInsertIptableRuleCmd(iptables.V6, iptables.Mangle, iptables.Postrouting, "", "MARK --set-mark 0x0"))
+	require.Contains(t, rules, iptables.GetAppendIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Forward, "-i azSnatbr -d 10.0.0.0/8", iptables.Drop))
+	require.Contains(t, rules, iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Output, "-o azSnatbr -d 168.63.129.16", iptables.Accept))
+	require.Contains(t, rules, iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Postrouting, "-s 169.254.0.0/16", iptables.Masquerade))
+}
+
+func TestMonitorBridgeModeGate(t *testing.T) {
+	mac, _ := net.ParseMAC("00:0d:3a:00:00:01")
+	newNetworkManager := func(nw *network) *networkManager {
+		return &networkManager{
+			ExternalInterfaces: map[string]*externalInterface{
+				"eth0": {Name: "eth0", MacAddress: mac, Networks: map[string]*network{nw.Id: nw}},
+			},
+		}
+	}
+
+	bridge := newNetworkManager(&network{Id: "azure", Mode: opModeBridge})
+	require.True(t, bridge.hasBridgeModeNetwork())
+	require.NotEmpty(t, bridge.AddStateRulesToMap())
+
+	tunnel := newNetworkManager(&network{Id: "azure", Mode: opModeTunnel})
+	require.True(t, tunnel.hasBridgeModeNetwork())
+
+	for _, nw := range []*network{
+		{Id: "azure", Mode: opModeTransparent},
+		{Id: "azure", Mode: opModeTunnel, Vxlan: &VxlanInfo{}},
+	} {
+		nm := newNetworkManager(nw)
+		require.False(t, nm.hasBridgeModeNetwork(), nw.Mode)
+		require.Empty(t, nm.AddStateRulesToMap(), nw.Mode)
+	}
+}
@@ -4,15 +4,43 @@
 package network
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 
+	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/netlink"
 
 	"golang.org/x/sys/unix"
 )
 
+var (
+	ErrNamespaceExists      = errors.New("network namespace already exists")
+	ErrNamespaceNotFound    = errors.New("network namespace not found")
+	ErrInvalidNamespaceName = errors.New("invalid network namespace name")
+)
+
+// namedNamespaceDir is the directory of the named network namespaces, shared with "ip netns".
+var namedNamespaceDir = "/var/run/netns"
+
+// NamespaceError records a failed operation on a network namespace.
+type NamespaceError struct {
+	Op   string
+	Name string
+	Err  error
+}
+
+func (e *NamespaceError) Error() string {
+	return fmt.Sprintf("Failed to %s netns %s, err:%v", e.Op, e.Name, e.Err)
+}
+
+func (e *NamespaceError) Unwrap() error {
+	return e.Err
+}
+
 // Namespace represents a network namespace.
 type Namespace struct {
 	file   *os.File
@@ -106,3 +134,158 @@ func (ns *Namespace) Exit() error {
 
 	return nil
 }
+
+// Do runs fn inside the namespace on a locked OS thread, and restores the thread to its namespace afterwards. fn runs
+// on a goroutine of its own. If the thread cannot be restored, it is left locked so that the runtime terminates it
+// instead of reusing it in the wrong namespace.
+func (ns *Namespace) Do(fn func() error) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		if err := ns.Enter(); err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+
+		fnErr := fn()
+
+		if err := ns.Exit(); err != nil {
+			errCh <- &NamespaceError{Op: "exit", Name: ns.file.Name(), Err: err}
+			return
+		}
+
+		runtime.UnlockOSThread()
+		errCh <- fnErr
+	}()
+
+	return <-errCh
+}
+
+// NewNamedNamespace creates a new network namespace named after name, like "ip netns add". The namespace is kept
+// alive by a bind mount in the named namespace directory until it is deleted.
+func NewNamedNamespace(name string) (*Namespace, error) {
+	if !isValidNamespaceName(name) {
+		return nil, &NamespaceError{Op: "create", Name: name, Err: ErrInvalidNamespaceName}
+	}
+
+	if err := os.MkdirAll(namedNamespaceDir, 0o755); err != nil {
+		return nil, &NamespaceError{Op: "create", Name: name, Err: err}
+	}
+
+	// Create the mount point, failing if the namespace already exists.
+	nsPath := filepath.Join(namedNamespaceDir, name)
+	file, err := os.OpenFile(nsPath, os.O_RDONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		if os.IsExist(err) {
+			err = ErrNamespaceExists
+		}
+		return nil, &NamespaceError{Op: "create", Name: name, Err: err}
+	}
+	file.Close()
+
+	if err = bindNewNamespace(nsPath); err != nil {
+		_ = unix.Unmount(nsPath, unix.MNT_DETACH)
+		os.Remove(nsPath)
+		return nil, &NamespaceError{Op: "create", Name: name, Err: err}
+	}
+
+	log.Printf("[net] Created netns %v.", nsPath)
+
+	return OpenNamespace(nsPath)
+}
+
+// DeleteNamedNamespace deletes the named network namespace. The namespace goes away once no process nor open
+// file refers to it anymore.
+func DeleteNamedNamespace(name string) error {
+	if !isValidNamespaceName(name) {
+		return &NamespaceError{Op: "delete", Name: name, Err: ErrInvalidNamespaceName}
+	}
+
+	nsPath := filepath.Join(namedNamespaceDir, name)
+	if _, err := os.Stat(nsPath); err != nil {
+		if os.IsNotExist(err) {
+			err = ErrNamespaceNotFound
+		}
+		return &NamespaceError{Op: "delete", Name: name, Err: err}
+	}
+
+	// A mount point left behind by a failed creation is not mounted.
+	if err := unix.Unmount(nsPath, unix.MNT_DETACH); err != nil && !errors.Is(err, unix.EINVAL) {
+		return &NamespaceError{Op: "delete", Name: name, Err: err}
+	}
+
+	if err := os.Remove(nsPath); err != nil {
+		return &NamespaceError{Op: "delete", Name: name, Err: err}
+	}
+
+	log.Printf("[net] Deleted netns %v.", nsPath)
+
+	return nil
+}
+
+// ListNamedNamespaces returns the names of the named network namespaces.
+func ListNamedNamespaces() ([]string, error) {
+	entries, err := os.ReadDir(namedNamespaceDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, &NamespaceError{Op: "list", Name: namedNamespaceDir, Err: err}
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// isValidNamespaceName returns whether name can name a file in the named namespace directory.
+func isValidNamespaceName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsRune(name, '/')
+}
+
+// bindNewNamespace creates a new network namespace on a locked OS thread and bind mounts it to nsPath. The thread
+// is restored to its namespace, or left locked for the runtime to terminate it if it cannot be.
+func bindNewNamespace(nsPath string) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		origNs, err := GetCurrentThreadNamespace()
+		if err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+		defer origNs.Close()
+
+		if err = unix.Unshare(unix.CLONE_NEWNET); err != nil {
+			runtime.UnlockOSThread()
+			errCh <- fmt.Errorf("Failed to unshare network namespace, err:%w", err)
+			return
+		}
+
+		threadNsPath := fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid())
+		if err = unix.Mount(threadNsPath, nsPath, "none", unix.MS_BIND, ""); err != nil {
+			err = fmt.Errorf("Failed to bind mount %v, err:%w", threadNsPath, err)
+		}
+
+		if setErr := origNs.set(); setErr != nil {
+			errCh <- setErr
+			return
+		}
+
+		runtime.UnlockOSThread()
+		errCh <- err
+	}()
+
+	return <-errCh
+}
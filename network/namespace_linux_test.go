@@ -0,0 +1,176 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// unsharedTestEnv is set in the test binary reexecuted under unshare.
+const unsharedTestEnv = "ACN_NETNS_TEST_UNSHARED"
+
+var errTestNamespace = errors.New("test namespace error")
+
+// runUnshared reexecutes the calling test under unshare, in new mount and network namespaces so that the namespaces
+// it creates do not leak to the host. It returns true in the reexecuted test, which runs the body of the test.
+func runUnshared(t *testing.T) bool {
+	t.Helper()
+
+	if os.Getenv(unsharedTestEnv) != "" {
+		namedNamespaceDir = t.TempDir()
+		return true
+	}
+
+	unshare, err := exec.LookPath("unshare")
+	if err != nil {
+		t.Skip("unshare not found")
+	}
+
+	args := []string{"--mount", "--net"}
+	if os.Geteuid() != 0 {
+		args = append(args, "--map-root-user")
+	}
+	args = append(args, os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+
+	cmd := exec.Command(unshare, args...)
+	cmd.Env = append(os.Environ(), unsharedTestEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "unshare failed") {
+			t.Skipf("unshare not permitted: %s", out)
+		}
+		t.Fatalf("Unshared test failed: %v\n%s", err, out)
+	}
+
+	return false
+}
+
+// threadNamespaceIno returns the inode of the network namespace of the caller thread.
+func threadNamespaceIno(t *testing.T) uint64 {
+	ns, err := GetCurrentThreadNamespace()
+	require.NoError(t, err)
+	defer ns.Close()
+
+	return namespaceIno(t, ns)
+}
+
+func namespaceIno(t *testing.T, ns *Namespace) uint64 {
+	var stat unix.Stat_t
+	require.NoError(t, unix.Fstat(int(ns.GetFd()), &stat))
+
+	return stat.Ino
+}
+
+func TestNamedNamespaces(t *testing.T) {
+	if !runUnshared(t) {
+		return
+	}
+
+	names, err := ListNamedNamespaces()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	ns1, err := NewNamedNamespace("ns1")
+	require.NoError(t, err)
+	defer ns1.Close()
+
+	ns2, err := NewNamedNamespace("ns2")
+	require.NoError(t, err)
+	defer ns2.Close()
+
+	require.NotEqual(t, namespaceIno(t, ns1), namespaceIno(t, ns2))
+	require.NotEqual(t, threadNamespaceIno(t), namespaceIno(t, ns1))
+
+	_, err = NewNamedNamespace("ns1")
+	require.ErrorIs(t, err, ErrNamespaceExists)
+
+	names, err = ListNamedNamespaces()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ns1", "ns2"}, names)
+
+	require.NoError(t, DeleteNamedNamespace("ns1"))
+	require.ErrorIs(t, DeleteNamedNamespace("ns1"), ErrNamespaceNotFound)
+
+	names, err = ListNamedNamespaces()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ns2"}, names)
+
+	require.NoError(t, DeleteNamedNamespace("ns2"))
+}
+
+func TestNamespaceDo(t *testing.T) {
+	if !runUnshared(t) {
+		return
+	}
+
+	ns, err := NewNamedNamespace("ns1")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, DeleteNamedNamespace("ns1")) }()
+	defer ns.Close()
+
+	hostIno := threadNamespaceIno(t)
+
+	// fn runs on a goroutine of its own, so its results are checked once it returns.
+	var nsIno uint64
+	var ifaces []net.Interface
+	err = ns.Do(func() error {
+		threadNs, err := GetCurrentThreadNamespace()
+		if err != nil {
+			return err
+		}
+		defer threadNs.Close()
+
+		var stat unix.Stat_t
+		if err = unix.Fstat(int(threadNs.GetFd()), &stat); err != nil {
+			return err
+		}
+		nsIno = stat.Ino
+
+		ifaces, err = net.Interfaces()
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, namespaceIno(t, ns), nsIno)
+	require.Equal(t, hostIno, threadNamespaceIno(t))
+
+	// A new namespace only has a loopback interface.
+	require.Len(t, ifaces, 1)
+	require.Equal(t, "lo", ifaces[0].Name)
+
+	// Errors of fn are returned once the thread is restored.
+	err = ns.Do(func() error { return errTestNamespace })
+	require.ErrorIs(t, err, errTestNamespace)
+	require.Equal(t, hostIno, threadNamespaceIno(t))
+}
+
+func TestNamedNamespaceErrors(t *testing.T) {
+	namedNamespaceDir = t.TempDir()
+	defer func() { namedNamespaceDir = "/var/run/netns" }()
+
+	names, err := ListNamedNamespaces()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	err = DeleteNamedNamespace("missing")
+	require.ErrorIs(t, err, ErrNamespaceNotFound)
+
+	var nsErr *NamespaceError
+	require.ErrorAs(t, err, &nsErr)
+	require.Equal(t, "delete", nsErr.Op)
+	require.Equal(t, "missing", nsErr.Name)
+
+	for _, name := range []string{"", ".", "..", "a/b"} {
+		_, err = NewNamedNamespace(name)
+		require.ErrorIs(t, err, ErrInvalidNamespaceName)
+		require.ErrorIs(t, DeleteNamedNamespace(name), ErrInvalidNamespaceName)
+	}
+}
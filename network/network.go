@@ -7,6 +7,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/Azure/azure-container-networking/iptables"
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/network/policy"
 	"github.com/Azure/azure-container-networking/platform"
@@ -17,6 +18,8 @@ const (
 	opModeBridge      = "bridge"
 	opModeTunnel      = "tunnel"
 	opModeTransparent = "transparent"
+	opModeIPVlanL2    = "ipvlan-l2"
+	opModeIPVlanL3    = "ipvlan-l3"
 	opModeDefault     = opModeTunnel
 )
 
@@ -48,10 +51,17 @@ type network struct {
 	Subnets          []SubnetInfo
 	Endpoints        map[string]*endpoint
 	extIf            *externalInterface
+	nm               *networkManager
 	DNS              DNSInfo
 	EnableSnatOnHost bool
 	NetNs            string
 	SnatBridgeIP     string
+	IPTablesRules    []iptables.IPTableEntry `json:",omitempty"`
+	Vxlan            *VxlanInfo              `json:",omitempty"`
+	VxlanPeers       []string                `json:",omitempty"`
+	IPVlanHostIfName string                  `json:",omitempty"`
+	MTU              int                     `json:",omitempty"`
+	IPV6Mode         string                  `json:",omitempty"`
 }
 
 // NetworkInfo contains read-only information about a container network.
@@ -72,6 +82,18 @@ type NetworkInfo struct {
 	IPV6Mode                      string
 	IPAMType                      string
 	ServiceCidrs                  string
+	Vxlan                         *VxlanInfo
+	MTU                           int
+}
+
+// VxlanInfo contains the VXLAN overlay configuration of a tunnel mode network. The remote nodes are the
+// union of Peers and of the list in PeersFile, which is read again on each endpoint creation so that the
+// node agent maintaining it can add and remove nodes.
+type VxlanInfo struct {
+	VNI       int
+	Port      int
+	Peers     []string
+	PeersFile string
 }
 
 // SubnetInfo contains subnet information for a container network.
@@ -195,6 +217,7 @@ func (nm *networkManager) newNetwork(nwInfo *NetworkInfo) (*network, error) {
 
 	// Add the network object.
 	nw.Subnets = nwInfo.Subnets
+	nw.nm = nm
 	extIf.Networks[nwInfo.Id] = nw
 
 	log.Printf("[net] Created network %v on interface %v.", nwInfo.Id, extIf.Name)
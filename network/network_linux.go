@@ -39,6 +39,12 @@ const (
 	LocalIPKey = "localIP"
 	// InfraVnetIPKey key for infra vnet
 	InfraVnetIPKey = "infraVnetIP"
+	// Minimum MTU of an IPv4 link.
+	minMTU = 68
+	// Minimum MTU of an IPv6 link.
+	minMTUIPv6 = 1280
+	// The sysctls of the pods are applied in their network namespace.
+	sysctlsSupported = true
 )
 
 const (
@@ -60,14 +66,31 @@ type route netlink.Route
 func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInterface) (*network, error) {
 	// Connect the external interface.
 	var (
-		vlanid int
-		ifName string
+		vlanid     int
+		ifName     string
+		vxlanPeers []string
+		ipvlanIf   string
 	)
 	opt, _ := nwInfo.Options[genericData].(map[string]interface{})
 	log.Printf("opt %+v options %+v", opt, nwInfo.Options)
 
+	mtu, err := nm.networkMTU(extIf, nwInfo)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[net] Network mtu %d.", mtu)
+
 	switch nwInfo.Mode {
 	case opModeTunnel:
+		if nwInfo.Vxlan != nil {
+			log.Printf("create vxlan tunnel")
+			if vxlanPeers, err = nm.connectVxlanTunnel(extIf, nwInfo, mtu); err != nil {
+				return nil, err
+			}
+			ifName = extIf.BridgeName
+			break
+		}
+		// Without a VXLAN config, the pod network is routed by the VNet as in bridge mode.
 		fallthrough
 	case opModeBridge:
 		log.Printf("create bridge")
@@ -76,6 +99,11 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 			return nil, err
 		}
 
+		log.Printf("[net] Setting link %v mtu %d.", extIf.BridgeName, mtu)
+		if err := nm.netlink.SetLinkMTU(extIf.BridgeName, mtu); err != nil {
+			return nil, err
+		}
+
 		if opt != nil && opt[VlanIDKey] != nil {
 			vlanid, _ = strconv.Atoi(opt[VlanIDKey].(string))
 		}
@@ -88,11 +116,17 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 				return nil, fmt.Errorf("Ipv6 forwarding failed: %w", err)
 			}
 		}
+	case opModeIPVlanL2, opModeIPVlanL3:
+		log.Printf("IPVlan mode")
+		ifName = extIf.Name
+		if ipvlanIf, err = nm.connectIPVlanHostInterface(extIf, nwInfo); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errNetworkModeInvalid
 	}
 
-	err := nm.handleCommonOptions(ifName, nwInfo)
+	err = nm.handleCommonOptions(ifName, nwInfo)
 	if err != nil {
 		log.Printf("handleCommonOptions failed with error %s", err.Error())
 		return nil, err
@@ -107,11 +141,67 @@ func (nm *networkManager) newNetworkImpl(nwInfo *NetworkInfo, extIf *externalInt
 		VlanId:           vlanid,
 		DNS:              nwInfo.DNS,
 		EnableSnatOnHost: nwInfo.EnableSnatOnHost,
+		Vxlan:            nwInfo.Vxlan,
+		VxlanPeers:       vxlanPeers,
+		IPVlanHostIfName: ipvlanIf,
+		MTU:              mtu,
+		IPV6Mode:         nwInfo.IPV6Mode,
+	}
+
+	// Remember the additional iptables rules so the network monitor can restore them.
+	if iptcmds, exists := nwInfo.Options[IPTablesKey]; exists {
+		nw.IPTablesRules = iptcmds.([]iptables.IPTableEntry)
 	}
 
 	return nw, nil
 }
 
+// networkMTU returns the MTU of the interfaces of a network, the configured one or else the one of the external
+// interface less the encapsulation overhead of the network mode. The configured MTU cannot exceed the latter, nor
+// be below the minimum MTU of the address families of the network.
+func (nm *networkManager) networkMTU(extIf *externalInterface, nwInfo *NetworkInfo) (int, error) {
+	if nwInfo.MTU != 0 {
+		if lowest := networkMinMTU(nwInfo); nwInfo.MTU < lowest {
+			return 0, newErrorNetworkManager(fmt.Sprintf("invalid mtu %d, the minimum is %d", nwInfo.MTU, lowest))
+		}
+	}
+
+	hostIf, err := nm.netio.GetNetworkInterfaceByName(extIf.Name)
+	if err != nil {
+		return 0, newErrorNetworkManager(err.Error())
+	}
+
+	maxMTU := hostIf.MTU
+	if nwInfo.Mode == opModeTunnel && nwInfo.Vxlan != nil {
+		addrs, err := nm.netio.GetNetworkInterfaceAddrs(hostIf)
+		if err != nil {
+			return 0, newErrorNetworkManager(err.Error())
+		}
+		maxMTU = vxlanMTU(hostIf.MTU, underlayIP(addrs))
+	}
+
+	if nwInfo.MTU == 0 {
+		return maxMTU, nil
+	}
+
+	if nwInfo.MTU > maxMTU {
+		return 0, newErrorNetworkManager(fmt.Sprintf("invalid mtu %d, the maximum on interface %s is %d", nwInfo.MTU, extIf.Name, maxMTU))
+	}
+
+	return nwInfo.MTU, nil
+}
+
+// networkMinMTU returns the minimum MTU of the links of a network, the one of IPv6 if it has an IPv6 subnet.
+func networkMinMTU(nwInfo *NetworkInfo) int {
+	for _, subnet := range nwInfo.Subnets {
+		if subnet.Family == platform.AfINET6 {
+			return minMTUIPv6
+		}
+	}
+
+	return minMTU
+}
+
 func (nm *networkManager) handleCommonOptions(ifName string, nwInfo *NetworkInfo) error {
 	var err error
 	if routes, exists := nwInfo.Options[RoutesKey]; exists {
@@ -135,6 +225,23 @@ func (nm *networkManager) handleCommonOptions(ifName string, nwInfo *NetworkInfo
 func (nm *networkManager) deleteNetworkImpl(nw *network) error {
 	var networkClient NetworkClient
 
+	if nw.Vxlan != nil {
+		// Delete the tunnel if this was the last network using it.
+		if len(nw.extIf.Networks) == 1 {
+			NewVxlanNetworkClient(nw.extIf.BridgeName, nw.extIf.Name, *nw.Vxlan, nw.MTU, nm.netlink, nm.plClient).DeleteTunnel()
+			nw.extIf.BridgeName = ""
+		}
+		return nil
+	}
+
+	if isIPVlanMode(nw.Mode) {
+		// Delete the host ipvlan interface if this was the last network using it.
+		if len(nw.extIf.Networks) == 1 {
+			NewIPVlanNetworkClient(nw.extIf.Name, nw.IPVlanHostIfName, nw.Mode, nm.netlink).DeleteHostInterface()
+		}
+		return nil
+	}
+
 	if nw.VlanId != 0 {
 		networkClient = NewOVSClient(nw.extIf.BridgeName, nw.extIf.Name, ovsctl.NewOvsctl(), nm.netlink, nm.plClient)
 	} else {
@@ -520,9 +627,7 @@ func (nm *networkManager) connectExternalInterface(extIf *externalInterface, nwI
 			return err
 		}
 
-		// unmark packet if set by kube-proxy to skip kube-postrouting rule and processed
-		// by cni snat rule
-		if err = iptables.InsertIptableRule(iptables.V6, iptables.Mangle, iptables.Postrouting, "", "MARK --set-mark 0x0"); err != nil {
+		if err = iptables.EnsureEntry(ipv6UnmarkRule()); err != nil {
 			log.Errorf("[net] Adding Iptable mangle rule failed:%v", err)
 			return err
 		}
@@ -534,6 +639,88 @@ func (nm *networkManager) connectExternalInterface(extIf *externalInterface, nwI
 	return nil
 }
 
+// connectVxlanTunnel creates the bridge of a tunnel mode network with a VXLAN interface to the peer nodes. Unlike
+// in bridge mode, the external interface is not attached to the bridge, it only carries the encapsulated traffic,
+// and the bridge gets the gateway IP of the pod subnet. It returns the peers which are programmed.
+func (nm *networkManager) connectVxlanTunnel(extIf *externalInterface, nwInfo *NetworkInfo, mtu int) ([]string, error) {
+	if extIf.BridgeName != "" {
+		log.Printf("[net] Interface is already connected to bridge %v.", extIf.BridgeName)
+		return nil, nil
+	}
+
+	hostIf, err := net.InterfaceByName(extIf.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeName := nwInfo.BridgeName
+	if bridgeName == "" {
+		bridgeName = fmt.Sprintf("%s%d", bridgePrefix, hostIf.Index)
+	}
+
+	client := NewVxlanNetworkClient(bridgeName, extIf.Name, *nwInfo.Vxlan, mtu, nm.netlink, nm.plClient)
+	defer func() {
+		if err != nil {
+			log.Printf("[net] cleanup vxlan tunnel")
+			client.DeleteTunnel()
+		}
+	}()
+
+	if err = client.CreateTunnel(); err != nil {
+		return nil, err
+	}
+
+	for _, subnet := range nwInfo.Subnets {
+		if subnet.Gateway == nil {
+			continue
+		}
+
+		gateway := &net.IPNet{IP: subnet.Gateway, Mask: subnet.Prefix.Mask}
+		log.Printf("[net] Adding gateway IP address %v to bridge %v.", gateway, bridgeName)
+		if err = nm.netlink.AddIPAddress(bridgeName, subnet.Gateway, gateway); err != nil {
+			return nil, err
+		}
+	}
+
+	peers, err := client.SyncPeers(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	extIf.BridgeName = bridgeName
+	return peers, nil
+}
+
+// connectIPVlanHostInterface creates the host ipvlan interface of an ipvlan network, through which the host
+// reaches the pods. The external interface is left as is, the pods get ipvlan children of it.
+func (nm *networkManager) connectIPVlanHostInterface(extIf *externalInterface, nwInfo *NetworkInfo) (string, error) {
+	hostIf, err := net.InterfaceByName(extIf.Name)
+	if err != nil {
+		return "", err
+	}
+
+	ipvlanIfName := ipvlanHostInterfaceName(hostIf)
+	client := NewIPVlanNetworkClient(extIf.Name, ipvlanIfName, nwInfo.Mode, nm.netlink)
+	if err = client.CreateHostInterface(); err != nil {
+		client.DeleteHostInterface()
+		return "", err
+	}
+
+	return ipvlanIfName, nil
+}
+
+// syncVxlanPeers programs the peers added to the VXLAN config of the network since it was last synced, along with
+// cnsPeers listed by CNS, and removes those which are gone.
+func (nw *network) syncVxlanPeers(nl netlink.NetlinkInterface, plc platform.ExecClient, cnsPeers []string) {
+	client := NewVxlanNetworkClient(nw.extIf.BridgeName, nw.extIf.Name, *nw.Vxlan, nw.MTU, nl, plc)
+
+	peers, err := client.SyncPeers(nw.VxlanPeers, cnsPeers)
+	if err != nil {
+		log.Printf("[net] Failed to sync vxlan peers: %v.", err)
+	}
+	nw.VxlanPeers = peers
+}
+
 // DisconnectExternalInterface disconnects a host interface from its bridge.
 func (nm *networkManager) disconnectExternalInterface(extIf *externalInterface, networkClient NetworkClient) {
 	log.Printf("[net] Disconnecting interface %v.", extIf.Name)
@@ -596,12 +783,26 @@ func (nm *networkManager) addIpv6NatGateway(nwInfo *NetworkInfo) error {
 
 // snat ipv6 traffic to secondary ipv6 ip before leaving VM
 func (*networkManager) addIpv6SnatRule(extIf *externalInterface, nwInfo *NetworkInfo) error {
-	var (
-		ipv6SnatRuleSet  bool
-		ipv6SubnetPrefix net.IPNet
-	)
+	rules, err := ipv6SnatRules(extIf, nwInfo.Subnets)
+	if err != nil {
+		return err
+	}
 
-	for _, subnet := range nwInfo.Subnets {
+	for _, rule := range rules {
+		log.Printf("[net] Adding ipv6 snat rule")
+		if err := iptables.EnsureEntry(rule); err != nil {
+			return fmt.Errorf("Adding iptable snat rule failed:%w", err)
+		}
+	}
+
+	return nil
+}
+
+// ipv6SnatRules returns the rules snatting the ipv6 pod subnet to the ipv6 addresses of the external interface.
+func ipv6SnatRules(extIf *externalInterface, subnets []SubnetInfo) ([]iptables.IPTableEntry, error) {
+	var ipv6SubnetPrefix net.IPNet
+
+	for _, subnet := range subnets {
 		if subnet.Family == platform.AfINET6 {
 			ipv6SubnetPrefix = subnet.Prefix
 			break
@@ -609,25 +810,28 @@ func (*networkManager) addIpv6SnatRule(extIf *externalInterface, nwInfo *Network
 	}
 
 	if len(ipv6SubnetPrefix.IP) == 0 {
-		return errSubnetV6NotFound
+		return nil, errSubnetV6NotFound
 	}
 
+	var rules []iptables.IPTableEntry
 	for _, ipAddr := range extIf.IPAddresses {
 		if ipAddr.IP.To4() == nil {
-			log.Printf("[net] Adding ipv6 snat rule")
 			matchSrcPrefix := fmt.Sprintf("-s %s", ipv6SubnetPrefix.String())
-			if err := networkutils.AddSnatRule(matchSrcPrefix, ipAddr.IP); err != nil {
-				return fmt.Errorf("Adding iptable snat rule failed:%w", err)
-			}
-			ipv6SnatRuleSet = true
+			rules = append(rules, networkutils.SnatRule(matchSrcPrefix, ipAddr.IP))
 		}
 	}
 
-	if !ipv6SnatRuleSet {
-		return errV6SnatRuleNotSet
+	if len(rules) == 0 {
+		return nil, errV6SnatRuleNotSet
 	}
 
-	return nil
+	return rules, nil
+}
+
+// ipv6UnmarkRule unmarks the packets kube-proxy marked, so that they skip the kube-postrouting rule and are
+// processed by the cni snat rule.
+func ipv6UnmarkRule() iptables.IPTableEntry {
+	return iptables.GetInsertIptableRuleCmd(iptables.V6, iptables.Mangle, iptables.Postrouting, "", "MARK --set-mark 0x0")
 }
 
 func getNetworkInfoImpl(nwInfo *NetworkInfo, nw *network) {
@@ -0,0 +1,89 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMTU(t *testing.T) {
+	tests := []struct {
+		name    string
+		nwInfo  *NetworkInfo
+		netio   netio.NetIOInterface
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "Configured mtu",
+			nwInfo: &NetworkInfo{Mode: opModeBridge, MTU: 900},
+			netio:  netio.NewMockNetIO(false, 0),
+			want:   900,
+		},
+		{
+			name:    "Configured mtu above the mtu of the master interface",
+			nwInfo:  &NetworkInfo{Mode: opModeBridge, MTU: 9000},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: true,
+		},
+		{
+			name:    "Configured mtu above the mtu of the master interface less the vxlan overhead",
+			nwInfo:  &NetworkInfo{Mode: opModeTunnel, Vxlan: &VxlanInfo{VNI: 4096}, MTU: 1000},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: true,
+		},
+		{
+			name:    "Configured mtu too low",
+			nwInfo:  &NetworkInfo{Mode: opModeBridge, MTU: 10},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: true,
+		},
+		{
+			name: "Configured mtu too low for ipv6",
+			nwInfo: &NetworkInfo{
+				Mode:    opModeBridge,
+				MTU:     900,
+				Subnets: []SubnetInfo{{Family: platform.AfINET}, {Family: platform.AfINET6}},
+			},
+			netio:   netio.NewMockNetIO(false, 0),
+			wantErr: true,
+		},
+		{
+			name:   "Mtu of the master interface",
+			nwInfo: &NetworkInfo{Mode: opModeTransparent},
+			netio:  netio.NewMockNetIO(false, 0),
+			want:   1000,
+		},
+		{
+			name:   "Mtu of the master interface less the vxlan overhead",
+			nwInfo: &NetworkInfo{Mode: opModeTunnel, Vxlan: &VxlanInfo{VNI: 4096}},
+			netio:  netio.NewMockNetIO(false, 0),
+			want:   1000 - vxlanOverheadIPv4,
+		},
+		{
+			name:    "Get master interface fail",
+			nwInfo:  &NetworkInfo{Mode: opModeBridge},
+			netio:   netio.NewMockNetIO(true, 1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nm := &networkManager{netio: tt.netio}
+			got, err := nm.networkMTU(&externalInterface{Name: "eth0"}, tt.nwInfo)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorNetworkManager)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -32,6 +32,8 @@ const (
 	ipv6DefaultHop = "::"
 	// ipv6 route cmd
 	routeCmd = "netsh interface ipv6 %s route \"%s\" \"%s\" \"%s\" store=persistent"
+	// Pods do not have network namespaces to apply sysctls in.
+	sysctlsSupported = false
 )
 
 // Windows implementation of route.
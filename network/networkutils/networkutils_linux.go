@@ -35,6 +35,7 @@ const (
 	enableIPV6ForwardCmd = "sysctl -w net.ipv6.conf.all.forwarding=1"
 	disableRACmd         = "sysctl -w net.ipv6.conf.%s.accept_ra=0"
 	acceptRAV6File       = "/proc/sys/net/ipv6/conf/%s/accept_ra"
+	setSysctlCmd         = "sysctl -w '%s=%s'"
 )
 
 var errorNetworkUtils = errors.New("NetworkUtils Error")
@@ -85,6 +86,22 @@ func (nu NetworkUtils) CreateEndpoint(hostVethName, containerVethName string) er
 	return nil
 }
 
+// SetEndpointMTU sets the MTU of both ends of a veth pair. An MTU of 0 keeps the default one.
+func (nu NetworkUtils) SetEndpointMTU(hostVethName, containerVethName string, mtu int) error {
+	if mtu <= 0 {
+		return nil
+	}
+
+	for _, name := range []string{hostVethName, containerVethName} {
+		log.Printf("[net] Setting link %v mtu %d.", name, mtu)
+		if err := nu.netlink.SetLinkMTU(name, mtu); err != nil {
+			return newErrorNetworkUtils(err.Error())
+		}
+	}
+
+	return nil
+}
+
 func (nu NetworkUtils) SetupContainerInterface(containerVethName, targetIfName string) error {
 	// Interface needs to be down before renaming.
 	log.Printf("[net] Setting link %v state down.", containerVethName)
@@ -125,69 +142,78 @@ func (nu NetworkUtils) AssignIPToInterface(interfaceName string, ipAddresses []n
 	return nil
 }
 
-func addOrDeleteFilterRule(bridgeName, action, ipAddress, chainName, target string) error {
-	var err error
+// filterRule is a filter rule on the traffic of a bridge to an IP address.
+type filterRule struct {
+	ipAddress string
+	chainName string
+	target    string
+}
+
+func (rule filterRule) match(bridgeName string) string {
 	option := "i"
 
-	if chainName == iptables.Output {
+	if rule.chainName == iptables.Output {
 		option = "o"
 	}
 
-	matchCondition := fmt.Sprintf("-%s %s -d %s", option, bridgeName, ipAddress)
+	return fmt.Sprintf("-%s %s -d %s", option, bridgeName, rule.ipAddress)
+}
 
-	switch action {
-	case iptables.Insert:
-		err = iptables.InsertIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
-	case iptables.Append:
-		err = iptables.AppendIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
-	case iptables.Delete:
-		err = iptables.DeleteIptableRule(iptables.V4, iptables.Filter, chainName, matchCondition, target)
+func (rule filterRule) entry(bridgeName, action string) iptables.IPTableEntry {
+	if action == iptables.Append {
+		return iptables.GetAppendIptableRuleCmd(iptables.V4, iptables.Filter, rule.chainName, rule.match(bridgeName), rule.target)
 	}
 
-	return err
+	return iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, rule.chainName, rule.match(bridgeName), rule.target)
 }
 
-func AllowIPAddresses(bridgeName string, skipAddresses []string, action string) error {
+func allowFilterRules(skipAddresses []string) []filterRule {
 	chains := getFilterChains()
 	target := getFilterchainTarget()
 
-	log.Printf("[net] Addresses to allow %v", skipAddresses)
-
+	var rules []filterRule
 	for _, address := range skipAddresses {
-		if err := addOrDeleteFilterRule(bridgeName, action, address, chains[0], target[0]); err != nil {
-			return err
-		}
-
-		if err := addOrDeleteFilterRule(bridgeName, action, address, chains[1], target[0]); err != nil {
-			return err
+		for _, chain := range chains {
+			rules = append(rules, filterRule{ipAddress: address, chainName: chain, target: target[0]})
 		}
-
-		if err := addOrDeleteFilterRule(bridgeName, action, address, chains[2], target[0]); err != nil {
-			return err
-		}
-
 	}
 
-	return nil
+	return rules
 }
 
-func BlockIPAddresses(bridgeName, action string) error {
-	privateIPAddresses := getPrivateIPSpace()
+func blockFilterRules() []filterRule {
 	chains := getFilterChains()
 	target := getFilterchainTarget()
 
-	log.Printf("[net] Addresses to block %v", privateIPAddresses)
-
-	for _, ipAddress := range privateIPAddresses {
-		if err := addOrDeleteFilterRule(bridgeName, action, ipAddress, chains[0], target[1]); err != nil {
-			return err
+	var rules []filterRule
+	for _, ipAddress := range getPrivateIPSpace() {
+		for _, chain := range chains {
+			rules = append(rules, filterRule{ipAddress: ipAddress, chainName: chain, target: target[1]})
 		}
+	}
 
-		if err := addOrDeleteFilterRule(bridgeName, action, ipAddress, chains[1], target[1]); err != nil {
-			return err
+	return rules
+}
+
+func filterRuleEntries(bridgeName, action string, rules []filterRule) []iptables.IPTableEntry {
+	entries := make([]iptables.IPTableEntry, 0, len(rules))
+	for _, rule := range rules {
+		entries = append(entries, rule.entry(bridgeName, action))
+	}
+
+	return entries
+}
+
+func addOrDeleteFilterRules(bridgeName, action string, rules []filterRule) error {
+	for _, rule := range rules {
+		var err error
+		if action == iptables.Delete {
+			err = iptables.DeleteIptableRule(iptables.V4, iptables.Filter, rule.chainName, rule.match(bridgeName), rule.target)
+		} else {
+			err = iptables.EnsureEntry(rule.entry(bridgeName, action))
 		}
 
-		if err := addOrDeleteFilterRule(bridgeName, action, ipAddress, chains[2], target[1]); err != nil {
+		if err != nil {
 			return err
 		}
 	}
@@ -195,6 +221,28 @@ func BlockIPAddresses(bridgeName, action string) error {
 	return nil
 }
 
+// AllowIPAddressesRules returns the iptables entries AllowIPAddresses programs with an insert or append action.
+func AllowIPAddressesRules(bridgeName string, skipAddresses []string, action string) []iptables.IPTableEntry {
+	return filterRuleEntries(bridgeName, action, allowFilterRules(skipAddresses))
+}
+
+func AllowIPAddresses(bridgeName string, skipAddresses []string, action string) error {
+	log.Printf("[net] Addresses to allow %v", skipAddresses)
+
+	return addOrDeleteFilterRules(bridgeName, action, allowFilterRules(skipAddresses))
+}
+
+// BlockIPAddressesRules returns the iptables entries BlockIPAddresses programs with an insert or append action.
+func BlockIPAddressesRules(bridgeName, action string) []iptables.IPTableEntry {
+	return filterRuleEntries(bridgeName, action, blockFilterRules())
+}
+
+func BlockIPAddresses(bridgeName, action string) error {
+	log.Printf("[net] Addresses to block %v", getPrivateIPSpace())
+
+	return addOrDeleteFilterRules(bridgeName, action, blockFilterRules())
+}
+
 // This fucntion enables ip forwarding in VM and allow forwarding packets from the interface
 func (nu NetworkUtils) EnableIPForwarding(ifName string) error {
 	// Enable ip forwading on linux vm.
@@ -207,7 +255,7 @@ func (nu NetworkUtils) EnableIPForwarding(ifName string) error {
 	}
 
 	// Append a rule in forward chain to allow forwarding from bridge
-	if err := iptables.AppendIptableRule(iptables.V4, iptables.Filter, iptables.Forward, "", iptables.Accept); err != nil {
+	if err := iptables.EnsureEntry(IPForwardingRule()); err != nil {
 		log.Printf("[net] Appending forward chain rule: allow traffic coming from snatbridge failed with: %v", err)
 		return err
 	}
@@ -215,6 +263,11 @@ func (nu NetworkUtils) EnableIPForwarding(ifName string) error {
 	return nil
 }
 
+// IPForwardingRule returns the iptables entry EnableIPForwarding programs to allow forwarding.
+func IPForwardingRule() iptables.IPTableEntry {
+	return iptables.GetAppendIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Forward, "", iptables.Accept)
+}
+
 func (nu NetworkUtils) EnableIPV6Forwarding() error {
 	cmd := fmt.Sprint(enableIPV6ForwardCmd)
 	_, err := nu.plClient.ExecuteCommand(cmd)
@@ -240,13 +293,29 @@ func (nu NetworkUtils) UpdateIPV6Setting(disable int) error {
 
 // This fucntion adds rule which snat to ip passed filtered by match string.
 func AddSnatRule(match string, ip net.IP) error {
+	return iptables.EnsureEntry(SnatRule(match, ip))
+}
+
+// SnatRule returns the iptables entry AddSnatRule programs.
+func SnatRule(match string, ip net.IP) iptables.IPTableEntry {
 	version := iptables.V4
 	if ip.To4() == nil {
 		version = iptables.V6
 	}
 
 	target := fmt.Sprintf("SNAT --to %s", ip.String())
-	return iptables.InsertIptableRule(version, iptables.Nat, iptables.Postrouting, match, target)
+	return iptables.GetInsertIptableRuleCmd(version, iptables.Nat, iptables.Postrouting, match, target)
+}
+
+// SetSysctl sets a sysctl in the current network namespace. The key and value are expected to be validated.
+func (nu NetworkUtils) SetSysctl(key, value string) error {
+	cmd := fmt.Sprintf(setSysctlCmd, key, value)
+	if _, err := nu.plClient.ExecuteCommand(cmd); err != nil {
+		log.Printf("[net] Setting sysctl %s failed with: %v", key, err)
+		return newErrorNetworkUtils(err.Error())
+	}
+
+	return nil
 }
 
 func (nu NetworkUtils) DisableRAForInterface(ifName string) error {
@@ -31,6 +31,7 @@ type OVSEndpointClient struct {
 	allowInboundFromHostToNC bool
 	allowInboundFromNCToHost bool
 	enableSnatForDns         bool
+	mtu                      int
 	netlink                  netlink.NetlinkInterface
 	netioshim                netio.NetIOInterface
 	ovsctlClient             ovsctl.OvsInterface
@@ -65,6 +66,7 @@ func NewOVSEndpointClient(
 		allowInboundFromHostToNC: epInfo.AllowInboundFromHostToNC,
 		allowInboundFromNCToHost: epInfo.AllowInboundFromNCToHost,
 		enableSnatForDns:         epInfo.EnableSnatForDns,
+		mtu:                      nw.MTU,
 		netlink:                  nl,
 		ovsctlClient:             ovs,
 		plClient:                 plc,
@@ -83,6 +85,10 @@ func (client *OVSEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
 		return err
 	}
 
+	if err := epc.SetEndpointMTU(client.hostVethName, client.containerVethName, client.mtu); err != nil {
+		return err
+	}
+
 	containerIf, err := net.InterfaceByName(client.containerVethName)
 	if err != nil {
 		log.Printf("InterfaceByName returns error for ifname %v with error %v", client.containerVethName, err)
@@ -162,57 +162,77 @@ func getNCLocalAndGatewayIP(client *OVSSnatClient) (net.IP, net.IP) {
 	return bridgeIP, containerIP
 }
 
-/**
-	This function adds iptables rules that allows only host to NC communication and not the other way
-**/
-func (client *OVSSnatClient) AllowInboundFromHostToNC() error {
-	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
+func hostToNCMatch(bridgeIP, containerIP net.IP) string {
+	return fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
+}
 
-	// Create CNI Ouptut chain
-	if err := iptables.CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Creating %v failed with error: %v", iptables.CNIOutputChain, err)
-		return newErrorOVSSnatClient(err.Error())
-	}
+func ncToHostMatch(bridgeIP, containerIP net.IP) string {
+	return fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
+}
 
-	// Forward traffic from Ouptut chain to CNI Output chain
-	if err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting forward rule to %v failed with error: %v", iptables.CNIOutputChain, err)
-		return newErrorOVSSnatClient(err.Error())
-	}
+func establishedFromNCMatch() string {
+	return fmt.Sprintf(" -i %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
+}
 
-	// Allow connection from Host to NC
-	matchCondition := fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
-	err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting output rule failed: %v", err)
-		return newErrorOVSSnatClient(err.Error())
-	}
+func establishedFromHostMatch() string {
+	return fmt.Sprintf(" -o %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
+}
 
-	// Create cniinput chain
-	if err := iptables.CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Creating %v failed with error: %v", iptables.CNIInputChain, err)
-		return newErrorOVSSnatClient(err.Error())
+// SnatBridgeRules returns the iptables entries programmed for the snat bridge by CreateSnatEndpoint,
+// AllowIPAddressesOnSnatBridge and BlockIPAddressesOnSnatBridge, in order.
+func SnatBridgeRules(snatBridgeIP string, skipAddressesFromBlock []string) []iptables.IPTableEntry {
+	rules := []iptables.IPTableEntry{masqueradeRule(snatBridgeIP)}
+	rules = append(rules, networkutils.AllowIPAddressesRules(SnatBridgeName, skipAddressesFromBlock, iptables.Insert)...)
+	return append(rules, networkutils.BlockIPAddressesRules(SnatBridgeName, iptables.Append)...)
+}
+
+// InboundFromHostToNCRules returns the iptables entries programmed by AllowInboundFromHostToNC, in order.
+func InboundFromHostToNCRules(snatBridgeIP, localIP string) []iptables.IPTableEntry {
+	bridgeIP, _, _ := net.ParseCIDR(snatBridgeIP)
+	containerIP, _, _ := net.ParseCIDR(localIP)
+	return []iptables.IPTableEntry{
+		iptables.GetCreateChainCmd(iptables.V4, iptables.Filter, iptables.CNIOutputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.CNIOutputChain, hostToNCMatch(bridgeIP, containerIP), iptables.Accept),
+		iptables.GetCreateChainCmd(iptables.V4, iptables.Filter, iptables.CNIInputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.CNIInputChain, establishedFromNCMatch(), iptables.Accept),
 	}
+}
 
-	// Forward from Input to cniinput chain
-	if err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting forward rule to %v failed with error: %v", iptables.CNIInputChain, err)
-		return newErrorOVSSnatClient(err.Error())
+// InboundFromNCToHostRules returns the iptables entries programmed by AllowInboundFromNCToHost, in order.
+func InboundFromNCToHostRules(snatBridgeIP, localIP string) []iptables.IPTableEntry {
+	bridgeIP, _, _ := net.ParseCIDR(snatBridgeIP)
+	containerIP, _, _ := net.ParseCIDR(localIP)
+	return []iptables.IPTableEntry{
+		iptables.GetCreateChainCmd(iptables.V4, iptables.Filter, iptables.CNIInputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.CNIInputChain, ncToHostMatch(bridgeIP, containerIP), iptables.Accept),
+		iptables.GetCreateChainCmd(iptables.V4, iptables.Filter, iptables.CNIOutputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain),
+		iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Filter, iptables.CNIOutputChain, establishedFromHostMatch(), iptables.Accept),
 	}
+}
 
-	// Accept packets from NC only if established connection
-	matchCondition = fmt.Sprintf(" -i %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
-	err = iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting input rule failed: %v", err)
-		return newErrorOVSSnatClient(err.Error())
+/**
+	This function adds iptables rules that allows only host to NC communication and not the other way
+**/
+func (client *OVSSnatClient) AllowInboundFromHostToNC() error {
+	_, containerIP := getNCLocalAndGatewayIP(client)
+
+	// Allow connection from Host to NC, and accept packets from NC only if established connection
+	for _, rule := range InboundFromHostToNCRules(client.snatBridgeIP, client.localIP) {
+		if err := iptables.EnsureEntry(rule); err != nil {
+			log.Printf("AllowInboundFromHostToNC: Programming %v failed with error: %v", rule.Params, err)
+			return newErrorOVSSnatClient(err.Error())
+		}
 	}
 
 	snatContainerVeth, _ := net.InterfaceByName(client.containerSnatVethName)
 
 	// Add static arp entry for localIP to prevent arp going out of VM
 	log.Printf("Adding static arp entry for ip %s mac %s", containerIP, snatContainerVeth.HardwareAddr.String())
-	err = client.netlink.AddOrRemoveStaticArp(netlink.ADD, SnatBridgeName, containerIP, snatContainerVeth.HardwareAddr, false)
+	err := client.netlink.AddOrRemoveStaticArp(netlink.ADD, SnatBridgeName, containerIP, snatContainerVeth.HardwareAddr, false)
 	if err != nil {
 		log.Printf("AllowInboundFromHostToNC: Error adding static arp entry for ip %s mac %s: %v", containerIP, snatContainerVeth.HardwareAddr.String(), err)
 		return newErrorOVSSnatClient(err.Error())
@@ -225,7 +245,7 @@ func (client *OVSSnatClient) DeleteInboundFromHostToNC() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
 	// Delete allow connection from Host to NC
-	matchCondition := fmt.Sprintf("-s %s -d %s", bridgeIP.String(), containerIP.String())
+	matchCondition := hostToNCMatch(bridgeIP, containerIP)
 	err := iptables.DeleteIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
 	if err != nil {
 		log.Printf("DeleteInboundFromHostToNC: Error removing output rule %v", err)
@@ -245,53 +265,21 @@ func (client *OVSSnatClient) DeleteInboundFromHostToNC() error {
 	This function adds iptables rules that allows only NC to Host communication and not the other way
 **/
 func (client *OVSSnatClient) AllowInboundFromNCToHost() error {
-	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
-
-	// Create CNI Input chain
-	if err := iptables.CreateChain(iptables.V4, iptables.Filter, iptables.CNIInputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Creating %v failed with error: %v", iptables.CNIInputChain, err)
-		return err
-	}
-
-	// Forward traffic from Input to cniinput chain
-	if err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Input, "", iptables.CNIInputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting forward rule to %v failed with error: %v", iptables.CNIInputChain, err)
-		return err
-	}
-
-	// Allow NC to Host connection
-	matchCondition := fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
-	err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting output rule failed: %v", err)
-		return err
-	}
+	_, containerIP := getNCLocalAndGatewayIP(client)
 
-	// Create CNI output chain
-	if err := iptables.CreateChain(iptables.V4, iptables.Filter, iptables.CNIOutputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Creating %v failed with error: %v", iptables.CNIOutputChain, err)
-		return err
-	}
-
-	// Forward traffic from Output to CNI Output chain
-	if err := iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.Output, "", iptables.CNIOutputChain); err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting forward rule to %v failed with error: %v", iptables.CNIOutputChain, err)
-		return err
-	}
-
-	// Accept packets from Host only if established connection
-	matchCondition = fmt.Sprintf(" -o %s -m state --state %s,%s", SnatBridgeName, iptables.Established, iptables.Related)
-	err = iptables.InsertIptableRule(iptables.V4, iptables.Filter, iptables.CNIOutputChain, matchCondition, iptables.Accept)
-	if err != nil {
-		log.Printf("AllowInboundFromHostToNC: Inserting input rule failed: %v", err)
-		return err
+	// Allow NC to Host connection, and accept packets from Host only if established connection
+	for _, rule := range InboundFromNCToHostRules(client.snatBridgeIP, client.localIP) {
+		if err := iptables.EnsureEntry(rule); err != nil {
+			log.Printf("AllowInboundFromNCToHost: Programming %v failed with error: %v", rule.Params, err)
+			return err
+		}
 	}
 
 	snatContainerVeth, _ := net.InterfaceByName(client.containerSnatVethName)
 
 	// Add static arp entry for localIP to prevent arp going out of VM
 	log.Printf("Adding static arp entry for ip %s mac %s", containerIP, snatContainerVeth.HardwareAddr.String())
-	err = client.netlink.AddOrRemoveStaticArp(netlink.ADD, SnatBridgeName, containerIP, snatContainerVeth.HardwareAddr, false)
+	err := client.netlink.AddOrRemoveStaticArp(netlink.ADD, SnatBridgeName, containerIP, snatContainerVeth.HardwareAddr, false)
 	if err != nil {
 		log.Printf("AllowInboundFromNCToHost: Error adding static arp entry for ip %s mac %s: %v", containerIP, snatContainerVeth.HardwareAddr.String(), err)
 	}
@@ -303,7 +291,7 @@ func (client *OVSSnatClient) DeleteInboundFromNCToHost() error {
 	bridgeIP, containerIP := getNCLocalAndGatewayIP(client)
 
 	// Delete allow NC to Host connection
-	matchCondition := fmt.Sprintf("-s %s -d %s", containerIP.String(), bridgeIP.String())
+	matchCondition := ncToHostMatch(bridgeIP, containerIP)
 	err := iptables.DeleteIptableRule(iptables.V4, iptables.Filter, iptables.CNIInputChain, matchCondition, iptables.Accept)
 	if err != nil {
 		log.Printf("DeleteInboundFromNCToHost: Error removing output rule %v", err)
@@ -473,9 +461,13 @@ func (client *OVSSnatClient) createSnatBridge(snatBridgeIP string, hostPrimaryMa
 	This function adds iptable rules that will snat all traffic that has source ip in apipa range and coming via linux bridge
 **/
 func (client *OVSSnatClient) addMasqueradeRule(snatBridgeIPWithPrefix string) error {
+	return iptables.EnsureEntry(masqueradeRule(snatBridgeIPWithPrefix))
+}
+
+func masqueradeRule(snatBridgeIPWithPrefix string) iptables.IPTableEntry {
 	_, ipNet, _ := net.ParseCIDR(snatBridgeIPWithPrefix)
 	matchCondition := fmt.Sprintf("-s %s", ipNet.String())
-	return iptables.InsertIptableRule(iptables.V4, iptables.Nat, iptables.Postrouting, matchCondition, iptables.Masquerade)
+	return iptables.GetInsertIptableRuleCmd(iptables.V4, iptables.Nat, iptables.Postrouting, matchCondition, iptables.Masquerade)
 }
 
 /**
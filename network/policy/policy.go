@@ -2,6 +2,8 @@ package policy
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 )
 
 const (
@@ -12,6 +14,7 @@ const (
 	PortMappingPolicy CNIPolicyType = "NAT"
 	ACLPolicy         CNIPolicyType = "ACL"
 	L4WFPProxyPolicy  CNIPolicyType = "L4WFPPROXY"
+	EgressSNATPolicy  CNIPolicyType = "EgressSNAT"
 )
 
 type CNIPolicyType string
@@ -26,3 +29,42 @@ type NATInfo struct {
 	Destinations []string
 	VirtualIP    string
 }
+
+// EgressSNATPolicySetting is the data of an EgressSNAT policy, the source IP the egress traffic of the endpoint is
+// SNATed to.
+type EgressSNATPolicySetting struct {
+	SourceIP string
+}
+
+// NewEgressSNATPolicy returns an EgressSNAT policy SNATing the egress traffic of the endpoint to sourceIP.
+func NewEgressSNATPolicy(sourceIP net.IP) (Policy, error) {
+	data, err := json.Marshal(EgressSNATPolicySetting{SourceIP: sourceIP.String()})
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to marshal EgressSNAT policy: %w", err)
+	}
+
+	return Policy{Type: EgressSNATPolicy, Data: data}, nil
+}
+
+// GetEgressSNATSourceIP returns the source IP of the EgressSNAT policy, nil if there is none.
+func GetEgressSNATSourceIP(policies []Policy) (net.IP, error) {
+	for _, policy := range policies {
+		if policy.Type != EgressSNATPolicy {
+			continue
+		}
+
+		var setting EgressSNATPolicySetting
+		if err := json.Unmarshal(policy.Data, &setting); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal EgressSNAT policy: %w", err)
+		}
+
+		sourceIP := net.ParseIP(setting.SourceIP)
+		if sourceIP == nil {
+			return nil, fmt.Errorf("invalid EgressSNAT source IP %q", setting.SourceIP)
+		}
+
+		return sourceIP, nil
+	}
+
+	return nil, nil
+}
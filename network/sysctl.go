@@ -0,0 +1,75 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sysctls which pods may set in their network namespace: the network sysctls of the Kubernetes safe set, which are
+// namespaced and cannot affect the node or the other pods, and the explicitly named ones below. The per-interface
+// net.ipv4.conf and net.ipv6.conf sysctls are not allowed, as they may undo the configuration of the interfaces done
+// by the plugin.
+var allowedSysctls = map[string]struct{}{
+	// Kubernetes safe set
+	"net.ipv4.ip_local_port_range":        {},
+	"net.ipv4.ip_local_reserved_ports":    {},
+	"net.ipv4.ip_unprivileged_port_start": {},
+	"net.ipv4.ping_group_range":           {},
+	"net.ipv4.tcp_fin_timeout":            {},
+	"net.ipv4.tcp_keepalive_intvl":        {},
+	"net.ipv4.tcp_keepalive_probes":       {},
+	"net.ipv4.tcp_keepalive_time":         {},
+	"net.ipv4.tcp_syncookies":             {},
+	// explicitly allowed
+	"net.core.somaxconn": {},
+}
+
+var (
+	errSysctlNotAllowed    = errors.New("sysctls not allowed")
+	errSysctlsNotSupported = errors.New("sysctls are not supported on this platform")
+	sysctlValueRegex       = regexp.MustCompile(`^[a-zA-Z0-9_.,:\- ]+$`)
+)
+
+func isAllowedSysctl(key, value string) bool {
+	_, allowed := allowedSysctls[key]
+	return allowed && sysctlValueRegex.MatchString(value)
+}
+
+// ValidateSysctls returns an error listing the sysctls which are not allowed in pod network namespaces, or
+// whose values are not valid. Any sysctl is rejected on the platforms which do not support them.
+func ValidateSysctls(sysctls map[string]string) error {
+	if len(sysctls) > 0 && !sysctlsSupported {
+		return errSysctlsNotSupported
+	}
+
+	var rejected []string
+	for key, value := range sysctls {
+		if !isAllowedSysctl(key, value) {
+			rejected = append(rejected, key)
+		}
+	}
+
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		return fmt.Errorf("%w: %s", errSysctlNotAllowed, strings.Join(rejected, ", "))
+	}
+
+	return nil
+}
+
+// sortedSysctlKeys returns the keys of the sysctls in the order they are applied.
+func sortedSysctlKeys(sysctls map[string]string) []string {
+	keys := make([]string, 0, len(sysctls))
+	for key := range sysctls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSysctls(t *testing.T) {
+	tests := []struct {
+		name    string
+		sysctls map[string]string
+		wantErr string
+	}{
+		{
+			name: "Allowed sysctls",
+			sysctls: map[string]string{
+				"net.core.somaxconn":                  "4096",
+				"net.ipv4.tcp_keepalive_time":         "600",
+				"net.ipv4.tcp_syncookies":             "1",
+				"net.ipv4.ip_local_port_range":        "1024 65000",
+				"net.ipv4.ip_unprivileged_port_start": "0",
+				"net.ipv4.ip_local_reserved_ports":    "8080,9090-9095",
+				"net.ipv4.ping_group_range":           "0 2147483647",
+			},
+		},
+		{
+			name: "Host sysctls",
+			sysctls: map[string]string{
+				"net.ipv4.ip_forward":    "1",
+				"kernel.shm_rmid_forced": "1",
+				"net.core.somaxconn":     "4096",
+			},
+			wantErr: "kernel.shm_rmid_forced, net.ipv4.ip_forward",
+		},
+		{
+			name: "Interface and unlisted sysctls",
+			sysctls: map[string]string{
+				"net.ipv4.conf.eth0.rp_filter":   "2",
+				"net.ipv6.conf.all.disable_ipv6": "1",
+				"net.ipv4.tcp_rmem":              "4096 87380 6291456",
+			},
+			wantErr: "net.ipv4.conf.eth0.rp_filter, net.ipv4.tcp_rmem, net.ipv6.conf.all.disable_ipv6",
+		},
+		{
+			name: "Invalid keys and values",
+			sysctls: map[string]string{
+				"net/ipv4/tcp_syncookies": "1",
+				"net.ipv4.tcp_syncookies": "1; reboot",
+				"net.core.somaxconn":      "'4096'",
+			},
+			wantErr: "net.core.somaxconn, net.ipv4.tcp_syncookies, net/ipv4/tcp_syncookies",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSysctls(tt.sysctls)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, errSysctlNotAllowed)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
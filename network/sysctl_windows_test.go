@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSysctlsNotSupported(t *testing.T) {
+	require.NoError(t, ValidateSysctls(nil))
+	require.ErrorIs(t, ValidateSysctls(map[string]string{"net.core.somaxconn": "4096"}), errSysctlsNotSupported)
+}
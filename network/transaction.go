@@ -0,0 +1,88 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package network
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/tracing"
+)
+
+// transaction runs the steps of a setup in order and keeps an undo log of them. When a step fails, rollback
+// undoes the steps in reverse order, so that a failed setup leaves nothing behind for the next one to trip over.
+type transaction struct {
+	ctx     context.Context
+	name    string
+	undoLog []undoEntry
+	timings []stepTiming
+}
+
+type undoEntry struct {
+	step string
+	undo func() error
+}
+
+// stepTiming records how long a step of a transaction took.
+type stepTiming struct {
+	step     string
+	duration time.Duration
+	err      error
+}
+
+func newTransaction(ctx context.Context, name string) *transaction {
+	return &transaction{
+		ctx:  ctx,
+		name: name,
+	}
+}
+
+// do runs a step in a span and records its undo function, which may be nil for steps with nothing to undo.
+// The undo function is recorded before the step runs, it must be best effort so that it also cleans up after
+// the step if it fails part-way.
+func (tx *transaction) do(step string, f func() error, undo func() error) error {
+	if undo != nil {
+		tx.undoLog = append(tx.undoLog, undoEntry{step: step, undo: undo})
+	}
+
+	start := time.Now()
+	err := tracing.Trace(tx.ctx, "network."+step, f)
+	duration := time.Since(start)
+
+	tx.timings = append(tx.timings, stepTiming{step: step, duration: duration, err: err})
+	log.Printf("[net] %s step %s took %v, err:%v.", tx.name, step, duration, err)
+
+	return err
+}
+
+// rollback undoes the steps in reverse order. A failure to undo a step is logged and the remaining steps are
+// still undone.
+func (tx *transaction) rollback() {
+	for i := len(tx.undoLog) - 1; i >= 0; i-- {
+		entry := tx.undoLog[i]
+		log.Printf("[net] Rolling back %s step %s.", tx.name, entry.step)
+		if err := entry.undo(); err != nil {
+			log.Printf("[net] Failed to roll back %s step %s, err:%v.", tx.name, entry.step, err)
+		}
+	}
+
+	tx.undoLog = nil
+}
+
+// commit drops the undo log once all the steps succeeded.
+func (tx *transaction) commit() {
+	tx.undoLog = nil
+	log.Printf("[net] %s completed in %v.", tx.name, tx.duration())
+}
+
+// duration returns the total duration of the steps.
+func (tx *transaction) duration() time.Duration {
+	var total time.Duration
+	for _, timing := range tx.timings {
+		total += timing.duration
+	}
+
+	return total
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errStep = errors.New("step fail")
+	errUndo = errors.New("undo fail")
+)
+
+func TestTransactionRollback(t *testing.T) {
+	var calls []string
+	step := func(name string, err error) func() error {
+		return func() error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	tx := newTransaction(context.Background(), "test")
+	require.NoError(t, tx.do("first", step("first", nil), step("undo first", nil)))
+	require.NoError(t, tx.do("second", step("second", nil), nil))
+	require.NoError(t, tx.do("third", step("third", nil), step("undo third", errUndo)))
+	require.ErrorIs(t, tx.do("fourth", step("fourth", errStep), step("undo fourth", nil)), errStep)
+
+	tx.rollback()
+	// The failed step is undone too, a failure to undo a step doesn't stop the rollback.
+	require.Equal(t, []string{"first", "second", "third", "fourth", "undo fourth", "undo third", "undo first"}, calls)
+
+	require.Len(t, tx.timings, 4)
+	require.Equal(t, "fourth", tx.timings[3].step)
+	require.ErrorIs(t, tx.timings[3].err, errStep)
+
+	// The undo log is emptied by the rollback.
+	calls = nil
+	tx.rollback()
+	require.Empty(t, calls)
+}
+
+func TestTransactionCommit(t *testing.T) {
+	undone := false
+	tx := newTransaction(context.Background(), "test")
+	require.NoError(t, tx.do("step", func() error { return nil }, func() error {
+		undone = true
+		return nil
+	}))
+
+	tx.commit()
+	tx.rollback()
+	require.False(t, undone)
+	require.Len(t, tx.timings, 1)
+}
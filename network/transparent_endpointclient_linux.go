@@ -39,6 +39,7 @@ type TransparentEndpointClient struct {
 	containerMac      net.HardwareAddr
 	hostVethMac       net.HardwareAddr
 	mode              string
+	mtu               int
 	netlink           netlink.NetlinkInterface
 	netioshim         netio.NetIOInterface
 	plClient          platform.ExecClient
@@ -50,6 +51,7 @@ func NewTransparentEndpointClient(
 	hostVethName string,
 	containerVethName string,
 	mode string,
+	mtu int,
 	nl netlink.NetlinkInterface,
 	plc platform.ExecClient,
 ) *TransparentEndpointClient {
@@ -61,6 +63,7 @@ func NewTransparentEndpointClient(
 		containerVethName: containerVethName,
 		hostPrimaryMac:    extIf.MacAddress,
 		mode:              mode,
+		mtu:               mtu,
 		netlink:           nl,
 		netioshim:         &netio.NetIO{},
 		plClient:          plc,
@@ -116,12 +119,18 @@ func (client *TransparentEndpointClient) AddEndpoints(epInfo *EndpointInfo) erro
 
 	client.hostVethMac = hostVethIf.HardwareAddr
 
-	log.Printf("Setting mtu %d on veth interface %s", primaryIf.MTU, client.hostVethName)
-	if err := client.netlink.SetLinkMTU(client.hostVethName, primaryIf.MTU); err != nil {
+	// Networks created before the MTU was stored use the one of the primary interface.
+	mtu := client.mtu
+	if mtu == 0 {
+		mtu = primaryIf.MTU
+	}
+
+	log.Printf("Setting mtu %d on veth interface %s", mtu, client.hostVethName)
+	if err := client.netlink.SetLinkMTU(client.hostVethName, mtu); err != nil {
 		log.Errorf("Setting mtu failed for hostveth %s:%v", client.hostVethName, err)
 	}
 
-	if err := client.netlink.SetLinkMTU(client.containerVethName, primaryIf.MTU); err != nil {
+	if err := client.netlink.SetLinkMTU(client.containerVethName, mtu); err != nil {
 		log.Errorf("Setting mtu failed for containerveth %s:%v", client.containerVethName, err)
 	}
 
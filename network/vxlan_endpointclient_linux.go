@@ -0,0 +1,132 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+)
+
+var errorVxlanEndpointClient = errors.New("VxlanEndpointClient Error")
+
+func newErrorVxlanEndpointClient(errStr string) error {
+	return fmt.Errorf("%w : %s", errorVxlanEndpointClient, errStr)
+}
+
+// VxlanEndpointClient connects the endpoints of a tunnel mode network to its bridge. The pods reach the
+// other nodes through the VXLAN interface on the bridge, so unlike in bridge mode no ebtables rule is needed,
+// and the veth MTU leaves room for the encapsulation.
+type VxlanEndpointClient struct {
+	bridgeName        string
+	vxlanName         string
+	hostVethName      string
+	containerVethName string
+	containerMac      net.HardwareAddr
+	netlink           netlink.NetlinkInterface
+	plClient          platform.ExecClient
+	netioshim         netio.NetIOInterface
+	nuc               networkutils.NetworkUtils
+}
+
+func NewVxlanEndpointClient(
+	nw *network,
+	hostVethName string,
+	containerVethName string,
+	nl netlink.NetlinkInterface,
+	plc platform.ExecClient,
+) *VxlanEndpointClient {
+	return &VxlanEndpointClient{
+		bridgeName:        nw.extIf.BridgeName,
+		vxlanName:         vxlanInterfaceName(nw.Vxlan.VNI),
+		hostVethName:      hostVethName,
+		containerVethName: containerVethName,
+		netlink:           nl,
+		plClient:          plc,
+		netioshim:         &netio.NetIO{},
+		nuc:               networkutils.NewNetworkUtils(nl, plc),
+	}
+}
+
+func (client *VxlanEndpointClient) AddEndpoints(epInfo *EndpointInfo) error {
+	if err := client.nuc.CreateEndpoint(client.hostVethName, client.containerVethName); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	// The veth MTU is the one of the VXLAN interface, which leaves room for the encapsulation.
+	vxlanIf, err := client.netioshim.GetNetworkInterfaceByName(client.vxlanName)
+	if err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	for _, name := range []string{client.hostVethName, client.containerVethName} {
+		log.Printf("[net] Setting link %v mtu %d.", name, vxlanIf.MTU)
+		if err = client.netlink.SetLinkMTU(name, vxlanIf.MTU); err != nil {
+			return newErrorVxlanEndpointClient(err.Error())
+		}
+	}
+
+	containerIf, err := client.netioshim.GetNetworkInterfaceByName(client.containerVethName)
+	if err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	client.containerMac = containerIf.HardwareAddr
+	return nil
+}
+
+func (client *VxlanEndpointClient) AddEndpointRules(epInfo *EndpointInfo) error {
+	log.Printf("[net] Setting link %v master %v.", client.hostVethName, client.bridgeName)
+	if err := client.netlink.SetLinkMaster(client.hostVethName, client.bridgeName); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+func (client *VxlanEndpointClient) DeleteEndpointRules(ep *endpoint) {}
+
+func (client *VxlanEndpointClient) MoveEndpointsToContainerNS(epInfo *EndpointInfo, nsID uintptr) error {
+	log.Printf("[net] Setting link %v netns %v.", client.containerVethName, epInfo.NetNsPath)
+	if err := client.netlink.SetLinkNetNs(client.containerVethName, nsID); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+func (client *VxlanEndpointClient) SetupContainerInterfaces(epInfo *EndpointInfo) error {
+	if err := client.nuc.SetupContainerInterface(client.containerVethName, epInfo.IfName); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	client.containerVethName = epInfo.IfName
+
+	return nil
+}
+
+func (client *VxlanEndpointClient) ConfigureContainerInterfacesAndRoutes(epInfo *EndpointInfo) error {
+	if err := client.nuc.AssignIPToInterface(client.containerVethName, epInfo.IPAddresses); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	if err := addRoutes(client.netlink, client.netioshim, client.containerVethName, epInfo.Routes); err != nil {
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
+
+func (client *VxlanEndpointClient) DeleteEndpoints(ep *endpoint) error {
+	log.Printf("[net] Deleting veth pair %v %v.", ep.HostIfName, ep.IfName)
+	if err := client.netlink.DeleteLink(ep.HostIfName); err != nil {
+		log.Printf("[net] Failed to delete veth pair %v: %v.", ep.HostIfName, err)
+		return newErrorVxlanEndpointClient(err.Error())
+	}
+
+	return nil
+}
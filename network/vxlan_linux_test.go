@@ -0,0 +1,176 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVxlanMTU(t *testing.T) {
+	require.Equal(t, 1450, vxlanMTU(1500, net.ParseIP("10.0.0.4")))
+	require.Equal(t, 1450, vxlanMTU(1500, nil))
+	require.Equal(t, 1430, vxlanMTU(1500, net.ParseIP("fd00::4")))
+}
+
+func TestReadVxlanPeers(t *testing.T) {
+	peersFile := filepath.Join(t.TempDir(), "peers.json")
+	require.NoError(t, os.WriteFile(peersFile, []byte(`["10.0.0.5", "10.0.0.6"]`), 0o600))
+
+	peers, err := readVxlanPeers(VxlanInfo{Peers: []string{"10.0.0.4"}, PeersFile: peersFile}, []string{"10.0.0.7"})
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("10.0.0.4"), net.ParseIP("10.0.0.7"), net.ParseIP("10.0.0.5"), net.ParseIP("10.0.0.6")}, peers)
+
+	_, err = readVxlanPeers(VxlanInfo{Peers: []string{"not an ip"}}, nil)
+	require.ErrorIs(t, err, errorVxlanNetworkClient)
+
+	_, err = readVxlanPeers(VxlanInfo{}, []string{"not an ip"})
+	require.ErrorIs(t, err, errorVxlanNetworkClient)
+
+	require.NoError(t, os.WriteFile(peersFile, []byte(`{}`), 0o600))
+	_, err = readVxlanPeers(VxlanInfo{PeersFile: peersFile}, nil)
+	require.ErrorIs(t, err, errorVxlanNetworkClient)
+
+	_, err = readVxlanPeers(VxlanInfo{PeersFile: filepath.Join(t.TempDir(), "missing.json")}, nil)
+	require.ErrorIs(t, err, errorVxlanNetworkClient)
+}
+
+func TestVxlanSyncPeers(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+
+	tests := []struct {
+		name       string
+		peers      []string
+		cnsPeers   []string
+		programmed []string
+		netlink    netlink.NetlinkInterface
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:  "Program new peers",
+			peers: []string{"10.0.0.5", "10.0.0.5"},
+			want:  []string{"10.0.0.5"},
+		},
+		{
+			name:     "Program the peers from CNS",
+			peers:    []string{"10.0.0.5"},
+			cnsPeers: []string{"10.0.0.5", "10.0.0.8"},
+			want:     []string{"10.0.0.5", "10.0.0.8"},
+		},
+		{
+			name:       "Remove the peers CNS no longer lists",
+			cnsPeers:   []string{"10.0.0.8"},
+			programmed: []string{"10.0.0.8", "10.0.0.9"},
+			want:       []string{"10.0.0.8"},
+		},
+		{
+			name:       "Keep programmed peers and remove the ones gone",
+			peers:      []string{"10.0.0.5"},
+			programmed: []string{"10.0.0.5", "10.0.0.6"},
+			want:       []string{"10.0.0.5"},
+		},
+		{
+			name:       "Netlink fail keeps the programmed peers",
+			peers:      []string{"10.0.0.5", "10.0.0.7"},
+			programmed: []string{"10.0.0.5"},
+			netlink:    netlink.NewMockNetlink(true, "netlink fail"),
+			want:       []string{"10.0.0.5"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewVxlanNetworkClient("azure0", "eth0", VxlanInfo{VNI: 4096, Peers: tt.peers}, 0, nl, plc)
+			client.netioshim = netio.NewMockNetIO(false, 0)
+			if tt.netlink != nil {
+				client.netlink = tt.netlink
+			}
+
+			got, err := client.SyncPeers(tt.programmed, tt.cnsPeers)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorVxlanNetworkClient)
+			} else {
+				require.NoError(t, err)
+			}
+			require.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestVxlanAddEndpoints(t *testing.T) {
+	nl := netlink.NewMockNetlink(false, "")
+	plc := platform.NewMockExecClient(false)
+
+	tests := []struct {
+		name    string
+		client  *VxlanEndpointClient
+		wantErr bool
+	}{
+		{
+			name: "Add endpoints",
+			client: &VxlanEndpointClient{
+				bridgeName:        "azure0",
+				vxlanName:         vxlanInterfaceName(4096),
+				hostVethName:      "azvhost",
+				containerVethName: "azvcontainer",
+				netlink:           netlink.NewMockNetlink(false, ""),
+				plClient:          platform.NewMockExecClient(false),
+				nuc:               networkutils.NewNetworkUtils(nl, plc),
+				netioshim:         netio.NewMockNetIO(false, 0),
+			},
+		},
+		{
+			name: "Add endpoints netlink fail",
+			client: &VxlanEndpointClient{
+				bridgeName:        "azure0",
+				vxlanName:         vxlanInterfaceName(4096),
+				hostVethName:      "azvhost",
+				containerVethName: "azvcontainer",
+				netlink:           netlink.NewMockNetlink(true, "netlink fail"),
+				plClient:          platform.NewMockExecClient(false),
+				nuc:               networkutils.NewNetworkUtils(nl, plc),
+				netioshim:         netio.NewMockNetIO(false, 0),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Add endpoints get vxlan interface fail",
+			client: &VxlanEndpointClient{
+				bridgeName:        "azure0",
+				vxlanName:         vxlanInterfaceName(4096),
+				hostVethName:      "azvhost",
+				containerVethName: "azvcontainer",
+				netlink:           netlink.NewMockNetlink(false, ""),
+				plClient:          platform.NewMockExecClient(false),
+				nuc:               networkutils.NewNetworkUtils(nl, plc),
+				netioshim:         netio.NewMockNetIO(true, 1),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.AddEndpoints(&EndpointInfo{})
+			if tt.wantErr {
+				require.ErrorIs(t, err, errorVxlanEndpointClient)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
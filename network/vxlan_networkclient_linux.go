@@ -0,0 +1,261 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/netio"
+	"github.com/Azure/azure-container-networking/netlink"
+	"github.com/Azure/azure-container-networking/network/networkutils"
+	"github.com/Azure/azure-container-networking/platform"
+)
+
+const (
+	// Prefix for VXLAN interface names.
+	vxlanPrefix = commonInterfacePrefix + "vx"
+	// IANA assigned VXLAN UDP port.
+	defaultVxlanPort = 4789
+	// Outer ethernet, IP, UDP and VXLAN headers added to the frames by the encapsulation.
+	vxlanOverheadIPv4 = 50
+	vxlanOverheadIPv6 = 70
+)
+
+var errorVxlanNetworkClient = errors.New("VxlanNetworkClient Error")
+
+func newErrorVxlanNetworkClient(errStr string) error {
+	return fmt.Errorf("%w : %s", errorVxlanNetworkClient, errStr)
+}
+
+// vxlanFloodMac is the FDB entry MAC address which floods the broadcast and unknown unicast frames to a peer.
+var vxlanFloodMac = net.HardwareAddr{0, 0, 0, 0, 0, 0}
+
+// VxlanNetworkClient sets up the bridge of a tunnel mode network with a VXLAN interface to the peer nodes.
+type VxlanNetworkClient struct {
+	bridgeName        string
+	vxlanName         string
+	hostInterfaceName string
+	vxlanInfo         VxlanInfo
+	mtu               int
+	netlink           netlink.NetlinkInterface
+	netioshim         netio.NetIOInterface
+	nuClient          networkutils.NetworkUtils
+}
+
+func NewVxlanNetworkClient(
+	bridgeName string,
+	hostInterfaceName string,
+	vxlanInfo VxlanInfo,
+	mtu int,
+	nl netlink.NetlinkInterface,
+	plc platform.ExecClient,
+) *VxlanNetworkClient {
+	return &VxlanNetworkClient{
+		bridgeName:        bridgeName,
+		vxlanName:         vxlanInterfaceName(vxlanInfo.VNI),
+		hostInterfaceName: hostInterfaceName,
+		vxlanInfo:         vxlanInfo,
+		mtu:               mtu,
+		netlink:           nl,
+		netioshim:         &netio.NetIO{},
+		nuClient:          networkutils.NewNetworkUtils(nl, plc),
+	}
+}
+
+func vxlanInterfaceName(vni int) string {
+	return fmt.Sprintf("%s%d", vxlanPrefix, vni)
+}
+
+// vxlanMTU returns the MTU of the interfaces of the overlay, which leaves room for the encapsulation in the
+// MTU of the underlay interface.
+func vxlanMTU(underlayMTU int, localIP net.IP) int {
+	if localIP != nil && localIP.To4() == nil {
+		return underlayMTU - vxlanOverheadIPv6
+	}
+	return underlayMTU - vxlanOverheadIPv4
+}
+
+// underlay returns the host interface the tunnel goes through and its IP address, nil if it has none.
+func (client *VxlanNetworkClient) underlay() (*net.Interface, net.IP, error) {
+	hostIf, err := client.netioshim.GetNetworkInterfaceByName(client.hostInterfaceName)
+	if err != nil {
+		return nil, nil, newErrorVxlanNetworkClient(err.Error())
+	}
+
+	addrs, err := client.netioshim.GetNetworkInterfaceAddrs(hostIf)
+	if err != nil {
+		return nil, nil, newErrorVxlanNetworkClient(err.Error())
+	}
+
+	return hostIf, underlayIP(addrs), nil
+}
+
+// underlayIP returns the address the tunnel goes through among those of the underlay interface, preferring
+// IPv4, nil if it has none.
+func underlayIP(addrs []net.Addr) net.IP {
+	var localIP net.IP
+	for _, addr := range addrs {
+		ipAddr, _, err := net.ParseCIDR(addr.String())
+		if err != nil || !ipAddr.IsGlobalUnicast() {
+			continue
+		}
+		if localIP == nil || localIP.To4() == nil && ipAddr.To4() != nil {
+			localIP = ipAddr
+		}
+	}
+
+	return localIP
+}
+
+// CreateTunnel creates the bridge of the network and the VXLAN interface attached to it.
+func (client *VxlanNetworkClient) CreateTunnel() error {
+	hostIf, localIP, err := client.underlay()
+	if err != nil {
+		return err
+	}
+
+	if _, err = client.netioshim.GetNetworkInterfaceByName(client.bridgeName); err != nil {
+		log.Printf("[net] Creating bridge %v.", client.bridgeName)
+		if err = client.netlink.AddLink(&netlink.BridgeLink{
+			LinkInfo: netlink.LinkInfo{
+				Type: netlink.LINK_TYPE_BRIDGE,
+				Name: client.bridgeName,
+			},
+		}); err != nil {
+			return newErrorVxlanNetworkClient(err.Error())
+		}
+
+		if err = client.nuClient.DisableRAForInterface(client.bridgeName); err != nil {
+			return newErrorVxlanNetworkClient(err.Error())
+		}
+	}
+
+	port := client.vxlanInfo.Port
+	if port == 0 {
+		port = defaultVxlanPort
+	}
+
+	mtu := client.mtu
+	if mtu == 0 {
+		mtu = vxlanMTU(hostIf.MTU, localIP)
+	}
+	log.Printf("[net] Creating vxlan %v VNI %d local %v port %d mtu %d.", client.vxlanName, client.vxlanInfo.VNI, localIP, port, mtu)
+	if err = client.netlink.AddLink(&netlink.VXLANLink{
+		LinkInfo: netlink.LinkInfo{
+			Type: netlink.LINK_TYPE_VXLAN,
+			Name: client.vxlanName,
+			MTU:  uint(mtu),
+		},
+		VNI:           uint32(client.vxlanInfo.VNI),
+		UnderlayIndex: hostIf.Index,
+		LocalIP:       localIP,
+		Port:          uint16(port),
+		Learning:      true,
+	}); err != nil {
+		return newErrorVxlanNetworkClient(err.Error())
+	}
+
+	log.Printf("[net] Setting link %v master %v.", client.vxlanName, client.bridgeName)
+	if err = client.netlink.SetLinkMaster(client.vxlanName, client.bridgeName); err != nil {
+		return newErrorVxlanNetworkClient(err.Error())
+	}
+
+	for _, name := range []string{client.vxlanName, client.bridgeName} {
+		log.Printf("[net] Setting link %v state up.", name)
+		if err = client.netlink.SetLinkState(name, true); err != nil {
+			return newErrorVxlanNetworkClient(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// DeleteTunnel deletes the VXLAN interface and the bridge of the network.
+func (client *VxlanNetworkClient) DeleteTunnel() {
+	if err := client.netlink.DeleteLink(client.vxlanName); err != nil {
+		log.Printf("[net] Failed to delete vxlan %v, err:%v.", client.vxlanName, err)
+	}
+
+	if err := client.netlink.DeleteLink(client.bridgeName); err != nil {
+		log.Printf("[net] Failed to delete bridge %v, err:%v.", client.bridgeName, err)
+	}
+}
+
+// SyncPeers programs the flood entries of the peer nodes on the VXLAN interface, the configured ones and cnsPeers,
+// removing those of the programmed peers which are no longer listed. It returns the peers which are programmed.
+func (client *VxlanNetworkClient) SyncPeers(programmed, cnsPeers []string) ([]string, error) {
+	_, localIP, err := client.underlay()
+	if err != nil {
+		return programmed, err
+	}
+
+	peers, err := readVxlanPeers(client.vxlanInfo, cnsPeers)
+	if err != nil {
+		return programmed, err
+	}
+
+	wanted := map[string]net.IP{}
+	for _, peer := range peers {
+		if !peer.Equal(localIP) {
+			wanted[peer.String()] = peer
+		}
+	}
+
+	current := []string{}
+	for _, peer := range programmed {
+		if _, ok := wanted[peer]; ok {
+			current = append(current, peer)
+			delete(wanted, peer)
+			continue
+		}
+
+		log.Printf("[net] Removing vxlan peer %v from %v.", peer, client.vxlanName)
+		if err := client.netlink.AddOrRemoveFdbEntry(netlink.REMOVE, client.vxlanName, vxlanFloodMac, net.ParseIP(peer)); err != nil {
+			log.Printf("[net] Failed to remove vxlan peer %v: %v.", peer, err)
+		}
+	}
+
+	for peer, ip := range wanted {
+		log.Printf("[net] Adding vxlan peer %v to %v.", peer, client.vxlanName)
+		if err := client.netlink.AddOrRemoveFdbEntry(netlink.ADD, client.vxlanName, vxlanFloodMac, ip); err != nil {
+			return current, newErrorVxlanNetworkClient(err.Error())
+		}
+		current = append(current, peer)
+	}
+
+	return current, nil
+}
+
+// readVxlanPeers returns the peers of the config, those of its peers file, a JSON list of IP addresses, and cnsPeers.
+func readVxlanPeers(vxlanInfo VxlanInfo, cnsPeers []string) ([]net.IP, error) {
+	addrs := append([]string{}, vxlanInfo.Peers...)
+	addrs = append(addrs, cnsPeers...)
+
+	if vxlanInfo.PeersFile != "" {
+		b, err := os.ReadFile(vxlanInfo.PeersFile)
+		if err != nil {
+			return nil, newErrorVxlanNetworkClient(err.Error())
+		}
+
+		var filePeers []string
+		if err := json.Unmarshal(b, &filePeers); err != nil {
+			return nil, newErrorVxlanNetworkClient(fmt.Sprintf("invalid peers file %s: %v", vxlanInfo.PeersFile, err))
+		}
+
+		addrs = append(addrs, filePeers...)
+	}
+
+	peers := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, newErrorVxlanNetworkClient(fmt.Sprintf("invalid peer address %q", addr))
+		}
+		peers = append(peers, ip)
+	}
+
+	return peers, nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+import (
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	receiveTimeout    = 10 * time.Second
+	receiveBufferSize = 32 * 1024
+)
+
+// netlinkConn programs nftables over an NETLINK_NETFILTER socket.
+// Each call opens its own socket so a failed batch can not leave stale replies behind.
+type netlinkConn struct {
+	seq uint32
+}
+
+// NewConn returns a Conn that talks nf_tables netlink.
+func NewConn() Conn {
+	return &netlinkConn{}
+}
+
+func (c *netlinkConn) AddTable(family Family, table string) error {
+	return c.execute(newMessage(nftMsgNewTable, unix.NLM_F_CREATE, family, tableAttributes(table)...))
+}
+
+func (c *netlinkConn) ListChains(family Family, table string) ([]Chain, error) {
+	bodies, err := c.dump(newMessage(nftMsgGetChain, 0, family))
+	if err != nil {
+		return nil, err
+	}
+
+	var chains []Chain
+	for _, body := range bodies {
+		chain, err := parseChain(body)
+		if err != nil {
+			return nil, err
+		}
+		if chain.Table == table {
+			chains = append(chains, chain)
+		}
+	}
+	return chains, nil
+}
+
+func (c *netlinkConn) AddChain(family Family, chain Chain) error {
+	return c.execute(newMessage(nftMsgNewChain, unix.NLM_F_CREATE, family, chainAttributes(chain)...))
+}
+
+func (c *netlinkConn) ListRules(family Family, table, chain string) ([]Rule, error) {
+	bodies, err := c.dump(newMessage(nftMsgGetRule, 0, family,
+		newAttributeStringZ(nftaRuleTable, table),
+		newAttributeStringZ(nftaRuleChain, chain)))
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, body := range bodies {
+		rule, err := parseRule(body)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Table == table && rule.Chain == chain {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (c *netlinkConn) InsertRule(family Family, rule Rule) error {
+	return c.addRule(family, rule, unix.NLM_F_CREATE)
+}
+
+func (c *netlinkConn) AppendRule(family Family, rule Rule) error {
+	return c.addRule(family, rule, unix.NLM_F_CREATE|unix.NLM_F_APPEND)
+}
+
+func (c *netlinkConn) addRule(family Family, rule Rule, flags uint16) error {
+	attrs, err := ruleAttributes(rule)
+	if err != nil {
+		return err
+	}
+	return c.execute(newMessage(nftMsgNewRule, flags, family, attrs...))
+}
+
+func (c *netlinkConn) DeleteRule(family Family, rule Rule) error {
+	return c.execute(newMessage(nftMsgDelRule, 0, family,
+		newAttributeStringZ(nftaRuleTable, rule.Table),
+		newAttributeStringZ(nftaRuleChain, rule.Chain),
+		newAttributeBE64(nftaRuleHandle, rule.Handle)))
+}
+
+// open creates a netlink netfilter socket.
+func open() (int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_NETFILTER)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create netfilter socket: %w", err)
+	}
+
+	tv := unix.NsecToTimeval(receiveTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to set netfilter socket timeout: %w", err)
+	}
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to bind netfilter socket: %w", err)
+	}
+
+	return fd, nil
+}
+
+func (c *netlinkConn) nextSeq() uint32 {
+	return atomic.AddUint32(&c.seq, 1)
+}
+
+// execute sends a change wrapped in a batch, which nf_tables requires, and waits for its ack.
+func (c *netlinkConn) execute(msg *message) error {
+	fd, err := open()
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	begin := &message{typ: nfnlMsgBatchBegin, flags: unix.NLM_F_REQUEST, seq: c.nextSeq(), resID: nfnlSubsysNftables}
+	msg.flags |= unix.NLM_F_REQUEST | unix.NLM_F_ACK
+	msg.seq = c.nextSeq()
+	end := &message{typ: nfnlMsgBatchEnd, flags: unix.NLM_F_REQUEST, seq: c.nextSeq(), resID: nfnlSubsysNftables}
+
+	var batch []byte
+	for _, m := range []*message{begin, msg, end} {
+		batch = append(batch, m.serialize()...)
+	}
+	if err := unix.Sendto(fd, batch, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return fmt.Errorf("failed to send nftables batch: %w", err)
+	}
+
+	for {
+		msgs, err := receive(fd)
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != msg.seq || m.Header.Type != unix.NLMSG_ERROR {
+				continue
+			}
+			if errCode := int32(encoder.Uint32(m.Data[0:4])); errCode != 0 {
+				err := syscall.Errno(-errCode)
+				log.Printf("[nftables] Request %d failed, err=%v", msg.typ, err)
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// dump sends a get request and returns the body of every message in the multipart reply.
+func (c *netlinkConn) dump(msg *message) ([][]byte, error) {
+	fd, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	msg.flags |= unix.NLM_F_REQUEST | unix.NLM_F_DUMP
+	msg.seq = c.nextSeq()
+	if err := unix.Sendto(fd, msg.serialize(), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to send nftables request: %w", err)
+	}
+
+	var bodies [][]byte
+	for {
+		msgs, err := receive(fd)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != msg.seq {
+				continue
+			}
+			switch m.Header.Type {
+			case unix.NLMSG_DONE:
+				return bodies, nil
+			case unix.NLMSG_ERROR:
+				if errCode := int32(encoder.Uint32(m.Data[0:4])); errCode != 0 {
+					return nil, syscall.Errno(-errCode)
+				}
+				return bodies, nil
+			default:
+				if len(m.Data) >= nfGenMsgLen {
+					bodies = append(bodies, m.Data[nfGenMsgLen:])
+				}
+			}
+		}
+	}
+}
+
+func receive(fd int) ([]syscall.NetlinkMessage, error) {
+	buf := make([]byte, receiveBufferSize)
+	n, _, err := unix.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive nftables reply: %w", err)
+	}
+	if n < unix.NLMSG_HDRLEN {
+		return nil, fmt.Errorf("invalid netlink message of %d bytes", n)
+	}
+	return syscall.ParseNetlinkMessage(buf[:n])
+}
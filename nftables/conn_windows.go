@@ -0,0 +1,25 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+// NewConn returns a Conn that fails every call, nftables only exists on Linux.
+func NewConn() Conn {
+	return unsupportedConn{}
+}
+
+type unsupportedConn struct{}
+
+func (unsupportedConn) AddTable(Family, string) error { return ErrNotSupported }
+
+func (unsupportedConn) ListChains(Family, string) ([]Chain, error) { return nil, ErrNotSupported }
+
+func (unsupportedConn) AddChain(Family, Chain) error { return ErrNotSupported }
+
+func (unsupportedConn) ListRules(Family, string, string) ([]Rule, error) { return nil, ErrNotSupported }
+
+func (unsupportedConn) InsertRule(Family, Rule) error { return ErrNotSupported }
+
+func (unsupportedConn) AppendRule(Family, Rule) error { return ErrNotSupported }
+
+func (unsupportedConn) DeleteRule(Family, Rule) error { return ErrNotSupported }
@@ -0,0 +1,287 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+import (
+	"encoding/binary"
+)
+
+// Expression attribute types, from linux/netfilter/nf_tables.h.
+const (
+	nftaMetaDreg = 1
+	nftaMetaKey  = 2
+	nftaMetaSreg = 3
+
+	nftaCmpSreg = 1
+	nftaCmpOp   = 2
+	nftaCmpData = 3
+
+	nftaPayloadDreg   = 1
+	nftaPayloadBase   = 2
+	nftaPayloadOffset = 3
+	nftaPayloadLen    = 4
+
+	nftaBitwiseSreg = 1
+	nftaBitwiseDreg = 2
+	nftaBitwiseLen  = 3
+	nftaBitwiseMask = 4
+	nftaBitwiseXor  = 5
+
+	nftaCtDreg = 1
+	nftaCtKey  = 2
+
+	nftaImmediateDreg = 1
+	nftaImmediateData = 2
+
+	nftaNatType       = 1
+	nftaNatFamily     = 2
+	nftaNatRegAddrMin = 3
+
+	nftaFibDreg   = 1
+	nftaFibResult = 2
+	nftaFibFlags  = 3
+)
+
+// Registers.
+const (
+	RegVerdict uint32 = 0
+	Reg1       uint32 = 1
+)
+
+// Meta keys.
+const (
+	MetaKeyMark    uint32 = 3
+	MetaKeyIIFName uint32 = 6
+	MetaKeyOIFName uint32 = 7
+	MetaKeyL4Proto uint32 = 16
+)
+
+// Cmp operators.
+const (
+	CmpOpEq  uint32 = 0
+	CmpOpNeq uint32 = 1
+)
+
+// Payload bases.
+const (
+	PayloadBaseNetwork   uint32 = 1
+	PayloadBaseTransport uint32 = 2
+)
+
+// Conntrack keys and state bits.
+const (
+	CtKeyState         uint32 = 0
+	CtStateInvalid     uint32 = 1
+	CtStateEstablished uint32 = 2
+	CtStateRelated     uint32 = 4
+	CtStateNew         uint32 = 8
+	CtStateUntracked   uint32 = 64
+)
+
+// Verdicts.
+const (
+	VerdictDrop   int32 = 0
+	VerdictAccept int32 = 1
+	VerdictJump   int32 = -3
+	VerdictReturn int32 = -5
+)
+
+// NAT types.
+const (
+	NatTypeSNAT uint32 = 0
+)
+
+// Fib results and flags.
+const (
+	FibResultAddrType uint32 = 3
+	FibFlagSaddr      uint32 = 1
+	FibFlagDaddr      uint32 = 2
+)
+
+// Expr is an nftables rule expression.
+type Expr interface {
+	name() string
+	attributes() []*attribute
+}
+
+// Meta loads packet metadata into a register, or sets it from one if SourceRegister is set.
+type Meta struct {
+	Key            uint32
+	Register       uint32
+	SourceRegister bool
+}
+
+func (*Meta) name() string { return "meta" }
+
+func (e *Meta) attributes() []*attribute {
+	reg := uint16(nftaMetaDreg)
+	if e.SourceRegister {
+		reg = nftaMetaSreg
+	}
+	return []*attribute{
+		newAttributeBE32(nftaMetaKey, e.Key),
+		newAttributeBE32(reg, e.Register),
+	}
+}
+
+// Cmp compares a register with Data.
+type Cmp struct {
+	Op       uint32
+	Register uint32
+	Data     []byte
+}
+
+func (*Cmp) name() string { return "cmp" }
+
+func (e *Cmp) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaCmpSreg, e.Register),
+		newAttributeBE32(nftaCmpOp, e.Op),
+		newNestedAttribute(nftaCmpData, newAttribute(nftaDataValue, e.Data)),
+	}
+}
+
+// Payload loads Len bytes at Offset from a packet header into a register.
+type Payload struct {
+	Base     uint32
+	Offset   uint32
+	Len      uint32
+	Register uint32
+}
+
+func (*Payload) name() string { return "payload" }
+
+func (e *Payload) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaPayloadDreg, e.Register),
+		newAttributeBE32(nftaPayloadBase, e.Base),
+		newAttributeBE32(nftaPayloadOffset, e.Offset),
+		newAttributeBE32(nftaPayloadLen, e.Len),
+	}
+}
+
+// Bitwise computes (register & Mask) ^ Xor.
+type Bitwise struct {
+	SourceRegister uint32
+	DestRegister   uint32
+	Len            uint32
+	Mask           []byte
+	Xor            []byte
+}
+
+func (*Bitwise) name() string { return "bitwise" }
+
+func (e *Bitwise) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaBitwiseSreg, e.SourceRegister),
+		newAttributeBE32(nftaBitwiseDreg, e.DestRegister),
+		newAttributeBE32(nftaBitwiseLen, e.Len),
+		newNestedAttribute(nftaBitwiseMask, newAttribute(nftaDataValue, e.Mask)),
+		newNestedAttribute(nftaBitwiseXor, newAttribute(nftaDataValue, e.Xor)),
+	}
+}
+
+// Ct loads conntrack data into a register.
+type Ct struct {
+	Key      uint32
+	Register uint32
+}
+
+func (*Ct) name() string { return "ct" }
+
+func (e *Ct) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaCtKey, e.Key),
+		newAttributeBE32(nftaCtDreg, e.Register),
+	}
+}
+
+// Immediate loads Data into a register.
+type Immediate struct {
+	Register uint32
+	Data     []byte
+}
+
+func (*Immediate) name() string { return "immediate" }
+
+func (e *Immediate) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaImmediateDreg, e.Register),
+		newNestedAttribute(nftaImmediateData, newAttribute(nftaDataValue, e.Data)),
+	}
+}
+
+// Verdict ends rule evaluation, Chain is the target of a jump.
+type Verdict struct {
+	Kind  int32
+	Chain string
+}
+
+func (*Verdict) name() string { return "immediate" }
+
+func (e *Verdict) attributes() []*attribute {
+	verdict := []*attribute{newAttributeBE32(nftaVerdictCode, uint32(e.Kind))}
+	if e.Chain != "" {
+		verdict = append(verdict, newAttributeStringZ(nftaVerdictChain, e.Chain))
+	}
+	return []*attribute{
+		newAttributeBE32(nftaImmediateDreg, RegVerdict),
+		newNestedAttribute(nftaImmediateData, newNestedAttribute(nftaDataVerdict, verdict...)),
+	}
+}
+
+// Masq masquerades to the address of the outgoing interface.
+type Masq struct{}
+
+func (*Masq) name() string { return "masq" }
+
+func (*Masq) attributes() []*attribute { return nil }
+
+// NAT translates to the address held in RegAddrMin.
+type NAT struct {
+	Type       uint32
+	Family     Family
+	RegAddrMin uint32
+}
+
+func (*NAT) name() string { return "nat" }
+
+func (e *NAT) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaNatType, e.Type),
+		newAttributeBE32(nftaNatFamily, uint32(e.Family)),
+		newAttributeBE32(nftaNatRegAddrMin, e.RegAddrMin),
+	}
+}
+
+// Fib looks up a packet address in the FIB and loads the result into a register.
+type Fib struct {
+	Register uint32
+	Result   uint32
+	Flags    uint32
+}
+
+func (*Fib) name() string { return "fib" }
+
+func (e *Fib) attributes() []*attribute {
+	return []*attribute{
+		newAttributeBE32(nftaFibDreg, e.Register),
+		newAttributeBE32(nftaFibResult, e.Result),
+		newAttributeBE32(nftaFibFlags, e.Flags),
+	}
+}
+
+// hostUint32 encodes a value in host byte order, as ct state and fib results are.
+func hostUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	encoder.PutUint32(b, v)
+	return b
+}
+
+// beUint16 encodes a value in network byte order, as ports are.
+func beUint16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
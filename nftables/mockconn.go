@@ -0,0 +1,145 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// ErrorMockConn - nftables mock error
+var ErrorMockConn = errors.New("Mock nftables Error")
+
+type mockTable struct {
+	chains []Chain
+	rules  map[string][]Rule
+}
+
+// MockConn is an in-memory Conn. Like the kernel, it fails with ENOENT when a table or chain is missing.
+type MockConn struct {
+	tables     map[Family]map[string]*mockTable
+	nextHandle uint64
+	returnErr  bool
+}
+
+// NewMockConn creates an empty MockConn. If returnErr is set, every call fails.
+func NewMockConn(returnErr bool) *MockConn {
+	return &MockConn{
+		tables:    map[Family]map[string]*mockTable{},
+		returnErr: returnErr,
+	}
+}
+
+func (m *MockConn) table(family Family, name string) (*mockTable, error) {
+	if m.returnErr {
+		return nil, ErrorMockConn
+	}
+	if t, ok := m.tables[family][name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("table %s: %w", name, syscall.ENOENT)
+}
+
+func (m *MockConn) chain(family Family, table, chain string) (*mockTable, error) {
+	t, err := m.table(family, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range t.chains {
+		if c.Name == chain {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("chain %s: %w", chain, syscall.ENOENT)
+}
+
+func (m *MockConn) AddTable(family Family, table string) error {
+	if m.returnErr {
+		return ErrorMockConn
+	}
+	if m.tables[family] == nil {
+		m.tables[family] = map[string]*mockTable{}
+	}
+	if _, ok := m.tables[family][table]; !ok {
+		m.tables[family][table] = &mockTable{rules: map[string][]Rule{}}
+	}
+	return nil
+}
+
+func (m *MockConn) ListChains(family Family, table string) ([]Chain, error) {
+	t, err := m.table(family, table)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Chain(nil), t.chains...), nil
+}
+
+func (m *MockConn) AddChain(family Family, chain Chain) error {
+	t, err := m.table(family, chain.Table)
+	if err != nil {
+		return err
+	}
+	for _, c := range t.chains {
+		if c.Name == chain.Name {
+			return nil
+		}
+	}
+	t.chains = append(t.chains, chain)
+	return nil
+}
+
+func (m *MockConn) ListRules(family Family, table, chain string) ([]Rule, error) {
+	t, err := m.chain(family, table, chain)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Rule(nil), t.rules[chain]...), nil
+}
+
+func (m *MockConn) addRule(family Family, rule Rule, insert bool) error {
+	t, err := m.chain(family, rule.Table, rule.Chain)
+	if err != nil {
+		return err
+	}
+	for _, e := range rule.Exprs {
+		if v, ok := e.(*Verdict); ok && v.Chain != "" {
+			if _, err := m.chain(family, rule.Table, v.Chain); err != nil {
+				return err
+			}
+		}
+	}
+
+	m.nextHandle++
+	rule.Handle = m.nextHandle
+	if insert {
+		t.rules[rule.Chain] = append([]Rule{rule}, t.rules[rule.Chain]...)
+	} else {
+		t.rules[rule.Chain] = append(t.rules[rule.Chain], rule)
+	}
+	return nil
+}
+
+func (m *MockConn) InsertRule(family Family, rule Rule) error {
+	return m.addRule(family, rule, true)
+}
+
+func (m *MockConn) AppendRule(family Family, rule Rule) error {
+	return m.addRule(family, rule, false)
+}
+
+func (m *MockConn) DeleteRule(family Family, rule Rule) error {
+	t, err := m.chain(family, rule.Table, rule.Chain)
+	if err != nil {
+		return err
+	}
+	rules := t.rules[rule.Chain]
+	for i := range rules {
+		if rules[i].Handle == rule.Handle {
+			t.rules[rule.Chain] = append(rules[:i:i], rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("rule handle %d: %w", rule.Handle, syscall.ENOENT)
+}
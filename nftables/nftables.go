@@ -0,0 +1,91 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+// Package nftables programs nftables tables, chains and rules by talking nf_tables netlink directly,
+// without shelling out to the nft or iptables binaries.
+package nftables
+
+import (
+	"errors"
+)
+
+// Family is the netfilter protocol family of a table.
+type Family uint8
+
+// Supported table families.
+const (
+	IPv4 Family = 2  // NFPROTO_IPV4
+	IPv6 Family = 10 // NFPROTO_IPV6
+)
+
+// Hook is the netfilter hook a base chain is attached to.
+type Hook uint32
+
+// Netfilter hooks.
+const (
+	HookPrerouting  Hook = 0
+	HookInput       Hook = 1
+	HookForward     Hook = 2
+	HookOutput      Hook = 3
+	HookPostrouting Hook = 4
+)
+
+// Base chain types.
+const (
+	ChainTypeFilter = "filter"
+	ChainTypeNat    = "nat"
+	ChainTypeRoute  = "route"
+)
+
+// Base chain priorities matching the iptables tables.
+const (
+	PriorityMangle = -150
+	PriorityDstNat = -100
+	PriorityFilter = 0
+	PrioritySrcNat = 100
+)
+
+// ErrNotSupported is returned when nf_tables netlink is not available.
+var ErrNotSupported = errors.New("nftables is not supported on this platform")
+
+// Chain is an nftables chain. Base chains set Type, Hook and Priority, regular chains leave Type empty.
+type Chain struct {
+	Table    string
+	Name     string
+	Type     string
+	Hook     Hook
+	Priority int32
+}
+
+// IsBaseChain returns true if the chain is attached to a netfilter hook.
+func (c *Chain) IsBaseChain() bool {
+	return c.Type != ""
+}
+
+// Rule is an nftables rule.
+// Rules listed from the kernel only carry their Handle and Comment, the expressions are not decoded.
+type Rule struct {
+	Table   string
+	Chain   string
+	Handle  uint64
+	Exprs   []Expr
+	Comment string
+}
+
+// Conn programs nftables objects.
+type Conn interface {
+	// AddTable creates the table if it does not exist.
+	AddTable(family Family, table string) error
+	// ListChains returns the chains in a table.
+	ListChains(family Family, table string) ([]Chain, error)
+	// AddChain creates the chain if it does not exist.
+	AddChain(family Family, chain Chain) error
+	// ListRules returns the rules in a chain, in order.
+	ListRules(family Family, table, chain string) ([]Rule, error)
+	// InsertRule adds the rule at the beginning of its chain.
+	InsertRule(family Family, rule Rule) error
+	// AppendRule adds the rule at the end of its chain.
+	AppendRule(family Family, rule Rule) error
+	// DeleteRule deletes the rule with the given handle.
+	DeleteRule(family Family, rule Rule) error
+}
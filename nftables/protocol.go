@@ -0,0 +1,305 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+)
+
+// nf_tables netlink protocol constants, from linux/netfilter/nf_tables.h and nfnetlink.h.
+const (
+	nfnlSubsysNftables = 10
+	nfnlMsgBatchBegin  = 0x10
+	nfnlMsgBatchEnd    = 0x11
+
+	nftMsgNewTable = 0
+	nftMsgNewChain = 3
+	nftMsgGetChain = 4
+	nftMsgNewRule  = 6
+	nftMsgGetRule  = 7
+	nftMsgDelRule  = 8
+
+	nftaTableName = 1
+
+	nftaChainTable  = 1
+	nftaChainName   = 3
+	nftaChainHook   = 4
+	nftaChainType   = 7
+	nftaHookHooknum = 1
+	nftaHookPrio    = 2
+
+	nftaRuleTable       = 1
+	nftaRuleChain       = 2
+	nftaRuleHandle      = 3
+	nftaRuleExpressions = 4
+	nftaRuleUserdata    = 7
+
+	nftaListElem = 1
+	nftaExprName = 1
+	nftaExprData = 2
+
+	nftaDataValue    = 1
+	nftaDataVerdict  = 2
+	nftaVerdictCode  = 1
+	nftaVerdictChain = 2
+
+	// NFTNL_UDATA_RULE_COMMENT, the userdata TLV nft uses for rule comments.
+	udataRuleComment = 0
+	maxCommentLen    = 128
+
+	nlaFNested   = 0x8000
+	nlaTypeMask  = ^uint16(0xc000)
+	nlaAlignTo   = 4
+	nlaHeaderLen = 4
+	nlMsgHdrLen  = 16
+	nfGenMsgLen  = 4
+)
+
+// encoder is the host byte order, used for netlink headers and some nf_tables registers.
+var encoder binary.ByteOrder
+
+func init() {
+	var x uint32 = 0x01020304
+	if *(*byte)(unsafe.Pointer(&x)) == 0x01 {
+		encoder = binary.BigEndian
+	} else {
+		encoder = binary.LittleEndian
+	}
+}
+
+// attribute is a netlink attribute, either a value or a list of nested attributes.
+type attribute struct {
+	typ      uint16
+	value    []byte
+	children []*attribute
+}
+
+func newAttribute(typ uint16, value []byte) *attribute {
+	return &attribute{typ: typ, value: value}
+}
+
+// newAttributeStringZ creates a null-terminated string attribute.
+func newAttributeStringZ(typ uint16, value string) *attribute {
+	return newAttribute(typ, []byte(value+"\000"))
+}
+
+// newAttributeBE32 creates a big endian uint32 attribute.
+func newAttributeBE32(typ uint16, value uint32) *attribute {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, value)
+	return newAttribute(typ, b)
+}
+
+// newAttributeBE64 creates a big endian uint64 attribute.
+func newAttributeBE64(typ uint16, value uint64) *attribute {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, value)
+	return newAttribute(typ, b)
+}
+
+func newNestedAttribute(typ uint16, children ...*attribute) *attribute {
+	return &attribute{typ: typ | nlaFNested, children: children}
+}
+
+func align(n int) int {
+	return (n + nlaAlignTo - 1) & ^(nlaAlignTo - 1)
+}
+
+func (a *attribute) length() int {
+	l := nlaHeaderLen + len(a.value)
+	for _, child := range a.children {
+		l += child.length()
+	}
+	return align(l)
+}
+
+func (a *attribute) serialize() []byte {
+	b := make([]byte, a.length())
+	unpadded := nlaHeaderLen + len(a.value)
+	for _, child := range a.children {
+		unpadded += child.length()
+	}
+	encoder.PutUint16(b[0:2], uint16(unpadded))
+	encoder.PutUint16(b[2:4], a.typ)
+
+	if a.value != nil {
+		copy(b[nlaHeaderLen:], a.value)
+		return b
+	}
+
+	offset := nlaHeaderLen
+	for _, child := range a.children {
+		offset += copy(b[offset:], child.serialize())
+	}
+	return b
+}
+
+// parseAttributes parses a buffer of netlink attributes, keyed by type with the nested flags masked off.
+func parseAttributes(b []byte) (map[uint16][]byte, error) {
+	attrs := map[uint16][]byte{}
+	for len(b) >= nlaHeaderLen {
+		l := int(encoder.Uint16(b[0:2]))
+		if l < nlaHeaderLen || l > len(b) {
+			return nil, fmt.Errorf("invalid attribute length %d", l)
+		}
+		attrs[encoder.Uint16(b[2:4])&nlaTypeMask] = b[nlaHeaderLen:l]
+		if align(l) >= len(b) {
+			break
+		}
+		b = b[align(l):]
+	}
+	return attrs, nil
+}
+
+// message is an nfnetlink message.
+type message struct {
+	typ    uint16
+	flags  uint16
+	seq    uint32
+	family Family
+	resID  uint16
+	attrs  []*attribute
+}
+
+func newMessage(msgType int, flags uint16, family Family, attrs ...*attribute) *message {
+	return &message{
+		typ:    uint16(nfnlSubsysNftables<<8 | msgType),
+		flags:  flags,
+		family: family,
+		attrs:  attrs,
+	}
+}
+
+func (m *message) serialize() []byte {
+	l := nlMsgHdrLen + nfGenMsgLen
+	for _, a := range m.attrs {
+		l += a.length()
+	}
+
+	b := make([]byte, l)
+	encoder.PutUint32(b[0:4], uint32(l))
+	encoder.PutUint16(b[4:6], m.typ)
+	encoder.PutUint16(b[6:8], m.flags)
+	encoder.PutUint32(b[8:12], m.seq)
+	// nfgenmsg: family, version and big endian resource id.
+	b[16] = byte(m.family)
+	b[17] = 0
+	binary.BigEndian.PutUint16(b[18:20], m.resID)
+
+	offset := nlMsgHdrLen + nfGenMsgLen
+	for _, a := range m.attrs {
+		offset += copy(b[offset:], a.serialize())
+	}
+	return b
+}
+
+// tableAttributes returns the attributes of a NEWTABLE message.
+func tableAttributes(table string) []*attribute {
+	return []*attribute{newAttributeStringZ(nftaTableName, table)}
+}
+
+// chainAttributes returns the attributes of a NEWCHAIN message.
+func chainAttributes(chain Chain) []*attribute {
+	attrs := []*attribute{
+		newAttributeStringZ(nftaChainTable, chain.Table),
+		newAttributeStringZ(nftaChainName, chain.Name),
+	}
+	if chain.IsBaseChain() {
+		attrs = append(attrs,
+			newNestedAttribute(nftaChainHook,
+				newAttributeBE32(nftaHookHooknum, uint32(chain.Hook)),
+				newAttributeBE32(nftaHookPrio, uint32(chain.Priority))),
+			newAttributeStringZ(nftaChainType, chain.Type))
+	}
+	return attrs
+}
+
+// ruleAttributes returns the attributes of a NEWRULE message.
+func ruleAttributes(rule Rule) ([]*attribute, error) {
+	exprs := make([]*attribute, 0, len(rule.Exprs))
+	for _, e := range rule.Exprs {
+		exprs = append(exprs, newNestedAttribute(nftaListElem,
+			newAttributeStringZ(nftaExprName, e.name()),
+			newNestedAttribute(nftaExprData, e.attributes()...)))
+	}
+
+	attrs := []*attribute{
+		newAttributeStringZ(nftaRuleTable, rule.Table),
+		newAttributeStringZ(nftaRuleChain, rule.Chain),
+		newNestedAttribute(nftaRuleExpressions, exprs...),
+	}
+	if rule.Comment != "" {
+		udata, err := commentUserdata(rule.Comment)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, newAttribute(nftaRuleUserdata, udata))
+	}
+	return attrs, nil
+}
+
+// commentUserdata encodes a rule comment the way nft does, so `nft list ruleset` shows it.
+func commentUserdata(comment string) ([]byte, error) {
+	if len(comment)+1 > maxCommentLen {
+		return nil, fmt.Errorf("comment %q is longer than %d bytes", comment, maxCommentLen-1)
+	}
+	b := make([]byte, 0, len(comment)+3)
+	b = append(b, udataRuleComment, byte(len(comment)+1))
+	b = append(b, comment...)
+	return append(b, 0), nil
+}
+
+// parseCommentUserdata returns the comment from rule userdata, if any.
+func parseCommentUserdata(b []byte) string {
+	for len(b) >= 2 {
+		typ, l := b[0], int(b[1])
+		if 2+l > len(b) {
+			return ""
+		}
+		if typ == udataRuleComment {
+			return string(trimNull(b[2 : 2+l]))
+		}
+		b = b[2+l:]
+	}
+	return ""
+}
+
+func trimNull(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// parseChain parses the body of a NEWCHAIN message received from a dump.
+func parseChain(body []byte) (Chain, error) {
+	attrs, err := parseAttributes(body)
+	if err != nil {
+		return Chain{}, err
+	}
+	return Chain{
+		Table: string(trimNull(attrs[nftaChainTable])),
+		Name:  string(trimNull(attrs[nftaChainName])),
+		Type:  string(trimNull(attrs[nftaChainType])),
+	}, nil
+}
+
+// parseRule parses the body of a NEWRULE message received from a dump.
+func parseRule(body []byte) (Rule, error) {
+	attrs, err := parseAttributes(body)
+	if err != nil {
+		return Rule{}, err
+	}
+	rule := Rule{
+		Table:   string(trimNull(attrs[nftaRuleTable])),
+		Chain:   string(trimNull(attrs[nftaRuleChain])),
+		Comment: parseCommentUserdata(attrs[nftaRuleUserdata]),
+	}
+	if h := attrs[nftaRuleHandle]; len(h) == 8 {
+		rule.Handle = binary.BigEndian.Uint64(h)
+	}
+	return rule, nil
+}
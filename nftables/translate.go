@@ -0,0 +1,392 @@
+// Copyright 2021 Microsoft. All rights reserved.
+// MIT License
+
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const ifNameSize = 16
+
+// ErrUnsupportedRule is returned for iptables matches and targets that have no translation.
+var ErrUnsupportedRule = errors.New("unsupported iptables rule")
+
+func newErrUnsupportedRule(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedRule, fmt.Sprintf(format, args...))
+}
+
+var protocolNumbers = map[string]byte{
+	"icmp":      1,
+	"tcp":       6,
+	"udp":       17,
+	"ipv6-icmp": 58,
+	"icmpv6":    58,
+}
+
+var ctStates = map[string]uint32{
+	"INVALID":     CtStateInvalid,
+	"ESTABLISHED": CtStateEstablished,
+	"RELATED":     CtStateRelated,
+	"NEW":         CtStateNew,
+	"UNTRACKED":   CtStateUntracked,
+}
+
+// rtn_type values for addrtype matches.
+var addrTypes = map[string]uint32{
+	"UNSPEC":      0,
+	"UNICAST":     1,
+	"LOCAL":       2,
+	"BROADCAST":   3,
+	"ANYCAST":     4,
+	"MULTICAST":   5,
+	"BLACKHOLE":   6,
+	"UNREACHABLE": 7,
+	"PROHIBIT":    8,
+}
+
+// Translate converts the match and target of an iptables rule, as passed to the iptables helpers,
+// into nftables expressions. jumpChain maps a user chain used as a target to the nftables chain to jump to.
+// Only the matches and targets the CNI programs are supported, anything else returns ErrUnsupportedRule.
+func Translate(family Family, match, target string, jumpChain func(string) string) ([]Expr, error) {
+	exprs, err := translateMatch(family, strings.Fields(match))
+	if err != nil {
+		return nil, err
+	}
+
+	targetExprs, err := translateTarget(family, strings.Fields(target), jumpChain)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(exprs, targetExprs...), nil
+}
+
+func translateMatch(family Family, args []string) ([]Expr, error) {
+	var exprs []Expr
+	var proto string
+	negate := false
+
+	next := func(i int) (string, error) {
+		if i+1 >= len(args) {
+			return "", newErrUnsupportedRule("%s requires a value", args[i])
+		}
+		return args[i+1], nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "!" {
+			negate = true
+			continue
+		}
+
+		op := CmpOpEq
+		if negate {
+			op = CmpOpNeq
+		}
+		negate = false
+
+		switch arg {
+		case "-m":
+			// match extensions are identified by their options
+			if _, err := next(i); err != nil {
+				return nil, err
+			}
+			i++
+		case "-s", "--source", "-d", "--destination":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			e, err := addressMatch(family, arg == "-s" || arg == "--source", value, op)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, e...)
+		case "-i", "--in-interface", "-o", "--out-interface":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			key := MetaKeyIIFName
+			if arg == "-o" || arg == "--out-interface" {
+				key = MetaKeyOIFName
+			}
+			exprs = append(exprs,
+				&Meta{Key: key, Register: Reg1},
+				&Cmp{Op: op, Register: Reg1, Data: interfaceName(value)})
+		case "-p", "--protocol":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			number, ok := protocolNumbers[strings.ToLower(value)]
+			if !ok {
+				n, err := strconv.ParseUint(value, 10, 8)
+				if err != nil {
+					return nil, newErrUnsupportedRule("protocol %s", value)
+				}
+				number = byte(n)
+			}
+			proto = strings.ToLower(value)
+			exprs = append(exprs,
+				&Meta{Key: MetaKeyL4Proto, Register: Reg1},
+				&Cmp{Op: op, Register: Reg1, Data: []byte{number}})
+		case "--sport", "--source-port", "--dport", "--destination-port":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			if proto != "tcp" && proto != "udp" {
+				return nil, newErrUnsupportedRule("%s without -p tcp or -p udp", arg)
+			}
+			port, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				return nil, newErrUnsupportedRule("port %s", value)
+			}
+			offset := uint32(0)
+			if arg == "--dport" || arg == "--destination-port" {
+				offset = 2
+			}
+			exprs = append(exprs,
+				&Payload{Base: PayloadBaseTransport, Offset: offset, Len: 2, Register: Reg1},
+				&Cmp{Op: op, Register: Reg1, Data: beUint16(uint16(port))})
+		case "--state", "--ctstate":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			var mask uint32
+			for _, state := range strings.Split(value, ",") {
+				bit, ok := ctStates[strings.ToUpper(state)]
+				if !ok {
+					return nil, newErrUnsupportedRule("conntrack state %s", state)
+				}
+				mask |= bit
+			}
+			// a negated state match is true when none of the states are set
+			cmpOp := CmpOpNeq
+			if op == CmpOpNeq {
+				cmpOp = CmpOpEq
+			}
+			exprs = append(exprs,
+				&Ct{Key: CtKeyState, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(mask), Xor: hostUint32(0)},
+				&Cmp{Op: cmpOp, Register: Reg1, Data: hostUint32(0)})
+		case "--mark":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			mark, mask, err := parseMark(value)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, &Meta{Key: MetaKeyMark, Register: Reg1})
+			if mask != math.MaxUint32 {
+				exprs = append(exprs,
+					&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(mask), Xor: hostUint32(0)})
+			}
+			exprs = append(exprs, &Cmp{Op: op, Register: Reg1, Data: hostUint32(mark & mask)})
+		case "--src-type", "--dst-type":
+			value, err := next(i)
+			if err != nil {
+				return nil, err
+			}
+			i++
+			addrType, ok := addrTypes[strings.ToUpper(value)]
+			if !ok {
+				return nil, newErrUnsupportedRule("address type %s", value)
+			}
+			flags := FibFlagDaddr
+			if arg == "--src-type" {
+				flags = FibFlagSaddr
+			}
+			exprs = append(exprs,
+				&Fib{Register: Reg1, Result: FibResultAddrType, Flags: flags},
+				&Cmp{Op: op, Register: Reg1, Data: hostUint32(addrType)})
+		default:
+			return nil, newErrUnsupportedRule("match %s", arg)
+		}
+	}
+
+	if negate {
+		return nil, newErrUnsupportedRule("trailing !")
+	}
+
+	return exprs, nil
+}
+
+// parseMark parses a mark match value, a mark optionally followed by a mask.
+func parseMark(value string) (mark, mask uint32, err error) {
+	markValue, maskValue := value, ""
+	if i := strings.Index(value, "/"); i >= 0 {
+		markValue, maskValue = value[:i], value[i+1:]
+	}
+
+	m, err := strconv.ParseUint(markValue, 0, 32)
+	if err != nil {
+		return 0, 0, newErrUnsupportedRule("mark %s", value)
+	}
+	mask = math.MaxUint32
+	if maskValue != "" {
+		n, err := strconv.ParseUint(maskValue, 0, 32)
+		if err != nil {
+			return 0, 0, newErrUnsupportedRule("mark %s", value)
+		}
+		mask = uint32(n)
+	}
+
+	return uint32(m), mask, nil
+}
+
+// addressMatch matches the source or destination address against an address or CIDR.
+func addressMatch(family Family, source bool, value string, op uint32) ([]Expr, error) {
+	var ipNet *net.IPNet
+	if strings.Contains(value, "/") {
+		_, n, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, newErrUnsupportedRule("address %s", value)
+		}
+		ipNet = n
+	} else {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, newErrUnsupportedRule("address %s", value)
+		}
+		ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+	}
+
+	var offset uint32
+	ip := ipNet.IP.To4()
+	switch family {
+	case IPv4:
+		if ip == nil {
+			return nil, newErrUnsupportedRule("IPv6 address %s in an IPv4 rule", value)
+		}
+		offset = 16
+		if source {
+			offset = 12
+		}
+	case IPv6:
+		if ip != nil {
+			return nil, newErrUnsupportedRule("IPv4 address %s in an IPv6 rule", value)
+		}
+		ip = ipNet.IP.To16()
+		offset = 24
+		if source {
+			offset = 8
+		}
+	}
+
+	mask := []byte(ipNet.Mask)
+	if len(mask) != len(ip) {
+		mask = mask[len(mask)-len(ip):]
+	}
+
+	exprs := []Expr{&Payload{Base: PayloadBaseNetwork, Offset: offset, Len: uint32(len(ip)), Register: Reg1}}
+	if ones, bits := ipNet.Mask.Size(); ones != bits {
+		exprs = append(exprs, &Bitwise{
+			SourceRegister: Reg1,
+			DestRegister:   Reg1,
+			Len:            uint32(len(ip)),
+			Mask:           mask,
+			Xor:            make([]byte, len(ip)),
+		})
+	}
+	return append(exprs, &Cmp{Op: op, Register: Reg1, Data: []byte(ip)}), nil
+}
+
+// interfaceName returns the comparison data for an interface name. Names ending in + are prefix matches.
+func interfaceName(name string) []byte {
+	if strings.HasSuffix(name, "+") {
+		return []byte(strings.TrimSuffix(name, "+"))
+	}
+	b := make([]byte, ifNameSize)
+	copy(b, name)
+	return b
+}
+
+func translateTarget(family Family, args []string, jumpChain func(string) string) ([]Expr, error) {
+	if len(args) == 0 {
+		return nil, newErrUnsupportedRule("rule without a target")
+	}
+
+	switch args[0] {
+	case "ACCEPT":
+		return []Expr{&Verdict{Kind: VerdictAccept}}, nil
+	case "DROP":
+		return []Expr{&Verdict{Kind: VerdictDrop}}, nil
+	case "RETURN":
+		return []Expr{&Verdict{Kind: VerdictReturn}}, nil
+	case "MASQUERADE":
+		if len(args) > 1 {
+			return nil, newErrUnsupportedRule("MASQUERADE options %v", args[1:])
+		}
+		return []Expr{&Masq{}}, nil
+	case "SNAT":
+		if len(args) != 3 || (args[1] != "--to" && args[1] != "--to-source") {
+			return nil, newErrUnsupportedRule("SNAT options %v", args[1:])
+		}
+		ip := net.ParseIP(args[2])
+		if ip == nil {
+			return nil, newErrUnsupportedRule("SNAT address %s", args[2])
+		}
+		if family == IPv4 {
+			ip = ip.To4()
+		}
+		if ip == nil {
+			return nil, newErrUnsupportedRule("SNAT address %s in an IPv4 rule", args[2])
+		}
+		return []Expr{
+			&Immediate{Register: Reg1, Data: []byte(ip)},
+			&NAT{Type: NatTypeSNAT, Family: family, RegAddrMin: Reg1},
+		}, nil
+	case "MARK":
+		if len(args) != 3 || (args[1] != "--set-mark" && args[1] != "--set-xmark") {
+			return nil, newErrUnsupportedRule("MARK options %v", args[1:])
+		}
+		if args[1] == "--set-xmark" {
+			// the bits of the mask are cleared, then xored with the value
+			value, mask, err := parseMark(args[2])
+			if err != nil {
+				return nil, err
+			}
+			return []Expr{
+				&Meta{Key: MetaKeyMark, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(^mask), Xor: hostUint32(value)},
+				&Meta{Key: MetaKeyMark, Register: Reg1, SourceRegister: true},
+			}, nil
+		}
+		mark, err := strconv.ParseUint(args[2], 0, 32)
+		if err != nil {
+			return nil, newErrUnsupportedRule("mark %s", args[2])
+		}
+		return []Expr{
+			&Immediate{Register: Reg1, Data: hostUint32(uint32(mark))},
+			&Meta{Key: MetaKeyMark, Register: Reg1, SourceRegister: true},
+		}, nil
+	default:
+		// anything else is a user chain, which takes no options
+		if len(args) > 1 {
+			return nil, newErrUnsupportedRule("target %v", args)
+		}
+		chain := args[0]
+		if jumpChain != nil {
+			chain = jumpChain(chain)
+		}
+		return []Expr{&Verdict{Kind: VerdictJump, Chain: chain}}, nil
+	}
+}
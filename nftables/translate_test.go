@@ -0,0 +1,195 @@
+package nftables
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jumpChain(chain string) string {
+	return "nat-" + chain
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name   string
+		family Family
+		match  string
+		target string
+		want   []Expr
+	}{
+		{
+			name:   "accept all",
+			family: IPv4,
+			target: "ACCEPT",
+			want:   []Expr{&Verdict{Kind: VerdictAccept}},
+		},
+		{
+			name:   "host to nc",
+			family: IPv4,
+			match:  "-s 169.254.0.1 -d 169.254.0.4",
+			target: "ACCEPT",
+			want: []Expr{
+				&Payload{Base: PayloadBaseNetwork, Offset: 12, Len: 4, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{169, 254, 0, 1}},
+				&Payload{Base: PayloadBaseNetwork, Offset: 16, Len: 4, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{169, 254, 0, 4}},
+				&Verdict{Kind: VerdictAccept},
+			},
+		},
+		{
+			name:   "established from bridge",
+			family: IPv4,
+			match:  " -i azSnatbr -m state --state ESTABLISHED,RELATED",
+			target: "ACCEPT",
+			want: []Expr{
+				&Meta{Key: MetaKeyIIFName, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte("azSnatbr\x00\x00\x00\x00\x00\x00\x00\x00")},
+				&Ct{Key: CtKeyState, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(CtStateEstablished | CtStateRelated), Xor: hostUint32(0)},
+				&Cmp{Op: CmpOpNeq, Register: Reg1, Data: hostUint32(0)},
+				&Verdict{Kind: VerdictAccept},
+			},
+		},
+		{
+			name:   "swift dns snat",
+			family: IPv4,
+			match:  " -m addrtype ! --dst-type local -s 10.240.0.0/16 -d 168.63.129.16 -p udp --dport 53",
+			target: "SNAT --to 10.240.0.4",
+			want: []Expr{
+				&Fib{Register: Reg1, Result: FibResultAddrType, Flags: FibFlagDaddr},
+				&Cmp{Op: CmpOpNeq, Register: Reg1, Data: hostUint32(2)},
+				&Payload{Base: PayloadBaseNetwork, Offset: 12, Len: 4, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: []byte{255, 255, 0, 0}, Xor: []byte{0, 0, 0, 0}},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{10, 240, 0, 0}},
+				&Payload{Base: PayloadBaseNetwork, Offset: 16, Len: 4, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{168, 63, 129, 16}},
+				&Meta{Key: MetaKeyL4Proto, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{17}},
+				&Payload{Base: PayloadBaseTransport, Offset: 2, Len: 2, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{0, 53}},
+				&Immediate{Register: Reg1, Data: []byte{10, 240, 0, 4}},
+				&NAT{Type: NatTypeSNAT, Family: IPv4, RegAddrMin: Reg1},
+			},
+		},
+		{
+			name:   "jump to user chain",
+			family: IPv4,
+			target: "SWIFT",
+			want:   []Expr{&Verdict{Kind: VerdictJump, Chain: "nat-SWIFT"}},
+		},
+		{
+			name:   "interface prefix",
+			family: IPv6,
+			match:  "! -o azv+",
+			target: "MARK --set-mark 0x0",
+			want: []Expr{
+				&Meta{Key: MetaKeyOIFName, Register: Reg1},
+				&Cmp{Op: CmpOpNeq, Register: Reg1, Data: []byte("azv")},
+				&Immediate{Register: Reg1, Data: hostUint32(0)},
+				&Meta{Key: MetaKeyMark, Register: Reg1, SourceRegister: true},
+			},
+		},
+		{
+			name:   "masked mark",
+			family: IPv4,
+			match:  "-m mark --mark 0x50000/0xffff0000",
+			target: "SNAT --to-source 10.240.5.0",
+			want: []Expr{
+				&Meta{Key: MetaKeyMark, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(0xffff0000), Xor: hostUint32(0)},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: hostUint32(0x50000)},
+				&Immediate{Register: Reg1, Data: []byte{10, 240, 5, 0}},
+				&NAT{Type: NatTypeSNAT, Family: IPv4, RegAddrMin: Reg1},
+			},
+		},
+		{
+			name:   "masked set mark",
+			family: IPv4,
+			match:  "-s 10.241.0.4",
+			target: "MARK --set-xmark 0x10000/0xffff0000",
+			want: []Expr{
+				&Payload{Base: PayloadBaseNetwork, Offset: 12, Len: 4, Register: Reg1},
+				&Cmp{Op: CmpOpEq, Register: Reg1, Data: []byte{10, 241, 0, 4}},
+				&Meta{Key: MetaKeyMark, Register: Reg1},
+				&Bitwise{SourceRegister: Reg1, DestRegister: Reg1, Len: 4, Mask: hostUint32(0x0000ffff), Xor: hostUint32(0x10000)},
+				&Meta{Key: MetaKeyMark, Register: Reg1, SourceRegister: true},
+			},
+		},
+		{
+			name:   "negated mark",
+			family: IPv4,
+			match:  "-m mark ! --mark 0x4000",
+			target: "RETURN",
+			want: []Expr{
+				&Meta{Key: MetaKeyMark, Register: Reg1},
+				&Cmp{Op: CmpOpNeq, Register: Reg1, Data: hostUint32(0x4000)},
+				&Verdict{Kind: VerdictReturn},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Translate(tt.family, tt.match, tt.target, jumpChain)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTranslateUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		family Family
+		match  string
+		target string
+	}{
+		{name: "unknown match", family: IPv4, match: "-m iprange ! --dst-range 168.63.129.16", target: "MASQUERADE"},
+		{name: "port without protocol", family: IPv4, match: "--dport 80", target: "ACCEPT"},
+		{name: "wrong family", family: IPv6, match: "-s 10.0.0.1", target: "ACCEPT"},
+		{name: "snat with port", family: IPv4, target: "SNAT --to 10.0.0.1:80"},
+		{name: "missing target", family: IPv4, match: "-s 10.0.0.1"},
+		{name: "invalid mark mask", family: IPv4, match: "-m mark --mark 0x1/mask", target: "ACCEPT"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Translate(tt.family, tt.match, tt.target, jumpChain)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrUnsupportedRule))
+		})
+	}
+}
+
+func TestRuleSerialization(t *testing.T) {
+	rule := Rule{
+		Table:   "azure-cni",
+		Chain:   "filter-INPUT",
+		Exprs:   []Expr{&Verdict{Kind: VerdictAccept}},
+		Comment: "-j ACCEPT",
+	}
+
+	attrs, err := ruleAttributes(rule)
+	require.NoError(t, err)
+
+	var body []byte
+	for _, a := range attrs {
+		b := a.serialize()
+		require.Zero(t, len(b)%nlaAlignTo)
+		body = append(body, b...)
+	}
+
+	got, err := parseRule(body)
+	require.NoError(t, err)
+	assert.Equal(t, rule.Table, got.Table)
+	assert.Equal(t, rule.Chain, got.Chain)
+	assert.Equal(t, rule.Comment, got.Comment)
+
+	_, err = commentUserdata(string(make([]byte, maxCommentLen)))
+	require.Error(t, err)
+}
@@ -16,6 +16,7 @@ import (
 	restserver "github.com/Azure/azure-container-networking/npm/http/server"
 	"github.com/Azure/azure-container-networking/npm/metrics"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/nftables"
 	"github.com/Azure/azure-container-networking/npm/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -109,14 +110,19 @@ func start(config npmconfig.Config) error {
 	k8sServerVersion := k8sServerVersion(clientset)
 
 	var dp dataplane.GenericDataplane
-	if config.Toggles.EnableV2Controllers {
-		dp, err = dataplane.NewDataPlane(npm.GetNodeName(), common.NewIOShim())
+	if config.Toggles.EnableV2Controllers && config.Toggles.EnableNftables {
+		dp, err = nftables.NewDataPlane(npm.GetNodeName(), common.NewIOShim())
+		if err != nil {
+			return fmt.Errorf("failed to create nftables dataplane with error %w", err)
+		}
+	} else if config.Toggles.EnableV2Controllers {
+		dp, err = dataplane.NewDataPlane(npm.GetNodeName(), common.NewIOShim(), &dataplane.Config{EnableIPv6: config.Toggles.EnableIPv6})
 		if err != nil {
 			return fmt.Errorf("failed to create dataplane with error %w", err)
 		}
 	}
 	npMgr := npm.NewNetworkPolicyManager(config, factory, dp, exec.New(), version, k8sServerVersion)
-	err = metrics.CreateTelemetryHandle(version, npm.GetAIMetadata())
+	err = metrics.CreateTelemetryHandle(version, npm.GetAIMetadata(), config.TelemetrySinks)
 	if err != nil {
 		klog.Infof("CreateTelemetryHandle failed with error %v.", err)
 		return fmt.Errorf("CreateTelemetryHandle failed with error %w", err)
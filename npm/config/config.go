@@ -1,5 +1,7 @@
 package npmconfig
 
+import "github.com/Azure/azure-container-networking/aitelemetry"
+
 const (
 	defaultResyncPeriod  = 15
 	defaultListeningPort = 10091
@@ -18,6 +20,8 @@ var DefaultConfig = Config{
 		EnablePprof:             true,
 		EnableHTTPDebugAPI:      true,
 		EnableV2Controllers:     false,
+		EnableNftables:          false,
+		EnableIPv6:              false,
 	},
 }
 
@@ -26,6 +30,8 @@ type Config struct {
 	ListeningPort         int     `json:"ListeningPort"`
 	ListeningAddress      string  `json:"ListeningAddress"`
 	Toggles               Toggles `json:"Toggles"`
+	// TelemetrySinks the telemetry is sent to, appinsights only if empty
+	TelemetrySinks []aitelemetry.SinkConfig `json:"TelemetrySinks,omitempty"`
 }
 
 type Toggles struct {
@@ -33,4 +39,8 @@ type Toggles struct {
 	EnablePprof             bool
 	EnableHTTPDebugAPI      bool
 	EnableV2Controllers     bool
+	// EnableNftables programs the V2 dataplane with nftables instead of iptables and ipset
+	EnableNftables bool
+	// EnableIPv6 programs IPv6 ipsets and ip6tables rules alongside the IPv4 ones in the V2 dataplane
+	EnableIPv6 bool
 }
@@ -12,8 +12,8 @@ import (
 
 var th aitelemetry.TelemetryHandle
 
-// CreateTelemetryHandle creates a handler to initialize AI telemetry
-func CreateTelemetryHandle(version, aiMetadata string) error {
+// CreateTelemetryHandle creates a handler to initialize AI telemetry, sending to the sinks if any
+func CreateTelemetryHandle(version, aiMetadata string, sinks []aitelemetry.SinkConfig) error {
 	aiConfig := aitelemetry.AIConfig{
 		AppName:                   util.AzureNpmFlag,
 		AppVersion:                version,
@@ -27,7 +27,7 @@ func CreateTelemetryHandle(version, aiMetadata string) error {
 
 	var err error
 	for i := 0; i < util.AiInitializeRetryCount; i++ {
-		th, err = aitelemetry.NewAITelemetry("", aiMetadata, aiConfig)
+		th, err = aitelemetry.NewTelemetry("", aiMetadata, aiConfig, sinks)
 		if err != nil {
 			log.Logf("Failed to init AppInsights with err: %+v for %d time", err, i+1)
 			time.Sleep(time.Minute * time.Duration(util.AiInitializeRetryInMin))
@@ -39,6 +39,7 @@ type NpmPod struct {
 	Name           string
 	Namespace      string
 	PodIP          string
+	PodIPs         []string
 	Labels         map[string]string
 	ContainerPorts []corev1.ContainerPort
 	Phase          corev1.PodPhase
@@ -49,6 +50,7 @@ func newNpmPod(podObj *corev1.Pod) *NpmPod {
 		Name:           podObj.ObjectMeta.Name,
 		Namespace:      podObj.ObjectMeta.Namespace,
 		PodIP:          podObj.Status.PodIP,
+		PodIPs:         getPodIPs(podObj),
 		Labels:         make(map[string]string),
 		ContainerPorts: []corev1.ContainerPort{},
 		Phase:          podObj.Status.Phase,
@@ -365,8 +367,7 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 
 	var err error
 	podKey, _ := cache.MetaNamespaceKeyFunc(podObj)
-
-	podMetadata := dataplane.NewPodMetadata(podKey, podObj.Status.PodIP, podObj.Spec.NodeName)
+	podIPs := getPodIPs(podObj)
 
 	namespaceSet := []*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(podObj.Namespace, ipsets.Namespace)}
 
@@ -374,8 +375,8 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 	c.dp.CreateIPSets(namespaceSet)
 
 	// Add the pod ip information into namespace's ipset.
-	klog.Infof("Adding pod %s to ipset %s", podObj.Status.PodIP, podObj.Namespace)
-	if err = c.dp.AddToSets(namespaceSet, podMetadata); err != nil {
+	klog.Infof("Adding pod %v to ipset %s", podIPs, podObj.Namespace)
+	if err = c.addPodToSets(namespaceSet, podKey, podIPs, podObj.Spec.NodeName); err != nil {
 		return fmt.Errorf("[syncAddedPod] Error: failed to add pod to namespace ipset with err: %w", err)
 	}
 
@@ -394,13 +395,13 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 		klog.Infof("Creating ipsets %v if it does not already exist", allSets)
 		c.dp.CreateIPSets(allSets)
 
-		klog.Infof("Adding pod %s to ipset %s", npmPodObj.PodIP, labelKey)
-		if err = c.dp.AddToSets([]*ipsets.IPSetMetadata{targetSetKey}, podMetadata); err != nil {
+		klog.Infof("Adding pod %v to ipset %s", podIPs, labelKey)
+		if err = c.addPodToSets([]*ipsets.IPSetMetadata{targetSetKey}, podKey, podIPs, podObj.Spec.NodeName); err != nil {
 			return fmt.Errorf("[syncAddedPod] Error: failed to add pod to label ipset with err: %w", err)
 		}
 
-		klog.Infof("Adding pod %s to ipset %s", npmPodObj.PodIP, podIPSetName)
-		if err = c.dp.AddToSets([]*ipsets.IPSetMetadata{targetSetKeyValue}, podMetadata); err != nil {
+		klog.Infof("Adding pod %v to ipset %s", podIPs, podIPSetName)
+		if err = c.addPodToSets([]*ipsets.IPSetMetadata{targetSetKeyValue}, podKey, podIPs, podObj.Spec.NodeName); err != nil {
 			return fmt.Errorf("[syncAddedPod] Error: failed to add pod to label ipset with err: %w", err)
 		}
 		npmPodObj.appendLabels(map[string]string{labelKey: labelVal}, appendToExistingLabels)
@@ -409,7 +410,7 @@ func (c *PodController) syncAddedPod(podObj *corev1.Pod) error {
 	// Add pod's named ports from its ipset.
 	klog.Infof("Adding named port ipsets")
 	containerPorts := getContainerPortList(podObj)
-	if err = c.manageNamedPortIpsets(containerPorts, podKey, npmPodObj.PodIP, addNamedPort); err != nil {
+	if err = c.manageNamedPortIpsets(containerPorts, podKey, podIPs, addNamedPort); err != nil {
 		return fmt.Errorf("[syncAddedPod] Error: failed to add pod to named port ipset with err: %w", err)
 	}
 	npmPodObj.appendContainerPorts(podObj)
@@ -422,7 +423,7 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) error {
 	var err error
 	podKey, _ := cache.MetaNamespaceKeyFunc(newPodObj)
 
-	newPodMetadata := dataplane.NewPodMetadata(podKey, newPodObj.Status.PodIP, newPodObj.Spec.NodeName)
+	newPodIPs := getPodIPs(newPodObj)
 
 	// lock before using nsMap since nsMap is shared with namespace controller
 	c.npmNamespaceCache.Lock()
@@ -458,9 +459,9 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) error {
 	// Dealing with #2 pod update event, the IP addresses of cached npmPod and newPodObj are different
 	// NPM should clean up existing references of cached pod obj and its IP.
 	// then, re-add new pod obj.
-	if cachedNpmPod.PodIP != newPodObj.Status.PodIP {
-		klog.Infof("Pod (Namespace:%s, Name:%s, newUid:%s), has cachedPodIp:%s which is different from PodIp:%s",
-			newPodObj.Namespace, newPodObj.Name, string(newPodObj.UID), cachedNpmPod.PodIP, newPodObj.Status.PodIP)
+	if !reflect.DeepEqual(cachedNpmPod.PodIPs, newPodIPs) {
+		klog.Infof("Pod (Namespace:%s, Name:%s, newUid:%s), has cachedPodIps:%v which is different from PodIps:%v",
+			newPodObj.Namespace, newPodObj.Name, string(newPodObj.UID), cachedNpmPod.PodIPs, newPodIPs)
 
 		klog.Infof("Deleting cached Pod with key:%s first due to IP Mistmatch", podKey)
 		if er := c.cleanUpDeletedPod(podKey); er != nil {
@@ -478,12 +479,11 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) error {
 
 	// Delete the pod from its label's ipset.
 	for _, podIPSetName := range deleteFromIPSets {
-		klog.Infof("Deleting pod %s from ipset %s", cachedNpmPod.PodIP, podIPSetName)
+		klog.Infof("Deleting pod %v from ipset %s", cachedNpmPod.PodIPs, podIPSetName)
 
 		// todo: verify pulling nodename from newpod
-		cachedPodMetadata := dataplane.NewPodMetadata(podKey, cachedNpmPod.PodIP, newPodMetadata.NodeName)
-
-		if err = c.dp.RemoveFromSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(podIPSetName, ipsets.KeyLabelOfPod)}, cachedPodMetadata); err != nil {
+		if err = c.removePodFromSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(podIPSetName, ipsets.KeyLabelOfPod)},
+			podKey, cachedNpmPod.PodIPs, newPodObj.Spec.NodeName); err != nil {
 			return fmt.Errorf("[syncAddAndUpdatePod] Error: failed to delete pod from label ipset with err: %w", err)
 		}
 		// {IMPORTANT} The order of compared list will be key and then key+val. NPM should only append after both key
@@ -501,8 +501,9 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) error {
 
 		c.dp.CreateIPSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(addIPSetName, ipsets.CIDRBlocks)})
 
-		klog.Infof("Adding pod %s to ipset %s", newPodObj.Status.PodIP, addIPSetName)
-		if err = c.dp.AddToSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(addIPSetName, ipsets.CIDRBlocks)}, newPodMetadata); err != nil {
+		klog.Infof("Adding pod %v to ipset %s", newPodIPs, addIPSetName)
+		if err = c.addPodToSets([]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(addIPSetName, ipsets.CIDRBlocks)},
+			podKey, newPodIPs, newPodObj.Spec.NodeName); err != nil {
 			return fmt.Errorf("[syncAddAndUpdatePod] Error: failed to add pod to label ipset with err: %w", err)
 		}
 		// {IMPORTANT} Same as above order is assumed to be key and then key+val. NPM should only append to existing labels
@@ -525,14 +526,14 @@ func (c *PodController) syncAddAndUpdatePod(newPodObj *corev1.Pod) error {
 	if !reflect.DeepEqual(cachedNpmPod.ContainerPorts, newPodPorts) {
 		// Delete cached pod's named ports from its ipset.
 		if err = c.manageNamedPortIpsets(
-			cachedNpmPod.ContainerPorts, podKey, cachedNpmPod.PodIP, deleteNamedPort); err != nil {
+			cachedNpmPod.ContainerPorts, podKey, cachedNpmPod.PodIPs, deleteNamedPort); err != nil {
 			return fmt.Errorf("[syncAddAndUpdatePod] Error: failed to delete pod from named port ipset with err: %w", err)
 		}
 		// Since portList ipset deletion is successful, NPM can remove cachedContainerPorts
 		cachedNpmPod.removeContainerPorts()
 
 		// Add new pod's named ports from its ipset.
-		if err = c.manageNamedPortIpsets(newPodPorts, podKey, newPodObj.Status.PodIP, addNamedPort); err != nil {
+		if err = c.manageNamedPortIpsets(newPodPorts, podKey, newPodIPs, addNamedPort); err != nil {
 			return fmt.Errorf("[syncAddAndUpdatePod] Error: failed to add pod to named port ipset with err: %w", err)
 		}
 		cachedNpmPod.appendContainerPorts(newPodObj)
@@ -554,26 +555,26 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 	var err error
 	// Delete the pod from its namespace's ipset.
 	// note: NodeName empty is not going to call update pod
-	if err = c.dp.RemoveFromSets(
+	if err = c.removePodFromSets(
 		[]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(cachedNpmPod.Namespace, ipsets.Namespace)},
-		dataplane.NewPodMetadata(cachedNpmPod.PodIP, cachedNpmPodKey, "")); err != nil {
+		cachedNpmPodKey, cachedNpmPod.PodIPs, ""); err != nil {
 		return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from namespace ipset with err: %w", err)
 	}
 
 	// Get lists of podLabelKey and podLabelKey + podLavelValue ,and then start deleting them from ipsets
 	for labelKey, labelVal := range cachedNpmPod.Labels {
-		klog.Infof("Deleting pod %s from ipset %s", cachedNpmPod.PodIP, labelKey)
-		if err = c.dp.RemoveFromSets(
+		klog.Infof("Deleting pod %v from ipset %s", cachedNpmPod.PodIPs, labelKey)
+		if err = c.removePodFromSets(
 			[]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(labelKey, ipsets.KeyLabelOfPod)},
-			dataplane.NewPodMetadata(cachedNpmPod.PodIP, cachedNpmPodKey, "")); err != nil {
+			cachedNpmPodKey, cachedNpmPod.PodIPs, ""); err != nil {
 			return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from label ipset with err: %w", err)
 		}
 
 		podIPSetName := util.GetIpSetFromLabelKV(labelKey, labelVal)
-		klog.Infof("Deleting pod %s from ipset %s", cachedNpmPod.PodIP, podIPSetName)
-		if err = c.dp.RemoveFromSets(
+		klog.Infof("Deleting pod %v from ipset %s", cachedNpmPod.PodIPs, podIPSetName)
+		if err = c.removePodFromSets(
 			[]*ipsets.IPSetMetadata{ipsets.NewIPSetMetadata(podIPSetName, ipsets.KeyValueLabelOfPod)},
-			dataplane.NewPodMetadata(cachedNpmPod.PodIP, cachedNpmPodKey, "")); err != nil {
+			cachedNpmPodKey, cachedNpmPod.PodIPs, ""); err != nil {
 			return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from label ipset with err: %w", err)
 		}
 		cachedNpmPod.removeLabelsWithKey(labelKey)
@@ -581,7 +582,7 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 
 	// Delete pod's named ports from its ipset. Need to pass true in the manageNamedPortIpsets function call
 	if err = c.manageNamedPortIpsets(
-		cachedNpmPod.ContainerPorts, cachedNpmPodKey, cachedNpmPod.PodIP, deleteNamedPort); err != nil {
+		cachedNpmPod.ContainerPorts, cachedNpmPodKey, cachedNpmPod.PodIPs, deleteNamedPort); err != nil {
 		return fmt.Errorf("[cleanUpDeletedPod] Error: failed to delete pod from named port ipset with err: %w", err)
 	}
 
@@ -589,8 +590,38 @@ func (c *PodController) cleanUpDeletedPod(cachedNpmPodKey string) error {
 	return nil
 }
 
+// addPodToSets adds each of the pod's IPs to the sets.
+func (c *PodController) addPodToSets(sets []*ipsets.IPSetMetadata, podKey string, podIPs []string, nodeName string) error {
+	for _, podIP := range podIPs {
+		if err := c.dp.AddToSets(sets, dataplane.NewPodMetadata(podKey, podIP, nodeName)); err != nil {
+			return fmt.Errorf("failed to add pod IP %s with err %w", podIP, err)
+		}
+	}
+	return nil
+}
+
+// removePodFromSets removes each of the pod's IPs from the sets.
+func (c *PodController) removePodFromSets(sets []*ipsets.IPSetMetadata, podKey string, podIPs []string, nodeName string) error {
+	for _, podIP := range podIPs {
+		if err := c.dp.RemoveFromSets(sets, dataplane.NewPodMetadata(podKey, podIP, nodeName)); err != nil {
+			return fmt.Errorf("failed to remove pod IP %s with err %w", podIP, err)
+		}
+	}
+	return nil
+}
+
 // manageNamedPortIpsets helps with adding or deleting Pod namedPort IPsets.
 func (c *PodController) manageNamedPortIpsets(portList []corev1.ContainerPort, podKey string,
+	podIPs []string, namedPortOperation NamedPortOperation) error {
+	for _, podIP := range podIPs {
+		if err := c.manageNamedPortIpsetsForIP(portList, podKey, podIP, namedPortOperation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PodController) manageNamedPortIpsetsForIP(portList []corev1.ContainerPort, podKey string,
 	podIP string, namedPortOperation NamedPortOperation) error {
 	for _, port := range portList {
 		klog.Infof("port is %+v", port)
@@ -642,6 +673,18 @@ func hasValidPodIP(podObj *corev1.Pod) bool {
 	return len(podObj.Status.PodIP) > 0
 }
 
+// getPodIPs returns all of the pod's addresses. Dual-stack pods have one per IP family.
+func getPodIPs(podObj *corev1.Pod) []string {
+	if len(podObj.Status.PodIPs) == 0 {
+		return []string{podObj.Status.PodIP}
+	}
+	podIPs := make([]string, 0, len(podObj.Status.PodIPs))
+	for _, podIP := range podObj.Status.PodIPs {
+		podIPs = append(podIPs, podIP.IP)
+	}
+	return podIPs
+}
+
 func isHostNetworkPod(podObj *corev1.Pod) bool {
 	return podObj.Spec.HostNetwork
 }
@@ -660,6 +703,7 @@ func isInvalidPodUpdate(npmPod *NpmPod, newPodObj *corev1.Pod) bool {
 		npmPod.Name == newPodObj.ObjectMeta.Name &&
 		npmPod.Phase == newPodObj.Status.Phase &&
 		npmPod.PodIP == newPodObj.Status.PodIP &&
+		reflect.DeepEqual(npmPod.PodIPs, getPodIPs(newPodObj)) &&
 		newPodObj.ObjectMeta.DeletionTimestamp == nil &&
 		newPodObj.ObjectMeta.DeletionGracePeriodSeconds == nil &&
 		reflect.DeepEqual(npmPod.Labels, newPodObj.ObjectMeta.Labels) &&
@@ -3,6 +3,7 @@ package translation
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
@@ -20,9 +21,6 @@ TODO
 (https://kubernetes.io/docs/concepts/services-networking/network-policies/#targeting-a-namespace-by-its-name)
 2. Check possible error - first check see how K8s guarantees correctness of the submitted network policy
 - Return error and validation
-3. Need to handle 0.0.0.0/0 in IPBlock field
-- Ipset doesn't allow 0.0.0.0/0 to be added. A general solution is split 0.0.0.0/1 in half which convert to
-  1.0.0.0/1 and 128.0.0.0/1 in linux
 */
 
 var errUnknownPortType = errors.New("unknown port Type")
@@ -119,11 +117,11 @@ func ipBlockIPSet(policyName, ns string, direction policies.Direction, ipBlockSe
 		return nil
 	}
 
-	members := make([]string, len(ipBlockRule.Except)+1) // except + cidr
-	cidrIndex := 0
-	members[cidrIndex] = ipBlockRule.CIDR
+	cidrs := splitAllAddressesCIDR(ipBlockRule.CIDR)
+	members := make([]string, 0, len(ipBlockRule.Except)+len(cidrs)) // except + cidr
+	members = append(members, cidrs...)
 	for i := 0; i < len(ipBlockRule.Except); i++ {
-		members[i+1] = ipBlockRule.Except[i] + util.IpsetNomatch
+		members = append(members, ipBlockRule.Except[i]+util.IpsetNomatch)
 	}
 
 	ipBlockIPSetName := ipBlockSetName(policyName, ns, direction, ipBlockSetIndex)
@@ -131,6 +129,22 @@ func ipBlockIPSet(policyName, ns string, direction policies.Direction, ipBlockSe
 	return ipBlockIPSet
 }
 
+// splitAllAddressesCIDR splits 0.0.0.0/0 and ::/0 into halves since ipset doesn't allow a zero prefix length.
+// Other CIDRs, whether IPv4 or IPv6, are returned as is.
+func splitAllAddressesCIDR(cidr string) []string {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return []string{cidr}
+	}
+	if ones, _ := ipNet.Mask.Size(); ones != 0 {
+		return []string{cidr}
+	}
+	if ipNet.IP.To4() != nil {
+		return []string{"0.0.0.0/1", "128.0.0.0/1"}
+	}
+	return []string{"::/1", "8000::/1"}
+}
+
 func ipBlockRule(policyName, ns string, direction policies.Direction, ipBlockSetIndex int, ipBlockRule *networkingv1.IPBlock) (*ipsets.TranslatedIPSet, policies.SetInfo) {
 	if ipBlockRule == nil || ipBlockRule.CIDR == "" {
 		return nil, policies.SetInfo{}
@@ -445,6 +445,59 @@ func TestIPBlockIPSet(t *testing.T) {
 				Members: []string{"172.17.0.0/16", "172.17.1.0/24nomatch", "172.17.2.0/24nomatch"},
 			},
 		},
+		{
+			name:            "ipv6 cidr and except",
+			policyName:      "test",
+			namemspace:      "default",
+			direction:       policies.Ingress,
+			ipBlockSetIndex: 0,
+			ipBlockRule: &networkingv1.IPBlock{
+				CIDR:   "fd00::/8",
+				Except: []string{"fd00:1::/32"},
+			},
+			translatedIPSet: &ipsets.TranslatedIPSet{
+				Metadata: &ipsets.IPSetMetadata{
+					Name: "test-in-ns-default-0IN",
+					Type: ipsets.CIDRBlocks,
+				},
+				Members: []string{"fd00::/8", "fd00:1::/32nomatch"},
+			},
+		},
+		{
+			name:            "all ipv4 addresses",
+			policyName:      "test",
+			namemspace:      "default",
+			direction:       policies.Egress,
+			ipBlockSetIndex: 1,
+			ipBlockRule: &networkingv1.IPBlock{
+				CIDR:   "0.0.0.0/0",
+				Except: []string{"10.0.0.0/8"},
+			},
+			translatedIPSet: &ipsets.TranslatedIPSet{
+				Metadata: &ipsets.IPSetMetadata{
+					Name: "test-in-ns-default-1OUT",
+					Type: ipsets.CIDRBlocks,
+				},
+				Members: []string{"0.0.0.0/1", "128.0.0.0/1", "10.0.0.0/8nomatch"},
+			},
+		},
+		{
+			name:            "all ipv6 addresses",
+			policyName:      "test",
+			namemspace:      "default",
+			direction:       policies.Egress,
+			ipBlockSetIndex: 0,
+			ipBlockRule: &networkingv1.IPBlock{
+				CIDR: "::/0",
+			},
+			translatedIPSet: &ipsets.TranslatedIPSet{
+				Metadata: &ipsets.IPSetMetadata{
+					Name: "test-in-ns-default-0OUT",
+					Type: ipsets.CIDRBlocks,
+				},
+				Members: []string{"::/1", "8000::/1"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -3,6 +3,7 @@ package dataplane
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/npm/metrics"
@@ -24,12 +25,12 @@ type dataplaneCfg struct {
 	policyMode policyMode
 }
 
-var (
-	iMgrDefaultCfg = &ipsets.IPSetManagerCfg{
-		IPSetMode:   ipsets.ApplyAllIPSets,
-		NetworkName: AzureNetworkName,
-	}
-)
+// Config holds the user-facing options for the dataplane.
+type Config struct {
+	// EnableIPv6 programs IPv6 ipsets and ip6tables rules alongside the IPv4 ones on Linux
+	// so that pods with both IPv4 and IPv6 addresses are covered by network policies in both families.
+	EnableIPv6 bool
+}
 
 type DataPlane struct {
 	policyMgr *policies.PolicyManager
@@ -52,11 +53,19 @@ type NPMEndpoint struct {
 	NetPolReference map[string]struct{}
 }
 
-func NewDataPlane(nodeName string, ioShim *common.IOShim) (*DataPlane, error) {
+func NewDataPlane(nodeName string, ioShim *common.IOShim, cfg *Config) (*DataPlane, error) {
 	metrics.InitializeAll()
+	iMgrCfg := &ipsets.IPSetManagerCfg{
+		IPSetMode:   ipsets.ApplyAllIPSets,
+		NetworkName: AzureNetworkName,
+		EnableIPv6:  cfg.EnableIPv6,
+	}
+	pMgrCfg := &policies.PolicyManagerCfg{
+		EnableIPv6: cfg.EnableIPv6,
+	}
 	dp := &DataPlane{
-		policyMgr:      policies.NewPolicyManager(ioShim),
-		ipsetMgr:       ipsets.NewIPSetManager(iMgrDefaultCfg, ioShim),
+		policyMgr:      policies.NewPolicyManager(ioShim, pMgrCfg),
+		ipsetMgr:       ipsets.NewIPSetManager(iMgrCfg, ioShim),
 		endpointCache:  make(map[string]*NPMEndpoint),
 		nodeName:       nodeName,
 		ioShim:         ioShim,
@@ -322,7 +331,7 @@ func (dp *DataPlane) createIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 		setType := set.Metadata.Type
 		if setType == ipsets.CIDRBlocks {
 			for _, ip := range set.Members {
-				_, _, err := net.ParseCIDR(ip)
+				err := validateCIDRMember(ip)
 				if err != nil {
 					return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("[dataplane] failed to parseCIDR in addIPSetReferences with err: %s", err.Error()))
 				}
@@ -368,7 +377,7 @@ func (dp *DataPlane) deleteIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 		setType := set.Metadata.Type
 		if setType == ipsets.CIDRBlocks {
 			for _, ip := range set.Members {
-				_, _, err := net.ParseCIDR(ip)
+				err := validateCIDRMember(ip)
 				if err != nil {
 					return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("[dataplane] failed to parseCIDR in deleteIPSetReferences with err: %s", err.Error()))
 				}
@@ -392,6 +401,15 @@ func (dp *DataPlane) deleteIPSetsAndReferences(sets []*ipsets.TranslatedIPSet, n
 	return nil
 }
 
+// validateCIDRMember checks an IPv4 or IPv6 CIDR member, which may carry the nomatch option for an except.
+func validateCIDRMember(member string) error {
+	cidr := strings.TrimSpace(strings.TrimSuffix(member, util.IpsetNomatch))
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	return nil
+}
+
 func getMembersOfTranslatedSets(members []string) []*ipsets.IPSetMetadata {
 	memberList := make([]*ipsets.IPSetMetadata, len(members))
 	i := 0
@@ -15,6 +15,8 @@ import (
 )
 
 var (
+	dpCfg = &Config{EnableIPv6: false}
+
 	nodeName                = "testnode"
 	fakeIPSetRestoreSuccess = testutils.TestCmd{
 		Cmd:      []string{util.Ipset, util.IpsetRestoreFlag},
@@ -69,7 +71,7 @@ func TestNewDataPlane(t *testing.T) {
 	metrics.InitializeAll()
 
 	calls := getNewDataplaneTestCalls()
-	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls), dpCfg)
 	require.NoError(t, err)
 
 	if dp == nil {
@@ -84,7 +86,7 @@ func TestInitializeDataPlane(t *testing.T) {
 	metrics.InitializeAll()
 
 	calls := append(getNewDataplaneTestCalls(), policies.GetInitializeTestCalls()...)
-	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls), dpCfg)
 	require.NoError(t, err)
 
 	assert.NotNil(t, dp)
@@ -97,7 +99,7 @@ func TestResetDataPlane(t *testing.T) {
 
 	calls := append(getNewDataplaneTestCalls(), getInitializeTestCalls()...)
 	calls = append(calls, getResetTestCalls()...)
-	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls), dpCfg)
 	require.NoError(t, err)
 
 	assert.NotNil(t, dp)
@@ -111,7 +113,7 @@ func TestCreateAndDeleteIpSets(t *testing.T) {
 	metrics.InitializeAll()
 
 	calls := getNewDataplaneTestCalls()
-	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls), dpCfg)
 	require.NoError(t, err)
 	assert.NotNil(t, dp)
 	setsTocreate := []*ipsets.IPSetMetadata{
@@ -151,7 +153,7 @@ func TestAddToSet(t *testing.T) {
 	metrics.InitializeAll()
 
 	calls := getNewDataplaneTestCalls()
-	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls), dpCfg)
 	require.NoError(t, err)
 
 	setsTocreate := []*ipsets.IPSetMetadata{
@@ -214,7 +216,7 @@ func TestApplyPolicy(t *testing.T) {
 
 	calls := append(getNewDataplaneTestCalls(), getAddPolicyTestCallsForDP(&testPolicyobj)...)
 	ioShim := common.NewMockIOShim(calls)
-	dp, err := NewDataPlane("testnode", ioShim)
+	dp, err := NewDataPlane("testnode", ioShim, dpCfg)
 	require.NoError(t, err)
 
 	err = dp.AddPolicy(&testPolicyobj)
@@ -227,7 +229,7 @@ func TestRemovePolicy(t *testing.T) {
 	calls := append(getNewDataplaneTestCalls(), getAddPolicyTestCallsForDP(&testPolicyobj)...)
 	calls = append(calls, getRemovePolicyTestCallsForDP(&testPolicyobj)...)
 	ioShim := common.NewMockIOShim(calls)
-	dp, err := NewDataPlane("testnode", ioShim)
+	dp, err := NewDataPlane("testnode", ioShim, dpCfg)
 	require.NoError(t, err)
 
 	err = dp.AddPolicy(&testPolicyobj)
@@ -256,7 +258,7 @@ func TestUpdatePolicy(t *testing.T) {
 		fmt.Println(call)
 	}
 	ioShim := common.NewMockIOShim(calls)
-	dp, err := NewDataPlane("testnode", ioShim)
+	dp, err := NewDataPlane("testnode", ioShim, dpCfg)
 	require.NoError(t, err)
 
 	err = dp.AddPolicy(&testPolicyobj)
@@ -395,23 +395,26 @@ func matchNAMEDPORTS(pod *controllersv1.NpmPod, setInfo *pb.RuleResponse_SetInfo
 	return false
 }
 
+// matchCIDRBLOCKS follows ipset's hash:net semantics for IPv4 and IPv6 entries:
+// the most specific entry containing the pod IP decides, and a nomatch entry excludes the IP.
+// Entries may be written as "172.17.1.0/24 nomatch" or "172.17.1.0/24nomatch".
 func matchCIDRBLOCKS(pod *controllersv1.NpmPod, setInfo *pb.RuleResponse_SetInfo) bool {
+	podIP := net.ParseIP(pod.PodIP)
+	if podIP == nil {
+		return false
+	}
 	matched := false
+	longestPrefix := -1
 	for _, entry := range setInfo.Contents {
-		entrySplitted := strings.Split(entry, " ")
-		if len(entrySplitted) > 1 { // nomatch condition. i.e [172.17.1.0/24 nomatch]
-			_, ipnet, _ := net.ParseCIDR(strings.TrimSpace(entrySplitted[0]))
-			podIP := net.ParseIP(pod.PodIP)
-			if ipnet.Contains(podIP) {
-				matched = false
-				break
-			}
-		} else {
-			_, ipnet, _ := net.ParseCIDR(strings.TrimSpace(entrySplitted[0]))
-			podIP := net.ParseIP(pod.PodIP)
-			if ipnet.Contains(podIP) {
-				matched = true
-			}
+		isNomatch := strings.HasSuffix(entry, util.IpsetNomatch)
+		cidr := strings.TrimSpace(strings.TrimSuffix(entry, util.IpsetNomatch))
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil || !ipnet.Contains(podIP) {
+			continue
+		}
+		if prefixLength, _ := ipnet.Mask.Size(); prefixLength > longestPrefix {
+			longestPrefix = prefixLength
+			matched = !isNomatch
 		}
 	}
 	return matched
@@ -254,7 +254,7 @@ func (creator *FileCreator) handleLineError(lineNum int, commandString, stdErr s
 			log.Errorf("aborting section associated with line %d for command [%s]", lineNumIndex, commandString)
 			section, exists := creator.sections[line.sectionID]
 			if !exists {
-				log.Errorf("can't abort section because line references section %d which doesn't exist, so skipping the line instead", line.sectionID)
+				log.Errorf("can't abort section because line references section %s which doesn't exist, so skipping the line instead", line.sectionID)
 				creator.lineNumbersToOmit[lineNumIndex] = struct{}{}
 			} else {
 				for _, lineNum := range section.lineNums {
@@ -183,6 +183,8 @@ const (
 type IPSet struct {
 	Name       string
 	HashedName string
+	// HashedNameV6 is the name of the set's IPv6 counterpart when IPv6 is enabled
+	HashedNameV6 string
 	// SetProperties embedding set properties
 	SetProperties
 	// IpPodKey is used for setMaps to store Ips and ports as keys
@@ -207,8 +209,9 @@ type IPSet struct {
 func NewIPSet(setMetadata *IPSetMetadata) *IPSet {
 	prefixedName := setMetadata.GetPrefixName()
 	set := &IPSet{
-		Name:       prefixedName,
-		HashedName: util.GetHashedName(prefixedName),
+		Name:         prefixedName,
+		HashedName:   util.GetHashedName(prefixedName),
+		HashedNameV6: util.GetHashedNameV6(prefixedName),
 		SetProperties: SetProperties{
 			Type: setMetadata.Type,
 			Kind: setMetadata.GetSetKind(),
@@ -35,6 +35,8 @@ type IPSetManager struct {
 type IPSetManagerCfg struct {
 	IPSetMode   IPSetMode
 	NetworkName string
+	// EnableIPv6 creates an IPv6 counterpart for each ipset. IPv6 members are ignored otherwise.
+	EnableIPv6 bool
 }
 
 func NewIPSetManager(iMgrCfg *IPSetManagerCfg, ioShim *common.IOShim) *IPSetManager {
@@ -2,6 +2,8 @@ package ipsets
 
 import (
 	"fmt"
+	"net"
+	"strings"
 
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ioutil"
@@ -85,8 +87,9 @@ func (iMgr *IPSetManager) handleDeletions(creator *ioutil.FileCreator, setNames
 			},
 		}
 		sectionID := getSectionID(deletionPrefix, setName)
-		hashedSetName := util.GetHashedName(setName)
-		creator.AddLine(sectionID, errorHandlers, util.IpsetFlushFlag, hashedSetName) // flush set
+		for _, hashedSetName := range iMgr.hashedNamesForFamilies(setName) {
+			creator.AddLine(sectionID, errorHandlers, util.IpsetFlushFlag, hashedSetName) // flush set
+		}
 	}
 
 	for _, setName := range setNames {
@@ -102,8 +105,9 @@ func (iMgr *IPSetManager) handleDeletions(creator *ioutil.FileCreator, setNames
 			},
 		}
 		sectionID := getSectionID(deletionPrefix, setName)
-		hashedSetName := util.GetHashedName(setName)
-		creator.AddLine(sectionID, errorHandlers, util.IpsetDestroyFlag, hashedSetName) // destroy set
+		for _, hashedSetName := range iMgr.hashedNamesForFamilies(setName) {
+			creator.AddLine(sectionID, errorHandlers, util.IpsetDestroyFlag, hashedSetName) // destroy set
+		}
 	}
 }
 
@@ -127,6 +131,17 @@ func (iMgr *IPSetManager) handleAddOrUpdates(creator *ioutil.FileCreator, setNam
 		if set.Type == CIDRBlocks {
 			specs = append(specs, util.IpsetMaxelemName, util.IpsetMaxelemNum)
 		}
+		var specsV6 []string
+		if iMgr.iMgrCfg.EnableIPv6 {
+			specsV6 = []string{util.IpsetCreationFlag, set.HashedNameV6, util.IpsetExistFlag, methodFlag}
+			if set.Kind == HashSet {
+				// list sets have no family, they hold whichever sets are added to them
+				specsV6 = append(specsV6, util.IpsetFamilyFlag, util.IpsetINet6)
+			}
+			if set.Type == CIDRBlocks {
+				specsV6 = append(specsV6, util.IpsetMaxelemName, util.IpsetMaxelemNum)
+			}
+		}
 
 		setName := setName // to appease golint complaints about function literal
 		errorHandlers := []*ioutil.LineErrorHandler{
@@ -141,6 +156,9 @@ func (iMgr *IPSetManager) handleAddOrUpdates(creator *ioutil.FileCreator, setNam
 		}
 		sectionID := getSectionID(creationPrefix, setName)
 		creator.AddLine(sectionID, errorHandlers, specs...) // create set
+		if specsV6 != nil {
+			creator.AddLine(sectionID, errorHandlers, specsV6...) // create IPv6 set
+		}
 	}
 
 	// flush and add all IPs/members for each set
@@ -149,31 +167,92 @@ func (iMgr *IPSetManager) handleAddOrUpdates(creator *ioutil.FileCreator, setNam
 	for _, setName := range setNames {
 		set := iMgr.setMap[setName]
 		sectionID := getSectionID(creationPrefix, setName)
-		creator.AddLine(sectionID, nil, util.IpsetFlushFlag, set.HashedName) // flush set (no error handler needed)
+		iMgr.addFlushAndMembers(creator, sectionID, set, false)
+		if iMgr.iMgrCfg.EnableIPv6 {
+			iMgr.addFlushAndMembers(creator, sectionID, set, true)
+		}
+	}
+}
+
+// addFlushAndMembers flushes one family's set and adds the set's members of that family.
+func (iMgr *IPSetManager) addFlushAndMembers(creator *ioutil.FileCreator, sectionID string, set *IPSet, isIPv6 bool) {
+	hashedSetName := set.HashedName
+	if isIPv6 {
+		hashedSetName = set.HashedNameV6
+	}
+	creator.AddLine(sectionID, nil, util.IpsetFlushFlag, hashedSetName) // flush set (no error handler needed)
 
-		if set.Kind == HashSet {
-			for ip := range set.IPPodKey {
-				// TODO add error handler?
-				creator.AddLine(sectionID, nil, util.IpsetAppendFlag, set.HashedName, ip) // add IP
+	if set.Kind == HashSet {
+		for ip := range set.IPPodKey {
+			memberIsIPv6, err := isIPv6Member(ip)
+			if err != nil {
+				log.Errorf("skipping invalid member %s of set %s: %v", ip, set.Name, err)
+				continue
 			}
-		} else {
-			setName := setName // to appease golint complaints about function literal
-			for _, member := range set.MemberIPSets {
-				memberName := member.Name // to appease golint complaints about function literal
-				errorHandlers := []*ioutil.LineErrorHandler{
-					{
-						Definition: ioutil.NewErrorDefinition(memberSetDoesntExist),
-						Method:     ioutil.SkipLine,
-						Callback: func() {
-							log.Errorf("was going to add member set %s to list %s, but the member doesn't exist", memberName, setName)
-							// TODO handle error
-						},
-					},
+			if memberIsIPv6 != isIPv6 {
+				if memberIsIPv6 && !iMgr.iMgrCfg.EnableIPv6 {
+					log.Logf("skipping IPv6 member %s of set %s since IPv6 is disabled", ip, set.Name)
 				}
-				creator.AddLine(sectionID, errorHandlers, util.IpsetAppendFlag, set.HashedName, member.HashedName) // add member
+				continue
 			}
+			// TODO add error handler?
+			creator.AddLine(sectionID, nil, append([]string{util.IpsetAppendFlag, hashedSetName}, memberSpecs(ip)...)...) // add IP
+		}
+		return
+	}
+
+	setName := set.Name // to appease golint complaints about function literal
+	for _, member := range set.MemberIPSets {
+		memberName := member.Name // to appease golint complaints about function literal
+		errorHandlers := []*ioutil.LineErrorHandler{
+			{
+				Definition: ioutil.NewErrorDefinition(memberSetDoesntExist),
+				Method:     ioutil.SkipLine,
+				Callback: func() {
+					log.Errorf("was going to add member set %s to list %s, but the member doesn't exist", memberName, setName)
+					// TODO handle error
+				},
+			},
 		}
+		memberHashedName := member.HashedName
+		if isIPv6 {
+			memberHashedName = member.HashedNameV6
+		}
+		creator.AddLine(sectionID, errorHandlers, util.IpsetAppendFlag, hashedSetName, memberHashedName) // add member
+	}
+}
+
+// hashedNamesForFamilies returns the kernel names of the set for each enabled IP family.
+func (iMgr *IPSetManager) hashedNamesForFamilies(setName string) []string {
+	if iMgr.iMgrCfg.EnableIPv6 {
+		return []string{util.GetHashedName(setName), util.GetHashedNameV6(setName)}
+	}
+	return []string{util.GetHashedName(setName)}
+}
+
+// isIPv6Member determines the family of a hash set member, which is an IP, a CIDR optionally
+// followed by nomatch, or an IP and port like 10.0.0.1,tcp:80.
+func isIPv6Member(member string) (bool, error) {
+	address := strings.TrimSpace(strings.TrimSuffix(member, util.IpsetNomatch))
+	if i := strings.Index(address, ","); i >= 0 {
+		address = address[:i]
+	}
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[:i]
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false, fmt.Errorf("%s is not an IP address", address)
+	}
+	return ip.To4() == nil, nil
+}
+
+// memberSpecs splits a CIDR member with the nomatch option into separate arguments for ipset.
+func memberSpecs(member string) []string {
+	if strings.HasSuffix(member, util.IpsetNomatch) {
+		return []string{strings.TrimSpace(strings.TrimSuffix(member, util.IpsetNomatch)), util.IpsetNomatch}
 	}
+	return []string{member}
 }
 
 func getSectionID(prefix, setName string) string {
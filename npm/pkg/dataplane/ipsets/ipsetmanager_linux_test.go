@@ -19,6 +19,12 @@ var (
 		NetworkName: "",
 	}
 
+	iMgrDualStackCfg = &IPSetManagerCfg{
+		IPSetMode:   ApplyAllIPSets,
+		NetworkName: "",
+		EnableIPv6:  true,
+	}
+
 	ipsetRestoreStringSlice = []string{"ipset", "restore"}
 )
 
@@ -110,6 +116,75 @@ func TestApplyCreationsAndAdds(t *testing.T) {
 	require.False(t, wasFileAltered)
 }
 
+func TestApplyCreationsAndAddsDualStack(t *testing.T) {
+	calls := []testutils.TestCmd{fakeRestoreSuccessCommand}
+	iMgr := NewIPSetManager(iMgrDualStackCfg, common.NewMockIOShim(calls))
+
+	lines := []string{
+		fmt.Sprintf("-N %s -exist nethash", TestNSSet.HashedName),
+		fmt.Sprintf("-N %s -exist nethash family inet6", TestNSSet.HashedNameV6),
+		fmt.Sprintf("-N %s -exist nethash maxelem 4294967295", TestCIDRSet.HashedName),
+		fmt.Sprintf("-N %s -exist nethash family inet6 maxelem 4294967295", TestCIDRSet.HashedNameV6),
+		fmt.Sprintf("-N %s -exist setlist", TestKeyNSList.HashedName),
+		fmt.Sprintf("-N %s -exist setlist", TestKeyNSList.HashedNameV6),
+	}
+	// a dual-stack pod is in both families' sets
+	lines = append(lines, getSortedLines(TestNSSet, "10.0.0.0")...)
+	lines = append(lines, getSortedLines(&TestSet{HashedName: TestNSSet.HashedNameV6}, "fd00::1")...)
+	lines = append(lines, getSortedLines(TestCIDRSet, "10.0.0.0/8", "10.1.0.0/16 nomatch")...)
+	lines = append(lines, getSortedLines(&TestSet{HashedName: TestCIDRSet.HashedNameV6}, "fd00::/8", "fd00:1::/32 nomatch")...)
+	lines = append(lines, getSortedLines(TestKeyNSList, TestNSSet.HashedName)...)
+	lines = append(lines, getSortedLines(&TestSet{HashedName: TestKeyNSList.HashedNameV6}, TestNSSet.HashedNameV6)...)
+	expectedFileString := strings.Join(lines, "\n") + "\n"
+
+	iMgr.CreateIPSets([]*IPSetMetadata{TestNSSet.Metadata})
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "10.0.0.0", "a"))
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "fd00::1", "a"))
+	iMgr.CreateIPSets([]*IPSetMetadata{TestCIDRSet.Metadata})
+	for _, member := range []string{"10.0.0.0/8", "10.1.0.0/16nomatch", "fd00::/8", "fd00:1::/32nomatch"} {
+		require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestCIDRSet.Metadata}, member, ""))
+	}
+	iMgr.CreateIPSets([]*IPSetMetadata{TestKeyNSList.Metadata})
+	require.NoError(t, iMgr.AddToLists([]*IPSetMetadata{TestKeyNSList.Metadata}, []*IPSetMetadata{TestNSSet.Metadata}))
+
+	toAddOrUpdateSetNames := []string{TestNSSet.PrefixName, TestCIDRSet.PrefixName, TestKeyNSList.PrefixName}
+	creator := iMgr.getFileCreator(1, nil, toAddOrUpdateSetNames)
+	actualFileString := getSortedFileString(creator)
+
+	dptestutils.AssertEqualMultilineStrings(t, expectedFileString, actualFileString)
+	wasFileAltered, err := creator.RunCommandOnceWithFile("ipset", "restore")
+	require.NoError(t, err)
+	require.False(t, wasFileAltered)
+}
+
+func TestApplyDeletionsDualStack(t *testing.T) {
+	iMgr := NewIPSetManager(iMgrDualStackCfg, common.NewMockIOShim(nil))
+	creator := iMgr.getFileCreator(1, []string{TestCIDRSet.PrefixName}, nil)
+
+	lines := []string{
+		fmt.Sprintf("-F %s", TestCIDRSet.HashedName),
+		fmt.Sprintf("-F %s", TestCIDRSet.HashedNameV6),
+		fmt.Sprintf("-X %s", TestCIDRSet.HashedName),
+		fmt.Sprintf("-X %s", TestCIDRSet.HashedNameV6),
+		"",
+	}
+	dptestutils.AssertEqualMultilineStrings(t, strings.Join(lines, "\n"), creator.ToString())
+}
+
+func TestIPv6MembersSkippedWhenDisabled(t *testing.T) {
+	iMgr := NewIPSetManager(iMgrApplyAllCfg, common.NewMockIOShim(nil))
+	iMgr.CreateIPSets([]*IPSetMetadata{TestNSSet.Metadata})
+	require.NoError(t, iMgr.AddToSets([]*IPSetMetadata{TestNSSet.Metadata}, "fd00::1", "a"))
+
+	creator := iMgr.getFileCreator(1, nil, []string{TestNSSet.PrefixName})
+	lines := []string{
+		fmt.Sprintf("-N %s -exist nethash", TestNSSet.HashedName),
+		fmt.Sprintf("-F %s", TestNSSet.HashedName),
+		"",
+	}
+	dptestutils.AssertEqualMultilineStrings(t, strings.Join(lines, "\n"), creator.ToString())
+}
+
 func TestApplyDeletions(t *testing.T) {
 	calls := []testutils.TestCmd{fakeRestoreSuccessCommand}
 	iMgr := NewIPSetManager(iMgrApplyAllCfg, common.NewMockIOShim(calls))
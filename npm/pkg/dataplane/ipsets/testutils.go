@@ -3,9 +3,10 @@ package ipsets
 import "github.com/Azure/azure-container-networking/npm/util"
 
 type TestSet struct {
-	Metadata   *IPSetMetadata
-	PrefixName string
-	HashedName string
+	Metadata     *IPSetMetadata
+	PrefixName   string
+	HashedName   string
+	HashedNameV6 string
 }
 
 func CreateTestSet(name string, setType SetType) *TestSet {
@@ -17,6 +18,7 @@ func CreateTestSet(name string, setType SetType) *TestSet {
 	}
 	set.PrefixName = set.Metadata.GetPrefixName()
 	set.HashedName = util.GetHashedName(set.PrefixName)
+	set.HashedNameV6 = util.GetHashedNameV6(set.PrefixName)
 	return set
 }
 
@@ -0,0 +1,538 @@
+// Package nftables is an NPM dataplane for Linux which programs network policies with nftables
+// instead of iptables and ipset. IPSets become named sets and policies become chains of the
+// inet azure-npm table, which is rewritten as a whole in a single nft transaction on every apply.
+package nftables
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
+	"k8s.io/klog"
+)
+
+var _ dataplane.GenericDataplane = (*DataPlane)(nil)
+
+// set is the cached content of an IPSet.
+type set struct {
+	metadata   *ipsets.IPSetMetadata
+	hashedName string
+	// Key is the member IP (or IP and port for named ports) and value is the pod key
+	ipPodKey map[string]string
+	// memberSets holds the prefixed names of the members of a list set
+	memberSets map[string]struct{}
+	// memberOf holds the prefixed names of the list sets this set is a member of
+	memberOf map[string]struct{}
+	// references holds the names of network policies using this set
+	references map[string]struct{}
+}
+
+func newSet(setMetadata *ipsets.IPSetMetadata) *set {
+	return &set{
+		metadata:   setMetadata,
+		hashedName: setMetadata.GetHashedName(),
+		ipPodKey:   make(map[string]string),
+		memberSets: make(map[string]struct{}),
+		memberOf:   make(map[string]struct{}),
+		references: make(map[string]struct{}),
+	}
+}
+
+func (s *set) canBeDeleted() bool {
+	return len(s.ipPodKey) == 0 && len(s.memberSets) == 0 && len(s.memberOf) == 0 && len(s.references) == 0
+}
+
+// DataPlane caches IPSets and network policies and renders them into the azure-npm nftables table.
+// Like the iptables dataplane, none of the IPSet operations reach the kernel until ApplyDataPlane,
+// while policy operations apply immediately.
+type DataPlane struct {
+	nodeName string
+	// Key is the prefixed IPSet name
+	setMap map[string]*set
+	// Key is the network policy name
+	policyMap map[string]*policies.NPMNetworkPolicy
+	// dirty is set when the cache differs from what was last applied
+	dirty  bool
+	ioShim *common.IOShim
+	sync.Mutex
+}
+
+// NewDataPlane creates an nftables dataplane, removing anything left over in the azure-npm table.
+func NewDataPlane(nodeName string, ioShim *common.IOShim) (*DataPlane, error) {
+	metrics.InitializeAll()
+	dp := &DataPlane{
+		nodeName:  nodeName,
+		setMap:    make(map[string]*set),
+		policyMap: make(map[string]*policies.NPMNetworkPolicy),
+		ioShim:    ioShim,
+	}
+
+	err := dp.ResetDataPlane()
+	if err != nil {
+		klog.Errorf("Failed to reset nftables dataplane: %v", err)
+		return nil, err
+	}
+
+	err = dp.InitializeDataPlane()
+	if err != nil {
+		klog.Errorf("Failed to initialize nftables dataplane: %v", err)
+		return nil, err
+	}
+
+	return dp, nil
+}
+
+// InitializeDataPlane creates the Kube-All-NS IPSet and the base chains of the azure-npm table
+func (dp *DataPlane) InitializeDataPlane() error {
+	dp.Lock()
+	defer dp.Unlock()
+	klog.Infof("Initializing nftables dataplane")
+
+	dp.createIPSet(ipsets.NewIPSetMetadata(util.KubeAllNamespacesFlag, ipsets.KeyLabelOfNamespace))
+	dp.dirty = true
+	if err := dp.applyDataPlane(); err != nil {
+		return npmerrors.ErrorWrapper(npmerrors.InitializeDataPlane, false, "failed to initialize nftables dataplane", err)
+	}
+	return nil
+}
+
+// ResetDataPlane deletes the azure-npm table and clears the cache
+func (dp *DataPlane) ResetDataPlane() error {
+	dp.Lock()
+	defer dp.Unlock()
+
+	creator := dp.getCreatorForReset()
+	if err := restore(creator); err != nil {
+		return npmerrors.ErrorWrapper(npmerrors.ResetDataPlane, false, "failed to delete nftables table", err)
+	}
+	dp.setMap = make(map[string]*set)
+	dp.policyMap = make(map[string]*policies.NPMNetworkPolicy)
+	dp.dirty = false
+	metrics.ResetNumIPSets()
+	metrics.ResetIPSetEntries()
+	metrics.ResetNumPolicies()
+	return nil
+}
+
+// CreateIPSets adds the sets to the cache
+func (dp *DataPlane) CreateIPSets(setMetadatas []*ipsets.IPSetMetadata) {
+	dp.Lock()
+	defer dp.Unlock()
+	for _, setMetadata := range setMetadatas {
+		dp.createIPSet(setMetadata)
+	}
+}
+
+func (dp *DataPlane) createIPSet(setMetadata *ipsets.IPSetMetadata) *set {
+	prefixedName := setMetadata.GetPrefixName()
+	if s, ok := dp.setMap[prefixedName]; ok {
+		return s
+	}
+	s := newSet(setMetadata)
+	dp.setMap[prefixedName] = s
+	dp.dirty = true
+	metrics.IncNumIPSets()
+	return s
+}
+
+// DeleteIPSet removes the set from the cache if it has no members and is not referenced
+func (dp *DataPlane) DeleteIPSet(setMetadata *ipsets.IPSetMetadata) {
+	dp.Lock()
+	defer dp.Unlock()
+	dp.deleteIPSet(setMetadata.GetPrefixName())
+}
+
+func (dp *DataPlane) deleteIPSet(prefixedName string) {
+	s, ok := dp.setMap[prefixedName]
+	if !ok || !s.canBeDeleted() {
+		return
+	}
+	delete(dp.setMap, prefixedName)
+	dp.dirty = true
+	metrics.DecNumIPSets()
+	metrics.DeleteIPSet(prefixedName)
+}
+
+// AddToSets adds the pod IP to the hash sets, creating any set which does not exist
+func (dp *DataPlane) AddToSets(setMetadatas []*ipsets.IPSetMetadata, podMetadata *dataplane.PodMetadata) error {
+	dp.Lock()
+	defer dp.Unlock()
+	return dp.addToSets(setMetadatas, podMetadata.PodIP, podMetadata.PodKey)
+}
+
+func (dp *DataPlane) addToSets(setMetadatas []*ipsets.IPSetMetadata, ip, podKey string) error {
+	if err := dp.checkForHashSets(setMetadatas, npmerrors.AppendIPSet); err != nil {
+		return err
+	}
+
+	for _, setMetadata := range setMetadatas {
+		prefixedName := setMetadata.GetPrefixName()
+		s := dp.setMap[prefixedName]
+		cachedPodKey, ok := s.ipPodKey[ip]
+		s.ipPodKey[ip] = podKey
+		if ok {
+			if cachedPodKey != podKey {
+				klog.Infof("AddToSet: PodOwner has changed for Ip: %s, setName:%s, Old podKey: %s, new podKey: %s. Replace context with new PodOwner.",
+					ip, prefixedName, cachedPodKey, podKey)
+			}
+			continue
+		}
+		dp.dirty = true
+		metrics.AddEntryToIPSet(prefixedName)
+	}
+	return nil
+}
+
+// RemoveFromSets removes the pod IP from the hash sets unless it now belongs to another pod
+func (dp *DataPlane) RemoveFromSets(setMetadatas []*ipsets.IPSetMetadata, podMetadata *dataplane.PodMetadata) error {
+	dp.Lock()
+	defer dp.Unlock()
+	return dp.removeFromSets(setMetadatas, podMetadata.PodIP, podMetadata.PodKey)
+}
+
+func (dp *DataPlane) removeFromSets(setMetadatas []*ipsets.IPSetMetadata, ip, podKey string) error {
+	if err := dp.checkForHashSets(setMetadatas, npmerrors.DeleteIPSet); err != nil {
+		return err
+	}
+
+	for _, setMetadata := range setMetadatas {
+		prefixedName := setMetadata.GetPrefixName()
+		s := dp.setMap[prefixedName]
+		cachedPodKey, exists := s.ipPodKey[ip]
+		if !exists {
+			continue
+		}
+		if cachedPodKey != podKey {
+			klog.Infof("DeleteFromSet: PodOwner has changed for Ip: %s, setName:%s, Old podKey: %s, new podKey: %s. Ignore the delete as this is stale update",
+				ip, prefixedName, cachedPodKey, podKey)
+			continue
+		}
+		delete(s.ipPodKey, ip)
+		dp.dirty = true
+		metrics.RemoveEntryFromIPSet(prefixedName)
+	}
+	return nil
+}
+
+// AddToLists adds the hash sets as members of the list sets
+func (dp *DataPlane) AddToLists(listMetadatas, setMetadatas []*ipsets.IPSetMetadata) error {
+	dp.Lock()
+	defer dp.Unlock()
+	return dp.addToLists(listMetadatas, setMetadatas)
+}
+
+func (dp *DataPlane) addToLists(listMetadatas, setMetadatas []*ipsets.IPSetMetadata) error {
+	if err := dp.checkForListMembers(listMetadatas, setMetadatas, npmerrors.AppendIPSet); err != nil {
+		return err
+	}
+
+	for _, listMetadata := range listMetadatas {
+		listName := listMetadata.GetPrefixName()
+		list := dp.setMap[listName]
+		for _, setMetadata := range setMetadatas {
+			memberName := setMetadata.GetPrefixName()
+			if _, ok := list.memberSets[memberName]; ok {
+				continue
+			}
+			list.memberSets[memberName] = struct{}{}
+			dp.setMap[memberName].memberOf[listName] = struct{}{}
+			dp.dirty = true
+			metrics.AddEntryToIPSet(listName)
+		}
+	}
+	return nil
+}
+
+// RemoveFromList removes the hash sets from the members of the list set
+func (dp *DataPlane) RemoveFromList(listMetadata *ipsets.IPSetMetadata, setMetadatas []*ipsets.IPSetMetadata) error {
+	dp.Lock()
+	defer dp.Unlock()
+	return dp.removeFromList(listMetadata, setMetadatas)
+}
+
+func (dp *DataPlane) removeFromList(listMetadata *ipsets.IPSetMetadata, setMetadatas []*ipsets.IPSetMetadata) error {
+	if err := dp.checkForListMembers([]*ipsets.IPSetMetadata{listMetadata}, setMetadatas, npmerrors.DeleteIPSet); err != nil {
+		return err
+	}
+
+	listName := listMetadata.GetPrefixName()
+	list := dp.setMap[listName]
+	for _, setMetadata := range setMetadatas {
+		memberName := setMetadata.GetPrefixName()
+		if _, ok := list.memberSets[memberName]; !ok {
+			continue
+		}
+		delete(list.memberSets, memberName)
+		delete(dp.setMap[memberName].memberOf, listName)
+		dp.dirty = true
+		metrics.RemoveEntryFromIPSet(listName)
+	}
+	return nil
+}
+
+// ApplyDataPlane replaces the azure-npm table with the cached sets and policies in one nft transaction.
+// It is a no-op if nothing changed since the last apply.
+func (dp *DataPlane) ApplyDataPlane() error {
+	dp.Lock()
+	defer dp.Unlock()
+	if err := dp.applyDataPlane(); err != nil {
+		return fmt.Errorf("[DataPlane] error while applying nftables: %w", err)
+	}
+	return nil
+}
+
+func (dp *DataPlane) applyDataPlane() error {
+	if !dp.dirty {
+		klog.Info("[DataPlane] No nftables changes to apply")
+		return nil
+	}
+
+	creator := dp.getCreatorForRuleset()
+	if err := restore(creator); err != nil {
+		return err
+	}
+	dp.dirty = false
+	return nil
+}
+
+// AddPolicy caches the policy along with its IPSets and applies the dataplane
+func (dp *DataPlane) AddPolicy(policy *policies.NPMNetworkPolicy) error {
+	klog.Infof("[DataPlane] Add Policy called for %s", policy.Name)
+	dp.Lock()
+	defer dp.Unlock()
+
+	if err := dp.addPolicy(policy); err != nil {
+		return err
+	}
+	if err := dp.applyDataPlane(); err != nil {
+		return npmerrors.ErrorWrapper(npmerrors.AddPolicy, false, fmt.Sprintf("failed to apply policy %s", policy.Name), err)
+	}
+	return nil
+}
+
+// RemovePolicy removes the policy and the IPSets only it referenced, then applies the dataplane
+func (dp *DataPlane) RemovePolicy(policyName string) error {
+	klog.Infof("[DataPlane] Remove Policy called for %s", policyName)
+	dp.Lock()
+	defer dp.Unlock()
+
+	if _, ok := dp.policyMap[policyName]; !ok {
+		klog.Infof("[DataPlane] Policy %s is not found. Might been deleted already", policyName)
+		return nil
+	}
+	if err := dp.removePolicy(policyName); err != nil {
+		return err
+	}
+	if err := dp.applyDataPlane(); err != nil {
+		return npmerrors.ErrorWrapper(npmerrors.RemovePolicy, false, fmt.Sprintf("failed to remove policy %s", policyName), err)
+	}
+	return nil
+}
+
+// UpdatePolicy replaces the cached policy. Unlike the iptables dataplane, the old and new policy
+// are swapped in the same transaction, so there is no window where neither is programmed.
+func (dp *DataPlane) UpdatePolicy(policy *policies.NPMNetworkPolicy) error {
+	klog.Infof("[DataPlane] Update Policy called for %s", policy.Name)
+	dp.Lock()
+	defer dp.Unlock()
+
+	if _, ok := dp.policyMap[policy.Name]; ok {
+		if err := dp.removePolicy(policy.Name); err != nil {
+			return fmt.Errorf("[DataPlane] error while updating policy: %w", err)
+		}
+	}
+	if err := dp.addPolicy(policy); err != nil {
+		return fmt.Errorf("[DataPlane] error while updating policy: %w", err)
+	}
+	if err := dp.applyDataPlane(); err != nil {
+		return npmerrors.ErrorWrapper(npmerrors.AddPolicy, false, fmt.Sprintf("failed to update policy %s", policy.Name), err)
+	}
+	return nil
+}
+
+func (dp *DataPlane) addPolicy(policy *policies.NPMNetworkPolicy) error {
+	if _, ok := dp.policyMap[policy.Name]; ok {
+		return npmerrors.Errorf(npmerrors.AddPolicy, false, fmt.Sprintf("policy %s already exists", policy.Name))
+	}
+
+	for _, translatedSets := range [][]*ipsets.TranslatedIPSet{policy.PodSelectorIPSets, policy.RuleIPSets} {
+		for _, translatedSet := range translatedSets {
+			s := dp.createIPSet(translatedSet.Metadata)
+			s.references[policy.Name] = struct{}{}
+		}
+		if err := dp.addTranslatedMembers(translatedSets); err != nil {
+			return npmerrors.Errorf(npmerrors.AddPolicy, false, fmt.Sprintf("failed to add members of policy %s ipsets: %s", policy.Name, err.Error()))
+		}
+	}
+	// every set a rule matches on must exist for the nft transaction to succeed
+	for _, setMetadata := range getACLSetMetadatas(policy) {
+		s := dp.createIPSet(setMetadata)
+		s.references[policy.Name] = struct{}{}
+	}
+
+	dp.policyMap[policy.Name] = policy
+	dp.dirty = true
+	metrics.IncNumPolicies()
+	return nil
+}
+
+func (dp *DataPlane) removePolicy(policyName string) error {
+	policy := dp.policyMap[policyName]
+	delete(dp.policyMap, policyName)
+	dp.dirty = true
+	metrics.DecNumPolicies()
+
+	for _, setMetadata := range getACLSetMetadatas(policy) {
+		prefixedName := setMetadata.GetPrefixName()
+		if s, ok := dp.setMap[prefixedName]; ok {
+			delete(s.references, policyName)
+			dp.deleteIPSet(prefixedName)
+		}
+	}
+	for _, translatedSets := range [][]*ipsets.TranslatedIPSet{policy.RuleIPSets, policy.PodSelectorIPSets} {
+		for _, translatedSet := range translatedSets {
+			if s, ok := dp.setMap[translatedSet.Metadata.GetPrefixName()]; ok {
+				delete(s.references, policyName)
+			}
+		}
+		if err := dp.removeTranslatedMembers(translatedSets); err != nil {
+			return npmerrors.Errorf(npmerrors.RemovePolicy, false, fmt.Sprintf("failed to remove members of policy %s ipsets: %s", policyName, err.Error()))
+		}
+		for _, translatedSet := range translatedSets {
+			dp.deleteIPSet(translatedSet.Metadata.GetPrefixName())
+		}
+	}
+	return nil
+}
+
+// addTranslatedMembers adds the CIDRs of CIDRBlocks sets and the members of NestedLabelOfPod sets
+// generated by the translation engine.
+func (dp *DataPlane) addTranslatedMembers(translatedSets []*ipsets.TranslatedIPSet) error {
+	for _, translatedSet := range translatedSets {
+		switch {
+		case translatedSet.Metadata.Type == ipsets.CIDRBlocks:
+			for _, cidr := range translatedSet.Members {
+				if _, err := parseCIDRMember(cidr); err != nil {
+					return err
+				}
+				if err := dp.addToSets([]*ipsets.IPSetMetadata{translatedSet.Metadata}, cidr, ""); err != nil {
+					return err
+				}
+			}
+		case translatedSet.Metadata.Type == ipsets.NestedLabelOfPod && len(translatedSet.Members) > 0:
+			if err := dp.addToLists([]*ipsets.IPSetMetadata{translatedSet.Metadata}, getMembersOfTranslatedSet(translatedSet)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeTranslatedMembers undoes addTranslatedMembers for sets which no policy references anymore.
+func (dp *DataPlane) removeTranslatedMembers(translatedSets []*ipsets.TranslatedIPSet) error {
+	for _, translatedSet := range translatedSets {
+		if s, ok := dp.setMap[translatedSet.Metadata.GetPrefixName()]; !ok || len(s.references) > 0 {
+			continue
+		}
+		switch {
+		case translatedSet.Metadata.Type == ipsets.CIDRBlocks:
+			for _, cidr := range translatedSet.Members {
+				if err := dp.removeFromSets([]*ipsets.IPSetMetadata{translatedSet.Metadata}, cidr, ""); err != nil {
+					return err
+				}
+			}
+		case translatedSet.Metadata.GetSetKind() == ipsets.ListSet && len(translatedSet.Members) > 0:
+			if err := dp.removeFromList(translatedSet.Metadata, getMembersOfTranslatedSet(translatedSet)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getACLSetMetadatas(policy *policies.NPMNetworkPolicy) []*ipsets.IPSetMetadata {
+	setMetadatas := make([]*ipsets.IPSetMetadata, 0)
+	for _, aclPolicy := range policy.ACLs {
+		for _, setInfo := range append(append([]policies.SetInfo{}, aclPolicy.SrcList...), aclPolicy.DstList...) {
+			setMetadatas = append(setMetadatas, setInfo.IPSet)
+		}
+	}
+	return setMetadatas
+}
+
+func getMembersOfTranslatedSet(translatedSet *ipsets.TranslatedIPSet) []*ipsets.IPSetMetadata {
+	members := make([]*ipsets.IPSetMetadata, 0, len(translatedSet.Members))
+	for _, member := range translatedSet.Members {
+		// translate engine only returns KeyValueLabelOfPod as member
+		members = append(members, ipsets.NewIPSetMetadata(member, ipsets.KeyValueLabelOfPod))
+	}
+	return members
+}
+
+func (dp *DataPlane) checkForHashSets(setMetadatas []*ipsets.IPSetMetadata, npmErrorString string) error {
+	for _, setMetadata := range setMetadatas {
+		s := dp.createIPSet(setMetadata)
+		if s.metadata.GetSetKind() != ipsets.HashSet {
+			return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("ipset %s is not a hash set", setMetadata.GetPrefixName()))
+		}
+	}
+	return nil
+}
+
+func (dp *DataPlane) checkForListMembers(listMetadatas, setMetadatas []*ipsets.IPSetMetadata, npmErrorString string) error {
+	for _, listMetadata := range listMetadatas {
+		list := dp.createIPSet(listMetadata)
+		if list.metadata.GetSetKind() != ipsets.ListSet {
+			return npmerrors.Errorf(npmErrorString, false, fmt.Sprintf("ipset %s is not a list set", listMetadata.GetPrefixName()))
+		}
+	}
+	return dp.checkForHashSets(setMetadatas, npmErrorString)
+}
+
+// sortedSetNames returns the prefixed names of the cached sets, so that rendering is deterministic.
+func (dp *DataPlane) sortedSetNames() []string {
+	names := make([]string, 0, len(dp.setMap))
+	for name := range dp.setMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (dp *DataPlane) sortedPolicies() []*policies.NPMNetworkPolicy {
+	names := make([]string, 0, len(dp.policyMap))
+	for name := range dp.policyMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sorted := make([]*policies.NPMNetworkPolicy, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, dp.policyMap[name])
+	}
+	return sorted
+}
+
+// parseCIDRMember parses a member of a CIDRBlocks set, which is a CIDR or an IP with an optional nomatch suffix.
+func parseCIDRMember(member string) (*net.IPNet, error) {
+	address := trimNomatch(member)
+	if ip := net.ParseIP(address); ip != nil {
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipset member %s: %w", member, err)
+	}
+	return ipNet, nil
+}
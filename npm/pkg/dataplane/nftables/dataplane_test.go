@@ -0,0 +1,98 @@
+package nftables
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCIDRPolicy = &policies.NPMNetworkPolicy{
+	Name: "ns1/cidrpolicy",
+	PodSelectorIPSets: []*ipsets.TranslatedIPSet{
+		{Metadata: ipsets.TestNSSet.Metadata},
+		{Metadata: ipsets.TestNestedLabelList.Metadata, Members: []string{"test-kvPod-set"}},
+	},
+	RuleIPSets: []*ipsets.TranslatedIPSet{
+		{Metadata: ipsets.TestCIDRSet.Metadata, Members: []string{"10.0.0.0/8", "10.1.0.0/16nomatch"}},
+	},
+	ACLs: []*policies.ACLPolicy{
+		{
+			PolicyID:  "cidrpolicy",
+			SrcList:   []policies.SetInfo{{IPSet: ipsets.TestCIDRSet.Metadata, Included: true, MatchType: policies.SrcMatch}},
+			Target:    policies.Allowed,
+			Direction: policies.Ingress,
+			Protocol:  policies.TCP,
+		},
+	},
+}
+
+func TestAddAndRemovePolicy(t *testing.T) {
+	calls := []testutils.TestCmd{fakeNftCommand, fakeNftCommand, fakeNftCommand, fakeNftCommand}
+	dp := newTestDataPlane(t, calls)
+
+	require.NoError(t, dp.AddPolicy(testCIDRPolicy))
+	require.Contains(t, dp.setMap, ipsets.TestCIDRSet.PrefixName)
+	assert.Len(t, dp.setMap[ipsets.TestCIDRSet.PrefixName].ipPodKey, 2)
+	require.Contains(t, dp.setMap, ipsets.TestNestedLabelList.PrefixName)
+	assert.Contains(t, dp.setMap[ipsets.TestNestedLabelList.PrefixName].memberSets, ipsets.TestKVPodSet.PrefixName)
+	require.Error(t, dp.addPolicy(testCIDRPolicy), "policy already exists")
+
+	// sets in use can't be deleted
+	dp.DeleteIPSet(ipsets.TestCIDRSet.Metadata)
+	assert.Contains(t, dp.setMap, ipsets.TestCIDRSet.PrefixName)
+
+	require.NoError(t, dp.RemovePolicy(testCIDRPolicy.Name))
+	assert.NotContains(t, dp.setMap, ipsets.TestCIDRSet.PrefixName)
+	assert.NotContains(t, dp.setMap, ipsets.TestNSSet.PrefixName)
+	assert.NotContains(t, dp.setMap, ipsets.TestNestedLabelList.PrefixName)
+	assert.Empty(t, dp.policyMap)
+
+	// removing a missing policy is a no-op
+	require.NoError(t, dp.RemovePolicy(testCIDRPolicy.Name))
+}
+
+func TestUpdatePolicyAppliesOnce(t *testing.T) {
+	calls := []testutils.TestCmd{fakeNftCommand, fakeNftCommand, fakeNftCommand, fakeNftCommand}
+	fexec := testutils.GetFakeExecWithScripts(calls)
+	defer testutils.VerifyCalls(t, fexec, calls)
+	dp, err := NewDataPlane("testnode", &common.IOShim{Exec: fexec})
+	require.NoError(t, err)
+
+	require.NoError(t, dp.AddPolicy(testCIDRPolicy))
+	updated := *testCIDRPolicy
+	updated.ACLs = []*policies.ACLPolicy{policies.TestNetworkPolicies[0].ACLs[3]}
+	require.NoError(t, dp.UpdatePolicy(&updated))
+	assert.Equal(t, &updated, dp.policyMap[testCIDRPolicy.Name])
+}
+
+func TestPodMembership(t *testing.T) {
+	dp := newTestDataPlane(t, []testutils.TestCmd{fakeNftCommand, fakeNftCommand, fakeNftFailureCommand})
+	podSets := []*ipsets.IPSetMetadata{ipsets.TestNSSet.Metadata, ipsets.TestKeyPodSet.Metadata}
+
+	require.NoError(t, dp.AddToSets(podSets, dataplane.NewPodMetadata("a/pod", "10.0.0.1", "testnode")))
+	require.Error(t, dp.AddToSets([]*ipsets.IPSetMetadata{ipsets.TestKVNSList.Metadata}, dataplane.NewPodMetadata("a/pod", "10.0.0.1", "testnode")))
+	require.NoError(t, dp.AddToLists([]*ipsets.IPSetMetadata{ipsets.TestKVNSList.Metadata}, []*ipsets.IPSetMetadata{ipsets.TestNSSet.Metadata}))
+	require.Error(t, dp.AddToLists([]*ipsets.IPSetMetadata{ipsets.TestNSSet.Metadata}, []*ipsets.IPSetMetadata{ipsets.TestKeyPodSet.Metadata}))
+
+	// a stale delete for an IP now owned by another pod is ignored
+	require.NoError(t, dp.AddToSets(podSets, dataplane.NewPodMetadata("b/pod", "10.0.0.1", "testnode")))
+	require.NoError(t, dp.RemoveFromSets(podSets, dataplane.NewPodMetadata("a/pod", "10.0.0.1", "testnode")))
+	assert.Equal(t, "b/pod", dp.setMap[ipsets.TestNSSet.PrefixName].ipPodKey["10.0.0.1"])
+
+	require.NoError(t, dp.RemoveFromSets(podSets, dataplane.NewPodMetadata("b/pod", "10.0.0.1", "testnode")))
+	dp.DeleteIPSet(ipsets.TestNSSet.Metadata)
+	assert.Contains(t, dp.setMap, ipsets.TestNSSet.PrefixName, "list member can't be deleted")
+	require.NoError(t, dp.RemoveFromList(ipsets.TestKVNSList.Metadata, []*ipsets.IPSetMetadata{ipsets.TestNSSet.Metadata}))
+	dp.DeleteIPSet(ipsets.TestNSSet.Metadata)
+	assert.NotContains(t, dp.setMap, ipsets.TestNSSet.PrefixName)
+
+	// the changes stay pending when nft fails
+	require.Error(t, dp.ApplyDataPlane())
+	assert.True(t, dp.dirty)
+}
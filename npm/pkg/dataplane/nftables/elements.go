@@ -0,0 +1,174 @@
+package nftables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/util"
+)
+
+var errInvalidMember = errors.New("invalid ipset member")
+
+// ipRange is an inclusive range of IPv4 addresses. uint64 avoids overflow at the end of the address space.
+type ipRange struct {
+	first, last uint64
+}
+
+type ipRanges []ipRange
+
+// add merges r into the sorted, non-overlapping ranges.
+func (ranges ipRanges) add(r ipRange) ipRanges {
+	result := append(ipRanges{}, ranges...)
+	result = append(result, r)
+	sort.Slice(result, func(i, j int) bool { return result[i].first < result[j].first })
+
+	merged := result[:1]
+	for _, next := range result[1:] {
+		last := &merged[len(merged)-1]
+		if next.first <= last.last+1 {
+			if next.last > last.last {
+				last.last = next.last
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// subtract removes r from the ranges.
+func (ranges ipRanges) subtract(r ipRange) ipRanges {
+	result := make(ipRanges, 0, len(ranges)+1)
+	for _, existing := range ranges {
+		if existing.last < r.first || existing.first > r.last {
+			result = append(result, existing)
+			continue
+		}
+		if existing.first < r.first {
+			result = append(result, ipRange{existing.first, r.first - 1})
+		}
+		if existing.last > r.last {
+			result = append(result, ipRange{r.last + 1, existing.last})
+		}
+	}
+	return result
+}
+
+// String formats the range as an nftables interval element, preferring an address or CIDR.
+func (r ipRange) String() string {
+	first := uint64ToIP(r.first)
+	size := r.last - r.first + 1
+	if size&(size-1) == 0 && r.first%size == 0 {
+		prefixLength := 8*net.IPv4len - bits.TrailingZeros64(size)
+		if prefixLength == 8*net.IPv4len {
+			return first.String()
+		}
+		return fmt.Sprintf("%s/%d", first, prefixLength)
+	}
+	return fmt.Sprintf("%s-%s", first, uint64ToIP(r.last))
+}
+
+func uint64ToIP(address uint64) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, uint32(address))
+	return ip
+}
+
+type cidrMember struct {
+	ipRange
+	prefixLength int
+	nomatch      bool
+}
+
+func trimNomatch(member string) string {
+	return strings.TrimSpace(strings.TrimSuffix(member, util.IpsetNomatch))
+}
+
+func parseIPv4Member(member string) (cidrMember, error) {
+	ipNet, err := parseCIDRMember(member)
+	if err != nil {
+		return cidrMember{}, err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return cidrMember{}, fmt.Errorf("%w: %s is not an IPv4 address", errInvalidMember, member)
+	}
+	prefixLength, _ := ipNet.Mask.Size()
+	first := uint64(binary.BigEndian.Uint32(ip.Mask(ipNet.Mask)))
+	return cidrMember{
+		ipRange:      ipRange{first, first + (1 << (8*net.IPv4len - prefixLength)) - 1},
+		prefixLength: prefixLength,
+		nomatch:      strings.HasSuffix(member, util.IpsetNomatch),
+	}, nil
+}
+
+// intervalElements converts the members of one or more hash:net sets into the elements of an
+// interval set matching the union of the sets. Within a set, ipset matches the most specific entry,
+// so a nomatch entry excludes its addresses from less specific entries but not from more specific ones.
+// Invalid members are skipped and reported in the returned error.
+func intervalElements(sets ...[]string) ([]string, error) {
+	var union ipRanges
+	var errs []string
+	for _, members := range sets {
+		cidrs := make([]cidrMember, 0, len(members))
+		for _, member := range members {
+			cidr, err := parseIPv4Member(member)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			cidrs = append(cidrs, cidr)
+		}
+		sort.SliceStable(cidrs, func(i, j int) bool { return cidrs[i].prefixLength < cidrs[j].prefixLength })
+
+		var ranges ipRanges
+		for _, cidr := range cidrs {
+			if cidr.nomatch {
+				ranges = ranges.subtract(cidr.ipRange)
+			} else {
+				ranges = ranges.add(cidr.ipRange)
+			}
+		}
+		for _, r := range ranges {
+			union = union.add(r)
+		}
+	}
+
+	elements := make([]string, 0, len(union))
+	for _, r := range union {
+		elements = append(elements, r.String())
+	}
+	if len(errs) > 0 {
+		return elements, fmt.Errorf("%w: %s", errInvalidMember, strings.Join(errs, ", "))
+	}
+	return elements, nil
+}
+
+// namedPortElements converts members of a hash:ip,port set, such as 10.0.0.1,8080 or 10.0.0.1,udp:53,
+// into ip . protocol . port elements. As with ipset, the protocol defaults to tcp.
+func namedPortElements(members []string) ([]string, error) {
+	sort.Strings(members)
+	elements := make([]string, 0, len(members))
+	var errs []string
+	for _, member := range members {
+		parts := strings.Split(member, ",")
+		if len(parts) != 2 || net.ParseIP(parts[0]).To4() == nil {
+			errs = append(errs, member)
+			continue
+		}
+		protocol, port := "tcp", parts[1]
+		if i := strings.Index(port, util.IpsetLabelDelimter); i >= 0 {
+			protocol, port = port[:i], port[i+1:]
+		}
+		elements = append(elements, fmt.Sprintf("%s . %s . %s", parts[0], protocol, port))
+	}
+	if len(errs) > 0 {
+		return elements, fmt.Errorf("%w: %s", errInvalidMember, strings.Join(errs, ", "))
+	}
+	return elements, nil
+}
@@ -0,0 +1,268 @@
+package nftables
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-container-networking/npm/metrics"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ioutil"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	"github.com/Azure/azure-container-networking/npm/util"
+	npmerrors "github.com/Azure/azure-container-networking/npm/util/errors"
+)
+
+const (
+	// the whole table is replaced in one transaction, so a failed line can't be skipped and retried
+	maxTryCount          = 1
+	lineErrorPattern     = "/dev/stdin:(\\d+):"
+	maxRuleCommentLength = 127
+
+	// the forward chain runs after the iptables filter table (priority 0), so that the jumps to
+	// KUBE-FORWARD and KUBE-SERVICES are evaluated first, as positionAzureChainJumpRule ensures for iptables
+	forwardChainPriority = 5
+
+	ipAddrType        = "ipv4_addr"
+	namedPortAddrType = "ipv4_addr . inet_proto . inet_service"
+)
+
+var matchTypeSelectors = map[policies.MatchType]string{
+	policies.SrcMatch:    "ip saddr",
+	policies.DstMatch:    "ip daddr",
+	policies.DstDstMatch: "ip daddr . meta l4proto . th dport",
+}
+
+// restore runs the nft script of the creator as a single transaction.
+func restore(creator *ioutil.FileCreator) error {
+	err := creator.RunCommandWithFile(util.Nft, util.NftFileFlag, util.NftStdin)
+	if err != nil {
+		return npmerrors.SimpleErrorWrapper("failed to run nft with file", err)
+	}
+	return nil
+}
+
+func (dp *DataPlane) newCreator() *ioutil.FileCreator {
+	creator := ioutil.NewFileCreator(dp.ioShim, maxTryCount, lineErrorPattern)
+	// declaring the table first makes the delete succeed when the table does not exist
+	creator.AddLine("", nil, "add", "table", util.NftFamily, util.NftTable)
+	creator.AddLine("", nil, "delete", "table", util.NftFamily, util.NftTable)
+	return creator
+}
+
+func (dp *DataPlane) getCreatorForReset() *ioutil.FileCreator {
+	return dp.newCreator()
+}
+
+// getCreatorForRuleset writes the whole azure-npm table. The chains mirror the iptables dataplane:
+// FORWARD jumps to AZURE-NPM for new connections, which goes through AZURE-NPM-INGRESS, AZURE-NPM-EGRESS
+// and AZURE-NPM-ACCEPT, and policy chains set the same drop and allow marks.
+func (dp *DataPlane) getCreatorForRuleset() *ioutil.FileCreator {
+	creator := dp.newCreator()
+	creator.AddLine("", nil, "add", "table", util.NftFamily, util.NftTable)
+
+	for _, prefixedName := range dp.sortedSetNames() {
+		dp.writeSet(creator, prefixedName)
+	}
+
+	sortedPolicies := dp.sortedPolicies()
+	writeChains(creator, sortedPolicies)
+	writeBaseRules(creator, sortedPolicies)
+	for _, policy := range sortedPolicies {
+		writeNetworkPolicyRules(creator, policy)
+	}
+	return creator
+}
+
+// writeSet writes a set along with its elements. A list set can't hold other sets in nftables,
+// so it holds the union of the elements of its members.
+func (dp *DataPlane) writeSet(creator *ioutil.FileCreator, prefixedName string) {
+	s := dp.setMap[prefixedName]
+	sectionID := "set-" + prefixedName
+
+	var elements []string
+	var err error
+	if s.metadata.Type == ipsets.NamedPorts {
+		creator.AddLine(sectionID, nil, "add", "set", util.NftFamily, util.NftTable, s.hashedName, "{", "type", namedPortAddrType+";", "}")
+		elements, err = namedPortElements(keys(s.ipPodKey))
+	} else {
+		creator.AddLine(sectionID, nil, "add", "set", util.NftFamily, util.NftTable, s.hashedName, "{", "type", ipAddrType+";", "flags", "interval;", "}")
+		members := make([][]string, 0, len(s.memberSets)+1)
+		members = append(members, keys(s.ipPodKey))
+		for memberName := range s.memberSets {
+			members = append(members, keys(dp.setMap[memberName].ipPodKey))
+		}
+		elements, err = intervalElements(members...)
+	}
+	if err != nil {
+		metrics.SendErrorLogAndMetric(util.IpsmID, "Error: skipping invalid members of set %s: %s", prefixedName, err.Error())
+	}
+
+	if len(elements) > 0 {
+		creator.AddLine(sectionID, nil, "add", "element", util.NftFamily, util.NftTable, s.hashedName, "{", strings.Join(elements, ", "), "}")
+	}
+}
+
+func writeChains(creator *ioutil.FileCreator, sortedPolicies []*policies.NPMNetworkPolicy) {
+	forwardChainSpecs := fmt.Sprintf("{ type filter hook forward priority %d; policy accept; }", forwardChainPriority)
+	addChain(creator, util.IptablesForwardChain, forwardChainSpecs)
+	addChain(creator, util.IptablesAzureChain)
+	addChain(creator, util.IptablesAzureIngressChain)
+	addChain(creator, util.IptablesAzureIngressAllowMarkChain)
+	addChain(creator, util.IptablesAzureEgressChain)
+	addChain(creator, util.IptablesAzureAcceptChain)
+
+	for _, policy := range sortedPolicies {
+		hasIngress, hasEgress := hasIngressAndEgress(policy)
+		if hasIngress {
+			addChain(creator, ingressChainName(policy))
+		}
+		if hasEgress {
+			addChain(creator, egressChainName(policy))
+		}
+	}
+}
+
+// writeBaseRules writes the rules of the NPM chains, with the jumps to the policy chains placed
+// before the rules acting on marks, like the jumps inserted at the top of the iptables chains.
+func writeBaseRules(creator *ioutil.FileCreator, sortedPolicies []*policies.NPMNetworkPolicy) {
+	addRule(creator, util.IptablesForwardChain, "ct state new", "jump", util.IptablesAzureChain)
+
+	addRule(creator, util.IptablesAzureChain, "jump", util.IptablesAzureIngressChain)
+	addRule(creator, util.IptablesAzureChain, "jump", util.IptablesAzureEgressChain)
+	addRule(creator, util.IptablesAzureChain, "jump", util.IptablesAzureAcceptChain)
+
+	for _, policy := range sortedPolicies {
+		if hasIngress, _ := hasIngressAndEgress(policy); hasIngress {
+			specs := append(getSelectorMatchSpecs(policy, policies.DstMatch), "jump", ingressChainName(policy))
+			addRule(creator, util.IptablesAzureIngressChain, specs...)
+		}
+	}
+	addRule(creator, util.IptablesAzureIngressChain, "meta mark", util.IptablesAzureIngressDropMarkHex, "drop",
+		getCommentSpecs("DROP-ON-INGRESS-DROP-MARK-"+util.IptablesAzureIngressDropMarkHex))
+
+	addRule(creator, util.IptablesAzureIngressAllowMarkChain, "meta mark set", util.IptablesAzureIngressAllowMarkHex,
+		getCommentSpecs("SET-INGRESS-ALLOW-MARK-"+util.IptablesAzureIngressAllowMarkHex))
+	addRule(creator, util.IptablesAzureIngressAllowMarkChain, "jump", util.IptablesAzureEgressChain)
+
+	for _, policy := range sortedPolicies {
+		if _, hasEgress := hasIngressAndEgress(policy); hasEgress {
+			specs := append(getSelectorMatchSpecs(policy, policies.SrcMatch), "jump", egressChainName(policy))
+			addRule(creator, util.IptablesAzureEgressChain, specs...)
+		}
+	}
+	// the marks are exact matches, so one verdict map replaces the iptables drop and accept rules
+	markVerdicts := fmt.Sprintf("{ %s : drop, %s : jump %s }",
+		util.IptablesAzureEgressDropMarkHex, util.IptablesAzureIngressAllowMarkHex, util.IptablesAzureAcceptChain)
+	addRule(creator, util.IptablesAzureEgressChain, "meta mark vmap", markVerdicts)
+
+	addRule(creator, util.IptablesAzureAcceptChain, "meta mark set", util.IptablesAzureClearMarkHex, getCommentSpecs("Clear-AZURE-NPM-MARKS"))
+	addRule(creator, util.IptablesAzureAcceptChain, "accept")
+}
+
+// writeNetworkPolicyRules writes the rules of the policy chain(s)
+func writeNetworkPolicyRules(creator *ioutil.FileCreator, policy *policies.NPMNetworkPolicy) {
+	for _, aclPolicy := range policy.ACLs {
+		if aclPolicy.Direction == policies.Ingress || aclPolicy.Direction == policies.Both {
+			actionSpecs := []string{"jump", util.IptablesAzureEgressChain}
+			if aclPolicy.Target != policies.Allowed {
+				actionSpecs = []string{"meta mark set", util.IptablesAzureIngressDropMarkHex}
+			}
+			addRule(creator, ingressChainName(policy), getACLRuleSpecs(aclPolicy, actionSpecs)...)
+		}
+		if aclPolicy.Direction == policies.Egress || aclPolicy.Direction == policies.Both {
+			actionSpecs := []string{"jump", util.IptablesAzureAcceptChain}
+			if aclPolicy.Target != policies.Allowed {
+				actionSpecs = []string{"meta mark set", util.IptablesAzureEgressDropMarkHex}
+			}
+			addRule(creator, egressChainName(policy), getACLRuleSpecs(aclPolicy, actionSpecs)...)
+		}
+	}
+}
+
+func getACLRuleSpecs(aclPolicy *policies.ACLPolicy, actionSpecs []string) []string {
+	specs := make([]string, 0)
+	if aclPolicy.Protocol != "" && aclPolicy.Protocol != policies.AnyProtocol {
+		specs = append(specs, "meta l4proto", string(aclPolicy.Protocol))
+	}
+	if ports := aclPolicy.DstPorts; ports.Port != 0 || ports.EndPort != 0 {
+		specs = append(specs, "th dport", getPortString(ports))
+	}
+	specs = append(specs, getSetMatchSpecs(aclPolicy.SrcList)...)
+	specs = append(specs, getSetMatchSpecs(aclPolicy.DstList)...)
+	specs = append(specs, actionSpecs...)
+	if aclPolicy.Comment != "" {
+		specs = append(specs, getCommentSpecs(aclPolicy.Comment))
+	}
+	return specs
+}
+
+func getPortString(ports policies.Ports) string {
+	if ports.EndPort == 0 || ports.Port == ports.EndPort {
+		return fmt.Sprint(ports.Port)
+	}
+	return fmt.Sprintf("%d-%d", ports.Port, ports.EndPort)
+}
+
+func getSelectorMatchSpecs(policy *policies.NPMNetworkPolicy, matchType policies.MatchType) []string {
+	specs := make([]string, 0, len(policy.PodSelectorIPSets))
+	for _, translatedSet := range policy.PodSelectorIPSets {
+		specs = append(specs, matchTypeSelectors[matchType], "@"+translatedSet.Metadata.GetHashedName())
+	}
+	return specs
+}
+
+func getSetMatchSpecs(setInfoList []policies.SetInfo) []string {
+	specs := make([]string, 0, len(setInfoList))
+	for _, setInfo := range setInfoList {
+		specs = append(specs, matchTypeSelectors[setInfo.MatchType])
+		if !setInfo.Included {
+			specs = append(specs, "!=")
+		}
+		specs = append(specs, "@"+setInfo.IPSet.GetHashedName())
+	}
+	return specs
+}
+
+func getCommentSpecs(comment string) string {
+	comment = strings.ReplaceAll(comment, `"`, "'")
+	if len(comment) > maxRuleCommentLength {
+		comment = comment[:maxRuleCommentLength]
+	}
+	return fmt.Sprintf("comment %q", comment)
+}
+
+func addChain(creator *ioutil.FileCreator, chainName string, specs ...string) {
+	line := append([]string{"add", "chain", util.NftFamily, util.NftTable, chainName}, specs...)
+	creator.AddLine("", nil, line...)
+}
+
+func addRule(creator *ioutil.FileCreator, chainName string, specs ...string) {
+	line := append([]string{"add", "rule", util.NftFamily, util.NftTable, chainName}, specs...)
+	creator.AddLine("", nil, line...)
+}
+
+// returns whether the network policy has ingress and egress ACLs respectively
+func hasIngressAndEgress(policy *policies.NPMNetworkPolicy) (hasIngress, hasEgress bool) {
+	for _, aclPolicy := range policy.ACLs {
+		hasIngress = hasIngress || aclPolicy.Direction == policies.Ingress || aclPolicy.Direction == policies.Both
+		hasEgress = hasEgress || aclPolicy.Direction == policies.Egress || aclPolicy.Direction == policies.Both
+	}
+	return
+}
+
+// the policy chains are named like those of the iptables dataplane
+func ingressChainName(policy *policies.NPMNetworkPolicy) string {
+	return fmt.Sprintf("%s-%s", util.IptablesAzureIngressPolicyChainPrefix, util.Hash(policy.Name))
+}
+
+func egressChainName(policy *policies.NPMNetworkPolicy) string {
+	return fmt.Sprintf("%s-%s", util.IptablesAzureEgressPolicyChainPrefix, util.Hash(policy.Name))
+}
+
+func keys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
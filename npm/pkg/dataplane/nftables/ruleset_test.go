@@ -0,0 +1,174 @@
+package nftables
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-container-networking/common"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/ipsets"
+	"github.com/Azure/azure-container-networking/npm/pkg/dataplane/policies"
+	dptestutils "github.com/Azure/azure-container-networking/npm/pkg/dataplane/testutils"
+	"github.com/Azure/azure-container-networking/npm/util"
+	testutils "github.com/Azure/azure-container-networking/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	fakeNftCommand        = testutils.TestCmd{Cmd: []string{"nft", "-f", "-"}}
+	fakeNftFailureCommand = testutils.TestCmd{
+		Cmd:      []string{"nft", "-f", "-"},
+		Stdout:   "/dev/stdin:3:1-5: Error: syntax error",
+		ExitCode: 1,
+	}
+
+	kubeAllNSSet = ipsets.CreateTestSet(util.KubeAllNamespacesFlag, ipsets.KeyLabelOfNamespace)
+
+	testPolicy1IngressChain = ingressChainName(policies.TestNetworkPolicies[0])
+	testPolicy1EgressChain  = egressChainName(policies.TestNetworkPolicies[0])
+	testPolicy2IngressChain = ingressChainName(policies.TestNetworkPolicies[1])
+	testPolicy3EgressChain  = egressChainName(policies.TestNetworkPolicies[2])
+
+	// the nftables equivalents of the rules in the iptables policy manager tests
+	testPolicy1IngressJump = fmt.Sprintf("ip daddr @%s jump %s", ipsets.TestKVNSList.HashedName, testPolicy1IngressChain)
+	testPolicy1EgressJump  = fmt.Sprintf("ip saddr @%s jump %s", ipsets.TestKVNSList.HashedName, testPolicy1EgressChain)
+	testPolicy2IngressJump = fmt.Sprintf("ip daddr @%s ip daddr @%s jump %s", ipsets.TestKVNSList.HashedName, ipsets.TestKeyPodSet.HashedName, testPolicy2IngressChain)
+	testPolicy3EgressJump  = fmt.Sprintf("jump %s", testPolicy3EgressChain)
+
+	testACLRule1 = fmt.Sprintf(
+		`meta l4proto tcp th dport 222-333 ip saddr @%s ip daddr != @%s meta mark set 0x4000 comment "comment1"`,
+		ipsets.TestCIDRSet.HashedName,
+		ipsets.TestKeyPodSet.HashedName,
+	)
+	testACLRule2 = fmt.Sprintf(`meta l4proto udp ip saddr @%s jump AZURE-NPM-EGRESS comment "comment2"`, ipsets.TestCIDRSet.HashedName)
+	testACLRule3 = fmt.Sprintf(`meta l4proto udp th dport 144 ip saddr @%s meta mark set 0x5000 comment "comment3"`, ipsets.TestCIDRSet.HashedName)
+	testACLRule4 = fmt.Sprintf(`ip saddr @%s jump AZURE-NPM-ACCEPT comment "comment4"`, ipsets.TestCIDRSet.HashedName)
+)
+
+func newTestDataPlane(t *testing.T, calls []testutils.TestCmd) *DataPlane {
+	dp, err := NewDataPlane("testnode", common.NewMockIOShim(calls))
+	require.NoError(t, err)
+	return dp
+}
+
+func TestRulesetForTestPolicies(t *testing.T) {
+	calls := []testutils.TestCmd{fakeNftCommand, fakeNftCommand, fakeNftCommand, fakeNftCommand, fakeNftCommand}
+	dp := newTestDataPlane(t, calls)
+	for _, policy := range policies.TestNetworkPolicies {
+		require.NoError(t, dp.AddPolicy(policy))
+	}
+
+	expectedLines := []string{
+		"add table inet azure-npm",
+		"delete table inet azure-npm",
+		"add table inet azure-npm",
+		// sets sorted by prefixed name
+		fmt.Sprintf("add set inet azure-npm %s { type ipv4_addr; flags interval; }", ipsets.TestCIDRSet.HashedName),
+		fmt.Sprintf("add set inet azure-npm %s { type ipv4_addr; flags interval; }", kubeAllNSSet.HashedName),
+		fmt.Sprintf("add set inet azure-npm %s { type ipv4_addr; flags interval; }", ipsets.TestKVNSList.HashedName),
+		fmt.Sprintf("add set inet azure-npm %s { type ipv4_addr; flags interval; }", ipsets.TestKeyPodSet.HashedName),
+		// chains
+		"add chain inet azure-npm FORWARD { type filter hook forward priority 5; policy accept; }",
+		"add chain inet azure-npm AZURE-NPM",
+		"add chain inet azure-npm AZURE-NPM-INGRESS",
+		"add chain inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK",
+		"add chain inet azure-npm AZURE-NPM-EGRESS",
+		"add chain inet azure-npm AZURE-NPM-ACCEPT",
+		"add chain inet azure-npm " + testPolicy1IngressChain,
+		"add chain inet azure-npm " + testPolicy1EgressChain,
+		"add chain inet azure-npm " + testPolicy2IngressChain,
+		"add chain inet azure-npm " + testPolicy3EgressChain,
+		// base rules
+		"add rule inet azure-npm FORWARD ct state new jump AZURE-NPM",
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-INGRESS",
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-EGRESS",
+		"add rule inet azure-npm AZURE-NPM jump AZURE-NPM-ACCEPT",
+		"add rule inet azure-npm AZURE-NPM-INGRESS " + testPolicy1IngressJump,
+		"add rule inet azure-npm AZURE-NPM-INGRESS " + testPolicy2IngressJump,
+		`add rule inet azure-npm AZURE-NPM-INGRESS meta mark 0x4000 drop comment "DROP-ON-INGRESS-DROP-MARK-0x4000"`,
+		`add rule inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK meta mark set 0x2000 comment "SET-INGRESS-ALLOW-MARK-0x2000"`,
+		"add rule inet azure-npm AZURE-NPM-INGRESS-ALLOW-MARK jump AZURE-NPM-EGRESS",
+		"add rule inet azure-npm AZURE-NPM-EGRESS " + testPolicy1EgressJump,
+		"add rule inet azure-npm AZURE-NPM-EGRESS " + testPolicy3EgressJump,
+		"add rule inet azure-npm AZURE-NPM-EGRESS meta mark vmap { 0x5000 : drop, 0x2000 : jump AZURE-NPM-ACCEPT }",
+		`add rule inet azure-npm AZURE-NPM-ACCEPT meta mark set 0x0 comment "Clear-AZURE-NPM-MARKS"`,
+		"add rule inet azure-npm AZURE-NPM-ACCEPT accept",
+		// policy 1
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy1IngressChain, testACLRule1),
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy1IngressChain, testACLRule2),
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy1EgressChain, testACLRule3),
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy1EgressChain, testACLRule4),
+		// policy 2
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy2IngressChain, testACLRule1),
+		// policy 3
+		fmt.Sprintf("add rule inet azure-npm %s %s", testPolicy3EgressChain, testACLRule4),
+		"",
+	}
+	expectedFileString := strings.Join(expectedLines, "\n")
+	dptestutils.AssertEqualMultilineStrings(t, expectedFileString, dp.getCreatorForRuleset().ToString())
+}
+
+func TestSetElements(t *testing.T) {
+	dp := newTestDataPlane(t, []testutils.TestCmd{fakeNftCommand, fakeNftCommand, fakeNftCommand})
+	require.NoError(t, dp.addToSets([]*ipsets.IPSetMetadata{ipsets.TestKeyPodSet.Metadata}, "10.0.0.5", "a/pod"))
+	require.NoError(t, dp.addToSets([]*ipsets.IPSetMetadata{ipsets.TestKVPodSet.Metadata}, "10.0.0.4", "b/pod"))
+	require.NoError(t, dp.addToLists([]*ipsets.IPSetMetadata{ipsets.TestNestedLabelList.Metadata},
+		[]*ipsets.IPSetMetadata{ipsets.TestKeyPodSet.Metadata, ipsets.TestKVPodSet.Metadata}))
+	require.NoError(t, dp.addToSets([]*ipsets.IPSetMetadata{ipsets.TestNamedportSet.Metadata}, "10.0.0.4,udp:53", "b/pod"))
+	require.NoError(t, dp.addToSets([]*ipsets.IPSetMetadata{ipsets.TestNamedportSet.Metadata}, "10.0.0.5,8080", "a/pod"))
+
+	fileString := dp.getCreatorForRuleset().ToString()
+	assert.Contains(t, fileString, fmt.Sprintf("add element inet azure-npm %s { 10.0.0.5 }\n", ipsets.TestKeyPodSet.HashedName))
+	// the list holds the union of its members
+	assert.Contains(t, fileString, fmt.Sprintf("add element inet azure-npm %s { 10.0.0.4/31 }\n", ipsets.TestNestedLabelList.HashedName))
+	assert.Contains(t, fileString, fmt.Sprintf("add set inet azure-npm %s { type ipv4_addr . inet_proto . inet_service; }\n", ipsets.TestNamedportSet.HashedName))
+	assert.Contains(t, fileString, fmt.Sprintf("add element inet azure-npm %s { 10.0.0.4 . udp . 53, 10.0.0.5 . tcp . 8080 }\n", ipsets.TestNamedportSet.HashedName))
+	// empty sets have no elements
+	assert.NotContains(t, fileString, "add element inet azure-npm "+kubeAllNSSet.HashedName)
+
+	require.NoError(t, dp.ApplyDataPlane())
+	require.NoError(t, dp.ApplyDataPlane(), "nothing changed, so nft should not run again")
+}
+
+func TestIntervalElements(t *testing.T) {
+	tests := []struct {
+		name    string
+		members [][]string
+		want    []string
+	}{
+		{
+			name:    "cidr with except",
+			members: [][]string{{"10.0.0.0/8", "10.1.0.0/16nomatch"}},
+			want:    []string{"10.0.0.0/16", "10.2.0.0-10.255.255.255"},
+		},
+		{
+			name:    "more specific entry inside except",
+			members: [][]string{{"10.1.2.0/24", "10.0.0.0/8", "10.1.0.0/16 nomatch"}},
+			want:    []string{"10.0.0.0/16", "10.1.2.0/24", "10.2.0.0-10.255.255.255"},
+		},
+		{
+			name:    "overlapping and adjacent entries",
+			members: [][]string{{"10.0.0.1", "10.0.0.0/31", "10.0.0.2/31"}, {"10.0.0.4"}},
+			want:    []string{"10.0.0.0-10.0.0.4"},
+		},
+		{
+			name:    "except only applies within its set",
+			members: [][]string{{"0.0.0.0/0", "10.0.0.0/8nomatch"}, {"10.0.0.1"}},
+			want:    []string{"0.0.0.0-9.255.255.255", "10.0.0.1", "11.0.0.0-255.255.255.255"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := intervalElements(tt.members...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := intervalElements([]string{"10.0.0.1", "fe80::1", "bad"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, got)
+}
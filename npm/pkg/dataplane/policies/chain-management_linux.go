@@ -72,10 +72,12 @@ func (pMgr *PolicyManager) reset() error {
 // AZURE-NPM chain is after the jumps to KUBE-FORWARD & KUBE-SERVICES chains (if they exist).
 func (pMgr *PolicyManager) initializeNPMChains() error {
 	klog.Infof("Initializing AZURE-NPM chains.")
-	creator := pMgr.getCreatorForInitChains()
-	err := restore(creator)
-	if err != nil {
-		return npmerrors.SimpleErrorWrapper("failed to create chains and rules", err)
+	for _, family := range pMgr.ipFamilies() {
+		creator := pMgr.getCreatorForInitChains()
+		err := restore(family, creator)
+		if err != nil {
+			return npmerrors.SimpleErrorWrapper(fmt.Sprintf("failed to create %s chains and rules", family.iptables), err)
+		}
 	}
 
 	// add the jump rule from FORWARD chain to AZURE-NPM chain
@@ -90,7 +92,16 @@ func (pMgr *PolicyManager) initializeNPMChains() error {
 // removeNPMChains removes the jump rule from FORWARD chain to AZURE-NPM chain
 // and flushes and deletes all NPM Chains.
 func (pMgr *PolicyManager) removeNPMChains() error {
-	deleteErrCode, deleteErr := pMgr.runIPTablesCommand(util.IptablesDeletionFlag, jumpFromForwardToAzureChainArgs...)
+	for _, family := range pMgr.ipFamilies() {
+		if err := pMgr.removeNPMChainsForFamily(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pMgr *PolicyManager) removeNPMChainsForFamily(family *ipFamily) error {
+	deleteErrCode, deleteErr := pMgr.runIPTablesCommand(family, util.IptablesDeletionFlag, jumpFromForwardToAzureChainArgs...)
 	hadDeleteError := deleteErr != nil && deleteErrCode != couldntLoadTargetErrorCode
 	if hadDeleteError {
 		baseErrString := "failed to delete jump from FORWARD chain to AZURE-NPM chain"
@@ -100,8 +111,8 @@ func (pMgr *PolicyManager) removeNPMChains() error {
 	}
 
 	// flush all chains (will create any chain, including deprecated ones, if they don't exist)
-	creatorToFlush, chainsToDelete := pMgr.getCreatorAndChainsForReset()
-	restoreError := restore(creatorToFlush)
+	creatorToFlush, chainsToDelete := pMgr.getCreatorAndChainsForReset(family)
+	restoreError := restore(family, creatorToFlush)
 	if restoreError != nil {
 		return npmerrors.SimpleErrorWrapper("failed to flush chains", restoreError)
 	}
@@ -109,7 +120,7 @@ func (pMgr *PolicyManager) removeNPMChains() error {
 	// TODO aggregate an error for each chain that failed to delete
 	var anyDeleteErr error
 	for _, chainName := range chainsToDelete {
-		errCode, err := pMgr.runIPTablesCommand(util.IptablesDestroyFlag, chainName)
+		errCode, err := pMgr.runIPTablesCommand(family, util.IptablesDestroyFlag, chainName)
 		if err != nil {
 			klog.Infof("couldn't delete chain %s with error [%v] and exit code [%d]", chainName, err, errCode)
 			anyDeleteErr = err
@@ -145,15 +156,15 @@ func (pMgr *PolicyManager) reconcileChains(stopChannel <-chan struct{}) {
 }
 
 // this function has a direct comparison in NPM v1 iptables manager (iptm.go)
-func (pMgr *PolicyManager) runIPTablesCommand(operationFlag string, args ...string) (int, error) {
+func (pMgr *PolicyManager) runIPTablesCommand(family *ipFamily, operationFlag string, args ...string) (int, error) {
 	allArgs := []string{util.IptablesWaitFlag, defaultlockWaitTimeInSeconds, operationFlag}
 	allArgs = append(allArgs, args...)
 
 	if operationFlag != util.IptablesCheckFlag {
-		klog.Infof("Executing iptables command with args %v", allArgs)
+		klog.Infof("Executing %s command with args %v", family.iptables, allArgs)
 	}
 
-	command := pMgr.ioShim.Exec.Command(util.Iptables, allArgs...)
+	command := pMgr.ioShim.Exec.Command(family.iptables, allArgs...)
 	output, err := command.CombinedOutput()
 
 	var exitError utilexec.ExitError
@@ -162,9 +173,9 @@ func (pMgr *PolicyManager) runIPTablesCommand(operationFlag string, args ...stri
 		allArgsString := strings.Join(allArgs, " ")
 		msgStr := strings.TrimSuffix(string(output), "\n")
 		if errCode > 0 && operationFlag != util.IptablesCheckFlag {
-			metrics.SendErrorLogAndMetric(util.IptmID, "Error: There was an error running command: [%s %s] Stderr: [%v, %s]", util.Iptables, allArgsString, exitError, msgStr)
+			metrics.SendErrorLogAndMetric(util.IptmID, "Error: There was an error running command: [%s %s] Stderr: [%v, %s]", family.iptables, allArgsString, exitError, msgStr)
 		}
-		return errCode, npmerrors.SimpleErrorWrapper(fmt.Sprintf("failed to run iptables command [%s %s] Stderr: [%s]", util.Iptables, allArgsString, msgStr), exitError)
+		return errCode, npmerrors.SimpleErrorWrapper(fmt.Sprintf("failed to run iptables command [%s %s] Stderr: [%s]", family.iptables, allArgsString, msgStr), exitError)
 	}
 	return 0, nil
 }
@@ -212,9 +223,18 @@ func (pMgr *PolicyManager) getCreatorForInitChains() *ioutil.FileCreator {
 }
 
 // add/reposition AZURE-NPM chain after KUBE-FORWARD and KUBE-SERVICE chains if they exist
-// this function has a direct comparison in NPM v1 iptables manager (iptm.go)
 func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
-	kubeServicesLine, kubeServicesLineNumErr := pMgr.getChainLineNumber(util.IptablesKubeServicesChain)
+	for _, family := range pMgr.ipFamilies() {
+		if err := pMgr.positionAzureChainJumpRuleForFamily(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// this function has a direct comparison in NPM v1 iptables manager (iptm.go)
+func (pMgr *PolicyManager) positionAzureChainJumpRuleForFamily(family *ipFamily) error {
+	kubeServicesLine, kubeServicesLineNumErr := pMgr.getChainLineNumber(family, util.IptablesKubeServicesChain)
 	if kubeServicesLineNumErr != nil {
 		// not possible to cover this branch currently because of testing limitations for pipeCommandToGrep()
 		baseErrString := "failed to get index of jump from KUBE-SERVICES chain to FORWARD chain with error"
@@ -225,7 +245,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 	index := kubeServicesLine + 1
 
 	// TODO could call getChainLineNumber instead, and say it doesn't exist for lineNum == 0
-	jumpRuleErrCode, checkErr := pMgr.runIPTablesCommand(util.IptablesCheckFlag, jumpFromForwardToAzureChainArgs...)
+	jumpRuleErrCode, checkErr := pMgr.runIPTablesCommand(family, util.IptablesCheckFlag, jumpFromForwardToAzureChainArgs...)
 	hadCheckError := checkErr != nil && jumpRuleErrCode != doesNotExistErrorCode
 	if hadCheckError {
 		baseErrString := "failed to check if jump from FORWARD chain to AZURE-NPM chain exists"
@@ -237,7 +257,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 	if !jumpRuleExists {
 		klog.Infof("Inserting jump from FORWARD chain to AZURE-NPM chain")
 		jumpRuleInsertionArgs := append([]string{util.IptablesForwardChain, strconv.Itoa(index)}, jumpToAzureChainArgs...)
-		if insertErrCode, insertErr := pMgr.runIPTablesCommand(util.IptablesInsertionFlag, jumpRuleInsertionArgs...); insertErr != nil {
+		if insertErrCode, insertErr := pMgr.runIPTablesCommand(family, util.IptablesInsertionFlag, jumpRuleInsertionArgs...); insertErr != nil {
 			baseErrString := "failed to insert jump from FORWARD chain to AZURE-NPM chain"
 			metrics.SendErrorLogAndMetric(util.IptmID, "Error: %s with error code %d and error %s", baseErrString, insertErrCode, insertErr.Error())
 			// FIXME update ID
@@ -251,7 +271,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 		return nil
 	}
 
-	npmChainLine, npmLineNumErr := pMgr.getChainLineNumber(util.IptablesAzureChain)
+	npmChainLine, npmLineNumErr := pMgr.getChainLineNumber(family, util.IptablesAzureChain)
 	if npmLineNumErr != nil {
 		// not possible to cover this branch currently because of testing limitations for pipeCommandToGrep()
 		baseErrString := "failed to get index of jump from FORWARD chain to AZURE-NPM chain"
@@ -268,7 +288,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 	// AZURE-NPM chain is before KUBE-SERVICES then
 	// delete existing jump rule and add it in the right order
 	metrics.SendErrorLogAndMetric(util.IptmID, "Info: Reconciler deleting and re-adding jump from FORWARD chain to AZURE-NPM chain table.")
-	if deleteErrCode, deleteErr := pMgr.runIPTablesCommand(util.IptablesDeletionFlag, jumpFromForwardToAzureChainArgs...); deleteErr != nil {
+	if deleteErrCode, deleteErr := pMgr.runIPTablesCommand(family, util.IptablesDeletionFlag, jumpFromForwardToAzureChainArgs...); deleteErr != nil {
 		baseErrString := "failed to delete jump from FORWARD chain to AZURE-NPM chain"
 		metrics.SendErrorLogAndMetric(util.IptmID, "Error: %s with error code %d and error %s", baseErrString, deleteErrCode, deleteErr.Error())
 		// FIXME update ID
@@ -280,7 +300,7 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 		index--
 	}
 	jumpRuleInsertionArgs := append([]string{util.IptablesForwardChain, strconv.Itoa(index)}, jumpToAzureChainArgs...)
-	if insertErrCode, insertErr := pMgr.runIPTablesCommand(util.IptablesInsertionFlag, jumpRuleInsertionArgs...); insertErr != nil {
+	if insertErrCode, insertErr := pMgr.runIPTablesCommand(family, util.IptablesInsertionFlag, jumpRuleInsertionArgs...); insertErr != nil {
 		baseErrString := "after deleting, failed to insert jump from FORWARD chain to AZURE-NPM chain"
 		// FIXME update ID
 		metrics.SendErrorLogAndMetric(util.IptmID, "Error: %s with error code %d and error %s", baseErrString, insertErrCode, insertErr.Error())
@@ -292,9 +312,9 @@ func (pMgr *PolicyManager) positionAzureChainJumpRule() error {
 
 // returns 0 if the chain d.n.e.
 // this function has a direct comparison in NPM v1 iptables manager (iptm.go)
-func (pMgr *PolicyManager) getChainLineNumber(chain string) (int, error) {
+func (pMgr *PolicyManager) getChainLineNumber(family *ipFamily, chain string) (int, error) {
 	// TODO could call this once and use regex instead of grep to cut down on OS calls
-	listForwardEntriesCommand := pMgr.ioShim.Exec.Command(util.Iptables,
+	listForwardEntriesCommand := pMgr.ioShim.Exec.Command(family.iptables,
 		util.IptablesWaitFlag, defaultlockWaitTimeInSeconds, util.IptablesTableFlag, util.IptablesFilterTable,
 		util.IptablesNumericFlag, util.IptablesListFlag, util.IptablesForwardChain, util.IptablesLineNumbersFlag,
 	)
@@ -343,8 +363,8 @@ func pipeCommandToGrep(command, grepCommand utilexec.Cmd) (searchResults []byte,
 }
 
 // make this a function for easier testing
-func (pMgr *PolicyManager) getCreatorAndChainsForReset() (creator *ioutil.FileCreator, chainsToFlush []string) {
-	oldPolicyChains, err := pMgr.getPolicyChainNames()
+func (pMgr *PolicyManager) getCreatorAndChainsForReset(family *ipFamily) (creator *ioutil.FileCreator, chainsToFlush []string) {
+	oldPolicyChains, err := pMgr.getPolicyChainNames(family)
 	if err != nil {
 		// not possible to cover this branch currently because of testing limitations for pipeCommandToGrep()
 		metrics.SendErrorLogAndMetric(util.IptmID, "Error: failed to determine NPM ingress/egress policy chains to delete")
@@ -356,8 +376,8 @@ func (pMgr *PolicyManager) getCreatorAndChainsForReset() (creator *ioutil.FileCr
 	return
 }
 
-func (pMgr *PolicyManager) getPolicyChainNames() ([]string, error) {
-	iptablesListCommand := pMgr.ioShim.Exec.Command(util.Iptables,
+func (pMgr *PolicyManager) getPolicyChainNames(family *ipFamily) ([]string, error) {
+	iptablesListCommand := pMgr.ioShim.Exec.Command(family.iptables,
 		util.IptablesWaitFlag, defaultlockWaitTimeInSeconds, util.IptablesTableFlag, util.IptablesFilterTable,
 		util.IptablesNumericFlag, util.IptablesListFlag,
 	)
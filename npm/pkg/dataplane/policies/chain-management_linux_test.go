@@ -12,7 +12,7 @@ import (
 )
 
 func TestInitChainsCreator(t *testing.T) {
-	pMgr := NewPolicyManager(common.NewMockIOShim(nil))
+	pMgr := NewPolicyManager(common.NewMockIOShim(nil), ipv4OnlyCfg)
 	creator := pMgr.getCreatorForInitChains() // doesn't make any exec calls
 	actualFileString := creator.ToString()
 	expectedLines := []string{"*filter"}
@@ -39,13 +39,13 @@ func TestInitChainsCreator(t *testing.T) {
 
 func TestInitChainsSuccess(t *testing.T) {
 	calls := GetInitializeTestCalls()
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.initializeNPMChains())
 }
 
 func TestInitChainsFailureOnRestore(t *testing.T) {
 	calls := []testutils.TestCmd{fakeIPTablesRestoreFailureCommand}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.initializeNPMChains())
 }
 
@@ -64,7 +64,7 @@ func TestInitChainsFailureOnPosition(t *testing.T) {
 		},
 		{Cmd: []string{"iptables", "-w", "60", "-C", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.initializeNPMChains())
 }
 
@@ -78,8 +78,8 @@ func TestRemoveChainsCreator(t *testing.T) {
 		{Cmd: []string{"grep", ingressOrEgressPolicyChainPattern}},
 	}
 
-	pMgr := NewPolicyManager(common.NewMockIOShim(creatorCalls))
-	creator, chainsToFlush := pMgr.getCreatorAndChainsForReset()
+	pMgr := NewPolicyManager(common.NewMockIOShim(creatorCalls), ipv4OnlyCfg)
+	creator, chainsToFlush := pMgr.getCreatorAndChainsForReset(ipv4Family)
 	expectedChainsToFlush := []string{
 		"AZURE-NPM",
 		"AZURE-NPM-INGRESS",
@@ -121,7 +121,7 @@ func TestRemoveChainsSuccess(t *testing.T) {
 		getFakeDestroyCommand("AZURE-NPM-EGRESS-123456"),
 	)
 
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.removeNPMChains())
 }
 
@@ -132,7 +132,7 @@ func TestRemoveChainsFailureOnDelete(t *testing.T) {
 			ExitCode: 1, // delete failure
 		},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.removeNPMChains())
 }
 
@@ -150,7 +150,7 @@ func TestRemoveChainsFailureOnRestore(t *testing.T) {
 		},
 		fakeIPTablesRestoreFailureCommand, // the exit code doesn't matter for this command since it receives the exit code of the command above
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.removeNPMChains())
 }
 
@@ -174,7 +174,7 @@ func TestRemoveChainsFailureOnDestroy(t *testing.T) {
 		getFakeDestroyCommand("AZURE-NPM-INGRESS-123456"),
 		getFakeDestroyCommand("AZURE-NPM-EGRESS-123456"),
 	)
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.removeNPMChains())
 }
 
@@ -193,7 +193,7 @@ func TestPositionJumpWhenNoChainsExist(t *testing.T) {
 		{Cmd: []string{"iptables", "-w", "60", "-C", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 		{Cmd: []string{"iptables", "-w", "60", "-I", "FORWARD", "1", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -207,7 +207,7 @@ func TestPositionJumpWhenOnlyAzureExists(t *testing.T) {
 		{Cmd: []string{"grep", "KUBE-SERVICES"}}, // ExitCode 0 for the iptables check command below
 		{Cmd: []string{"iptables", "-w", "60", "-C", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -226,7 +226,7 @@ func TestPositionJumpWhenOnlyKubeServicesExists(t *testing.T) {
 		{Cmd: []string{"iptables", "-w", "60", "-C", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 		{Cmd: []string{"iptables", "-w", "60", "-I", "FORWARD", "4", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -248,7 +248,7 @@ func TestPositionJumpWhenOnlyKubeServicesExistsAndInsertFails(t *testing.T) {
 		},
 		{Cmd: []string{"iptables", "-w", "60", "-I", "FORWARD", "4", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -268,7 +268,7 @@ func TestPositionJumpWhenAzureAfterKubeServices(t *testing.T) {
 		{Cmd: listLineNumbersCommandStrings},
 		{Cmd: []string{"grep", "AZURE-NPM"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -290,7 +290,7 @@ func TestPositionJumpWhenAzureBeforeKubeServices(t *testing.T) {
 		{Cmd: []string{"iptables", "-w", "60", "-D", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 		{Cmd: []string{"iptables", "-w", "60", "-I", "FORWARD", "3", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.NoError(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -315,7 +315,7 @@ func TestPositionJumpWhenAzureBeforeKubeServicesAndDeleteFails(t *testing.T) {
 		{Cmd: []string{"grep", "AZURE-NPM"}},
 		{Cmd: []string{"iptables", "-w", "60", "-D", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -340,7 +340,7 @@ func TestPositionJumpWhenAzureBeforeKubeServicesAndInsertFails(t *testing.T) {
 		{Cmd: []string{"iptables", "-w", "60", "-D", "FORWARD", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 		{Cmd: []string{"iptables", "-w", "60", "-I", "FORWARD", "3", "-j", "AZURE-NPM", "-m", "conntrack", "--ctstate", "NEW"}},
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	require.Error(t, pMgr.positionAzureChainJumpRule())
 }
 
@@ -357,8 +357,8 @@ func TestGetChainLineNumber(t *testing.T) {
 		},
 		grepCommand,
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
-	lineNum, err := pMgr.getChainLineNumber(testChainName)
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
+	lineNum, err := pMgr.getChainLineNumber(ipv4Family, testChainName)
 	require.Equal(t, 3, lineNum)
 	require.NoError(t, err)
 
@@ -370,8 +370,8 @@ func TestGetChainLineNumber(t *testing.T) {
 		},
 		grepCommand,
 	}
-	pMgr = NewPolicyManager(common.NewMockIOShim(calls))
-	lineNum, err = pMgr.getChainLineNumber(testChainName)
+	pMgr = NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
+	lineNum, err = pMgr.getChainLineNumber(ipv4Family, testChainName)
 	require.Equal(t, 0, lineNum)
 	require.NoError(t, err)
 }
@@ -386,8 +386,8 @@ func TestGetPolicyChainNames(t *testing.T) {
 		},
 		grepCommand,
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
-	chainNames, err := pMgr.getPolicyChainNames()
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
+	chainNames, err := pMgr.getPolicyChainNames(ipv4Family)
 	expectedChainNames := []string{
 		"AZURE-NPM-INGRESS-123456",
 		"AZURE-NPM-EGRESS-123456",
@@ -403,8 +403,8 @@ func TestGetPolicyChainNames(t *testing.T) {
 		},
 		grepCommand,
 	}
-	pMgr = NewPolicyManager(common.NewMockIOShim(calls))
-	chainNames, err = pMgr.getPolicyChainNames()
+	pMgr = NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
+	chainNames, err = pMgr.getPolicyChainNames(ipv4Family)
 	expectedChainNames = nil
 	require.Equal(t, expectedChainNames, chainNames)
 	require.NoError(t, err)
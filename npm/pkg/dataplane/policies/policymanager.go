@@ -15,14 +15,21 @@ type PolicyMap struct {
 type PolicyManager struct {
 	policyMap *PolicyMap
 	ioShim    *common.IOShim
+	pMgrCfg   *PolicyManagerCfg
 }
 
-func NewPolicyManager(ioShim *common.IOShim) *PolicyManager {
+type PolicyManagerCfg struct {
+	// EnableIPv6 programs ip6tables alongside iptables on Linux, matching the IPv6 counterpart of each ipset.
+	EnableIPv6 bool
+}
+
+func NewPolicyManager(ioShim *common.IOShim, pMgrCfg *PolicyManagerCfg) *PolicyManager {
 	return &PolicyManager{
 		policyMap: &PolicyMap{
 			cache: make(map[string]*NPMNetworkPolicy),
 		},
-		ioShim: ioShim,
+		ioShim:  ioShim,
+		pMgrCfg: pMgrCfg,
 	}
 }
 
@@ -18,34 +18,67 @@ const (
 	maxLengthForMatchSetSpecs = 6       // 5-6 elements depending on Included boolean
 )
 
+// ipFamily holds what differs between programming iptables and ip6tables.
+// Both families get the same chains and rules, but each matches its own family's ipsets.
+type ipFamily struct {
+	iptables        string
+	iptablesRestore string
+	hashedSetName   func(prefixedName string) string
+}
+
+var (
+	ipv4Family = &ipFamily{
+		iptables:        util.Iptables,
+		iptablesRestore: util.IptablesRestore,
+		hashedSetName:   util.GetHashedName,
+	}
+	ipv6Family = &ipFamily{
+		iptables:        util.Ip6tables,
+		iptablesRestore: util.Ip6tablesRestore,
+		hashedSetName:   util.GetHashedNameV6,
+	}
+)
+
+// ipFamilies returns the families to program, always starting with IPv4.
+func (pMgr *PolicyManager) ipFamilies() []*ipFamily {
+	if pMgr.pMgrCfg != nil && pMgr.pMgrCfg.EnableIPv6 {
+		return []*ipFamily{ipv4Family, ipv6Family}
+	}
+	return []*ipFamily{ipv4Family}
+}
+
 // shouldn't call this if the np has no ACLs (check in generic)
 func (pMgr *PolicyManager) addPolicy(networkPolicy *NPMNetworkPolicy, _ map[string]string) error {
 	// TODO check for newPolicy errors
-	creator := pMgr.getCreatorForNewNetworkPolicies(networkPolicy)
-	err := restore(creator)
-	if err != nil {
-		return npmerrors.SimpleErrorWrapper("failed to restore iptables with updated policies", err)
+	for _, family := range pMgr.ipFamilies() {
+		creator := pMgr.getCreatorForNewNetworkPolicies(family, networkPolicy)
+		err := restore(family, creator)
+		if err != nil {
+			return npmerrors.SimpleErrorWrapper(fmt.Sprintf("failed to restore %s with updated policies", family.iptables), err)
+		}
 	}
 	return nil
 }
 
 func (pMgr *PolicyManager) removePolicy(networkPolicy *NPMNetworkPolicy, _ map[string]string) error {
-	deleteErr := pMgr.deleteOldJumpRulesOnRemove(networkPolicy)
-	if deleteErr != nil {
-		return npmerrors.SimpleErrorWrapper("failed to delete jumps to policy chains", deleteErr)
-	}
-	creator := pMgr.getCreatorForRemovingPolicies(networkPolicy)
-	restoreErr := restore(creator)
-	if restoreErr != nil {
-		return npmerrors.SimpleErrorWrapper("failed to flush policies", restoreErr)
+	for _, family := range pMgr.ipFamilies() {
+		deleteErr := pMgr.deleteOldJumpRulesOnRemove(family, networkPolicy)
+		if deleteErr != nil {
+			return npmerrors.SimpleErrorWrapper("failed to delete jumps to policy chains", deleteErr)
+		}
+		creator := pMgr.getCreatorForRemovingPolicies(networkPolicy)
+		restoreErr := restore(family, creator)
+		if restoreErr != nil {
+			return npmerrors.SimpleErrorWrapper("failed to flush policies", restoreErr)
+		}
 	}
 	return nil
 }
 
-func restore(creator *ioutil.FileCreator) error {
-	err := creator.RunCommandWithFile(util.IptablesRestore, util.IptablesRestoreTableFlag, util.IptablesFilterTable, util.IptablesRestoreNoFlushFlag)
+func restore(family *ipFamily, creator *ioutil.FileCreator) error {
+	err := creator.RunCommandWithFile(family.iptablesRestore, util.IptablesRestoreTableFlag, util.IptablesFilterTable, util.IptablesRestoreNoFlushFlag)
 	if err != nil {
-		return npmerrors.SimpleErrorWrapper("failed to restore iptables file", err)
+		return npmerrors.SimpleErrorWrapper(fmt.Sprintf("failed to restore %s file", family.iptables), err)
 	}
 	return nil
 }
@@ -112,37 +145,37 @@ func (pMgr *PolicyManager) getNewCreatorWithChains(chainNames []string) *ioutil.
 }
 
 // will make a similar func for on update eventually
-func (pMgr *PolicyManager) deleteOldJumpRulesOnRemove(policy *NPMNetworkPolicy) error {
+func (pMgr *PolicyManager) deleteOldJumpRulesOnRemove(family *ipFamily, policy *NPMNetworkPolicy) error {
 	shouldDeleteIngress, shouldDeleteEgress := policy.hasIngressAndEgress()
 	if shouldDeleteIngress {
-		if err := pMgr.deleteJumpRule(policy, true); err != nil {
+		if err := pMgr.deleteJumpRule(family, policy, true); err != nil {
 			return err
 		}
 	}
 	if shouldDeleteEgress {
-		if err := pMgr.deleteJumpRule(policy, false); err != nil {
+		if err := pMgr.deleteJumpRule(family, policy, false); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (pMgr *PolicyManager) deleteJumpRule(policy *NPMNetworkPolicy, isIngress bool) error {
+func (pMgr *PolicyManager) deleteJumpRule(family *ipFamily, policy *NPMNetworkPolicy, isIngress bool) error {
 	var specs []string
 	var baseChainName string
 	var chainName string
 	if isIngress {
-		specs = getIngressJumpSpecs(policy)
+		specs = getIngressJumpSpecs(family, policy)
 		baseChainName = util.IptablesAzureIngressChain
 		chainName = policy.getIngressChainName()
 	} else {
-		specs = getEgressJumpSpecs(policy)
+		specs = getEgressJumpSpecs(family, policy)
 		baseChainName = util.IptablesAzureEgressChain
 		chainName = policy.getEgressChainName()
 	}
 
 	specs = append([]string{baseChainName}, specs...)
-	errCode, err := pMgr.runIPTablesCommand(util.IptablesDeletionFlag, specs...)
+	errCode, err := pMgr.runIPTablesCommand(family, util.IptablesDeletionFlag, specs...)
 	if err != nil && errCode != couldntLoadTargetErrorCode {
 		errorString := fmt.Sprintf("failed to delete jump from %s chain to %s chain for policy %s with exit code %d", baseChainName, chainName, policy.Name, errCode)
 		log.Errorf(errorString+": %w", err)
@@ -151,37 +184,37 @@ func (pMgr *PolicyManager) deleteJumpRule(policy *NPMNetworkPolicy, isIngress bo
 	return nil
 }
 
-func getIngressJumpSpecs(networkPolicy *NPMNetworkPolicy) []string {
+func getIngressJumpSpecs(family *ipFamily, networkPolicy *NPMNetworkPolicy) []string {
 	chainName := networkPolicy.getIngressChainName()
 	specs := []string{util.IptablesJumpFlag, chainName}
-	return append(specs, getMatchSetSpecsForNetworkPolicy(networkPolicy, DstMatch)...)
+	return append(specs, getMatchSetSpecsForNetworkPolicy(family, networkPolicy, DstMatch)...)
 }
 
-func getEgressJumpSpecs(networkPolicy *NPMNetworkPolicy) []string {
+func getEgressJumpSpecs(family *ipFamily, networkPolicy *NPMNetworkPolicy) []string {
 	chainName := networkPolicy.getEgressChainName()
 	specs := []string{util.IptablesJumpFlag, chainName}
-	return append(specs, getMatchSetSpecsForNetworkPolicy(networkPolicy, SrcMatch)...)
+	return append(specs, getMatchSetSpecsForNetworkPolicy(family, networkPolicy, SrcMatch)...)
 }
 
 // noflush add to chains impacted
-func (pMgr *PolicyManager) getCreatorForNewNetworkPolicies(networkPolicies ...*NPMNetworkPolicy) *ioutil.FileCreator {
+func (pMgr *PolicyManager) getCreatorForNewNetworkPolicies(family *ipFamily, networkPolicies ...*NPMNetworkPolicy) *ioutil.FileCreator {
 	allChainNames := getAllChainNames(networkPolicies)
 	creator := pMgr.getNewCreatorWithChains(allChainNames)
 
 	ingressJumpLineNumber := 1
 	egressJumpLineNumber := 1
 	for _, networkPolicy := range networkPolicies {
-		writeNetworkPolicyRules(creator, networkPolicy)
+		writeNetworkPolicyRules(family, creator, networkPolicy)
 
 		// add jump rule(s) to policy chain(s)
 		hasIngress, hasEgress := networkPolicy.hasIngressAndEgress()
 		if hasIngress {
-			ingressJumpSpecs := getInsertSpecs(util.IptablesAzureIngressChain, ingressJumpLineNumber, getIngressJumpSpecs(networkPolicy))
+			ingressJumpSpecs := getInsertSpecs(util.IptablesAzureIngressChain, ingressJumpLineNumber, getIngressJumpSpecs(family, networkPolicy))
 			creator.AddLine("", nil, ingressJumpSpecs...) // TODO error handler
 			ingressJumpLineNumber++
 		}
 		if hasEgress {
-			egressJumpSpecs := getInsertSpecs(util.IptablesAzureEgressChain, egressJumpLineNumber, getEgressJumpSpecs(networkPolicy))
+			egressJumpSpecs := getInsertSpecs(util.IptablesAzureEgressChain, egressJumpLineNumber, getEgressJumpSpecs(family, networkPolicy))
 			creator.AddLine("", nil, egressJumpSpecs...) // TODO error handler
 			egressJumpLineNumber++
 		}
@@ -191,7 +224,7 @@ func (pMgr *PolicyManager) getCreatorForNewNetworkPolicies(networkPolicies ...*N
 }
 
 // write rules for the policy chain(s)
-func writeNetworkPolicyRules(creator *ioutil.FileCreator, networkPolicy *NPMNetworkPolicy) {
+func writeNetworkPolicyRules(family *ipFamily, creator *ioutil.FileCreator, networkPolicy *NPMNetworkPolicy) {
 	for _, aclPolicy := range networkPolicy.ACLs {
 		var chainName string
 		var actionSpecs []string
@@ -212,17 +245,17 @@ func writeNetworkPolicyRules(creator *ioutil.FileCreator, networkPolicy *NPMNetw
 		}
 		line := []string{"-A", chainName}
 		line = append(line, actionSpecs...)
-		line = append(line, getIPTablesRuleSpecs(aclPolicy)...)
+		line = append(line, getIPTablesRuleSpecs(family, aclPolicy)...)
 		creator.AddLine("", nil, line...) // TODO add error handler
 	}
 }
 
-func getIPTablesRuleSpecs(aclPolicy *ACLPolicy) []string {
+func getIPTablesRuleSpecs(family *ipFamily, aclPolicy *ACLPolicy) []string {
 	specs := make([]string, 0)
 	specs = append(specs, util.IptablesProtFlag, string(aclPolicy.Protocol)) // NOTE: protocol must be ALL instead of nil
 	specs = append(specs, getPortSpecs([]Ports{aclPolicy.DstPorts})...)
-	specs = append(specs, getMatchSetSpecsFromSetInfo(aclPolicy.SrcList)...)
-	specs = append(specs, getMatchSetSpecsFromSetInfo(aclPolicy.DstList)...)
+	specs = append(specs, getMatchSetSpecsFromSetInfo(family, aclPolicy.SrcList)...)
+	specs = append(specs, getMatchSetSpecsFromSetInfo(family, aclPolicy.DstList)...)
 	if aclPolicy.Comment != "" {
 		specs = append(specs, getCommentSpecs(aclPolicy.Comment)...)
 	}
@@ -243,18 +276,18 @@ func getPortSpecs(portRanges []Ports) []string {
 	return []string{util.IptablesDstPortFlag, portRanges[0].toIPTablesString()}
 }
 
-func getMatchSetSpecsForNetworkPolicy(networkPolicy *NPMNetworkPolicy, matchType MatchType) []string {
+func getMatchSetSpecsForNetworkPolicy(family *ipFamily, networkPolicy *NPMNetworkPolicy, matchType MatchType) []string {
 	// TODO update to use included boolean/new data structure from Junguk's PR
 	specs := make([]string, 0, maxLengthForMatchSetSpecs*len(networkPolicy.PodSelectorIPSets))
 	for _, translatedIPSet := range networkPolicy.PodSelectorIPSets {
 		matchString := matchType.toIPTablesString()
-		hashedSetName := util.GetHashedName(translatedIPSet.Metadata.GetPrefixName())
+		hashedSetName := family.hashedSetName(translatedIPSet.Metadata.GetPrefixName())
 		specs = append(specs, util.IptablesModuleFlag, util.IptablesSetModuleFlag, util.IptablesMatchSetFlag, hashedSetName, matchString)
 	}
 	return specs
 }
 
-func getMatchSetSpecsFromSetInfo(setInfoList []SetInfo) []string {
+func getMatchSetSpecsFromSetInfo(family *ipFamily, setInfoList []SetInfo) []string {
 	specs := make([]string, 0, maxLengthForMatchSetSpecs*len(setInfoList))
 	for _, setInfo := range setInfoList {
 		matchString := setInfo.MatchType.toIPTablesString()
@@ -262,7 +295,7 @@ func getMatchSetSpecsFromSetInfo(setInfoList []SetInfo) []string {
 		if !setInfo.Included {
 			specs = append(specs, util.IptablesNotFlag)
 		}
-		hashedSetName := util.GetHashedName(setInfo.IPSet.GetPrefixName())
+		hashedSetName := family.hashedSetName(setInfo.IPSet.GetPrefixName())
 		specs = append(specs, util.IptablesMatchSetFlag, hashedSetName, matchString)
 	}
 	return specs
@@ -35,8 +35,8 @@ var (
 
 func TestAddPolicies(t *testing.T) {
 	calls := []testutils.TestCmd{fakeIPTablesRestoreCommand}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
-	creator := pMgr.getCreatorForNewNetworkPolicies(TestNetworkPolicies...)
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
+	creator := pMgr.getCreatorForNewNetworkPolicies(ipv4Family, TestNetworkPolicies...)
 	fileString := creator.ToString()
 	expectedLines := []string{
 		"*filter",
@@ -67,9 +67,53 @@ func TestAddPolicies(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAddPoliciesDualStack(t *testing.T) {
+	calls := []testutils.TestCmd{fakeIPTablesRestoreCommand, fakeIP6TablesRestoreCommand}
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), &PolicyManagerCfg{EnableIPv6: true})
+
+	// the ip6tables rules match the IPv6 counterparts of the same ipsets
+	creator := pMgr.getCreatorForNewNetworkPolicies(ipv6Family, TestNetworkPolicies[1])
+	expectedLines := []string{
+		"*filter",
+		fmt.Sprintf(":%s - -", testPolicy2IngressChain),
+		fmt.Sprintf("-A %s %s", testPolicy2IngressChain, strings.NewReplacer(
+			ipsets.TestCIDRSet.HashedName, ipsets.TestCIDRSet.HashedNameV6,
+			ipsets.TestKeyPodSet.HashedName, ipsets.TestKeyPodSet.HashedNameV6,
+		).Replace(testACLRule1)),
+		fmt.Sprintf("-I AZURE-NPM-INGRESS 1 -j %s -m set --match-set %s dst -m set --match-set %s dst",
+			testPolicy2IngressChain, ipsets.TestKVNSList.HashedNameV6, ipsets.TestKeyPodSet.HashedNameV6),
+		"COMMIT\n",
+	}
+	dptestutils.AssertEqualMultilineStrings(t, strings.Join(expectedLines, "\n"), creator.ToString())
+
+	require.NoError(t, pMgr.addPolicy(TestNetworkPolicies[1], nil))
+}
+
+func TestRemovePoliciesDualStack(t *testing.T) {
+	deleteV6IngressJump := getFakeDeleteJumpCommand("AZURE-NPM-INGRESS", strings.ReplaceAll(testPolicy1IngressJump, ipsets.TestKVNSList.HashedName, ipsets.TestKVNSList.HashedNameV6))
+	deleteV6IngressJump.Cmd[0] = "ip6tables"
+	deleteV6EgressJump := getFakeDeleteJumpCommand("AZURE-NPM-EGRESS", strings.ReplaceAll(testPolicy1EgressJump, ipsets.TestKVNSList.HashedName, ipsets.TestKVNSList.HashedNameV6))
+	deleteV6EgressJump.Cmd[0] = "ip6tables"
+	calls := []testutils.TestCmd{
+		fakeIPTablesRestoreCommand,
+		fakeIP6TablesRestoreCommand,
+		getFakeDeleteJumpCommand("AZURE-NPM-INGRESS", testPolicy1IngressJump),
+		getFakeDeleteJumpCommand("AZURE-NPM-EGRESS", testPolicy1EgressJump),
+		fakeIPTablesRestoreCommand,
+		deleteV6IngressJump,
+		deleteV6EgressJump,
+		fakeIP6TablesRestoreCommand,
+	}
+	fexec := testutils.GetFakeExecWithScripts(calls)
+	defer testutils.VerifyCalls(t, fexec, calls)
+	pMgr := NewPolicyManager(&common.IOShim{Exec: fexec}, &PolicyManagerCfg{EnableIPv6: true})
+	require.NoError(t, pMgr.AddPolicy(TestNetworkPolicies[0], nil))
+	require.NoError(t, pMgr.RemovePolicy(TestNetworkPolicies[0].Name, nil))
+}
+
 func TestAddPoliciesError(t *testing.T) {
 	calls := []testutils.TestCmd{fakeIPTablesRestoreFailureCommand}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	err := pMgr.addPolicy(TestNetworkPolicies[0], nil)
 	require.Error(t, err)
 }
@@ -81,7 +125,7 @@ func TestRemovePolicies(t *testing.T) {
 		getFakeDeleteJumpCommandWithCode("AZURE-NPM-EGRESS", testPolicy1EgressJump, 2), // if the policy chain doesn't exist, we shouldn't error
 		fakeIPTablesRestoreCommand,
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	creator := pMgr.getCreatorForRemovingPolicies(TestNetworkPolicies...)
 	fileString := creator.ToString()
 	expectedLines := []string{
@@ -108,7 +152,7 @@ func TestRemovePoliciesErrorOnRestore(t *testing.T) {
 		getFakeDeleteJumpCommand("AZURE-NPM-EGRESS", testPolicy1EgressJump),
 		fakeIPTablesRestoreFailureCommand,
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	err := pMgr.AddPolicy(TestNetworkPolicies[0], nil)
 	require.NoError(t, err)
 	err = pMgr.RemovePolicy(TestNetworkPolicies[0].Name, nil)
@@ -120,7 +164,7 @@ func TestRemovePoliciesErrorOnIngressRule(t *testing.T) {
 		fakeIPTablesRestoreCommand,
 		getFakeDeleteJumpCommandWithCode("AZURE-NPM-INGRESS", testPolicy1IngressJump, 1), // anything but 0 or 2
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	err := pMgr.AddPolicy(TestNetworkPolicies[0], nil)
 	require.NoError(t, err)
 	err = pMgr.RemovePolicy(TestNetworkPolicies[0].Name, nil)
@@ -133,7 +177,7 @@ func TestRemovePoliciesErrorOnEgressRule(t *testing.T) {
 		getFakeDeleteJumpCommand("AZURE-NPM-INGRESS", testPolicy1IngressJump),
 		getFakeDeleteJumpCommandWithCode("AZURE-NPM-EGRESS", testPolicy1EgressJump, 1), // anything but 0 or 2
 	}
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 	err := pMgr.AddPolicy(TestNetworkPolicies[0], nil)
 	require.NoError(t, err)
 	err = pMgr.RemovePolicy(TestNetworkPolicies[0].Name, nil)
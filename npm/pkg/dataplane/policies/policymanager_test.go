@@ -10,6 +10,8 @@ import (
 )
 
 var (
+	ipv4OnlyCfg = &PolicyManagerCfg{EnableIPv6: false}
+
 	// below epList is no-op for linux
 	epList        = map[string]string{"10.0.0.1": "test123", "10.0.0.2": "test456"}
 	testNSSet     = ipsets.NewIPSetMetadata("test-ns-set", ipsets.Namespace)
@@ -66,7 +68,7 @@ func TestAddPolicy(t *testing.T) {
 	netpol := &NPMNetworkPolicy{}
 
 	calls := GetAddPolicyTestCalls(netpol)
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 
 	require.NoError(t, pMgr.AddPolicy(netpol, epList))
 
@@ -86,7 +88,7 @@ func TestGetPolicy(t *testing.T) {
 	}
 
 	calls := GetAddPolicyTestCalls(netpol)
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 
 	require.NoError(t, pMgr.AddPolicy(netpol, epList))
 
@@ -100,7 +102,7 @@ func TestGetPolicy(t *testing.T) {
 func TestRemovePolicy(t *testing.T) {
 	calls := append(GetAddPolicyTestCalls(testNetPol), GetRemovePolicyTestCalls(testNetPol)...)
 	fmt.Println(calls)
-	pMgr := NewPolicyManager(common.NewMockIOShim(calls))
+	pMgr := NewPolicyManager(common.NewMockIOShim(calls), ipv4OnlyCfg)
 
 	require.NoError(t, pMgr.AddPolicy(testNetPol, epList))
 
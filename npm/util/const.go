@@ -25,6 +25,7 @@ const (
 	Ip6tables                  string = "ip6tables" //nolint (avoid warning to capitalize this p)
 	IptablesSave               string = "iptables-save"
 	IptablesRestore            string = "iptables-restore"
+	Ip6tablesRestore           string = "ip6tables-restore" //nolint (avoid warning to capitalize this p)
 	IptablesRestoreNoFlushFlag string = "--noflush"
 	IptablesRestoreTableFlag   string = "-T"
 	IptablesRestoreCommit      string = "COMMIT"
@@ -154,6 +155,9 @@ const (
 	IpsetNetHashFlag    string = "nethash"
 	IpsetIPPortHashFlag string = "hash:ip,port"
 
+	IpsetFamilyFlag string = "family"
+	IpsetINet6      string = "inet6"
+
 	IpsetUDPFlag  string = "udp:"
 	IpsetSCTPFlag string = "sctp:"
 	IpsetTCPFlag  string = "tcp:"
@@ -162,6 +166,8 @@ const (
 
 	AzureNpmFlag   string = "azure-npm"
 	AzureNpmPrefix string = "azure-npm-"
+	// AzureNpmPrefixV6 prefixes the IPv6 counterpart of an ipset, since the two families can't share a set.
+	AzureNpmPrefixV6 string = "azure-npm6-"
 
 	IpsetMaxelemName string = "maxelem" // todo, what's using this?
 	IpsetMaxelemNum  string = "4294967295"
@@ -181,6 +187,15 @@ const (
 	SetPolicyDelimiter string = ","
 )
 
+// nftables related constants.
+const (
+	Nft         string = "nft"
+	NftFileFlag string = "-f"
+	NftStdin    string = "-"
+	NftFamily   string = "inet"
+	NftTable    string = "azure-npm"
+)
+
 // NPM telemetry constants.
 const (
 	AddNamespaceEvent    string = "Add Namespace"
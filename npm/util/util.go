@@ -148,6 +148,11 @@ func GetHashedName(name string) string {
 	return AzureNpmPrefix + Hash(name)
 }
 
+// GetHashedNameV6 returns hashed name of the IPv6 ipset for name.
+func GetHashedNameV6(name string) string {
+	return AzureNpmPrefixV6 + Hash(name)
+}
+
 // CompareK8sVer compares two k8s versions.
 // returns -1, 0, 1 if firstVer smaller, equals, bigger than secondVer respectively.
 // returns -2 for error.
@@ -48,6 +48,8 @@ type OvsInterface interface {
 	DeleteIPSnatRule(bridgeName string, port string)
 	DeleteMacDnatRule(bridgeName string, port string, ip net.IP, vlanid int)
 	DeletePortFromOVS(bridgeName string, interfaceName string) error
+	AddMirror(bridgeName string, mirrorName string, portName string, outputPort string) error
+	DeleteMirror(bridgeName string, mirrorName string) error
 }
 
 type Ovsctl struct {
@@ -300,3 +302,29 @@ func (o Ovsctl) DeletePortFromOVS(bridgeName, interfaceName string) error {
 
 	return nil
 }
+
+// AddMirror mirrors the traffic from and to a port of the bridge to an output port.
+func (o Ovsctl) AddMirror(bridgeName, mirrorName, portName, outputPort string) error {
+	cmd := fmt.Sprintf("ovs-vsctl -- --id=@p get port %s -- --id=@o get port %s "+
+		"-- --id=@m create mirror name=%s select-src-port=@p select-dst-port=@p output-port=@o "+
+		"-- add bridge %s mirrors @m", portName, outputPort, mirrorName, bridgeName)
+	_, err := o.execcli.ExecuteCommand(cmd)
+	if err != nil {
+		log.Printf("[ovs] Failed to add mirror %v of port %v, err:%v.", mirrorName, portName, err)
+		return newErrorOvsctl(err.Error())
+	}
+
+	return nil
+}
+
+// DeleteMirror deletes a mirror of the bridge.
+func (o Ovsctl) DeleteMirror(bridgeName, mirrorName string) error {
+	cmd := fmt.Sprintf("ovs-vsctl -- --id=@m get mirror %s -- remove bridge %s mirrors @m", mirrorName, bridgeName)
+	_, err := o.execcli.ExecuteCommand(cmd)
+	if err != nil {
+		log.Printf("[ovs] Failed to delete mirror %v, err:%v.", mirrorName, err)
+		return newErrorOvsctl(err.Error())
+	}
+
+	return nil
+}
@@ -104,3 +104,17 @@ func (m MockOvsctl) DeletePortFromOVS(bridgeName string, interfaceName string) e
 	}
 	return nil
 }
+
+func (m MockOvsctl) AddMirror(bridgeName string, mirrorName string, portName string, outputPort string) error {
+	if m.returnError {
+		return newErrorOvsctl(m.errorStr)
+	}
+	return nil
+}
+
+func (m MockOvsctl) DeleteMirror(bridgeName string, mirrorName string) error {
+	if m.returnError {
+		return newErrorOvsctl(m.errorStr)
+	}
+	return nil
+}
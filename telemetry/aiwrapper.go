@@ -21,7 +21,8 @@ const (
 	waitTimeInSecs = 10
 )
 
-func CreateAITelemetryHandle(aiConfig aitelemetry.AIConfig, disableAll, disableMetric, disableTrace bool) error {
+// CreateAITelemetryHandle creates the telemetry handle sending to the sinks, or to appinsights if there are none.
+func CreateAITelemetryHandle(aiConfig aitelemetry.AIConfig, sinks []aitelemetry.SinkConfig, disableAll, disableMetric, disableTrace bool) error {
 	var err error
 
 	if disableAll {
@@ -29,7 +30,7 @@ func CreateAITelemetryHandle(aiConfig aitelemetry.AIConfig, disableAll, disableM
 		return fmt.Errorf("Telmetry disabled")
 	}
 
-	th, err = aitelemetry.NewAITelemetry("", aiMetadata, aiConfig)
+	th, err = aitelemetry.NewTelemetry("", aiMetadata, aiConfig, sinks)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ const (
 	CNIAddTimeMetricStr    = "CNIAddTimeMs"
 	CNIDelTimeMetricStr    = "CNIDelTimeMs"
 	CNIUpdateTimeMetricStr = "CNIUpdateTimeMs"
+	CNIIPAMErrorMetricStr  = "CNIIPAMErrors"
 
 	// Dimension Names
 	ContextStr        = "Context"
@@ -19,6 +20,7 @@ const (
 	CNIModeStr        = "CNIMode"
 	CNINetworkModeStr = "CNINetworkMode"
 	OSTypeStr         = "OSType"
+	IPAMTypeStr       = "IPAMType"
 
 	// Values
 	SucceededStr     = "Succeeded"
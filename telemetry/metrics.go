@@ -0,0 +1,150 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "cni"
+	metricsPath      = "/metrics"
+
+	operationLabel = "operation"
+	modeLabel      = "mode"
+	resultLabel    = "result"
+	codeLabel      = "code"
+	ipamLabel      = "ipam"
+	reasonLabel    = "reason"
+
+	resultSucceeded = "succeeded"
+	resultFailed    = "failed"
+
+	dropReasonExpired    = "expired"
+	dropReasonSpoolFull  = "spool_full"
+	dropReasonBufferFull = "buffer_full"
+
+	metricsReadHeaderTimeout = 10 * time.Second
+)
+
+// The telemetry service aggregates the reports and metrics sent by CNI into these Prometheus metrics.
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	operationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of the CNI operations.",
+			// 50ms to ~100s
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12), //nolint:gomnd // 50ms base, doubling
+		},
+		[]string{operationLabel, modeLabel, resultLabel},
+	)
+	operations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "operations_total",
+			Help:      "Number of CNI operations.",
+		},
+		[]string{operationLabel, modeLabel, resultLabel},
+	)
+	operationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "operation_errors_total",
+			Help:      "Number of failed CNI operations by CNI error code.",
+		},
+		[]string{operationLabel, codeLabel},
+	)
+	ipamErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "ipam_errors_total",
+			Help:      "Number of errors returned by the IPAM invoker.",
+		},
+		[]string{operationLabel, ipamLabel},
+	)
+	spooledReports = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "telemetry_spooled_reports_total",
+			Help:      "Number of reports recovered from the spool written while the telemetry service was down.",
+		},
+	)
+	droppedReports = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "telemetry_dropped_reports_total",
+			Help:      "Number of reports dropped before reaching the telemetry service.",
+		},
+		[]string{reasonLabel},
+	)
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		operationDuration,
+		operations,
+		operationErrors,
+		ipamErrors,
+		spooledReports,
+		droppedReports,
+	)
+}
+
+// ServeMetrics exposes the CNI metrics for Prometheus on the address until the server fails.
+func ServeMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+	log.Logf("[Telemetry] Serving metrics on %s%s", addr, metricsPath)
+	return server.ListenAndServe() //nolint:wrapcheck // returned to main
+}
+
+// recordMetrics aggregates a report or metric sent by CNI into the Prometheus metrics.
+func recordMetrics(x interface{}) {
+	switch y := x.(type) {
+	case CNIReport:
+		result := resultSucceeded
+		if !y.CniSucceeded {
+			result = resultFailed
+		}
+		operation := strings.ToLower(y.OperationType)
+		operations.WithLabelValues(operation, y.BridgeDetails.NetworkMode, result).Inc()
+		if !y.CniSucceeded {
+			operationErrors.WithLabelValues(operation, strconv.FormatUint(uint64(y.ErrorCode), 10)).Inc()
+		}
+
+	case AIMetric:
+		dims := y.Metric.CustomDimensions
+		switch y.Metric.Name {
+		case CNIAddTimeMetricStr, CNIDelTimeMetricStr, CNIUpdateTimeMetricStr:
+			result := resultSucceeded
+			if dims[StatusStr] == FailedStr {
+				result = resultFailed
+			}
+			operationDuration.WithLabelValues(metricOperation(y.Metric.Name), dims[CNINetworkModeStr], result).
+				Observe(y.Metric.Value / float64(time.Second/time.Millisecond))
+		case CNIIPAMErrorMetricStr:
+			ipamErrors.WithLabelValues(strings.ToLower(dims[OperationTypeStr]), dims[IPAMTypeStr]).Add(y.Metric.Value)
+		}
+	}
+}
+
+// metricOperation returns the operation timed by the metric, e.g. "add" for CNIAddTimeMs.
+func metricOperation(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(name, "CNI"), "TimeMs"))
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-container-networking/aitelemetry"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordMetricsReport(t *testing.T) {
+	failed := CNIReport{OperationType: "ADD", ErrorCode: 7}
+	failed.BridgeDetails.NetworkMode = "transparent"
+	succeeded := CNIReport{OperationType: "DEL", CniSucceeded: true}
+	succeeded.BridgeDetails.NetworkMode = "transparent"
+
+	recordMetrics(failed)
+	recordMetrics(failed)
+	recordMetrics(succeeded)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(operations.WithLabelValues("add", "transparent", resultFailed)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(operations.WithLabelValues("del", "transparent", resultSucceeded)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(operationErrors.WithLabelValues("add", "7")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(operationErrors.WithLabelValues("del", "0")))
+}
+
+func TestRecordMetricsAIMetric(t *testing.T) {
+	recordMetrics(AIMetric{Metric: aitelemetry.Metric{
+		Name:             CNIAddTimeMetricStr,
+		Value:            1500,
+		CustomDimensions: map[string]string{StatusStr: SucceededStr, CNINetworkModeStr: "bridge"},
+	}})
+	recordMetrics(AIMetric{Metric: aitelemetry.Metric{
+		Name:             CNIIPAMErrorMetricStr,
+		Value:            1,
+		CustomDimensions: map[string]string{OperationTypeStr: "DEL", IPAMTypeStr: "azure-cns"},
+	}})
+
+	families, err := metricsRegistry.Gather()
+	require.NoError(t, err)
+	var sum float64
+	for _, family := range families {
+		if family.GetName() == "cni_operation_duration_seconds" {
+			for _, m := range family.GetMetric() {
+				sum += m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	assert.Equal(t, 1.5, sum)
+	assert.Equal(t, float64(1), testutil.ToFloat64(ipamErrors.WithLabelValues("del", "azure-cns")))
+}
+
+func TestMetricOperation(t *testing.T) {
+	assert.Equal(t, "add", metricOperation(CNIAddTimeMetricStr))
+	assert.Equal(t, "del", metricOperation(CNIDelTimeMetricStr))
+	assert.Equal(t, "update", metricOperation(CNIUpdateTimeMetricStr))
+}
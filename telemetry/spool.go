@@ -0,0 +1,215 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/Azure/azure-container-networking/log"
+	"github.com/Azure/azure-container-networking/store"
+	"github.com/pkg/errors"
+)
+
+// SpoolFileName - name of the file in the log directory where CNI spools the reports while the telemetry service is down
+// DefaultSpoolMaxSizeInBytes - size beyond which the oldest spooled reports are dropped
+// DefaultSpoolMaxAge - age beyond which the spooled reports are dropped
+const (
+	SpoolFileName              = "azure-vnet-telemetry.spool"
+	DefaultSpoolMaxSizeInBytes = 1 << 20
+	DefaultSpoolMaxAge         = time.Hour
+)
+
+// spoolRecord is a line of the spool. Dropped carries the number of reports dropped when the spool was compacted.
+type spoolRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Report    json.RawMessage `json:"report,omitempty"`
+	Dropped   int             `json:"dropped,omitempty"`
+}
+
+// Spool is a bounded file holding the reports which could not be written to the telemetry service.
+// It is shared by the CNI processes, which append to it, and the telemetry service, which drains it.
+type Spool struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+}
+
+// NewSpool - create a spool at the path with the size and age limits
+func NewSpool(path string, maxSize int64, maxAge time.Duration) *Spool {
+	return &Spool{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+}
+
+// DefaultSpoolPath - path of the spool in the log directory
+func DefaultSpoolPath() string {
+	return path.Join(log.GetLogDirectory(), SpoolFileName)
+}
+
+// Append - append the report to the spool, dropping the expired and oldest reports if it is full
+func (s *Spool) Append(report []byte) error {
+	record, err := json.Marshal(spoolRecord{Timestamp: time.Now(), Report: report})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal spool record")
+	}
+	record = append(record, Delimiter)
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var size int64
+	if info, err := os.Stat(s.path); err == nil {
+		size = info.Size()
+	}
+
+	if size+int64(len(record)) > s.maxSize {
+		return s.compact(record)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gomnd // rw-r--r--
+	if err != nil {
+		return errors.Wrap(err, "failed to open spool")
+	}
+	defer f.Close()
+
+	_, err = f.Write(record)
+	return errors.Wrap(err, "failed to write to spool")
+}
+
+// Drain - remove the spool and return the reports which have not expired, along with the number of reports
+// which expired and the number which were dropped as the spool was full or corrupt
+func (s *Spool) Drain() (reports [][]byte, expired, dropped int, err error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer unlock()
+
+	records, expired, dropped, err := s.read()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return nil, 0, 0, errors.Wrap(err, "failed to remove spool")
+	}
+
+	reports = make([][]byte, 0, len(records))
+	for _, record := range records {
+		reports = append(reports, record.Report)
+	}
+
+	return reports, expired, dropped, nil
+}
+
+// read - read the unexpired reports from the spool along with the number of expired and dropped reports
+func (s *Spool) read() (records []spoolRecord, expired, dropped int, err error) {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, 0, 0, nil
+	}
+	if err != nil {
+		return nil, 0, 0, errors.Wrap(err, "failed to read spool")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, MaxPayloadSize), int(s.maxSize))
+	for scanner.Scan() {
+		var record spoolRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A CNI process was killed mid-write.
+			dropped++
+			continue
+		}
+
+		switch {
+		case record.Dropped > 0:
+			dropped += record.Dropped
+		case time.Since(record.Timestamp) > s.maxAge:
+			expired++
+		default:
+			records = append(records, record)
+		}
+	}
+
+	return records, expired, dropped, errors.Wrap(scanner.Err(), "failed to scan spool")
+}
+
+// compact - rewrite the spool with the newest reports and the record, so that it fits within its size limit
+func (s *Spool) compact(record []byte) error {
+	// The expired reports are accounted as dropped, since they made room for the record.
+	records, expired, dropped, err := s.read()
+	if err != nil {
+		return err
+	}
+	dropped += expired
+
+	lines := make([][]byte, 0, len(records)+1)
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal spool record")
+		}
+		lines = append(lines, append(line, Delimiter))
+	}
+	lines = append(lines, record)
+
+	// Keep the newest lines which fit, leaving room for the dropped record.
+	const droppedRecordSize = 128
+	size := int64(droppedRecordSize)
+	first := len(lines)
+	for first > 0 && size+int64(len(lines[first-1])) <= s.maxSize {
+		first--
+		size += int64(len(lines[first]))
+	}
+	dropped += first
+
+	var buf bytes.Buffer
+	if dropped > 0 {
+		line, err := json.Marshal(spoolRecord{Timestamp: time.Now(), Dropped: dropped})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal spool record")
+		}
+		buf.Write(append(line, Delimiter))
+	}
+	for _, line := range lines[first:] {
+		buf.Write(line)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0o644); err != nil { //nolint:gomnd // rw-r--r--
+		return errors.Wrap(err, "failed to write spool")
+	}
+
+	return errors.Wrap(os.Rename(tmp, s.path), "failed to replace spool")
+}
+
+// lock - lock the spool against the other processes and return the func which unlocks it
+func (s *Spool) lock() (func(), error) {
+	kvs, err := store.NewJsonFileStore(s.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create spool lock")
+	}
+
+	if err := kvs.Lock(true); err != nil {
+		return nil, errors.Wrap(err, "failed to lock spool")
+	}
+
+	return func() {
+		if err := kvs.Unlock(false); err != nil {
+			log.Printf("[Telemetry] Failed to unlock spool: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 Microsoft. All rights reserved.
+// MIT License
+
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSpool(t *testing.T, maxSize int64, maxAge time.Duration) *Spool {
+	return NewSpool(filepath.Join(t.TempDir(), SpoolFileName), maxSize, maxAge)
+}
+
+func TestSpoolAppendDrain(t *testing.T) {
+	s := newTestSpool(t, DefaultSpoolMaxSizeInBytes, DefaultSpoolMaxAge)
+
+	require.NoError(t, s.Append([]byte(`{"CniSucceeded":true}`)))
+	require.NoError(t, s.Append([]byte(`{"Metric":{"Name":"CNIAddTimeMs"}}`)))
+
+	reports, expired, dropped, err := s.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"CniSucceeded":true}`, `{"Metric":{"Name":"CNIAddTimeMs"}}`}, toStrings(reports))
+	assert.Zero(t, expired)
+	assert.Zero(t, dropped)
+
+	_, err = os.Stat(s.path)
+	assert.True(t, os.IsNotExist(err))
+
+	reports, _, _, err = s.Drain()
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestSpoolExpiry(t *testing.T) {
+	s := newTestSpool(t, DefaultSpoolMaxSizeInBytes, time.Millisecond)
+
+	require.NoError(t, s.Append([]byte(`{"CniSucceeded":true}`)))
+	time.Sleep(10 * time.Millisecond)
+
+	reports, expired, dropped, err := s.Drain()
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+	assert.Equal(t, 1, expired)
+	assert.Zero(t, dropped)
+}
+
+func TestSpoolDropsOldestWhenFull(t *testing.T) {
+	s := newTestSpool(t, 1024, DefaultSpoolMaxAge)
+
+	const n = 50
+	for i := 0; i < n; i++ {
+		require.NoError(t, s.Append([]byte(`{"Id":`+strconv.Itoa(i)+`}`)))
+		info, err := os.Stat(s.path)
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(1024))
+	}
+
+	reports, _, dropped, err := s.Drain()
+	require.NoError(t, err)
+	require.NotEmpty(t, reports)
+	assert.Equal(t, n, len(reports)+dropped)
+	assert.Equal(t, `{"Id":49}`, string(reports[len(reports)-1]))
+}
+
+func TestSendSpoolsWhenDisconnected(t *testing.T) {
+	s := newTestSpool(t, DefaultSpoolMaxSizeInBytes, DefaultSpoolMaxAge)
+	tb := NewTelemetryBuffer()
+	tb.SetSpool(s)
+
+	reportMgr := &ReportManager{Report: &CNIReport{OperationType: "ADD"}}
+	require.NoError(t, reportMgr.SendReport(tb))
+
+	tb.DrainSpool(s)
+	report := <-tb.data
+	assert.Equal(t, "ADD", report.(CNIReport).OperationType)
+}
+
+func toStrings(b [][]byte) []string {
+	s := make([]string, 0, len(b))
+	for _, x := range b {
+		s = append(s, string(x))
+	}
+	return s
+}
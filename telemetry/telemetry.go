@@ -65,6 +65,7 @@ type CNIReport struct {
 	Name              string
 	Version           string
 	ErrorMessage      string
+	ErrorCode         uint
 	EventMessage      string
 	OperationType     string
 	OperationDuration int
@@ -107,13 +108,10 @@ func (reportMgr *ReportManager) SendReport(tb *TelemetryBuffer) error {
 	var err error
 	var report []byte
 
-	if tb != nil && tb.Connected {
+	if tb != nil {
 		report, err = reportMgr.ReportToBytes()
 		if err == nil {
-			// If write fails, try to re-establish connections as server/client
-			if _, err = tb.Write(report); err != nil {
-				tb.Cancel()
-			}
+			err = tb.send(report)
 		}
 	}
 
@@ -139,14 +137,11 @@ func SendCNIMetric(cniMetric *AIMetric, tb *TelemetryBuffer) error {
 	var err error
 	var report []byte
 
-	if tb != nil && tb.Connected {
+	if tb != nil {
 		reportMgr := &ReportManager{Report: cniMetric}
 		report, err = reportMgr.ReportToBytes()
 		if err == nil {
-			// If write fails, try to re-establish connections as server/client
-			if _, err = tb.Write(report); err != nil {
-				tb.Cancel()
-			}
+			err = tb.send(report)
 		}
 	}
 
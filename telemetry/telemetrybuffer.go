@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Azure/azure-container-networking/aitelemetry"
 	"github.com/Azure/azure-container-networking/common"
 	"github.com/Azure/azure-container-networking/log"
 	"github.com/Azure/azure-container-networking/platform"
@@ -30,11 +31,14 @@ type TelemetryConfig struct {
 	DisableMetadataThread         bool
 	DebugMode                     bool
 	DisableTelemetryToNetAgent    bool
+	MetricsBindAddress            string
 	RefreshTimeoutInSecs          int
 	BatchIntervalInSecs           int
 	BatchSizeInBytes              int
 	GetEnvRetryCount              int
 	GetEnvRetryWaitTimeInSecs     int
+	SpoolMaxAgeInSecs             int
+	Sinks                         []aitelemetry.SinkConfig
 }
 
 // FdName - file descriptor name
@@ -57,6 +61,7 @@ type TelemetryBuffer struct {
 	data        chan interface{}
 	cancel      chan bool
 	mutex       sync.Mutex
+	spool       *Spool
 }
 
 // Buffer object holds the different types of reports
@@ -108,17 +113,7 @@ func (tb *TelemetryBuffer) StartServer() error {
 					for {
 						reportStr, err := read(conn)
 						if err == nil {
-							var tmp map[string]interface{}
-							json.Unmarshal(reportStr, &tmp)
-							if _, ok := tmp["CniSucceeded"]; ok {
-								var cniReport CNIReport
-								json.Unmarshal([]byte(reportStr), &cniReport)
-								tb.data <- cniReport
-							} else if _, ok := tmp["Metric"]; ok {
-								var aiMetric AIMetric
-								json.Unmarshal([]byte(reportStr), &aiMetric)
-								tb.data <- aiMetric
-							}
+							tb.enqueue(reportStr)
 						} else {
 							var index int
 							var value net.Conn
@@ -153,6 +148,76 @@ func (tb *TelemetryBuffer) StartServer() error {
 	return nil
 }
 
+// enqueue - decode the report and queue it for PushData, dropping it if the queue is full
+func (tb *TelemetryBuffer) enqueue(reportStr []byte) {
+	var report interface{}
+	var tmp map[string]interface{}
+	json.Unmarshal(reportStr, &tmp)
+	if _, ok := tmp["CniSucceeded"]; ok {
+		var cniReport CNIReport
+		json.Unmarshal(reportStr, &cniReport)
+		report = cniReport
+	} else if _, ok := tmp["Metric"]; ok {
+		var aiMetric AIMetric
+		json.Unmarshal(reportStr, &aiMetric)
+		report = aiMetric
+	} else {
+		return
+	}
+
+	select {
+	case tb.data <- report:
+	default:
+		droppedReports.WithLabelValues(dropReasonBufferFull).Inc()
+		log.Logf("[Telemetry] Dropped report as %d reports are queued", MaxNumReports)
+	}
+}
+
+// SetSpool - spool the reports to s while they cannot be written to the telemetry service
+func (tb *TelemetryBuffer) SetSpool(s *Spool) {
+	tb.spool = s
+}
+
+// DrainSpool - queue the reports spooled while the telemetry service was down
+func (tb *TelemetryBuffer) DrainSpool(s *Spool) {
+	reports, expired, dropped, err := s.Drain()
+	if err != nil {
+		log.Logf("[Telemetry] Failed to drain spool: %v", err)
+		return
+	}
+
+	log.Logf("[Telemetry] Drained %d reports from spool, %d expired and %d were dropped", len(reports), expired, dropped)
+	spooledReports.Add(float64(len(reports)))
+	droppedReports.WithLabelValues(dropReasonExpired).Add(float64(expired))
+	droppedReports.WithLabelValues(dropReasonSpoolFull).Add(float64(dropped))
+	for _, report := range reports {
+		tb.enqueue(report)
+	}
+}
+
+// send - write the report to the telemetry service, or to the spool if the service is unavailable
+func (tb *TelemetryBuffer) send(report []byte) error {
+	if tb.Connected {
+		_, err := tb.Write(report)
+		if err == nil {
+			return nil
+		}
+
+		// If write fails, try to re-establish connections as server/client
+		tb.Connected = false
+		tb.Cancel()
+		if tb.spool == nil {
+			return err
+		}
+	}
+
+	if tb.spool == nil {
+		return nil
+	}
+
+	return tb.spool.Append(report)
+}
+
 func (tb *TelemetryBuffer) Connect() error {
 	err := tb.Dial(FdName)
 	if err == nil {
@@ -169,6 +234,7 @@ func (tb *TelemetryBuffer) PushData() {
 	for {
 		select {
 		case report := <-tb.data:
+			recordMetrics(report)
 			tb.mutex.Lock()
 			push(report)
 			tb.mutex.Unlock()
@@ -195,7 +261,7 @@ func read(conn net.Conn) (b []byte, err error) {
 // Write - write to the file descriptor
 func (tb *TelemetryBuffer) Write(b []byte) (c int, err error) {
 	buf := make([]byte, len(b))
-	copy(b, buf)
+	copy(buf, b)
 	b = append(buf, Delimiter)
 	w := bufio.NewWriter(tb.client)
 	c, err = w.Write(b)
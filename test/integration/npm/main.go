@@ -60,7 +60,7 @@ var (
 )
 
 func main() {
-	dp, err := dataplane.NewDataPlane(nodeName, common.NewIOShim())
+	dp, err := dataplane.NewDataPlane(nodeName, common.NewIOShim(), &dataplane.Config{})
 	panicOnError(err)
 	printAndWait()
 
@@ -114,7 +114,7 @@ func main() {
 }
 
 func testPolicyManager() {
-	pMgr := policies.NewPolicyManager(common.NewIOShim())
+	pMgr := policies.NewPolicyManager(common.NewIOShim(), &policies.PolicyManagerCfg{})
 
 	panicOnError(pMgr.Reset())
 	printAndWait()
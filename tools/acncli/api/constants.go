@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 const (
@@ -29,6 +30,25 @@ const (
 	FlagFollow      = "follow"
 	FlagLogFilePath = "log-file"
 
+	// CNI Mirror Flags
+	FlagNetworkID       = "network-id"
+	FlagEndpointID      = "endpoint-id"
+	FlagPodName         = "pod-name"
+	FlagPodNamespace    = "pod-namespace"
+	FlagTargetInterface = "target-interface"
+	FlagRemoteIP        = "remote-ip"
+	FlagDuration        = "duration"
+	FlagWait            = "wait"
+
+	// Doctor Flags
+	FlagOutputDirectory = "output-directory"
+	FlagCNSURL          = "cns-url"
+	FlagNPMURL          = "npm-url"
+	FlagCNSConfigPath   = "cns-config"
+	FlagStateFilePath   = "state-file"
+	FlagLockDirectory   = "lock-directory"
+	FlagLockMaxAge      = "lock-max-age"
+
 	// tenancy flags
 	Singletenancy = "singletenancy"
 	Multitenancy  = "multitenancy"
@@ -63,6 +83,14 @@ const (
 	DefaultBinDirLinux      = "/opt/cni/bin/"
 	DefaultConflistDirLinux = "/etc/cni/net.d/"
 	DefaultLogFile          = "/var/log/azure-vnet.log"
+	DefaultLogGlob          = "/var/log/azure-vnet*.log"
+	DefaultStateFile        = "/var/run/azure-vnet.json"
+	DefaultLockDirectory    = "/var/run/azure-vnet/"
+	DefaultCNSConfig        = "/etc/azure-cns/cns_config.json"
+	DefaultCNSURL           = "http://localhost:10090"
+	DefaultNPMURL           = "http://localhost:10091"
+	DefaultOutputDirectory  = "/tmp/"
+	DefaultNetworkID        = "azure"
 	Transparent             = "transparent"
 	Bridge                  = "bridge"
 	Azure0                  = "azure0"
@@ -91,14 +119,26 @@ var (
 		FlagConflistDirectory:        DefaultConflistDirLinux,
 		FlagVersion:                  Packaged,
 		FlagLogFilePath:              DefaultLogFile,
+		FlagOutputDirectory:          DefaultOutputDirectory,
+		FlagCNSURL:                   DefaultCNSURL,
+		FlagNPMURL:                   DefaultNPMURL,
+		FlagCNSConfigPath:            DefaultCNSConfig,
+		FlagStateFilePath:            DefaultStateFile,
+		FlagLockDirectory:            DefaultLockDirectory,
+		FlagNetworkID:                DefaultNetworkID,
 		EnvCNILogFile:                EnvCNILogFile,
 		EnvCNISourceDir:              DefaultSrcDirLinux,
 		EnvCNIDestinationBinDir:      DefaultBinDirLinux,
 		EnvCNIDestinationConflistDir: DefaultConflistDirLinux,
 	}
 
+	DefaultLockMaxAge = 5 * time.Minute
+
+	DefaultMirrorDuration = 10 * time.Minute
+
 	DefaultToggles = map[string]bool{
 		FlagFollow: false,
+		FlagWait:   true,
 	}
 )
 
@@ -23,5 +23,6 @@ func CNICmd() *cobra.Command {
 	cmd.AddCommand(InstallCmd())
 	cmd.AddCommand(LogsCmd())
 	cmd.AddCommand(ManagerCmd())
+	cmd.AddCommand(MirrorCmd())
 	return cmd
 }
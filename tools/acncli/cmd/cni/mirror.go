@@ -0,0 +1,154 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package cni
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cni/api"
+	"github.com/Azure/azure-container-networking/cni/client"
+	c "github.com/Azure/azure-container-networking/tools/acncli/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/utils/exec"
+)
+
+// MirrorCmd mirrors the traffic of pods for troubleshooting
+func MirrorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Mirrors the traffic of a pod",
+		Long: "The mirror command sends copies of the traffic of a pod to a local interface or a GRE remote, " +
+			"until the mirror expires",
+	}
+	cmd.AddCommand(MirrorAddCmd())
+	cmd.AddCommand(MirrorDeleteCmd())
+	return cmd
+}
+
+func MirrorAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Mirrors the traffic of a pod until the mirror expires",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := mirrorRequestFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			if req.Duration, err = cmd.Flags().GetDuration(c.FlagDuration); err != nil {
+				return err
+			}
+
+			if req.TargetIfName, err = cmd.Flags().GetString(c.FlagTargetInterface); err != nil {
+				return err
+			}
+
+			remoteIP, err := cmd.Flags().GetString(c.FlagRemoteIP)
+			if err != nil {
+				return err
+			}
+			if remoteIP != "" {
+				if req.RemoteIP = net.ParseIP(remoteIP); req.RemoteIP == nil {
+					return fmt.Errorf("invalid remote IP %s", remoteIP)
+				}
+			}
+
+			cli := client.New(exec.New())
+			if err = cli.AddEndpointMirror(req); err != nil {
+				return err
+			}
+
+			expiresAt := time.Now().Add(req.Duration)
+			fmt.Printf("🪞 - mirroring the traffic of %s until %s\n", mirrorSubject(req), expiresAt.Format(time.RFC3339))
+
+			// Without waiting, the mirror is deleted by the first CNI invocation after it expires.
+			wait, err := cmd.Flags().GetBool(c.FlagWait)
+			if err != nil || !wait {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			select {
+			case <-time.After(time.Until(expiresAt)):
+			case <-ctx.Done():
+			}
+
+			fmt.Printf("🧹 - deleting the mirror of %s\n", mirrorSubject(req))
+			return cli.DeleteEndpointMirror(req)
+		},
+	}
+
+	addMirrorRequestFlags(cmd.Flags())
+	cmd.Flags().String(c.FlagTargetInterface, "", "Local interface receiving the mirrored traffic, created as an IFB interface if it does not exist")
+	cmd.Flags().String(c.FlagRemoteIP, "", "GRE remote receiving the mirrored traffic, instead of a local interface")
+	cmd.Flags().Duration(c.FlagDuration, c.DefaultMirrorDuration, "Duration after which the mirror expires")
+	cmd.Flags().Bool(c.FlagWait, c.DefaultToggles[c.FlagWait], "Wait for the mirror to expire, or for an interrupt, then delete it")
+
+	return cmd
+}
+
+func MirrorDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Deletes the mirror of the traffic of a pod",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := mirrorRequestFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+
+			return client.New(exec.New()).DeleteEndpointMirror(req)
+		},
+	}
+
+	addMirrorRequestFlags(cmd.Flags())
+
+	return cmd
+}
+
+// addMirrorRequestFlags adds the flags identifying the mirrored endpoint.
+func addMirrorRequestFlags(flags *pflag.FlagSet) {
+	flags.String(c.FlagNetworkID, c.Defaults[c.FlagNetworkID], "ID of the network of the pod")
+	flags.String(c.FlagEndpointID, "", "ID of the endpoint of the pod, looked up from the pod name and namespace if not set")
+	flags.String(c.FlagPodName, "", "Name of the pod")
+	flags.String(c.FlagPodNamespace, "", "Namespace of the pod")
+}
+
+// mirrorRequestFromFlags returns the mirror request of the endpoint identified by the flags. The flags are read from
+// the command rather than from viper, since the add and delete commands share their names.
+func mirrorRequestFromFlags(flags *pflag.FlagSet) (*api.EndpointMirrorRequest, error) {
+	req := &api.EndpointMirrorRequest{}
+
+	for flag, value := range map[string]*string{
+		c.FlagNetworkID:    &req.NetworkID,
+		c.FlagEndpointID:   &req.EndpointID,
+		c.FlagPodName:      &req.PodName,
+		c.FlagPodNamespace: &req.PodNamespace,
+	} {
+		var err error
+		if *value, err = flags.GetString(flag); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// mirrorSubject describes the mirrored endpoint of the request.
+func mirrorSubject(req *api.EndpointMirrorRequest) string {
+	if req.EndpointID != "" {
+		return "endpoint " + req.EndpointID
+	}
+
+	return fmt.Sprintf("pod %s/%s", req.PodNamespace, req.PodName)
+}
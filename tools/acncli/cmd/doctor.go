@@ -0,0 +1,79 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	c "github.com/Azure/azure-container-networking/tools/acncli/api"
+	"github.com/Azure/azure-container-networking/tools/acncli/doctor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	utilexec "k8s.io/utils/exec"
+)
+
+// DoctorCmd collects node-level network diagnostics into a bundle and runs sanity checks
+func DoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Collects node network diagnostics into a bundle and runs sanity checks",
+		Long: "The doctor command gathers iptables, ipset, ebtables, routing state, the CNI state file, CNS and NPM " +
+			"debug data and logs into a timestamped tarball, then checks them for common problems",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lockMaxAge, err := cmd.Flags().GetDuration(c.FlagLockMaxAge)
+			if err != nil {
+				return err
+			}
+
+			config := doctor.Config{
+				OutputDirectory: viper.GetString(c.FlagOutputDirectory),
+				CNSURL:          viper.GetString(c.FlagCNSURL),
+				NPMURL:          viper.GetString(c.FlagNPMURL),
+				CNSConfigPath:   viper.GetString(c.FlagCNSConfigPath),
+				StateFilePath:   viper.GetString(c.FlagStateFilePath),
+				LockDirectory:   viper.GetString(c.FlagLockDirectory),
+				LogGlob:         c.DefaultLogGlob,
+				LockMaxAge:      lockMaxAge,
+			}
+
+			fmt.Println("🩺 - collecting diagnostics...")
+			report, err := doctor.New(config, utilexec.New()).Run()
+			if err != nil {
+				return err
+			}
+
+			printDoctorSummary(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().String(c.FlagOutputDirectory, c.Defaults[c.FlagOutputDirectory], "Directory where the diagnostics bundle will be written")
+	cmd.Flags().String(c.FlagCNSURL, c.Defaults[c.FlagCNSURL], "Base URL of the local CNS")
+	cmd.Flags().String(c.FlagNPMURL, c.Defaults[c.FlagNPMURL], "Base URL of the local NPM")
+	cmd.Flags().String(c.FlagCNSConfigPath, c.Defaults[c.FlagCNSConfigPath], "Path of the CNS config file, secrets are redacted in the bundle")
+	cmd.Flags().String(c.FlagStateFilePath, c.Defaults[c.FlagStateFilePath], "Path of the Azure CNI state file")
+	cmd.Flags().String(c.FlagLockDirectory, c.Defaults[c.FlagLockDirectory], "Directory of the Azure CNI lock files")
+	cmd.Flags().Duration(c.FlagLockMaxAge, c.DefaultLockMaxAge, "Age after which a held CNI lock file is reported")
+
+	return cmd
+}
+
+func printDoctorSummary(report *doctor.Report) {
+	failed := 0
+	for _, entry := range report.Manifest.Entries {
+		if entry.Error != "" {
+			failed++
+		}
+	}
+	fmt.Printf("📦 - collected %d of %d items into %s\n", len(report.Manifest.Entries)-failed, len(report.Manifest.Entries), report.BundlePath)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tDETAILS")
+	for _, f := range report.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Check, f.Severity, f.Message)
+	}
+	w.Flush()
+}
@@ -41,6 +41,7 @@ func NewRootCmd(version string) *cobra.Command {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(cni.CNICmd())
 	rootCmd.AddCommand(npm.NPMRootCmd())
+	rootCmd.AddCommand(DoctorCmd())
 	rootCmd.SetVersionTemplate(version)
 	return rootCmd
 }
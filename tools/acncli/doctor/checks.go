@@ -0,0 +1,339 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package doctor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	SeverityOK      Severity = "OK"
+	SeverityWarning Severity = "Warning"
+	SeverityError   Severity = "Error"
+	SeveritySkipped Severity = "Skipped"
+)
+
+// Check names.
+const (
+	CheckNPMJumpPosition  = "npm-jump-position"
+	CheckOrphanedVeths    = "orphaned-veths"
+	CheckStateIPMismatch  = "state-kernel-ip-mismatch"
+	CheckLockFileAge      = "lock-file-age"
+	hostVEthPrefix        = "azv"
+	transparentMode       = "transparent"
+	forwardChainRule      = "-A FORWARD "
+	azureNPMChain         = "AZURE-NPM"
+	kubeServicesChain     = "KUBE-SERVICES"
+	kubeForwardChain      = "KUBE-FORWARD"
+	iptablesFilterSection = "*filter"
+)
+
+// Finding is the result of a single sanity check.
+type Finding struct {
+	Check    string
+	Severity Severity
+	Message  string
+}
+
+// cniState is the subset of the CNI state file the checks need.
+type cniState struct {
+	Network struct {
+		ExternalInterfaces map[string]struct {
+			Networks map[string]struct {
+				Mode      string
+				Endpoints map[string]struct {
+					Id          string
+					HostIfName  string
+					IPAddresses []net.IPNet
+				}
+			}
+		}
+	}
+}
+
+// stateEndpoint is a flattened endpoint from the CNI state file.
+type stateEndpoint struct {
+	id         string
+	mode       string
+	hostIfName string
+	ips        []net.IP
+}
+
+func (d *Doctor) runChecks(outputs map[string][]byte) []Finding {
+	var endpoints []stateEndpoint
+	var stateErr error
+	if b, ok := outputs[stateFileCollector]; ok {
+		endpoints, stateErr = parseStateEndpoints(b)
+	} else {
+		stateErr = fmt.Errorf("%s was not collected", stateFileCollector)
+	}
+
+	findings := []Finding{}
+	findings = append(findings, checkNPMJumpPosition(outputs[iptablesSaveCollector])...)
+	if stateErr != nil {
+		findings = append(findings,
+			skipped(CheckOrphanedVeths, stateErr.Error()),
+			skipped(CheckStateIPMismatch, stateErr.Error()))
+	} else {
+		findings = append(findings, checkOrphanedVeths(endpoints, outputs[ipLinkCollector])...)
+		findings = append(findings, checkStateIPMismatch(endpoints, outputs[ipRouteCollector])...)
+	}
+	findings = append(findings, checkLockFileAge(outputs[lockFilesCollector], d.now(), d.config.LockMaxAge)...)
+	return findings
+}
+
+func skipped(check, reason string) Finding {
+	return Finding{Check: check, Severity: SeveritySkipped, Message: reason}
+}
+
+func passed(check, msg string) Finding {
+	return Finding{Check: check, Severity: SeverityOK, Message: msg}
+}
+
+func parseStateEndpoints(b []byte) ([]stateEndpoint, error) {
+	var state cniState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse CNI state file: %w", err)
+	}
+
+	var endpoints []stateEndpoint
+	for _, extIf := range state.Network.ExternalInterfaces {
+		for _, nw := range extIf.Networks {
+			for _, ep := range nw.Endpoints {
+				sep := stateEndpoint{
+					id:         ep.Id,
+					mode:       nw.Mode,
+					hostIfName: ep.HostIfName,
+				}
+				for _, ipNet := range ep.IPAddresses {
+					sep.ips = append(sep.ips, ipNet.IP)
+				}
+				endpoints = append(endpoints, sep)
+			}
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].id < endpoints[j].id })
+	return endpoints, nil
+}
+
+// checkNPMJumpPosition verifies the FORWARD chain jumps to AZURE-NPM after the
+// kube-proxy jumps, which is where NPM itself positions the rule.
+func checkNPMJumpPosition(iptablesSave []byte) []Finding {
+	if iptablesSave == nil {
+		return []Finding{skipped(CheckNPMJumpPosition, iptablesSaveCollector+" was not collected")}
+	}
+
+	var forwardRules []string
+	inFilter := false
+	scanner := bufio.NewScanner(bytes.NewReader(iptablesSave))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "*"):
+			inFilter = line == iptablesFilterSection
+		case inFilter && strings.HasPrefix(line, forwardChainRule):
+			forwardRules = append(forwardRules, line)
+		}
+	}
+
+	npmPos, kubePos := -1, -1
+	for i, rule := range forwardRules {
+		switch {
+		case npmPos == -1 && jumpsTo(rule, azureNPMChain):
+			npmPos = i
+		case jumpsTo(rule, kubeServicesChain) || jumpsTo(rule, kubeForwardChain):
+			kubePos = i
+		}
+	}
+
+	switch {
+	case npmPos == -1:
+		return []Finding{{Check: CheckNPMJumpPosition, Severity: SeverityWarning, Message: "no jump from FORWARD to AZURE-NPM found, NPM may not be running"}}
+	case kubePos > npmPos:
+		return []Finding{{
+			Check:    CheckNPMJumpPosition,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("jump to AZURE-NPM is FORWARD rule %d, before the kube-proxy jump at rule %d", npmPos+1, kubePos+1),
+		}}
+	default:
+		return []Finding{passed(CheckNPMJumpPosition, fmt.Sprintf("jump to AZURE-NPM is FORWARD rule %d", npmPos+1))}
+	}
+}
+
+// jumpsTo matches the jump target exactly so AZURE-NPM does not match AZURE-NPM-ACCEPT.
+func jumpsTo(rule, chain string) bool {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "-j" && fields[i+1] == chain {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLinkNames returns the interface names from `ip -o link show` output.
+func parseLinkNames(ipLink []byte) map[string]bool {
+	names := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(ipLink))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimSuffix(fields[1], ":")
+		if i := strings.Index(name, "@"); i >= 0 {
+			name = name[:i]
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// checkOrphanedVeths reports host veths with no endpoint in state, and endpoints whose host veth is gone.
+func checkOrphanedVeths(endpoints []stateEndpoint, ipLink []byte) []Finding {
+	if ipLink == nil {
+		return []Finding{skipped(CheckOrphanedVeths, ipLinkCollector+" was not collected")}
+	}
+
+	links := parseLinkNames(ipLink)
+	known := map[string]bool{}
+	findings := []Finding{}
+	for _, ep := range endpoints {
+		if ep.hostIfName == "" {
+			continue
+		}
+		known[ep.hostIfName] = true
+		if !links[ep.hostIfName] {
+			findings = append(findings, Finding{
+				Check:    CheckOrphanedVeths,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("endpoint %s references host interface %s which does not exist", ep.id, ep.hostIfName),
+			})
+		}
+	}
+
+	orphans := []string{}
+	for name := range links {
+		if strings.HasPrefix(name, hostVEthPrefix) && !known[name] {
+			orphans = append(orphans, name)
+		}
+	}
+	sort.Strings(orphans)
+	for _, name := range orphans {
+		findings = append(findings, Finding{
+			Check:    CheckOrphanedVeths,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("host interface %s has no endpoint in the CNI state file", name),
+		})
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, passed(CheckOrphanedVeths, fmt.Sprintf("%d endpoints match their host interfaces", len(known))))
+	}
+	return findings
+}
+
+// parseHostRoutes returns the device of each host route from `ip route show` output.
+func parseHostRoutes(ipRoute []byte) map[string]string {
+	routes := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(ipRoute))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		dst := strings.TrimSuffix(fields[0], "/32")
+		if net.ParseIP(dst) == nil {
+			continue
+		}
+		for i := 1; i < len(fields)-1; i++ {
+			if fields[i] == "dev" {
+				routes[dst] = fields[i+1]
+				break
+			}
+		}
+	}
+	return routes
+}
+
+// checkStateIPMismatch verifies that transparent mode endpoint IPs are routed to the endpoint's host veth.
+func checkStateIPMismatch(endpoints []stateEndpoint, ipRoute []byte) []Finding {
+	if ipRoute == nil {
+		return []Finding{skipped(CheckStateIPMismatch, ipRouteCollector+" was not collected")}
+	}
+
+	routes := parseHostRoutes(ipRoute)
+	findings := []Finding{}
+	checked := 0
+	for _, ep := range endpoints {
+		if ep.mode != transparentMode || ep.hostIfName == "" {
+			continue
+		}
+		for _, ip := range ep.ips {
+			if ip.To4() == nil {
+				continue
+			}
+			checked++
+			dev, found := routes[ip.String()]
+			switch {
+			case !found:
+				findings = append(findings, Finding{
+					Check:    CheckStateIPMismatch,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("endpoint %s has IP %s in state but no host route", ep.id, ip),
+				})
+			case dev != ep.hostIfName:
+				findings = append(findings, Finding{
+					Check:    CheckStateIPMismatch,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("endpoint %s IP %s is routed to %s, state says %s", ep.id, ip, dev, ep.hostIfName),
+				})
+			}
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, passed(CheckStateIPMismatch, fmt.Sprintf("%d endpoint IPs match host routes", checked)))
+	}
+	return findings
+}
+
+// checkLockFileAge reports CNI lock files older than maxAge. Lock files only exist
+// while held, so an old one usually means a CNI invocation died holding the lock.
+func checkLockFileAge(lockFiles []byte, now time.Time, maxAge time.Duration) []Finding {
+	if lockFiles == nil {
+		return []Finding{skipped(CheckLockFileAge, lockFilesCollector+" was not collected")}
+	}
+
+	var locks []lockFile
+	if err := json.Unmarshal(lockFiles, &locks); err != nil {
+		return []Finding{skipped(CheckLockFileAge, err.Error())}
+	}
+
+	findings := []Finding{}
+	for _, lock := range locks {
+		if age := now.Sub(lock.ModTime); age > maxAge {
+			findings = append(findings, Finding{
+				Check:    CheckLockFileAge,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("lock file %s is %s old", lock.Name, age.Round(time.Second)),
+			})
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, passed(CheckLockFileAge, fmt.Sprintf("%d lock files are younger than %s", len(locks), maxAge)))
+	}
+	return findings
+}
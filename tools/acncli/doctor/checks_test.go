@@ -0,0 +1,161 @@
+package doctor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testState = `{
+	"Network": {
+		"ExternalInterfaces": {
+			"eth0": {
+				"Networks": {
+					"azure": {
+						"Mode": "transparent",
+						"Endpoints": {
+							"aaaa-eth0": {"Id": "aaaa-eth0", "HostIfName": "azvaaaa", "IPAddresses": [{"IP": "10.240.0.5", "Mask": "//8AAA=="}]},
+							"bbbb-eth0": {"Id": "bbbb-eth0", "HostIfName": "azvbbbb", "IPAddresses": [{"IP": "10.240.0.6", "Mask": "//8AAA=="}]}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestCheckNPMJumpPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		save     string
+		severity Severity
+	}{
+		{
+			name: "after kube-services",
+			save: `*filter
+-A FORWARD -m comment --comment "kubernetes forwarding rules" -j KUBE-FORWARD
+-A FORWARD -m conntrack --ctstate NEW -j KUBE-SERVICES
+-A FORWARD -m conntrack --ctstate NEW -j AZURE-NPM
+COMMIT`,
+			severity: SeverityOK,
+		},
+		{
+			name: "before kube-services",
+			save: `*filter
+-A FORWARD -m conntrack --ctstate NEW -j AZURE-NPM
+-A FORWARD -m conntrack --ctstate NEW -j KUBE-SERVICES
+COMMIT`,
+			severity: SeverityError,
+		},
+		{
+			name: "missing",
+			save: `*filter
+-A FORWARD -j AZURE-NPM-ACCEPT
+COMMIT
+*nat
+-A FORWARD -j AZURE-NPM
+COMMIT`,
+			severity: SeverityWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			findings := checkNPMJumpPosition([]byte(tt.save))
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.severity, findings[0].Severity, findings[0].Message)
+		})
+	}
+}
+
+func TestCheckOrphanedVeths(t *testing.T) {
+	endpoints, err := parseStateEndpoints([]byte(testState))
+	require.NoError(t, err)
+
+	ipLink := `1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc mq state UP mode DEFAULT group default qlen 1000
+7: azvaaaa@if6: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default
+9: azvcccc@if8: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default`
+
+	findings := checkOrphanedVeths(endpoints, []byte(ipLink))
+	require.Len(t, findings, 2)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "azvbbbb")
+	assert.Equal(t, SeverityWarning, findings[1].Severity)
+	assert.Contains(t, findings[1].Message, "azvcccc")
+}
+
+func TestCheckStateIPMismatch(t *testing.T) {
+	endpoints, err := parseStateEndpoints([]byte(testState))
+	require.NoError(t, err)
+
+	ipRoute := `default via 10.240.0.1 dev eth0 proto dhcp src 10.240.0.4 metric 100
+10.240.0.0/16 dev eth0 proto kernel scope link src 10.240.0.4
+10.240.0.5 dev azvaaaa proto static scope link
+10.240.0.6 dev azvcccc proto static scope link`
+
+	findings := checkStateIPMismatch(endpoints, []byte(ipRoute))
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "10.240.0.6 is routed to azvcccc")
+
+	ipRoute = `10.240.0.5 dev azvaaaa proto static scope link
+10.240.0.6 dev azvbbbb proto static scope link`
+	findings = checkStateIPMismatch(endpoints, []byte(ipRoute))
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+}
+
+func TestCheckLockFileAge(t *testing.T) {
+	now := time.Now()
+	locks, err := json.Marshal([]lockFile{
+		{Name: "azure-vnet.json.lock", ModTime: now.Add(-time.Hour)},
+		{Name: "azure-vnet-ipam.json.lock", ModTime: now.Add(-time.Second)},
+	})
+	require.NoError(t, err)
+
+	findings := checkLockFileAge(locks, now, 5*time.Minute)
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityWarning, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "azure-vnet.json.lock")
+}
+
+func TestChecksSkippedWithoutInput(t *testing.T) {
+	d := New(Config{LockMaxAge: time.Minute}, nil)
+	findings := d.runChecks(map[string][]byte{})
+	require.Len(t, findings, 4)
+	for _, f := range findings {
+		assert.Equal(t, SeveritySkipped, f.Severity, f.Check)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	config := `{
+		"TLSSubjectName": "cns.example.com",
+		"TLSCertificatePath": "/etc/certs/cns.pem",
+		"ManagedSettings": {"PrivateEndpoint": "10.0.0.1", "NodeID": "node"},
+		"TelemetrySettings": {"AppInsightsInstrumentationKey": "abc", "DebugMode": false},
+		"KeyVaultSettings": {"URL": ""},
+		"Empty": {"Password": ""}
+	}`
+
+	b, err := RedactJSON([]byte(config))
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, Redacted, got["TLSSubjectName"])
+	assert.Equal(t, "/etc/certs/cns.pem", got["TLSCertificatePath"])
+	assert.Equal(t, Redacted, got["ManagedSettings"].(map[string]interface{})["PrivateEndpoint"])
+	assert.Equal(t, "node", got["ManagedSettings"].(map[string]interface{})["NodeID"])
+	assert.Equal(t, Redacted, got["TelemetrySettings"].(map[string]interface{})["AppInsightsInstrumentationKey"])
+	assert.Equal(t, Redacted, got["KeyVaultSettings"])
+	assert.Equal(t, "", got["Empty"].(map[string]interface{})["Password"])
+
+	_, err = RedactJSON([]byte("not json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,217 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-container-networking/cns"
+	cnsclient "github.com/Azure/azure-container-networking/cns/client"
+	npmclient "github.com/Azure/azure-container-networking/npm/http/client"
+)
+
+const (
+	collectorTimeout = 10 * time.Second
+
+	iptablesSaveCollector = "iptables-save"
+	ipLinkCollector       = "ip-link"
+	ipRouteCollector      = "ip-route"
+	stateFileCollector    = "cni-state"
+	lockFilesCollector    = "lock-files"
+)
+
+// collector gathers one artifact for the bundle.
+type collector struct {
+	name   string
+	file   string
+	source string
+	fn     func() ([]byte, error)
+}
+
+// lockFile is the serialized form of a CNI lock file in the bundle.
+type lockFile struct {
+	Name    string
+	ModTime time.Time
+	Size    int64
+}
+
+func (d *Doctor) collectors() []collector {
+	collectors := []collector{
+		d.commandCollector(iptablesSaveCollector, "iptables/iptables-save.txt", "iptables-save"),
+		d.commandCollector("ip6tables-save", "iptables/ip6tables-save.txt", "ip6tables-save"),
+		d.commandCollector("ipset", "ipset/ipset-list.txt", "ipset", "list"),
+		d.commandCollector("ebtables-filter", "ebtables/filter.txt", "ebtables", "-t", "filter", "-L", "--Lmac2"),
+		d.commandCollector("ebtables-nat", "ebtables/nat.txt", "ebtables", "-t", "nat", "-L", "--Lmac2"),
+		d.commandCollector("ebtables-broute", "ebtables/broute.txt", "ebtables", "-t", "broute", "-L", "--Lmac2"),
+		d.commandCollector(ipLinkCollector, "ip/link.txt", "ip", "-o", "link", "show"),
+		d.commandCollector("ip-addr", "ip/addr.txt", "ip", "addr", "show"),
+		d.commandCollector(ipRouteCollector, "ip/route.txt", "ip", "route", "show", "table", "all"),
+		d.commandCollector("ip-rule", "ip/rule.txt", "ip", "rule", "show"),
+		d.commandCollector("ip-neigh", "ip/neigh.txt", "ip", "neigh", "show"),
+		{
+			name:   stateFileCollector,
+			file:   "cni/" + filepath.Base(d.config.StateFilePath),
+			source: d.config.StateFilePath,
+			fn:     func() ([]byte, error) { return ioutil.ReadFile(d.config.StateFilePath) },
+		},
+		{
+			name:   lockFilesCollector,
+			file:   "cni/lock-files.json",
+			source: d.config.LockDirectory,
+			fn:     d.collectLockFiles,
+		},
+		{
+			name:   "cns-config",
+			file:   "cns/" + filepath.Base(d.config.CNSConfigPath),
+			source: d.config.CNSConfigPath,
+			fn:     d.collectCNSConfig,
+		},
+		{
+			name:   "cns-ipaddresses",
+			file:   "cns/ipaddresses.json",
+			source: d.config.CNSURL + cns.PathDebugIPAddresses,
+			fn:     d.collectCNSIPAddresses,
+		},
+		{
+			name:   "cns-podcontext",
+			file:   "cns/podcontext.json",
+			source: d.config.CNSURL + cns.PathDebugPodContext,
+			fn:     d.collectCNSPodContext,
+		},
+		{
+			name:   "cns-restdata",
+			file:   "cns/restdata.json",
+			source: d.config.CNSURL + cns.PathDebugRestData,
+			fn:     d.collectCNSRestData,
+		},
+		{
+			name:   "npm-cache",
+			file:   "npm/cache.json",
+			source: d.config.NPMURL,
+			fn:     d.collectNPMCache,
+		},
+	}
+
+	return append(collectors, d.logCollectors()...)
+}
+
+// commandCollector runs a command and captures its combined output.
+func (d *Doctor) commandCollector(name, file, cmd string, args ...string) collector {
+	return collector{
+		name:   name,
+		file:   file,
+		source: strings.Join(append([]string{cmd}, args...), " "),
+		fn: func() ([]byte, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), collectorTimeout)
+			defer cancel()
+			return d.exec.CommandContext(ctx, cmd, args...).CombinedOutput()
+		},
+	}
+}
+
+// logCollectors returns a collector for each log file matching the configured glob.
+func (d *Doctor) logCollectors() []collector {
+	matches, err := filepath.Glob(d.config.LogGlob)
+	if err != nil {
+		return []collector{{
+			name:   "logs",
+			source: d.config.LogGlob,
+			fn:     func() ([]byte, error) { return nil, err },
+		}}
+	}
+
+	collectors := make([]collector, 0, len(matches))
+	for _, match := range matches {
+		path := match
+		collectors = append(collectors, collector{
+			name:   "log-" + filepath.Base(path),
+			file:   "logs/" + filepath.Base(path),
+			source: path,
+			fn:     func() ([]byte, error) { return ioutil.ReadFile(path) },
+		})
+	}
+	return collectors
+}
+
+func (d *Doctor) collectLockFiles() ([]byte, error) {
+	infos, err := ioutil.ReadDir(d.config.LockDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := []lockFile{}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".lock") {
+			continue
+		}
+		locks = append(locks, lockFile{
+			Name:    info.Name(),
+			ModTime: info.ModTime().UTC(),
+			Size:    info.Size(),
+		})
+	}
+	return json.MarshalIndent(locks, "", "\t")
+}
+
+func (d *Doctor) collectCNSConfig() ([]byte, error) {
+	b, err := ioutil.ReadFile(d.config.CNSConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return RedactJSON(b)
+}
+
+func (d *Doctor) cnsClient() (*cnsclient.Client, error) {
+	return cnsclient.New(d.config.CNSURL, collectorTimeout)
+}
+
+func (d *Doctor) collectCNSIPAddresses() ([]byte, error) {
+	c, err := d.cnsClient()
+	if err != nil {
+		return nil, err
+	}
+	ips, err := c.GetIPAddressesMatchingStates(context.Background(), cns.Available, cns.Allocated, cns.PendingRelease, cns.PendingProgramming)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(ips, "", "\t")
+}
+
+func (d *Doctor) collectCNSPodContext() ([]byte, error) {
+	c, err := d.cnsClient()
+	if err != nil {
+		return nil, err
+	}
+	podContext, err := c.GetPodOrchestratorContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(podContext, "", "\t")
+}
+
+func (d *Doctor) collectCNSRestData() ([]byte, error) {
+	c, err := d.cnsClient()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.GetHTTPServiceData(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(data, "", "\t")
+}
+
+func (d *Doctor) collectNPMCache() ([]byte, error) {
+	mgr, err := npmclient.NewNPMHttpClient(d.config.NPMURL).GetNpmMgr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPM cache: %w", err)
+	}
+	return json.MarshalIndent(mgr, "", "\t")
+}
@@ -0,0 +1,225 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	bundlePrefix     = "acn-doctor-"
+	bundleExtension  = ".tar.gz"
+	manifestFileName = "manifest.json"
+	findingsFileName = "findings.json"
+	timestampFormat  = "20060102-150405"
+)
+
+// Config holds the locations the doctor collects from and writes to.
+type Config struct {
+	OutputDirectory string
+	CNSURL          string
+	NPMURL          string
+	CNSConfigPath   string
+	StateFilePath   string
+	LockDirectory   string
+	LogGlob         string
+	LockMaxAge      time.Duration
+}
+
+// Manifest describes the contents of a diagnostics bundle.
+type Manifest struct {
+	CreatedAt time.Time
+	Hostname  string
+	Entries   []ManifestEntry
+}
+
+// ManifestEntry records the outcome of a single collector.
+type ManifestEntry struct {
+	Name     string
+	File     string `json:",omitempty"`
+	Source   string
+	Size     int64
+	Duration time.Duration
+	Error    string `json:",omitempty"`
+}
+
+// Report is the result of a doctor run.
+type Report struct {
+	BundlePath string
+	Manifest   Manifest
+	Findings   []Finding
+}
+
+// Doctor gathers node-level network diagnostics into a bundle and runs sanity checks against them.
+type Doctor struct {
+	config Config
+	exec   utilexec.Interface
+	now    func() time.Time
+}
+
+// New creates a Doctor for the given config.
+func New(config Config, exec utilexec.Interface) *Doctor {
+	return &Doctor{
+		config: config,
+		exec:   exec,
+		now:    time.Now,
+	}
+}
+
+// Run collects everything into a timestamped tarball, runs the sanity checks, and returns the report.
+func (d *Doctor) Run() (*Report, error) {
+	start := d.now()
+	name := bundlePrefix + start.UTC().Format(timestampFormat)
+
+	workDir, err := ioutil.TempDir("", name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create working directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	hostname, _ := os.Hostname()
+	report := &Report{
+		Manifest: Manifest{
+			CreatedAt: start.UTC(),
+			Hostname:  hostname,
+		},
+	}
+
+	outputs := map[string][]byte{}
+	for _, c := range d.collectors() {
+		entry, data := d.collect(workDir, c)
+		report.Manifest.Entries = append(report.Manifest.Entries, entry)
+		if entry.Error == "" {
+			outputs[c.name] = data
+		}
+	}
+
+	report.Findings = d.runChecks(outputs)
+
+	if err := writeJSON(filepath.Join(workDir, findingsFileName), report.Findings); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(workDir, manifestFileName), report.Manifest); err != nil {
+		return nil, err
+	}
+
+	report.BundlePath = filepath.Join(d.config.OutputDirectory, name+bundleExtension)
+	if err := writeTarball(report.BundlePath, workDir, name); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// collect runs a single collector and writes its output into the working directory.
+func (d *Doctor) collect(workDir string, c collector) (ManifestEntry, []byte) {
+	entry := ManifestEntry{
+		Name:   c.name,
+		File:   c.file,
+		Source: c.source,
+	}
+
+	start := d.now()
+	data, err := c.fn()
+	entry.Duration = d.now().Sub(start)
+	if err != nil {
+		entry.Error = err.Error()
+		// keep whatever partial output we got, it is often the most useful part
+		if len(data) == 0 {
+			entry.File = ""
+			return entry, nil
+		}
+	}
+
+	path := filepath.Join(workDir, c.file)
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0o755)); err != nil {
+		entry.Error = err.Error()
+		return entry, nil
+	}
+	if err := ioutil.WriteFile(path, data, os.FileMode(0o644)); err != nil {
+		entry.Error = err.Error()
+		return entry, nil
+	}
+	entry.Size = int64(len(data))
+
+	return entry, data
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", filepath.Base(path))
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, b, os.FileMode(0o644)), "failed to write %s", filepath.Base(path))
+}
+
+// writeTarball archives everything under srcDir into a gzipped tarball rooted at prefix.
+func writeTarball(dst, srcDir, prefix string) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0o600))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create bundle %s", dst)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	if err := archiveDir(tw, srcDir, prefix); err != nil {
+		return err
+	}
+
+	// the writers flush on close, so a failure here leaves a truncated bundle behind
+	if err := tw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write bundle %s", dst)
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write bundle %s", dst)
+	}
+	return errors.Wrapf(f.Close(), "failed to write bundle %s", dst)
+}
+
+// archiveDir writes everything under srcDir into the tar writer, rooted at prefix.
+func archiveDir(tw *tar.Writer, srcDir, prefix string) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(tw, src); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", rel, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTarball(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "iptables"), os.FileMode(0o755)))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "iptables", "filter.txt"), []byte("-P INPUT ACCEPT\n"), os.FileMode(0o644)))
+
+	dst := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	require.NoError(t, writeTarball(dst, srcDir, "acn-doctor-test"))
+
+	f, err := os.Open(dst)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	contents := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		contents[hdr.Name] = string(b)
+	}
+	require.Equal(t, map[string]string{
+		"acn-doctor-test":                     "",
+		"acn-doctor-test/iptables":            "",
+		"acn-doctor-test/iptables/filter.txt": "-P INPUT ACCEPT\n",
+	}, contents)
+}
+
+func TestWriteTarballFlushError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+
+	srcDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "state.json"), []byte("{}"), os.FileMode(0o644)))
+
+	// the small bundle is buffered until the writers are closed, where the write fails
+	require.Error(t, writeTarball("/dev/full", srcDir, "acn-doctor-test"))
+}
@@ -0,0 +1,76 @@
+//go:build !ignore_uncovered
+// +build !ignore_uncovered
+
+package doctor
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the value of any sensitive key in a collected config.
+const Redacted = "<redacted>"
+
+// sensitiveKeyFragments are matched case-insensitively against JSON object keys.
+var sensitiveKeyFragments = []string{
+	"password",
+	"secret",
+	"token",
+	"key",
+	"privateendpoint",
+	"subjectname",
+}
+
+// RedactJSON replaces the values of sensitive keys anywhere in a JSON document.
+// Empty values are left as-is so the bundle still shows which settings were unset.
+func RedactJSON(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config for redaction")
+	}
+	return json.MarshalIndent(redact(doc), "", "\t")
+}
+
+func redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if isSensitiveKey(k) && !isEmpty(child) {
+				t[k] = Redacted
+				continue
+			}
+			t[k] = redact(child)
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = redact(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeyFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmpty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+// Package tracing records OpenTelemetry spans around the stages of the pod network setup path, so that the
+// time spent in CNI, CNS and the host network stack can be told apart. Tracing is a no-op unless an OTLP
+// collector is configured.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Azure/azure-container-networking"
+
+// Config configures the export of the traces.
+type Config struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector. Tracing is disabled when it is empty.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Insecure disables TLS to the collector.
+	Insecure bool `json:"insecure,omitempty"`
+	// SampleRatio is the fraction of the traces which are sampled, all of them when it is 0.
+	SampleRatio float64 `json:"sampleRatio,omitempty"`
+}
+
+// Init sets up the global tracer provider from the config and returns a func which flushes the
+// spans and stops the export. The W3C trace context propagator is always installed, so that the
+// traces of callers are continued even when tracing is disabled in this process.
+func Init(ctx context.Context, serviceName, version string, cfg *Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if cfg == nil || cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create OTLP exporter")
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start starts a span which is a child of the span in the context, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError records the error on the span and marks the span as failed.
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records the error, if any, on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		RecordError(span, err)
+	}
+	span.End()
+}
+
+// Trace runs f in a span.
+func Trace(ctx context.Context, name string, f func() error) error {
+	_, span := Start(ctx, name)
+	err := f()
+	End(span, err)
+	return err
+}
+
+// Inject sets the trace context of the span in the context in the HTTP headers.
+func Inject(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Extract returns a copy of the context carrying the trace context from the HTTP headers.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
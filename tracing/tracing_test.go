@@ -0,0 +1,61 @@
+// Copyright 2017 Microsoft. All rights reserved.
+// MIT License
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	shutdown, err := Init(context.Background(), "test", "v0", nil)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func TestPropagationOverHTTPHeaders(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, parent := Start(context.Background(), "cni.Add")
+	h := http.Header{}
+	Inject(ctx, h)
+	assert.NotEmpty(t, h.Get("traceparent"))
+
+	_, child := Start(Extract(context.Background(), h), "cns.requestIPConfigHandler")
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestTraceRecordsError(t *testing.T) {
+	recorder := newRecorder(t)
+
+	errFailed := errors.New("failed")
+	err := Trace(context.Background(), "network.AddEndpoints", func() error { return errFailed })
+	assert.Equal(t, errFailed, err)
+	require.NoError(t, Trace(context.Background(), "network.AddEndpointRules", func() error { return nil }))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "failed", spans[0].Status().Description)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}